@@ -308,12 +308,42 @@ func HexKey(pkg, version string) string {
 	return fmt.Sprintf("hex/packages/%s@%s", pkg, version)
 }
 
+// NpmKey returns the cache key for an npm package tarball at version.
+// Format: npm/packages/{package}@{version}
+func NpmKey(pkg, version string) string {
+	return fmt.Sprintf("npm/packages/%s@%s", pkg, version)
+}
+
+// MavenKey returns the cache key for a jar of a Maven artifact, such as its
+// javadoc or sources jar.
+// Format: maven/artifacts/{group}/{artifact}@{version}/{classifier}
+func MavenKey(group, artifact, version, classifier string) string {
+	return fmt.Sprintf("maven/artifacts/%s/%s@%s/%s", group, artifact, version, classifier)
+}
+
+// PythonDistKey returns the cache key for a wheel or sdist downloaded from
+// PyPI, whose file name carries the project and version.
+// Format: python/dists/{filename}
+func PythonDistKey(filename string) string {
+	return fmt.Sprintf("python/dists/%s", filename)
+}
+
 // RustCrateKey returns the cache key for a Rust crate at version.
 // Format: rust/crates/{crate}@{version}
 func RustCrateKey(crate, version string) string {
 	return fmt.Sprintf("rust/crates/%s@%s", crate, version)
 }
 
+// RustdocJSONKey returns the cache key for a Rust crate's rustdoc JSON.
+// Format: rust/json/{crate}@{version}, or rust/json/{crate}@{version}/{target}
+// for a non-default target.
+func RustdocJSONKey(crate, version, target string) string {
+	if target != "" {
+		return fmt.Sprintf("rust/json/%s@%s/%s", crate, version, target)
+	}
+	return fmt.Sprintf("rust/json/%s@%s", crate, version)
+}
+
 // GithubRepoKey returns the cache key for a GitHub repository.
 // Format: github/repos/{owner}/{repo}@{branch}
 func GithubRepoKey(owner, repo, branch string) string {
@@ -322,3 +352,13 @@ func GithubRepoKey(owner, repo, branch string) string {
 	}
 	return fmt.Sprintf("github/repos/%s/%s@%s", owner, repo, branch)
 }
+
+// GitRepoKey returns the cache key for a checkout of a git repository, named
+// by host and path.
+// Format: git/repos/{host}/{path}@{ref}
+func GitRepoKey(repo, ref string) string {
+	if ref == "" {
+		return fmt.Sprintf("git/repos/%s", repo)
+	}
+	return fmt.Sprintf("git/repos/%s@%s", repo, ref)
+}
@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -117,14 +120,38 @@ func GetRepoCommit(repoPath string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// ShallowClone clones a repository to a specific commit SHA.
-func ShallowClone(url, commit, dest string) error {
-	cmd := exec.Command("git", "clone", "--depth", "1", url, dest)
-	if err := cmd.Run(); err != nil {
+// ShallowClone checks out a specific commit of a repository into dest. It
+// fetches only that commit, which most servers allow for full SHAs, and
+// falls back to a full clone for servers that refuse or abbreviated SHAs.
+func ShallowClone(ctx context.Context, url, commit, dest string) error {
+	if err := fetchCommit(ctx, url, commit, dest); err == nil {
+		return nil
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+
+	if err := runGit(ctx, "", "clone", "--quiet", "--", url, dest); err != nil {
+		return err
+	}
+	return runGit(ctx, dest, "checkout", "--quiet", commit)
+}
+
+func fetchCommit(ctx context.Context, url, commit, dest string) error {
+	if err := runGit(ctx, "", "init", "--quiet", "--", dest); err != nil {
+		return err
+	}
+	if err := runGit(ctx, dest, "fetch", "--quiet", "--depth", "1", "--", url, commit); err != nil {
 		return err
 	}
+	return runGit(ctx, dest, "checkout", "--quiet", "FETCH_HEAD")
+}
 
-	cmd = exec.Command("git", "checkout", commit)
-	cmd.Dir = dest
-	return cmd.Run()
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
 }
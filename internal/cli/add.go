@@ -11,11 +11,18 @@ import (
 
 	"github.com/stormlightlabs/documango/internal/cache"
 	"github.com/stormlightlabs/documango/internal/db"
-	githubingest "github.com/stormlightlabs/documango/internal/ingest/github"
 	"github.com/stormlightlabs/documango/internal/ingest/atproto"
+	cheaderingest "github.com/stormlightlabs/documango/internal/ingest/cheader"
+	gitingest "github.com/stormlightlabs/documango/internal/ingest/git"
+	githubingest "github.com/stormlightlabs/documango/internal/ingest/github"
 	golangingest "github.com/stormlightlabs/documango/internal/ingest/golang"
 	"github.com/stormlightlabs/documango/internal/ingest/hexpm"
+	localingest "github.com/stormlightlabs/documango/internal/ingest/local"
+	maveningest "github.com/stormlightlabs/documango/internal/ingest/maven"
+	npmingest "github.com/stormlightlabs/documango/internal/ingest/npm"
+	pythoningest "github.com/stormlightlabs/documango/internal/ingest/python"
 	rustingest "github.com/stormlightlabs/documango/internal/ingest/rust"
+	zigingest "github.com/stormlightlabs/documango/internal/ingest/zig"
 )
 
 var (
@@ -23,7 +30,25 @@ var (
 	addStart    string
 	addMax      int
 	addStdlib   bool
+	addGoroot   string
+	addDir      string
+	addGoSum    string
+	addExported bool
+	addGOOS     string
+	addGOARCH   string
+	addPlatform string
 	addLexicons bool
+	addRustdoc  string
+	addTarget   string
+	addFeatures string
+	addExpand   int
+	addLexDir   string
+	addInclude  []string
+	addExclude  []string
+	addRef      string
+	addSubdir   string
+	addName     string
+	addRepo     string
 )
 
 func newAddCommand() *cobra.Command {
@@ -34,26 +59,88 @@ func newAddCommand() *cobra.Command {
 
 Supported source types:
   go       - Go module or standard library
-  atproto  - AT Protocol specifications and documentation
+  atproto  - AT Protocol specifications and documentation, or lexicons from a
+             directory, git repository or by NSID
   hex      - Elixir or Gleam package from Hex.pm
-  rust     - Rust crate from crates.io
-  github   - GitHub repository markdown documentation`,
+  rust     - Rust crate from crates.io, or local cargo doc output
+  python   - Python package from PyPI, read from its wheel or sdist
+  npm      - npm package, read from its TypeScript declarations (or its
+             @types package)
+  maven    - Java or Kotlin library from a Maven repository, read from its
+             javadoc jar (or its sources jar)
+  c        - C or C++ headers and their Doxygen comments, from a local
+             directory or git repository
+  zig      - Zig package doc comments, from a local directory or git
+             repository
+  github   - GitHub repository markdown documentation
+  git      - Markdown, MDX, reStructuredText, AsciiDoc and Org documentation
+             of any git repository (GitLab, Codeberg, Gitea, sourcehut,
+             self-hosted)
+  local    - Markdown, MDX, reStructuredText, AsciiDoc and Org files in a
+             local directory, kept up to date by "documango watch"`,
 		Example: `  documango add go golang.org/x/net
   documango add go --stdlib
+  documango add go --stdlib --goroot /usr/local/go
+  documango add go --dir ./
+  documango add go github.com/spf13/cobra --exported-only
+  documango add go golang.org/x/sys --goos windows --platforms linux/amd64,darwin/arm64
   documango add atproto
+  documango add atproto --expand-depth 2
+  documango add atproto --lexicons ./lexicons
+  documango add atproto --lexicons https://github.com/example/lexicons
+  documango add atproto com.example.feed.post,com.example.feed.like
   documango add hex gleam_stdlib
+  documango add python requests
+  documango add python 'pydantic==2.8.2'
+  documango add npm @atproto/api
+  documango add npm zod@3.23.8
+  documango add maven com.google.code.gson:gson
+  documango add maven org.jetbrains.kotlinx:kotlinx-coroutines-core:1.8.1
+  documango add maven com.example:internal-lib --repository ~/.m2/repository
+  documango add c https://github.com/libgit2/libgit2 --ref v1.8.4 --subdir include
+  documango add c ./vendor/raylib/src --name raylib
+  documango add zig https://github.com/karlseguin/http.zig --name httpz
+  documango add zig ~/src/ziglings --exclude 'exercises/**'
   documango add rust pulldown-cmark
-  documango add github folke/snacks.nvim`,
+  documango add rust serde --rustdoc-format html
+  documango add rust tokio --target x86_64-pc-windows-msvc --features fs,net
+  documango add rust --dir ./
+  documango add github folke/snacks.nvim
+  documango add github cli/cli --include 'docs/**' --include README.md
+  GITHUB_TOKEN=... documango add github kubernetes/website --exclude '**/i18n/**'
+  documango add git https://gitlab.com/gitlab-org/gitlab-runner.git --subdir docs/
+  documango add git https://codeberg.org/forgejo/docs --ref v9.0
+  documango add git git@gitea.example.com:team/handbook.git
+  documango add local ~/work/handbook --name team-handbook
+  documango add local ./docs --include 'runbooks/**'`,
 		Args:              cobra.MinimumNArgs(1),
 		RunE:              runAdd,
 		ValidArgsFunction: addSourceCompletion,
 	}
 
-	cmd.Flags().StringVar(&addVersion, "version", "", "Version for Go modules (module mode) or Go toolchain tag (stdlib mode)")
+	cmd.Flags().StringVar(&addVersion, "version", "", "Version for Go modules (module mode), Go toolchain tag (stdlib mode), or Hex, PyPI, npm, Maven and crates.io packages")
 	cmd.Flags().StringVarP(&addStart, "start", "s", "", "Start at a specific stdlib package path (stdlib mode only)")
 	cmd.Flags().IntVarP(&addMax, "max", "m", 0, "Limit number of stdlib packages ingested (stdlib mode only)")
 	cmd.Flags().BoolVar(&addStdlib, "stdlib", false, "Use stdlib mode (no module argument)")
+	cmd.Flags().StringVar(&addGoroot, "goroot", "", "Ingest stdlib from a local GOROOT (stdlib mode only, defaults to go env GOROOT unless --version is set)")
+	cmd.Flags().StringVar(&addDir, "dir", "", "Ingest a local Go module or go.work workspace (go mode), or the cargo doc output of a local crate or Cargo workspace (rust mode)")
+	cmd.Flags().StringVar(&addGoSum, "gosum", "", "Verify downloaded Go modules against this go.sum before consulting GOSUMDB (go mode only)")
+	cmd.Flags().BoolVar(&addExported, "exported-only", false, "Skip unexported Go symbols (go mode only)")
+	cmd.Flags().StringVar(&addGOOS, "goos", "", "Target GOOS for Go build constraints (go mode only, default linux)")
+	cmd.Flags().StringVar(&addGOARCH, "goarch", "", "Target GOARCH for Go build constraints (go mode only, default amd64)")
+	cmd.Flags().StringVar(&addPlatform, "platforms", "", "Comma-separated goos/goarch pairs used to annotate platform-specific Go symbols (go mode only)")
 	cmd.Flags().BoolVar(&addLexicons, "lexicons-only", false, "Only ingest lexicons (atproto mode only)")
+	cmd.Flags().StringVar(&addLexDir, "lexicons", "", "Directory or git URL of lexicon JSON files to ingest instead of the official repositories (atproto mode only)")
+	cmd.Flags().IntVar(&addExpand, "expand-depth", 0, "Levels of referenced lexicon objects to expand inline (atproto mode only)")
+	cmd.Flags().StringVar(&addRustdoc, "rustdoc-format", "", "Rustdoc build to ingest: json or html (rust mode only, default json with html fallback)")
+	cmd.Flags().StringVar(&addTarget, "target", "", "Target triple to ingest documentation for (rust mode only, default docs.rs default target)")
+	cmd.Flags().StringVar(&addFeatures, "features", "", "Comma-separated cargo features; items gated on other features are skipped (rust mode only)")
+	cmd.Flags().StringSliceVar(&addInclude, "include", nil, "Only ingest documentation files matching these globs (github, git, local, c and zig modes only)")
+	cmd.Flags().StringSliceVar(&addExclude, "exclude", nil, "Skip documentation files matching these globs; replaces the defaults of each mode, --exclude= disables them (github, git, local, c and zig modes only)")
+	cmd.Flags().StringVar(&addRef, "ref", "", "Branch, tag or commit to ingest (git, c and zig modes only, default the remote's default branch)")
+	cmd.Flags().StringVar(&addSubdir, "subdir", "", "Only ingest this directory of the repository (git, c and zig modes only)")
+	cmd.Flags().StringVar(&addRepo, "repository", "", "Maven repository URL or local directory laid out like one (maven mode only, default Maven Central)")
+	cmd.Flags().StringVar(&addName, "name", "", "Name of the source, stored as local/<name>, c/<name> or zig/<name> (local, c and zig modes only, default the directory or repository name)")
 
 	return cmd
 }
@@ -65,7 +152,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		source = args[1]
 	}
 
-	if sourceType != "atproto" && source == "" {
+	if sourceType != "atproto" && sourceType != "go" && !(sourceType == "rust" && addDir != "") && source == "" {
 		return errors.New("add requires a source identifier for " + sourceType)
 	}
 
@@ -105,13 +192,27 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	case "go":
 		return addGoSource(ctx, cmd, store, source, c)
 	case "atproto":
-		return addAtprotoSource(ctx, cmd, store, c)
+		return addAtprotoSource(ctx, cmd, store, source, c)
 	case "hex":
 		return addHexSource(ctx, cmd, store, source, c)
 	case "rust":
 		return addRustSource(ctx, cmd, store, source, c)
+	case "python":
+		return addPythonSource(ctx, cmd, store, source, c)
+	case "npm":
+		return addNpmSource(ctx, cmd, store, source, c)
+	case "maven":
+		return addMavenSource(ctx, cmd, store, source, c)
+	case "c":
+		return addCSource(ctx, cmd, store, source, c)
+	case "zig":
+		return addZigSource(ctx, cmd, store, source, c)
 	case "github":
 		return addGithubSource(ctx, cmd, store, source, c)
+	case "git":
+		return addGitSource(ctx, cmd, store, source, c)
+	case "local":
+		return addLocalSource(ctx, cmd, store, source)
 	default:
 		return fmt.Errorf("unknown source type: %s", sourceType)
 	}
@@ -137,17 +238,160 @@ func addHexSource(ctx context.Context, _ *cobra.Command, store *db.Store, source
 	return nil
 }
 
+func addPythonSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	name, version, err := pythoningest.SplitSpec(source)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return errors.New("python package name is required")
+	}
+	if version == "" {
+		version = addVersion
+	} else if addVersion != "" && addVersion != version {
+		return fmt.Errorf("conflicting versions %s and --version %s", version, addVersion)
+	}
+
+	if err := pythoningest.IngestPackage(ctx, pythoningest.Options{
+		Package: name,
+		Version: version,
+		DB:      store,
+		Cache:   c,
+	}); err != nil {
+		return err
+	}
+
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Ingested python package %s", p.FormatSymbol(source)))
+	}
+	return nil
+}
+
+func addNpmSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	name, version := npmingest.SplitSpec(source)
+	if name == "" {
+		return errors.New("npm package name is required")
+	}
+	if version == "" {
+		version = addVersion
+	} else if addVersion != "" && addVersion != version {
+		return fmt.Errorf("conflicting versions %s and --version %s", version, addVersion)
+	}
+
+	if err := npmingest.IngestPackage(ctx, npmingest.Options{
+		Package: name,
+		Version: version,
+		DB:      store,
+		Cache:   c,
+	}); err != nil {
+		return err
+	}
+
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Ingested npm package %s", p.FormatSymbol(source)))
+	}
+	return nil
+}
+
+func addMavenSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	group, artifact, version, err := maveningest.ParseCoordinate(source)
+	if err != nil {
+		return err
+	}
+	if version == "" {
+		version = addVersion
+	} else if addVersion != "" && addVersion != version {
+		return fmt.Errorf("conflicting versions %s and --version %s", version, addVersion)
+	}
+
+	if err := maveningest.IngestArtifact(ctx, maveningest.Options{
+		Group:      group,
+		Artifact:   artifact,
+		Version:    version,
+		Repository: addRepo,
+		DB:         store,
+		Cache:      c,
+	}); err != nil {
+		return err
+	}
+
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Ingested maven artifact %s", p.FormatSymbol(source)))
+	}
+	return nil
+}
+
+func addCSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	if err := cheaderingest.IngestHeaders(ctx, cheaderingest.Options{
+		Source:  source,
+		Ref:     addRef,
+		Subdir:  addSubdir,
+		Name:    addName,
+		Include: addInclude,
+		Exclude: addExclude,
+		DB:      store,
+		Cache:   c,
+	}); err != nil {
+		return err
+	}
+
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Ingested C headers from %s", p.FormatSymbol(source)))
+	}
+	return nil
+}
+
+func addZigSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	if err := zigingest.IngestSource(ctx, zigingest.Options{
+		Source:  source,
+		Ref:     addRef,
+		Subdir:  addSubdir,
+		Name:    addName,
+		Include: addInclude,
+		Exclude: addExclude,
+		DB:      store,
+		Cache:   c,
+	}); err != nil {
+		return err
+	}
+
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Ingested zig sources from %s", p.FormatSymbol(source)))
+	}
+	return nil
+}
+
 func addGoSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	platforms, err := golangingest.ParsePlatforms(addPlatform)
+	if err != nil {
+		return err
+	}
+
+	if addGoroot != "" && !addStdlib {
+		return errors.New("--goroot requires --stdlib")
+	}
 	if addStdlib {
-		if source != "" {
-			return errors.New("module argument not allowed with --stdlib")
+		if source != "" || addDir != "" {
+			return errors.New("module argument and --dir are not allowed with --stdlib")
+		}
+		if addGoroot != "" && addVersion != "" {
+			return errors.New("--goroot and --version are not allowed together")
+		}
+		goroot := addGoroot
+		if goroot == "" && addVersion == "" {
+			goroot = golangingest.LocalGOROOT(ctx)
 		}
 		opts := golangingest.StdlibOptions{
-			DB:          store,
-			Version:     addVersion,
-			Start:       addStart,
-			MaxPackages: addMax,
-			Cache:       c,
+			DB:           store,
+			Version:      addVersion,
+			Start:        addStart,
+			MaxPackages:  addMax,
+			Cache:        c,
+			GOROOT:       goroot,
+			ExportedOnly: addExported,
+			GOOS:         addGOOS,
+			GOARCH:       addGOARCH,
+			Platforms:    platforms,
 		}
 		if err := golangingest.IngestStdlib(ctx, opts); err != nil {
 			return err
@@ -158,15 +402,42 @@ func addGoSource(ctx context.Context, _ *cobra.Command, store *db.Store, source
 		return nil
 	}
 
+	if addDir != "" {
+		if addVersion != "" || addGoSum != "" {
+			return errors.New("--version and --gosum are not allowed with --dir")
+		}
+		if err := golangingest.IngestModule(ctx, golangingest.Options{
+			Module:       source,
+			DB:           store,
+			Cache:        c,
+			Dir:          addDir,
+			ExportedOnly: addExported,
+			GOOS:         addGOOS,
+			GOARCH:       addGOARCH,
+			Platforms:    platforms,
+		}); err != nil {
+			return err
+		}
+		if !quiet {
+			p.PrintSuccess(fmt.Sprintf("Ingested Go modules from %s", p.FormatPath(addDir)))
+		}
+		return nil
+	}
+
 	if source == "" {
-		return errors.New("module argument is required unless --stdlib is set")
+		return errors.New("module argument is required unless --stdlib or --dir is set")
 	}
 
 	if err := golangingest.IngestModule(ctx, golangingest.Options{
-		Module:  source,
-		Version: addVersion,
-		DB:      store,
-		Cache:   c,
+		Module:       source,
+		Version:      addVersion,
+		DB:           store,
+		Cache:        c,
+		GoSum:        addGoSum,
+		ExportedOnly: addExported,
+		GOOS:         addGOOS,
+		GOARCH:       addGOARCH,
+		Platforms:    platforms,
 	}); err != nil {
 		return err
 	}
@@ -177,37 +448,83 @@ func addGoSource(ctx context.Context, _ *cobra.Command, store *db.Store, source
 	return nil
 }
 
-func addAtprotoSource(ctx context.Context, _ *cobra.Command, store *db.Store, c *cache.FilesystemCache) error {
+func addAtprotoSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	var nsids []string
+	for _, nsid := range strings.Split(source, ",") {
+		if nsid = strings.TrimSpace(nsid); nsid != "" {
+			nsids = append(nsids, nsid)
+		}
+	}
+
 	if err := atproto.IngestAtproto(ctx, atproto.Options{
-		DB:    store,
-		Cache: c,
+		DB:           store,
+		Cache:        c,
+		ExpandDepth:  addExpand,
+		LexiconsOnly: addLexicons,
+		Lexicons:     addLexDir,
+		NSIDs:        nsids,
 	}); err != nil {
 		return err
 	}
 
 	if !quiet {
-		p.PrintSuccess("Ingested AT Protocol documentation")
+		switch {
+		case addLexDir != "":
+			p.PrintSuccess(fmt.Sprintf("Ingested lexicons from %s", p.FormatPath(addLexDir)))
+		case len(nsids) > 0:
+			p.PrintSuccess(fmt.Sprintf("Ingested lexicons %s", p.FormatSymbol(strings.Join(nsids, ", "))))
+		default:
+			p.PrintSuccess("Ingested AT Protocol documentation")
+		}
 	}
 	return nil
 }
 
 func addSourceCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) == 0 {
-		return []string{"go", "atproto", "hex", "rust", "github"}, cobra.ShellCompDirectiveNoFileComp
+		return []string{"go", "atproto", "hex", "rust", "python", "npm", "maven", "c", "zig", "github", "git", "local"}, cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) == 1 && (args[0] == "local" || args[0] == "c" || args[0] == "zig") {
+		return nil, cobra.ShellCompDirectiveFilterDirs
 	}
 	return nil, cobra.ShellCompDirectiveNoFileComp
 }
 
-func addRustSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+func addRustSource(ctx context.Context, cmd *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	var features []string
+	if cmd.Flags().Changed("features") {
+		features = rustingest.ParseFeatures(addFeatures)
+	}
+
+	if addDir != "" {
+		if err := rustingest.IngestCrate(ctx, rustingest.Options{
+			Crate:    source,
+			Format:   addRustdoc,
+			Dir:      addDir,
+			Target:   addTarget,
+			Features: features,
+			DB:       store,
+		}); err != nil {
+			return err
+		}
+		if !quiet {
+			p.PrintSuccess(fmt.Sprintf("Ingested rust crates from %s", p.FormatPath(addDir)))
+		}
+		return nil
+	}
+
 	if source == "" {
 		return errors.New("rust crate name is required")
 	}
 
 	if err := rustingest.IngestCrate(ctx, rustingest.Options{
-		Crate:   source,
-		Version: addVersion,
-		DB:      store,
-		Cache:   c,
+		Crate:    source,
+		Version:  addVersion,
+		Format:   addRustdoc,
+		Target:   addTarget,
+		Features: features,
+		DB:       store,
+		Cache:    c,
 	}); err != nil {
 		return err
 	}
@@ -232,11 +549,14 @@ func addGithubSource(ctx context.Context, _ *cobra.Command, store *db.Store, sou
 	repo := parts[1]
 
 	if err := githubingest.IngestRepository(ctx, githubingest.Options{
-		Owner:  owner,
-		Repo:   repo,
-		Branch: addVersion,
-		DB:     store,
-		Cache:  c,
+		Owner:   owner,
+		Repo:    repo,
+		Branch:  addVersion,
+		Token:   githubToken(),
+		Include: addInclude,
+		Exclude: addExclude,
+		DB:      store,
+		Cache:   c,
 	}); err != nil {
 		return err
 	}
@@ -246,3 +566,58 @@ func addGithubSource(ctx context.Context, _ *cobra.Command, store *db.Store, sou
 	}
 	return nil
 }
+
+func addGitSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string, c *cache.FilesystemCache) error {
+	if source == "" {
+		return errors.New("git clone url is required")
+	}
+
+	if err := gitingest.IngestRepository(ctx, gitingest.Options{
+		URL:     source,
+		Ref:     addRef,
+		Subdir:  addSubdir,
+		Include: addInclude,
+		Exclude: addExclude,
+		DB:      store,
+		Cache:   c,
+	}); err != nil {
+		return err
+	}
+
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Ingested git repository %s", p.FormatSymbol(source)))
+	}
+	return nil
+}
+
+func addLocalSource(ctx context.Context, _ *cobra.Command, store *db.Store, source string) error {
+	stats, err := localingest.IngestDirectory(ctx, localingest.Options{
+		Name:    addName,
+		Dir:     source,
+		Include: addInclude,
+		Exclude: addExclude,
+		DB:      store,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Ingested %s (%d written, %d unchanged, %d removed)", p.FormatPath(source), stats.Written, stats.Unchanged, stats.Removed))
+	}
+	return nil
+}
+
+// githubToken reads the GitHub token from GITHUB_TOKEN, GH_TOKEN or the
+// github.token setting, in that order.
+func githubToken() string {
+	for _, env := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
+	}
+	if cfg != nil {
+		return cfg.GitHub.Token
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/atproto"
+)
+
+var (
+	atprotoPart    string
+	atprotoLang    string
+	atprotoPackage string
+	atprotoOutput  string
+)
+
+func newAtprotoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "atproto",
+		Short: "Work with ingested AT Protocol lexicons",
+		Long: `Validate documents against, generate examples from and generate client
+types for the AT Protocol lexicons stored in the database by
+"documango add atproto".
+
+Lexicons are named by NSID (app.bsky.feed.post) or by NSID and definition
+(app.bsky.feed.defs#postView). Refs to other stored lexicons are followed.`,
+	}
+
+	cmd.AddCommand(newAtprotoValidateCommand())
+	cmd.AddCommand(newAtprotoExampleCommand())
+	cmd.AddCommand(newAtprotoCodegenCommand())
+
+	return cmd
+}
+
+func newAtprotoValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate <nsid> <file.json>",
+		Short: "Validate a record or XRPC payload against a lexicon",
+		Long: `Validate a JSON document against a stored lexicon, including referenced
+definitions, string formats (did, handle, at-uri, datetime, ...), length and
+grapheme limits, enums and blob constraints. Use "-" to read from stdin.
+
+By default records are validated against the record schema, procedures
+against their input, queries against their output and subscriptions against
+their message; --part selects another schema.`,
+		Example: `  documango atproto validate app.bsky.feed.post post.json
+  documango atproto validate app.bsky.feed.getTimeline --part params params.json
+  cat profile.json | documango atproto validate app.bsky.actor.defs#profileView -`,
+		Args: cobra.ExactArgs(2),
+		RunE: runAtprotoValidate,
+	}
+
+	cmd.Flags().StringVar(&atprotoPart, "part", "", "Schema to use: record, params, input, output or message")
+	return cmd
+}
+
+func newAtprotoExampleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "example <nsid>",
+		Short: "Generate a minimal valid document for a lexicon",
+		Long: `Generate a minimal JSON document that validates against a stored lexicon:
+required fields only, each set to the smallest value its constraints allow.`,
+		Example: `  documango atproto example app.bsky.feed.post
+  documango atproto example com.atproto.repo.createRecord --part output`,
+		Args: cobra.ExactArgs(1),
+		RunE: runAtprotoExample,
+	}
+
+	cmd.Flags().StringVar(&atprotoPart, "part", "", "Schema to use: record, params, input, output or message")
+	return cmd
+}
+
+func newAtprotoCodegenCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "codegen <nsid-prefix>",
+		Short: "Generate Go or TypeScript types from lexicons",
+		Long: `Generate Go structs or TypeScript interfaces for the stored lexicons in a
+namespace: records, objects, and the params, input, output and message
+schemas of queries, procedures and subscriptions. Definitions referenced from
+other stored lexicons are generated too; refs to lexicons that were never
+ingested become untyped JSON.
+
+The prefix matches a whole NSID or every NSID below it: app.bsky.feed
+matches app.bsky.feed.post and app.bsky.feed.defs.`,
+		Example: `  documango atproto codegen --lang go app.bsky.feed.post
+  documango atproto codegen --lang ts app.bsky.feed -o feed.ts
+  documango atproto codegen --lang go --package bsky app.bsky > bsky/lexicons.go`,
+		Args: cobra.ExactArgs(1),
+		RunE: runAtprotoCodegen,
+	}
+
+	cmd.Flags().StringVar(&atprotoLang, "lang", atproto.LangGo, "Output language: go or ts")
+	cmd.Flags().StringVar(&atprotoPackage, "package", "lexicon", "Package name of generated Go code")
+	cmd.Flags().StringVarP(&atprotoOutput, "output", "o", "", "Write to a file instead of stdout")
+	return cmd
+}
+
+// loadLexiconTarget opens the database and resolves the schema for ref.
+func loadLexiconTarget(ctx context.Context, ref string) (atproto.Catalog, atproto.Target, error) {
+	path, err := resolveDBPath()
+	if err != nil {
+		return nil, atproto.Target{}, err
+	}
+	store, err := db.Open(path)
+	if err != nil {
+		return nil, atproto.Target{}, err
+	}
+	defer store.Close()
+
+	nsid, _ := atproto.SplitRef("", ref)
+	catalog, err := atproto.LoadCatalog(ctx, store, nsid)
+	if err != nil {
+		return nil, atproto.Target{}, err
+	}
+	target, err := catalog.Target(ref, atprotoPart)
+	if err != nil {
+		return nil, atproto.Target{}, err
+	}
+	return catalog, target, nil
+}
+
+func runAtprotoValidate(cmd *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if args[1] == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	catalog, target, err := loadLexiconTarget(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	errs, err := catalog.Validate(target, data)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		for _, e := range errs {
+			p.PrintError(e.Error())
+		}
+		return fmt.Errorf("%d validation errors against %s", len(errs), args[0])
+	}
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Valid %s", p.FormatSymbol(args[0])))
+	}
+	return nil
+}
+
+func runAtprotoExample(cmd *cobra.Command, args []string) error {
+	catalog, target, err := loadLexiconTarget(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	example, err := catalog.Example(target)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(example, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(out))
+	return nil
+}
+
+func runAtprotoCodegen(cmd *cobra.Command, args []string) error {
+	path, err := resolveDBPath()
+	if err != nil {
+		return err
+	}
+	store, err := db.Open(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx := cmd.Context()
+	nsids, err := atproto.StoredLexicons(ctx, store, args[0])
+	if err != nil {
+		return err
+	}
+	if len(nsids) == 0 {
+		return fmt.Errorf("no lexicons match %s (run documango add atproto)", args[0])
+	}
+	catalog, err := atproto.LoadCatalog(ctx, store, nsids...)
+	if err != nil {
+		return err
+	}
+	code, err := catalog.Codegen(nsids, atproto.CodegenOptions{Lang: atprotoLang, Package: atprotoPackage})
+	if err != nil {
+		return err
+	}
+
+	if atprotoOutput == "" {
+		fmt.Fprint(cmd.OutOrStdout(), code)
+		return nil
+	}
+	if err := os.WriteFile(atprotoOutput, []byte(code), 0o644); err != nil {
+		return err
+	}
+	if !quiet {
+		p.PrintSuccess(fmt.Sprintf("Generated %s types for %d lexicons in %s", atprotoLang, len(nsids), atprotoOutput))
+	}
+	return nil
+}
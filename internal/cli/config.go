@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -68,6 +69,10 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	} else {
 		fmt.Fprintf(cmd.OutOrStdout(), "color_output = auto\n")
 	}
+	if cfg.GitHub.Token != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "\n[github]\n")
+		fmt.Fprintf(cmd.OutOrStdout(), "token = %q\n", maskToken(cfg.GitHub.Token))
+	}
 
 	return nil
 }
@@ -177,6 +182,8 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("invalid boolean: %s (use true/false)", value)
 		}
 		cfg.Display.RenderMarkdown = render
+	case "github.token":
+		cfg.GitHub.Token = value
 	default:
 		return fmt.Errorf("unknown configuration key: %s", key)
 	}
@@ -186,6 +193,9 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 	}
 
 	if !quiet {
+		if key == "github.token" {
+			value = maskToken(value)
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Set %s = %s\n", key, value)
 	}
 
@@ -228,6 +238,8 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 		fmt.Fprintln(cmd.OutOrStdout(), cfg.Display.UsePager)
 	case "display.render_markdown":
 		fmt.Fprintln(cmd.OutOrStdout(), cfg.Display.RenderMarkdown)
+	case "github.token":
+		fmt.Fprintln(cmd.OutOrStdout(), maskToken(cfg.GitHub.Token))
 	default:
 		return fmt.Errorf("unknown configuration key: %s", key)
 	}
@@ -262,3 +274,11 @@ func configFilePath() (string, error) {
 	}
 	return fmt.Sprintf("%s/config.toml", configDir), nil
 }
+
+// maskToken hides all but the last four characters of a secret.
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", len(token)-4) + token[len(token)-4:]
+}
@@ -16,6 +16,7 @@ var (
 	listType  string
 	listTree  bool
 	listCount bool
+	listTOC   bool
 )
 
 func newListCommand() *cobra.Command {
@@ -24,17 +25,21 @@ func newListCommand() *cobra.Command {
 		Short: "List documentation paths in the database",
 		Long: `List all documentation paths stored in the database.
 
-Paths can be filtered by type and displayed in various formats.`,
+Paths can be filtered by type and displayed in various formats. Documentation
+sites built with mdBook, MkDocs, Docusaurus or Hugo can be listed as their
+table of contents with --toc.`,
 		Example: `  documango list
   documango list -t go
   documango list --tree
-  documango list --count`,
+  documango list --count
+  documango list --toc git/gitlab.com/gitlab-org/gitlab-runner`,
 		RunE: runList,
 	}
 
 	cmd.Flags().StringVarP(&listType, "type", "t", "", "Filter by path prefix (e.g., go, atproto)")
 	cmd.Flags().BoolVar(&listTree, "tree", false, "Display as tree structure")
 	cmd.Flags().BoolVar(&listCount, "count", false, "Show only count of documents")
+	cmd.Flags().BoolVar(&listTOC, "toc", false, "Display the table of contents of documentation sites")
 
 	return cmd
 }
@@ -52,11 +57,6 @@ func runList(cmd *cobra.Command, args []string) error {
 	defer store.Close()
 
 	ctx := context.Background()
-	paths, err := listPaths(ctx, store)
-	if err != nil {
-		return err
-	}
-
 	prefix := listType
 	if len(args) > 0 {
 		if prefix != "" {
@@ -66,6 +66,15 @@ func runList(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if listTOC {
+		return printSites(ctx, cmd.OutOrStdout(), store, prefix)
+	}
+
+	paths, err := listPaths(ctx, store)
+	if err != nil {
+		return err
+	}
+
 	if prefix != "" {
 		paths = filterPaths(paths, prefix)
 	}
@@ -115,6 +124,45 @@ func filterPaths(paths []string, prefix string) []string {
 	return filtered
 }
 
+// printSites prints the table of contents of every site stored below
+// prefix, with page paths relative to the site's root.
+func printSites(ctx context.Context, w io.Writer, store *db.Store, prefix string) error {
+	sites, err := store.Sites(ctx)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, site := range sites {
+		if !strings.HasPrefix(site.Root, prefix) {
+			continue
+		}
+		nav, err := store.SiteNav(ctx, site.Root)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+		n++
+
+		fmt.Fprintf(w, "%s (%s, %s)\n", site.Title, site.Generator, site.Root)
+		for _, entry := range nav {
+			indent := strings.Repeat("  ", entry.Depth+1)
+			if entry.Path == "" {
+				fmt.Fprintf(w, "%s%s\n", indent, entry.Title)
+				continue
+			}
+			fmt.Fprintf(w, "%s%s  %s\n", indent, entry.Title, strings.TrimPrefix(entry.Path, site.Root+"/"))
+		}
+	}
+
+	if n == 0 {
+		fmt.Fprintln(w, "No documentation sites found.")
+	}
+	return nil
+}
+
 func printTree(cmd *cobra.Command, paths []string) error {
 	root := &treeNode{name: "root"}
 
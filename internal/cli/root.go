@@ -44,6 +44,8 @@ func Execute() error {
 	rootCmd.AddCommand(
 		newInitCommand(),
 		newAddCommand(),
+		newWatchCommand(),
+		newAtprotoCommand(),
 		newSearchCommand(),
 		newReadCommand(),
 		newListCommand(),
@@ -26,10 +26,15 @@ func newSearchCommand() *cobra.Command {
 		Long: `Search the full-text index for documentation matching the query.
 
 Results are ranked by relevance using BM25 ranking with exact matches
-receiving a boost.`,
+receiving a boost.
+
+Metadata filters narrow results to items with a recorded attribute:
+feature:<name> (cargo feature), cfg:<option> (e.g. cfg:unix or
+cfg:target_os=linux) and target:<triple>.`,
 		Example: `  documango search "http.Client"
   documango search -l 50 -t Func "Write"
-  documango search -f json "net/http"`,
+  documango search -f json "net/http"
+  documango search "Serialize feature:derive"`,
 		Args: cobra.ExactArgs(1),
 		RunE: runSearch,
 	}
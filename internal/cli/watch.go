@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/local"
+)
+
+func newWatchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch [name...]",
+		Short: "Re-index local documentation sources as they change",
+		Long: `Watch the local directories registered with "documango add local" and
+re-index their files as they are written, renamed or removed.
+
+Each source is synced first, so changes made while nothing was watching are
+picked up. With names, only those sources are watched. Runs until interrupted.`,
+		Example: `  documango watch
+  documango watch team-handbook`,
+		RunE: runWatch,
+	}
+
+	return cmd
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	dbPath, err := resolveDBPath()
+	if err != nil {
+		return err
+	}
+
+	store, err := db.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := store.EnsureSchema(ctx); err != nil {
+		return err
+	}
+
+	sources, err := local.Sources(ctx, store)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		var selected []local.Source
+		for _, name := range args {
+			i := slices.IndexFunc(sources, func(src local.Source) bool { return src.Name == name })
+			if i < 0 {
+				return fmt.Errorf("unknown local source: %s", name)
+			}
+			selected = append(selected, sources[i])
+		}
+		sources = selected
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no local sources registered; add one with: documango add local <dir> --name <name>")
+	}
+
+	if !quiet {
+		p.PrintInfo(fmt.Sprintf("Watching %d local source(s), press Ctrl+C to stop", len(sources)))
+	}
+	return local.Watch(ctx, store, sources)
+}
@@ -14,6 +14,7 @@ type Config struct {
 	Cache    CacheConfig    `toml:"cache"`
 	Search   SearchConfig   `toml:"search"`
 	Display  DisplayConfig  `toml:"display"`
+	GitHub   GitHubConfig   `toml:"github"`
 }
 
 // DatabaseConfig holds database-related settings.
@@ -41,6 +42,11 @@ type DisplayConfig struct {
 	ColorOutput    *bool `toml:"color_output"`    // Enable colored output (nil = auto)
 }
 
+// GitHubConfig holds GitHub API settings.
+type GitHubConfig struct {
+	Token string `toml:"token,omitempty"` // API token; GITHUB_TOKEN takes precedence
+}
+
 // Load reads the configuration from the XDG config path or uses defaults.
 func Load() (*Config, error) {
 	configPath, err := configFilePath()
@@ -85,7 +91,7 @@ func (cfg *Config) Save() error {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0o644)
+	return os.WriteFile(configPath, data, 0o600)
 }
 
 func configFilePath() (string, error) {
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PutLexiconTx stores the raw JSON schema of a lexicon next to its rendered
+// document, replacing any previous version. It is removed with the document.
+func PutLexiconTx(ctx context.Context, tx *sql.Tx, nsid string, docID int64, schema string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO lexicons (nsid, doc_id, schema) VALUES (?, ?, ?)`,
+		nsid, docID, schema,
+	)
+	return err
+}
+
+// Lexicon returns the raw JSON schema of a stored lexicon, or sql.ErrNoRows
+// when it was never ingested.
+func (s *Store) Lexicon(ctx context.Context, nsid string) (string, error) {
+	var schema string
+	err := s.db.QueryRowContext(ctx, `SELECT schema FROM lexicons WHERE nsid = ?`, nsid).Scan(&schema)
+	return schema, err
+}
+
+// Lexicons lists the NSIDs of stored lexicons in a namespace: prefix itself
+// and every NSID below it, so app.bsky.feed matches app.bsky.feed.post but
+// not app.bsky.feedgen.
+func (s *Store) Lexicons(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT nsid FROM lexicons WHERE nsid = ? OR substr(nsid, 1, ?) = ? ORDER BY nsid`,
+		prefix, len(prefix)+1, prefix+".",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nsids []string
+	for rows.Next() {
+		var nsid string
+		if err := rows.Scan(&nsid); err != nil {
+			return nil, err
+		}
+		nsids = append(nsids, nsid)
+	}
+	return nsids, rows.Err()
+}
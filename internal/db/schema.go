@@ -28,6 +28,47 @@ CREATE TABLE IF NOT EXISTS agent_context (
 	FOREIGN KEY (doc_id) REFERENCES documents(id) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS item_metadata (
+	doc_id INTEGER NOT NULL,
+	symbol TEXT NOT NULL,
+	key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	FOREIGN KEY (doc_id) REFERENCES documents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sources (
+	name TEXT PRIMARY KEY,
+	kind TEXT NOT NULL,
+	location TEXT NOT NULL,
+	options TEXT NOT NULL DEFAULT '{}',
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sites (
+	root TEXT PRIMARY KEY,
+	generator TEXT NOT NULL,
+	title TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS lexicons (
+	nsid TEXT PRIMARY KEY,
+	doc_id INTEGER NOT NULL,
+	schema TEXT NOT NULL,
+	FOREIGN KEY (doc_id) REFERENCES documents(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS site_nav (
+	root TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	depth INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	path TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (root, position)
+);
+
 CREATE INDEX IF NOT EXISTS idx_agent_context_symbol ON agent_context(symbol);
+CREATE INDEX IF NOT EXISTS idx_item_metadata_symbol ON item_metadata(symbol);
+CREATE INDEX IF NOT EXISTS idx_item_metadata_key ON item_metadata(key, value);
 CREATE INDEX IF NOT EXISTS idx_documents_path ON documents(path);
+CREATE INDEX IF NOT EXISTS idx_site_nav_path ON site_nav(path);
 `
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Site is a documentation site built with a static-site generator, such as
+// an mdBook or MkDocs project, whose documents are stored below Root.
+type Site struct {
+	Root      string
+	Generator string
+	Title     string
+}
+
+// NavEntry is an entry of a site's table of contents, in reading order.
+// Depth is 0 for top-level entries; Path is empty for a section heading
+// without a page of its own.
+type NavEntry struct {
+	Title string
+	Path  string
+	Depth int
+}
+
+// ReplaceSiteTx stores a site and its table of contents, replacing any
+// previous version.
+func ReplaceSiteTx(ctx context.Context, tx *sql.Tx, site Site, nav []NavEntry) error {
+	if err := DeleteSiteTx(ctx, tx, site.Root); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO sites (root, generator, title) VALUES (?, ?, ?)`,
+		site.Root, site.Generator, site.Title,
+	); err != nil {
+		return err
+	}
+	for i, entry := range nav {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO site_nav (root, position, depth, title, path) VALUES (?, ?, ?, ?, ?)`,
+			site.Root, i, entry.Depth, entry.Title, entry.Path,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteSiteTx removes a site and its table of contents. Deleting a site
+// that does not exist is not an error.
+func DeleteSiteTx(ctx context.Context, tx *sql.Tx, root string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM site_nav WHERE root = ?`, root); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM sites WHERE root = ?`, root)
+	return err
+}
+
+// Sites lists the stored documentation sites ordered by root.
+func (s *Store) Sites(ctx context.Context) ([]Site, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT root, generator, title FROM sites ORDER BY root`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sites []Site
+	for rows.Next() {
+		var site Site
+		if err := rows.Scan(&site.Root, &site.Generator, &site.Title); err != nil {
+			return nil, err
+		}
+		sites = append(sites, site)
+	}
+	return sites, rows.Err()
+}
+
+// SiteNav returns the table of contents of the site stored at root.
+func (s *Store) SiteNav(ctx context.Context, root string) ([]NavEntry, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT title, path, depth FROM site_nav WHERE root = ? ORDER BY position`,
+		root,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nav []NavEntry
+	for rows.Next() {
+		var entry NavEntry
+		if err := rows.Scan(&entry.Title, &entry.Path, &entry.Depth); err != nil {
+			return nil, err
+		}
+		nav = append(nav, entry)
+	}
+	return nav, rows.Err()
+}
+
+// SiteOf returns the site whose table of contents lists the document at
+// path. ok is false when the document belongs to no site.
+func (s *Store) SiteOf(ctx context.Context, path string) (site Site, ok bool, err error) {
+	err = s.db.QueryRowContext(
+		ctx,
+		`SELECT s.root, s.generator, s.title FROM site_nav n JOIN sites s ON s.root = n.root WHERE n.path = ? LIMIT 1`,
+		path,
+	).Scan(&site.Root, &site.Generator, &site.Title)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Site{}, false, nil
+	}
+	return site, err == nil, err
+}
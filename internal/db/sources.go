@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Source is a registered documentation source that can be ingested again
+// later, such as a local directory kept up to date by the watcher. Options
+// holds source-specific settings as JSON.
+type Source struct {
+	Name      string
+	Kind      string
+	Location  string
+	Options   string
+	UpdatedAt time.Time
+}
+
+// PutSource registers a source, replacing one of the same name.
+func (s *Store) PutSource(ctx context.Context, src Source) error {
+	if src.Options == "" {
+		src.Options = "{}"
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO sources (name, kind, location, options, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		src.Name, src.Kind, src.Location, src.Options, time.Now().UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// Sources lists the registered sources of a kind, or of every kind when kind
+// is empty, ordered by name.
+func (s *Store) Sources(ctx context.Context, kind string) ([]Source, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT name, kind, location, options, updated_at FROM sources WHERE ? = '' OR kind = ? ORDER BY name`,
+		kind, kind,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []Source
+	for rows.Next() {
+		var src Source
+		var updated string
+		if err := rows.Scan(&src.Name, &src.Kind, &src.Location, &src.Options, &updated); err != nil {
+			return nil, err
+		}
+		src.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
+
+// DocumentHashesTx returns the hash of every document whose path starts with
+// prefix, keyed by path.
+func DocumentHashesTx(ctx context.Context, tx *sql.Tx, prefix string) (map[string]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT path, hash FROM documents WHERE substr(path, 1, length(?)) = ?`, prefix, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := map[string]string{}
+	for rows.Next() {
+		var path, hash string
+		if err := rows.Scan(&path, &hash); err != nil {
+			return nil, err
+		}
+		hashes[path] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// DeleteDocumentTx removes a document with its search entries, agent context,
+// metadata and raw lexicon. Deleting a path that does not exist is not an error.
+func DeleteDocumentTx(ctx context.Context, tx *sql.Tx, path string) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM documents WHERE path = ?`, path).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		`DELETE FROM search_index WHERE doc_id = ?`,
+		`DELETE FROM agent_context WHERE doc_id = ?`,
+		`DELETE FROM item_metadata WHERE doc_id = ?`,
+		`DELETE FROM lexicons WHERE doc_id = ?`,
+		`DELETE FROM documents WHERE id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
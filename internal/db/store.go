@@ -41,6 +41,16 @@ type AgentContext struct {
 	Summary   string
 }
 
+// Metadata is a structured key/value fact about a symbol, such as a cargo
+// feature it requires. Keys listed in MetadataFilters can be used as search
+// filters (feature:derive).
+type Metadata struct {
+	DocID  int64
+	Symbol string
+	Key    string
+	Value  string
+}
+
 type SearchResult struct {
 	Name  string
 	Type  string
@@ -52,7 +62,9 @@ func Open(path string) (*Store, error) {
 	if path == "" {
 		return nil, errors.New("db path is required")
 	}
-	db, err := sql.Open("sqlite", path)
+	// Wait for locks rather than failing, since the watcher writes while the
+	// TUI, web server or MCP server read.
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, err
 	}
@@ -166,11 +178,20 @@ func InsertAgentContextTx(ctx context.Context, tx *sql.Tx, entry AgentContext) e
 	return err
 }
 
+func InsertMetadataTx(ctx context.Context, tx *sql.Tx, entry Metadata) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO item_metadata (doc_id, symbol, key, value) VALUES (?, ?, ?, ?)`,
+		entry.DocID, entry.Symbol, entry.Key, entry.Value,
+	)
+	return err
+}
+
 func (s *Store) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
 	return s.SearchPackage(ctx, query, "", limit)
 }
 
-var namespaces = []string{"atproto", "go", "rust", "hex", "github"}
+var namespaces = []string{"atproto", "go", "rust", "hex", "python", "npm", "maven", "c", "zig", "github", "git", "local"}
 
 // SearchPackage searches for documents matching the given query and optional package prefix.
 //
@@ -179,7 +200,9 @@ var namespaces = []string{"atproto", "go", "rust", "hex", "github"}
 // package prefix and the rest of the query as the symbol to search for.
 //
 //   - For ATProto, it also handles special cases like "lexicon/", "docs/", and "spec/".
-//   - For Go/Hex, the first part after the namespace is usually the package name.
+//   - For Go/Hex/Python/C/Zig, the first part after the namespace is usually the package name.
+//   - npm/@scope/name/item -> npm/@scope/name/% (scoped npm packages take two parts)
+//   - maven/group/artifact/item -> maven/group/artifact/% (artifacts take two parts)
 //   - rust/crate/item -> rust/crate/%/item
 //   - rust/crate -> rust/crate/index or rust/crate/% (for crate root)
 func (s *Store) SearchPackage(ctx context.Context, query, packagePrefix string, limit int) ([]SearchResult, error) {
@@ -203,6 +226,12 @@ func (s *Store) SearchPackage(ctx context.Context, query, packagePrefix string,
 							break
 						}
 					}
+				} else if ns == "npm" && len(remaining) >= 3 && strings.HasPrefix(remaining[0], "@") {
+					packagePrefix += remaining[0] + "/" + remaining[1] + "/"
+					remaining = remaining[2:]
+				} else if ns == "maven" && len(remaining) >= 3 {
+					packagePrefix += remaining[0] + "/" + remaining[1] + "/"
+					remaining = remaining[2:]
 				} else if len(remaining) >= 2 {
 					packagePrefix += remaining[0] + "/"
 					remaining = remaining[1:]
@@ -212,27 +241,40 @@ func (s *Store) SearchPackage(ctx context.Context, query, packagePrefix string,
 		}
 	}
 
+	query, filters := splitMetadataFilters(query)
 	query = SanitizeQuery(query)
 
-	var sqlQuery string
+	// bm25() is only available alongside MATCH, so a filter-only query ranks
+	// every result equally.
+	score := "0"
+	var where []string
 	var args []any
+	if query != "" {
+		score = "(CASE WHEN name = ? THEN 100 ELSE 0 END) - bm25(search_index, 5.0, 1.0, 1.0)"
+		where = append(where, "search_index MATCH ?")
+		args = append(args, query, query)
+	}
 
+	from := "search_index"
 	if packagePrefix != "" {
-		sqlQuery = `SELECT name, type, search_index.doc_id, (CASE WHEN name = ? THEN 100 ELSE 0 END) - bm25(search_index, 5.0, 1.0, 1.0) AS score
-			FROM search_index
-			JOIN documents ON search_index.doc_id = documents.id
-			WHERE search_index MATCH ? AND documents.path LIKE ?
-			ORDER BY score DESC
-			LIMIT ?`
-		args = []any{query, query, packagePrefix + "%", limit}
-	} else {
-		sqlQuery = `SELECT name, type, doc_id, (CASE WHEN name = ? THEN 100 ELSE 0 END) - bm25(search_index, 5.0, 1.0, 1.0) AS score
-			FROM search_index
-			WHERE search_index MATCH ?
-			ORDER BY score DESC
-			LIMIT ?`
-		args = []any{query, query, limit}
+		from += " JOIN documents ON search_index.doc_id = documents.id"
+		where = append(where, "documents.path LIKE ?")
+		args = append(args, packagePrefix+"%")
+	}
+	for _, f := range filters {
+		where = append(where, "EXISTS (SELECT 1 FROM item_metadata m WHERE m.symbol = search_index.name AND m.key = ? AND m.value = ?)")
+		args = append(args, f.Key, f.Value)
 	}
+	if len(where) == 0 {
+		return nil, nil
+	}
+
+	sqlQuery := `SELECT name, type, search_index.doc_id, ` + score + ` AS score
+		FROM ` + from + `
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY score DESC, name
+		LIMIT ?`
+	args = append(args, limit)
 
 	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
@@ -324,6 +366,13 @@ func (s *Store) ReadDocumentByID(ctx context.Context, id int64) (Document, error
 	return doc, nil
 }
 
+// DocumentID returns the id of the document stored at path.
+func (s *Store) DocumentID(ctx context.Context, path string) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM documents WHERE path = ?`, path).Scan(&id)
+	return id, err
+}
+
 func (s *Store) CountDocuments(ctx context.Context) (int, error) {
 	var count int
 	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM documents`).Scan(&count); err != nil {
@@ -422,6 +471,27 @@ func (s *Store) ListPackages(ctx context.Context) ([]PackageInfo, error) {
 	return packages, rows.Err()
 }
 
+// MetadataFilters are the item_metadata keys that can be used as key:value
+// search terms, e.g. "Serialize feature:derive" or "cfg:unix".
+var MetadataFilters = []string{"feature", "cfg", "target"}
+
+// splitMetadataFilters removes metadata filter terms from the query and
+// returns them separately, since they match item_metadata rather than the
+// full-text index.
+func splitMetadataFilters(q string) (string, []Metadata) {
+	var rest []string
+	var filters []Metadata
+	for _, term := range strings.Fields(q) {
+		key, value, ok := strings.Cut(term, ":")
+		if ok && value != "" && slices.Contains(MetadataFilters, strings.ToLower(key)) {
+			filters = append(filters, Metadata{Key: strings.ToLower(key), Value: strings.Trim(value, "\"")})
+			continue
+		}
+		rest = append(rest, term)
+	}
+	return strings.Join(rest, " "), filters
+}
+
 // SanitizeQuery wraps the query in double quotes if it contains characters
 // that might break FTS5 (like slashes) and isn't already quoted.
 // It preserves column filters like "type:Func".
@@ -0,0 +1,25 @@
+// Package dbtest opens stores for tests.
+package dbtest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/db"
+)
+
+// Open opens a store with the schema in place in a temporary directory of
+// a test, and closes it when the test ends.
+func Open(t testing.TB) *db.Store {
+	t.Helper()
+	store, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.EnsureSchema(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
@@ -1,14 +1,15 @@
 package atproto
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/charmbracelet/log"
@@ -16,14 +17,33 @@ import (
 	"github.com/stormlightlabs/documango/internal/cache"
 	"github.com/stormlightlabs/documango/internal/codec"
 	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
 )
 
 type Options struct {
 	DB    *db.Store
 	Cache *cache.FilesystemCache
+
+	// ExpandDepth is how many levels of referenced objects are expanded
+	// inline in lexicon documents; 0 renders refs as links only.
+	ExpandDepth int
+
+	// LexiconsOnly skips the specification and developer docs repositories.
+	LexiconsOnly bool
+
+	// Lexicons is a directory or git URL of lexicon JSON files, and NSIDs
+	// are lexicons to fetch through Resolver. Either one replaces the
+	// official repositories as the source.
+	Lexicons string
+	NSIDs    []string
+	Resolver *Resolver
 }
 
 func IngestAtproto(ctx context.Context, opts Options) error {
+	if opts.Lexicons != "" || len(opts.NSIDs) > 0 {
+		return ingestLexiconSources(ctx, opts)
+	}
+
 	tmpDir, err := os.MkdirTemp("", "documango-atproto-")
 	if err != nil {
 		return err
@@ -31,9 +51,11 @@ func IngestAtproto(ctx context.Context, opts Options) error {
 	defer os.RemoveAll(tmpDir)
 
 	repos := map[string]string{
-		"atproto":         "https://github.com/bluesky-social/atproto",
-		"atproto-website": "https://github.com/bluesky-social/atproto-website",
-		"bsky-docs":       "https://github.com/bluesky-social/bsky-docs",
+		"atproto": "https://github.com/bluesky-social/atproto",
+	}
+	if !opts.LexiconsOnly {
+		repos["atproto-website"] = "https://github.com/bluesky-social/atproto-website"
+		repos["bsky-docs"] = "https://github.com/bluesky-social/bsky-docs"
 	}
 
 	for name, url := range repos {
@@ -46,7 +68,7 @@ func IngestAtproto(ctx context.Context, opts Options) error {
 			if err == nil {
 				if commitSHA, ok := gitCache.GetCommit(cacheKey); ok {
 					log.Info("using cached commit", "repo", name, "commit", commitSHA)
-					if err := cache.ShallowClone(url, commitSHA, dest); err == nil {
+					if err := cache.ShallowClone(ctx, url, commitSHA, dest); err == nil {
 						continue
 					}
 					log.Warn("shallow clone failed, falling back to full clone", "repo", name, "err", err)
@@ -73,10 +95,14 @@ func IngestAtproto(ctx context.Context, opts Options) error {
 
 	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
 		lexiconDir := filepath.Join(tmpDir, "atproto", "lexicons")
-		if err := ingestLexicons(ctx, tx, lexiconDir); err != nil {
+		if err := ingestLexicons(ctx, tx, lexiconDir, opts.ExpandDepth); err != nil {
 			return err
 		}
 
+		if opts.LexiconsOnly {
+			return nil
+		}
+
 		specDir := filepath.Join(tmpDir, "atproto-website", "src", "app", "[locale]")
 		if err := ingestSpecs(ctx, tx, specDir); err != nil {
 			return err
@@ -91,13 +117,109 @@ func IngestAtproto(ctx context.Context, opts Options) error {
 	})
 }
 
+// ingestLexiconSources ingests lexicons from a directory, a git repository
+// or by NSID resolution. Refs to lexicons already in the database, such as
+// the official app.bsky and com.atproto ones, render as links too.
+func ingestLexiconSources(ctx context.Context, opts Options) error {
+	var files []lexiconFile
+	if opts.Lexicons != "" {
+		dir := opts.Lexicons
+		if isGitURL(dir) {
+			tmpDir, err := os.MkdirTemp("", "documango-lexicons-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tmpDir)
+
+			log.Info("fetching repository", "repo", dir)
+			if err := gitClone(ctx, dir, tmpDir); err != nil {
+				return fmt.Errorf("failed to clone %s: %w", dir, err)
+			}
+			dir = tmpDir
+			if info, err := os.Stat(filepath.Join(tmpDir, "lexicons")); err == nil && info.IsDir() {
+				dir = filepath.Join(tmpDir, "lexicons")
+			}
+		}
+
+		loaded, err := loadLexiconDir(dir)
+		if err != nil {
+			return err
+		}
+		if len(loaded) == 0 {
+			return fmt.Errorf("no lexicons found in %s", opts.Lexicons)
+		}
+		files = append(files, loaded...)
+	}
+
+	resolver := opts.Resolver
+	if resolver == nil {
+		resolver = &Resolver{}
+	}
+	for _, nsid := range opts.NSIDs {
+		log.Info("resolving lexicon", "nsid", nsid)
+		lex, raw, err := resolver.Resolve(ctx, nsid)
+		if err != nil {
+			return err
+		}
+		files = append(files, lexiconFile{lex: lex, raw: raw})
+	}
+
+	catalog := lexiconCatalog(files)
+	if err := catalog.loadReferences(ctx, opts.DB); err != nil {
+		return err
+	}
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		return writeLexicons(ctx, tx, files, catalog, opts.ExpandDepth)
+	})
+}
+
+// isGitURL reports whether a --lexicons source is a repository to clone
+// rather than a local directory.
+func isGitURL(source string) bool {
+	if _, err := os.Stat(source); err == nil {
+		return false
+	}
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "git@"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(source, ".git")
+}
+
 func gitClone(ctx context.Context, url, dest string) error {
 	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", url, dest)
 	return cmd.Run()
 }
 
-func ingestLexicons(ctx context.Context, tx *sql.Tx, root string) error {
-	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+// lexiconFile is a parsed lexicon and its compacted source JSON.
+type lexiconFile struct {
+	lex *Lexicon
+	raw []byte
+}
+
+// parseLexicon parses lexicon JSON from a file or a PDS record.
+func parseLexicon(data []byte) (lexiconFile, error) {
+	var lex Lexicon
+	if err := json.Unmarshal(data, &lex); err != nil {
+		return lexiconFile{}, err
+	}
+	if lex.ID == "" || len(lex.Defs) == 0 {
+		return lexiconFile{}, errors.New("not a lexicon: missing id or defs")
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return lexiconFile{}, err
+	}
+	return lexiconFile{lex: &lex, raw: compact.Bytes()}, nil
+}
+
+// loadLexiconDir parses every lexicon JSON file under root. Other JSON files
+// are skipped with a warning.
+func loadLexiconDir(root string) ([]lexiconFile, error) {
+	var files []lexiconFile
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -110,16 +232,44 @@ func ingestLexicons(ctx context.Context, tx *sql.Tx, root string) error {
 			return err
 		}
 
-		var lex Lexicon
-		if err := json.Unmarshal(data, &lex); err != nil {
+		file, err := parseLexicon(data)
+		if err != nil {
 			log.Warn("failed to parse lexicon", "path", path, "err", err)
 			return nil
 		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
+
+// ingestLexicons loads every lexicon under root before rendering any, so refs
+// between lexicons resolve to links regardless of file order.
+func ingestLexicons(ctx context.Context, tx *sql.Tx, root string, expandDepth int) error {
+	files, err := loadLexiconDir(root)
+	if err != nil {
+		return err
+	}
+	return writeLexicons(ctx, tx, files, lexiconCatalog(files), expandDepth)
+}
+
+func lexiconCatalog(files []lexiconFile) Catalog {
+	lexicons := make([]*Lexicon, len(files))
+	for i, f := range files {
+		lexicons[i] = f.lex
+	}
+	return NewCatalog(lexicons...)
+}
 
-		md := LexiconToMarkdown(&lex)
-		docPath := "atproto/lexicon/" + lex.ID
+// writeLexicons stores a document, search entry and raw schema for each
+// lexicon, rendering refs against catalog.
+func writeLexicons(ctx context.Context, tx *sql.Tx, files []lexiconFile, catalog Catalog, expandDepth int) error {
+	opts := RenderOptions{Catalog: catalog, ExpandDepth: expandDepth}
+	for _, f := range files {
+		lex := f.lex
+		md := RenderLexicon(lex, opts)
 
-		docID, err := insertDoc(ctx, tx, docPath, md)
+		docID, err := insertDoc(ctx, tx, DocPath(lex.ID), md)
 		if err != nil {
 			return err
 		}
@@ -136,8 +286,32 @@ func ingestLexicons(ctx context.Context, tx *sql.Tx, root string) error {
 			return err
 		}
 
-		return nil
-	})
+		summary := lex.Description
+		if def, ok := lex.Defs["main"]; ok && def.Description != "" {
+			summary = def.Description
+		}
+		if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    lex.ID,
+			Signature: lexiconSignature(lex),
+			Summary:   summary,
+		}); err != nil {
+			return err
+		}
+		if err := db.PutLexiconTx(ctx, tx, lex.ID, docID, string(f.raw)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lexiconSignature names a lexicon with the type of its main definition, as
+// in "record app.bsky.feed.post", or as a lexicon of shared definitions.
+func lexiconSignature(lex *Lexicon) string {
+	if def, ok := lex.Defs["main"]; ok && def.Type != "" {
+		return def.Type + " " + lex.ID
+	}
+	return "lexicon " + lex.ID
 }
 
 func insertDoc(ctx context.Context, tx *sql.Tx, path, body string) (int64, error) {
@@ -171,7 +345,7 @@ func ingestSpecs(ctx context.Context, tx *sql.Tx, root string) error {
 			return err
 		}
 
-		body := transformMDX(string(data))
+		body := docset.TransformMDX(string(data))
 		rel, _ := filepath.Rel(root, path)
 
 		docPath := "atproto/spec/" + rel
@@ -229,7 +403,7 @@ func ingestDocs(ctx context.Context, tx *sql.Tx, root string) error {
 			return err
 		}
 
-		body := transformMDX(string(data))
+		body := docset.TransformMDX(string(data))
 		rel, _ := filepath.Rel(root, path)
 		docPath := "atproto/docs/" + strings.TrimSuffix(rel, filepath.Ext(rel))
 
@@ -260,44 +434,3 @@ func ingestDocs(ctx context.Context, tx *sql.Tx, root string) error {
 		return nil
 	})
 }
-
-func transformMDX(input string) string {
-	if strings.HasPrefix(input, "---") {
-		parts := strings.SplitN(input, "---", 3)
-		if len(parts) == 3 {
-			input = parts[2]
-		}
-	}
-
-	lines := strings.Split(input, "\n")
-	var out []string
-	inExport := false
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "export const") || strings.HasPrefix(trimmed, "import ") {
-			if strings.Contains(line, "{") && !strings.Contains(line, "}") {
-				inExport = true
-			}
-			continue
-		}
-		if inExport {
-			if strings.Contains(line, "}") {
-				inExport = false
-			}
-			continue
-		}
-		out = append(out, line)
-	}
-	input = strings.Join(out, "\n")
-
-	re := regexp.MustCompile(`\{\{.*?\}\}`)
-	input = re.ReplaceAllString(input, "")
-
-	tagRegex := regexp.MustCompile(`<(Tabs|TabItem|Admonition|video|img|br|hr|p|div|section)[^>]*>`)
-	input = tagRegex.ReplaceAllString(input, "")
-
-	closeTagRegex := regexp.MustCompile(`</(Tabs|TabItem|Admonition|video|img|br|hr|p|div|section)>`)
-	input = closeTagRegex.ReplaceAllString(input, "")
-
-	return strings.TrimSpace(input)
-}
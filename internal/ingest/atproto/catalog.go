@@ -0,0 +1,116 @@
+package atproto
+
+import (
+	"slices"
+	"strings"
+)
+
+// Catalog is a set of lexicons keyed by NSID, used to resolve refs across
+// lexicon documents.
+type Catalog map[string]*Lexicon
+
+// NewCatalog indexes lexicons by ID. A later lexicon with the same ID
+// replaces an earlier one.
+func NewCatalog(lexicons ...*Lexicon) Catalog {
+	c := make(Catalog, len(lexicons))
+	for _, lex := range lexicons {
+		c[lex.ID] = lex
+	}
+	return c
+}
+
+// Definition looks up a definition by NSID and name.
+func (c Catalog) Definition(nsid, name string) (Definition, bool) {
+	lex, ok := c[nsid]
+	if !ok {
+		return Definition{}, false
+	}
+	def, ok := lex.Defs[name]
+	return def, ok
+}
+
+// Resolve looks up the definition a ref points to. Refs are relative to the
+// lexicon from, the NSID of the lexicon containing them.
+func (c Catalog) Resolve(from, ref string) (nsid, name string, def Definition, ok bool) {
+	nsid, name = SplitRef(from, ref)
+	def, ok = c.Definition(nsid, name)
+	return nsid, name, def, ok
+}
+
+// SplitRef splits a ref into NSID and definition name: "#reply" is local to
+// from, "app.bsky.feed.defs#postView" names a definition of another lexicon,
+// and a bare NSID refers to its main definition.
+func SplitRef(from, ref string) (nsid, name string) {
+	nsid, name, found := strings.Cut(ref, "#")
+	if nsid == "" {
+		nsid = from
+	}
+	if !found || name == "" {
+		name = "main"
+	}
+	return nsid, name
+}
+
+// DocPath is the document path of a lexicon.
+func DocPath(nsid string) string {
+	return "atproto/lexicon/" + nsid
+}
+
+// RefURL links to a definition: the web path of the lexicon document and the
+// anchor of the definition's heading.
+func RefURL(nsid, name string) string {
+	return "/doc/" + DocPath(nsid) + "#" + headingID(defHeading(nsid, name))
+}
+
+// References lists the other lexicons this one refers to through refs and
+// union members, sorted and without duplicates.
+func (lex *Lexicon) References() []string {
+	seen := map[string]bool{}
+	for _, def := range lex.Defs {
+		def.eachRef(func(ref string) {
+			if nsid, _ := SplitRef(lex.ID, ref); nsid != lex.ID {
+				seen[nsid] = true
+			}
+		})
+	}
+
+	refs := make([]string, 0, len(seen))
+	for nsid := range seen {
+		refs = append(refs, nsid)
+	}
+	slices.Sort(refs)
+	return refs
+}
+
+// eachRef calls fn with every ref and union member in the definition's
+// schemas, in no particular order.
+func (def Definition) eachRef(fn func(ref string)) {
+	var walk func(p *Property)
+	walk = func(p *Property) {
+		if p == nil {
+			return
+		}
+		if p.Ref != "" {
+			fn(p.Ref)
+		}
+		for _, ref := range p.Refs {
+			fn(ref)
+		}
+		for _, prop := range p.Properties {
+			walk(&prop)
+		}
+		walk(p.Items)
+	}
+	walk(&def.Property)
+	walk(def.Record)
+	walk(def.Parameters)
+	if def.Input != nil {
+		walk(def.Input.Schema)
+	}
+	if def.Output != nil {
+		walk(def.Output.Schema)
+	}
+	if def.Message != nil {
+		walk(def.Message.Schema)
+	}
+}
@@ -0,0 +1,172 @@
+package atproto
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Code generation languages.
+const (
+	LangGo = "go"
+	LangTS = "ts"
+)
+
+// CodegenOptions controls generated code.
+type CodegenOptions struct {
+	// Lang is LangGo or LangTS.
+	Lang string
+	// Package is the Go package name; empty uses "lexicon".
+	Package string
+}
+
+// Codegen emits types for every definition of the named lexicons and for the
+// definitions of other lexicons in the catalog that they reference, directly
+// or transitively: structs or interfaces for records, objects and the params,
+// input, output and message schemas of queries, procedures and
+// subscriptions, aliases for other field types and constants for tokens.
+// Refs that do not resolve in the catalog become untyped JSON.
+func (c Catalog) Codegen(nsids []string, opts CodegenOptions) (string, error) {
+	defs, err := c.codegenDefs(nsids)
+	if err != nil {
+		return "", err
+	}
+	switch opts.Lang {
+	case LangGo:
+		pkg := opts.Package
+		if pkg == "" {
+			pkg = "lexicon"
+		}
+		return c.generateGo(pkg, defs)
+	case LangTS:
+		return c.generateTS(defs), nil
+	}
+	return "", fmt.Errorf("unsupported language %q (want go or ts)", opts.Lang)
+}
+
+// defKey names a definition of a lexicon.
+type defKey struct {
+	nsid, name string
+}
+
+// codegenDefs collects the definitions to generate, ordered by NSID with
+// main first in each lexicon.
+func (c Catalog) codegenDefs(nsids []string) ([]defKey, error) {
+	var queue []defKey
+	for _, nsid := range nsids {
+		lex, ok := c[nsid]
+		if !ok {
+			return nil, fmt.Errorf("lexicon not found: %s", nsid)
+		}
+		for name := range lex.Defs {
+			queue = append(queue, defKey{nsid, name})
+		}
+	}
+
+	seen := map[defKey]bool{}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if seen[key] {
+			continue
+		}
+		def, ok := c.Definition(key.nsid, key.name)
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		def.eachRef(func(ref string) {
+			nsid, name := SplitRef(key.nsid, ref)
+			queue = append(queue, defKey{nsid, name})
+		})
+	}
+
+	keys := make([]defKey, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b defKey) int {
+		if a.nsid != b.nsid {
+			return strings.Compare(a.nsid, b.nsid)
+		}
+		switch {
+		case a.name == "main":
+			return -1
+		case b.name == "main":
+			return 1
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return keys, nil
+}
+
+// codegenRef resolves a ref for code generation: the generated type name of
+// the target, or ok false when the ref does not resolve or its target has no
+// type of its own. Tokens resolve to their string value.
+func (c Catalog) codegenRef(from, ref string) (typ string, token bool, ok bool) {
+	nsid, name, def, found := c.Resolve(from, ref)
+	if !found {
+		return "", false, false
+	}
+	switch def.Type {
+	case "token":
+		return "", true, true
+	case "query", "procedure", "subscription", "permission-set":
+		return "", false, false
+	}
+	return typeName(nsid, name), false, true
+}
+
+// typeName is the generated name of a definition: the NSID segments in
+// PascalCase, followed by an underscore and the definition name for anything
+// but main. app.bsky.feed.defs#postView becomes AppBskyFeedDefs_PostView.
+func typeName(nsid, name string) string {
+	var b strings.Builder
+	for _, seg := range strings.Split(nsid, ".") {
+		b.WriteString(pascal(seg))
+	}
+	if name != "main" {
+		b.WriteString("_" + pascal(name))
+	}
+	return b.String()
+}
+
+// pascal upper-cases the first letter of s and of each run of letters and
+// digits that follows a separator, dropping the separators.
+func pascal(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z':
+			if upper {
+				c -= 'a' - 'A'
+			}
+			b.WriteRune(c)
+			upper = false
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
+
+// schemaDoc is the description of a generated type: what it was generated
+// from, then the schema's own description.
+func schemaDoc(what, description string) string {
+	if d := oneLine(description); d != "" {
+		return what + ": " + d
+	}
+	return what + "."
+}
+
+// nestedType is an inline object or union that gets a named type of its own,
+// emitted after the type that contains it.
+type nestedType struct {
+	name   string
+	nsid   string
+	schema Property
+}
@@ -0,0 +1,335 @@
+package atproto
+
+import (
+	"fmt"
+	"go/format"
+	"slices"
+	"strings"
+)
+
+// goHelpers are the support types and functions generated Go code may use,
+// in output order, with the imports each needs.
+var goHelpers = []struct {
+	name    string
+	imports []string
+	code    string
+}{
+	{"LexLink", nil, `// LexLink is a CID link, encoded in JSON as {"$link": "<cid>"}.
+type LexLink struct {
+	Link string ` + "`json:\"$link\"`" + `
+}
+`},
+	{"LexBlob", nil, `// LexBlob is a reference to a blob stored in a repository.
+type LexBlob struct {
+	Type     string  ` + "`json:\"$type\"`" + `
+	Ref      LexLink ` + "`json:\"ref\"`" + `
+	MimeType string  ` + "`json:\"mimeType\"`" + `
+	Size     int64   ` + "`json:\"size\"`" + `
+}
+`},
+	{"LexBytes", []string{"encoding/base64", "encoding/json"}, `// LexBytes is binary data, encoded in JSON as {"$bytes": "<base64>"}.
+type LexBytes []byte
+
+func (b LexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"$bytes": base64.RawStdEncoding.EncodeToString(b)})
+}
+
+func (b *LexBytes) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Bytes string ` + "`json:\"$bytes\"`" + `
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	out, err := base64.RawStdEncoding.DecodeString(v.Bytes)
+	if err != nil {
+		return err
+	}
+	*b = out
+	return nil
+}
+`},
+	{"lexTypedJSON", []string{"bytes", "encoding/json"}, `// lexTypedJSON encodes a union member with its $type as the first field.
+func lexTypedJSON(typ string, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(data) < 2 || data[0] != '{' || bytes.HasPrefix(data, []byte(` + "`{\"$type\":`" + `)) {
+		return data, err
+	}
+	t, err := json.Marshal(typ)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(` + "`{\"$type\":`" + `), t...)
+	if len(data) > 2 {
+		out = append(out, ',')
+	}
+	return append(out, data[1:]...), nil
+}
+`},
+}
+
+type goGen struct {
+	catalog Catalog
+	b       strings.Builder
+	nested  []nestedType
+	imports map[string]bool
+	helpers map[string]bool
+}
+
+func (c Catalog) generateGo(pkg string, defs []defKey) (string, error) {
+	g := &goGen{catalog: c, imports: map[string]bool{}, helpers: map[string]bool{}}
+	for _, key := range defs {
+		def, _ := c.Definition(key.nsid, key.name)
+		g.definition(key.nsid, key.name, def)
+		for len(g.nested) > 0 {
+			n := g.nested[0]
+			g.nested = g.nested[1:]
+			if n.schema.Type == "union" {
+				g.union(n.name, n.nsid, n.schema, "")
+			} else {
+				g.named(n.name, n.nsid, n.schema, schemaDoc(n.name+" is an inline object of "+n.nsid, n.schema.Description))
+			}
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("// Code generated by documango atproto codegen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	for _, h := range goHelpers {
+		if g.helpers[h.name] {
+			for _, imp := range h.imports {
+				g.imports[imp] = true
+			}
+		}
+	}
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for imp := range g.imports {
+			imports = append(imports, imp)
+		}
+		slices.Sort(imports)
+		out.WriteString("import (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+		out.WriteString(")\n\n")
+	}
+	for _, h := range goHelpers {
+		if g.helpers[h.name] {
+			out.WriteString(h.code + "\n")
+		}
+	}
+	out.WriteString(g.b.String())
+
+	src, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return "", fmt.Errorf("format generated code: %w", err)
+	}
+	return string(src), nil
+}
+
+func (g *goGen) use(helper string) {
+	g.helpers[helper] = true
+	if helper == "LexBlob" {
+		g.helpers["LexLink"] = true
+	}
+}
+
+func (g *goGen) doc(text string) {
+	if text == "" {
+		g.b.WriteString("//\n")
+		return
+	}
+	fmt.Fprintf(&g.b, "// %s\n", text)
+}
+
+func (g *goGen) definition(nsid, name string, def Definition) {
+	tn := typeName(nsid, name)
+	ref := refName(nsid, name)
+	switch def.Type {
+	case "record":
+		if def.Record == nil {
+			return
+		}
+		desc := def.Description
+		if desc == "" {
+			desc = def.Record.Description
+		}
+		g.doc(schemaDoc(tn+" is the "+ref+" record", desc))
+		g.object(tn, nsid, *def.Record, true)
+	case "query", "procedure", "subscription":
+		if def.Parameters != nil && len(def.Parameters.Properties) > 0 {
+			g.named(tn+"_Params", nsid, *def.Parameters, schemaDoc(tn+"_Params are the parameters of "+ref, def.Parameters.Description))
+		}
+		if def.Input != nil && def.Input.Schema != nil {
+			g.named(tn+"_Input", nsid, *def.Input.Schema, schemaDoc(tn+"_Input is the input of "+ref, def.Input.Description))
+		}
+		if def.Output != nil && def.Output.Schema != nil {
+			g.named(tn+"_Output", nsid, *def.Output.Schema, schemaDoc(tn+"_Output is the output of "+ref, def.Output.Description))
+		}
+		if def.Message != nil && def.Message.Schema != nil {
+			g.named(tn+"_Message", nsid, *def.Message.Schema, schemaDoc(tn+"_Message is a message of "+ref, def.Message.Description))
+		}
+	case "token":
+		g.doc(schemaDoc(tn+" is the "+ref+" token", def.Description))
+		fmt.Fprintf(&g.b, "const %s = %q\n\n", tn, ref)
+	case "permission-set":
+	default:
+		g.named(tn, nsid, def.Property, schemaDoc(tn+" is the "+ref+" "+def.Type, def.Description))
+	}
+}
+
+// named emits a named type for a schema: a struct for objects and params, a
+// union type for unions and an alias for anything else.
+func (g *goGen) named(name, nsid string, p Property, doc string) {
+	switch p.Type {
+	case "object", "params":
+		g.doc(doc)
+		g.object(name, nsid, p, false)
+	case "union":
+		g.union(name, nsid, p, doc)
+	default:
+		typ := g.goType(name, nsid, p)
+		g.doc(doc)
+		if len(p.KnownValues) > 0 {
+			g.doc("")
+			g.doc("Known values: " + strings.Join(p.KnownValues, ", ") + ".")
+		}
+		fmt.Fprintf(&g.b, "type %s = %s\n\n", name, typ)
+	}
+}
+
+// object emits a struct. Optional and nullable fields are pointers, except
+// for slices and raw JSON, and optional fields are omitted when empty.
+func (g *goGen) object(name, nsid string, p Property, record bool) {
+	fmt.Fprintf(&g.b, "type %s struct {\n", name)
+	if record {
+		g.b.WriteString("\tLexiconTypeID string `json:\"$type,omitempty\"`\n")
+	}
+	for _, prop := range sortedProps(p.Properties) {
+		field := p.Properties[prop]
+		required := slices.Contains(p.Required, prop)
+		typ := g.goType(name+"_"+pascal(prop), nsid, field)
+		if (!required || slices.Contains(p.Nullable, prop)) && !strings.HasPrefix(typ, "[]") && typ != "json.RawMessage" && typ != "LexBytes" {
+			typ = "*" + typ
+		}
+		tag := prop
+		if !required {
+			tag += ",omitempty"
+		}
+		if d := oneLine(field.Description); d != "" {
+			fmt.Fprintf(&g.b, "\t// %s\n", d)
+		}
+		fmt.Fprintf(&g.b, "\t%s %s `json:%q`\n", pascal(prop), typ, tag)
+	}
+	g.b.WriteString("}\n\n")
+}
+
+// goType is the Go type of a field. Inline objects and unions are queued as
+// nested types called name.
+func (g *goGen) goType(name, nsid string, p Property) string {
+	switch p.Type {
+	case "string":
+		return "string"
+	case "integer":
+		return "int64"
+	case "boolean":
+		return "bool"
+	case "bytes", "cid-link", "blob":
+		helper := map[string]string{"bytes": "LexBytes", "cid-link": "LexLink", "blob": "LexBlob"}[p.Type]
+		g.use(helper)
+		return helper
+	case "array":
+		if p.Items != nil {
+			return "[]" + g.goType(name+"_Elem", nsid, *p.Items)
+		}
+	case "ref":
+		typ, token, ok := g.catalog.codegenRef(nsid, p.Ref)
+		if token {
+			return "string"
+		}
+		if ok {
+			return typ
+		}
+	case "union", "object":
+		g.nested = append(g.nested, nestedType{name: name, nsid: nsid, schema: p})
+		return name
+	}
+	g.imports["encoding/json"] = true
+	return "json.RawMessage"
+}
+
+// union emits a struct with a pointer field per member, encoded and decoded
+// by $type. Open unions keep members of other types as raw JSON in Unknown;
+// closed unions reject them. An empty doc lists the members.
+func (g *goGen) union(name, nsid string, p Property, doc string) {
+	type member struct{ ref, field string }
+	var members []member
+	for _, ref := range p.Refs {
+		typ, _, ok := g.catalog.codegenRef(nsid, ref)
+		if !ok || typ == "" {
+			continue
+		}
+		target, defName := SplitRef(nsid, ref)
+		m := member{refName(target, defName), typ}
+		if !slices.Contains(members, m) {
+			members = append(members, m)
+		}
+	}
+	g.imports["encoding/json"] = true
+	if len(members) > 0 {
+		g.use("lexTypedJSON")
+	}
+
+	if doc == "" {
+		refs := make([]string, len(members))
+		for i, m := range members {
+			refs[i] = m.ref
+		}
+		what := name + " is a union of " + strings.Join(refs, ", ")
+		if len(refs) == 0 {
+			what = name + " is a union of lexicon types"
+		}
+		doc = schemaDoc(what, p.Description)
+	}
+	g.doc(doc)
+	g.doc("")
+	if p.Closed {
+		g.doc("At most one member is set.")
+	} else {
+		g.doc("At most one member is set; Unknown holds members of other types.")
+	}
+	fmt.Fprintf(&g.b, "type %s struct {\n", name)
+	for _, m := range members {
+		fmt.Fprintf(&g.b, "\t%s *%s\n", m.field, m.field)
+	}
+	if !p.Closed {
+		g.b.WriteString("\tUnknown json.RawMessage\n")
+	}
+	g.b.WriteString("}\n\n")
+
+	fmt.Fprintf(&g.b, "func (u %s) MarshalJSON() ([]byte, error) {\n\tswitch {\n", name)
+	for _, m := range members {
+		fmt.Fprintf(&g.b, "\tcase u.%s != nil:\n\t\treturn lexTypedJSON(%q, u.%s)\n", m.field, m.ref, m.field)
+	}
+	g.b.WriteString("\t}\n")
+	if !p.Closed {
+		g.b.WriteString("\tif u.Unknown != nil {\n\t\treturn u.Unknown, nil\n\t}\n")
+	}
+	g.b.WriteString("\treturn []byte(\"null\"), nil\n}\n\n")
+
+	fmt.Fprintf(&g.b, "func (u *%s) UnmarshalJSON(data []byte) error {\n", name)
+	g.b.WriteString("\tvar probe struct {\n\t\tType string `json:\"$type\"`\n\t}\n")
+	g.b.WriteString("\tif err := json.Unmarshal(data, &probe); err != nil {\n\t\treturn err\n\t}\n")
+	fmt.Fprintf(&g.b, "\t*u = %s{}\n\tswitch probe.Type {\n", name)
+	for _, m := range members {
+		fmt.Fprintf(&g.b, "\tcase %q:\n\t\tu.%s = new(%s)\n\t\treturn json.Unmarshal(data, u.%s)\n", m.ref, m.field, m.field, m.field)
+	}
+	g.b.WriteString("\t}\n")
+	if p.Closed {
+		g.imports["fmt"] = true
+		fmt.Fprintf(&g.b, "\treturn fmt.Errorf(\"%s: unexpected $type %%q\", probe.Type)\n}\n\n", name)
+	} else {
+		g.b.WriteString("\tu.Unknown = append(json.RawMessage(nil), data...)\n\treturn nil\n}\n\n")
+	}
+}
@@ -0,0 +1,136 @@
+package atproto
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+// timelineLexicon is a query whose output uses a closed union, an inline
+// object, a token and a ref to a lexicon that is not in the catalog.
+const timelineLexicon = `{
+  "lexicon": 1,
+  "id": "app.bsky.feed.getTimeline",
+  "defs": {
+    "main": {
+      "type": "query",
+      "parameters": {"type": "params", "properties": {"limit": {"type": "integer", "minimum": 1}, "cursor": {"type": "string"}}},
+      "output": {
+        "encoding": "application/json",
+        "schema": {
+          "type": "object",
+          "required": ["feed"],
+          "properties": {
+            "cursor": {"type": "string"},
+            "feed": {"type": "array", "items": {"type": "union", "refs": ["app.bsky.feed.post", "#skip"], "closed": true}},
+            "sort": {"type": "string", "knownValues": ["new", "old"]},
+            "reason": {"type": "ref", "ref": "#reasonPin"},
+            "extra": {"type": "ref", "ref": "com.example.missing#extra"},
+            "meta": {"type": "object", "properties": {"count": {"type": "integer"}, "data": {"type": "bytes"}}}
+          }
+        }
+      }
+    },
+    "skip": {
+      "type": "object",
+      "required": ["reason"],
+      "nullable": ["reason"],
+      "properties": {"reason": {"type": "string", "enum": ["muted", "blocked"]}}
+    },
+    "reasonPin": {"type": "token", "description": "The post is pinned."}
+  }
+}`
+
+func TestCodegenGo(t *testing.T) {
+	catalog := testCatalog(t, postLexicon, strongRefLexicon, imagesLexicon, timelineLexicon)
+	code, err := catalog.Codegen([]string{"app.bsky.feed.getTimeline"}, CodegenOptions{Lang: LangGo, Package: "bsky"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "lexicons.go", code, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, code)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("bsky", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated code does not type-check: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		"package bsky",
+		"type AppBskyFeedGetTimeline_Params struct {\n\tCursor *string `json:\"cursor,omitempty\"`\n\tLimit  *int64  `json:\"limit,omitempty\"`\n}",
+		"Feed   []AppBskyFeedGetTimeline_Output_Feed_Elem `json:\"feed\"`",
+		"Extra  json.RawMessage ",
+		"Reason *string ",
+		"// AppBskyFeedGetTimeline_Output_Feed_Elem is a union of app.bsky.feed.post, app.bsky.feed.getTimeline#skip.",
+		"return fmt.Errorf(\"AppBskyFeedGetTimeline_Output_Feed_Elem: unexpected $type %q\", probe.Type)",
+		"type AppBskyFeedGetTimeline_Output_Meta struct {",
+		"Data  LexBytes `json:\"data,omitempty\"`",
+		"// AppBskyFeedGetTimeline_ReasonPin is the app.bsky.feed.getTimeline#reasonPin token: The post is pinned.\nconst AppBskyFeedGetTimeline_ReasonPin = \"app.bsky.feed.getTimeline#reasonPin\"",
+		"Reason *string `json:\"reason\"`",
+		"LexiconTypeID string ",
+		"\tAppBskyEmbedImages *AppBskyEmbedImages\n\tUnknown            json.RawMessage\n",
+		"Image LexBlob `json:\"image\"`",
+		"Root   ComAtprotoRepoStrongRef `json:\"root\"`",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated Go is missing %q\n%s", want, code)
+		}
+	}
+	if strings.Index(code, "type AppBskyEmbedImages struct") > strings.Index(code, "type ComAtprotoRepoStrongRef struct") {
+		t.Error("definitions are not ordered by NSID")
+	}
+}
+
+func TestCodegenTS(t *testing.T) {
+	catalog := testCatalog(t, postLexicon, strongRefLexicon, imagesLexicon, timelineLexicon)
+	code, err := catalog.Codegen([]string{"app.bsky.feed.getTimeline"}, CodegenOptions{Lang: LangTS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"export type $Typed<T, N extends string> = T & { $type: N };",
+		"export interface AppBskyFeedGetTimeline_Params {\n  cursor?: string;\n  limit?: number;\n}",
+		`  feed: ($Typed<AppBskyFeedPost, "app.bsky.feed.post"> | $Typed<AppBskyFeedGetTimeline_Skip, "app.bsky.feed.getTimeline#skip">)[];`,
+		"  extra?: unknown;",
+		`  sort?: "new" | "old" | (string & {});`,
+		"  meta?: AppBskyFeedGetTimeline_Output_Meta;",
+		"  data?: LexBytes;",
+		`export const AppBskyFeedGetTimeline_ReasonPin = "app.bsky.feed.getTimeline#reasonPin";`,
+		`  reason: "muted" | "blocked" | null;`,
+		`  $type?: "app.bsky.feed.post";`,
+		`  embed?: $Typed<AppBskyEmbedImages, "app.bsky.embed.images"> | { $type: string };`,
+		"  image: LexBlob;",
+		"export interface LexLink {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated TypeScript is missing %q\n%s", want, code)
+		}
+	}
+
+	if _, err := catalog.Codegen([]string{"app.bsky.feed.getTimeline"}, CodegenOptions{Lang: "rust"}); err == nil {
+		t.Error("expected an error for an unsupported language")
+	}
+	if _, err := catalog.Codegen([]string{"com.example.missing"}, CodegenOptions{Lang: LangTS}); err == nil {
+		t.Error("expected an error for a lexicon that is not in the catalog")
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	tests := map[[2]string]string{
+		{"app.bsky.feed.post", "main"}:       "AppBskyFeedPost",
+		{"app.bsky.feed.defs", "postView"}:   "AppBskyFeedDefs_PostView",
+		{"com.example.my-app.thing", "main"}: "ComExampleMyAppThing",
+	}
+	for in, want := range tests {
+		if got := typeName(in[0], in[1]); got != want {
+			t.Errorf("typeName(%q, %q) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
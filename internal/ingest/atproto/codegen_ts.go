@@ -0,0 +1,230 @@
+package atproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tsHelpers are the support types generated TypeScript may use, in output
+// order.
+var tsHelpers = []struct {
+	name string
+	code string
+}{
+	{"$Typed", "/** A union member tagged with its $type. */\nexport type $Typed<T, N extends string> = T & { $type: N };\n"},
+	{"LexLink", "/** A CID link. */\nexport interface LexLink {\n  $link: string;\n}\n"},
+	{"LexBlob", "/** A reference to a blob stored in a repository. */\nexport interface LexBlob {\n  $type: \"blob\";\n  ref: LexLink;\n  mimeType: string;\n  size: number;\n}\n"},
+	{"LexBytes", "/** Binary data, base64 encoded. */\nexport interface LexBytes {\n  $bytes: string;\n}\n"},
+}
+
+var tsIdent = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+type tsGen struct {
+	catalog Catalog
+	b       strings.Builder
+	nested  []nestedType
+	helpers map[string]bool
+}
+
+func (c Catalog) generateTS(defs []defKey) string {
+	g := &tsGen{catalog: c, helpers: map[string]bool{}}
+	for _, key := range defs {
+		def, _ := c.Definition(key.nsid, key.name)
+		g.definition(key.nsid, key.name, def)
+		for len(g.nested) > 0 {
+			n := g.nested[0]
+			g.nested = g.nested[1:]
+			g.named(n.name, n.nsid, n.schema, "", schemaDoc("Inline object of "+n.nsid, n.schema.Description))
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("// Code generated by documango atproto codegen. DO NOT EDIT.\n\n")
+	for _, h := range tsHelpers {
+		if g.helpers[h.name] {
+			out.WriteString(h.code + "\n")
+		}
+	}
+	out.WriteString(g.b.String())
+	return strings.TrimRight(out.String(), "\n") + "\n"
+}
+
+func (g *tsGen) use(helper string) {
+	g.helpers[helper] = true
+	if helper == "LexBlob" {
+		g.helpers["LexLink"] = true
+	}
+}
+
+func (g *tsGen) doc(indent, text string) {
+	fmt.Fprintf(&g.b, "%s/** %s */\n", indent, strings.ReplaceAll(text, "*/", "*\\/"))
+}
+
+func (g *tsGen) definition(nsid, name string, def Definition) {
+	tn := typeName(nsid, name)
+	ref := refName(nsid, name)
+	switch def.Type {
+	case "record":
+		if def.Record == nil {
+			return
+		}
+		desc := def.Description
+		if desc == "" {
+			desc = def.Record.Description
+		}
+		g.doc("", schemaDoc("The "+ref+" record", desc))
+		g.object(tn, nsid, *def.Record, ref)
+	case "query", "procedure", "subscription":
+		if def.Parameters != nil && len(def.Parameters.Properties) > 0 {
+			g.named(tn+"_Params", nsid, *def.Parameters, "", schemaDoc("Parameters of "+ref, def.Parameters.Description))
+		}
+		if def.Input != nil && def.Input.Schema != nil {
+			g.named(tn+"_Input", nsid, *def.Input.Schema, "", schemaDoc("Input of "+ref, def.Input.Description))
+		}
+		if def.Output != nil && def.Output.Schema != nil {
+			g.named(tn+"_Output", nsid, *def.Output.Schema, "", schemaDoc("Output of "+ref, def.Output.Description))
+		}
+		if def.Message != nil && def.Message.Schema != nil {
+			g.named(tn+"_Message", nsid, *def.Message.Schema, "", schemaDoc("Message of "+ref, def.Message.Description))
+		}
+	case "token":
+		g.doc("", schemaDoc("The "+ref+" token", def.Description))
+		fmt.Fprintf(&g.b, "export const %s = %s;\n\n", tn, tsLiteral(ref))
+	case "permission-set":
+	default:
+		g.named(tn, nsid, def.Property, ref, schemaDoc("The "+ref+" "+def.Type, def.Description))
+	}
+}
+
+// named emits an interface for objects and params and a type alias for
+// anything else. Objects with a ref name accept it as their optional $type.
+func (g *tsGen) named(name, nsid string, p Property, ref, doc string) {
+	g.doc("", doc)
+	switch p.Type {
+	case "object", "params":
+		g.object(name, nsid, p, ref)
+	default:
+		fmt.Fprintf(&g.b, "export type %s = %s;\n\n", name, g.tsType(name, nsid, p))
+	}
+}
+
+func (g *tsGen) object(name, nsid string, p Property, ref string) {
+	fmt.Fprintf(&g.b, "export interface %s {\n", name)
+	if ref != "" {
+		fmt.Fprintf(&g.b, "  $type?: %s;\n", tsLiteral(ref))
+	}
+	for _, prop := range sortedProps(p.Properties) {
+		field := p.Properties[prop]
+		typ := g.tsType(name+"_"+pascal(prop), nsid, field)
+		if slices.Contains(p.Nullable, prop) {
+			typ += " | null"
+		}
+		key := prop
+		if !tsIdent.MatchString(key) {
+			key = tsLiteral(key)
+		}
+		if !slices.Contains(p.Required, prop) {
+			key += "?"
+		}
+		if d := oneLine(field.Description); d != "" {
+			g.doc("  ", d)
+		}
+		fmt.Fprintf(&g.b, "  %s: %s;\n", key, typ)
+	}
+	g.b.WriteString("}\n\n")
+}
+
+// tsType is the TypeScript type of a field. Inline objects are queued as
+// nested interfaces called name; unions are written inline.
+func (g *tsGen) tsType(name, nsid string, p Property) string {
+	switch p.Type {
+	case "string":
+		switch {
+		case p.Const != nil:
+			return tsLiteral(p.Const)
+		case len(p.Enum) > 0:
+			return tsLiterals(p.Enum)
+		case len(p.KnownValues) > 0:
+			values := make([]any, len(p.KnownValues))
+			for i, v := range p.KnownValues {
+				values[i] = v
+			}
+			return tsLiterals(values) + " | (string & {})"
+		}
+		return "string"
+	case "integer":
+		switch {
+		case p.Const != nil:
+			return tsLiteral(p.Const)
+		case len(p.Enum) > 0:
+			return tsLiterals(p.Enum)
+		}
+		return "number"
+	case "boolean":
+		if p.Const != nil {
+			return tsLiteral(p.Const)
+		}
+		return "boolean"
+	case "bytes", "cid-link", "blob":
+		helper := map[string]string{"bytes": "LexBytes", "cid-link": "LexLink", "blob": "LexBlob"}[p.Type]
+		g.use(helper)
+		return helper
+	case "array":
+		if p.Items == nil {
+			return "unknown[]"
+		}
+		item := g.tsType(name+"_Elem", nsid, *p.Items)
+		if strings.ContainsAny(item, "|&") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "ref":
+		typ, token, ok := g.catalog.codegenRef(nsid, p.Ref)
+		if token {
+			return "string"
+		}
+		if ok {
+			return typ
+		}
+	case "union":
+		var members []string
+		for _, ref := range p.Refs {
+			typ, _, ok := g.catalog.codegenRef(nsid, ref)
+			if !ok || typ == "" {
+				continue
+			}
+			target, defName := SplitRef(nsid, ref)
+			g.use("$Typed")
+			member := fmt.Sprintf("$Typed<%s, %s>", typ, tsLiteral(refName(target, defName)))
+			if !slices.Contains(members, member) {
+				members = append(members, member)
+			}
+		}
+		if !p.Closed || len(members) == 0 {
+			members = append(members, "{ $type: string }")
+		}
+		return strings.Join(members, " | ")
+	case "object":
+		g.nested = append(g.nested, nestedType{name: name, nsid: nsid, schema: p})
+		return name
+	case "unknown":
+		return "{ [key: string]: unknown }"
+	}
+	return "unknown"
+}
+
+func tsLiteral(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func tsLiterals(values []any) string {
+	literals := make([]string, len(values))
+	for i, v := range values {
+		literals[i] = tsLiteral(v)
+	}
+	return strings.Join(literals, " | ")
+}
@@ -0,0 +1,211 @@
+package atproto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// exampleCID is a syntactically valid CIDv1 used for links and blobs.
+const exampleCID = "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm"
+
+// formatExamples are minimal valid values for each string format.
+var formatExamples = map[string]string{
+	"did":           "did:plc:ewvi7nxzyoun6zhxrhs64oiz",
+	"handle":        "alice.example.com",
+	"at-identifier": "alice.example.com",
+	"nsid":          "com.example.record",
+	"at-uri":        "at://did:plc:ewvi7nxzyoun6zhxrhs64oiz/com.example.record/3jzfcijpj2z2a",
+	"cid":           exampleCID,
+	"datetime":      "2024-01-01T00:00:00.000Z",
+	"language":      "en",
+	"uri":           "https://example.com",
+	"tid":           "3jzfcijpj2z2a",
+	"record-key":    "self",
+}
+
+// Example generates a minimal document that validates against the target:
+// required fields only, each set to the smallest value its constraints
+// allow. Records carry their $type and union members the $type of their
+// first ref.
+func (c Catalog) Example(t Target) (any, error) {
+	g := exampler{catalog: c}
+	value := g.value(t.NSID, t.Schema)
+	if g.err != nil {
+		return nil, g.err
+	}
+	if t.Part == PartRecord {
+		if obj, ok := value.(map[string]any); ok {
+			obj["$type"] = t.NSID
+		}
+	}
+	return value, nil
+}
+
+type exampler struct {
+	catalog Catalog
+	stack   []string
+	err     error
+}
+
+func (g *exampler) value(nsid string, p Property) any {
+	if g.err != nil {
+		return nil
+	}
+	switch p.Type {
+	case "object", "params":
+		obj := map[string]any{}
+		for _, name := range p.Required {
+			if prop, ok := p.Properties[name]; ok {
+				obj[name] = g.value(nsid, prop)
+			}
+		}
+		return obj
+	case "ref":
+		return g.ref(nsid, p.Ref)
+	case "union":
+		if len(p.Refs) == 0 {
+			return map[string]any{"$type": "com.example.unknown"}
+		}
+		value := g.ref(nsid, p.Refs[0])
+		if obj, ok := value.(map[string]any); ok {
+			target, name := SplitRef(nsid, p.Refs[0])
+			obj["$type"] = refName(target, name)
+		}
+		return value
+	case "array":
+		arr := []any{}
+		if p.Items != nil && p.MinLength != nil {
+			for range *p.MinLength {
+				arr = append(arr, g.value(nsid, *p.Items))
+			}
+		}
+		return arr
+	case "string":
+		return exampleString(p)
+	case "integer":
+		return exampleInteger(p)
+	case "boolean":
+		if b, ok := p.Const.(bool); ok {
+			return b
+		}
+		if b, ok := p.Default.(bool); ok {
+			return b
+		}
+		return false
+	case "bytes":
+		n := 0
+		if p.MinLength != nil {
+			n = *p.MinLength
+		}
+		return map[string]any{"$bytes": base64.RawStdEncoding.EncodeToString(make([]byte, n))}
+	case "cid-link":
+		return map[string]any{"$link": exampleCID}
+	case "blob":
+		mime := "application/octet-stream"
+		if len(p.Accept) > 0 {
+			mime = exampleMIME(p.Accept[0])
+		}
+		return map[string]any{
+			"$type":    "blob",
+			"ref":      map[string]any{"$link": exampleCID},
+			"mimeType": mime,
+			"size":     1,
+		}
+	case "unknown":
+		return map[string]any{}
+	}
+	g.err = fmt.Errorf("cannot generate an example for schema type %q", p.Type)
+	return nil
+}
+
+// ref generates the referenced definition, failing on a cycle of required
+// fields, which no finite document satisfies.
+func (g *exampler) ref(nsid, ref string) any {
+	target, name, def, ok := g.catalog.Resolve(nsid, ref)
+	if !ok {
+		g.err = fmt.Errorf("unresolved ref %s", ref)
+		return nil
+	}
+	full := refName(target, name)
+	for _, seen := range g.stack {
+		if seen == full {
+			g.err = fmt.Errorf("required fields form a cycle through %s", full)
+			return nil
+		}
+	}
+	g.stack = append(g.stack, full)
+	defer func() { g.stack = g.stack[:len(g.stack)-1] }()
+
+	schema := def.Property
+	if def.Type == "record" && def.Record != nil {
+		schema = *def.Record
+	}
+	return g.value(target, schema)
+}
+
+func exampleString(p Property) string {
+	if s, ok := p.Const.(string); ok {
+		return s
+	}
+	if len(p.Enum) > 0 {
+		return fmt.Sprint(p.Enum[0])
+	}
+	if s, ok := p.Default.(string); ok {
+		return s
+	}
+	if len(p.KnownValues) > 0 {
+		return p.KnownValues[0]
+	}
+	if s, ok := formatExamples[p.Format]; ok {
+		return s
+	}
+	n := 0
+	if p.MinLength != nil {
+		n = *p.MinLength
+	}
+	if p.MinGraphemes != nil && *p.MinGraphemes > n {
+		n = *p.MinGraphemes
+	}
+	return strings.Repeat("a", n)
+}
+
+func exampleInteger(p Property) any {
+	if p.Const != nil {
+		return p.Const
+	}
+	if len(p.Enum) > 0 {
+		return p.Enum[0]
+	}
+	if p.Default != nil {
+		return p.Default
+	}
+	n := 0
+	if p.Minimum != nil && *p.Minimum > n {
+		n = *p.Minimum
+	}
+	if p.Maximum != nil && *p.Maximum < n {
+		n = *p.Maximum
+	}
+	return n
+}
+
+// exampleMIME picks a concrete type for an accept pattern such as image/*.
+func exampleMIME(accept string) string {
+	switch accept {
+	case "*/*":
+		return "application/octet-stream"
+	case "image/*":
+		return "image/png"
+	case "video/*":
+		return "video/mp4"
+	case "audio/*":
+		return "audio/mpeg"
+	case "text/*":
+		return "text/plain"
+	}
+	if prefix, ok := strings.CutSuffix(accept, "/*"); ok {
+		return prefix + "/octet-stream"
+	}
+	return accept
+}
@@ -6,46 +6,132 @@ import (
 	"strings"
 )
 
+// Lexicon is a lexicon schema document. Each definition in Defs is either a
+// primary type (record, query, procedure, subscription, permission-set), which
+// may only appear as "main", or a reusable field type referenced from other
+// definitions and lexicons.
 type Lexicon struct {
-	Lexicon int                   `json:"lexicon"`
-	ID      string                `json:"id"`
-	Defs    map[string]Definition `json:"defs"`
+	Lexicon     int                   `json:"lexicon"`
+	ID          string                `json:"id"`
+	Revision    int                   `json:"revision,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Defs        map[string]Definition `json:"defs"`
 }
 
+// Definition is a named definition of a lexicon. Field type definitions
+// (object, string, token, array, ...) use the embedded Property; primary types
+// add their own fields.
 type Definition struct {
-	Type        string              `json:"type"`
-	Description string              `json:"description,omitempty"`
-	Parameters  *Schema             `json:"parameters,omitempty"`
-	Input       *Schema             `json:"input,omitempty"`
-	Output      *Schema             `json:"output,omitempty"`
-	Record      *Schema             `json:"record,omitempty"`
-	Properties  map[string]Property `json:"properties,omitempty"`
-	Required    []string            `json:"required,omitempty"`
+	Property
+
+	// record
+	Key    string    `json:"key,omitempty"`
+	Record *Property `json:"record,omitempty"`
+
+	// query, procedure and subscription
+	Parameters *Property `json:"parameters,omitempty"`
+	Input      *Body     `json:"input,omitempty"`
+	Output     *Body     `json:"output,omitempty"`
+	Message    *Message  `json:"message,omitempty"`
+	Errors     []Error   `json:"errors,omitempty"`
+
+	// permission-set
+	Title       string       `json:"title,omitempty"`
+	Detail      string       `json:"detail,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
 }
 
-type Schema struct {
-	Type        string              `json:"type"`
-	Properties  map[string]Property `json:"properties,omitempty"`
-	Required    []string            `json:"required,omitempty"`
-	Description string              `json:"description,omitempty"`
+// Body is the input or output of a query or procedure. Schema is usually an
+// object, ref or union; it is absent for non-JSON encodings.
+type Body struct {
+	Description string    `json:"description,omitempty"`
+	Encoding    string    `json:"encoding"`
+	Schema      *Property `json:"schema,omitempty"`
 }
 
-type Property struct {
-	Type        string    `json:"type"`
+// Message is the message schema of a subscription, a union of event types.
+type Message struct {
 	Description string    `json:"description,omitempty"`
-	Ref         string    `json:"ref,omitempty"`
-	Refs        []string  `json:"refs,omitempty"`
-	Items       *Property `json:"items,omitempty"`
-	Format      string    `json:"format,omitempty"`
-	Minimum     *int      `json:"minimum,omitempty"`
-	Maximum     *int      `json:"maximum,omitempty"`
-	MinLength   *int      `json:"minLength,omitempty"`
-	MaxLength   *int      `json:"maxLength,omitempty"`
+	Schema      *Property `json:"schema,omitempty"`
+}
+
+// Error is a named error an endpoint may return.
+type Error struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Permission is an entry of a permission-set.
+type Permission struct {
+	Type       string   `json:"type"`
+	Resource   string   `json:"resource"`
+	Collection []string `json:"collection,omitempty"`
+	Action     []string `json:"action,omitempty"`
+	Lxm        []string `json:"lxm,omitempty"`
+	Aud        string   `json:"aud,omitempty"`
+	InheritAud bool     `json:"inheritAud,omitempty"`
+}
+
+// Property is a field type: the schema of an object property, array item,
+// params entry or body, and the base of every definition.
+type Property struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+
+	// ref and union
+	Ref    string   `json:"ref,omitempty"`
+	Refs   []string `json:"refs,omitempty"`
+	Closed bool     `json:"closed,omitempty"`
+
+	// object and params
+	Properties map[string]Property `json:"properties,omitempty"`
+	Required   []string            `json:"required,omitempty"`
+	Nullable   []string            `json:"nullable,omitempty"`
+
+	// array
+	Items *Property `json:"items,omitempty"`
+
+	// string, integer, bytes and array limits
+	Format       string `json:"format,omitempty"`
+	Minimum      *int   `json:"minimum,omitempty"`
+	Maximum      *int   `json:"maximum,omitempty"`
+	MinLength    *int   `json:"minLength,omitempty"`
+	MaxLength    *int   `json:"maxLength,omitempty"`
+	MinGraphemes *int   `json:"minGraphemes,omitempty"`
+	MaxGraphemes *int   `json:"maxGraphemes,omitempty"`
+
+	// value restrictions; Enum, Const and Default hold strings, integers or
+	// booleans depending on the type
+	KnownValues []string `json:"knownValues,omitempty"`
+	Enum        []any    `json:"enum,omitempty"`
+	Const       any      `json:"const,omitempty"`
+	Default     any      `json:"default,omitempty"`
+
+	// blob
+	Accept  []string `json:"accept,omitempty"`
+	MaxSize *int     `json:"maxSize,omitempty"`
+}
+
+// RenderOptions controls how refs are rendered. With a Catalog, refs become
+// links to the definition's document and anchor, and ExpandDepth levels of
+// referenced objects are expanded inline below the table that uses them.
+type RenderOptions struct {
+	Catalog     Catalog
+	ExpandDepth int
 }
 
 func LexiconToMarkdown(lex *Lexicon) string {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("# %s\n\n", lex.ID))
+	return RenderLexicon(lex, RenderOptions{})
+}
+
+// RenderLexicon renders a lexicon with "main" first and the other
+// definitions sorted by name.
+func RenderLexicon(lex *Lexicon, opts RenderOptions) string {
+	r := renderer{opts: opts, nsid: lex.ID}
+	r.sb.WriteString(fmt.Sprintf("# %s\n\n", lex.ID))
+	if lex.Description != "" {
+		r.sb.WriteString(lex.Description + "\n\n")
+	}
 
 	keys := make([]string, 0, len(lex.Defs))
 	for k := range lex.Defs {
@@ -54,103 +140,412 @@ func LexiconToMarkdown(lex *Lexicon) string {
 	sort.Strings(keys)
 
 	if def, ok := lex.Defs["main"]; ok {
-		renderDefinition(&sb, lex.ID, "main", def)
+		r.definition("main", def)
 	}
 
 	for _, k := range keys {
 		if k == "main" {
 			continue
 		}
-		renderDefinition(&sb, lex.ID, k, lex.Defs[k])
+		r.definition(k, lex.Defs[k])
 	}
 
-	return sb.String()
+	return r.sb.String()
 }
 
-func renderDefinition(sb *strings.Builder, nsid, name string, def Definition) {
-	if name == "main" {
-		sb.WriteString(fmt.Sprintf("## Definition: %s\n\n", nsid))
-	} else {
-		sb.WriteString(fmt.Sprintf("## Definition: %s#%s\n\n", nsid, name))
-	}
+type renderer struct {
+	sb   strings.Builder
+	opts RenderOptions
+	nsid string
+}
+
+func (r *renderer) definition(name string, def Definition) {
+	sb := &r.sb
+	sb.WriteString("## " + defHeading(r.nsid, name) + "\n\n")
 
+	if def.Title != "" {
+		sb.WriteString("**" + def.Title + "**\n\n")
+	}
 	if def.Description != "" {
 		sb.WriteString(def.Description + "\n\n")
 	}
+	if def.Detail != "" {
+		sb.WriteString(def.Detail + "\n\n")
+	}
 
 	fmt.Fprintf(sb, "- **Type**: %s\n", def.Type)
+	if def.Key != "" {
+		fmt.Fprintf(sb, "- **Key**: %s\n", def.Key)
+	}
+	switch def.Type {
+	case "record", "query", "procedure", "subscription", "object", "params", "permission-set":
+	default:
+		// Field type definitions: strings with knownValues, tokens, arrays,
+		// unions, blobs and so on.
+		if def.Type == "array" || def.Type == "ref" || def.Type == "union" {
+			fmt.Fprintf(sb, "- **Schema**: %s\n", r.typeLabel(def.Property))
+		}
+		for _, c := range r.constraints(def.Property) {
+			fmt.Fprintf(sb, "- **%s**: %s\n", c.label, c.value)
+		}
+	}
 
 	switch def.Type {
 	case "record":
 		if def.Record != nil {
 			sb.WriteString("\n### Record Properties\n\n")
-			renderProperties(sb, def.Record.Properties, def.Record.Required)
+			r.object(*def.Record, 0)
 		}
-	case "query", "procedure":
+	case "query", "procedure", "subscription":
 		if def.Parameters != nil && len(def.Parameters.Properties) > 0 {
 			sb.WriteString("\n### Parameters\n\n")
-			renderProperties(sb, def.Parameters.Properties, def.Parameters.Required)
+			r.object(*def.Parameters, 0)
 		}
-		if def.Input != nil && def.Input.Type == "object" && len(def.Input.Properties) > 0 {
-			sb.WriteString("\n### Input\n\n")
-			renderProperties(sb, def.Input.Properties, def.Input.Required)
+		r.body("Input", def.Input)
+		r.body("Output", def.Output)
+		if def.Message != nil {
+			sb.WriteString("\n### Message\n\n")
+			if def.Message.Description != "" {
+				sb.WriteString(def.Message.Description + "\n\n")
+			}
+			if def.Message.Schema != nil {
+				r.schema(*def.Message.Schema)
+			}
 		}
-		if def.Output != nil && def.Output.Type == "object" && len(def.Output.Properties) > 0 {
-			sb.WriteString("\n### Output\n\n")
-			renderProperties(sb, def.Output.Properties, def.Output.Required)
+		if len(def.Errors) > 0 {
+			sb.WriteString("\n### Errors\n\n")
+			for _, e := range def.Errors {
+				if e.Description != "" {
+					fmt.Fprintf(sb, "- `%s`: %s\n", e.Name, oneLine(e.Description))
+				} else {
+					fmt.Fprintf(sb, "- `%s`\n", e.Name)
+				}
+			}
 		}
-	case "object":
+	case "object", "params":
 		sb.WriteString("\n### Properties\n\n")
-		renderProperties(sb, def.Properties, def.Required)
+		r.object(def.Property, 0)
+	case "permission-set":
+		if len(def.Permissions) > 0 {
+			sb.WriteString("\n### Permissions\n\n")
+			for _, p := range def.Permissions {
+				sb.WriteString("- " + r.permission(p) + "\n")
+			}
+		}
 	}
 
 	sb.WriteString("\n")
 }
 
-func renderProperties(sb *strings.Builder, props map[string]Property, required []string) {
+// body renders a query or procedure input or output.
+func (r *renderer) body(title string, b *Body) {
+	if b == nil {
+		return
+	}
+	sb := &r.sb
+	sb.WriteString("\n### " + title + "\n\n")
+	if b.Description != "" {
+		sb.WriteString(b.Description + "\n\n")
+	}
+	if b.Encoding != "" {
+		fmt.Fprintf(sb, "- **Encoding**: `%s`\n", b.Encoding)
+	}
+	if b.Schema != nil {
+		r.schema(*b.Schema)
+	}
+}
+
+// schema renders a body or message schema: an object as a table, anything
+// else as a type line with its referenced objects expanded.
+func (r *renderer) schema(p Property) {
+	if p.Type == "object" {
+		if b := r.sb.String(); !strings.HasSuffix(b, "\n\n") {
+			r.sb.WriteString("\n")
+		}
+		r.object(p, 0)
+		return
+	}
+	fmt.Fprintf(&r.sb, "- **Schema**: %s\n", r.typeLabel(p))
+	r.expand("", p, r.nsid, 0)
+}
+
+func (r *renderer) permission(p Permission) string {
+	var parts []string
+	if len(p.Collection) > 0 {
+		parts = append(parts, "collection "+codeList(p.Collection))
+	}
+	if len(p.Action) > 0 {
+		parts = append(parts, "action "+strings.Join(p.Action, ", "))
+	}
+	if len(p.Lxm) > 0 {
+		lxm := make([]string, len(p.Lxm))
+		for i, m := range p.Lxm {
+			lxm[i] = r.refLink(m)
+		}
+		parts = append(parts, "methods "+strings.Join(lxm, ", "))
+	}
+	if p.Aud != "" {
+		parts = append(parts, "audience `"+p.Aud+"`")
+	}
+	if p.InheritAud {
+		parts = append(parts, "inherits audience")
+	}
+	line := "**" + p.Resource + "**"
+	if len(parts) > 0 {
+		line += ": " + strings.Join(parts, "; ")
+	}
+	return line
+}
+
+// object renders the properties of an object or params schema as a table,
+// followed by the expansions of the objects it references.
+func (r *renderer) object(obj Property, depth int) {
+	r.properties(obj.Properties, obj.Required, obj.Nullable)
+	if r.opts.ExpandDepth > depth {
+		for _, k := range sortedProps(obj.Properties) {
+			r.expand(k, obj.Properties[k], r.nsid, depth)
+		}
+	}
+}
+
+func (r *renderer) properties(props map[string]Property, required, nullable []string) {
 	if len(props) == 0 {
 		return
 	}
 
 	reqMap := make(map[string]bool)
-	for _, r := range required {
-		reqMap[r] = true
+	for _, n := range required {
+		reqMap[n] = true
+	}
+	nullMap := make(map[string]bool)
+	for _, n := range nullable {
+		nullMap[n] = true
 	}
 
+	sb := &r.sb
 	sb.WriteString("| Name | Type | Required | Description |\n")
 	sb.WriteString("| ---- | ---- | -------- | ----------- |\n")
 
-	keys := make([]string, 0, len(props))
-	for k := range props {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	for _, k := range keys {
+	for _, k := range sortedProps(props) {
 		p := props[k]
-		typeStr := p.Type
-		if p.Ref != "" {
-			typeStr = fmt.Sprintf("ref(%s)", p.Ref)
-		} else if p.Type == "union" {
-			typeStr = fmt.Sprintf("union(%s)", strings.Join(p.Refs, ", "))
-		} else if p.Type == "array" && p.Items != nil {
-			itemType := p.Items.Type
-			if p.Items.Ref != "" {
-				itemType = fmt.Sprintf("ref(%s)", p.Items.Ref)
-			}
-			typeStr = fmt.Sprintf("array of %s", itemType)
-		}
 
 		req := "No"
 		if reqMap[k] {
 			req = "Yes"
 		}
 
-		desc := p.Description
-		if p.Format != "" {
-			desc = fmt.Sprintf("(Format: %s) %s", p.Format, desc)
+		var notes []string
+		if nullMap[k] {
+			notes = append(notes, "Nullable")
+		}
+		for _, c := range r.constraints(p) {
+			notes = append(notes, c.label+": "+c.value)
+		}
+		desc := oneLine(p.Description)
+		if len(notes) > 0 {
+			desc = fmt.Sprintf("(%s) %s", strings.Join(notes, "; "), desc)
+		}
+
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", k, cell(r.typeLabel(p)), req, cell(desc)))
+	}
+}
+
+// expand writes the objects a property references, depth levels deep, each
+// under a bold label naming the property and the definition.
+func (r *renderer) expand(name string, p Property, from string, depth int) {
+	if r.opts.Catalog == nil || r.opts.ExpandDepth <= depth {
+		return
+	}
+	var refs []string
+	switch {
+	case p.Ref != "":
+		refs = []string{p.Ref}
+	case p.Type == "union":
+		refs = p.Refs
+	case p.Items != nil && p.Items.Ref != "":
+		refs = []string{p.Items.Ref}
+	case p.Items != nil && p.Items.Type == "union":
+		refs = p.Items.Refs
+	}
+
+	for _, ref := range refs {
+		nsid, defName, def, ok := r.opts.Catalog.Resolve(from, ref)
+		if !ok || def.Type != "object" || len(def.Properties) == 0 {
+			continue
+		}
+		label := "`" + refName(nsid, defName) + "`"
+		if name != "" {
+			label = "`" + name + "` → " + label
+		}
+		r.sb.WriteString("\n**" + label + "**")
+		if def.Description != "" {
+			r.sb.WriteString(": " + oneLine(def.Description))
+		}
+		r.sb.WriteString("\n\n")
+
+		// Refs inside the expanded object are relative to its lexicon.
+		outer := r.nsid
+		r.nsid = nsid
+		r.properties(def.Properties, def.Required, def.Nullable)
+		for _, k := range sortedProps(def.Properties) {
+			child := k
+			if name != "" {
+				child = name + "." + k
+			}
+			r.expand(child, def.Properties[k], nsid, depth+1)
+		}
+		r.nsid = outer
+	}
+}
+
+// typeLabel is the Type column of a property: its type, with refs rendered
+// as links when they resolve.
+func (r *renderer) typeLabel(p Property) string {
+	switch {
+	case p.Ref != "":
+		return r.refLink(p.Ref)
+	case p.Type == "union":
+		refs := make([]string, len(p.Refs))
+		for i, ref := range p.Refs {
+			refs[i] = r.refLink(ref)
+		}
+		return fmt.Sprintf("union(%s)", strings.Join(refs, ", "))
+	case p.Type == "array" && p.Items != nil:
+		return "array of " + r.typeLabel(*p.Items)
+	}
+	return p.Type
+}
+
+// refLink renders a ref as a link to its definition, or as ref(...) when the
+// target is not in the catalog.
+func (r *renderer) refLink(ref string) string {
+	nsid, name, _, ok := r.opts.Catalog.Resolve(r.nsid, ref)
+	if !ok {
+		return fmt.Sprintf("ref(%s)", ref)
+	}
+	return fmt.Sprintf("[%s](%s)", ref, RefURL(nsid, name))
+}
+
+type constraint struct {
+	label, value string
+}
+
+// constraints lists the restrictions of a field type in the order they are
+// rendered.
+func (r *renderer) constraints(p Property) []constraint {
+	var out []constraint
+	add := func(label, value string) {
+		out = append(out, constraint{label, value})
+	}
+	intValue := func(label string, v *int) {
+		if v != nil {
+			add(label, fmt.Sprint(*v))
 		}
+	}
 
-		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", k, typeStr, req, desc))
+	if p.Format != "" {
+		add("Format", p.Format)
 	}
+	intValue("Min length", p.MinLength)
+	intValue("Max length", p.MaxLength)
+	intValue("Min graphemes", p.MinGraphemes)
+	intValue("Max graphemes", p.MaxGraphemes)
+	intValue("Minimum", p.Minimum)
+	intValue("Maximum", p.Maximum)
+	if len(p.KnownValues) > 0 {
+		values := make([]string, len(p.KnownValues))
+		for i, v := range p.KnownValues {
+			values[i] = r.knownValue(v)
+		}
+		add("Known values", strings.Join(values, ", "))
+	}
+	if len(p.Enum) > 0 {
+		values := make([]string, len(p.Enum))
+		for i, v := range p.Enum {
+			values[i] = "`" + fmt.Sprint(v) + "`"
+		}
+		add("Enum", strings.Join(values, ", "))
+	}
+	if p.Const != nil {
+		add("Const", "`"+fmt.Sprint(p.Const)+"`")
+	}
+	if p.Default != nil {
+		add("Default", "`"+fmt.Sprint(p.Default)+"`")
+	}
+	if len(p.Accept) > 0 {
+		add("Accept", codeList(p.Accept))
+	}
+	intValue("Max size", p.MaxSize)
+	if p.Closed {
+		add("Closed", "yes")
+	}
+	return out
+}
+
+// knownValue links a known value that names a token definition, such as
+// app.bsky.graph.defs#modlist, and quotes anything else.
+func (r *renderer) knownValue(v string) string {
+	if r.opts.Catalog != nil {
+		if nsid, name, def, ok := r.opts.Catalog.Resolve(r.nsid, v); ok && def.Type == "token" {
+			return fmt.Sprintf("[%s](%s)", v, RefURL(nsid, name))
+		}
+	}
+	return "`" + v + "`"
+}
+
+// defHeading is the heading of a definition; RefURL anchors are derived from
+// it.
+func defHeading(nsid, name string) string {
+	return "Definition: " + refName(nsid, name)
+}
+
+func refName(nsid, name string) string {
+	if name == "main" {
+		return nsid
+	}
+	return nsid + "#" + name
+}
+
+// headingID mirrors the automatic heading IDs of the web renderer: ASCII
+// letters and digits lowercased, spaces, dashes and underscores turned into
+// dashes, everything else dropped.
+func headingID(heading string) string {
+	var b strings.Builder
+	for _, c := range strings.TrimSpace(heading) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			b.WriteRune(c + 'a' - 'A')
+		case c == ' ' || c == '-' || c == '_':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+func sortedProps(props map[string]Property) []string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func codeList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "`" + v + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// cell escapes a value for a Markdown table cell.
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
 }
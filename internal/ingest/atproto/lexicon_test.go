@@ -49,7 +49,7 @@ func TestLexiconToMarkdown(t *testing.T) {
 		"## Definition: app.bsky.feed.post",
 		"Record containing a Bluesky post.",
 		"| Name | Type | Required | Description |",
-		"| text | string | Yes | The primary post content. |",
+		"| text | string | Yes | (Max length: 3000) The primary post content. |",
 		"| langs | array of string | No |  |",
 		"| createdAt | string | Yes | (Format: datetime)  |",
 	}
@@ -60,3 +60,178 @@ func TestLexiconToMarkdown(t *testing.T) {
 		}
 	}
 }
+
+// lexiconFixtures is a small lexicon set with cross-lexicon refs, a token, a
+// subscription and a query with an output schema and errors.
+var lexiconFixtures = []string{`{
+  "lexicon": 1,
+  "id": "app.bsky.feed.defs",
+  "defs": {
+    "postView": {
+      "type": "object",
+      "required": ["uri"],
+      "nullable": ["record"],
+      "properties": {
+        "uri": {"type": "string", "format": "at-uri"},
+        "author": {"type": "ref", "ref": "app.bsky.actor.defs#profileViewBasic"},
+        "record": {"type": "unknown"}
+      }
+    },
+    "requestLess": {"type": "token", "description": "Request that less content like the given feed item be shown."},
+    "interaction": {
+      "type": "object",
+      "properties": {
+        "event": {"type": "string", "knownValues": ["app.bsky.feed.defs#requestLess", "other"]}
+      }
+    }
+  }
+}`, `{
+  "lexicon": 1,
+  "id": "app.bsky.actor.defs",
+  "defs": {
+    "profileViewBasic": {
+      "type": "object",
+      "description": "A basic profile.",
+      "required": ["did"],
+      "properties": {
+        "did": {"type": "string", "format": "did"},
+        "avatar": {"type": "blob", "accept": ["image/png", "image/jpeg"], "maxSize": 1000000}
+      }
+    }
+  }
+}`, `{
+  "lexicon": 1,
+  "id": "app.bsky.feed.getPosts",
+  "defs": {
+    "main": {
+      "type": "query",
+      "parameters": {
+        "type": "params",
+        "required": ["uris"],
+        "properties": {
+          "uris": {"type": "array", "items": {"type": "string", "format": "at-uri"}, "maxLength": 25},
+          "limit": {"type": "integer", "minimum": 1, "maximum": 100, "default": 50}
+        }
+      },
+      "output": {
+        "encoding": "application/json",
+        "schema": {
+          "type": "object",
+          "required": ["posts"],
+          "properties": {
+            "posts": {"type": "array", "items": {"type": "ref", "ref": "app.bsky.feed.defs#postView"}}
+          }
+        }
+      },
+      "errors": [{"name": "NotFound", "description": "No such post."}]
+    }
+  }
+}`, `{
+  "lexicon": 1,
+  "id": "com.atproto.sync.subscribeRepos",
+  "defs": {
+    "main": {
+      "type": "subscription",
+      "message": {"schema": {"type": "union", "refs": ["#commit", "com.example.missing#event"]}},
+      "errors": [{"name": "FutureCursor"}]
+    },
+    "commit": {"type": "object", "properties": {"seq": {"type": "integer"}, "ops": {"type": "bytes", "maxLength": 2000000}}}
+  }
+}`}
+
+func loadLexiconFixtures(t *testing.T) Catalog {
+	t.Helper()
+	var lexicons []*Lexicon
+	for _, data := range lexiconFixtures {
+		var lex Lexicon
+		if err := json.Unmarshal([]byte(data), &lex); err != nil {
+			t.Fatal(err)
+		}
+		lexicons = append(lexicons, &lex)
+	}
+	return NewCatalog(lexicons...)
+}
+
+func TestSplitRef(t *testing.T) {
+	tests := []struct{ ref, nsid, name string }{
+		{"#reply", "app.bsky.feed.post", "reply"},
+		{"app.bsky.feed.defs#postView", "app.bsky.feed.defs", "postView"},
+		{"com.atproto.repo.strongRef", "com.atproto.repo.strongRef", "main"},
+	}
+	for _, tt := range tests {
+		nsid, name := SplitRef("app.bsky.feed.post", tt.ref)
+		if nsid != tt.nsid || name != tt.name {
+			t.Errorf("SplitRef(%q) = %q %q, want %q %q", tt.ref, nsid, name, tt.nsid, tt.name)
+		}
+	}
+	if got := RefURL("app.bsky.feed.defs", "postView"); got != "/doc/atproto/lexicon/app.bsky.feed.defs#definition-appbskyfeeddefspostview" {
+		t.Errorf("RefURL() = %q", got)
+	}
+}
+
+func TestRenderLexiconRefs(t *testing.T) {
+	catalog := loadLexiconFixtures(t)
+
+	md := RenderLexicon(catalog["app.bsky.feed.getPosts"], RenderOptions{Catalog: catalog})
+	for _, sub := range []string{
+		"### Parameters",
+		"| limit | integer | No | (Minimum: 1; Maximum: 100; Default: `50`)  |",
+		"| uris | array of string | Yes | (Max length: 25)  |",
+		"### Output\n\n- **Encoding**: `application/json`\n\n| Name |",
+		"| posts | array of [app.bsky.feed.defs#postView](/doc/atproto/lexicon/app.bsky.feed.defs#definition-appbskyfeeddefspostview) | Yes |  |",
+		"### Errors\n\n- `NotFound`: No such post.",
+	} {
+		if !strings.Contains(md, sub) {
+			t.Errorf("getPosts missing %q:\n%s", sub, md)
+		}
+	}
+	if strings.Contains(md, "profileViewBasic") {
+		t.Errorf("refs expanded without ExpandDepth:\n%s", md)
+	}
+
+	md = RenderLexicon(catalog["app.bsky.feed.defs"], RenderOptions{Catalog: catalog})
+	for _, sub := range []string{
+		"| record | unknown | No | (Nullable)  |",
+		"- **Type**: token",
+		"| event | string | No | (Known values: [app.bsky.feed.defs#requestLess](/doc/atproto/lexicon/app.bsky.feed.defs#definition-appbskyfeeddefsrequestless), `other`)  |",
+	} {
+		if !strings.Contains(md, sub) {
+			t.Errorf("feed.defs missing %q:\n%s", sub, md)
+		}
+	}
+
+	md = RenderLexicon(catalog["com.atproto.sync.subscribeRepos"], RenderOptions{Catalog: catalog})
+	for _, sub := range []string{
+		"- **Type**: subscription",
+		"### Message\n\n- **Schema**: union([#commit](/doc/atproto/lexicon/com.atproto.sync.subscribeRepos#definition-comatprotosyncsubscribereposcommit), ref(com.example.missing#event))",
+		"- `FutureCursor`",
+		"| ops | bytes | No | (Max length: 2000000)  |",
+	} {
+		if !strings.Contains(md, sub) {
+			t.Errorf("subscribeRepos missing %q:\n%s", sub, md)
+		}
+	}
+}
+
+func TestRenderLexiconExpand(t *testing.T) {
+	catalog := loadLexiconFixtures(t)
+
+	md := RenderLexicon(catalog["app.bsky.feed.getPosts"], RenderOptions{Catalog: catalog, ExpandDepth: 1})
+	if !strings.Contains(md, "**`posts` → `app.bsky.feed.defs#postView`**") {
+		t.Errorf("postView not expanded:\n%s", md)
+	}
+	if strings.Contains(md, "profileViewBasic`**") {
+		t.Errorf("expanded past depth 1:\n%s", md)
+	}
+
+	md = RenderLexicon(catalog["app.bsky.feed.getPosts"], RenderOptions{Catalog: catalog, ExpandDepth: 2})
+	for _, sub := range []string{
+		"**`posts.author` → `app.bsky.actor.defs#profileViewBasic`**: A basic profile.",
+		"| avatar | blob | No | (Accept: `image/png`, `image/jpeg`; Max size: 1000000)  |",
+		"| did | string | Yes | (Format: did)  |",
+	} {
+		if !strings.Contains(md, sub) {
+			t.Errorf("depth 2 missing %q:\n%s", sub, md)
+		}
+	}
+}
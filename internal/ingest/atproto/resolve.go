@@ -0,0 +1,156 @@
+package atproto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// lexiconCollection is the repository collection lexicons are published in.
+const lexiconCollection = "com.atproto.lexicon.schema"
+
+// Resolver fetches published lexicons by NSID, following the lexicon
+// resolution process: a DNS TXT record at _lexicon.<authority> names the
+// DID of the account publishing the namespace, the DID document names its
+// PDS, and the PDS serves the lexicon as a com.atproto.lexicon.schema record
+// keyed by NSID.
+type Resolver struct {
+	// LookupTXT resolves DNS TXT records; nil uses the system resolver.
+	LookupTXT func(ctx context.Context, name string) ([]string, error)
+	// Client is used for DID documents and PDS requests; nil uses a client
+	// with a 30 second timeout.
+	Client *http.Client
+	// PLCDirectory resolves did:plc identifiers; empty uses
+	// https://plc.directory.
+	PLCDirectory string
+}
+
+// Resolve fetches the lexicon for nsid and returns it with its source JSON.
+func (r *Resolver) Resolve(ctx context.Context, nsid string) (*Lexicon, []byte, error) {
+	if !isNSID(nsid) {
+		return nil, nil, fmt.Errorf("invalid NSID: %s", nsid)
+	}
+
+	did, err := r.authorityDID(ctx, nsid)
+	if err != nil {
+		return nil, nil, err
+	}
+	pds, err := r.pdsEndpoint(ctx, did)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve %s: %w", did, err)
+	}
+
+	q := url.Values{"repo": {did}, "collection": {lexiconCollection}, "rkey": {nsid}}
+	var record struct {
+		URI   string          `json:"uri"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := r.getJSON(ctx, strings.TrimSuffix(pds, "/")+"/xrpc/com.atproto.repo.getRecord?"+q.Encode(), &record); err != nil {
+		return nil, nil, fmt.Errorf("fetch lexicon %s from %s: %w", nsid, pds, err)
+	}
+
+	file, err := parseLexicon(record.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("lexicon record %s: %w", record.URI, err)
+	}
+	if file.lex.ID != nsid {
+		return nil, nil, fmt.Errorf("lexicon record %s has id %s, want %s", record.URI, file.lex.ID, nsid)
+	}
+	return file.lex, file.raw, nil
+}
+
+// authorityDomain is the domain whose _lexicon TXT record covers an NSID:
+// the NSID without its name segment, reversed. app.bsky.feed.post is
+// published under feed.bsky.app.
+func authorityDomain(nsid string) string {
+	parts := strings.Split(nsid, ".")
+	parts = parts[:len(parts)-1]
+	slices.Reverse(parts)
+	return strings.Join(parts, ".")
+}
+
+func (r *Resolver) authorityDID(ctx context.Context, nsid string) (string, error) {
+	lookup := r.LookupTXT
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupTXT
+	}
+	name := "_lexicon." + authorityDomain(nsid)
+	records, err := lookup(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("lookup %s: %w", name, err)
+	}
+	for _, rec := range records {
+		if did, ok := strings.CutPrefix(strings.TrimSpace(rec), "did="); ok && isDID(did) {
+			return did, nil
+		}
+	}
+	return "", fmt.Errorf("no did= TXT record at %s", name)
+}
+
+// pdsEndpoint reads the #atproto_pds service from the DID document of a
+// did:plc or did:web account.
+func (r *Resolver) pdsEndpoint(ctx context.Context, did string) (string, error) {
+	var docURL string
+	switch {
+	case strings.HasPrefix(did, "did:plc:"):
+		plc := r.PLCDirectory
+		if plc == "" {
+			plc = "https://plc.directory"
+		}
+		docURL = strings.TrimSuffix(plc, "/") + "/" + did
+	case strings.HasPrefix(did, "did:web:"):
+		host, err := url.PathUnescape(strings.TrimPrefix(did, "did:web:"))
+		if err != nil {
+			return "", fmt.Errorf("invalid did:web %s", did)
+		}
+		docURL = "https://" + host + "/.well-known/did.json"
+	default:
+		return "", fmt.Errorf("unsupported DID method: %s", did)
+	}
+
+	var doc struct {
+		Service []struct {
+			ID              string `json:"id"`
+			Type            string `json:"type"`
+			ServiceEndpoint string `json:"serviceEndpoint"`
+		} `json:"service"`
+	}
+	if err := r.getJSON(ctx, docURL, &doc); err != nil {
+		return "", err
+	}
+	for _, svc := range doc.Service {
+		if (svc.ID == "#atproto_pds" || svc.ID == did+"#atproto_pds") && svc.ServiceEndpoint != "" {
+			return svc.ServiceEndpoint, nil
+		}
+	}
+	return "", fmt.Errorf("DID document has no #atproto_pds service")
+}
+
+func (r *Resolver) getJSON(ctx context.Context, u string, v any) error {
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,178 @@
+package atproto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/codec"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+const exampleLexicon = `{
+  "lexicon": 1,
+  "id": "com.example.feed.pin",
+  "defs": {
+    "main": {
+      "type": "record",
+      "description": "A pinned post.",
+      "key": "tid",
+      "record": {
+        "type": "object",
+        "required": ["subject"],
+        "properties": {"subject": {"type": "ref", "ref": "com.atproto.repo.strongRef"}}
+      }
+    }
+  }
+}`
+
+// newLexiconServer stands in for both the PLC directory and the PDS that
+// publishes com.example lexicons.
+func newLexiconServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("GET /did:plc:examplepublisher", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id": "did:plc:examplepublisher",
+			"service": []map[string]string{
+				{"id": "#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": srv.URL},
+			},
+		})
+	})
+	mux.HandleFunc("GET /xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("repo") != "did:plc:examplepublisher" || q.Get("collection") != "com.atproto.lexicon.schema" || q.Get("rkey") != "com.example.feed.pin" {
+			http.Error(w, `{"error":"RecordNotFound"}`, http.StatusBadRequest)
+			return
+		}
+		value := strings.Replace(exampleLexicon, "{", `{"$type": "com.atproto.lexicon.schema",`, 1)
+		_, _ = w.Write([]byte(`{"uri": "at://did:plc:examplepublisher/com.atproto.lexicon.schema/com.example.feed.pin", "cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm", "value": ` + value + `}`))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testResolver(srv *httptest.Server) *Resolver {
+	return &Resolver{
+		LookupTXT: func(ctx context.Context, name string) ([]string, error) {
+			if name == "_lexicon.feed.example.com" {
+				return []string{"did=did:plc:examplepublisher"}, nil
+			}
+			return nil, errors.New("no such host")
+		},
+		Client:       srv.Client(),
+		PLCDirectory: srv.URL,
+	}
+}
+
+func TestResolve(t *testing.T) {
+	srv := newLexiconServer(t)
+	r := testResolver(srv)
+
+	lex, raw, err := r.Resolve(context.Background(), "com.example.feed.pin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lex.ID != "com.example.feed.pin" || lex.Defs["main"].Type != "record" {
+		t.Errorf("lexicon = %+v", lex)
+	}
+	if strings.Contains(string(raw), "\n") {
+		t.Errorf("raw lexicon is not compacted: %s", raw)
+	}
+
+	if _, _, err := r.Resolve(context.Background(), "com.example.other.thing"); err == nil || !strings.Contains(err.Error(), "_lexicon.other.example.com") {
+		t.Errorf("expected a DNS lookup error, got %v", err)
+	}
+	if _, _, err := r.Resolve(context.Background(), "com.example.feed.missing"); err == nil || !strings.Contains(err.Error(), "RecordNotFound") {
+		t.Errorf("expected a PDS error, got %v", err)
+	}
+}
+
+func TestAuthorityDomain(t *testing.T) {
+	if got := authorityDomain("app.bsky.feed.post"); got != "feed.bsky.app" {
+		t.Errorf("authorityDomain() = %q", got)
+	}
+}
+
+func TestIsGitURL(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]bool{
+		dir:                                   false,
+		"https://github.com/example/lexicons": true,
+		"git@github.com:example/lexicons.git": true,
+		"./missing/lexicons":                  false,
+	}
+	for source, want := range tests {
+		if got := isGitURL(source); got != want {
+			t.Errorf("isGitURL(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+// TestIngestLexiconSources ingests a third-party lexicon directory and a
+// resolved lexicon next to an official one already in the database.
+func TestIngestLexiconSources(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	official := t.TempDir()
+	if err := os.WriteFile(filepath.Join(official, "strongRef.json"), []byte(strongRefLexicon), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	custom := t.TempDir()
+	for name, data := range map[string]string{
+		"com/example/feed/like.json": strings.ReplaceAll(exampleLexicon, "com.example.feed.pin", "com.example.feed.like"),
+		"package.json":               `{"name": "lexicons"}`,
+	} {
+		path := filepath.Join(custom, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := IngestAtproto(ctx, Options{DB: store, Lexicons: official}); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestAtproto(ctx, Options{
+		DB:       store,
+		Lexicons: custom,
+		NSIDs:    []string{"com.example.feed.pin"},
+		Resolver: testResolver(newLexiconServer(t)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, nsid := range []string{"com.example.feed.like", "com.example.feed.pin"} {
+		doc, err := store.ReadDocument(ctx, DocPath(nsid))
+		if err != nil {
+			t.Fatalf("%s: %v", nsid, err)
+		}
+		body, err := codec.Decompress(doc.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		link := "[com.atproto.repo.strongRef](" + RefURL("com.atproto.repo.strongRef", "main") + ")"
+		if !strings.Contains(string(body), link) {
+			t.Errorf("%s does not link the stored strongRef:\n%s", nsid, body)
+		}
+	}
+
+	catalog, err := LoadCatalog(ctx, store, "com.example.feed.pin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := catalog["com.atproto.repo.strongRef"]; !ok {
+		t.Errorf("catalog = %v, want strongRef loaded through the ref", catalog)
+	}
+}
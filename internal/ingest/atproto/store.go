@@ -0,0 +1,83 @@
+package atproto
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/stormlightlabs/documango/internal/db"
+)
+
+// LoadCatalog reads the lexicons named by nsids from the store, along with
+// every lexicon they reference, directly or transitively. Referenced
+// lexicons that were never ingested are left out; refs to them fail to
+// resolve.
+func LoadCatalog(ctx context.Context, store *db.Store, nsids ...string) (Catalog, error) {
+	catalog := Catalog{}
+	for _, nsid := range nsids {
+		lex, err := loadLexicon(ctx, store, nsid)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("lexicon not found: %s (run documango add atproto)", nsid)
+		}
+		if err != nil {
+			return nil, err
+		}
+		catalog[nsid] = lex
+	}
+	if err := catalog.loadReferences(ctx, store); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// loadReferences adds the stored lexicons that the catalog refers to,
+// transitively, skipping any that were never ingested.
+func (c Catalog) loadReferences(ctx context.Context, store *db.Store) error {
+	missing := map[string]bool{}
+	var queue []string
+	for _, lex := range c {
+		queue = append(queue, lex.References()...)
+	}
+	for len(queue) > 0 {
+		nsid := queue[0]
+		queue = queue[1:]
+		if _, ok := c[nsid]; ok || missing[nsid] {
+			continue
+		}
+
+		lex, err := loadLexicon(ctx, store, nsid)
+		if errors.Is(err, sql.ErrNoRows) {
+			missing[nsid] = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c[nsid] = lex
+		queue = append(queue, lex.References()...)
+	}
+	return nil
+}
+
+// loadLexicon reads the raw lexicon JSON that ingestion stores next to the
+// lexicon's document.
+func loadLexicon(ctx context.Context, store *db.Store, nsid string) (*Lexicon, error) {
+	raw, err := store.Lexicon(ctx, nsid)
+	if err != nil {
+		return nil, err
+	}
+	var lex Lexicon
+	if err := json.Unmarshal([]byte(raw), &lex); err != nil {
+		return nil, fmt.Errorf("parse stored lexicon %s: %w", nsid, err)
+	}
+	return &lex, nil
+}
+
+// StoredLexicons lists the NSIDs of stored lexicons in a namespace: prefix
+// itself and every NSID below it, so app.bsky.feed matches
+// app.bsky.feed.post but not app.bsky.feedgen.
+func StoredLexicons(ctx context.Context, store *db.Store, prefix string) ([]string, error) {
+	return store.Lexicons(ctx, prefix)
+}
@@ -0,0 +1,581 @@
+package atproto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Schema parts that can be validated or exemplified. A record lexicon has
+// only a record, a query params and output, a procedure params, input and
+// output, and a subscription params and message.
+const (
+	PartRecord  = "record"
+	PartParams  = "params"
+	PartInput   = "input"
+	PartOutput  = "output"
+	PartMessage = "message"
+)
+
+// ValidationError is a value that does not match its schema. Path locates the
+// value in the document, e.g. "embed.images[0].alt".
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Target is the schema a document is validated against: a property and the
+// NSID its refs are relative to.
+type Target struct {
+	NSID   string
+	Part   string
+	Schema Property
+}
+
+// Target finds the schema for ref, an NSID or nsid#def. Without a part, a
+// record lexicon selects its record, a procedure its input, a query its
+// output and a subscription its message; other definitions select
+// themselves.
+func (c Catalog) Target(ref, part string) (Target, error) {
+	nsid, name := SplitRef("", ref)
+	def, ok := c.Definition(nsid, name)
+	if !ok {
+		return Target{}, fmt.Errorf("lexicon definition not found: %s", refName(nsid, name))
+	}
+
+	if part == "" {
+		switch def.Type {
+		case "record":
+			part = PartRecord
+		case "procedure":
+			part = PartInput
+		case "query":
+			part = PartOutput
+		case "subscription":
+			part = PartMessage
+		default:
+			return Target{NSID: nsid, Schema: def.Property}, nil
+		}
+	}
+
+	t := Target{NSID: nsid, Part: part}
+	var schema *Property
+	switch part {
+	case PartRecord:
+		schema = def.Record
+	case PartParams:
+		schema = def.Parameters
+	case PartInput:
+		if def.Input != nil {
+			schema = def.Input.Schema
+		}
+	case PartOutput:
+		if def.Output != nil {
+			schema = def.Output.Schema
+		}
+	case PartMessage:
+		if def.Message != nil {
+			schema = def.Message.Schema
+		}
+	default:
+		return Target{}, fmt.Errorf("unknown schema part %q (want record, params, input, output or message)", part)
+	}
+	if schema == nil {
+		return Target{}, fmt.Errorf("%s (%s) has no %s schema", refName(nsid, name), def.Type, part)
+	}
+	t.Schema = *schema
+	return t, nil
+}
+
+// Validate checks a JSON document against the target. It returns every
+// mismatch found, or nil when the document is valid. Data model values
+// follow the atproto JSON encoding: bytes as {"$bytes": ...}, CID links as
+// {"$link": ...} and blobs as {"$type": "blob", ...}.
+func (c Catalog) Validate(t Target, data []byte) ([]ValidationError, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+
+	v := validator{catalog: c}
+	if t.Part == PartRecord {
+		if obj, ok := value.(map[string]any); ok {
+			if typ, ok := obj["$type"]; ok && typ != t.NSID {
+				v.fail("$type", "must be %q, got %v", t.NSID, typ)
+			}
+		}
+	}
+	v.value("", t.NSID, t.Schema, value)
+	return v.errs, nil
+}
+
+type validator struct {
+	catalog Catalog
+	errs    []ValidationError
+	depth   int
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) value(path, nsid string, p Property, value any) {
+	switch p.Type {
+	case "object", "params":
+		v.object(path, nsid, p, value)
+	case "ref":
+		v.ref(path, nsid, p.Ref, value)
+	case "union":
+		v.union(path, nsid, p, value)
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			v.fail(path, "expected array, got %s", jsonType(value))
+			return
+		}
+		if p.MinLength != nil && len(arr) < *p.MinLength {
+			v.fail(path, "array has %d items, fewer than the minimum %d", len(arr), *p.MinLength)
+		}
+		if p.MaxLength != nil && len(arr) > *p.MaxLength {
+			v.fail(path, "array has %d items, more than the maximum %d", len(arr), *p.MaxLength)
+		}
+		if p.Items != nil {
+			for i, item := range arr {
+				v.value(fmt.Sprintf("%s[%d]", path, i), nsid, *p.Items, item)
+			}
+		}
+	case "string":
+		v.string(path, p, value)
+	case "integer":
+		v.integer(path, p, value)
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok {
+			v.fail(path, "expected boolean, got %s", jsonType(value))
+			return
+		}
+		if p.Const != nil && p.Const != b {
+			v.fail(path, "must be %v", p.Const)
+		}
+	case "bytes":
+		v.bytes(path, p, value)
+	case "cid-link":
+		if _, ok := link(value); !ok {
+			v.fail(path, `expected a CID link ({"$link": "..."})`)
+		}
+	case "blob":
+		v.blob(path, p, value)
+	case "unknown":
+		if _, ok := value.(map[string]any); !ok {
+			v.fail(path, "expected object, got %s", jsonType(value))
+		}
+	case "token":
+		v.fail(path, "tokens cannot be used as values")
+	default:
+		v.fail(path, "unsupported schema type %q", p.Type)
+	}
+}
+
+func (v *validator) object(path, nsid string, p Property, value any) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		v.fail(path, "expected object, got %s", jsonType(value))
+		return
+	}
+	nullable := make(map[string]bool, len(p.Nullable))
+	for _, n := range p.Nullable {
+		nullable[n] = true
+	}
+	for _, name := range p.Required {
+		if _, ok := obj[name]; !ok {
+			v.fail(join(path, name), "required field is missing")
+		}
+	}
+	for _, name := range sortedProps(p.Properties) {
+		val, ok := obj[name]
+		if !ok {
+			continue
+		}
+		if val == nil {
+			if !nullable[name] {
+				v.fail(join(path, name), "must not be null")
+			}
+			continue
+		}
+		v.value(join(path, name), nsid, p.Properties[name], val)
+	}
+}
+
+// ref validates a value against a referenced definition. A ref to a record
+// validates the record object.
+func (v *validator) ref(path, nsid, ref string, value any) {
+	target, name, def, ok := v.catalog.Resolve(nsid, ref)
+	if !ok {
+		v.fail(path, "unresolved ref %s", ref)
+		return
+	}
+	// Recursive lexicons are bounded by the document, but guard against a
+	// ref cycle that consumes no data.
+	if v.depth > 64 {
+		v.fail(path, "ref nesting too deep at %s", refName(target, name))
+		return
+	}
+	v.depth++
+	defer func() { v.depth-- }()
+
+	schema := def.Property
+	if def.Type == "record" && def.Record != nil {
+		schema = *def.Record
+	}
+	v.value(path, target, schema, value)
+}
+
+// union checks $type against the union's refs. Open unions accept types they
+// do not list without validating them.
+func (v *validator) union(path, nsid string, p Property, value any) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		v.fail(path, "expected object, got %s", jsonType(value))
+		return
+	}
+	typ, _ := obj["$type"].(string)
+	if typ == "" {
+		v.fail(join(path, "$type"), "union member must have a $type")
+		return
+	}
+	for _, ref := range p.Refs {
+		if sameRef(nsid, ref, typ) {
+			v.ref(path, nsid, ref, value)
+			return
+		}
+	}
+	if p.Closed {
+		v.fail(join(path, "$type"), "%s is not one of %s", typ, strings.Join(p.Refs, ", "))
+	}
+}
+
+// sameRef compares a union ref with a $type value. "nsid#main" and "nsid"
+// name the same definition.
+func sameRef(from, ref, typ string) bool {
+	a, an := SplitRef(from, ref)
+	b, bn := SplitRef(from, typ)
+	return a == b && an == bn
+}
+
+func (v *validator) string(path string, p Property, value any) {
+	s, ok := value.(string)
+	if !ok {
+		v.fail(path, "expected string, got %s", jsonType(value))
+		return
+	}
+	if p.MinLength != nil && len(s) < *p.MinLength {
+		v.fail(path, "string is %d bytes, shorter than the minimum %d", len(s), *p.MinLength)
+	}
+	if p.MaxLength != nil && len(s) > *p.MaxLength {
+		v.fail(path, "string is %d bytes, longer than the maximum %d", len(s), *p.MaxLength)
+	}
+	if p.MinGraphemes != nil || p.MaxGraphemes != nil {
+		n := graphemeCount(s)
+		if p.MinGraphemes != nil && n < *p.MinGraphemes {
+			v.fail(path, "string has %d graphemes, fewer than the minimum %d", n, *p.MinGraphemes)
+		}
+		if p.MaxGraphemes != nil && n > *p.MaxGraphemes {
+			v.fail(path, "string has %d graphemes, more than the maximum %d", n, *p.MaxGraphemes)
+		}
+	}
+	if p.Const != nil && p.Const != s {
+		v.fail(path, "must be %q", p.Const)
+	}
+	if len(p.Enum) > 0 && !inEnum(p.Enum, s) {
+		v.fail(path, "%q is not one of %s", s, enumList(p.Enum))
+	}
+	if p.Format != "" {
+		if check, ok := formats[p.Format]; ok && !check(s) {
+			v.fail(path, "%q is not a valid %s", s, p.Format)
+		}
+	}
+}
+
+func (v *validator) integer(path string, p Property, value any) {
+	num, ok := value.(json.Number)
+	if !ok {
+		v.fail(path, "expected integer, got %s", jsonType(value))
+		return
+	}
+	n, err := num.Int64()
+	if err != nil {
+		v.fail(path, "expected integer, got %s", num)
+		return
+	}
+	if p.Minimum != nil && n < int64(*p.Minimum) {
+		v.fail(path, "%d is less than the minimum %d", n, *p.Minimum)
+	}
+	if p.Maximum != nil && n > int64(*p.Maximum) {
+		v.fail(path, "%d is greater than the maximum %d", n, *p.Maximum)
+	}
+	if p.Const != nil {
+		if c, ok := asInt64(p.Const); !ok || c != n {
+			v.fail(path, "must be %s", formatValue(p.Const))
+		}
+	}
+	if len(p.Enum) > 0 && !slices.ContainsFunc(p.Enum, func(e any) bool {
+		c, ok := asInt64(e)
+		return ok && c == n
+	}) {
+		v.fail(path, "%d is not one of %s", n, enumList(p.Enum))
+	}
+}
+
+func (v *validator) bytes(path string, p Property, value any) {
+	obj, _ := value.(map[string]any)
+	enc, ok := obj["$bytes"].(string)
+	if !ok {
+		v.fail(path, `expected bytes ({"$bytes": "<base64>"})`)
+		return
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(enc, "="))
+	if err != nil {
+		v.fail(path, "invalid base64: %v", err)
+		return
+	}
+	if p.MinLength != nil && len(raw) < *p.MinLength {
+		v.fail(path, "%d bytes, fewer than the minimum %d", len(raw), *p.MinLength)
+	}
+	if p.MaxLength != nil && len(raw) > *p.MaxLength {
+		v.fail(path, "%d bytes, more than the maximum %d", len(raw), *p.MaxLength)
+	}
+}
+
+// blob validates a blob reference, including the legacy {"cid", "mimeType"}
+// form.
+func (v *validator) blob(path string, p Property, value any) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		v.fail(path, "expected blob, got %s", jsonType(value))
+		return
+	}
+	mime, _ := obj["mimeType"].(string)
+	if obj["$type"] == "blob" {
+		if _, ok := link(obj["ref"]); !ok {
+			v.fail(join(path, "ref"), `expected a CID link ({"$link": "..."})`)
+		}
+		size, ok := obj["size"].(json.Number)
+		if !ok {
+			v.fail(join(path, "size"), "blob size is missing")
+		} else if n, err := size.Int64(); err == nil && p.MaxSize != nil && n > int64(*p.MaxSize) {
+			v.fail(join(path, "size"), "%d bytes, larger than the maximum %d", n, *p.MaxSize)
+		}
+	} else if _, ok := obj["cid"].(string); !ok {
+		v.fail(path, `expected blob ({"$type": "blob", "ref": ..., "mimeType": ..., "size": ...})`)
+		return
+	}
+	if mime == "" {
+		v.fail(join(path, "mimeType"), "blob mimeType is missing")
+	} else if len(p.Accept) > 0 && !acceptsMIME(p.Accept, mime) {
+		v.fail(join(path, "mimeType"), "%s is not one of %s", mime, strings.Join(p.Accept, ", "))
+	}
+}
+
+func acceptsMIME(accept []string, mime string) bool {
+	for _, a := range accept {
+		if a == "*/*" || a == mime {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(mime, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func link(value any) (string, bool) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	s, ok := obj["$link"].(string)
+	return s, ok && s != ""
+}
+
+func inEnum(enum []any, s string) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []any) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = formatValue(e)
+	}
+	return strings.Join(values, ", ")
+}
+
+// asInt64 returns the integer a schema value holds. Lexicons are decoded
+// into float64s, which hold every integer a record may use exactly.
+func asInt64(value any) (int64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return int64(n), n == math.Trunc(n)
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// formatValue prints a schema value, integers without an exponent.
+func formatValue(value any) string {
+	if n, ok := value.(float64); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// graphemeCount approximates the number of user-perceived characters:
+// combining marks, variation selectors, skin tone modifiers and characters
+// joined by a zero-width joiner extend the previous character, and regional
+// indicators pair into flags.
+func graphemeCount(s string) int {
+	n := 0
+	joined := false
+	regional := false
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me), r >= 0xFE00 && r <= 0xFE0F, r >= 0x1F3FB && r <= 0x1F3FF:
+			continue
+		case r == 0x200D:
+			joined = true
+			continue
+		case joined:
+			joined = false
+			continue
+		case r >= 0x1F1E6 && r <= 0x1F1FF:
+			if regional {
+				regional = false
+				continue
+			}
+			regional = true
+		default:
+			regional = false
+		}
+		n++
+	}
+	return n
+}
+
+var (
+	didPattern      = regexp.MustCompile(`^did:[a-z]+:[a-zA-Z0-9._:%-]*[a-zA-Z0-9._-]$`)
+	handlePattern   = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	nsidPattern     = regexp.MustCompile(`^[a-zA-Z]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+(\.[a-zA-Z]([a-zA-Z0-9]{0,62})?)$`)
+	cidPattern      = regexp.MustCompile(`^[a-zA-Z0-9+=]{8,256}$`)
+	datetimePattern = regexp.MustCompile(`^[0-9]{4}-[01][0-9]-[0-3][0-9]T[0-2][0-9]:[0-6][0-9]:[0-6][0-9](\.[0-9]+)?(Z|[+-][0-2][0-9]:[0-5][0-9])$`)
+	languagePattern = regexp.MustCompile(`^(i|[a-z]{2,3})(-[a-zA-Z0-9]+)*$`)
+	uriPattern      = regexp.MustCompile(`^[a-z][a-z0-9.+-]*:[^\s]+$`)
+	tidPattern      = regexp.MustCompile(`^[234567abcdefghij][234567abcdefghijklmnopqrstuvwxyz]{12}$`)
+	rkeyPattern     = regexp.MustCompile(`^[a-zA-Z0-9_~.:-]{1,512}$`)
+)
+
+// formats checks the string formats defined by the lexicon specification.
+var formats = map[string]func(string) bool{
+	"did":           isDID,
+	"handle":        isHandle,
+	"at-identifier": func(s string) bool { return isDID(s) || isHandle(s) },
+	"nsid":          isNSID,
+	"at-uri":        isATURI,
+	"cid":           cidPattern.MatchString,
+	"datetime":      isDatetime,
+	"language":      languagePattern.MatchString,
+	"uri":           func(s string) bool { return len(s) <= 8192 && uriPattern.MatchString(s) },
+	"tid":           tidPattern.MatchString,
+	"record-key":    isRecordKey,
+}
+
+func isDID(s string) bool {
+	return len(s) <= 2048 && didPattern.MatchString(s)
+}
+
+func isHandle(s string) bool {
+	return len(s) <= 253 && handlePattern.MatchString(s)
+}
+
+func isNSID(s string) bool {
+	return len(s) <= 317 && nsidPattern.MatchString(s)
+}
+
+func isRecordKey(s string) bool {
+	return s != "." && s != ".." && rkeyPattern.MatchString(s)
+}
+
+// isATURI accepts at://authority[/collection[/rkey]], with a DID or handle
+// authority.
+func isATURI(s string) bool {
+	rest, ok := strings.CutPrefix(s, "at://")
+	if !ok || len(s) > 8192 {
+		return false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) > 3 {
+		return false
+	}
+	if !isDID(parts[0]) && !isHandle(parts[0]) {
+		return false
+	}
+	if len(parts) > 1 && !isNSID(parts[1]) {
+		return false
+	}
+	return len(parts) < 3 || isRecordKey(parts[2])
+}
+
+func isDatetime(s string) bool {
+	if !datetimePattern.MatchString(s) || strings.HasSuffix(s, "-00:00") {
+		return false
+	}
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
@@ -0,0 +1,328 @@
+package atproto
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+// postLexicon is a trimmed app.bsky.feed.post with a local ref, an open
+// union and string limits.
+const postLexicon = `{
+  "lexicon": 1,
+  "id": "app.bsky.feed.post",
+  "defs": {
+    "main": {
+      "type": "record",
+      "key": "tid",
+      "record": {
+        "type": "object",
+        "required": ["text", "createdAt"],
+        "properties": {
+          "text": {"type": "string", "maxLength": 3000, "maxGraphemes": 300},
+          "reply": {"type": "ref", "ref": "#replyRef"},
+          "embed": {"type": "union", "refs": ["app.bsky.embed.images"]},
+          "langs": {"type": "array", "maxLength": 3, "items": {"type": "string", "format": "language"}},
+          "createdAt": {"type": "string", "format": "datetime"}
+        }
+      }
+    },
+    "replyRef": {
+      "type": "object",
+      "required": ["root", "parent"],
+      "properties": {
+        "root": {"type": "ref", "ref": "com.atproto.repo.strongRef"},
+        "parent": {"type": "ref", "ref": "com.atproto.repo.strongRef"}
+      }
+    }
+  }
+}`
+
+const strongRefLexicon = `{
+  "lexicon": 1,
+  "id": "com.atproto.repo.strongRef",
+  "defs": {
+    "main": {
+      "type": "object",
+      "required": ["uri", "cid"],
+      "properties": {
+        "uri": {"type": "string", "format": "at-uri"},
+        "cid": {"type": "string", "format": "cid"}
+      }
+    }
+  }
+}`
+
+const imagesLexicon = `{
+  "lexicon": 1,
+  "id": "app.bsky.embed.images",
+  "defs": {
+    "main": {
+      "type": "object",
+      "required": ["images"],
+      "properties": {
+        "images": {"type": "array", "minLength": 1, "maxLength": 4, "items": {"type": "ref", "ref": "#image"}}
+      }
+    },
+    "image": {
+      "type": "object",
+      "required": ["image", "alt"],
+      "properties": {
+        "image": {"type": "blob", "accept": ["image/*"], "maxSize": 1000000},
+        "alt": {"type": "string"}
+      }
+    }
+  }
+}`
+
+func testCatalog(t *testing.T, lexicons ...string) Catalog {
+	t.Helper()
+	var lexs []*Lexicon
+	for _, data := range lexicons {
+		var lex Lexicon
+		if err := json.Unmarshal([]byte(data), &lex); err != nil {
+			t.Fatal(err)
+		}
+		lexs = append(lexs, &lex)
+	}
+	return NewCatalog(lexs...)
+}
+
+func TestValidate(t *testing.T) {
+	catalog := testCatalog(t, postLexicon, strongRefLexicon, imagesLexicon)
+	target, err := catalog.Target("app.bsky.feed.post", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			"valid",
+			`{"$type": "app.bsky.feed.post", "text": "hi 👋🏽", "createdAt": "2024-05-01T12:00:00.000Z", "langs": ["en", "pt-BR"],
+			  "reply": {"root": {"uri": "at://did:plc:abc123/app.bsky.feed.post/3jzfcijpj2z2a", "cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm"},
+			            "parent": {"uri": "at://alice.example.com", "cid": "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm"}},
+			  "embed": {"$type": "app.bsky.embed.images", "images": [{"alt": "", "image": {"$type": "blob", "ref": {"$link": "bafkreibme22gw2h7y2h7tg2fhqotaqjucnbc24deqo72b6mkl2egezxhvy"}, "mimeType": "image/jpeg", "size": 1024}}]}}`,
+			nil,
+		},
+		{
+			"constraints",
+			`{"$type": "app.bsky.feed.like", "text": 5, "createdAt": "2024-05-01 12:00:00", "langs": ["en", "en", "en", "en"],
+			  "reply": {"root": {"uri": "https://example.com", "cid": "x"}},
+			  "embed": {"$type": "app.bsky.embed.images", "images": [{"alt": "a", "image": {"$type": "blob", "ref": {"$link": "bafkrei"}, "mimeType": "text/plain", "size": 2000000}}]}}`,
+			[]string{
+				`$type: must be "app.bsky.feed.post", got app.bsky.feed.like`,
+				`createdAt: "2024-05-01 12:00:00" is not a valid datetime`,
+				`embed.images[0].image.size: 2000000 bytes, larger than the maximum 1000000`,
+				`embed.images[0].image.mimeType: text/plain is not one of image/*`,
+				`langs: array has 4 items, more than the maximum 3`,
+				`reply.parent: required field is missing`,
+				`reply.root.cid: "x" is not a valid cid`,
+				`reply.root.uri: "https://example.com" is not a valid at-uri`,
+				`text: expected string, got number`,
+			},
+		},
+		{
+			"missing and open union",
+			`{"embed": {"$type": "com.example.custom"}}`,
+			[]string{
+				"text: required field is missing",
+				"createdAt: required field is missing",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := catalog.Validate(target, []byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("errors =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateLargeIntegers(t *testing.T) {
+	catalog := testCatalog(t, `{
+  "lexicon": 1,
+  "id": "com.example.limits",
+  "defs": {
+    "main": {
+      "type": "object",
+      "properties": {
+        "n": {"type": "integer", "const": 1000000},
+        "m": {"type": "integer", "enum": [1, 2000000]}
+      }
+    }
+  }
+}`)
+	target, err := catalog.Target("com.example.limits", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for doc, want := range map[string][]string{
+		`{"n": 1000000, "m": 2000000}`: nil,
+		`{"n": 1000001, "m": 3}`:       {"n: must be 1000000", "m: 3 is not one of 1, 2000000"},
+	} {
+		errs, err := catalog.Validate(target, []byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range errs {
+			got = append(got, e.Error())
+		}
+		slices.Sort(got)
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Errorf("Validate(%s) = %q, want %q", doc, got, want)
+		}
+	}
+}
+
+func TestGraphemeCount(t *testing.T) {
+	tests := map[string]int{
+		"abc":                  3,
+		"e\u0301":              1,
+		"\U0001F44B\U0001F3FD": 1,
+		"\U0001F468\u200D\U0001F469\u200D\U0001F467": 1,
+		"\U0001F1E7\U0001F1F7\U0001F1F5\U0001F1F9":   2,
+	}
+	for s, want := range tests {
+		if got := graphemeCount(s); got != want {
+			t.Errorf("graphemeCount(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format, value string
+		valid         bool
+	}{
+		{"did", "did:web:example.com", true},
+		{"did", "did:plc:", false},
+		{"handle", "alice.bsky.social", true},
+		{"handle", "alice", false},
+		{"nsid", "app.bsky.feed.post", true},
+		{"nsid", "app.bsky", false},
+		{"at-uri", "at://did:plc:abc/app.bsky.feed.post/3jzfcijpj2z2a", true},
+		{"at-uri", "at://alice.bsky.social/not an nsid", false},
+		{"datetime", "2024-01-01T00:00:00+02:00", true},
+		{"datetime", "2024-01-01T00:00:00-00:00", false},
+		{"datetime", "2024-01-01t00:00:00Z", false},
+		{"tid", "3jzfcijpj2z2a", true},
+		{"tid", "3jzfcijpj2z2", false},
+		{"record-key", "self", true},
+		{"record-key", "..", false},
+		{"language", "zh-Hant", true},
+		{"uri", "https://example.com/a?b", true},
+		{"uri", "example.com", false},
+	}
+	for _, tt := range tests {
+		if got := formats[tt.format](tt.value); got != tt.valid {
+			t.Errorf("%s %q valid = %v, want %v", tt.format, tt.value, got, tt.valid)
+		}
+	}
+}
+
+func TestExampleValidates(t *testing.T) {
+	catalog := testCatalog(t, postLexicon, strongRefLexicon, imagesLexicon)
+	for _, ref := range []string{"app.bsky.feed.post", "app.bsky.feed.post#replyRef", "app.bsky.embed.images"} {
+		target, err := catalog.Target(ref, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		example, err := catalog.Example(target)
+		if err != nil {
+			t.Fatalf("%s: %v", ref, err)
+		}
+		data, err := json.Marshal(example)
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs, err := catalog.Validate(target, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(errs) > 0 {
+			t.Errorf("%s example %s is invalid: %v", ref, data, errs)
+		}
+	}
+
+	target, _ := catalog.Target("app.bsky.feed.post", "")
+	example, _ := catalog.Example(target)
+	obj := example.(map[string]any)
+	if obj["$type"] != "app.bsky.feed.post" || obj["createdAt"] != "2024-01-01T00:00:00.000Z" || len(obj) != 3 {
+		t.Errorf("post example = %v", obj)
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for name, data := range map[string]string{"post.json": postLexicon, "strongRef.json": strongRefLexicon, "images.json": imagesLexicon} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := dbtest.Open(t)
+	if err := store.WithTx(ctx, func(tx *sql.Tx) error {
+		return ingestLexicons(ctx, tx, dir, 0)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := LoadCatalog(ctx, store, "app.bsky.feed.post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for id := range catalog {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	if want := []string{"app.bsky.embed.images", "app.bsky.feed.post", "com.atproto.repo.strongRef"}; !slices.Equal(ids, want) {
+		t.Errorf("catalog = %v, want %v", ids, want)
+	}
+
+	sym, err := store.GetSymbolContext(ctx, "app.bsky.feed.post")
+	if err != nil || sym.Signature != "record app.bsky.feed.post" {
+		t.Errorf("GetSymbolContext(app.bsky.feed.post) = %+v, %v", sym, err)
+	}
+
+	if _, err := LoadCatalog(ctx, store, "com.example.missing"); err == nil {
+		t.Error("expected an error for a lexicon that was not ingested")
+	}
+
+	for prefix, want := range map[string][]string{
+		"app.bsky":                   {"app.bsky.embed.images", "app.bsky.feed.post"},
+		"app.bsky.feed.post":         {"app.bsky.feed.post"},
+		"app.bsky.fe":                nil,
+		"com.atproto.repo.strongRef": {"com.atproto.repo.strongRef"},
+	} {
+		nsids, err := StoredLexicons(ctx, store, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(nsids, want) {
+			t.Errorf("StoredLexicons(%q) = %v, want %v", prefix, nsids, want)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Package cheader ingests the API documentation of C and C++ libraries from
+// their headers: the declarations of functions, types, macros and variables
+// and the Doxygen comments on them.
+package cheader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+	"github.com/stormlightlabs/documango/internal/ingest/git"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+type Options struct {
+	// Source is a local directory or anything git clone accepts.
+	Source string
+	// Ref is a branch, tag or commit of a repository; a local directory is
+	// read in place without one.
+	Ref string
+	// Subdir limits ingestion to a directory of the source.
+	Subdir string
+	// Name names the library in document paths; the base name of the
+	// directory or repository when empty.
+	Name string
+	// Include and Exclude select headers by path glob relative to Subdir
+	// (see docset.Filter); a nil Exclude uses DefaultExclude.
+	Include []string
+	Exclude []string
+	DB      *db.Store
+	Cache   *cache.FilesystemCache
+}
+
+// DefaultExclude leaves out the headers of tests, examples, build output
+// and bundled dependencies.
+var DefaultExclude = append(append([]string{}, docset.DefaultExclude...),
+	"test", "tests", "example", "examples", "build", "deps", "external", "extern", "third-party", "3rdparty")
+
+// IsHeader reports whether a path has a C or C++ header extension.
+func IsHeader(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".h", ".hh", ".hpp", ".hxx", ".h++":
+		return true
+	}
+	return false
+}
+
+// IngestHeaders reads the headers of a local directory or repository and
+// stores a document per header under c/<name>/<path>, with its functions,
+// types, macros and variables as symbols.
+func IngestHeaders(ctx context.Context, opts Options) error {
+	if opts.Source == "" {
+		return errors.New("header directory or repository is required")
+	}
+	if opts.DB == nil {
+		return errors.New("db store is required")
+	}
+
+	dir, name, cleanup, err := git.OpenTree(ctx, opts.Source, opts.Ref, opts.Cache)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if opts.Name != "" {
+		name = opts.Name
+	}
+
+	subdir := path.Clean("/" + filepath.ToSlash(opts.Subdir))[1:]
+	root := filepath.Join(dir, filepath.FromSlash(subdir))
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("subdirectory %q not found in %s", opts.Subdir, opts.Source)
+	}
+
+	exclude := opts.Exclude
+	if exclude == nil {
+		exclude = DefaultExclude
+	}
+	files, err := walkHeaders(root, docset.NewFilter(opts.Include, exclude))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no headers found in %s", opts.Source)
+	}
+
+	log.Info("c header ingest starting", "library", name, "headers", len(files))
+
+	var headers []*header
+	for _, p := range files {
+		src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			log.Warn("failed to read header", "path", p, "err", err)
+			continue
+		}
+		h := parseHeader(p, shared.NormalizeLineEndings(string(src)))
+		if len(h.Decls) > 0 {
+			headers = append(headers, h)
+		}
+	}
+	if len(headers) == 0 {
+		return fmt.Errorf("no declarations found in the headers of %s", opts.Source)
+	}
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := ingestHeaders(ctx, tx, name, headers); err != nil {
+			return err
+		}
+		log.Info("c headers ingested", "library", name, "headers", len(headers))
+		return nil
+	})
+}
+
+// walkHeaders lists the headers below root that pass the filter, as
+// slash-separated paths relative to root.
+func walkHeaders(root string, filter docset.Filter) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || filter.SkipDir(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsHeader(rel) && filter.Match(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// DocPath returns the document path of a header of a library.
+func DocPath(name, header string) string {
+	return "c/" + name + "/" + header
+}
+
+// IncludePath returns the path a header is included by: its path without a
+// leading include/ directory.
+func IncludePath(header string) string {
+	return strings.TrimPrefix(header, "include/")
+}
+
+func ingestHeaders(ctx context.Context, tx *sql.Tx, name string, headers []*header) error {
+	prefix := "c/" + name + "/"
+	old, err := db.DocumentHashesTx(ctx, tx, prefix)
+	if err != nil {
+		return err
+	}
+	for p := range old {
+		if err := db.DeleteDocumentTx(ctx, tx, p); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range headers {
+		if err := writeHeader(ctx, tx, DocPath(name, h.Path), h); err != nil {
+			return err
+		}
+	}
+
+	title, md := renderIndex(name, headers)
+	_, err = docset.WriteDocument(ctx, tx, prefix+"index", title, md)
+	return err
+}
+
+// headerSections orders the declaration sections of a header document.
+var headerSections = []struct {
+	title string
+	kinds map[string]bool
+}{
+	{"Macros", map[string]bool{"Macro": true}},
+	{"Types", map[string]bool{"Struct": true, "Union": true, "Class": true, "Enum": true, "Typedef": true, "TypeAlias": true}},
+	{"Functions", map[string]bool{"Function": true}},
+	{"Variables", map[string]bool{"Variable": true}},
+}
+
+func renderHeader(h *header) string {
+	var b strings.Builder
+	b.WriteString("# " + h.Path + "\n\n")
+	b.WriteString("```" + h.Lang + "\n#include \"" + IncludePath(h.Path) + "\"\n```\n\n")
+	if h.Doc != "" {
+		b.WriteString(h.Doc + "\n\n")
+	}
+
+	for _, section := range headerSections {
+		first := true
+		for _, d := range h.Decls {
+			if !section.kinds[d.Kind] {
+				continue
+			}
+			if first {
+				b.WriteString("## " + section.title + "\n\n")
+				first = false
+			}
+			writeDecl(&b, "###", h.Lang, d)
+			writeMembers(&b, h.Lang, d)
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeDecl(b *strings.Builder, heading, lang string, d *decl) {
+	b.WriteString(heading + " " + d.Name + "\n\n")
+	b.WriteString("```" + lang + "\n" + d.Signature + "\n```\n\n")
+	if d.Doc != "" {
+		b.WriteString(d.Doc + "\n\n")
+	}
+}
+
+// writeMembers renders the members of a type: the constants of an enum and
+// the fields of a struct its definition shows as lists, and the members of
+// a C++ class under their own headings.
+func writeMembers(b *strings.Builder, lang string, d *decl) {
+	if len(d.Members) == 0 {
+		return
+	}
+	if !strings.Contains(d.Signature, "{") {
+		for _, m := range d.Members {
+			writeDecl(b, "####", lang, m)
+		}
+		return
+	}
+
+	if d.Kind == "Enum" {
+		b.WriteString("**Values**\n\n")
+	} else {
+		b.WriteString("**Fields**\n\n")
+	}
+	for _, m := range d.Members {
+		line := "- `" + strings.TrimLeft(strings.TrimPrefix(m.Name, d.Name), ".:") + "`"
+		if d.Kind == "Enum" {
+			line = "- `" + m.Signature + "`"
+		}
+		if doc := oneLine(m.Doc); doc != "" {
+			line += ": " + doc
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+}
+
+// renderIndex lists the headers of a library with their summaries.
+func renderIndex(name string, headers []*header) (title, md string) {
+	var b strings.Builder
+	b.WriteString("# " + name + "\n\n## Headers\n\n")
+	for _, h := range headers {
+		line := "- `" + h.Path + "`"
+		if h.Summary != "" {
+			line += ": " + h.Summary
+		}
+		b.WriteString(line + "\n")
+	}
+	return name, b.String()
+}
+
+func writeHeader(ctx context.Context, tx *sql.Tx, docPath string, h *header) error {
+	md := renderHeader(h)
+	docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
+		Path:   docPath,
+		Format: "markdown",
+		Body:   shared.Compress(md),
+		Hash:   db.HashBytes([]byte(md)),
+	})
+	if err != nil {
+		return err
+	}
+
+	include := `#include "` + IncludePath(h.Path) + `"`
+	if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+		Name:  h.Path,
+		Type:  "Header",
+		Body:  h.Path + " " + h.Summary,
+		DocID: docID,
+	}); err != nil {
+		return err
+	}
+	if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+		DocID:     docID,
+		Symbol:    h.Path,
+		Signature: include,
+		Summary:   h.Summary,
+	}); err != nil {
+		return err
+	}
+
+	// Overloads share a symbol, and one row with their declarations on
+	// separate lines.
+	var (
+		order  []string
+		byName = map[string]*decl{}
+	)
+	var collect func(ds []*decl)
+	collect = func(ds []*decl) {
+		for _, d := range ds {
+			if have, ok := byName[d.Name]; ok {
+				merged := *have
+				merged.Signature += "\n" + d.Signature
+				if merged.Summary == "" {
+					merged.Summary = d.Summary
+				}
+				merged.Doc += " " + d.Doc
+				byName[d.Name] = &merged
+			} else {
+				byName[d.Name] = d
+				order = append(order, d.Name)
+			}
+			collect(d.Members)
+		}
+	}
+	collect(h.Decls)
+
+	for _, symbol := range order {
+		d := byName[symbol]
+		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+			Name:  symbol,
+			Type:  d.Kind,
+			Body:  symbol + " " + d.Signature + " " + d.Doc,
+			DocID: docID,
+		}); err != nil {
+			return err
+		}
+		if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    symbol,
+			Signature: d.Signature,
+			Summary:   d.Summary,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
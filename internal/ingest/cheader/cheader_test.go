@@ -0,0 +1,117 @@
+package cheader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/codec"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func TestIngestHeaders(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"include/git2/buffer.h": cFixture,
+		"include/ui/widget.hpp": cppFixture,
+		"include/git2/empty.h":  "#pragma once\n#include <stddef.h>\n",
+		"tests/helper.h":        "/** A test helper. */\nint helper(void);\n",
+		"src/buffer.c":          "int git_buf_free(git_buf *buffer) { return 0; }\n",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := IngestHeaders(ctx, Options{Source: root, Name: "libgit2", DB: store}); err != nil {
+		t.Fatal(err)
+	}
+
+	readDoc := func(p string) string {
+		t.Helper()
+		doc, err := store.ReadDocument(ctx, p)
+		if err != nil {
+			t.Fatalf("ReadDocument(%s): %v", p, err)
+		}
+		raw, err := codec.Decompress(doc.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	index := readDoc("c/libgit2/index")
+	for _, want := range []string{
+		"# libgit2",
+		"- `include/git2/buffer.h`: Growable byte buffers.",
+		"- `include/ui/widget.hpp`: Widgets for drawing.",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index document lacks %q:\n%s", want, index)
+		}
+	}
+	if strings.Contains(index, "empty.h") || strings.Contains(index, "helper.h") {
+		t.Errorf("index lists a header without declarations or a test header:\n%s", index)
+	}
+
+	buffer := readDoc("c/libgit2/include/git2/buffer.h")
+	for _, want := range []string{
+		"# include/git2/buffer.h\n\n```c\n#include \"git2/buffer.h\"\n```\n\nGrowable byte buffers.",
+		"## Macros\n\n### GIT_BUF_MAX",
+		"### git_buf\n\n```c\ntypedef struct {\n    char *ptr;\n    size_t reserved;\n    size_t size;\n} git_buf\n```",
+		"**Values**\n\n- `GIT_OK = 0`: No error",
+		"### git_buf_free\n\n```c\nGIT_EXTERN(int) git_buf_free(git_buf *buffer)\n```\n\n> **Deprecated**: Use git_buf_dispose instead.",
+	} {
+		if !strings.Contains(buffer, want) {
+			t.Errorf("buffer.h document lacks %q:\n%s", want, buffer)
+		}
+	}
+
+	for symbol, want := range map[string]string{
+		"include/git2/buffer.h": `#include "git2/buffer.h"`,
+		"git_buf_dispose":       "GIT_EXTERN(void) git_buf_dispose(git_buf *buffer) __attribute__((nonnull))",
+		"git_buf.size":          "size_t size",
+		"GIT_ENOTFOUND":         "GIT_ENOTFOUND = -3",
+		"ui::Widget::resize":    "void resize(int w, int h) noexcept\nvoid resize(int side)",
+	} {
+		sym, err := store.GetSymbolContext(ctx, symbol)
+		if err != nil || sym.Signature != want {
+			t.Errorf("GetSymbolContext(%s) = %+v, %v", symbol, sym, err)
+		}
+	}
+	results, err := store.Search(ctx, "c/libgit2/dispose", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(results, func(r db.SearchResult) bool {
+		return r.Name == "git_buf_dispose" && r.Type == "Function"
+	}) {
+		t.Errorf("search for c/libgit2/dispose = %+v", results)
+	}
+
+	// A new ingest replaces the documents of headers that are gone.
+	if err := os.Remove(filepath.Join(root, "include", "ui", "widget.hpp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestHeaders(ctx, Options{Source: root, Name: "libgit2", DB: store}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.ReadDocument(ctx, "c/libgit2/include/ui/widget.hpp"); err == nil {
+		t.Error("document of a removed header kept")
+	}
+
+	if err := IngestHeaders(ctx, Options{Source: root, Subdir: "src", DB: store}); err == nil || !strings.Contains(err.Error(), "no headers found") {
+		t.Errorf("IngestHeaders(src) = %v", err)
+	}
+}
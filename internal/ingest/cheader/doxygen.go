@@ -0,0 +1,379 @@
+package cheader
+
+import (
+	"regexp"
+	"strings"
+)
+
+// docItem is an entry of a doc comment started by a command, such as a
+// @param, with the lines of its paragraph.
+type docItem struct {
+	name, dir string
+	lines     []string
+}
+
+func (it *docItem) text() string {
+	return inline(oneLine(strings.Join(it.lines, " ")))
+}
+
+// docComment is a Doxygen comment split into its brief, its description
+// and its block commands.
+type docComment struct {
+	lang                             string
+	brief, desc                      []string
+	params, tparams, retvals, throws []*docItem
+	notes                            []*docItem // the name of a note is its title
+	returns, since, deprecated       *docItem
+	see                              []string
+	hidden                           bool
+}
+
+// commentText returns the text of a doc comment without its delimiters and
+// the asterisks leading its lines.
+func commentText(c string) string {
+	if strings.HasPrefix(c, "//") {
+		c = strings.TrimPrefix(c[3:], "<")
+		return strings.TrimRight(strings.TrimPrefix(c, " "), " \t\r")
+	}
+	c = strings.TrimPrefix(strings.TrimSuffix(c[3:], "*/"), "<")
+	lines := strings.Split(c, "\n")
+	starred := true
+	for _, line := range lines[1:] {
+		if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "*") {
+			starred = false
+		}
+	}
+	for i, line := range lines {
+		if starred {
+			line = strings.TrimLeft(line, " \t")
+			if strings.HasPrefix(line, "*") {
+				line = strings.TrimPrefix(line[1:], " ")
+			}
+		}
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	if !starred && len(lines) > 1 {
+		lines = append(lines[:1], dedent(lines[1:])...)
+	}
+	lines[0] = strings.TrimSpace(lines[0])
+	for len(lines) > 0 && strings.Trim(lines[len(lines)-1], "*") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+var (
+	blockCommand = regexp.MustCompile(`^[@\\]([a-z]+)(\[[^\]]*\])?(\{[^}]*\})?(?:\s+(.*))?$`)
+	codeLang     = regexp.MustCompile(`^\{\.?(\w+)\}$`)
+)
+
+// noteTitles are the titles of the paragraphs commands such as @note
+// start, which are rendered as block quotes.
+var noteTitles = map[string]string{
+	"note": "Note", "remark": "Remark", "remarks": "Remark", "attention": "Attention",
+	"warning": "Warning", "bug": "Bug", "pre": "Precondition", "post": "Postcondition",
+	"invariant": "Invariant",
+}
+
+// ignoredCommands describe the file or its grouping rather than the
+// declaration, and are dropped with the paragraph they start.
+var ignoredCommands = map[string]bool{
+	"author": true, "authors": true, "date": true, "copyright": true, "version": true,
+	"ingroup": true, "defgroup": true, "addtogroup": true, "weakgroup": true, "name": true,
+	"cond": true, "endcond": true, "todo": true, "test": true,
+	"hideinitializer": true, "showinitializer": true, "nosubgrouping": true, "headerfile": true,
+}
+
+// parseDoxygen reads the doc comments of a declaration. Commands start
+// with @ or \. Consecutive line comments run on as one comment; block
+// comments are separate paragraphs.
+func parseDoxygen(comments []string, lang string) docComment {
+	c := docComment{lang: lang}
+	var text []string
+	for i, comment := range comments {
+		if i > 0 && !(strings.HasPrefix(comment, "//") && strings.HasPrefix(comments[i-1], "//")) {
+			text = append(text, "")
+		}
+		text = append(text, strings.Split(commentText(comment), "\n")...)
+	}
+
+	var (
+		target  = &c.desc
+		discard []string
+		fence   string // what closes the code block being read
+	)
+	item := func(list *[]*docItem, it *docItem) {
+		if list != nil {
+			*list = append(*list, it)
+		}
+		target = &it.lines
+	}
+	for _, line := range text {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if trimmed == fence || fence[0] != '`' && strings.HasPrefix(trimmed, fence) {
+				c.desc = append(c.desc, "```", "")
+				fence = ""
+			} else {
+				c.desc = append(c.desc, line)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+			c.desc = append(c.desc, "", "```"+strings.Trim(trimmed, "`~{}. "))
+			target = &c.desc
+			continue
+		case trimmed == "":
+			if len(c.desc) > 0 || len(c.brief) > 0 {
+				c.desc = append(c.desc, "")
+			}
+			target = &c.desc
+			continue
+		}
+
+		m := blockCommand.FindStringSubmatch(trimmed)
+		if m == nil {
+			*target = append(*target, line)
+			continue
+		}
+		cmd, opt, arg := m[1], strings.Trim(m[2], "[]"), strings.TrimSpace(m[4])
+		switch cmd {
+		case "brief", "short":
+			c.brief = append(c.brief, arg)
+			target = &c.brief
+		case "details":
+			c.desc = append(c.desc, arg)
+			target = &c.desc
+		case "param", "tparam", "retval", "throw", "throws", "exception":
+			name, rest, _ := strings.Cut(arg, " ")
+			it := &docItem{name: name, dir: opt, lines: []string{rest}}
+			switch cmd {
+			case "param":
+				item(&c.params, it)
+			case "tparam":
+				item(&c.tparams, it)
+			case "retval":
+				item(&c.retvals, it)
+			default:
+				item(&c.throws, it)
+			}
+		case "return", "returns", "result":
+			if c.returns == nil {
+				c.returns = &docItem{}
+			}
+			c.returns.lines = append(c.returns.lines, arg)
+			target = &c.returns.lines
+		case "since":
+			c.since = &docItem{lines: []string{arg}}
+			item(nil, c.since)
+		case "deprecated":
+			c.deprecated = &docItem{lines: []string{arg}}
+			item(nil, c.deprecated)
+		case "see", "sa":
+			c.see = append(c.see, arg)
+			target = &discard
+		case "code", "verbatim":
+			lang := ""
+			if cmd == "code" {
+				lang = c.lang
+				if l := codeLang.FindStringSubmatch(m[3]); l != nil {
+					lang = l[1]
+				}
+			}
+			c.desc = append(c.desc, "", "```"+lang)
+			fence = trimmed[:1] + "end" + cmd
+			target = &c.desc
+		case "par":
+			c.desc = append(c.desc, "", "**"+arg+"**", "")
+			target = &c.desc
+		case "li", "arg":
+			c.desc = append(c.desc, "- "+arg)
+			target = &c.desc
+		case "file":
+			// The file name is dropped, and the text after it describes
+			// the file.
+			target = &c.desc
+		case "internal", "private":
+			c.hidden = true
+			target = &discard
+		default:
+			switch {
+			case noteTitles[cmd] != "":
+				item(&c.notes, &docItem{name: noteTitles[cmd], lines: []string{arg}})
+			case ignoredCommands[cmd]:
+				target = &discard
+			default:
+				*target = append(*target, line)
+			}
+		}
+	}
+	if fence != "" {
+		c.desc = append(c.desc, "```")
+	}
+	return c
+}
+
+// description returns the brief and detailed description as Markdown.
+func (c docComment) description() string {
+	var paras []string
+	if brief := inline(oneLine(strings.Join(c.brief, " "))); brief != "" {
+		paras = append(paras, brief)
+	}
+	var (
+		lines []string
+		code  bool
+	)
+	for _, line := range c.desc {
+		if strings.HasPrefix(line, "```") {
+			code = !code
+			lines = append(lines, line)
+			continue
+		}
+		if !code {
+			line = inline(line)
+		}
+		lines = append(lines, line)
+	}
+	if desc := strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")); desc != "" {
+		paras = append(paras, desc)
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// summary returns the brief, or the first sentence of the description,
+// marked when the declaration is deprecated.
+func (c docComment) summary() string {
+	summary := inline(oneLine(strings.Join(c.brief, " ")))
+	if summary == "" {
+		para, _, _ := strings.Cut(strings.TrimSpace(strings.Join(c.desc, "\n")), "\n\n")
+		if !strings.HasPrefix(para, "```") {
+			summary = firstSentence(inline(oneLine(para)))
+		}
+	}
+	if c.deprecated != nil {
+		return strings.TrimSpace(summary + " (deprecated)")
+	}
+	return summary
+}
+
+// markdown converts the comment to Markdown, with its commands as labelled
+// sections.
+func (c docComment) markdown() string {
+	var parts []string
+	if c.deprecated != nil {
+		deprecated := "> **Deprecated**"
+		if text := c.deprecated.text(); text != "" {
+			deprecated += ": " + text
+		}
+		parts = append(parts, deprecated)
+	}
+	if desc := c.description(); desc != "" {
+		parts = append(parts, desc)
+	}
+	for _, note := range c.notes {
+		parts = append(parts, "> **"+note.name+"**: "+note.text())
+	}
+	if len(c.tparams) > 0 {
+		parts = append(parts, "**Template Parameters**", itemList(c.tparams))
+	}
+	if len(c.params) > 0 {
+		parts = append(parts, "**Parameters**", itemList(c.params))
+	}
+	if c.returns != nil {
+		parts = append(parts, "**Returns**", c.returns.text())
+	}
+	if len(c.retvals) > 0 {
+		parts = append(parts, "**Return Values**", itemList(c.retvals))
+	}
+	if len(c.throws) > 0 {
+		parts = append(parts, "**Throws**", itemList(c.throws))
+	}
+	if c.since != nil {
+		parts = append(parts, "Since: "+c.since.text())
+	}
+	if len(c.see) > 0 {
+		var see []string
+		for _, s := range c.see {
+			for _, ref := range strings.Split(s, ",") {
+				if ref = strings.TrimSpace(ref); ref != "" {
+					see = append(see, "- "+seeRef(ref))
+				}
+			}
+		}
+		parts = append(parts, "**See Also**", strings.Join(see, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// itemList renders named entries as a list: "- `name` (in): text".
+func itemList(items []*docItem) string {
+	lines := make([]string, len(items))
+	for i, it := range items {
+		line := "- `" + it.name + "`"
+		if it.dir != "" {
+			line += " (" + strings.ReplaceAll(it.dir, " ", "") + ")"
+		}
+		if text := it.text(); text != "" {
+			line += ": " + text
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// seeRef renders the target of a @see: a symbol as a code span, and a URL
+// or text as it reads.
+func seeRef(ref string) string {
+	if symbolRef.MatchString(ref) {
+		return "`" + ref + "`"
+	}
+	return inline(ref)
+}
+
+var symbolRef = regexp.MustCompile(`^[A-Za-z_~][\w:.~]*(\(\))?$`)
+
+// inlineWord is the argument of an inline command: a word, without the
+// punctuation after it unless it is a call's parentheses.
+const inlineWord = `[^\s,;:()]*[^\s,;:().](?:\(\))?`
+
+var (
+	inlineCode   = regexp.MustCompile(`(^|[^\w@\\])[@\\][cp]\s+(` + inlineWord + `)`)
+	inlineEmph   = regexp.MustCompile(`(^|[^\w@\\])[@\\](?:a|e|em)\s+(` + inlineWord + `)`)
+	inlineBold   = regexp.MustCompile(`(^|[^\w@\\])[@\\]b\s+(` + inlineWord + `)`)
+	inlineRef    = regexp.MustCompile(`[@\\]ref\s+([\w:~#]+(?:\.[\w:~#]+)*(?:\(\))?)(?:\s+"([^"]*)")?`)
+	inlineLink   = regexp.MustCompile(`[@\\]link\s+\S+\s+(.*?)\s*[@\\]endlink`)
+	inlineEscape = regexp.MustCompile(`\\([@\\&$#<>%".])`)
+)
+
+// inline converts the inline commands of a line: @c and @p to code spans,
+// @a and @e to emphasis, @b to bold and @ref to a code span or its text.
+func inline(s string) string {
+	if !strings.ContainsAny(s, `@\`) {
+		return s
+	}
+	s = inlineCode.ReplaceAllString(s, "$1`$2`")
+	s = inlineEmph.ReplaceAllString(s, "$1*$2*")
+	s = inlineBold.ReplaceAllString(s, "$1**$2**")
+	s = inlineLink.ReplaceAllString(s, "$1")
+	s = inlineRef.ReplaceAllStringFunc(s, func(m string) string {
+		sub := inlineRef.FindStringSubmatch(m)
+		if sub[2] != "" {
+			return sub[2]
+		}
+		return "`" + sub[1] + "`"
+	})
+	return inlineEscape.ReplaceAllString(s, "$1")
+}
+
+// firstSentence returns the text up to the first period followed by a
+// space.
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
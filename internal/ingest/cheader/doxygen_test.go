@@ -0,0 +1,88 @@
+package cheader
+
+import "testing"
+
+func TestDoxygenMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []string
+		markdown string
+		summary  string
+	}{
+		{
+			name: "javadoc style",
+			comments: []string{`/**
+ * Lookup a reference by name.
+ *
+ * The name is checked for validity; see @ref git_reference_name_is_valid.
+ *
+ * @param[out] out pointer to the looked-up reference
+ * @param repo the repository to look up the reference
+ * @param name the long name for the reference, e.g.
+ *        refs/heads/main
+ * @return 0 or an error code
+ * @retval GIT_ENOTFOUND if the reference does not exist
+ * @note The reference must be freed.
+ * @since 1.2
+ * @see git_reference_free, git_reference_dwim
+ */`},
+			markdown: "Lookup a reference by name.\n\nThe name is checked for validity; see `git_reference_name_is_valid`.\n\n" +
+				"> **Note**: The reference must be freed.\n\n" +
+				"**Parameters**\n\n- `out` (out): pointer to the looked-up reference\n- `repo`: the repository to look up the reference\n- `name`: the long name for the reference, e.g. refs/heads/main\n\n" +
+				"**Returns**\n\n0 or an error code\n\n" +
+				"**Return Values**\n\n- `GIT_ENOTFOUND`: if the reference does not exist\n\n" +
+				"Since: 1.2\n\n" +
+				"**See Also**\n\n- `git_reference_free`\n- `git_reference_dwim`",
+			summary: "Lookup a reference by name.",
+		},
+		{
+			name: "qt style with brief",
+			comments: []string{`/*!
+    \brief Creates a widget
+    with a parent.
+
+    Use \c show() to display it and \a parent to nest it.
+
+    \code{.cpp}
+    auto *w = new Widget(parent);
+    \endcode
+
+    \deprecated Use \b make_widget instead.
+    \author Jane Doe
+    \throws std::bad_alloc when out of memory
+*/`},
+			markdown: "> **Deprecated**: Use **make_widget** instead.\n\n" +
+				"Creates a widget with a parent.\n\nUse `show()` to display it and *parent* to nest it.\n\n" +
+				"```cpp\nauto *w = new Widget(parent);\n```\n\n" +
+				"**Throws**\n\n- `std::bad_alloc`: when out of memory",
+			summary: "Creates a widget with a parent. (deprecated)",
+		},
+		{
+			name:     "line comments",
+			comments: []string{"/// Frees a buffer.", "///", "/// Safe to call twice. Does nothing on NULL."},
+			markdown: "Frees a buffer.\n\nSafe to call twice. Does nothing on NULL.",
+			summary:  "Frees a buffer.",
+		},
+		{
+			name:     "trailing",
+			comments: []string{"/**< The number of bytes. */"},
+			markdown: "The number of bytes.",
+			summary:  "The number of bytes.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := parseDoxygen(tt.comments, "c")
+			if got := c.markdown(); got != tt.markdown {
+				t.Errorf("markdown =\n%s\nwant\n%s", got, tt.markdown)
+			}
+			if got := c.summary(); got != tt.summary {
+				t.Errorf("summary = %q, want %q", got, tt.summary)
+			}
+		})
+	}
+
+	if !parseDoxygen([]string{"/** @internal Used by the allocator. */"}, "c").hidden {
+		t.Error("@internal comment not hidden")
+	}
+}
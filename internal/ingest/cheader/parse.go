@@ -0,0 +1,944 @@
+package cheader
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// header is the API read from one header file.
+type header struct {
+	Path    string // slash-separated, relative to the source root
+	Lang    string // c or cpp, the language of its code blocks
+	Doc     string // the Markdown of its @file comment
+	Summary string
+	Decls   []*decl
+}
+
+// decl is a declaration of a header: a macro, function, variable or type,
+// or a member of a type or enum.
+type decl struct {
+	// Name is qualified the way code refers to it: git_buf.ptr for a
+	// field of a C struct, ns::Widget::resize for a C++ method.
+	Name      string
+	Kind      string
+	Signature string
+	Summary   string
+	Doc       string // Markdown
+	Members   []*decl
+}
+
+// typeKinds are the kinds of decl listed as types of a header rather than
+// as members of one.
+var typeKinds = map[string]bool{"Struct": true, "Union": true, "Class": true, "Enum": true}
+
+var (
+	cppExtensions = map[string]bool{".hpp": true, ".hh": true, ".hxx": true, ".h++": true, ".hp": true, ".ipp": true, ".tpp": true, ".inl": true}
+	cppMarker     = regexp.MustCompile(`(?m)^\s*(namespace\s+[\w:]+\s*\{|template\s*<|class\s+\w+[^;(]*\{)`)
+	defineName    = regexp.MustCompile(`^define\s+(\w+)(\([^)]*\))?(.*)`)
+	identifierEnd = regexp.MustCompile(`~?[A-Za-z_$][\w$]*$`)
+	operatorName  = regexp.MustCompile(`operator\s*(\(\)|\[\]|new\[\]|delete\[\]|[^\w\s(]+|\w[\w\s:*&<>]*?)\s*$`)
+)
+
+// internalNamespaces hold implementation details by convention and are not
+// documented.
+var internalNamespaces = map[string]bool{"detail": true, "details": true, "internal": true, "impl": true}
+
+// headerLang reports the language of a header: C++ for the C++ header
+// extensions, or a .h file with namespaces, templates or classes.
+func headerLang(p, src string) string {
+	if cppExtensions[strings.ToLower(path.Ext(p))] || cppMarker.MatchString(removeComments(src)) {
+		return "cpp"
+	}
+	return "c"
+}
+
+// parseHeader reads the declarations of a header and their doc comments.
+func parseHeader(p, src string) *header {
+	h := &header{Path: p, Lang: headerLang(p, src)}
+	ps := &parser{h: h, seen: map[string]*decl{}}
+	ps.walk(scanItems(src), scope{access: "public"})
+	return h
+}
+
+type parser struct {
+	h     *header
+	guard string           // the include guard macro
+	seen  map[string]*decl // C declarations by kind and name, read once across #if branches
+	decls int              // declarations read, to tell the include guard from other #ifndefs
+}
+
+// scope is where declarations are read: a namespace or the body of a type.
+type scope struct {
+	prefix string // qualifies names declared in it: "ns::" or "git_buf."
+	owner  *decl  // the type whose members are read, or nil
+	class  string // the owner's simple name, which constructors have
+	access string
+	static bool // members are fields of a C struct rather than of a class
+}
+
+func (p *parser) sep() string {
+	if p.h.Lang == "cpp" {
+		return "::"
+	}
+	return "."
+}
+
+func (p *parser) walk(items []*item, s scope) {
+	for _, it := range items {
+		switch {
+		case it.directive != "":
+			p.directive(it)
+		case it.access != "":
+			s.access = it.access
+		case s.owner != nil && s.access != "public":
+		default:
+			p.declaration(it, s)
+		}
+	}
+}
+
+// directive reads the macros a header defines. Include guards and macros
+// defined empty, as feature switches are, are left out unless documented.
+func (p *parser) directive(it *item) {
+	d := strings.TrimSpace(strings.TrimPrefix(it.directive, "#"))
+	if rest, ok := strings.CutPrefix(d, "ifndef"); ok && p.guard == "" && p.decls == 0 {
+		p.guard = strings.TrimSpace(rest)
+		return
+	}
+	m := defineName.FindStringSubmatch(d)
+	if m == nil {
+		return
+	}
+	docs := append(p.takeDocs(it.doc), it.trailing...)
+	name, value := m[1], strings.TrimSpace(m[3])
+	if name == p.guard && value == "" {
+		return
+	}
+	if len(docs) == 0 && m[2] == "" && value == "" {
+		return
+	}
+	p.add(&decl{Name: name, Kind: "Macro", Signature: "#" + d}, docs, scope{})
+}
+
+// declaration reads a declaration of a namespace or of the body of a type.
+func (p *parser) declaration(it *item, s scope) {
+	docs := append(p.takeDocs(it.doc), it.trailing...)
+	h := stripAttributes(it.header)
+	_, h = cutTemplate(h)
+	if h == "" && !it.hasBody {
+		return
+	}
+	word := identifier.FindString(h)
+	switch {
+	case word == "namespace" || strings.HasPrefix(h, "inline namespace"):
+		name := namespaceName.FindString(strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(h, "inline "), "namespace")))
+		if !it.hasBody || name == "" || internalNamespaces[lastSegment(name)] {
+			return
+		}
+		inner := scope{prefix: s.prefix, access: "public"}
+		if !strings.HasPrefix(h, "inline") {
+			inner.prefix += name + "::"
+		}
+		p.walk(scanItems(it.body), inner)
+		return
+	case strings.HasPrefix(h, `extern "C`):
+		if it.hasBody {
+			p.walk(scanItems(it.body), s)
+			return
+		}
+		h = strings.TrimSpace(h[strings.Index(h[8:], `"`)+9:])
+	case word == "using":
+		before, _, ok := strings.Cut(h, "=")
+		if !ok || s.static {
+			return
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(before, "using"))
+		p.add(&decl{Name: s.prefix + name, Kind: "TypeAlias", Signature: formatSignature(it.header)}, docs, s)
+		return
+	case word == "typedef":
+		p.typedef(it, strings.TrimSpace(strings.TrimPrefix(h, "typedef")), docs, s)
+		return
+	case word == "friend" || word == "static_assert" || word == "_Static_assert" || word == "template":
+		return
+	case !it.hasBody && macroName.MatchString(h):
+		// A macro standing alone, such as G_BEGIN_DECLS.
+		return
+	}
+
+	if kind, rest := aggregateKeyword(h); kind != "" && (it.hasBody || topLevelIndex(h, '(') < 0) {
+		if it.hasBody {
+			p.aggregate(it, kind, rest, docs, s)
+			return
+		}
+		if len(strings.Fields(rest)) <= 1 {
+			return // a forward declaration
+		}
+	}
+	p.member(it, h, docs, s)
+}
+
+// typedef reads a typedef: of a struct, union or enum defined with it, or
+// of another type or a function pointer.
+func (p *parser) typedef(it *item, rest string, docs []string, s scope) {
+	if it.hasBody {
+		kind, tagRest := aggregateKeyword(rest)
+		if kind == "" {
+			return
+		}
+		name := firstDeclarator(it.tail)
+		if name == "" {
+			name = aggregateName(tagRest)
+		}
+		p.addType(it, kind, name, docs, s)
+		return
+	}
+	name, _, _ := declarator(rest)
+	if name == "" {
+		return
+	}
+	p.add(&decl{Name: s.prefix + name, Kind: "Typedef", Signature: formatSignature(it.header)}, docs, s)
+}
+
+// aggregate reads a struct, union, class or enum definition, or a member
+// of a struct whose type is defined along with it.
+func (p *parser) aggregate(it *item, kind, rest string, docs []string, s scope) {
+	if s.owner != nil && it.tail != "" {
+		name := firstDeclarator(it.tail)
+		if name != "" {
+			p.add(&decl{Name: s.prefix + name, Kind: "Field", Signature: oneLine(definition(it))}, docs, s)
+		}
+		return
+	}
+	name := aggregateName(rest)
+	if name == "" {
+		return
+	}
+	p.addType(it, kind, name, docs, s)
+}
+
+// addType adds a type and its members. The signature of an enum, a C struct
+// or a C++ struct of data members is its whole definition; a C++ class is
+// declared by its header and documents its members one by one.
+func (p *parser) addType(it *item, kind, name string, docs []string, s scope) {
+	d := &decl{Name: s.prefix + name, Kind: kind}
+	if kind == "Enum" {
+		d.Signature = definition(it)
+		p.add(d, docs, s)
+		p.enumConstants(d, it, s)
+		return
+	}
+
+	items := scanItems(it.body)
+	inner := scope{prefix: d.Name + p.sep(), owner: d, class: name, access: "public"}
+	if p.h.Lang == "c" || kind != "Class" && plainData(items) {
+		d.Signature = definition(it)
+		inner.static = true
+	} else {
+		d.Signature = formatSignature(it.header)
+		if kind == "Class" {
+			inner.access = "private"
+		}
+	}
+	if p.add(d, docs, s) {
+		p.walk(items, inner)
+	}
+}
+
+// plainData reports whether the body of a C++ struct or union only holds
+// data members, as C structs do.
+func plainData(items []*item) bool {
+	for _, it := range items {
+		switch {
+		case it.directive != "":
+		case it.access != "":
+			return false
+		case topLevelIndex(stripAttributes(it.header), '(') >= 0 && !strings.Contains(it.header, "(*"):
+			return false
+		case strings.HasPrefix(it.header, "using ") || strings.HasPrefix(it.header, "template") || strings.HasPrefix(it.header, "static"):
+			return false
+		}
+	}
+	return true
+}
+
+// enumConstants adds the constants of an enum, which are members of the
+// enclosing scope unless it is a scoped C++ enum.
+func (p *parser) enumConstants(d *decl, it *item, s scope) {
+	prefix := s.prefix
+	if strings.HasPrefix(it.header, "enum class") || strings.HasPrefix(it.header, "enum struct") {
+		prefix = d.Name + "::"
+	}
+	for _, e := range splitList(it.body) {
+		name := identifier.FindString(e.text)
+		if name == "" || strings.HasPrefix(name, "_") && len(e.doc)+len(e.trailing) == 0 {
+			continue
+		}
+		c := parseDoxygen(append(e.doc, e.trailing...), p.h.Lang)
+		if c.hidden {
+			continue
+		}
+		d.Members = append(d.Members, &decl{
+			Name:      prefix + name,
+			Kind:      "EnumConstant",
+			Signature: oneLine(e.text),
+			Summary:   c.summary(),
+			Doc:       c.markdown(),
+		})
+	}
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][\w$]*`)
+
+// namespaceName matches the name of a namespace, which may be nested as in
+// namespace a::b, ahead of any attribute macros.
+var namespaceName = regexp.MustCompile(`^[A-Za-z_]\w*(?:\s*::\s*[A-Za-z_]\w*)*`)
+
+// member reads a function, variable or typedef-less function pointer, at
+// namespace scope or as a member of a type.
+func (p *parser) member(it *item, h string, docs []string, s scope) {
+	if it.hasBody {
+		h = cutInitList(h)
+	}
+	d := h
+	if i := initializerIndex(h); i >= 0 {
+		d = strings.TrimSpace(h[:i])
+	}
+	name, fn, start := declarator(d)
+	if name == "" {
+		return
+	}
+	ret := strings.TrimSpace(d[:start])
+	static := hasWord(ret, "static")
+
+	if fn {
+		kind := "Function"
+		switch {
+		case s.owner == nil && ret == "":
+			return // a macro invocation, such as DECLARE_HANDLE(x);
+		case s.owner == nil && static && !hasWord(ret, "inline"):
+			return
+		case s.owner != nil && (name == s.class || name == "explicit "+s.class):
+			kind = "Constructor"
+		case s.owner != nil && name == "~"+s.class:
+			kind = "Destructor"
+		case s.owner != nil:
+			kind = "Method"
+		}
+		p.add(&decl{Name: s.prefix + name, Kind: kind, Signature: formatSignature(cutInitList(removeComments(it.header)))}, docs, s)
+		return
+	}
+
+	kind := "Variable"
+	if s.owner != nil {
+		kind = "Field"
+	} else if static && !hasWord(ret, "const") && !hasWord(ret, "constexpr") {
+		return
+	}
+	sig := it.header
+	if i := initializerIndex(sig); i >= 0 {
+		if init := strings.TrimSpace(sig[i+1:]); strings.Contains(init, "\n") || len(init) > 60 {
+			sig = strings.TrimSpace(sig[:i])
+		}
+	}
+	sig = formatSignature(sig)
+
+	// int x, y; declares each of its names.
+	names := []string{name}
+	if parts := splitTopLevel(d, ','); len(parts) > 1 {
+		names = nil
+		for _, part := range parts {
+			if n, _, _ := declarator(part); n != "" {
+				names = append(names, n)
+			}
+		}
+	}
+	for _, n := range names {
+		p.add(&decl{Name: s.prefix + n, Kind: kind, Signature: sig}, docs, s)
+	}
+}
+
+// add records a declaration with its doc comments. Types and macros are
+// listed in the header and members in their type. C declarations repeated
+// in the branches of a conditional are read once, documented by whichever
+// branch has a comment. It reports whether the declaration was added.
+func (p *parser) add(d *decl, docs []string, s scope) bool {
+	simple := d.Name[strings.LastIndexAny(d.Name, ".:")+1:]
+	if strings.HasPrefix(simple, "_") && len(docs) == 0 {
+		return false
+	}
+	c := parseDoxygen(docs, p.h.Lang)
+	if c.hidden {
+		return false
+	}
+	d.Doc, d.Summary = c.markdown(), c.summary()
+
+	if p.h.Lang == "c" {
+		key := d.Kind + " " + d.Name
+		if have, ok := p.seen[key]; ok {
+			if have.Doc == "" {
+				have.Doc, have.Summary = d.Doc, d.Summary
+			}
+			return false
+		}
+		p.seen[key] = d
+	}
+
+	p.decls++
+	if s.owner != nil && !typeKinds[d.Kind] {
+		s.owner.Members = append(s.owner.Members, d)
+	} else {
+		p.h.Decls = append(p.h.Decls, d)
+	}
+	return true
+}
+
+// takeDocs returns the doc comments that document a declaration, setting
+// the header's own documentation from an @file comment. Comments that open
+// or close Doxygen groups, or document something elsewhere with @fn or
+// @def, are left out.
+func (p *parser) takeDocs(comments []string) []string {
+	var docs []string
+	for i := 0; i < len(comments); i++ {
+		c := comments[i]
+		text := commentText(c)
+		switch {
+		case fileCommand.MatchString(text):
+			// Line comments run on until a block comment.
+			group := []string{c}
+			for strings.HasPrefix(c, "//") && i+1 < len(comments) && strings.HasPrefix(comments[i+1], "//") {
+				i++
+				group = append(group, comments[i])
+			}
+			if p.h.Doc == "" {
+				fc := parseDoxygen(group, p.h.Lang)
+				p.h.Doc, p.h.Summary = fc.markdown(), fc.summary()
+			}
+		case detachedCommand.MatchString(text) || groupMarker.MatchString(strings.TrimSpace(text)):
+		default:
+			docs = append(docs, c)
+		}
+	}
+	return docs
+}
+
+var (
+	fileCommand     = regexp.MustCompile(`(?m)^\s*[@\\]file\b`)
+	detachedCommand = regexp.MustCompile(`(?m)^\s*[@\\](defgroup|addtogroup|weakgroup|name|fn|def|struct|union|enum|typedef|var|class|namespace|page|mainpage|section)\b`)
+	groupMarker     = regexp.MustCompile(`^([@\\][{}]\s*)+$`)
+)
+
+// aggregateKeyword returns the kind of type a declaration defines or refers
+// to, and its text after the keyword.
+func aggregateKeyword(h string) (kind, rest string) {
+	for _, k := range []struct{ prefix, kind string }{
+		{"enum class", "Enum"},
+		{"enum struct", "Enum"},
+		{"struct", "Struct"},
+		{"union", "Union"},
+		{"class", "Class"},
+		{"enum", "Enum"},
+	} {
+		if rest, ok := strings.CutPrefix(h, k.prefix); ok && (rest == "" || !isIdentByte(rest[0])) {
+			return k.kind, strings.TrimSpace(rest)
+		}
+	}
+	return "", h
+}
+
+// aggregateName returns the tag of a struct, union, class or enum from the
+// text after its keyword: the last word before a base clause or underlying
+// type, past export macros and final.
+func aggregateName(rest string) string {
+	rest = stripAttributes(rest)
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == ':' {
+			if i+1 < len(rest) && rest[i+1] == ':' {
+				i++
+				continue
+			}
+			rest = rest[:i]
+			break
+		}
+	}
+	fields := strings.Fields(rest)
+	for len(fields) > 0 && (fields[len(fields)-1] == "final" || fields[len(fields)-1] == "sealed") {
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// firstDeclarator returns the first name declared after a struct body, as
+// in typedef struct { ... } name, *name_ptr.
+func firstDeclarator(tail string) string {
+	for _, part := range splitTopLevel(stripAttributes(tail), ',') {
+		if !strings.ContainsAny(part, "*([") && identifier.MatchString(part) {
+			return identifier.FindString(part)
+		}
+	}
+	return ""
+}
+
+// declarator finds the name a declaration declares, whether it declares a
+// function, and where the name starts. A function's parameters are the
+// last parenthesized group preceded by a name; a group preceded by another
+// one, as in int (*cb)(void), is a function pointer named in the first.
+func declarator(decl string) (name string, fn bool, start int) {
+	d := stripAttributes(decl)
+	if i := strings.Index(d, "->"); i >= 0 && topLevelIndex(d, '(') >= 0 && topLevelIndex(d, '(') < i {
+		d = d[:i] // a trailing return type
+	}
+
+	var groups []int
+	depth := 0
+	for i := 0; i < len(d); i++ {
+		switch d[i] {
+		case '(':
+			if depth == 0 {
+				groups = append(groups, i)
+			}
+			depth++
+		case ')':
+			depth--
+		case '"', '\'':
+			i = skipLiteral(d, i) - 1
+		}
+	}
+
+	for g := len(groups) - 1; g >= 0; g-- {
+		before := strings.TrimRight(d[:groups[g]], " \t\n")
+		if m := operatorName.FindStringSubmatchIndex(before); m != nil && !(groups[g]+1 < len(d) && d[groups[g]+1] == ')' && strings.HasSuffix(before, "operator")) {
+			op := strings.Join(strings.Fields(before[m[2]:m[3]]), " ")
+			if isIdentByte(op[0]) {
+				op = " " + op
+			}
+			return "operator" + op, true, offset(decl, before[:m[0]])
+		}
+		if strings.HasSuffix(before, "operator") {
+			// operator() is followed by its parameters.
+			continue
+		}
+		if strings.HasSuffix(before, ")") && g > 0 {
+			inner := d[groups[g-1]+1 : strings.LastIndex(before, ")")]
+			if i := strings.Index(inner, "("); i >= 0 {
+				// A function returning a function pointer, as in
+				// void (*signal(int sig))(int).
+				if m := identifierEnd.FindString(strings.TrimSpace(inner[:i])); m != "" {
+					return m, true, offset(decl, d[:groups[g-1]])
+				}
+			} else if m := identifierEnd.FindString(strings.TrimSpace(inner)); m != "" {
+				return m, false, offset(decl, d[:groups[g-1]])
+			}
+			return "", false, 0
+		}
+		if m := identifierEnd.FindStringIndex(before); m != nil {
+			name := before[m[0]:]
+			if nonNames[name] || g > 0 && trailingMacro(name, before[:m[0]]) {
+				continue
+			}
+			return name, true, offset(decl, before[:m[0]])
+		}
+	}
+	if len(groups) > 0 {
+		return "", false, 0
+	}
+
+	// A variable: the last name before an array size or bit-field width.
+	end := len(d)
+	if i := topLevelIndex(d, '['); i >= 0 {
+		end = i
+	}
+	if i := strings.LastIndex(d[:end], ":"); i >= 0 && (i == 0 || d[i-1] != ':') && (i+1 >= len(d) || d[i+1] != ':') {
+		end = i
+	}
+	before := strings.TrimRight(d[:end], " \t\n")
+	m := identifierEnd.FindStringIndex(before)
+	if m == nil || m[0] == 0 {
+		// A type without a name declares nothing.
+		return "", false, 0
+	}
+	return before[m[0]:], false, offset(decl, before[:m[0]])
+}
+
+// nonNames are keywords that may precede a parenthesized group without
+// naming a function.
+// trailingMacro reports whether name, preceded by prefix, is an attribute
+// macro following a parameter list, as in
+// void f(int x) DEPRECATED("use g").
+func trailingMacro(name, prefix string) bool {
+	if !macroName.MatchString(name) {
+		return false
+	}
+	prefix = strings.TrimRight(prefix, " \t\n&")
+	for {
+		m := identifierEnd.FindStringIndex(prefix)
+		if m == nil || !qualifiers[prefix[m[0]:]] {
+			break
+		}
+		prefix = strings.TrimRight(prefix[:m[0]], " \t\n&")
+	}
+	return strings.HasSuffix(prefix, ")")
+}
+
+// qualifiers may follow the parameter list of a function.
+var qualifiers = map[string]bool{
+	"const": true, "volatile": true, "noexcept": true, "override": true, "final": true,
+}
+
+var nonNames = map[string]bool{
+	"sizeof": true, "alignof": true, "decltype": true, "noexcept": true, "throw": true,
+	"alignas": true, "_Alignas": true, "__attribute__": true, "__declspec": true, "requires": true,
+}
+
+// offset maps the length of a prefix of a declaration's stripped text back
+// to the declaration itself, approximately where attributes were removed.
+func offset(decl, prefix string) int {
+	words := strings.Fields(prefix)
+	if len(words) == 0 {
+		return 0
+	}
+	i := 0
+	for _, w := range words {
+		j := strings.Index(decl[i:], w)
+		if j < 0 {
+			return min(len(prefix), len(decl))
+		}
+		i += j + len(w)
+	}
+	return i
+}
+
+// initializerIndex returns the index of the = starting the initializer of a
+// declaration, or -1.
+func initializerIndex(h string) int {
+	depth := 0
+	for i := 0; i < len(h); i++ {
+		switch h[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '"', '\'':
+			i = skipLiteral(h, i) - 1
+		case '=':
+			if depth == 0 && isAssign(h, i) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// cutInitList removes the member initializer list of a constructor
+// definition.
+func cutInitList(h string) string {
+	closing := strings.LastIndex(h, ")")
+	if closing < 0 {
+		return h
+	}
+	depth := 0
+	for i := 0; i < len(h); i++ {
+		switch h[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ':':
+			if depth == 0 && i > strings.Index(h, ")") && (i+1 >= len(h) || h[i+1] != ':') && h[i-1] != ':' {
+				return strings.TrimSpace(h[:i])
+			}
+		}
+	}
+	return h
+}
+
+// cutTemplate splits the template parameter lists off a declaration.
+func cutTemplate(h string) (tmpl, rest string) {
+	h = strings.TrimSpace(h)
+	for {
+		after, ok := strings.CutPrefix(h, "template")
+		after = strings.TrimLeft(after, " \t\n")
+		if !ok || !strings.HasPrefix(after, "<") {
+			return tmpl, h
+		}
+		depth := 0
+		end := len(after)
+		for i := 0; i < len(after); i++ {
+			if after[i] == '<' {
+				depth++
+			} else if after[i] == '>' {
+				depth--
+				if depth == 0 {
+					end = i + 1
+					break
+				}
+			}
+		}
+		tmpl += "template " + after[:end] + " "
+		h = strings.TrimSpace(after[end:])
+		if rest, ok := strings.CutPrefix(h, "requires"); ok {
+			// A requires clause ends at the line's end.
+			_, h, _ = strings.Cut(rest, "\n")
+			h = strings.TrimSpace(h)
+		}
+	}
+}
+
+// stripAttributes removes the attributes of a declaration, which do not
+// change what it declares: __attribute__((...)), __declspec(...), [[...]],
+// alignas(...), noexcept(...) and GCC's __macros(...).
+func stripAttributes(s string) string {
+	if !strings.ContainsAny(s, "[_an") {
+		return strings.TrimSpace(s)
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "[[") {
+			if end := strings.Index(s[i:], "]]"); end >= 0 {
+				i += end + 2
+				b.WriteByte(' ')
+				continue
+			}
+		}
+		if isIdentByte(s[i]) && (i == 0 || !isIdentByte(s[i-1])) {
+			j := i
+			for j < len(s) && isIdentByte(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			k := j
+			for k < len(s) && (s[k] == ' ' || s[k] == '\t') {
+				k++
+			}
+			if (strings.HasPrefix(word, "__") || attributeWords[word]) && k < len(s) && s[k] == '(' {
+				end := matchParen(s, k)
+				i = end
+				b.WriteByte(' ')
+				continue
+			}
+			b.WriteString(word)
+			i = j
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var attributeWords = map[string]bool{"alignas": true, "_Alignas": true, "noexcept": true, "throw": true}
+
+// matchParen returns the index after the parenthesis closing the one at i.
+func matchParen(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		case '"', '\'':
+			j = skipLiteral(s, j) - 1
+		}
+	}
+	return len(s)
+}
+
+// listEntry is an entry of an enum body with its doc comments.
+type listEntry struct {
+	doc, trailing []string
+	text          string
+}
+
+// splitList splits an enum body at its top-level commas. A trailing doc
+// comment after a comma documents the entry before it.
+func splitList(body string) []listEntry {
+	var (
+		entries []listEntry
+		cur     listEntry
+		b       strings.Builder
+		depth   int
+	)
+	finish := func() {
+		cur.text = strings.TrimSpace(b.String())
+		if cur.text != "" {
+			entries = append(entries, cur)
+		}
+		cur, b = listEntry{}, strings.Builder{}
+	}
+	for i := 0; i < len(body); {
+		ch := body[i]
+		switch {
+		case ch == '"' || ch == '\'':
+			end := skipLiteral(body, i)
+			b.WriteString(body[i:end])
+			i = end
+			continue
+		case strings.HasPrefix(body[i:], "//") || strings.HasPrefix(body[i:], "/*"):
+			end := commentEnd(body, i)
+			switch c := body[i:end]; docKind(c) {
+			case leadingDoc:
+				cur.doc = append(cur.doc, c)
+			case trailingDoc:
+				if strings.TrimSpace(b.String()) == "" && len(entries) > 0 {
+					last := &entries[len(entries)-1]
+					last.trailing = append(last.trailing, c)
+				} else {
+					cur.trailing = append(cur.trailing, c)
+				}
+			}
+			b.WriteByte(' ')
+			i = end
+			continue
+		case ch == '\n':
+			b.WriteByte(' ')
+			i = skipDirectiveLines(body, i+1)
+			continue
+		case ch == '(' || ch == '[' || ch == '{':
+			depth++
+		case ch == ')' || ch == ']' || ch == '}':
+			depth--
+		case ch == ',' && depth == 0:
+			finish()
+			i++
+			continue
+		}
+		b.WriteByte(ch)
+		i++
+	}
+	finish()
+	return entries
+}
+
+// splitTopLevel splits s at the separators outside brackets.
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}', '>':
+			depth--
+		case '"', '\'':
+			i = skipLiteral(s, i) - 1
+		case sep:
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// definition renders the whole definition of a type: its header, its body
+// without comments and conditionals, reindented, and the names declared
+// after it.
+func definition(it *item) string {
+	var lines []string
+	for _, line := range strings.Split(removeComments(it.body), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	lines = dedent(lines)
+
+	var b strings.Builder
+	b.WriteString(oneLine(it.header))
+	if len(lines) == 0 {
+		b.WriteString(" {}")
+	} else {
+		b.WriteString(" {\n")
+		for _, line := range lines {
+			b.WriteString("    " + line + "\n")
+		}
+		b.WriteString("}")
+	}
+	if it.tail != "" {
+		b.WriteString(" " + oneLine(it.tail))
+	}
+	return b.String()
+}
+
+// formatSignature renders a declaration on one line, or with its line breaks
+// when it is longer than 100 characters.
+func formatSignature(s string) string {
+	s = strings.TrimSpace(s)
+	if line := signatureSpace.Replace(oneLine(s)); len(line) <= 100 {
+		return line
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	if len(lines) > 1 {
+		rest := dedent(lines[1:])
+		for i, line := range rest {
+			rest[i] = "    " + line
+		}
+		lines = append(lines[:1], rest...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+var signatureSpace = strings.NewReplacer("( ", "(", " )", ")")
+
+// dedent removes the indentation lines have in common.
+func dedent(lines []string) []string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, prefix)
+	}
+	return out
+}
+
+func hasWord(s, word string) bool {
+	for _, w := range strings.Fields(s) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+func lastSegment(name string) string {
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		return name[i+2:]
+	}
+	return name
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,355 @@
+package cheader
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const cFixture = `/**
+ * @file buffer.h
+ * @brief Growable byte buffers.
+ *
+ * Buffers own their memory.
+ */
+#ifndef GIT_BUFFER_H
+#define GIT_BUFFER_H
+
+#include <stddef.h>
+
+G_BEGIN_DECLS
+
+/** Maximum size of a buffer, in bytes. */
+#define GIT_BUF_MAX (1 << 30)
+
+/**
+ * Returns the larger of @p a and @p b.
+ */
+#define GIT_MAX(a, b) \
+	((a) > (b) ? (a) : (b))
+
+#define GIT_HAVE_THREADS
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/**
+ * A data buffer for exporting data from libgit2.
+ */
+typedef struct {
+	char *ptr;     /**< The buffer contents. */
+	size_t reserved; ///< Bytes allocated.
+	/** Bytes used. */
+	size_t size;
+} git_buf;
+
+/** Error codes. */
+typedef enum {
+	GIT_OK = 0,     /**< No error */
+	GIT_ERROR = -1, /**< Generic error */
+	/** Requested object could not be found */
+	GIT_ENOTFOUND = -3,
+	_GIT_PRIVATE = 99
+} git_error_code;
+
+struct git_repository;
+
+/** Options for opening a repository. */
+struct git_open_options {
+	unsigned int version;
+	/** Called for each progress update. */
+	int (*progress)(unsigned int current, void *payload);
+	struct { int depth; } limits; /**< Search limits. */
+};
+
+/**
+ * Free the memory referred to by the git_buf.
+ *
+ * @param[in] buffer The buffer to deallocate
+ * @return 0 on success, or an error code
+ * @deprecated Use git_buf_dispose instead.
+ */
+GIT_EXTERN(int) git_buf_free(git_buf *buffer);
+
+/// Dispose a buffer.
+GIT_EXTERN(void) git_buf_dispose(
+	git_buf *buffer) __attribute__((nonnull));
+
+#ifdef GIT_WIN32
+/** Open a repository at a path. */
+GIT_EXTERN(int) git_repository_open(struct git_repository **out, const wchar_t *path);
+#else
+GIT_EXTERN(int) git_repository_open(struct git_repository **out, const char *path);
+#endif
+
+/** @internal */
+GIT_EXTERN(int) git__hidden(void);
+
+static int helper(void) { return 1; }
+
+static inline size_t git_buf_len(const git_buf *b) { return b->size; }
+
+DECLARE_HANDLE(git_handle);
+
+/** Callback for transfers. */
+typedef int (*git_transfer_cb)(unsigned int current, void *payload);
+
+extern const char *git_version_string; /**< The version. */
+
+#ifdef __cplusplus
+}
+#endif
+
+G_END_DECLS
+
+#endif /* GIT_BUFFER_H */
+`
+
+// decls lists declarations and their members as "Kind Name: Signature",
+// with signatures on one line.
+func decls(ds []*decl) []string {
+	var out []string
+	for _, d := range ds {
+		out = append(out, d.Kind+" "+d.Name+": "+oneLine(d.Signature))
+		out = append(out, decls(d.Members)...)
+	}
+	return out
+}
+
+func TestParseC(t *testing.T) {
+	h := parseHeader("include/git2/buffer.h", cFixture)
+	if h.Lang != "c" {
+		t.Errorf("Lang = %q", h.Lang)
+	}
+	if h.Summary != "Growable byte buffers." || !strings.Contains(h.Doc, "Buffers own their memory.") {
+		t.Errorf("header doc = %q, %q", h.Summary, h.Doc)
+	}
+
+	want := []string{
+		"Macro GIT_BUF_MAX: #define GIT_BUF_MAX (1 << 30)",
+		"Macro GIT_MAX: #define GIT_MAX(a, b) \\ ((a) > (b) ? (a) : (b))",
+		"Struct git_buf: typedef struct { char *ptr; size_t reserved; size_t size; } git_buf",
+		"Field git_buf.ptr: char *ptr",
+		"Field git_buf.reserved: size_t reserved",
+		"Field git_buf.size: size_t size",
+		"Enum git_error_code: typedef enum { GIT_OK = 0, GIT_ERROR = -1, GIT_ENOTFOUND = -3, _GIT_PRIVATE = 99 } git_error_code",
+		"EnumConstant GIT_OK: GIT_OK = 0",
+		"EnumConstant GIT_ERROR: GIT_ERROR = -1",
+		"EnumConstant GIT_ENOTFOUND: GIT_ENOTFOUND = -3",
+		"Struct git_open_options: struct git_open_options { unsigned int version; int (*progress)(unsigned int current, void *payload); struct { int depth; } limits; }",
+		"Field git_open_options.version: unsigned int version",
+		"Field git_open_options.progress: int (*progress)(unsigned int current, void *payload)",
+		"Field git_open_options.limits: struct { int depth; } limits",
+		"Function git_buf_free: GIT_EXTERN(int) git_buf_free(git_buf *buffer)",
+		"Function git_buf_dispose: GIT_EXTERN(void) git_buf_dispose(git_buf *buffer) __attribute__((nonnull))",
+		"Function git_repository_open: GIT_EXTERN(int) git_repository_open(struct git_repository **out, const wchar_t *path)",
+		"Function git_buf_len: static inline size_t git_buf_len(const git_buf *b)",
+		"Typedef git_transfer_cb: typedef int (*git_transfer_cb)(unsigned int current, void *payload)",
+		"Variable git_version_string: extern const char *git_version_string",
+	}
+	if got := decls(h.Decls); !slices.Equal(got, want) {
+		t.Errorf("decls =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	byName := map[string]*decl{}
+	var index func([]*decl)
+	index = func(ds []*decl) {
+		for _, d := range ds {
+			byName[d.Name] = d
+			index(d.Members)
+		}
+	}
+	index(h.Decls)
+	for name, summary := range map[string]string{
+		"GIT_MAX":                   "Returns the larger of `a` and `b`.",
+		"git_buf.ptr":               "The buffer contents.",
+		"git_buf.reserved":          "Bytes allocated.",
+		"git_buf.size":              "Bytes used.",
+		"GIT_ENOTFOUND":             "Requested object could not be found",
+		"git_open_options.limits":   "Search limits.",
+		"git_buf_free":              "Free the memory referred to by the git_buf. (deprecated)",
+		"git_repository_open":       "Open a repository at a path.",
+		"git_version_string":        "The version.",
+		"git_open_options.progress": "Called for each progress update.",
+		"git_buf_dispose":           "Dispose a buffer.",
+		"git_transfer_cb":           "Callback for transfers.",
+		"git_error_code":            "Error codes.",
+		"git_buf":                   "A data buffer for exporting data from libgit2.",
+		"GIT_BUF_MAX":               "Maximum size of a buffer, in bytes.",
+		"git_open_options":          "Options for opening a repository.",
+		"git_open_options.version":  "",
+		"git_buf_len":               "",
+		"GIT_OK":                    "No error",
+		"GIT_ERROR":                 "Generic error",
+	} {
+		if d := byName[name]; d == nil || d.Summary != summary {
+			t.Errorf("%s summary = %+v, want %q", name, d, summary)
+		}
+	}
+	if doc := byName["git_buf_free"].Doc; !strings.Contains(doc, "- `buffer` (in): The buffer to deallocate") {
+		t.Errorf("git_buf_free doc = %q", doc)
+	}
+}
+
+const cppFixture = `#pragma once
+/// @file widget.hpp
+/// Widgets for drawing.
+#include <string>
+
+namespace ui {
+namespace detail { struct Impl; int secret(); }
+
+/// A point on the screen.
+struct Point {
+    int x; ///< Horizontal offset.
+    int y; ///< Vertical offset.
+};
+
+/**
+ * A drawable widget.
+ * @tparam T the payload type
+ */
+template <typename T>
+class UI_EXPORT Widget final : public Base {
+public:
+    /// Creates an empty widget.
+    Widget();
+    /// Creates a widget with a name.
+    explicit Widget(std::string name) : name_(std::move(name)) {}
+    virtual ~Widget() = default;
+
+    /// Resizes the widget.
+    void resize(int w, int h) noexcept;
+    /// Resizes to a square.
+    void resize(int side);
+
+    /// Compares widgets.
+    bool operator==(const Widget &other) const;
+    /// Calls the widget.
+    int operator()(int x) const { return x; }
+
+    /// Modes of drawing.
+    enum class Mode { Fill, /**< Fill it */ Stroke };
+
+    using size_type = std::size_t;
+
+protected:
+    void hidden();
+private:
+    std::string name_;
+};
+
+/// Draws a widget.
+template <typename T>
+[[nodiscard]] auto draw(const Widget<T> &w) -> bool;
+
+/// Colors.
+enum Color { Red, Green };
+
+constexpr int kMaxWidgets = 16; ///< Upper bound.
+
+} // namespace ui
+`
+
+func TestParseCpp(t *testing.T) {
+	h := parseHeader("include/ui/widget.h", cppFixture)
+	if h.Lang != "cpp" {
+		t.Errorf("Lang = %q", h.Lang)
+	}
+	if h.Summary != "Widgets for drawing." {
+		t.Errorf("Summary = %q", h.Summary)
+	}
+
+	want := []string{
+		"Struct ui::Point: struct Point { int x; int y; }",
+		"Field ui::Point::x: int x",
+		"Field ui::Point::y: int y",
+		"Class ui::Widget: template <typename T> class UI_EXPORT Widget final : public Base",
+		"Constructor ui::Widget::Widget: Widget()",
+		"Constructor ui::Widget::Widget: explicit Widget(std::string name)",
+		"Destructor ui::Widget::~Widget: virtual ~Widget() = default",
+		"Method ui::Widget::resize: void resize(int w, int h) noexcept",
+		"Method ui::Widget::resize: void resize(int side)",
+		"Method ui::Widget::operator==: bool operator==(const Widget &other) const",
+		"Method ui::Widget::operator(): int operator()(int x) const",
+		"TypeAlias ui::Widget::size_type: using size_type = std::size_t",
+		"Enum ui::Widget::Mode: enum class Mode { Fill, Stroke }",
+		"EnumConstant ui::Widget::Mode::Fill: Fill",
+		"EnumConstant ui::Widget::Mode::Stroke: Stroke",
+		"Function ui::draw: template <typename T> [[nodiscard]] auto draw(const Widget<T> &w) -> bool",
+		"Enum ui::Color: enum Color { Red, Green }",
+		"EnumConstant ui::Red: Red",
+		"EnumConstant ui::Green: Green",
+		"Variable ui::kMaxWidgets: constexpr int kMaxWidgets = 16",
+	}
+	if got := decls(h.Decls); !slices.Equal(got, want) {
+		t.Errorf("decls =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	md := renderHeader(h)
+	for _, want := range []string{
+		"# include/ui/widget.h\n\n```cpp\n#include \"ui/widget.h\"\n```",
+		"## Types\n\n### ui::Point",
+		"**Fields**\n\n- `x`: Horizontal offset.\n- `y`: Vertical offset.",
+		"**Template Parameters**\n\n- `T`: the payload type",
+		"#### ui::Widget::resize\n\n```cpp\nvoid resize(int side)\n```",
+		"**Values**\n\n- `Fill`: Fill it\n- `Stroke`",
+		"## Functions\n\n### ui::draw",
+		"## Variables\n\n### ui::kMaxWidgets",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("header document lacks %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestParseNamespaceMacros(t *testing.T) {
+	h := parseHeader("include/bits/vector.hpp", `namespace std _GLIBCXX_VISIBILITY(default)
+{
+/// Swaps two vectors.
+void swap(vector &x, vector &y) _GLIBCXX_NOEXCEPT_IF(true);
+
+namespace chrono {
+/// The current time.
+time_point now() noexcept;
+}
+}
+`)
+	want := []string{
+		"Function std::swap: void swap(vector &x, vector &y) _GLIBCXX_NOEXCEPT_IF(true)",
+		"Function std::chrono::now: time_point now() noexcept",
+	}
+	if got := decls(h.Decls); !slices.Equal(got, want) {
+		t.Errorf("decls =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDeclarator(t *testing.T) {
+	tests := []struct {
+		decl string
+		name string
+		fn   bool
+	}{
+		{"int foo(void)", "foo", true},
+		{"GIT_EXTERN(int) git_foo(const char *s)", "git_foo", true},
+		{"int (*callback)(void *)", "callback", false},
+		{"void (*signal_fn(int sig))(int)", "signal_fn", true},
+		{"const char *names[4]", "names", false},
+		{"unsigned int flags : 3", "flags", false},
+		{"T &operator[](size_t i)", "operator[]", true},
+		{"operator bool() const", "operator bool", true},
+		{"struct stat st", "st", false},
+		{"std::vector<int> values", "values", false},
+		{"size_t", "", false},
+		{`void widget_free(widget *w) WIDGET_DEPRECATED("use widget_release")`, "widget_free", true},
+		{"void swap(vector &x) _GLIBCXX_NOEXCEPT_IF(true)", "swap", true},
+		{"int size() const NOEXCEPT_IF(x)", "size", true},
+	}
+	for _, tt := range tests {
+		name, fn, _ := declarator(tt.decl)
+		if name != tt.name || fn != tt.fn {
+			t.Errorf("declarator(%q) = %q, %v; want %q, %v", tt.decl, name, fn, tt.name, tt.fn)
+		}
+	}
+}
@@ -0,0 +1,478 @@
+package cheader
+
+import (
+	"regexp"
+	"strings"
+)
+
+// item is an element of a header, or of a braced body within one: a
+// preprocessor directive, an access label of a C++ class or a declaration,
+// with the doc comments around it.
+type item struct {
+	doc      []string // doc comments before the item
+	trailing []string // doc comments after it, as ///< or /**< introduce
+	// directive is a preprocessor line without its comments; continued
+	// lines keep their backslashes and line breaks.
+	directive string
+	// access is public, protected or private for an access label.
+	access string
+	// header is the text of a declaration before its body, or all of it
+	// when there is none, without comments.
+	header  string
+	body    string // the raw text between the braces of a body
+	hasBody bool
+	tail    string // the text between a body and the semicolon ending it
+}
+
+// scanItems splits a header, or the body of a namespace, class, struct or
+// extern "C" block, into items. Declarations end at a semicolon or, for
+// function definitions and namespaces, at the closing brace of their body.
+// Strings, comments and directive lines are skipped over.
+func scanItems(src string) []*item {
+	var (
+		items     []*item
+		doc       []string
+		lineStart = true
+	)
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == '\n':
+			lineStart = true
+			i++
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\f' || ch == '\v':
+			i++
+		case ch == '#' && lineStart:
+			end := directiveEnd(src, i)
+			text, trailing := cleanDirective(src[i:end])
+			it := &item{directive: text, trailing: trailing}
+			// Doc comments belong to the next declaration, across the
+			// conditionals around it, unless it is a macro.
+			if isDefine(text) {
+				it.doc, doc = doc, nil
+			}
+			items = append(items, it)
+			i = end
+		case strings.HasPrefix(src[i:], "//") || strings.HasPrefix(src[i:], "/*"):
+			end := commentEnd(src, i)
+			switch c := src[i:end]; docKind(c) {
+			case leadingDoc:
+				doc = append(doc, c)
+			case trailingDoc:
+				if len(items) > 0 {
+					last := items[len(items)-1]
+					last.trailing = append(last.trailing, c)
+				}
+			}
+			i = end
+		default:
+			lineStart = false
+			it, end := scanDecl(src, i)
+			i = end
+			if it.header == "" && !it.hasBody && it.access == "" {
+				continue
+			}
+			it.doc, doc = doc, nil
+			items = append(items, it)
+		}
+	}
+	return items
+}
+
+// scanDecl reads the declaration starting at i and returns it with the
+// index after it.
+func scanDecl(src string, i int) (*item, int) {
+	it := &item{}
+	var (
+		b      strings.Builder
+		depth  int
+		angle  int // depth in template parameter lists, whose defaults are not initializers
+		assign bool
+	)
+	for j := i; j < len(src); {
+		ch := src[j]
+		switch {
+		case ch == '<' && (angle > 0 || strings.HasSuffix(strings.TrimSpace(b.String()), "template")):
+			angle++
+		case ch == '>' && angle > 0:
+			angle--
+		case ch == '"' || ch == '\'':
+			end := skipLiteral(src, j)
+			b.WriteString(src[j:end])
+			j = end
+			continue
+		case strings.HasPrefix(src[j:], "//") || strings.HasPrefix(src[j:], "/*"):
+			end := commentEnd(src, j)
+			if c := src[j:end]; docKind(c) == trailingDoc {
+				it.trailing = append(it.trailing, c)
+			}
+			b.WriteByte(' ')
+			j = end
+			continue
+		case ch == '\n':
+			if depth == 0 && standaloneMacro(strings.TrimSpace(b.String()), src[j+1:]) {
+				// G_BEGIN_DECLS and the like stand alone, without a
+				// semicolon.
+				it.header = strings.TrimSpace(b.String())
+				return it, j + 1
+			}
+			b.WriteByte('\n')
+			j = skipDirectiveLines(src, j+1)
+			continue
+		case ch == '(' || ch == '[':
+			depth++
+		case ch == ')' || ch == ']':
+			depth--
+		case depth > 0:
+		case ch == ';':
+			it.header = strings.TrimSpace(b.String())
+			return it, j + 1
+		case ch == '}':
+			// A brace closing a block opened under another condition, as
+			// in #ifdef __cplusplus } #endif.
+			it.header = strings.TrimSpace(b.String())
+			return it, j + 1
+		case ch == '=' && angle == 0 && isAssign(src, j):
+			assign = true
+		case ch == ':' && !strings.HasPrefix(src[j:], "::") && (j == 0 || src[j-1] != ':'):
+			if label := strings.TrimSpace(b.String()); accessLabels[label] {
+				it.access = strings.Fields(label)[0]
+				return it, j + 1
+			}
+		case ch == '{':
+			end := matchBrace(src, j)
+			if assign {
+				b.WriteString(src[j:end])
+				j = end
+				continue
+			}
+			it.header = strings.TrimSpace(b.String())
+			it.hasBody = true
+			it.body = src[j+1 : max(j+1, end-1)]
+			if endsAtBody(it.header) {
+				return it, end
+			}
+			return it, scanTail(src, end, it)
+		}
+		b.WriteByte(ch)
+		j++
+	}
+	it.header = strings.TrimSpace(b.String())
+	return it, len(src)
+}
+
+var (
+	macroName  = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+	blockMacro = regexp.MustCompile(`(BEGIN|END)`)
+)
+
+// standaloneMacro reports whether a line holding only text is a macro used
+// on its own: one named for beginning or ending a block, or followed by a
+// blank line, a directive or a comment rather than the rest of a
+// declaration.
+func standaloneMacro(text, rest string) bool {
+	if !macroName.MatchString(text) {
+		return false
+	}
+	if blockMacro.MatchString(text) {
+		return true
+	}
+	next := strings.TrimLeft(rest, " \t\r")
+	return next == "" || next[0] == '\n' || next[0] == '#' || strings.HasPrefix(next, "/")
+}
+
+var accessLabels = map[string]bool{
+	"public": true, "protected": true, "private": true,
+	"public slots": true, "protected slots": true, "private slots": true,
+}
+
+// scanTail reads the declarators between the body of a struct, union, enum
+// or class and the semicolon ending its declaration, as in typedef struct
+// { ... } name;.
+func scanTail(src string, i int, it *item) int {
+	var (
+		b     strings.Builder
+		depth int
+	)
+	for j := i; j < len(src); {
+		ch := src[j]
+		switch {
+		case ch == '"' || ch == '\'':
+			end := skipLiteral(src, j)
+			b.WriteString(src[j:end])
+			j = end
+			continue
+		case strings.HasPrefix(src[j:], "//") || strings.HasPrefix(src[j:], "/*"):
+			end := commentEnd(src, j)
+			if c := src[j:end]; docKind(c) == trailingDoc {
+				it.trailing = append(it.trailing, c)
+			}
+			b.WriteByte(' ')
+			j = end
+			continue
+		case ch == '\n':
+			b.WriteByte(' ')
+			j = skipDirectiveLines(src, j+1)
+			continue
+		case ch == '(' || ch == '[' || ch == '{':
+			depth++
+		case ch == ')' || ch == ']' || ch == '}':
+			depth--
+			if depth < 0 {
+				it.tail = strings.TrimSpace(b.String())
+				return j
+			}
+		case ch == ';' && depth == 0:
+			it.tail = strings.TrimSpace(b.String())
+			return j + 1
+		}
+		b.WriteByte(ch)
+		j++
+	}
+	it.tail = strings.TrimSpace(b.String())
+	return len(src)
+}
+
+// endsAtBody reports whether a declaration with a body ends at its closing
+// brace: a function definition, a namespace or an extern "C" block, rather
+// than a type whose declaration goes on to a semicolon.
+func endsAtBody(header string) bool {
+	h := stripAttributes(header)
+	_, h = cutTemplate(h)
+	if strings.HasPrefix(h, "namespace") || strings.HasPrefix(h, "inline namespace") || strings.HasPrefix(h, "extern \"C") {
+		return true
+	}
+	return topLevelIndex(h, '(') >= 0
+}
+
+// skipDirectiveLines skips the preprocessor lines starting at i, the start
+// of a line, and returns the index of the first other line.
+func skipDirectiveLines(src string, i int) int {
+	for {
+		j := i
+		for j < len(src) && (src[j] == ' ' || src[j] == '\t') {
+			j++
+		}
+		if j >= len(src) || src[j] != '#' {
+			return i
+		}
+		i = directiveEnd(src, j)
+		if i < len(src) {
+			i++
+		}
+	}
+}
+
+// directiveEnd returns the index of the newline ending the directive at i,
+// following backslash continuations and block comments across lines.
+func directiveEnd(src string, i int) int {
+	for j := i; j < len(src); j++ {
+		switch {
+		case strings.HasPrefix(src[j:], "/*"):
+			j = commentEnd(src, j) - 1
+		case strings.HasPrefix(src[j:], "//"):
+			return commentEnd(src, j)
+		case src[j] == '\\' && j+1 < len(src) && (src[j+1] == '\n' || src[j+1] == '\r'):
+			j++
+			if src[j] == '\r' && j+1 < len(src) && src[j+1] == '\n' {
+				j++
+			}
+		case src[j] == '\n':
+			return j
+		}
+	}
+	return len(src)
+}
+
+// cleanDirective removes the comments of a directive, returning the doc
+// comments that document what it defines from after it.
+func cleanDirective(text string) (string, []string) {
+	var (
+		b        strings.Builder
+		trailing []string
+	)
+	for j := 0; j < len(text); {
+		switch {
+		case text[j] == '"' || text[j] == '\'':
+			end := skipLiteral(text, j)
+			b.WriteString(text[j:end])
+			j = end
+		case strings.HasPrefix(text[j:], "//") || strings.HasPrefix(text[j:], "/*"):
+			end := commentEnd(text, j)
+			if c := text[j:end]; docKind(c) == trailingDoc {
+				trailing = append(trailing, c)
+			}
+			b.WriteByte(' ')
+			j = end
+		default:
+			b.WriteByte(text[j])
+			j++
+		}
+	}
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), trailing
+}
+
+func isDefine(directive string) bool {
+	return strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(directive, "#")), "define")
+}
+
+type commentKind int
+
+const (
+	plainComment commentKind = iota
+	leadingDoc
+	trailingDoc
+)
+
+// docKind tells doc comments, /** */, /*! */, /// and //!, from other
+// comments, and those documenting the declaration before them, which start
+// with <, from those documenting the one after.
+func docKind(c string) commentKind {
+	var rest string
+	switch {
+	case strings.HasPrefix(c, "///"):
+		rest = c[3:]
+		if strings.HasPrefix(rest, "/") {
+			return plainComment
+		}
+	case strings.HasPrefix(c, "//!"):
+		rest = c[3:]
+	case strings.HasPrefix(c, "/**"):
+		rest = c[3:]
+		if rest == "/" || strings.HasPrefix(rest, "*") {
+			return plainComment
+		}
+	case strings.HasPrefix(c, "/*!"):
+		rest = c[3:]
+	default:
+		return plainComment
+	}
+	if strings.HasPrefix(rest, "<") {
+		return trailingDoc
+	}
+	return leadingDoc
+}
+
+// commentEnd returns the index after the comment at i: the end of its line
+// for a line comment, which the newline is not part of.
+func commentEnd(src string, i int) int {
+	if strings.HasPrefix(src[i:], "//") {
+		if end := strings.IndexByte(src[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(src)
+	}
+	if end := strings.Index(src[i+2:], "*/"); end >= 0 {
+		return i + 2 + end + 2
+	}
+	return len(src)
+}
+
+// skipLiteral returns the index after the string or character literal at i,
+// a raw string R"delim(...)delim" included.
+func skipLiteral(src string, i int) int {
+	quote := src[i]
+	if quote == '"' && i > 0 && src[i-1] == 'R' {
+		if open := strings.IndexByte(src[i:], '('); open >= 0 {
+			delim := ")" + src[i+1:i+open] + "\""
+			if end := strings.Index(src[i+open:], delim); end >= 0 {
+				return i + open + end + len(delim)
+			}
+		}
+	}
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(src)
+}
+
+// matchBrace returns the index after the brace closing the one at i.
+func matchBrace(src string, i int) int {
+	depth := 0
+	for j := i; j < len(src); {
+		switch ch := src[j]; {
+		case ch == '"' || ch == '\'':
+			j = skipLiteral(src, j)
+			continue
+		case strings.HasPrefix(src[j:], "//") || strings.HasPrefix(src[j:], "/*"):
+			j = commentEnd(src, j)
+			continue
+		case ch == '\n':
+			j = skipDirectiveLines(src, j+1)
+			continue
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+		j++
+	}
+	return len(src)
+}
+
+// isAssign reports whether the = at i starts an initializer rather than
+// being part of an operator such as ==, <= or operator=.
+func isAssign(src string, i int) bool {
+	if i+1 < len(src) && src[i+1] == '=' {
+		return false
+	}
+	if i > 0 && strings.ContainsRune("=!<>+-*/%&|^", rune(src[i-1])) {
+		return false
+	}
+	return !strings.HasSuffix(strings.TrimRight(src[:i], " \t"), "operator")
+}
+
+// removeComments removes the comments of declaration text, keeping its
+// strings.
+func removeComments(src string) string {
+	var b strings.Builder
+	for j := 0; j < len(src); {
+		switch {
+		case src[j] == '"' || src[j] == '\'':
+			end := skipLiteral(src, j)
+			b.WriteString(src[j:end])
+			j = end
+		case strings.HasPrefix(src[j:], "//") || strings.HasPrefix(src[j:], "/*"):
+			j = commentEnd(src, j)
+		default:
+			b.WriteByte(src[j])
+			j++
+		}
+	}
+	return b.String()
+}
+
+// topLevelIndex returns the index of the first ch outside parentheses,
+// brackets and angle brackets of templates, or -1.
+func topLevelIndex(s string, ch byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ch && depth == 0 {
+			return i
+		}
+		switch c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '"', '\'':
+			i = skipLiteral(s, i) - 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,446 @@
+package docset
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	adocHeading     = regexp.MustCompile(`^(={1,6})\s+(.+?)(?:\s+=+)?\s*$`)
+	adocAttribute   = regexp.MustCompile(`^:(!?[\w][\w-]*!?):(?:\s+(.*))?$`)
+	adocAttrRef     = regexp.MustCompile(`\{([\w-]+)\}`)
+	adocBlockAttrs  = regexp.MustCompile(`^\[(.*)\]$`)
+	adocBlockTitle  = regexp.MustCompile(`^\.([^.\s].*)$`)
+	adocAdmonition  = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	adocListItem    = regexp.MustCompile(`^(\*{1,5}|-|\.{1,5})\s+(.*)$`)
+	adocDescription = regexp.MustCompile(`^(\S.*?)(:{2,4}|;;)(?:\s+(.*))?$`)
+	adocCallout     = regexp.MustCompile(`\s*(?://|#|--|;;)?\s*(?:<(?:\d+|\.)>\s*)+$`)
+	adocCalloutItem = regexp.MustCompile(`^<(\d+|\.)>\s+(.*)$`)
+	adocCellSpec    = regexp.MustCompile(`^[\d.+*<>^]*[aehlmsdv]?$`)
+	adocSkipped     = regexp.MustCompile(`^(?:(?:ifdef|ifndef|ifeval|endif)::.*\[.*\]|toc::\[.*\]|include::.*\[.*\]|<<<|\[\[.*\]\])$`)
+	adocCode        = regexp.MustCompile("`[^`]+`")
+	adocLiteralCode = regexp.MustCompile("`\\+(.+?)\\+`")
+	adocLink        = regexp.MustCompile(`(?:link:|mailto:)?((?:https?://|mailto:)?[^\s\[\]]+)\[([^\]]*)\]`)
+	adocURLLink     = regexp.MustCompile(`\b(?:link:[^\s\[\]]+|mailto:[^\s\[\]]+|https?://[^\s\[\]]+)\[([^\]]*)\]`)
+	adocImage       = regexp.MustCompile(`image::?([^\s\[\]]+)\[([^\]\[,]*)[^\]]*\]`)
+	adocXref        = regexp.MustCompile(`<<([^,>]+)(?:,\s*([^>]+))?>>|xref:([^\s\[\]]+)\[([^\]]*)\]`)
+	adocMacro       = regexp.MustCompile(`(kbd|btn|footnote):\[([^\]]*)\]`)
+	adocRole        = regexp.MustCompile(`\[[.#][\w.#-]*\]#([^#]+)#`)
+	adocBold        = regexp.MustCompile(`(^|[^\w*])\*([^*\s](?:[^*]*[^*\s])?)\*([^\w*]|$)`)
+	adocItalic      = regexp.MustCompile(`__(.+?)__`)
+	adocPassthrough = regexp.MustCompile(`(^|[^\w+])\+([^+\s](?:[^+]*[^+\s])?)\+([^\w+]|$)`)
+)
+
+// adocAdmonitions are the admonition labels of AsciiDoc, as written in the
+// quote that replaces them.
+var adocAdmonitions = map[string]string{
+	"NOTE": "Note", "TIP": "Tip", "IMPORTANT": "Important", "WARNING": "Warning", "CAUTION": "Caution",
+}
+
+// asciidocToMarkdown converts an AsciiDoc document to Markdown: section
+// titles become ATX headings, lists, tables and source blocks their
+// Markdown equivalents and admonitions block quotes. Attribute references
+// are replaced by the values the document defines. Includes are expected to
+// be expanded already, see ExpandIncludes.
+func asciidocToMarkdown(input string) string {
+	c := adocConverter{attrs: map[string]string{"nbsp": " ", "sp": " ", "empty": "", "vbar": "|", "plus": "+"}}
+	return strings.TrimSpace(strings.Join(c.convert(strings.Split(input, "\n"), true), "\n"))
+}
+
+type adocConverter struct {
+	attrs map[string]string
+	// Attributes and title of the next block.
+	blockAttrs []string
+	blockTitle string
+}
+
+// convert converts a sequence of blocks. header is set for the whole
+// document, whose title may be followed by author and revision lines.
+func (c *adocConverter) convert(lines []string, header bool) []string {
+	var (
+		out  []string
+		para bool // inside a paragraph or list
+	)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+
+		if m := adocAttribute.FindStringSubmatch(line); m != nil {
+			name := strings.Trim(m[1], "!")
+			if strings.HasPrefix(m[1], "!") || strings.HasSuffix(m[1], "!") {
+				delete(c.attrs, name)
+			} else {
+				c.attrs[name] = m[2]
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "////") || adocSkipped.MatchString(line) {
+			continue
+		}
+
+		if m := adocBlockAttrs.FindStringSubmatch(line); m != nil && !strings.HasPrefix(line, "[[") {
+			c.blockAttrs = splitAttrs(m[1])
+			continue
+		}
+		if m := adocBlockTitle.FindStringSubmatch(line); m != nil && (i == 0 || strings.TrimSpace(lines[i-1]) == "" || adocBlockAttrs.MatchString(lines[i-1])) {
+			c.blockTitle = c.inline(m[1])
+			continue
+		}
+
+		if m := adocHeading.FindStringSubmatch(line); m != nil {
+			out = append(out, strings.Repeat("#", len(m[1]))+" "+c.inline(m[2]))
+			c.blockAttrs, c.blockTitle = nil, ""
+			if header && len(m[1]) == 1 {
+				// Author and revision lines follow the document title.
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && !adocAttribute.MatchString(lines[i+1]) {
+					i++
+				}
+			}
+			header = false
+			continue
+		}
+		if line != "" {
+			header = false
+		}
+
+		if strings.HasPrefix(line, "```") {
+			// Markdown-style fences are kept as they are.
+			out = append(out, line)
+			for i++; i < len(lines); i++ {
+				out = append(out, lines[i])
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+					break
+				}
+			}
+			c.blockAttrs, c.blockTitle = nil, ""
+			continue
+		}
+
+		if isAdocDelimiter(line) {
+			end := i + 1
+			for end < len(lines) && strings.TrimRight(lines[end], " \t") != line {
+				end++
+			}
+			out = append(out, c.block(line, lines[i+1:min(end, len(lines))])...)
+			i = end
+			continue
+		}
+
+		if line == "" {
+			out = append(out, "")
+			c.blockAttrs, c.blockTitle = nil, ""
+			para = false
+			continue
+		}
+
+		// Paragraphs, styled by the attributes above them.
+		end := i
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		paragraph := lines[i:end]
+		if style := c.style(); !para && style != "" && style != "normal" {
+			i = end - 1
+			out = append(out, c.styledParagraph(style, paragraph)...)
+			continue
+		}
+		if m := adocAdmonition.FindStringSubmatch(line); !para && m != nil {
+			i = end - 1
+			out = append(out, c.quote("**"+adocAdmonitions[m[1]]+"**", append([]string{m[2]}, paragraph[1:]...))...)
+			continue
+		}
+		if !para && (strings.HasPrefix(lines[i], " ") || strings.HasPrefix(lines[i], "\t")) {
+			i = end - 1
+			out = append(out, fenced("", dedent(paragraph))...)
+			continue
+		}
+
+		out = append(out, c.line(line)...)
+		c.blockAttrs, c.blockTitle = nil, ""
+		para = true
+	}
+	return out
+}
+
+// line converts a line of a paragraph or list.
+func (c *adocConverter) line(line string) []string {
+	switch {
+	case line == "+":
+		// A list continuation joins the next block to the item.
+		return nil
+	case line == "'''":
+		return []string{"---"}
+	}
+	if m := adocListItem.FindStringSubmatch(line); m != nil {
+		depth := len(m[1])
+		marker := "-"
+		if m[1][0] == '.' {
+			marker = "1."
+		} else if m[1] == "-" {
+			depth = 1
+		}
+		return []string{strings.Repeat("    ", depth-1) + marker + " " + c.inline(m[2])}
+	}
+	if m := adocCalloutItem.FindStringSubmatch(line); m != nil {
+		n := m[1]
+		if n == "." {
+			n = "1"
+		}
+		return []string{n + ". " + c.inline(m[2])}
+	}
+	if m := adocDescription.FindStringSubmatch(line); m != nil {
+		term := "**" + c.inline(m[1]) + "**"
+		if m[3] != "" {
+			term += ": " + c.inline(m[3])
+		}
+		return []string{term}
+	}
+	if strings.HasSuffix(line, " +") {
+		return []string{c.inline(strings.TrimSuffix(line, " +")) + "\\"}
+	}
+	return []string{c.inline(line)}
+}
+
+// isAdocDelimiter reports whether a line delimits a block: "--" opens an
+// open block and "|===" a table, others repeat a character at least four
+// times (---- listing, .... literal, ==== example, **** sidebar, ____ quote,
+// ++++ passthrough, //// comment).
+func isAdocDelimiter(line string) bool {
+	return line == "--" || line == "|===" ||
+		len(line) >= 4 && strings.Count(line, line[:1]) == len(line) && strings.ContainsRune("-.=*_+/", rune(line[0]))
+}
+
+// block converts a block delimited by delim.
+func (c *adocConverter) block(delim string, content []string) []string {
+	style := c.style()
+	attrs, title := c.blockAttrs, c.blockTitle
+	c.blockAttrs, c.blockTitle = nil, ""
+
+	var out []string
+	titled := func(block []string) []string {
+		if title != "" {
+			out = append(out, "**"+title+"**", "")
+		}
+		return append(out, block...)
+	}
+
+	kind, open := delim[0], delim == "--"
+	switch {
+	case kind == '/':
+		return nil
+	case kind == '+':
+		return content
+	case kind == '|':
+		return titled(c.table(attrs, content))
+	case style == "source" || style == "listing" || style == "literal" || style == "" && (kind == '-' && !open || kind == '.'):
+		lang := ""
+		if style == "source" && len(attrs) > 1 {
+			lang = attrs[1]
+		} else if style == "source" {
+			lang = c.attrs["source-language"]
+		}
+		return titled(fenced(lang, stripCallouts(content)))
+	case adocAdmonitions[style] != "":
+		heading := "**" + adocAdmonitions[style] + "**"
+		if title != "" {
+			heading += " " + title
+		}
+		return c.quote(heading, content)
+	case kind == '_' || style == "quote" || style == "verse":
+		block := c.quote("", content)
+		if len(attrs) > 1 && attrs[1] != "" {
+			block = append(block, ">", "> — "+c.inline(attrs[1]))
+		}
+		return titled(block)
+	}
+	// Example, sidebar and open blocks hold ordinary content.
+	sub := adocConverter{attrs: c.attrs}
+	return titled(sub.convert(content, false))
+}
+
+// styledParagraph converts a paragraph with a block style.
+func (c *adocConverter) styledParagraph(style string, paragraph []string) []string {
+	switch {
+	case style == "source" || style == "listing" || style == "literal":
+		return c.block("----", paragraph)
+	case adocAdmonitions[style] != "" || style == "quote" || style == "verse":
+		return c.block("____", paragraph)
+	}
+	c.blockAttrs, c.blockTitle = nil, ""
+	var out []string
+	for _, line := range paragraph {
+		out = append(out, c.line(strings.TrimRight(line, " \t"))...)
+	}
+	return out
+}
+
+// style returns the block style of the pending attributes: the first
+// positional attribute, or source when only a language is given.
+func (c *adocConverter) style() string {
+	if len(c.blockAttrs) == 0 {
+		return ""
+	}
+	style := c.blockAttrs[0]
+	if style == "" && len(c.blockAttrs) > 1 {
+		return "source"
+	}
+	style, _, _ = strings.Cut(style, "%")
+	style, _, _ = strings.Cut(style, "#")
+	style, _, _ = strings.Cut(style, ".")
+	if strings.Contains(style, "=") {
+		return ""
+	}
+	if _, ok := adocAdmonitions[style]; ok {
+		return style
+	}
+	return strings.ToLower(style)
+}
+
+// quote converts content into a block quote opened by heading.
+func (c *adocConverter) quote(heading string, content []string) []string {
+	sub := adocConverter{attrs: c.attrs}
+	return quoteLines(heading, sub.convert(content, false))
+}
+
+// table converts a table to a Markdown table whose first row is the header.
+func (c *adocConverter) table(attrs, content []string) []string {
+	cols := 0
+	for _, attr := range attrs {
+		if spec, ok := strings.CutPrefix(attr, "cols="); ok {
+			for _, col := range strings.Split(strings.Trim(spec, `"'`), ",") {
+				if n, _, ok := strings.Cut(col, "*"); ok {
+					if count, err := strconv.Atoi(strings.TrimSpace(n)); err == nil {
+						cols += count
+						continue
+					}
+				}
+				cols++
+			}
+		}
+	}
+
+	var cells []string
+	for i, line := range content {
+		line = strings.ReplaceAll(strings.TrimSpace(line), `\|`, "\x00")
+		prefix, rest, found := strings.Cut(line, "|")
+		if !found || !adocCellSpec.MatchString(strings.TrimSpace(prefix)) {
+			// Continuation of the previous cell.
+			if len(cells) > 0 && line != "" {
+				cells[len(cells)-1] = strings.TrimSpace(cells[len(cells)-1] + " " + line)
+			}
+			continue
+		}
+		row := strings.Split(rest, "|")
+		if cols == 0 && i == 0 || cols == 0 && len(cells) == 0 {
+			cols = len(row)
+		}
+		for _, cell := range row {
+			cells = append(cells, strings.TrimSpace(cell))
+		}
+	}
+	if cols == 0 {
+		return nil
+	}
+
+	var out []string
+	for start := 0; start < len(cells); start += cols {
+		row := cells[start:min(start+cols, len(cells))]
+		for i, cell := range row {
+			row[i] = strings.ReplaceAll(strings.ReplaceAll(c.inline(cell), "|", `\|`), "\x00", `\|`)
+		}
+		out = append(out, "| "+strings.Join(row, " | ")+" |")
+		if start == 0 {
+			out = append(out, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+	return out
+}
+
+// inline converts inline markup outside code spans: attribute references,
+// links, cross references, images, constrained bold, passthroughs and UI
+// macros.
+func (c *adocConverter) inline(s string) string {
+	s = adocAttrRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := c.attrs[ref[1:len(ref)-1]]; ok {
+			return v
+		}
+		return ref
+	})
+	s = adocLiteralCode.ReplaceAllString(s, "`$1`")
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range adocCode.FindAllStringIndex(s, -1) {
+		b.WriteString(adocInline(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(adocInline(s[last:]))
+	return b.String()
+}
+
+func adocInline(s string) string {
+	s = adocImage.ReplaceAllString(s, "![$2]($1)")
+	s = adocURLLink.ReplaceAllStringFunc(s, func(match string) string {
+		m := adocLink.FindStringSubmatch(match)
+		if m[2] == "" {
+			return "<" + m[1] + ">"
+		}
+		return "[" + m[2] + "](" + m[1] + ")"
+	})
+	s = adocXref.ReplaceAllStringFunc(s, func(match string) string {
+		m := adocXref.FindStringSubmatch(match)
+		switch {
+		case m[2] != "":
+			return m[2]
+		case m[4] != "":
+			return m[4]
+		case m[3] != "":
+			return m[3]
+		}
+		return m[1]
+	})
+	s = adocMacro.ReplaceAllStringFunc(s, func(match string) string {
+		m := adocMacro.FindStringSubmatch(match)
+		switch m[1] {
+		case "kbd":
+			return "`" + m[2] + "`"
+		case "btn":
+			return "**" + m[2] + "**"
+		}
+		return " (" + m[2] + ")"
+	})
+	s = adocRole.ReplaceAllString(s, "$1")
+	s = adocPassthrough.ReplaceAllString(s, "$1`$2`$3")
+	s = adocItalic.ReplaceAllString(s, "*$1*")
+	return adocBold.ReplaceAllString(s, "$1**$2**$3")
+}
+
+// splitAttrs splits a block attribute list on commas outside quotes.
+func splitAttrs(list string) []string {
+	var (
+		attrs  []string
+		quoted bool
+		start  int
+	)
+	for i, r := range list {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			attrs = append(attrs, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	return append(attrs, strings.TrimSpace(list[start:]))
+}
+
+// stripCallouts removes callout markers (<1>) from the lines of a listing.
+func stripCallouts(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = adocCallout.ReplaceAllString(line, "")
+	}
+	return out
+}
@@ -0,0 +1,331 @@
+// Package docset ingests trees of prose documentation, the Markdown, MDX,
+// reStructuredText, AsciiDoc and Org-mode files of a repository or
+// directory, as Markdown documents with a search entry per document and per
+// section.
+package docset
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/log"
+	"github.com/goccy/go-yaml"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// FrontMatter is the YAML or TOML front matter of a Markdown or MDX page.
+// Besides the title it carries the navigation fields of static-site
+// generators: Hugo's linkTitle, weight and draft, and Docusaurus' id,
+// sidebar_label and sidebar_position.
+type FrontMatter struct {
+	Title           string   `json:"title" toml:"title"`
+	Description     string   `json:"description" toml:"description"`
+	Tags            []string `json:"tags" toml:"tags"`
+	LinkTitle       string   `json:"linkTitle" toml:"linkTitle"`
+	Weight          float64  `json:"weight" toml:"weight"`
+	Draft           bool     `json:"draft" toml:"draft"`
+	ID              string   `json:"id" toml:"id"`
+	SidebarLabel    string   `json:"sidebar_label" toml:"sidebar_label"`
+	SidebarPosition float64  `json:"sidebar_position" toml:"sidebar_position"`
+}
+
+// IsDocFile reports whether a path has a documentation extension: .md,
+// .markdown, .mdx, .rst, .adoc, .asciidoc or .org.
+func IsDocFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".mdx", ".rst", ".adoc", ".asciidoc", ".org":
+		return true
+	}
+	return false
+}
+
+// Walk lists the documentation files below root that pass the filter, as
+// slash-separated paths relative to root. .git and excluded directories are
+// not entered.
+func Walk(root string, filter Filter) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if d.IsDir() {
+			if relPath != "." && (d.Name() == ".git" || filter.SkipDir(relPath)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if IsDocFile(relPath) && filter.Match(relPath) {
+			files = append(files, relPath)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// IngestDir converts and writes every documentation file below root that
+// passes the filter, under prefix followed by the file's relative path.
+// Includes are resolved below root, and files that cannot be read are logged
+// and skipped. When root is an mdBook, MkDocs, Docusaurus, Hugo or Sphinx
+// site its table of contents is stored as well. It returns the number of
+// documents written.
+func IngestDir(ctx context.Context, tx *sql.Tx, root, prefix string, filter Filter) (int, error) {
+	files, err := Walk(root, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	site, err := DetectSite(root)
+	if err != nil {
+		log.Warn("failed to read site navigation", "root", root, "err", err)
+	} else if site != nil {
+		log.Info("detected documentation site", "generator", site.Generator, "pages", len(site.Nav))
+	}
+
+	read := DirReader(root)
+	var written []string
+	for _, path := range files {
+		content, err := read(path)
+		if err != nil {
+			log.Warn("failed to read file", "path", path, "err", err)
+			continue
+		}
+		title, markdown := site.Convert(path, ExpandIncludes(path, string(content), read))
+		if _, err := WriteDocument(ctx, tx, prefix+"/"+path, title, markdown); err != nil {
+			return len(written), err
+		}
+		written = append(written, path)
+	}
+	return len(written), IndexSite(ctx, tx, prefix, site, written)
+}
+
+// Convert turns a documentation file into Markdown and finds its title: the
+// front matter title, else the first H1, else one derived from the file name.
+func Convert(path, content string) (title, markdown string) {
+	content = shared.NormalizeLineEndings(content)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mdx":
+		title, markdown = ExtractTitleAndContent(content)
+		markdown = TransformMDX(markdown)
+	case ".rst":
+		title, markdown = ExtractTitleAndContent(rstToMarkdown(content))
+	case ".adoc", ".asciidoc":
+		title, markdown = ExtractTitleAndContent(asciidocToMarkdown(content))
+	case ".org":
+		title, markdown = ExtractTitleAndContent(orgToMarkdown(content))
+	default:
+		title, markdown = ExtractTitleAndContent(content)
+	}
+	if title == "" {
+		title = TitleFromPath(path)
+	}
+	return title, markdown
+}
+
+// WriteDocument stores a Markdown document with a Document search entry
+// carrying its introduction and a Section entry for every other heading, so
+// queries land on the relevant section of a long page. A previous version of
+// the document is replaced along with its search entries.
+func WriteDocument(ctx context.Context, tx *sql.Tx, docPath, title, markdown string) (int64, error) {
+	if err := db.DeleteDocumentTx(ctx, tx, docPath); err != nil {
+		return 0, err
+	}
+
+	docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
+		Path:   docPath,
+		Format: "markdown",
+		Body:   shared.Compress(markdown),
+		Hash:   Hash(title, markdown),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var intro []string
+	for _, section := range shared.MarkdownSections(markdown) {
+		if section.Level == 0 || (section.Level == 1 && section.Heading == shared.PlainHeading(title)) {
+			intro = append(intro, section.Body)
+			continue
+		}
+		if section.Heading == "" {
+			continue
+		}
+		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+			Name:  section.Heading,
+			Type:  "Section",
+			Body:  title + " " + section.Heading + "\n" + section.Body,
+			DocID: docID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+		Name:  title,
+		Type:  "Document",
+		Body:  strings.TrimSpace(title + " " + strings.Join(intro, "\n")),
+		DocID: docID,
+	}); err != nil {
+		return 0, err
+	}
+	return docID, nil
+}
+
+// Hash is the hash WriteDocument stores for a document, which changes with
+// its title as well as its content.
+func Hash(title, markdown string) string {
+	return db.HashBytes([]byte(title + "\n" + markdown))
+}
+
+// ExtractTitleAndContent strips YAML front matter and returns the page title
+// with the remaining content. The title comes from the front matter or the
+// first H1; it is empty when neither exists.
+func ExtractTitleAndContent(content string) (string, string) {
+	content = shared.NormalizeLineEndings(content)
+	if fm, body, ok := SplitFrontMatter(content); ok {
+		content = strings.TrimSpace(body)
+		if fm.Title != "" {
+			return fm.Title, content
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "# ") {
+			return strings.TrimPrefix(trimmed, "# "), content
+		}
+	}
+
+	return "", content
+}
+
+// SplitFrontMatter parses the YAML front matter between leading --- lines,
+// or the TOML front matter between +++ lines, and returns it with the
+// content that follows. ok is false when the content has no front matter or
+// it does not parse.
+func SplitFrontMatter(content string) (fm FrontMatter, body string, ok bool) {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 3 {
+		return fm, content, false
+	}
+	delim := strings.TrimSpace(lines[0])
+	if delim != "---" && delim != "+++" {
+		return fm, content, false
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != delim {
+			continue
+		}
+		raw := strings.Join(lines[1:i], "\n")
+		var err error
+		if delim == "+++" {
+			_, err = toml.Decode(raw, &fm)
+		} else {
+			err = yaml.Unmarshal([]byte(raw), &fm)
+		}
+		if err != nil {
+			return FrontMatter{}, content, false
+		}
+		return fm, strings.Join(lines[i+1:], "\n"), true
+	}
+	return fm, content, false
+}
+
+// TitleFromPath derives a title from a file name: getting-started.md
+// becomes "Getting Started".
+func TitleFromPath(path string) string {
+	base := filepath.Base(path)
+	title := strings.TrimSuffix(base, filepath.Ext(base))
+	title = strings.ReplaceAll(title, "-", " ")
+	title = strings.ReplaceAll(title, "_", " ")
+	return shared.Capitalize(title)
+}
+
+var (
+	mdxExpression = regexp.MustCompile(`\{\{.*?\}\}`)
+	mdxTag        = regexp.MustCompile(`</?(?:[A-Z][\w.]*|video|img|br|hr|p|div|section)(?:\s[^>]*)?/?>`)
+	mdxAdmonition = regexp.MustCompile(`^:::\s*(\w+)(?:\[(.*)\]|\s+(.*))?$`)
+)
+
+// TransformMDX reduces MDX to Markdown: front matter, import and export
+// statements and {{ }} expressions are dropped along with JSX component tags
+// (their children are kept) and common HTML layout tags, and Docusaurus
+// admonitions (:::note ... :::) become block quotes. Fenced code is left
+// untouched.
+func TransformMDX(input string) string {
+	if strings.HasPrefix(input, "---") {
+		parts := strings.SplitN(input, "---", 3)
+		if len(parts) == 3 {
+			input = parts[2]
+		}
+	}
+
+	var (
+		out        []string
+		fence      string
+		open       int // unclosed brackets of an import or export statement
+		admonition bool
+	)
+	for _, line := range strings.Split(input, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			switch {
+			case fence == "":
+				fence = trimmed[:3]
+			case strings.HasPrefix(trimmed, fence):
+				fence = ""
+			}
+			if admonition {
+				line = "> " + line
+			}
+			out = append(out, line)
+			continue
+		}
+
+		if open > 0 || strings.HasPrefix(trimmed, "import ") || strings.HasPrefix(trimmed, "export ") {
+			open += strings.Count(line, "{") + strings.Count(line, "(") - strings.Count(line, "}") - strings.Count(line, ")")
+			continue
+		}
+
+		if m := mdxAdmonition.FindStringSubmatch(trimmed); m != nil && !admonition {
+			admonition = true
+			heading := "> **" + shared.Capitalize(m[1]) + "**"
+			if title := strings.TrimSpace(m[2] + m[3]); title != "" {
+				heading += " " + title
+			}
+			out = append(out, heading, ">")
+			continue
+		}
+		if trimmed == ":::" && admonition {
+			admonition = false
+			continue
+		}
+
+		line = mdxExpression.ReplaceAllString(line, "")
+		line = mdxTag.ReplaceAllString(line, "")
+		if admonition {
+			line = strings.TrimRight("> "+line, " ")
+		}
+		out = append(out, line)
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
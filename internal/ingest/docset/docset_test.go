@@ -0,0 +1,169 @@
+package docset
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		include, exclude []string
+		path             string
+		want             bool
+	}{
+		{nil, DefaultExclude, "README.md", true},
+		{nil, DefaultExclude, "docs/guide/install.md", true},
+		{nil, DefaultExclude, "node_modules/pkg/README.md", false},
+		{nil, DefaultExclude, "web/vendor/lib/README.md", false},
+		{nil, DefaultExclude, "internal/parser/testdata/input.md", false},
+		{nil, DefaultExclude, ".github/ISSUE_TEMPLATE/bug.md", false},
+		{nil, DefaultExclude, "CHANGELOG.md", false},
+		{nil, DefaultExclude, "packages/core/changelog.md", false},
+		{nil, []string{}, "vendor/README.md", true},
+		{[]string{"docs/**"}, nil, "docs/a/b.md", true},
+		{[]string{"docs/**"}, nil, "README.md", false},
+		{[]string{"docs/*.md", "README*"}, nil, "docs/a/b.md", false},
+		{[]string{"docs/*.md", "README*"}, nil, "pkg/readme.markdown", true},
+		{[]string{"**/guide/*.md"}, nil, "guide/intro.md", true},
+		{[]string{"**/guide/*.md"}, nil, "site/content/guide/intro.md", true},
+		{nil, []string{"docs/internal/**"}, "docs/internal/notes.md", false},
+		{nil, []string{"docs/internal/**"}, "docs/public.md", true},
+	}
+	for _, tt := range tests {
+		if got := NewFilter(tt.include, tt.exclude).Match(tt.path); got != tt.want {
+			t.Errorf("include %v exclude %v: match(%q) = %v, want %v", tt.include, tt.exclude, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"README.md", "docs/usage.md", "docs/api.rst", "docs/logo.png", "vendor/dep/README.md", "CHANGELOG.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := Walk(dir, NewFilter(nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	if want := []string{"README.md", "docs/api.rst", "docs/usage.md"}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+}
+
+func TestWriteDocumentSections(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	readme := "# Snacks\n\nA collection of small plugins.\n\n## Installation\n\nUse lazy.nvim to install.\n\n```sh\n# not a heading\n```\n\n## Configuration\n\n### `picker.sources`\n\nSources shown by the fuzzy picker.\n"
+	if err := store.WithTx(ctx, func(tx *sql.Tx) error {
+		title, markdown := Convert("README.md", readme)
+		_, err := WriteDocument(ctx, tx, "github/folke/snacks.nvim/README.md", title, markdown)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query, name, typ string
+	}{
+		{"fuzzy", "picker.sources", "Section"},
+		{"lazy", "Installation", "Section"},
+		{"plugins", "Snacks", "Document"},
+	}
+	for _, tt := range tests {
+		results, err := store.Search(ctx, tt.query, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Name != tt.name || results[0].Type != tt.typ {
+			t.Errorf("Search(%q) = %+v, want one %s %q", tt.query, results, tt.typ, tt.name)
+		}
+	}
+	results, _ := store.Search(ctx, "heading", 10)
+	if len(results) != 1 || results[0].Name != "Installation" {
+		t.Errorf("a comment in a code block became a section: %+v", results)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		path, content, title, markdown string
+	}{
+		{
+			"docs/intro.md",
+			"---\ntitle: Introduction\n---\n\n# Welcome\n\nHello.",
+			"Introduction", "# Welcome\n\nHello.",
+		},
+		{
+			"docs/no-title.md",
+			"---\nsidebar_position: 2\n---\nPlain text.",
+			"No Title", "Plain text.",
+		},
+		{
+			"docs/tabs.mdx",
+			"---\ntitle: Tabs\n---\nimport Tabs from '@theme/Tabs';\n\n<Tabs>\nContent\n</Tabs>",
+			"Tabs", "Content",
+		},
+		{
+			"docs/callouts.mdx",
+			"# Callouts\n\nexport const Highlight = ({children}) => (\n  <span>{children}</span>\n);\n\n:::warning[Breaking change]\nRename <Code name=\"x\" /> first.\n:::\n\n```jsx\n<Tabs />\n```",
+			"Callouts", "# Callouts\n\n\n> **Warning** Breaking change\n>\n> Rename  first.\n\n```jsx\n<Tabs />\n```",
+		},
+		{
+			"content/posts/hello.md",
+			"+++\ntitle = 'Hello'\nweight = 3\n+++\nBody.",
+			"Hello", "Body.",
+		},
+		{
+			"docs/api.rst",
+			"=====\nUsage\n=====\n\nRun ``tool``::\n\n    tool --help\n\nOptions\n-------\n\n.. code-block:: python\n\n   import tool\n\nMore text.",
+			"Usage", "# Usage\n\nRun `tool`:\n\n```\ntool --help\n```\n\n## Options\n\n```python\nimport tool\n```\n\nMore text.",
+		},
+		{
+			"docs/reference.rst",
+			"API\n===\n\n.. module:: tool\n\n.. note:: Needs Python 3.10.\n\n.. autofunction:: tool.run\n   :noindex:\n\n" +
+				".. py:class:: Runner(jobs=1)\n\n   Runs jobs, see :func:`~tool.run` and :class:`the base <tool.Base>`.\n\n" +
+				"   .. py:method:: start()\n\n      Starts.\n\n.. code-block:: python\n   :linenos:\n\n   tool.run()\n\n" +
+				".. _links:\n\nSee `the docs <https://tool.dev>`_ and :ref:`usage`.\n\n.. versionadded:: 2.0 The runner.\n\n.. toctree::\n\n   usage\n",
+			"API", "# API\n\n> **Note**\n>\n> Needs Python 3.10.\n\n## `tool.run`\n\n*function*\n\n## `Runner(jobs=1)`\n\n*class*\n\n" +
+				"Runs jobs, see [`run()`](/search?q=tool.run) and [`the base`](/search?q=tool.Base).\n\n### `start()`\n\n*method*\n\nStarts.\n\n" +
+				"```python\ntool.run()\n```\n\nSee [the docs](https://tool.dev) and usage.\n\n> **Added in version 2.0**\n>\n> The runner.",
+		},
+		{
+			"docs/guide.adoc",
+			"= Widget Guide\nJane Doe <jane@example.com>\n:project: Widget\n\n== Install\n\n{project} is *fast*, see https://widget.dev[the site].\n\n" +
+				"NOTE: Requires Go 1.22.\n\n. Download\n. Run:\n+\n[source,go]\n----\nwidget.Render() // <1>\n----\n\n" +
+				"[cols=\"1,2\"]\n|===\n|Flag |Meaning\n\n|`-v`\n|Verbose\n|===\n\n[WARNING]\n====\n* Breaking\n** nested\n====",
+			"Widget Guide", "# Widget Guide\n\n## Install\n\nWidget is **fast**, see [the site](https://widget.dev).\n\n> **Note**\n>\n> Requires Go 1.22.\n\n" +
+				"1. Download\n1. Run:\n```go\nwidget.Render()\n```\n\n| Flag | Meaning |\n| --- | --- |\n| `-v` | Verbose |\n\n> **Warning**\n>\n> - Breaking\n>     - nested",
+		},
+		{
+			"notes/ops.org",
+			"#+TITLE: Team Notes\n#+OPTIONS: toc:nil\n\n* TODO [#A] Deploy :ops:\n  SCHEDULED: <2024-05-01 Wed>\n  :PROPERTIES:\n  :ID: abc\n  :END:\n" +
+				"Run =make deploy= with *care*, see [[https://ops.example.com][the runbook]].\n\n- [X] build\n  - nested\n- term :: definition\n\n" +
+				"** Config\n#+BEGIN_SRC yaml\nreplicas: 3\n#+END_SRC\n\n| Name | Value |\n|------+-------|\n| a | 1 |\n\n#+BEGIN_WARNING\nCheck dashboards.\n#+END_WARNING",
+			"Team Notes", "# Team Notes\n\n## Deploy\nRun `make deploy` with **care**, see [the runbook](https://ops.example.com).\n\n- [x] build\n    - nested\n- **term**: definition\n\n" +
+				"### Config\n```yaml\nreplicas: 3\n```\n\n| Name | Value |\n| --- | --- |\n| a | 1 |\n\n> **Warning**\n>\n> Check dashboards.",
+		},
+	}
+	for _, tt := range tests {
+		title, markdown := Convert(tt.path, tt.content)
+		if title != tt.title || markdown != tt.markdown {
+			t.Errorf("Convert(%q) = %q, %q, want %q, %q", tt.path, title, markdown, tt.title, tt.markdown)
+		}
+	}
+}
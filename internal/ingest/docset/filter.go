@@ -0,0 +1,104 @@
+package docset
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultExclude skips dependency trees, test fixtures, repository metadata
+// and changelogs, which rarely document the project itself.
+var DefaultExclude = []string{"node_modules", "vendor", "third_party", "testdata", "fixtures", ".github", "CHANGELOG*"}
+
+// Filter selects document paths by glob. A pattern without a slash
+// matches any single path element, so "vendor" excludes every vendor
+// directory and "README*" includes READMEs at any depth. A pattern with a
+// slash matches the whole path, where "**" stands for any number of
+// directories: "docs/**" or "**/guide/*.md". Matching ignores case.
+type Filter struct {
+	include []string
+	exclude []string
+}
+
+// NewFilter builds a filter from include and exclude patterns; a nil exclude
+// uses DefaultExclude.
+func NewFilter(include, exclude []string) Filter {
+	if exclude == nil {
+		exclude = DefaultExclude
+	}
+	return Filter{include: lowerAll(include), exclude: lowerAll(exclude)}
+}
+
+// Match reports whether a file should be ingested: it matches an include
+// pattern, or there are none, and no exclude pattern.
+func (f Filter) Match(p string) bool {
+	p = strings.ToLower(p)
+	for _, pattern := range f.exclude {
+		if matchGlob(pattern, p) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if matchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// SkipDir reports whether an exclude pattern rules out everything below a
+// directory.
+func (f Filter) SkipDir(dir string) bool {
+	dir = strings.ToLower(dir)
+	for _, pattern := range f.exclude {
+		if matchGlob(pattern, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(pattern, p string) bool {
+	if !strings.Contains(pattern, "/") {
+		for _, elem := range strings.Split(p, "/") {
+			if ok, _ := path.Match(pattern, elem); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(p, "/"))
+}
+
+func matchSegments(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchSegments(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+func lowerAll(patterns []string) []string {
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, strings.ToLower(p))
+		}
+	}
+	return out
+}
@@ -0,0 +1,247 @@
+package docset
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// maxIncludeDepth bounds nested includes, so a file including itself does
+// not loop.
+const maxIncludeDepth = 8
+
+var (
+	adocIncludeLine = regexp.MustCompile(`^include::([^\[\s]+)\[(.*)\]\s*$`)
+	adocTagLine     = regexp.MustCompile(`\b(tag|end)::([\w-]+)\[\]`)
+	orgIncludeLine  = regexp.MustCompile(`(?i)^\s*#\+include:\s*"([^"]+)"\s*(.*)$`)
+	orgLinesArg     = regexp.MustCompile(`:lines\s+"([^"]*)"`)
+)
+
+// ReadFunc reads a file by its slash-separated path relative to the root
+// of the repository or directory being ingested.
+type ReadFunc func(p string) ([]byte, error)
+
+// DirReader reads files below root. Symbolic links are followed only while
+// they stay below root, so a cloned repository cannot pull in files from
+// elsewhere on disk.
+func DirReader(root string) ReadFunc {
+	return func(p string) ([]byte, error) {
+		r, err := os.OpenRoot(root)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		f, err := r.Open(filepath.FromSlash(p))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	}
+}
+
+// ExpandIncludes replaces the include directives of an AsciiDoc
+// (include::file[]) or Org-mode (#+INCLUDE: "file") document at p with the
+// files they name, resolved relative to p and read with read. Includes that
+// leave the root or cannot be read are dropped. Other formats are returned
+// unchanged.
+func ExpandIncludes(p, content string, read ReadFunc) string {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".adoc", ".asciidoc":
+		return expandAsciiDoc(p, shared.NormalizeLineEndings(content), read, 0)
+	case ".org":
+		return expandOrg(p, shared.NormalizeLineEndings(content), read, 0)
+	}
+	return content
+}
+
+// readInclude reads the target of an include in the file at from.
+func readInclude(from, target string, read ReadFunc) (string, string, bool) {
+	if strings.Contains(target, "://") || path.IsAbs(target) {
+		log.Debug("skipping include", "file", from, "target", target)
+		return "", "", false
+	}
+	p := path.Join(path.Dir(from), target)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		log.Debug("skipping include outside the root", "file", from, "target", target)
+		return "", "", false
+	}
+	content, err := read(p)
+	if err != nil {
+		log.Warn("failed to read include", "file", from, "target", target, "err", err)
+		return "", "", false
+	}
+	return p, shared.NormalizeLineEndings(string(content)), true
+}
+
+func expandAsciiDoc(p, content string, read ReadFunc, depth int) string {
+	attrs := map[string]string{}
+	lines := strings.Split(content, "\n")
+	var out []string
+	for _, line := range lines {
+		if m := adocAttribute.FindStringSubmatch(line); m != nil {
+			attrs[strings.Trim(m[1], "!")] = m[2]
+		}
+		m := adocIncludeLine.FindStringSubmatch(line)
+		if m == nil || depth >= maxIncludeDepth {
+			out = append(out, line)
+			continue
+		}
+
+		target := adocAttrRef.ReplaceAllStringFunc(m[1], func(ref string) string {
+			if v, ok := attrs[ref[1:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+		included, text, ok := readInclude(p, target, read)
+		if !ok {
+			continue
+		}
+
+		opts := map[string]string{}
+		for _, attr := range splitAttrs(m[2]) {
+			if k, v, ok := strings.Cut(attr, "="); ok {
+				opts[k] = strings.Trim(v, `"`)
+			}
+		}
+		body := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		if tags := opts["tags"] + ";" + opts["tag"]; tags != ";" {
+			body = taggedLines(body, strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' }))
+		}
+		if spec, ok := opts["lines"]; ok {
+			body = selectLines(body, spec, "..", false)
+		}
+		if ext := strings.ToLower(path.Ext(included)); ext == ".adoc" || ext == ".asciidoc" {
+			body = strings.Split(expandAsciiDoc(included, strings.Join(body, "\n"), read, depth+1), "\n")
+			if offset, err := strconv.Atoi(opts["leveloffset"]); err == nil {
+				body = shiftHeadings(body, offset)
+			}
+		}
+		out = append(out, body...)
+	}
+	return strings.Join(out, "\n")
+}
+
+func expandOrg(p, content string, read ReadFunc, depth int) string {
+	var out []string
+	for _, line := range strings.Split(content, "\n") {
+		m := orgIncludeLine.FindStringSubmatch(line)
+		if m == nil || depth >= maxIncludeDepth {
+			out = append(out, line)
+			continue
+		}
+		included, text, ok := readInclude(p, m[1], read)
+		if !ok {
+			continue
+		}
+
+		body := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		if l := orgLinesArg.FindStringSubmatch(m[2]); l != nil {
+			body = selectLines(body, l[1], "-", true)
+		}
+		args := strings.Fields(orgLinesArg.ReplaceAllString(m[2], ""))
+		switch {
+		case len(args) > 0 && strings.EqualFold(args[0], "src"):
+			lang := ""
+			if len(args) > 1 {
+				lang = args[1]
+			}
+			out = append(out, "#+BEGIN_SRC "+lang)
+			out = append(out, body...)
+			out = append(out, "#+END_SRC")
+		case len(args) > 0 && strings.EqualFold(args[0], "example"):
+			out = append(out, "#+BEGIN_EXAMPLE")
+			out = append(out, body...)
+			out = append(out, "#+END_EXAMPLE")
+		case strings.EqualFold(path.Ext(included), ".org"):
+			out = append(out, expandOrg(included, strings.Join(body, "\n"), read, depth+1))
+		default:
+			out = append(out, body...)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// taggedLines keeps the lines between the tag::name[] and end::name[]
+// markers of the given tags, without the marker lines.
+func taggedLines(lines, tags []string) []string {
+	var (
+		out  []string
+		open = map[string]bool{}
+	)
+	for _, line := range lines {
+		if m := adocTagLine.FindStringSubmatch(line); m != nil {
+			open[m[2]] = m[1] == "tag"
+			continue
+		}
+		for _, tag := range tags {
+			if open[tag] {
+				out = append(out, line)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// selectLines keeps the lines in spec: 1-based ranges separated by ; or ,
+// whose bounds are joined by sep. An open or -1 end runs to the last line;
+// Org excludes the end of a range, AsciiDoc includes it.
+func selectLines(lines []string, spec, sep string, exclusive bool) []string {
+	var out []string
+	for _, r := range strings.FieldsFunc(spec, func(r rune) bool { return r == ';' || r == ',' }) {
+		from, to, isRange := strings.Cut(strings.TrimSpace(r), sep)
+		start, err := strconv.Atoi(from)
+		if from == "" {
+			start, err = 1, nil
+		}
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil || end < 0 {
+				end = len(lines)
+			} else if exclusive {
+				end--
+			}
+		}
+		for i := max(start, 1); i <= min(end, len(lines)); i++ {
+			out = append(out, lines[i-1])
+		}
+	}
+	return out
+}
+
+// shiftHeadings moves the section titles of AsciiDoc lines by offset
+// levels, leaving listings and literal blocks alone.
+func shiftHeadings(lines []string, offset int) []string {
+	out := make([]string, len(lines))
+	delim := ""
+	for i, line := range lines {
+		out[i] = line
+		switch trimmed := strings.TrimRight(line, " \t"); {
+		case delim != "":
+			if trimmed == delim {
+				delim = ""
+			}
+		case isAdocDelimiter(trimmed) && (trimmed[0] == '-' || trimmed[0] == '.' || trimmed[0] == '/' || trimmed[0] == '+'):
+			delim = trimmed
+		default:
+			if m := adocHeading.FindStringSubmatch(line); m != nil {
+				level := min(max(len(m[1])+offset, 1), 6)
+				out[i] = strings.Repeat("=", level) + strings.TrimPrefix(line, m[1])
+			}
+		}
+	}
+	return out
+}
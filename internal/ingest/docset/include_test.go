@@ -0,0 +1,71 @@
+package docset
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandIncludes(t *testing.T) {
+	files := map[string]string{
+		"docs/partials/setup.adoc": "= Setup\n\nInstall it.\n\ninclude::../../../secret.adoc[]\n",
+		"docs/partials/loop.adoc":  "include::loop.adoc[]",
+		"examples/main.go":         "package main\n\n// tag::run[]\nfunc main() {}\n// end::run[]\n",
+		"notes/shared.org":         "* Shared\nBody.\n",
+		"notes/deploy.sh":          "#!/bin/sh\nset -e\nmake deploy\n",
+	}
+	read := func(p string) ([]byte, error) {
+		if content, ok := files[p]; ok {
+			return []byte(content), nil
+		}
+		return nil, fs.ErrNotExist
+	}
+
+	tests := []struct {
+		path, content, want string
+	}{
+		{
+			"docs/guide.adoc",
+			":partials: partials\n= Guide\n\ninclude::{partials}/setup.adoc[leveloffset=+1]\n\n[source,go]\n----\ninclude::../examples/main.go[tag=run]\n----\n\ninclude::missing.adoc[]\ninclude::partials/loop.adoc[]",
+			":partials: partials\n= Guide\n\n== Setup\n\nInstall it.\n\n\n[source,go]\n----\nfunc main() {}\n----\n\ninclude::loop.adoc[]",
+		},
+		{
+			"notes/index.org",
+			"#+TITLE: Notes\n#+INCLUDE: \"shared.org\"\n#+INCLUDE: \"deploy.sh\" src sh :lines \"2-3\"",
+			"#+TITLE: Notes\n* Shared\nBody.\n#+BEGIN_SRC sh\nset -e\n#+END_SRC",
+		},
+		{
+			"README.md",
+			"include::docs/partials/setup.adoc[]",
+			"include::docs/partials/setup.adoc[]",
+		},
+	}
+	for _, tt := range tests {
+		if got := ExpandIncludes(tt.path, tt.content, read); got != tt.want {
+			t.Errorf("ExpandIncludes(%q) =\n%q\nwant\n%q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDirReaderStaysInRoot(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "id_rsa"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "intro.adoc"), []byte("Intro."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"home": outside, "key.adoc": filepath.Join(outside, "id_rsa"), "alias.adoc": "intro.adoc"} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skip("symlinks not supported:", err)
+		}
+	}
+
+	read := DirReader(root)
+	got := ExpandIncludes("guide.adoc", "include::intro.adoc[]\ninclude::alias.adoc[]\ninclude::home/id_rsa[]\ninclude::key.adoc[]", read)
+	if got != "Intro.\nIntro." {
+		t.Errorf("ExpandIncludes() = %q", got)
+	}
+}
@@ -0,0 +1,228 @@
+package docset
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	orgKeyword     = regexp.MustCompile(`^#\+(\w+):\s*(.*)$`)
+	orgHeading     = regexp.MustCompile(`^(\*+)\s+(?:(?:TODO|DONE|NEXT|WAITING|CANCELLED)\s+)?(?:\[#[A-Z0-9]\]\s+)?(.*?)(?:\s+:[\w@#%:]+:)?\s*$`)
+	orgBlockBegin  = regexp.MustCompile(`(?i)^#\+begin_(\w+)(?:\s+(.*))?$`)
+	orgDrawer      = regexp.MustCompile(`^:[\w-]+:$`)
+	orgPlanning    = regexp.MustCompile(`^(?:(?:SCHEDULED|DEADLINE|CLOSED):\s*[<\[][^>\]]*[>\]]\s*)+$`)
+	orgListItem    = regexp.MustCompile(`^(\s*)(?:[-+]|\d+[.)])\s+(?:\[([ X-])\]\s+)?(.*)$`)
+	orgDescription = regexp.MustCompile(`^(.*?)\s+::(?:\s+(.*))?$`)
+	orgTableRule   = regexp.MustCompile(`^\|[-+]+\|?$`)
+	orgCode        = regexp.MustCompile(`(^|[\s(\-'"{])[=~]([^\s=~](?:[^=~]*?[^\s=~])?)[=~]([\s\-.,:!?;'")}\]]|$)`)
+	orgLink        = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	orgBold        = regexp.MustCompile(`(^|[\s(\-'"{])\*([^\s*](?:[^*]*?[^\s*])?)\*([\s\-.,:!?;'")}\]]|$)`)
+	orgItalic      = regexp.MustCompile(`(^|[\s(\-'"{])/([^\s/](?:[^/]*?[^\s/])?)/([\s\-.,:!?;'")}\]]|$)`)
+	orgStrike      = regexp.MustCompile(`(^|[\s(\-'"{])\+([^\s+](?:[^+]*?[^\s+])?)\+([\s\-.,:!?;'")}\]]|$)`)
+)
+
+// orgAdmonitions are the special blocks Org users write admonitions with.
+var orgAdmonitions = map[string]string{
+	"note": "Note", "tip": "Tip", "important": "Important", "warning": "Warning", "caution": "Caution",
+}
+
+// orgToMarkdown converts an Org-mode document to Markdown. The #+TITLE
+// becomes the H1, pushing outline headings down a level, and headings lose
+// their tags, priorities and TODO keywords. Lists, tables and source and
+// example blocks become their Markdown equivalents, quote and admonition
+// blocks block quotes; drawers, planning lines, comments and other
+// keywords are dropped. Includes are expected to be expanded already, see
+// ExpandIncludes.
+func orgToMarkdown(input string) string {
+	lines := strings.Split(input, "\n")
+	var out []string
+
+	offset := 0
+	for _, line := range lines {
+		if m := orgKeyword.FindStringSubmatch(line); m != nil && strings.EqualFold(m[1], "title") && m[2] != "" {
+			out = append(out, "# "+orgInline(m[2]))
+			offset = 1
+			break
+		}
+	}
+
+	out = append(out, orgBlocks(lines, offset)...)
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func orgBlocks(lines []string, offset int) []string {
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if m := orgHeading.FindStringSubmatch(line); m != nil {
+			out = append(out, strings.Repeat("#", min(len(m[1])+offset, 6))+" "+orgInline(m[2]))
+			continue
+		}
+
+		if m := orgBlockBegin.FindStringSubmatch(trimmed); m != nil {
+			end := i + 1
+			for end < len(lines) && !strings.EqualFold(strings.TrimSpace(lines[end]), "#+end_"+m[1]) {
+				end++
+			}
+			out = append(out, orgBlock(strings.ToLower(m[1]), m[2], dedent(lines[i+1:min(end, len(lines))]), offset)...)
+			i = end
+			continue
+		}
+
+		switch {
+		case orgKeyword.MatchString(trimmed), trimmed == "#", strings.HasPrefix(trimmed, "# "), orgPlanning.MatchString(trimmed):
+			continue
+		case orgDrawer.MatchString(trimmed) && trimmed != ":END:":
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ":END:"; i++ {
+			}
+			continue
+		case strings.HasPrefix(trimmed, "|"):
+			end := i
+			for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "|") {
+				end++
+			}
+			out = append(out, orgTable(lines[i:end])...)
+			i = end - 1
+			continue
+		case trimmed == ":" || strings.HasPrefix(trimmed, ": "):
+			var block []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if t != ":" && !strings.HasPrefix(t, ": ") {
+					break
+				}
+				block = append(block, strings.TrimPrefix(strings.TrimPrefix(t, ":"), " "))
+			}
+			out = append(out, fenced("", block)...)
+			i--
+			continue
+		case len(trimmed) >= 5 && strings.Count(trimmed, "-") == len(trimmed):
+			out = append(out, "---")
+			continue
+		}
+
+		if m := orgListItem.FindStringSubmatch(line); m != nil {
+			marker := "-"
+			if c := strings.TrimSpace(line)[0]; c >= '0' && c <= '9' {
+				marker = "1."
+			}
+			switch m[2] {
+			case "X":
+				marker += " [x]"
+			case " ", "-":
+				marker += " [ ]"
+			}
+			text := m[3]
+			if d := orgDescription.FindStringSubmatch(text); d != nil && marker == "-" {
+				text = "**" + orgInline(d[1]) + "**"
+				if d[2] != "" {
+					text += ": " + orgInline(d[2])
+				}
+			} else {
+				text = orgInline(text)
+			}
+			out = append(out, listIndent(m[1])+marker+" "+text)
+			continue
+		}
+
+		out = append(out, orgInline(line))
+	}
+	return out
+}
+
+// orgBlock converts a #+BEGIN_ block of the given type.
+func orgBlock(kind, params string, content []string, offset int) []string {
+	switch kind {
+	case "src":
+		lang, _, _ := strings.Cut(params, " ")
+		return fenced(lang, content)
+	case "example":
+		return fenced("", content)
+	case "comment":
+		return nil
+	case "export":
+		if format := strings.ToLower(strings.TrimSpace(params)); format == "markdown" || format == "md" || format == "html" {
+			return content
+		}
+		return nil
+	case "quote", "verse":
+		return quoteLines("", orgBlocks(content, offset))
+	}
+	if label := orgAdmonitions[kind]; label != "" {
+		return quoteLines("**"+label+"**", orgBlocks(content, offset))
+	}
+	// center and other special blocks keep their content.
+	return orgBlocks(content, offset)
+}
+
+// orgTable converts an Org table; a rule below the first row, or none at
+// all, makes it the header.
+func orgTable(lines []string) []string {
+	var rows [][]string
+	cols := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if orgTableRule.MatchString(line) {
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for i, cell := range cells {
+			cells[i] = strings.TrimSpace(orgInline(strings.TrimSpace(cell)))
+		}
+		cols = max(cols, len(cells))
+		rows = append(rows, cells)
+	}
+
+	var out []string
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		out = append(out, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			out = append(out, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+	return out
+}
+
+// orgInline converts links and emphasis outside code.
+func orgInline(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range orgCode.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(orgMarkup(s[last:m[3]]))
+		b.WriteString("`" + s[m[4]:m[5]] + "`")
+		last = m[6]
+	}
+	b.WriteString(orgMarkup(s[last:]))
+	return b.String()
+}
+
+func orgMarkup(s string) string {
+	s = orgLink.ReplaceAllStringFunc(s, func(match string) string {
+		m := orgLink.FindStringSubmatch(match)
+		target, desc := m[1], m[2]
+		isURL := strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:")
+		switch {
+		case isURL && desc != "":
+			return "[" + desc + "](" + target + ")"
+		case isURL:
+			return "<" + target + ">"
+		case desc != "":
+			return desc
+		}
+		return strings.TrimLeft(strings.TrimPrefix(target, "file:"), "*#")
+	})
+	s = orgBold.ReplaceAllString(s, "$1**$2**$3")
+	s = orgItalic.ReplaceAllString(s, "$1*$2*$3")
+	return orgStrike.ReplaceAllString(s, "$1~~$2~~$3")
+}
+
+// listIndent doubles an Org list's indentation, so nested items stay
+// inside the content of their parent in Markdown.
+func listIndent(indent string) string {
+	return strings.Repeat(" ", 2*len(strings.ReplaceAll(indent, "\t", "  ")))
+}
@@ -0,0 +1,378 @@
+package docset
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	rstDirective   = regexp.MustCompile(`^(\s*)\.\.\s+([\w:.-]+)::(?:\s+(.*?))?\s*$`)
+	rstComment     = regexp.MustCompile(`^\s*\.\.(\s|$)`)
+	rstOption      = regexp.MustCompile(`^:([\w-]+):\s*(.*)$`)
+	rstAutoNumber  = regexp.MustCompile(`^(\s*)#\.(\s)`)
+	rstInline      = regexp.MustCompile("``(.+?)``|:((?:[\\w-]+:)?[\\w-]+):`([^`]+)`|`([^`]+)`__?")
+	rstObject      = regexp.MustCompile(`^(?:[a-z]+:)?(auto)?(function|class|method|attribute|data|exception|module|decorator|property|classmethod|staticmethod|macro|struct|member|type|var|enum)$`)
+	rstTitledLink  = regexp.MustCompile(`^(.*?)\s*<([^<>]+)>$`)
+	rstAdmonitions = map[string]string{
+		"note": "Note", "warning": "Warning", "tip": "Tip", "hint": "Hint",
+		"important": "Important", "caution": "Caution", "danger": "Danger",
+		"attention": "Attention", "error": "Error", "seealso": "See also", "todo": "Todo",
+	}
+	rstVersions = map[string]string{
+		"versionadded": "Added in version", "versionchanged": "Changed in version",
+		"deprecated": "Deprecated since version", "versionremoved": "Removed in version",
+	}
+)
+
+// rstSkipped are directives whose output cannot be reproduced from the
+// source alone, or that only affect the build, and are dropped with their
+// content. Navigation (toctree) is read by detectSphinx instead.
+var rstSkipped = map[string]bool{
+	"toctree": true, "contents": true, "index": true, "include": true, "literalinclude": true,
+	"image": true, "raw": true, "highlight": true, "module": true, "currentmodule": true,
+	"default-role": true, "default-domain": true, "sectionauthor": true, "codeauthor": true,
+	"moduleauthor": true, "meta": true, "autosummary": true, "tabularcolumns": true,
+}
+
+// rstToMarkdown converts a reStructuredText document to Markdown: section
+// titles become ATX headings, levelled in the order their adornment styles
+// first appear, literal and code blocks become fenced code and admonitions
+// block quotes. Sphinx object descriptions (autofunction, py:class, ...)
+// become headings one level below the enclosing section, with their
+// docstrings left to the source, and cross-reference roles (:func:,
+// :class:, ...) become links to a search for their target.
+func rstToMarkdown(input string) string {
+	var c rstConverter
+	return strings.TrimSpace(strings.Join(c.convert(strings.Split(input, "\n")), "\n"))
+}
+
+type rstConverter struct {
+	styles []string // adornment styles in the order they appeared
+	level  int      // level of the last heading written
+}
+
+func (c *rstConverter) heading(style, title string) string {
+	level := 0
+	for i, s := range c.styles {
+		if s == style {
+			level = i + 1
+		}
+	}
+	if level == 0 {
+		c.styles = append(c.styles, style)
+		level = len(c.styles)
+	}
+	c.level = min(level, 6)
+	return strings.Repeat("#", c.level) + " " + convertInlineRST(title)
+}
+
+func (c *rstConverter) convert(lines []string) []string {
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		// Overlined title: adornment, title, matching adornment.
+		if isAdornment(line) && i+2 < len(lines) && strings.TrimSpace(lines[i+1]) != "" &&
+			strings.TrimRight(lines[i+2], " \t") == strings.TrimRight(line, " \t") {
+			out = append(out, c.heading("over"+line[:1], strings.TrimSpace(lines[i+1])))
+			i += 2
+			continue
+		}
+
+		// Underlined title: text followed by an adornment at least as long.
+		if trimmed != "" && !isAdornment(line) && i+1 < len(lines) &&
+			isAdornment(lines[i+1]) && len(strings.TrimSpace(lines[i+1])) >= len(trimmed) &&
+			line == strings.TrimLeft(line, " \t") {
+			out = append(out, c.heading(lines[i+1][:1], trimmed))
+			i++
+			continue
+		}
+
+		if m := rstDirective.FindStringSubmatch(line); m != nil {
+			block, next := indentedBlock(lines, i+1, len(m[1]))
+			_, body := splitOptions(block)
+			converted := c.directive(m[2], m[3], body)
+			for _, l := range converted {
+				if l != "" {
+					l = m[1] + l
+				}
+				out = append(out, l)
+			}
+			i = next - 1
+			if len(converted) == 0 {
+				i = skipBlank(lines, i, out)
+			}
+			continue
+		}
+
+		// Comments and hyperlink targets, with any indented text.
+		if rstComment.MatchString(line) {
+			_, next := indentedBlock(lines, i+1, len(line)-len(strings.TrimLeft(line, " \t")))
+			i = skipBlank(lines, next-1, out)
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, "::") {
+			block, next := indentedBlock(lines, i+1, len(line)-len(strings.TrimLeft(line, " \t")))
+			if len(block) > 0 {
+				if text := strings.TrimSpace(strings.TrimSuffix(trimmed, "::")); text != "" {
+					out = append(out, convertInlineRST(text)+":", "")
+				}
+				out = append(out, fenced("", block)...)
+				i = next - 1
+				continue
+			}
+		}
+
+		line = rstAutoNumber.ReplaceAllString(line, "${1}1.$2")
+		out = append(out, convertInlineRST(line))
+	}
+	return out
+}
+
+// directive converts a directive with its argument and content.
+func (c *rstConverter) directive(name, arg string, body []string) []string {
+	short := name[strings.LastIndex(name, ":")+1:]
+	switch {
+	case rstSkipped[short]:
+		return nil
+
+	case short == "code-block" || short == "code" || short == "sourcecode":
+		return fenced(arg, body)
+
+	case short == "math":
+		if arg != "" {
+			body = append([]string{arg}, body...)
+		}
+		return fenced("math", body)
+
+	case rstAdmonitions[short] != "":
+		if arg != "" {
+			body = append([]string{arg, ""}, body...)
+		}
+		return c.quote("**"+rstAdmonitions[short]+"**", body)
+
+	case short == "admonition":
+		return c.quote("**"+convertInlineRST(arg)+"**", body)
+
+	case rstVersions[short] != "":
+		version, text, _ := strings.Cut(arg, " ")
+		if text != "" {
+			body = append([]string{text, ""}, body...)
+		}
+		return c.quote("**"+rstVersions[short]+" "+version+"**", body)
+	}
+
+	if m := rstObject.FindStringSubmatch(short); m != nil && arg != "" && !(m[1] == "" && m[2] == "module") {
+		return c.object(m[2], arg, body)
+	}
+
+	// Containers (only, container, figure, tab, ...) keep their content.
+	return c.convert(body)
+}
+
+// object writes the description of an API object as a heading one level
+// below the enclosing section. autodoc directives carry no text in the
+// source, so theirs is just the heading and the kind of object.
+func (c *rstConverter) object(kind, signature string, body []string) []string {
+	parent := c.level
+	c.level = min(max(parent+1, 2), 6)
+	out := []string{strings.Repeat("#", c.level) + " `" + signature + "`", "", "*" + kind + "*"}
+	if content := c.convert(body); len(content) > 0 {
+		out = append(out, "")
+		out = append(out, content...)
+	}
+	c.level = parent
+	return out
+}
+
+// quote converts body into a block quote opened by heading.
+func (c *rstConverter) quote(heading string, body []string) []string {
+	return quoteLines(heading, c.convert(body))
+}
+
+// skipBlank moves past the blank line following line i when the output
+// already ends with one, so dropped markup leaves a single blank line.
+func skipBlank(lines []string, i int, out []string) int {
+	if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) == "" && (len(out) == 0 || out[len(out)-1] == "") {
+		return i + 1
+	}
+	return i
+}
+
+func fenced(lang string, block []string) []string {
+	out := append([]string{"```" + lang}, block...)
+	return append(out, "```")
+}
+
+// quoteLines turns lines into a block quote opened by heading.
+func quoteLines(heading string, lines []string) []string {
+	var out []string
+	if heading != "" {
+		out = append(out, "> "+heading, ">")
+	}
+	for _, line := range trimBlank(lines) {
+		out = append(out, strings.TrimRight("> "+line, " "))
+	}
+	return out
+}
+
+// dedent removes the indentation common to all non-blank lines.
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		out[i] = line
+	}
+	return out
+}
+
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// splitOptions separates the field list of options opening a directive's
+// content from the content itself.
+func splitOptions(block []string) (map[string]string, []string) {
+	options := map[string]string{}
+	i := 0
+	for ; i < len(block); i++ {
+		m := rstOption.FindStringSubmatch(block[i])
+		if m == nil {
+			break
+		}
+		options[m[1]] = m[2]
+	}
+	for i < len(block) && strings.TrimSpace(block[i]) == "" {
+		i++
+	}
+	return options, block[i:]
+}
+
+// indentedBlock returns the lines starting at start that are indented
+// further than indent, skipping leading blank lines and dedented by the
+// first line's indentation, and the index of the first line after the
+// block.
+func indentedBlock(lines []string, start, indent int) ([]string, int) {
+	i := start
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i >= len(lines) || len(lines[i])-len(strings.TrimLeft(lines[i], " \t")) <= indent {
+		return nil, start
+	}
+	prefix := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+
+	var block []string
+	end := i
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			block = append(block, "")
+			continue
+		}
+		if !strings.HasPrefix(lines[i], prefix) {
+			break
+		}
+		block = append(block, strings.TrimPrefix(lines[i], prefix))
+		end = i + 1
+	}
+	return block[:len(block)-(i-end)], end
+}
+
+// isAdornment reports whether a line is a section title underline or
+// overline: three or more of the same punctuation character.
+func isAdornment(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 3 || !strings.ContainsRune(`=-~^"'`+"`"+`#*+:._`, rune(line[0])) {
+		return false
+	}
+	return strings.Count(line, line[:1]) == len(line)
+}
+
+// convertInlineRST converts inline literals, roles and hyperlink references.
+func convertInlineRST(s string) string {
+	return rstInline.ReplaceAllStringFunc(s, func(match string) string {
+		m := rstInline.FindStringSubmatch(match)
+		switch {
+		case m[1] != "":
+			return "`" + m[1] + "`"
+		case m[2] != "":
+			return rstRole(m[2][strings.LastIndex(m[2], ":")+1:], m[3])
+		}
+		if title, target, ok := titledTarget(m[4]); ok && !strings.HasSuffix(target, "_") {
+			return "[" + title + "](" + target + ")"
+		} else if ok {
+			return title
+		}
+		return m[4]
+	})
+}
+
+// rstRole converts an interpreted text role. Sphinx cross-references to
+// API objects link to a search for their target; references to labels,
+// documents and terms keep their text.
+func rstRole(role, text string) string {
+	switch role {
+	case "func", "meth", "class", "mod", "attr", "exc", "data", "obj", "const",
+		"type", "member", "macro", "struct", "var", "enum", "enumerator", "any":
+		title, target, explicit := titledTarget(text)
+		if !explicit {
+			target = strings.TrimLeft(text, "~!.")
+			title = target
+			if strings.HasPrefix(text, "~") {
+				title = title[strings.LastIndex(title, ".")+1:]
+			}
+			if role == "func" || role == "meth" {
+				title += "()"
+			}
+		}
+		if strings.HasPrefix(text, "!") {
+			return "`" + title + "`"
+		}
+		return "[`" + title + "`](/search?q=" + url.QueryEscape(target) + ")"
+	case "ref", "doc", "term", "numref", "keyword", "abbr", "pep", "rfc":
+		if title, _, ok := titledTarget(text); ok {
+			return title
+		}
+		if role == "pep" || role == "rfc" {
+			return strings.ToUpper(role) + " " + text
+		}
+		return text
+	case "code", "samp", "file", "command", "program", "envvar", "kbd", "option",
+		"makevar", "regexp", "mimetype", "literal", "math":
+		return "`" + text + "`"
+	case "emphasis", "dfn":
+		return "*" + text + "*"
+	case "strong":
+		return "**" + text + "**"
+	}
+	return text
+}
+
+// titledTarget splits "Title <target>" references.
+func titledTarget(text string) (title, target string, ok bool) {
+	m := rstTitledLink.FindStringSubmatch(text)
+	if m == nil || m[1] == "" {
+		return "", "", false
+	}
+	return strings.Join(strings.Fields(m[1]), " "), m[2], true
+}
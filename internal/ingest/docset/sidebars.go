@@ -0,0 +1,277 @@
+package docset
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+var docusaurusTitle = regexp.MustCompile(`\btitle\s*:\s*["']([^"']+)["']`)
+
+// detectDocusaurus reads the sidebars of a Docusaurus site. Without a
+// sidebars file the docs directory is listed as an autogenerated sidebar.
+func detectDocusaurus(root string) (*Site, error) {
+	sidebars, hasSidebars := firstFile(root, "sidebars.js", "sidebars.ts", "sidebars.mjs", "sidebars.cjs", "sidebars.json")
+	config, hasConfig := firstFile(root, "docusaurus.config.js", "docusaurus.config.ts", "docusaurus.config.mjs")
+	if !hasSidebars && !hasConfig {
+		return nil, nil
+	}
+
+	site := &Site{Generator: "docusaurus"}
+	if hasConfig {
+		if content, err := os.ReadFile(config); err == nil {
+			if m := docusaurusTitle.FindSubmatch(content); m != nil {
+				site.Title = string(m[1])
+			}
+		}
+	}
+
+	d := docusaurus{root: root, docsDir: "docs"}
+	if !hasSidebars {
+		site.Nav = treeNav(root, d.docsDir, 0)
+		return site, nil
+	}
+
+	content, err := os.ReadFile(sidebars)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := jsObject(string(content))
+	if !ok {
+		return nil, errors.New(filepath.Base(sidebars) + ": no sidebars object found")
+	}
+	var parsed yaml.MapSlice
+	if err := yaml.UnmarshalWithOptions([]byte(object), &parsed, yaml.UseOrderedMap()); err != nil {
+		return nil, errors.New(filepath.Base(sidebars) + ": " + err.Error())
+	}
+
+	d.ids = docIDs(root, d.docsDir)
+	if len(parsed) == 1 {
+		d.items(parsed[0].Value, 0, &site.Nav)
+		return site, nil
+	}
+	for _, sidebar := range parsed {
+		site.Nav = append(site.Nav, db.NavEntry{Title: splitCamel(toString(sidebar.Key))})
+		d.items(sidebar.Value, 1, &site.Nav)
+	}
+	return site, nil
+}
+
+type docusaurus struct {
+	root    string
+	docsDir string
+	// ids maps document ids to page paths.
+	ids map[string]string
+}
+
+// items appends a list of sidebar items, or the categories of the
+// shorthand {"Label": [items]} form.
+func (d docusaurus) items(v any, depth int, nav *[]db.NavEntry) {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			d.item(item, depth, nav)
+		}
+	case yaml.MapSlice:
+		for _, category := range v {
+			*nav = append(*nav, db.NavEntry{Title: toString(category.Key), Depth: depth})
+			d.items(category.Value, depth+1, nav)
+		}
+	}
+}
+
+// item appends a sidebar item: a doc id, a doc, ref or category object, or
+// an autogenerated directory. Links and HTML items are left out.
+func (d docusaurus) item(v any, depth int, nav *[]db.NavEntry) {
+	switch v := v.(type) {
+	case string:
+		*nav = append(*nav, db.NavEntry{Path: d.ids[v], Depth: depth})
+	case yaml.MapSlice:
+		switch lookup(v, "type") {
+		case "doc", "ref":
+			*nav = append(*nav, db.NavEntry{Title: toString(lookup(v, "label")), Path: d.ids[toString(lookup(v, "id"))], Depth: depth})
+		case "category":
+			entry := db.NavEntry{Title: toString(lookup(v, "label")), Depth: depth}
+			if link, ok := lookup(v, "link").(yaml.MapSlice); ok && lookup(link, "type") == "doc" {
+				entry.Path = d.ids[toString(lookup(link, "id"))]
+			}
+			*nav = append(*nav, entry)
+			d.items(lookup(v, "items"), depth+1, nav)
+		case "autogenerated":
+			*nav = append(*nav, treeNav(d.root, path.Join(d.docsDir, toString(lookup(v, "dirName"))), depth)...)
+		case nil:
+			if id, ok := lookup(v, "id").(string); ok {
+				*nav = append(*nav, db.NavEntry{Title: toString(lookup(v, "label")), Path: d.ids[id], Depth: depth})
+			} else {
+				d.items(v, depth, nav)
+			}
+		}
+	}
+}
+
+// docIDs maps the ids Docusaurus gives the pages below docsDir to their
+// paths: the path without extension and number prefixes, with the last
+// segment replaced by the front matter id when there is one.
+func docIDs(root, docsDir string) map[string]string {
+	ids := map[string]string{}
+	base := filepath.Join(root, filepath.FromSlash(docsDir))
+	_ = filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !IsDocFile(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		segments := strings.Split(strings.TrimSuffix(rel, path.Ext(rel)), "/")
+		for i, s := range segments {
+			segments[i] = numberPrefix.ReplaceAllString(s, "")
+		}
+		if content, err := os.ReadFile(p); err == nil {
+			if fm, _, ok := SplitFrontMatter(shared.NormalizeLineEndings(string(content))); ok && fm.ID != "" {
+				segments[len(segments)-1] = fm.ID
+			}
+		}
+		ids[strings.Join(segments, "/")] = path.Join(docsDir, rel)
+		return nil
+	})
+	return ids
+}
+
+func lookup(m yaml.MapSlice, key string) any {
+	for _, item := range m {
+		if toString(item.Key) == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func toString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+// splitCamel turns a sidebar id such as tutorialSidebar into a title.
+func splitCamel(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return shared.Capitalize(b.String())
+}
+
+var jsObjectStart = regexp.MustCompile(`(?:=|export\s+default)\s*\{`)
+
+// jsObject extracts the object literal a sidebars file exports and rewrites
+// it into YAML flow syntax: comments and trailing commas are dropped,
+// single-quoted and template strings are re-quoted and keys get a space
+// after their colon. Object literals that compute their values cannot be
+// read this way.
+func jsObject(src string) (string, bool) {
+	src = normalizeJS(src)
+	start := 0
+	if !strings.HasPrefix(strings.TrimSpace(src), "{") {
+		loc := jsObjectStart.FindStringIndex(src)
+		if loc == nil {
+			return "", false
+		}
+		start = loc[1] - 1
+	}
+
+	var b strings.Builder
+	depth := 0
+	for i := start; i < len(src); i++ {
+		c := src[i]
+		switch c {
+		case '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			b.WriteString(src[i:min(end+1, len(src))])
+			i = end
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			if next := strings.TrimLeft(src[i+1:], " \t\r\n"); next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		case ':':
+			b.WriteString(": ")
+			continue
+		}
+		b.WriteByte(c)
+		if depth == 0 {
+			return b.String(), true
+		}
+	}
+	return "", false
+}
+
+// normalizeJS removes comments and re-quotes every string literal as a
+// double-quoted JSON string.
+func normalizeJS(src string) string {
+	var b strings.Builder
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+		case c == '"' || c == '\'' || c == '`':
+			var s strings.Builder
+			i++
+			for ; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						s.WriteByte('\n')
+					case 't':
+						s.WriteByte('\t')
+					default:
+						s.WriteByte(src[i])
+					}
+					continue
+				}
+				s.WriteByte(src[i])
+			}
+			quoted, _ := json.Marshal(s.String())
+			b.Write(quoted)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
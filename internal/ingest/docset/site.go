@@ -0,0 +1,548 @@
+package docset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goccy/go-yaml"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// Site is a documentation site built by a static-site generator, with the
+// table of contents its configuration declares.
+type Site struct {
+	// Generator is mdbook, gitbook, mkdocs, docusaurus, hugo or sphinx.
+	Generator string
+	Title     string
+	// Nav lists the pages in reading order by path relative to the site
+	// root. Sections without a page of their own have an empty path.
+	Nav []db.NavEntry
+}
+
+// siteFiles are the configuration files the generators read their
+// navigation from.
+var siteFiles = []string{
+	"book.toml", "SUMMARY.md",
+	"mkdocs.yml", "mkdocs.yaml",
+	"sidebars.js", "sidebars.ts", "sidebars.mjs", "sidebars.cjs", "sidebars.json",
+	"docusaurus.config.js", "docusaurus.config.ts", "docusaurus.config.mjs",
+	"_category_.json", "_category_.yml", "_category_.yaml",
+	"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json",
+	"config.toml", "config.yaml", "config.yml",
+	"conf.py",
+}
+
+// IsSiteFile reports whether a path names a file a static-site generator
+// reads navigation from, so changing it can reorder the table of contents.
+func IsSiteFile(p string) bool {
+	return slices.Contains(siteFiles, path.Base(filepath.ToSlash(p)))
+}
+
+// DetectSite looks for the configuration of mdBook, MkDocs, Docusaurus,
+// Hugo or Sphinx in root and returns the site it declares, or nil when root is not
+// such a site. Pages listed without a title take their label from the
+// front matter, else the title Convert finds.
+func DetectSite(root string) (*Site, error) {
+	for _, detect := range []func(string) (*Site, error){detectMdBook, detectMkDocs, detectDocusaurus, detectHugo, detectSphinx} {
+		site, err := detect(root)
+		if err != nil {
+			return nil, err
+		}
+		if site != nil {
+			site.fillTitles(root)
+			return site, nil
+		}
+	}
+	return nil, nil
+}
+
+// Convert converts a file like the package-level Convert, but a page
+// without a title of its own takes the one the site's navigation declares.
+// A nil site converts as Convert does.
+func (s *Site) Convert(p, content string) (title, markdown string) {
+	title, markdown = Convert(p, content)
+	if s == nil || title != TitleFromPath(p) {
+		return title, markdown
+	}
+	for _, entry := range s.Nav {
+		if entry.Path == p && entry.Title != "" {
+			return entry.Title, markdown
+		}
+	}
+	return title, markdown
+}
+
+// IndexSite stores the table of contents of site for the documents below
+// prefix, keeping the entries whose page is among files and the sections
+// leading to them. A nil site, or one without any of the files, removes a
+// previously stored table of contents.
+func IndexSite(ctx context.Context, tx *sql.Tx, prefix string, site *Site, files []string) error {
+	if site == nil {
+		return db.DeleteSiteTx(ctx, tx, prefix)
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, f := range files {
+		present[f] = true
+	}
+
+	keep := make([]bool, len(site.Nav))
+	for i := len(site.Nav) - 1; i >= 0; i-- {
+		entry := site.Nav[i]
+		keep[i] = present[entry.Path]
+		for j := i + 1; !keep[i] && j < len(site.Nav) && site.Nav[j].Depth > entry.Depth; j++ {
+			keep[i] = keep[j]
+		}
+	}
+
+	var nav []db.NavEntry
+	for i, entry := range site.Nav {
+		if !keep[i] {
+			continue
+		}
+		if present[entry.Path] {
+			entry.Path = prefix + "/" + entry.Path
+		} else {
+			entry.Path = ""
+		}
+		nav = append(nav, entry)
+	}
+	if len(nav) == 0 {
+		return db.DeleteSiteTx(ctx, tx, prefix)
+	}
+
+	title := site.Title
+	if title == "" {
+		title = path.Base(prefix)
+	}
+	return db.ReplaceSiteTx(ctx, tx, db.Site{Root: prefix, Generator: site.Generator, Title: title}, nav)
+}
+
+// fillTitles gives pages listed without a title their front matter label or
+// converted title.
+func (s *Site) fillTitles(root string) {
+	for i, entry := range s.Nav {
+		if entry.Title != "" || entry.Path == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(entry.Path)))
+		if err != nil {
+			s.Nav[i].Title = TitleFromPath(entry.Path)
+			continue
+		}
+		if fm, _, ok := SplitFrontMatter(shared.NormalizeLineEndings(string(content))); ok && fm.label() != "" {
+			s.Nav[i].Title = fm.label()
+			continue
+		}
+		s.Nav[i].Title, _ = Convert(entry.Path, string(content))
+	}
+}
+
+// label is the navigation label a page declares: Docusaurus' sidebar_label
+// or Hugo's linkTitle, else its title.
+func (fm FrontMatter) label() string {
+	switch {
+	case fm.SidebarLabel != "":
+		return fm.SidebarLabel
+	case fm.LinkTitle != "":
+		return fm.LinkTitle
+	}
+	return fm.Title
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+// firstFile returns the first of names that exists in dir.
+func firstFile(dir string, names ...string) (string, bool) {
+	for _, name := range names {
+		if p := filepath.Join(dir, name); exists(p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+var (
+	summaryHeading = regexp.MustCompile(`^#+\s+(.+?)\s*$`)
+	summaryLink    = regexp.MustCompile(`^(\s*)([-*+]\s+)?\[(.*)\]\((.*)\)\s*$`)
+)
+
+// detectMdBook reads the SUMMARY.md of an mdBook (book.toml, with the
+// summary in its src directory) or of a GitBook (SUMMARY.md in the root).
+func detectMdBook(root string) (*Site, error) {
+	var book struct {
+		Book struct {
+			Title string `toml:"title"`
+			Src   string `toml:"src"`
+		} `toml:"book"`
+	}
+
+	site := &Site{Generator: "gitbook"}
+	src := ""
+	if content, err := os.ReadFile(filepath.Join(root, "book.toml")); err == nil {
+		if err := toml.Unmarshal(content, &book); err != nil {
+			return nil, errors.New("book.toml: " + err.Error())
+		}
+		site.Generator, site.Title = "mdbook", book.Book.Title
+		src = book.Book.Src
+		if src == "" {
+			src = "src"
+		}
+		src = path.Clean(filepath.ToSlash(src))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(src), "SUMMARY.md"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	site.Nav = parseSummary(string(content), src)
+	return site, nil
+}
+
+// parseSummary reads the chapters of an mdBook SUMMARY.md: prefix and
+// suffix chapters as top-level entries, nested list items as nested
+// chapters and part titles as sections holding the chapters that follow.
+// Paths are joined to dir.
+func parseSummary(content, dir string) []db.NavEntry {
+	var (
+		nav     []db.NavEntry
+		indents []int
+		base    int
+	)
+	for _, line := range strings.Split(shared.NormalizeLineEndings(content), "\n") {
+		if m := summaryHeading.FindStringSubmatch(line); m != nil {
+			// The first heading titles the summary itself.
+			if len(nav) > 0 {
+				nav = append(nav, db.NavEntry{Title: shared.PlainHeading(m[1])})
+				base, indents = 1, nil
+			}
+			continue
+		}
+
+		m := summaryLink.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		depth := 0
+		if m[2] != "" {
+			indent := len(strings.ReplaceAll(m[1], "\t", "    "))
+			for len(indents) > 0 && indents[len(indents)-1] > indent {
+				indents = indents[:len(indents)-1]
+			}
+			if len(indents) == 0 || indents[len(indents)-1] < indent {
+				indents = append(indents, indent)
+			}
+			depth = base + len(indents) - 1
+		}
+		nav = append(nav, db.NavEntry{Title: shared.PlainHeading(m[3]), Path: linkPath(dir, m[4]), Depth: depth})
+	}
+	return nav
+}
+
+// linkPath resolves a relative link to a page against dir. External links,
+// anchors and empty (draft) links resolve to "".
+func linkPath(dir, link string) string {
+	link, _, _ = strings.Cut(strings.TrimSpace(link), "#")
+	if link == "" || strings.Contains(link, "://") || strings.HasPrefix(link, "/") {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(link); err == nil {
+		link = unescaped
+	}
+	return strings.TrimPrefix(path.Join(dir, link), "./")
+}
+
+// detectMkDocs reads the nav of mkdocs.yml, or lists docs_dir like MkDocs
+// does when there is none.
+func detectMkDocs(root string) (*Site, error) {
+	file, ok := firstFile(root, "mkdocs.yml", "mkdocs.yaml")
+	if !ok {
+		return nil, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		SiteName string `json:"site_name"`
+		DocsDir  string `json:"docs_dir"`
+		Nav      []any  `json:"nav"`
+	}
+	if err := yaml.Unmarshal([]byte(yamlKeys(string(content), "site_name", "docs_dir", "nav")), &config); err != nil {
+		return nil, errors.New(filepath.Base(file) + ": " + err.Error())
+	}
+	docsDir := config.DocsDir
+	if docsDir == "" {
+		docsDir = "docs"
+	}
+	docsDir = path.Clean(filepath.ToSlash(docsDir))
+
+	site := &Site{Generator: "mkdocs", Title: config.SiteName}
+	if config.Nav == nil {
+		site.Nav = treeNav(root, docsDir, 0)
+	} else {
+		mkdocsNav(config.Nav, docsDir, 0, &site.Nav)
+	}
+	return site, nil
+}
+
+// mkdocsNav appends the entries of an MkDocs nav: bare paths, "Title: path"
+// pages and "Title: [...]" sections. External links are left out.
+func mkdocsNav(items []any, dir string, depth int, nav *[]db.NavEntry) {
+	for _, item := range items {
+		switch item := item.(type) {
+		case string:
+			if p := linkPath(dir, item); p != "" {
+				*nav = append(*nav, db.NavEntry{Path: p, Depth: depth})
+			}
+		case map[string]any:
+			for title, value := range item {
+				switch value := value.(type) {
+				case string:
+					if p := linkPath(dir, value); p != "" {
+						*nav = append(*nav, db.NavEntry{Title: title, Path: p, Depth: depth})
+					}
+				case []any:
+					*nav = append(*nav, db.NavEntry{Title: title, Depth: depth})
+					mkdocsNav(value, dir, depth+1, nav)
+				}
+			}
+		}
+	}
+}
+
+var yamlTopKey = regexp.MustCompile(`^([A-Za-z_][\w-]*)\s*:`)
+
+// yamlKeys keeps the top-level blocks of the named keys of a YAML document,
+// so configuration using tags the parser cannot resolve elsewhere, such as
+// MkDocs' !!python/name and !ENV, still yields them.
+func yamlKeys(content string, keys ...string) string {
+	var out []string
+	keep := false
+	for _, line := range strings.Split(shared.NormalizeLineEndings(content), "\n") {
+		if m := yamlTopKey.FindStringSubmatch(line); m != nil {
+			keep = slices.Contains(keys, m[1])
+		}
+		if keep {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// detectHugo lists the content directory of a Hugo site, ordered by the
+// pages' weight.
+func detectHugo(root string) (*Site, error) {
+	file, ok := firstFile(root,
+		"hugo.toml", "hugo.yaml", "hugo.yml", "hugo.json",
+		"config.toml", "config.yaml", "config.yml",
+		"config/_default/hugo.toml", "config/_default/hugo.yaml", "config/_default/config.toml", "config/_default/config.yaml",
+	)
+	if !ok {
+		return nil, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Title      string `json:"title" toml:"title"`
+		ContentDir string `json:"contentDir" toml:"contentDir"`
+	}
+	if filepath.Ext(file) == ".toml" {
+		_, err = toml.Decode(string(content), &config)
+	} else {
+		err = yaml.Unmarshal(content, &config)
+	}
+	if err != nil {
+		return nil, errors.New(filepath.Base(file) + ": " + err.Error())
+	}
+
+	contentDir := config.ContentDir
+	if contentDir == "" {
+		contentDir = "content"
+	}
+	contentDir = path.Clean(filepath.ToSlash(contentDir))
+	if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(contentDir))); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	return &Site{Generator: "hugo", Title: config.Title, Nav: treeNav(root, contentDir, 0)}, nil
+}
+
+// navNode is a page or directory while a tree is ordered.
+type navNode struct {
+	entry    db.NavEntry
+	name     string
+	order    float64
+	ordered  bool
+	children []navNode
+}
+
+var numberPrefix = regexp.MustCompile(`^(\d+)[-_. ]+`)
+
+// treeNav lists the pages below dir the way generators without an explicit
+// nav do: a directory is a section whose page is its index.md, _index.md or
+// README.md, and entries are ordered by their sidebar_position, weight or
+// number prefix (02-setup.md), then by name. The root's own index comes
+// first. Drafts, dot files and names starting with an underscore are left
+// out.
+func treeNav(root, dir string, depth int) []db.NavEntry {
+	nodes, index := dirNodes(root, dir)
+	if index != nil {
+		nodes = append([]navNode{*index}, nodes...)
+	}
+	var nav []db.NavEntry
+	flattenNav(nodes, depth, &nav)
+	return nav
+}
+
+func flattenNav(nodes []navNode, depth int, nav *[]db.NavEntry) {
+	for _, node := range nodes {
+		node.entry.Depth = depth
+		*nav = append(*nav, node.entry)
+		flattenNav(node.children, depth+1, nav)
+	}
+}
+
+// dirNodes returns the ordered pages and subdirectories of dir, and its
+// index page.
+func dirNodes(root, dir string) ([]navNode, *navNode) {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, nil
+	}
+
+	var (
+		nodes []navNode
+		index *navNode
+	)
+	for _, e := range entries {
+		name := e.Name()
+		rel := path.Join(dir, name)
+		if strings.HasPrefix(name, ".") || (strings.HasPrefix(name, "_") && name != "_index.md") {
+			continue
+		}
+
+		if e.IsDir() {
+			children, dirIndex := dirNodes(root, rel)
+			if len(children) == 0 && dirIndex == nil {
+				continue
+			}
+			node := navNode{name: name, children: children}
+			node.entry.Title, node.order, node.ordered = category(root, rel)
+			if dirIndex != nil {
+				node.entry.Path = dirIndex.entry.Path
+				if !node.ordered {
+					node.order, node.ordered = dirIndex.order, dirIndex.ordered
+				}
+			} else if node.entry.Title == "" {
+				node.entry.Title = TitleFromPath(numberPrefix.ReplaceAllString(name, ""))
+			}
+			if !node.ordered {
+				node.order, node.ordered = prefixOrder(name)
+			}
+			nodes = append(nodes, node)
+			continue
+		}
+
+		if !IsDocFile(name) {
+			continue
+		}
+		node := navNode{name: name, entry: db.NavEntry{Path: rel}}
+		if content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel))); err == nil {
+			fm, _, _ := SplitFrontMatter(shared.NormalizeLineEndings(string(content)))
+			if fm.Draft {
+				continue
+			}
+			switch {
+			case fm.SidebarPosition != 0:
+				node.order, node.ordered = fm.SidebarPosition, true
+			case fm.Weight != 0:
+				node.order, node.ordered = fm.Weight, true
+			}
+		}
+		if !node.ordered {
+			node.order, node.ordered = prefixOrder(name)
+		}
+		switch strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))) {
+		case "index", "_index", "readme":
+			if index == nil {
+				index = &node
+				continue
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+	slices.SortStableFunc(nodes, func(a, b navNode) int {
+		switch {
+		case a.ordered && b.ordered && a.order != b.order:
+			if a.order < b.order {
+				return -1
+			}
+			return 1
+		case a.ordered != b.ordered:
+			if a.ordered {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return nodes, index
+}
+
+// category reads a Docusaurus _category_.json or _category_.yml in dir.
+func category(root, dir string) (label string, position float64, ok bool) {
+	file, found := firstFile(filepath.Join(root, filepath.FromSlash(dir)), "_category_.json", "_category_.yml", "_category_.yaml")
+	if !found {
+		return "", 0, false
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", 0, false
+	}
+	var c struct {
+		Label    string   `json:"label"`
+		Position *float64 `json:"position"`
+	}
+	if err := yaml.Unmarshal(content, &c); err != nil {
+		return "", 0, false
+	}
+	if c.Position == nil {
+		return c.Label, 0, false
+	}
+	return c.Label, *c.Position, true
+}
+
+func prefixOrder(name string) (float64, bool) {
+	m := numberPrefix.FindStringSubmatch(name)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	return n, err == nil
+}
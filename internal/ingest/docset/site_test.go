@@ -0,0 +1,242 @@
+package docset
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetectSite(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		generator string
+		title     string
+		nav       []db.NavEntry
+	}{
+		{
+			name: "mdbook",
+			files: map[string]string{
+				"book.toml": "[book]\ntitle = \"The Guide\"\nsrc = \"book\"\n",
+				"book/SUMMARY.md": "# Summary\n\n[Introduction](README.md)\n\n# User guide\n\n- [Installation](guide/install.md)\n" +
+					"    - [From source](guide/source%20build.md)\n- [Configuration](guide/config.md#options)\n- [Roadmap]()\n\n---\n\n[Contributors](misc/contributors.md)\n",
+				"book/README.md":               "# Introduction\n",
+				"book/guide/install.md":        "# Install\n",
+				"book/guide/source build.md":   "# Building\n",
+				"book/guide/config.md":         "# Config\n",
+				"book/misc/contributors.md":    "# Contributors\n",
+				"book/misc/unlisted.md":        "# Unlisted\n",
+				"docs/not-part-of-the-book.md": "# Elsewhere\n",
+			},
+			generator: "mdbook",
+			title:     "The Guide",
+			nav: []db.NavEntry{
+				{Title: "Introduction", Path: "book/README.md"},
+				{Title: "User guide"},
+				{Title: "Installation", Path: "book/guide/install.md", Depth: 1},
+				{Title: "From source", Path: "book/guide/source build.md", Depth: 2},
+				{Title: "Configuration", Path: "book/guide/config.md", Depth: 1},
+				{Title: "Roadmap", Depth: 1},
+				{Title: "Contributors", Path: "book/misc/contributors.md"},
+			},
+		},
+		{
+			name: "mkdocs",
+			files: map[string]string{
+				"mkdocs.yml": "site_name: Widgets\ntheme:\n  name: material\nmarkdown_extensions:\n  - pymdownx.emoji:\n      emoji_generator: !!python/name:material.extensions.emoji.to_svg\n" +
+					"nav:\n- Home: index.md\n- Usage:\n  - usage/basics.md\n  - Advanced: usage/advanced.md\n  - Issues: https://example.com/issues\n",
+				"docs/index.md":          "# Welcome to Widgets\n",
+				"docs/usage/basics.md":   "---\ntitle: The basics\n---\nText.\n",
+				"docs/usage/advanced.md": "# Advanced usage\n",
+			},
+			generator: "mkdocs",
+			title:     "Widgets",
+			nav: []db.NavEntry{
+				{Title: "Home", Path: "docs/index.md"},
+				{Title: "Usage"},
+				{Title: "The basics", Path: "docs/usage/basics.md", Depth: 1},
+				{Title: "Advanced", Path: "docs/usage/advanced.md", Depth: 1},
+			},
+		},
+		{
+			name: "docusaurus",
+			files: map[string]string{
+				"docusaurus.config.ts": "const config: Config = {\n  title: 'Gizmo Docs',\n  url: 'https://gizmo.dev',\n};\n",
+				"sidebars.ts": "import type {SidebarsConfig} from '@docusaurus/plugin-content-docs';\n\n" +
+					"// The sidebar of the docs plugin.\nconst sidebars: SidebarsConfig = {\n  docs: [\n    'intro',\n" +
+					"    {\n      type: 'category',\n      label: 'Guides',\n      link: {type: 'doc', id: 'guides/overview'},\n" +
+					"      items: ['guides/setup', {type: 'doc', id: 'guides/deploy', label: \"Deploying\"},],\n    },\n" +
+					"    {type: 'link', label: 'GitHub', href: 'https://github.com/gizmo/gizmo'},\n" +
+					"    {type: 'category', label: 'Reference', items: [{type: 'autogenerated', dirName: 'reference'}]},\n  ],\n};\n\nexport default sidebars;\n",
+				"docs/intro.md":                        "---\nsidebar_label: Start here\n---\n# Introduction\n",
+				"docs/guides/overview.md":              "# Guides\n",
+				"docs/guides/01-setup.mdx":             "# Setup\n",
+				"docs/guides/deploy.md":                "---\nid: deploy\n---\n# Deploy to production\n",
+				"docs/reference/_category_.json":       "{\"label\": \"Ignored at the top\"}",
+				"docs/reference/cli.md":                "---\nsidebar_position: 2\n---\n# CLI\n",
+				"docs/reference/api.md":                "---\nsidebar_position: 1\n---\n# API\n",
+				"docs/reference/config/_category_.yml": "label: Configuration files\nposition: 3\n",
+				"docs/reference/config/server.md":      "# Server\n",
+			},
+			generator: "docusaurus",
+			title:     "Gizmo Docs",
+			nav: []db.NavEntry{
+				{Title: "Start here", Path: "docs/intro.md"},
+				{Title: "Guides", Path: "docs/guides/overview.md"},
+				{Title: "Setup", Path: "docs/guides/01-setup.mdx", Depth: 1},
+				{Title: "Deploying", Path: "docs/guides/deploy.md", Depth: 1},
+				{Title: "Reference"},
+				{Title: "API", Path: "docs/reference/api.md", Depth: 1},
+				{Title: "CLI", Path: "docs/reference/cli.md", Depth: 1},
+				{Title: "Configuration files", Depth: 1},
+				{Title: "Server", Path: "docs/reference/config/server.md", Depth: 2},
+			},
+		},
+		{
+			name: "hugo",
+			files: map[string]string{
+				"hugo.toml":                        "baseURL = 'https://example.org/'\ntitle = 'Sprocket'\n",
+				"content/_index.md":                "+++\ntitle = 'Sprocket'\n+++\nWelcome.\n",
+				"content/docs/_index.md":           "---\ntitle: Documentation\nweight: 1\n---\n",
+				"content/docs/install.md":          "+++\ntitle = 'Install'\nweight = 20\n+++\n",
+				"content/docs/quickstart/index.md": "+++\ntitle = 'Quick start'\nlinkTitle = 'Quickstart'\nweight = 10\n+++\n",
+				"content/docs/faq.md":              "---\ntitle: FAQ\n---\n",
+				"content/docs/wip.md":              "---\ntitle: Upcoming\ndraft: true\n---\n",
+				"content/blog/_index.md":           "---\ntitle: Blog\nweight: 2\n---\n",
+				"content/blog/launch.md":           "---\ntitle: Launch\n---\n",
+			},
+			generator: "hugo",
+			title:     "Sprocket",
+			nav: []db.NavEntry{
+				{Title: "Sprocket", Path: "content/_index.md"},
+				{Title: "Documentation", Path: "content/docs/_index.md"},
+				{Title: "Quickstart", Path: "content/docs/quickstart/index.md", Depth: 1},
+				{Title: "Install", Path: "content/docs/install.md", Depth: 1},
+				{Title: "FAQ", Path: "content/docs/faq.md", Depth: 1},
+				{Title: "Blog", Path: "content/blog/_index.md"},
+				{Title: "Launch", Path: "content/blog/launch.md", Depth: 1},
+			},
+		},
+		{
+			name: "sphinx",
+			files: map[string]string{
+				"docs/conf.py":           "# Configuration file for the Sphinx documentation builder.\nproject = 'Toolkit'\nroot_doc = 'contents'\nextensions = ['myst_parser']\n",
+				"docs/contents.rst":      "Toolkit\n=======\n\n.. toctree::\n   :maxdepth: 2\n\n   self\n   intro\n   Usage guide <guide/index>\n\n.. toctree::\n   :caption: API\n   :glob:\n\n   api/*\n   https://example.com/changelog\n",
+				"docs/intro.rst":         "Introduction\n============\n",
+				"docs/guide/index.md":    "# Guide\n\n```{toctree}\n:hidden:\n\nsetup\n/intro\n```\n",
+				"docs/guide/setup.rst":   "Setting up\n----------\n",
+				"docs/api/tool.rst":      "tool\n====\n\n.. automodule:: tool\n",
+				"docs/api/cli.rst":       "Command line\n============\n",
+				"docs/unlisted.rst":      "Unlisted\n========\n",
+				"docs/_build/index.html": "<html></html>",
+				"docs/api/notes.txt":     "not a source",
+			},
+			generator: "sphinx",
+			title:     "Toolkit",
+			nav: []db.NavEntry{
+				{Title: "Toolkit", Path: "docs/contents.rst"},
+				{Title: "Introduction", Path: "docs/intro.rst"},
+				{Title: "Usage guide", Path: "docs/guide/index.md"},
+				{Title: "Setting up", Path: "docs/guide/setup.rst", Depth: 1},
+				{Title: "API"},
+				{Title: "Command line", Path: "docs/api/cli.rst", Depth: 1},
+				{Title: "tool", Path: "docs/api/tool.rst", Depth: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site, err := DetectSite(writeTree(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if site == nil {
+				t.Fatal("no site detected")
+			}
+			if site.Generator != tt.generator || site.Title != tt.title {
+				t.Errorf("site = %s %q, want %s %q", site.Generator, site.Title, tt.generator, tt.title)
+			}
+			if !slices.Equal(site.Nav, tt.nav) {
+				t.Errorf("nav =\n%v\nwant\n%v", site.Nav, tt.nav)
+			}
+		})
+	}
+
+	if site, err := DetectSite(writeTree(t, map[string]string{"README.md": "# Plain\n"})); site != nil || err != nil {
+		t.Errorf("DetectSite(plain directory) = %+v, %v", site, err)
+	}
+
+	for p, want := range map[string]bool{"mkdocs.yml": true, "docs/conf.py": true, "docs/source/conf.py": true, "src/conf.py": false, "docs/mkdocs.yml": false} {
+		if IsSiteConfig(p) != want {
+			t.Errorf("IsSiteConfig(%q) = %v, want %v", p, !want, want)
+		}
+	}
+}
+
+func TestIngestDirSite(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	dir := writeTree(t, map[string]string{
+		"mkdocs.yml":              "site_name: Widgets\nnav:\n  - Start: index.md\n  - Internals:\n    - internal/design.md\n  - Setup: setup.md\n",
+		"docs/index.md":           "Widgets render things.\n",
+		"docs/setup.md":           "# Setting up\n",
+		"docs/internal/design.md": "# Design\n",
+	})
+	filter := NewFilter(nil, []string{"docs/internal/**"})
+	if err := store.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := IngestDir(ctx, tx, dir, "git/example.com/widgets", filter)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sites, err := store.Sites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []db.Site{{Root: "git/example.com/widgets", Generator: "mkdocs", Title: "Widgets"}}; !slices.Equal(sites, want) {
+		t.Errorf("Sites = %+v, want %+v", sites, want)
+	}
+
+	nav, err := store.SiteNav(ctx, "git/example.com/widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []db.NavEntry{
+		{Title: "Start", Path: "git/example.com/widgets/docs/index.md"},
+		{Title: "Setup", Path: "git/example.com/widgets/docs/setup.md"},
+	}
+	if !slices.Equal(nav, want) {
+		t.Errorf("SiteNav = %+v, want %+v", nav, want)
+	}
+
+	if results, _ := store.Search(ctx, "render things", 10); len(results) != 1 || results[0].Name != "Start" {
+		t.Errorf("a page without a title did not take its nav title: %+v", results)
+	}
+	if site, ok, err := store.SiteOf(ctx, "git/example.com/widgets/docs/setup.md"); err != nil || !ok || site.Title != "Widgets" {
+		t.Errorf("SiteOf = %+v, %v, %v", site, ok, err)
+	}
+}
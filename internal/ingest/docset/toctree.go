@@ -0,0 +1,194 @@
+package docset
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// sphinxDirs are the directories, relative to the ingested root, searched
+// for a Sphinx conf.py: the root itself and the layouts sphinx-quickstart
+// creates.
+var sphinxDirs = []string{".", "docs", "doc", "docs/source", "doc/source"}
+
+var (
+	sphinxSetting = regexp.MustCompile(`(?m)^(project|root_doc|master_doc)\s*=\s*u?["']([^"']*)["']`)
+	mystToctree   = regexp.MustCompile("^(```+|:::+)\\s*\\{toctree\\}")
+)
+
+// sphinxSuffixes are the source suffixes a document name is tried with:
+// reStructuredText, and Markdown through MyST.
+var sphinxSuffixes = []string{".rst", ".md"}
+
+// IsSiteConfig reports whether a path relative to a repository root is a
+// configuration file DetectSite reads: one in the root, or a Sphinx
+// conf.py in the directories it is looked for in.
+func IsSiteConfig(p string) bool {
+	p = path.Clean(filepath.ToSlash(p))
+	if !IsSiteFile(p) {
+		return false
+	}
+	dir := path.Dir(p)
+	return dir == "." || path.Base(p) == "conf.py" && slices.Contains(sphinxDirs, dir)
+}
+
+// detectSphinx reads the toctrees of a Sphinx project, starting from the
+// root document named in conf.py (index by default). Each document's
+// toctree entries nest below it, except the root document's, which follow
+// it; captioned toctrees become sections.
+func detectSphinx(root string) (*Site, error) {
+	for _, dir := range sphinxDirs {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), "conf.py"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		site := &Site{Generator: "sphinx"}
+		rootDoc := "index"
+		for _, m := range sphinxSetting.FindAllStringSubmatch(string(content), -1) {
+			if m[1] == "project" {
+				site.Title = m[2]
+			} else if m[2] != "" {
+				rootDoc = m[2]
+			}
+		}
+
+		s := sphinx{root: root, srcDir: dir, seen: map[string]bool{rootDoc: true}}
+		file, ok := s.file(rootDoc)
+		if !ok {
+			return nil, errors.New(path.Join(dir, rootDoc) + ": root document not found")
+		}
+		site.Nav = append(site.Nav, db.NavEntry{Path: file})
+		s.toctrees(rootDoc, file, 0, &site.Nav)
+		return site, nil
+	}
+	return nil, nil
+}
+
+type sphinx struct {
+	root, srcDir string
+	seen         map[string]bool // documents already listed
+}
+
+// file finds the source of a document name, relative to the ingested root.
+func (s sphinx) file(docname string) (string, bool) {
+	for _, suffix := range sphinxSuffixes {
+		p := path.Join(s.srcDir, docname+suffix)
+		if exists(filepath.Join(s.root, filepath.FromSlash(p))) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// toctrees lists the entries of the toctrees in a document at depth, each
+// followed by the entries of its own toctrees.
+func (s sphinx) toctrees(docname, file string, depth int, nav *[]db.NavEntry) {
+	content, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(file)))
+	if err != nil {
+		return
+	}
+	for _, tree := range parseToctrees(shared.NormalizeLineEndings(string(content))) {
+		entryDepth := depth
+		if caption := tree.options["caption"]; caption != "" {
+			*nav = append(*nav, db.NavEntry{Title: caption, Depth: depth})
+			entryDepth++
+		}
+		for _, entry := range tree.entries {
+			title, target, ok := titledTarget(entry)
+			if !ok {
+				target = entry
+			}
+			if target == "self" || strings.Contains(target, "://") {
+				continue
+			}
+
+			var names []string
+			if _, glob := tree.options["glob"]; glob && strings.ContainsAny(target, "*?[") {
+				names = s.glob(s.docname(docname, target))
+			} else {
+				names = []string{s.docname(docname, target)}
+			}
+			for _, name := range names {
+				if s.seen[name] {
+					continue
+				}
+				f, ok := s.file(name)
+				if !ok {
+					continue
+				}
+				s.seen[name] = true
+				*nav = append(*nav, db.NavEntry{Title: title, Path: f, Depth: entryDepth})
+				s.toctrees(name, f, entryDepth+1, nav)
+			}
+		}
+	}
+}
+
+// docname resolves a toctree entry: relative to the document holding the
+// toctree, or to the source directory when it starts with a slash.
+func (s sphinx) docname(from, target string) string {
+	target = strings.TrimSuffix(strings.TrimSuffix(target, ".rst"), ".md")
+	if strings.HasPrefix(target, "/") {
+		return path.Clean(strings.TrimPrefix(target, "/"))
+	}
+	return path.Join(path.Dir(from), target)
+}
+
+// glob lists the document names matching pattern, sorted as Sphinx does.
+func (s sphinx) glob(pattern string) []string {
+	var names []string
+	for _, suffix := range sphinxSuffixes {
+		matches, _ := fs.Glob(os.DirFS(filepath.Join(s.root, filepath.FromSlash(s.srcDir))), pattern+suffix)
+		for _, m := range matches {
+			names = append(names, strings.TrimSuffix(m, suffix))
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+type toctree struct {
+	options map[string]string
+	entries []string
+}
+
+// parseToctrees finds the toctree directives of a reStructuredText
+// document, or the {toctree} fences of a MyST Markdown one.
+func parseToctrees(content string) []toctree {
+	lines := strings.Split(content, "\n")
+	var trees []toctree
+	for i := 0; i < len(lines); i++ {
+		var block []string
+		if m := rstDirective.FindStringSubmatch(lines[i]); m != nil && m[2] == "toctree" {
+			block, _ = indentedBlock(lines, i+1, len(m[1]))
+		} else if m := mystToctree.FindStringSubmatch(strings.TrimSpace(lines[i])); m != nil {
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				block = append(block, strings.TrimSpace(lines[i]))
+			}
+		} else {
+			continue
+		}
+
+		options, body := splitOptions(block)
+		tree := toctree{options: options}
+		for _, line := range body {
+			if line = strings.TrimSpace(line); line != "" {
+				tree.entries = append(tree.entries, line)
+			}
+		}
+		trees = append(trees, tree)
+	}
+	return trees
+}
@@ -0,0 +1,304 @@
+// Package git ingests the prose documentation of any git repository, hosted
+// on GitLab, Codeberg, Gitea, sourcehut or a self-hosted server, by cloning
+// it rather than going through a forge API.
+package git
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+)
+
+type Options struct {
+	// URL is anything git clone accepts: https://, ssh://, git@host:path,
+	// file:// or a local path. Authentication uses the user's git
+	// credential helpers and SSH keys.
+	URL string
+	// Ref is a branch, tag or commit; empty uses the remote's default
+	// branch.
+	Ref string
+	// Subdir limits ingestion to a directory of the repository.
+	Subdir string
+	// Include and Exclude select documentation files by path glob relative
+	// to Subdir (see docset.Filter); a nil Exclude uses
+	// docset.DefaultExclude.
+	Include []string
+	Exclude []string
+	DB      *db.Store
+	Cache   *cache.FilesystemCache
+}
+
+var (
+	fullSHA  = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	shortSHA = regexp.MustCompile(`^[0-9a-fA-F]{7,39}$`)
+)
+
+// IngestRepository clones a repository at a ref and stores its Markdown, MDX
+// and reStructuredText files under git/<host>/<path>.
+func IngestRepository(ctx context.Context, opts Options) error {
+	if opts.URL == "" {
+		return errors.New("repository url is required")
+	}
+	if opts.DB == nil {
+		return errors.New("db store is required")
+	}
+
+	repo, err := RepoName(opts.URL)
+	if err != nil {
+		return err
+	}
+	subdir := path.Clean("/" + filepath.ToSlash(opts.Subdir))[1:]
+
+	log.Info("git repository ingest starting", "repo", repo, "ref", opts.Ref)
+
+	dir, _, cleanup, err := Checkout(ctx, opts.URL, opts.Ref, opts.Cache)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	root := filepath.Join(dir, filepath.FromSlash(subdir))
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("subdirectory %q not found in repository", opts.Subdir)
+	}
+
+	prefix := "git/" + repo
+	if subdir != "" {
+		prefix += "/" + subdir
+	}
+
+	var n int
+	if err := opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		n, err = docset.IngestDir(ctx, tx, root, prefix, docset.NewFilter(opts.Include, opts.Exclude))
+		if err == nil && n == 0 {
+			err = fmt.Errorf("no documentation files found in repository")
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+
+	log.Info("git repository ingest complete", "repo", repo, "documents", n)
+	return nil
+}
+
+// Checkout returns a working tree of a repository at ref, or at the remote's
+// default branch when ref is empty, along with its RepoName. rawURL is
+// anything IngestRepository accepts. cleanup removes a checkout that is not
+// kept in the cache.
+func Checkout(ctx context.Context, rawURL, ref string, c *cache.FilesystemCache) (dir, repo string, cleanup func(), err error) {
+	repo, err = RepoName(rawURL)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	repoURL := rawURL
+	if !strings.Contains(repoURL, "://") && !isSCPLike(repoURL) {
+		// Clones run in other directories, so local paths must be absolute.
+		if repoURL, err = filepath.Abs(repoURL); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	commit, err := resolveRef(ctx, repoURL, ref)
+	if err != nil {
+		return "", "", nil, err
+	}
+	dir, cleanup, err = checkout(ctx, repoURL, repo, ref, commit, c)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return dir, repo, cleanup, nil
+}
+
+// OpenTree returns the directory to read a source from: a local directory
+// in place, or a checkout of a repository as Checkout makes it. A local
+// directory is checked out too when a ref is given. name is the base name
+// of the directory or repository.
+func OpenTree(ctx context.Context, source, ref string, c *cache.FilesystemCache) (dir, name string, cleanup func(), err error) {
+	if info, statErr := os.Stat(source); statErr == nil && info.IsDir() && ref == "" {
+		abs, err := filepath.Abs(source)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return abs, filepath.Base(abs), func() {}, nil
+	}
+
+	dir, repo, cleanup, err := Checkout(ctx, source, ref, c)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return dir, path.Base(repo), cleanup, nil
+}
+
+// RepoName derives the host and path of a clone URL, without a .git suffix
+// or port: https://gitlab.com/group/sub/project.git becomes
+// gitlab.com/group/sub/project and git@git.sr.ht:~user/repo becomes
+// git.sr.ht/~user/repo. Local repositories use the host "local" and their
+// directory name.
+func RepoName(rawURL string) (string, error) {
+	var host, repoPath string
+	switch {
+	case strings.Contains(rawURL, "://"):
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid repository url: %w", err)
+		}
+		if u.Scheme == "file" {
+			host, repoPath = "local", path.Base(strings.TrimSuffix(u.Path, "/"))
+		} else {
+			host, repoPath = u.Hostname(), u.Path
+		}
+	case isSCPLike(rawURL):
+		userHost, p, _ := strings.Cut(rawURL, ":")
+		_, host, _ = strings.Cut(userHost, "@")
+		if host == "" {
+			host = userHost
+		}
+		repoPath = p
+	default:
+		abs, err := filepath.Abs(rawURL)
+		if err != nil {
+			return "", err
+		}
+		host, repoPath = "local", filepath.Base(abs)
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	name := strings.ToLower(host) + "/" + repoPath
+	// The name becomes a cache directory and a document path, so it must
+	// not climb out of either.
+	for _, seg := range strings.Split(name, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("cannot derive repository name from %q", rawURL)
+		}
+	}
+	return name, nil
+}
+
+// isSCPLike reports whether a clone URL uses the [user@]host:path form,
+// which git distinguishes from a local path by a colon before any slash.
+func isSCPLike(s string) bool {
+	colon := strings.Index(s, ":")
+	if colon <= 0 {
+		return false
+	}
+	slash := strings.Index(s, "/")
+	return slash < 0 || colon < slash
+}
+
+// resolveRef finds the commit a ref points to with git ls-remote. Full SHAs
+// are used as is. Refs the remote does not advertise are returned unchanged
+// when they look like an abbreviated SHA, which the clone then resolves.
+func resolveRef(ctx context.Context, repoURL, ref string) (string, error) {
+	if fullSHA.MatchString(ref) {
+		return ref, nil
+	}
+
+	want := []string{"HEAD"}
+	if ref != "" {
+		want = []string{"refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref, ref}
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "ls-remote", "--", repoURL).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git ls-remote %s: %s", repoURL, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git ls-remote %s: %w", repoURL, err)
+	}
+
+	refs := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if sha, name, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+			refs[name] = sha
+		}
+	}
+	for _, name := range want {
+		if sha, ok := refs[name]; ok {
+			return sha, nil
+		}
+	}
+
+	if shortSHA.MatchString(ref) {
+		return strings.ToLower(ref), nil
+	}
+	if ref == "" {
+		return "", fmt.Errorf("repository %s has no default branch", repoURL)
+	}
+	return "", fmt.Errorf("ref %q not found in %s", ref, repoURL)
+}
+
+// checkout returns a working tree of the repository at commit. With a cache,
+// checkouts are kept per repository and ref and reused while the ref still
+// points at the cached commit; otherwise the tree is cloned into a temporary
+// directory that cleanup removes.
+func checkout(ctx context.Context, repoURL, repo, ref, commit string, c *cache.FilesystemCache) (string, func(), error) {
+	noop := func() {}
+
+	var gitCache *cache.GitCache
+	var cachedDir, cacheKey string
+	if c != nil {
+		cacheKey = cache.GitRepoKey(repo, ref)
+		cachedDir = filepath.Join(c.Dir(), filepath.FromSlash(cacheKey))
+		if rel, err := filepath.Rel(c.Dir(), cachedDir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", nil, fmt.Errorf("repository %s would be cached outside %s", repo, c.Dir())
+		}
+		if gc, err := cache.NewGitCache(c.Dir()); err == nil {
+			gitCache = gc
+			if cached, ok := gc.GetCommit(cacheKey); ok && strings.HasPrefix(cached, commit) {
+				if _, err := os.Stat(cachedDir); err == nil {
+					log.Info("using cached repository", "repo", repo, "commit", cached)
+					return cachedDir, noop, nil
+				}
+			}
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "documango-git-clone-")
+	if err != nil {
+		return "", nil, err
+	}
+	dest := filepath.Join(tmpDir, "repo")
+	if err := cache.ShallowClone(ctx, repoURL, commit, dest); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", nil, fmt.Errorf("clone %s: %w", repoURL, err)
+	}
+	cleanup := func() { _ = os.RemoveAll(tmpDir) }
+
+	if gitCache == nil {
+		return dest, cleanup, nil
+	}
+
+	if sha, err := cache.GetRepoCommit(dest); err == nil {
+		commit = sha
+	}
+	_ = os.RemoveAll(cachedDir)
+	if err := os.MkdirAll(filepath.Dir(cachedDir), 0o755); err == nil {
+		if err := os.Rename(dest, cachedDir); err == nil {
+			cleanup()
+			if err := gitCache.PutCommit(cacheKey, repoURL, commit, 0); err != nil {
+				log.Warn("failed to record cached commit", "repo", repo, "err", err)
+			}
+			log.Info("cached repository", "repo", repo, "commit", commit, "path", cacheKey)
+			return cachedDir, noop, nil
+		}
+	}
+	log.Warn("failed to cache repository, using temp dir", "repo", repo)
+	return dest, cleanup, nil
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func TestRepoName(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://gitlab.com/group/subgroup/project.git", "gitlab.com/group/subgroup/project"},
+		{"https://codeberg.org/forgejo/docs", "codeberg.org/forgejo/docs"},
+		{"https://Gitea.Example.com:3000/team/handbook/", "gitea.example.com/team/handbook"},
+		{"ssh://git@gitea.example.com:2222/team/handbook.git", "gitea.example.com/team/handbook"},
+		{"git@gitlab.com:group/project.git", "gitlab.com/group/project"},
+		{"git@git.sr.ht:~sircmpwn/scdoc", "git.sr.ht/~sircmpwn/scdoc"},
+		{"https://git.sr.ht/~sircmpwn/scdoc", "git.sr.ht/~sircmpwn/scdoc"},
+		{"file:///srv/git/handbook.git", "local/handbook"},
+		{"/srv/git/handbook", "local/handbook"},
+	}
+	for _, tt := range tests {
+		got, err := RepoName(tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("RepoName(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+	for _, url := range []string{"https://example.com/", "https://example.com/../../../x", "host:../../x", "..:x", "https://example.com/a//b"} {
+		if got, err := RepoName(url); err == nil {
+			t.Errorf("RepoName(%q) = %q, want an error", url, got)
+		}
+	}
+}
+
+// gitRepo creates a repository with a v1 tag on the first commit and a
+// second commit on main that adds more documentation.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "handbook")
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	write("README.md", "# Handbook\n\nHow the team works.\n")
+	write("docs/onboarding.md", "# Onboarding\n\n## Laptop setup\n\nRequest a laptop from IT.\n")
+	run("add", "-A")
+	run("commit", "--quiet", "-m", "first")
+	run("tag", "-a", "v1", "-m", "v1")
+	write("docs/deploys.rst", "Deploys\n=======\n\nShip it with ``make deploy``.\n")
+	run("add", "-A")
+	run("commit", "--quiet", "-m", "second")
+	return dir
+}
+
+func TestIngestRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	repoDir := gitRepo(t)
+
+	tests := []struct {
+		name, ref, subdir string
+		want, absent      []string
+	}{
+		{"default branch", "", "", []string{"git/local/handbook/README.md", "git/local/handbook/docs/deploys.rst"}, nil},
+		{"tag", "v1", "", []string{"git/local/handbook/docs/onboarding.md"}, []string{"git/local/handbook/docs/deploys.rst"}},
+		{"subdir", "main", "docs/", []string{"git/local/handbook/docs/deploys.rst"}, []string{"git/local/handbook/README.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := dbtest.Open(t)
+			c, err := cache.New(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for range 2 {
+				if err := IngestRepository(ctx, Options{URL: "file://" + repoDir, Ref: tt.ref, Subdir: tt.subdir, DB: store, Cache: c}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, path := range tt.want {
+				if _, err := store.ReadDocument(ctx, path); err != nil {
+					t.Errorf("document %s: %v", path, err)
+				}
+			}
+			for _, path := range tt.absent {
+				if _, err := store.ReadDocument(ctx, path); err == nil {
+					t.Errorf("document %s was ingested", path)
+				}
+			}
+		})
+	}
+
+	store := dbtest.Open(t)
+	if err := IngestRepository(ctx, Options{URL: repoDir, DB: store}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := store.Search(ctx, "laptop", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "Laptop setup" {
+		t.Errorf("Search(laptop) = %+v, want the Laptop setup section", results)
+	}
+
+	err = IngestRepository(ctx, Options{URL: repoDir, Ref: "no-such-branch", DB: store})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing ref: err = %v", err)
+	}
+}
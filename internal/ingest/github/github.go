@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,26 +12,30 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/charmbracelet/log"
-	"github.com/goccy/go-yaml"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 
 	"github.com/stormlightlabs/documango/internal/cache"
 	"github.com/stormlightlabs/documango/internal/db"
-	"github.com/stormlightlabs/documango/internal/shared"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
 )
 
 type Options struct {
 	Owner  string
 	Repo   string
 	Branch string
-	DB     *db.Store
-	Cache  *cache.FilesystemCache
+	// Token authenticates API, raw content and clone requests. Anonymous
+	// API requests are limited to 60 per hour.
+	Token string
+	// Include and Exclude select documentation files by path glob (see
+	// docset.Filter); a nil Exclude uses docset.DefaultExclude.
+	Include []string
+	Exclude []string
+	DB      *db.Store
+	Cache   *cache.FilesystemCache
 }
 
 type repoMetadata struct {
@@ -51,14 +56,9 @@ type treeEntry struct {
 	Size int    `json:"size,omitempty"`
 }
 
-type frontMatter struct {
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Tags        []string `json:"tags"`
-}
-
 type httpClient struct {
 	client    *http.Client
+	token     string
 	remaining int
 	resetAt   time.Time
 }
@@ -85,8 +85,14 @@ func IngestRepository(ctx context.Context, opts Options) error {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		token: opts.Token,
+	}
+	if opts.Token == "" {
+		log.Info("no GitHub token set, anonymous API requests are limited to 60 per hour (set GITHUB_TOKEN or github.token)")
 	}
 
+	filter := docset.NewFilter(opts.Include, opts.Exclude)
+
 	metadata, err := fetchRepoMetadata(ctx, httpClient, opts.Owner, opts.Repo)
 	if err != nil {
 		return err
@@ -102,53 +108,55 @@ func IngestRepository(ctx context.Context, opts Options) error {
 		return err
 	}
 
-	var markdownFiles []string
+	var docFiles []string
 
-	if truncated {
-		log.Info("tree truncated, falling back to clone", "repo", fmt.Sprintf("%s/%s", opts.Owner, opts.Repo))
+	hasSite := slices.ContainsFunc(tree, func(entry treeEntry) bool {
+		return docset.IsSiteConfig(entry.Path)
+	})
 
-		tmpDir, cleanup, err := cloneRepository(ctx, opts.Owner, opts.Repo, branch, opts.Cache)
-		if err != nil {
-			return err
+	if truncated || hasSite {
+		if truncated {
+			log.Info("tree truncated, falling back to clone", "repo", fmt.Sprintf("%s/%s", opts.Owner, opts.Repo))
+		} else {
+			log.Info("site generator configuration found, cloning to read navigation", "repo", fmt.Sprintf("%s/%s", opts.Owner, opts.Repo))
 		}
-		defer cleanup()
 
-		markdownFiles, err = walkMarkdownFiles(tmpDir)
+		tmpDir, cleanup, err := cloneRepository(ctx, opts.Owner, opts.Repo, branch, opts.Token, opts.Cache)
 		if err != nil {
 			return err
 		}
-
-		if len(markdownFiles) == 0 {
-			return fmt.Errorf("no markdown files found in repository")
-		}
+		defer cleanup()
 
 		return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
-			return processMarkdownFiles(ctx, tx, tmpDir, markdownFiles, fmt.Sprintf("%s/%s", opts.Owner, opts.Repo))
+			n, err := docset.IngestDir(ctx, tx, tmpDir, fmt.Sprintf("github/%s/%s", opts.Owner, opts.Repo), filter)
+			if err == nil && n == 0 {
+				err = fmt.Errorf("no documentation files found in repository")
+			}
+			return err
 		})
 	}
 
 	for _, entry := range tree {
-		if entry.Type == "blob" && isMarkdownFile(entry.Path) {
-			markdownFiles = append(markdownFiles, entry.Path)
+		if entry.Type == "blob" && docset.IsDocFile(entry.Path) && filter.Match(entry.Path) {
+			docFiles = append(docFiles, entry.Path)
 		}
 	}
 
-	if len(markdownFiles) == 0 {
-		return fmt.Errorf("no markdown files found in repository")
+	if len(docFiles) == 0 {
+		return fmt.Errorf("no documentation files found in repository")
 	}
 
 	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
-		return processMarkdownFromAPI(ctx, tx, httpClient, opts.Owner, opts.Repo, branch, markdownFiles)
+		return processMarkdownFromAPI(ctx, tx, httpClient, opts.Owner, opts.Repo, branch, docFiles)
 	})
 }
 
 func fetchRepoMetadata(ctx context.Context, client *httpClient, owner, repo string) (*repoMetadata, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := client.newRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
 	req.Header.Set("Accept", "application/vnd.github+json")
 
 	var metadata repoMetadata
@@ -161,11 +169,10 @@ func fetchRepoMetadata(ctx context.Context, client *httpClient, owner, repo stri
 
 func fetchTree(ctx context.Context, client *httpClient, owner, repo, ref string) ([]treeEntry, bool, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s?recursive=1", owner, repo, ref)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := client.newRequest(ctx, url)
 	if err != nil {
 		return nil, false, err
 	}
-	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
 	req.Header.Set("Accept", "application/vnd.github+json")
 
 	var tree treeResponse
@@ -178,11 +185,10 @@ func fetchTree(ctx context.Context, client *httpClient, owner, repo, ref string)
 
 func fetchRawContent(ctx context.Context, client *httpClient, owner, repo, ref, path string) (string, error) {
 	url := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", owner, repo, ref, path)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := client.newRequest(ctx, url)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
 
 	resp, err := client.client.Do(req)
 	if err != nil {
@@ -202,6 +208,20 @@ func fetchRawContent(ctx context.Context, client *httpClient, owner, repo, ref,
 	return string(content), nil
 }
 
+// newRequest builds a GET request with the user agent and, when set, the
+// token.
+func (c *httpClient) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
 func (c *httpClient) do(ctx context.Context, req *http.Request, result any) error {
 	var lastErr error
 
@@ -226,6 +246,9 @@ func (c *httpClient) do(ctx context.Context, req *http.Request, result any) erro
 			resp.Body.Close()
 			waitUntil := time.Until(c.resetAt)
 			if waitUntil > 0 {
+				if c.token == "" {
+					log.Warn("anonymous rate limit exceeded, set GITHUB_TOKEN to raise it")
+				}
 				log.Info("rate limit exceeded, waiting", "until", c.resetAt, "wait_seconds", waitUntil.Seconds())
 				select {
 				case <-ctx.Done():
@@ -267,7 +290,7 @@ func (c *httpClient) updateRateLimits(resp *http.Response) {
 	}
 }
 
-func cloneRepository(ctx context.Context, owner, repo, branch string, c *cache.FilesystemCache) (string, func(), error) {
+func cloneRepository(ctx context.Context, owner, repo, branch, token string, c *cache.FilesystemCache) (string, func(), error) {
 	cacheKey := cache.GithubRepoKey(owner, repo, branch)
 	repoURL := fmt.Sprintf("https://github.com/%s/%s.git", owner, repo)
 
@@ -293,6 +316,16 @@ func cloneRepository(ctx context.Context, owner, repo, branch string, c *cache.F
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
+	if token != "" {
+		// Pass the token as a header through the environment so it stays
+		// out of the process list and the clone's remote URL.
+		auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.https://github.com/.extraheader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		)
+	}
 
 	if err := cmd.Run(); err != nil {
 		_ = os.RemoveAll(tmpDir)
@@ -313,36 +346,6 @@ func cloneRepository(ctx context.Context, owner, repo, branch string, c *cache.F
 	return tmpDir, func() { _ = os.RemoveAll(tmpDir) }, nil
 }
 
-func walkMarkdownFiles(dir string) ([]string, error) {
-	var files []string
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-
-		relPath, err := filepath.Rel(dir, path)
-		if err != nil {
-			return err
-		}
-
-		if isMarkdownFile(relPath) {
-			files = append(files, relPath)
-		}
-
-		return nil
-	})
-
-	return files, err
-}
-
-func isMarkdownFile(path string) bool {
-	return strings.HasSuffix(strings.ToLower(path), ".md") || strings.HasSuffix(strings.ToLower(path), ".markdown")
-}
-
 func processMarkdownFromAPI(ctx context.Context, tx *sql.Tx, client *httpClient, owner, repo, branch string, paths []string) error {
 	repoPrefix := fmt.Sprintf("github/%s/%s", owner, repo)
 
@@ -353,27 +356,12 @@ func processMarkdownFromAPI(ctx context.Context, tx *sql.Tx, client *httpClient,
 			continue
 		}
 
-		if err := processMarkdownContent(ctx, tx, content, repoPrefix, path); err != nil {
-			log.Warn("failed to process markdown", "path", path, "err", err)
-			continue
-		}
-	}
-
-	return nil
-}
-
-func processMarkdownFiles(ctx context.Context, tx *sql.Tx, rootDir string, paths []string, repoName string) error {
-	repoPrefix := fmt.Sprintf("github/%s", repoName)
-
-	for _, path := range paths {
-		fullPath := filepath.Join(rootDir, path)
-		content, err := os.ReadFile(fullPath)
-		if err != nil {
-			log.Warn("failed to read file", "path", path, "err", err)
-			continue
-		}
-
-		if err := processMarkdownContent(ctx, tx, string(content), repoPrefix, path); err != nil {
+		content = docset.ExpandIncludes(path, content, func(p string) ([]byte, error) {
+			include, err := fetchRawContent(ctx, client, owner, repo, branch, p)
+			return []byte(include), err
+		})
+		title, markdown := docset.Convert(path, content)
+		if _, err := docset.WriteDocument(ctx, tx, repoPrefix+"/"+path, title, markdown); err != nil {
 			log.Warn("failed to process markdown", "path", path, "err", err)
 			continue
 		}
@@ -381,76 +369,3 @@ func processMarkdownFiles(ctx context.Context, tx *sql.Tx, rootDir string, paths
 
 	return nil
 }
-
-func processMarkdownContent(ctx context.Context, tx *sql.Tx, content, repoPrefix, docPath string) error {
-	title, contentWithoutFrontMatter := extractTitleAndContent(content)
-
-	docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
-		Path:   repoPrefix + "/" + docPath,
-		Format: "markdown",
-		Body:   shared.Compress(contentWithoutFrontMatter),
-		Hash:   db.HashBytes([]byte(contentWithoutFrontMatter)),
-	})
-	if err != nil {
-		return err
-	}
-
-	if title == "" {
-		title = titleFromPath(docPath)
-	}
-
-	if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
-		Name:  title,
-		Type:  "Document",
-		Body:  title + " " + shared.FirstLine(contentWithoutFrontMatter),
-		DocID: docID,
-	}); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func extractTitleAndContent(content string) (string, string) {
-	content = shared.NormalizeLineEndings(content)
-
-	lines := strings.Split(content, "\n")
-
-	if len(lines) >= 3 && strings.TrimSpace(lines[0]) == "---" {
-		endIdx := -1
-		for i := 1; i < len(lines); i++ {
-			if strings.TrimSpace(lines[i]) == "---" {
-				endIdx = i
-				break
-			}
-		}
-
-		if endIdx > 0 {
-			frontMatterText := strings.Join(lines[1:endIdx], "\n")
-			var fm frontMatter
-			if err := yaml.Unmarshal([]byte(frontMatterText), &fm); err == nil && fm.Title != "" {
-				remaining := strings.Join(lines[endIdx+1:], "\n")
-				return fm.Title, strings.TrimSpace(remaining)
-			}
-		}
-	}
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "# ") {
-			title := strings.TrimPrefix(trimmed, "# ")
-			return title, content
-		}
-	}
-
-	return "", content
-}
-
-func titleFromPath(path string) string {
-	base := filepath.Base(path)
-	title := strings.TrimSuffix(base, filepath.Ext(base))
-	title = strings.ReplaceAll(title, "-", " ")
-	title = strings.ReplaceAll(title, "_", " ")
-	title = cases.Title(language.Und).String(title)
-	return title
-}
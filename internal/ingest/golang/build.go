@@ -0,0 +1,172 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Packages are documented for linux/amd64 unless another target is given,
+// matching pkg.go.dev.
+const (
+	defaultGOOS   = "linux"
+	defaultGOARCH = "amd64"
+)
+
+// target returns the GOOS/GOARCH pair documentation is rendered for.
+func (o PackageOptions) target() string {
+	goos, goarch := o.GOOS, o.GOARCH
+	if goos == "" {
+		goos = defaultGOOS
+	}
+	if goarch == "" {
+		goarch = defaultGOARCH
+	}
+	return goos + "/" + goarch
+}
+
+// ParsePlatforms splits a comma-separated list of goos/goarch pairs.
+func ParsePlatforms(value string) ([]string, error) {
+	var platforms []string
+	for item := range strings.SplitSeq(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		goos, goarch, ok := strings.Cut(item, "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("invalid platform %q, want goos/goarch", item)
+		}
+		platforms = append(platforms, item)
+	}
+	return platforms, nil
+}
+
+// buildContext returns a go/build context for a goos/goarch pair. cgo is
+// enabled so that files importing "C" are documented.
+func buildContext(platform string) build.Context {
+	goos, goarch, _ := strings.Cut(platform, "/")
+	bctx := build.Default
+	bctx.GOOS = goos
+	bctx.GOARCH = goarch
+	bctx.CgoEnabled = true
+	return bctx
+}
+
+// parseBuildFiles parses the non-test files of pkgDir that the build context
+// selects, honoring build tags and _GOOS/_GOARCH file suffixes. When files
+// declare several packages (a library next to a stray package main, say) the
+// library is preferred. It returns a nil slice if nothing matches.
+func parseBuildFiles(fset *token.FileSet, bctx build.Context, pkgDir string) (string, []*ast.File, error) {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	byPackage := make(map[string][]*ast.File)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		ok, err := bctx.MatchFile(pkgDir, name)
+		if err != nil {
+			return "", nil, err
+		}
+		if !ok {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(pkgDir, name), nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		byPackage[file.Name.Name] = append(byPackage[file.Name.Name], file)
+	}
+	if len(byPackage) == 0 {
+		return "", nil, nil
+	}
+
+	names := make([]string, 0, len(byPackage))
+	for name := range byPackage {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return packageRank(names[i], pkgDir) < packageRank(names[j], pkgDir)
+	})
+	return names[0], byPackage[names[0]], nil
+}
+
+// packageRank orders candidate package names: the one matching the directory
+// first, then any other library, then main and documentation-only packages.
+func packageRank(name, pkgDir string) string {
+	switch {
+	case name == filepath.Base(pkgDir):
+		return "0" + name
+	case name == "main" || name == "documentation":
+		return "2" + name
+	default:
+		return "1" + name
+	}
+}
+
+// platformAvailability reports, for each symbol that is missing on at least one
+// of the given platforms, the platforms where it is declared.
+func platformAvailability(pkgDir, importPath string, mode doc.Mode, platforms []string) (map[string][]string, error) {
+	if len(platforms) < 2 {
+		return nil, nil
+	}
+
+	seen := make(map[string][]string)
+	for _, platform := range platforms {
+		fset := token.NewFileSet()
+		_, files, err := parseBuildFiles(fset, buildContext(platform), pkgDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", platform, err)
+		}
+		if len(files) == 0 {
+			continue
+		}
+		pkg, err := doc.NewFromFiles(fset, files, importPath, mode)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", platform, err)
+		}
+		symbols, _ := collectSymbols(pkg, fset)
+		for _, sym := range symbols {
+			seen[sym.Name] = append(seen[sym.Name], platform)
+		}
+	}
+
+	partial := make(map[string][]string)
+	for name, available := range seen {
+		if len(available) < len(platforms) {
+			partial[name] = available
+		}
+	}
+	return partial, nil
+}
+
+// commandMarkdown renders a package main directory as a command page: its
+// name, how to install it and the package comment.
+func commandMarkdown(pkg *doc.Package, importPath string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "# %s\n\n", commandName(importPath))
+	fmt.Fprintf(&buf, "```sh\ngo install %s@latest\n```\n\n", importPath)
+	buf.Write(pkg.Markdown(pkg.Doc))
+	return buf.String()
+}
+
+func commandName(importPath string) string {
+	name := importPath[strings.LastIndex(importPath, "/")+1:]
+	if strings.HasPrefix(name, "v") && len(name) > 1 && strings.Trim(name[1:], "0123456789") == "" {
+		parent := strings.TrimSuffix(importPath, "/"+name)
+		return parent[strings.LastIndex(parent, "/")+1:]
+	}
+	return name
+}
@@ -0,0 +1,116 @@
+package golang
+
+import (
+	"go/doc"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writePlatformFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "fsutil", "fsutil.go"), "// Package fsutil is a fixture.\npackage fsutil\n\n// Open opens a file.\nfunc Open() {}\n")
+	writeFile(t, filepath.Join(dir, "fsutil", "fsutil_linux.go"), "package fsutil\n\n// Inotify watches files.\nfunc Inotify() {}\n")
+	writeFile(t, filepath.Join(dir, "fsutil", "fsutil_windows.go"), "package fsutil\n\n// ReadDirectoryChanges watches files.\nfunc ReadDirectoryChanges() {}\n")
+	writeFile(t, filepath.Join(dir, "fsutil", "tagged.go"), "//go:build darwin\n\npackage fsutil\n\n// FSEvents watches files.\nfunc FSEvents() {}\n")
+	writeFile(t, filepath.Join(dir, "fsutil", "gen.go"), "//go:build ignore\n\npackage main\n\nfunc main() {}\n")
+	return filepath.Join(dir, "fsutil")
+}
+
+func TestParseBuildFiles(t *testing.T) {
+	pkgDir := writePlatformFixture(t)
+
+	tests := []struct {
+		platform string
+		want     []string
+	}{
+		{"linux/amd64", []string{"fsutil.go", "fsutil_linux.go"}},
+		{"windows/amd64", []string{"fsutil.go", "fsutil_windows.go"}},
+		{"darwin/arm64", []string{"fsutil.go", "tagged.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			fset := token.NewFileSet()
+			name, files, err := parseBuildFiles(fset, buildContext(tt.platform), pkgDir)
+			if err != nil {
+				t.Fatalf("parseBuildFiles: %v", err)
+			}
+			if name != "fsutil" {
+				t.Errorf("package name = %q, want fsutil", name)
+			}
+			var got []string
+			for _, file := range files {
+				got = append(got, filepath.Base(fset.File(file.Pos()).Name()))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("library preferred over main", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "tool")
+		writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+		writeFile(t, filepath.Join(dir, "lib.go"), "package tool\n")
+		name, _, err := parseBuildFiles(token.NewFileSet(), buildContext("linux/amd64"), dir)
+		if err != nil {
+			t.Fatalf("parseBuildFiles: %v", err)
+		}
+		if name != "tool" {
+			t.Errorf("package name = %q, want tool", name)
+		}
+	})
+}
+
+func TestPlatformAvailability(t *testing.T) {
+	pkgDir := writePlatformFixture(t)
+	partial, err := platformAvailability(pkgDir, "example.com/fsutil", doc.AllDecls, []string{"linux/amd64", "windows/amd64"})
+	if err != nil {
+		t.Fatalf("platformAvailability: %v", err)
+	}
+	if _, ok := partial["Open"]; ok {
+		t.Error("Open is available everywhere and should not be annotated")
+	}
+	if got := partial["Inotify"]; !slices.Equal(got, []string{"linux/amd64"}) {
+		t.Errorf("Inotify platforms = %v", got)
+	}
+	if got := partial["ReadDirectoryChanges"]; !slices.Equal(got, []string{"windows/amd64"}) {
+		t.Errorf("ReadDirectoryChanges platforms = %v", got)
+	}
+
+	symbols := []symbolEntry{{Name: "Inotify", Body: "Inotify watches files."}}
+	agents := []agentEntry{{Symbol: "Inotify", Summary: "Inotify watches files."}}
+	annotatePlatforms(symbols, agents, partial)
+	if !strings.HasSuffix(symbols[0].Body, "Platforms: linux/amd64") {
+		t.Errorf("annotated body = %q", symbols[0].Body)
+	}
+	if !strings.HasSuffix(agents[0].Summary, "(linux/amd64 only)") {
+		t.Errorf("annotated summary = %q", agents[0].Summary)
+	}
+}
+
+func TestParsePlatforms(t *testing.T) {
+	got, err := ParsePlatforms("linux/amd64, darwin/arm64,")
+	if err != nil || !slices.Equal(got, []string{"linux/amd64", "darwin/arm64"}) {
+		t.Errorf("ParsePlatforms() = %v, %v", got, err)
+	}
+	if _, err := ParsePlatforms("linux"); err == nil {
+		t.Error("expected error for platform without goarch")
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := map[string]string{
+		"golang.org/x/tools/cmd/stringer": "stringer",
+		"example.com/tool/v2":             "tool",
+		"example.com/v2tool":              "v2tool",
+	}
+	for importPath, want := range tests {
+		if got := commandName(importPath); got != want {
+			t.Errorf("commandName(%q) = %q, want %q", importPath, got, want)
+		}
+	}
+}
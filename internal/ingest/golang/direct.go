@@ -0,0 +1,206 @@
+package golang
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/charmbracelet/log"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	modzip "golang.org/x/mod/zip"
+)
+
+// vcsRepo describes where a module's code lives, as advertised by the
+// go-import meta tag of its import path.
+type vcsRepo struct {
+	Prefix string
+	URL    string
+	// Subdir is the module's directory relative to the repository root,
+	// without any major version suffix.
+	Subdir string
+	// MajorDir is Subdir including the /vN suffix, for modules laid out in a
+	// major version subdirectory.
+	MajorDir string
+}
+
+// tagPrefix returns the prefix of release tags for the module, e.g.
+// "tools/" for a module rooted at the tools directory of its repository.
+func (r vcsRepo) tagPrefix() string {
+	if r.Subdir == "" {
+		return ""
+	}
+	return r.Subdir + "/"
+}
+
+// resolveRepo looks up the go-import meta tag for modulePath using the same
+// ?go-get=1 protocol as the go command. Only git repositories are supported.
+func (f *moduleFetcher) resolveRepo(ctx context.Context, modulePath string) (vcsRepo, error) {
+	resp, err := f.get(ctx, "https://"+modulePath+"?go-get=1")
+	if err != nil {
+		return vcsRepo{}, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return vcsRepo{}, err
+	}
+	return parseGoImport(doc, modulePath)
+}
+
+func parseGoImport(doc *goquery.Document, modulePath string) (vcsRepo, error) {
+	var repo vcsRepo
+	var vcs string
+	doc.Find(`meta[name="go-import"]`).Each(func(_ int, sel *goquery.Selection) {
+		content, _ := sel.Attr("content")
+		fields := strings.Fields(content)
+		if len(fields) != 3 {
+			return
+		}
+		prefix := fields[0]
+		if modulePath != prefix && !strings.HasPrefix(modulePath, prefix+"/") {
+			return
+		}
+		if len(prefix) > len(repo.Prefix) {
+			repo = vcsRepo{Prefix: prefix, URL: fields[2]}
+			vcs = fields[1]
+		}
+	})
+	if repo.Prefix == "" {
+		return vcsRepo{}, fmt.Errorf("no go-import meta tag found for %s", modulePath)
+	}
+	if vcs != "git" {
+		return vcsRepo{}, fmt.Errorf("unsupported vcs %q for %s", vcs, modulePath)
+	}
+
+	repo.MajorDir = strings.TrimPrefix(strings.TrimPrefix(modulePath, repo.Prefix), "/")
+	repo.Subdir = repo.MajorDir
+	if prefix, pathMajor, ok := module.SplitPathVersion(modulePath); ok && pathMajor != "" && strings.HasPrefix(pathMajor, "/") {
+		repo.Subdir = strings.TrimPrefix(strings.TrimPrefix(prefix, repo.Prefix), "/")
+	}
+	return repo, nil
+}
+
+// directLatest returns the highest release tag of the module's repository
+// that is compatible with its major version suffix.
+func (f *moduleFetcher) directLatest(ctx context.Context, modulePath string) (string, error) {
+	repo, err := f.resolveRepo(ctx, modulePath)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.CommandContext(ctx, "git", "ls-remote", "--tags", "--refs", repo.URL).Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote %s: %w", repo.URL, err)
+	}
+
+	_, pathMajor, _ := module.SplitPathVersion(modulePath)
+	var latest string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		tag, ok := strings.CutPrefix(fields[1], "refs/tags/"+repo.tagPrefix())
+		if !ok || strings.Contains(tag, "/") || !semver.IsValid(tag) || semver.Build(tag) != "" {
+			continue
+		}
+		if module.CheckPathMajor(tag, pathMajor) != nil {
+			continue
+		}
+		if latest == "" || preferVersion(tag, latest) {
+			latest = tag
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no release tags found for %s in %s", modulePath, repo.URL)
+	}
+	return latest, nil
+}
+
+// preferVersion reports whether v should be chosen over current: releases
+// beat pre-releases, then the higher semantic version wins.
+func preferVersion(v, current string) bool {
+	vRelease := semver.Prerelease(v) == ""
+	currentRelease := semver.Prerelease(current) == ""
+	if vRelease != currentRelease {
+		return vRelease
+	}
+	return semver.Compare(v, current) > 0
+}
+
+// directZip clones the module's repository at version and packs the module
+// directory into a zip identical to what a proxy would serve.
+func (f *moduleFetcher) directZip(ctx context.Context, modulePath, version string) (string, string, error) {
+	repo, err := f.resolveRepo(ctx, modulePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	cloneDir, err := os.MkdirTemp("", "documango-vcs-")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(cloneDir)
+
+	if err := cloneAtVersion(ctx, repo, version, cloneDir); err != nil {
+		return "", "", err
+	}
+
+	codeDir := filepath.Join(cloneDir, filepath.FromSlash(repo.MajorDir))
+	if _, err := os.Stat(filepath.Join(codeDir, "go.mod")); err != nil {
+		codeDir = filepath.Join(cloneDir, filepath.FromSlash(repo.Subdir))
+	}
+
+	tmpFile, err := os.CreateTemp("", "documango-module-*.zip")
+	if err != nil {
+		return "", "", err
+	}
+	err = modzip.CreateFromDir(tmpFile, module.Version{Path: modulePath, Version: version}, codeDir)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return "", "", err
+	}
+
+	log.Info("fetched module directly from vcs", "module", modulePath, "version", version, "repo", repo.URL)
+	return tmpFile.Name(), repo.URL, nil
+}
+
+// cloneAtVersion checks out the tag for a release version, or the commit
+// encoded in a pseudo-version.
+func cloneAtVersion(ctx context.Context, repo vcsRepo, version, dest string) error {
+	if module.IsPseudoVersion(version) {
+		rev, err := module.PseudoVersionRev(version)
+		if err != nil {
+			return err
+		}
+		if err := runGit(ctx, "", "clone", "--quiet", "--no-checkout", repo.URL, dest); err != nil {
+			return err
+		}
+		return runGit(ctx, dest, "checkout", "--quiet", rev)
+	}
+
+	tag := repo.tagPrefix() + strings.TrimSuffix(version, "+incompatible")
+	return runGit(ctx, "", "clone", "--quiet", "--depth", "1", "--branch", tag, repo.URL, dest)
+}
+
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
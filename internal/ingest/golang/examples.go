@@ -0,0 +1,112 @@
+package golang
+
+import (
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// collectExamples parses the package's _test.go files that match the build
+// context and returns one search entry per runnable Example function, with
+// its doc, code and expected output. Test files that fail to parse are
+// skipped, so a broken test never blocks the package's documentation.
+func collectExamples(fset *token.FileSet, bctx build.Context, pkgDir, pkgName string) []symbolEntry {
+	entries, err := os.ReadDir(pkgDir)
+	if err != nil {
+		log.Warn("failed to list test files", "dir", pkgDir, "err", err)
+		return nil
+	}
+
+	var testFiles []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if ok, err := bctx.MatchFile(pkgDir, name); err != nil || !ok {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(pkgDir, name), nil, parser.ParseComments)
+		if err != nil {
+			log.Warn("skipping examples of unparsable test file", "file", filepath.Join(pkgDir, name), "err", err)
+			continue
+		}
+		if file.Name.Name == pkgName || file.Name.Name == pkgName+"_test" {
+			testFiles = append(testFiles, file)
+		}
+	}
+
+	var symbols []symbolEntry
+	for _, ex := range doc.Examples(testFiles...) {
+		symbols = append(symbols, symbolEntry{
+			Name: exampleName(ex),
+			Type: "Example",
+			Body: exampleBody(fset, ex),
+		})
+	}
+	return symbols
+}
+
+// exampleName reconstructs the function name, e.g. "ExampleList_Push_second".
+func exampleName(ex *doc.Example) string {
+	name := "Example" + ex.Name
+	if ex.Suffix != "" {
+		name += "_" + ex.Suffix
+	}
+	return name
+}
+
+func exampleBody(fset *token.FileSet, ex *doc.Example) string {
+	var parts []string
+	if text := strings.TrimSpace(ex.Doc); text != "" {
+		parts = append(parts, text)
+	}
+	if code := exampleCode(fset, ex); code != "" {
+		parts = append(parts, "```go\n"+code+"\n```")
+	}
+	if ex.Output != "" || ex.EmptyOutput {
+		output := "Output:"
+		if ex.Unordered {
+			output = "Unordered output:"
+		}
+		parts = append(parts, output+"\n"+strings.TrimRight(ex.Output, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// exampleCode prefers the playable program go/doc synthesizes for whole-file
+// and self-contained examples, falling back to the body of the function.
+func exampleCode(fset *token.FileSet, ex *doc.Example) string {
+	if ex.Play != nil {
+		var buf strings.Builder
+		if err := format.Node(&buf, fset, ex.Play); err == nil {
+			return strings.TrimSpace(buf.String())
+		}
+	}
+
+	var buf strings.Builder
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&buf, fset, &printer.CommentedNode{Node: ex.Code, Comments: ex.Comments}); err != nil {
+		return ""
+	}
+	code := buf.String()
+	if _, ok := ex.Code.(*ast.BlockStmt); !ok {
+		return code
+	}
+
+	code = strings.TrimSuffix(strings.TrimPrefix(code, "{"), "}")
+	lines := strings.Split(strings.Trim(code, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "\t")
+	}
+	return strings.Join(lines, "\n")
+}
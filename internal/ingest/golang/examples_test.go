@@ -0,0 +1,163 @@
+package golang
+
+import (
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const genericFixture = `// Package list is a fixture.
+package list
+
+// List holds items.
+type List[T any] struct{ items []T }
+
+// NewList builds a list.
+func NewList[T any](items ...T) *List[T] { return &List[T]{items: items} }
+
+// Push appends v.
+func (l *List[T]) Push(v T) { l.items = append(l.items, v) }
+
+// Map applies f to every element.
+func Map[T, U any](in []T, f func(T) U) []U { return nil }
+
+func hidden() {}
+`
+
+const exampleFixture = `package list_test
+
+import (
+	"fmt"
+
+	"example.com/list"
+)
+
+// Doubling a slice.
+func ExampleMap() {
+	out := list.Map([]int{1, 2}, func(i int) int { return i * 2 })
+	fmt.Println(out)
+	// Output: [2 4]
+}
+
+func ExampleList_Push_twice() {
+	l := list.NewList[int]()
+	l.Push(1)
+	l.Push(2)
+}
+`
+
+func parseFixture(t *testing.T, mode doc.Mode) (string, *token.FileSet, *doc.Package) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "list.go"), genericFixture)
+	writeFile(t, filepath.Join(dir, "list_test.go"), exampleFixture)
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, fset, doc.New(pkgs["list"], "example.com/list", mode)
+}
+
+func TestCollectSymbols(t *testing.T) {
+	t.Run("all decls", func(t *testing.T) {
+		_, fset, pkg := parseFixture(t, doc.AllDecls)
+		symbols, agents := collectSymbols(pkg, fset)
+
+		bodies := make(map[string]string)
+		for _, sym := range symbols {
+			bodies[sym.Name] = sym.Body
+		}
+		if _, ok := bodies["hidden"]; !ok {
+			t.Error("expected unexported func with doc.AllDecls")
+		}
+		if !strings.HasPrefix(bodies["Map"], "func Map[T, U any](in []T, f func(T) U) []U") {
+			t.Errorf("Map body = %q, want generic signature", bodies["Map"])
+		}
+		if !strings.HasPrefix(bodies["List"], "type List[T any] struct") {
+			t.Errorf("List body = %q, want type header", bodies["List"])
+		}
+
+		var constructor *agentEntry
+		for i := range agents {
+			if agents[i].Symbol == "NewList" {
+				constructor = &agents[i]
+			}
+		}
+		if constructor == nil {
+			t.Fatal("missing agent context for constructor NewList")
+		}
+		if constructor.Signature != "func NewList[T any](items ...T) *List[T]" {
+			t.Errorf("NewList signature = %q", constructor.Signature)
+		}
+		if !strings.Contains(constructor.Summary, "constructor for List") {
+			t.Errorf("NewList summary = %q", constructor.Summary)
+		}
+	})
+
+	t.Run("exported only", func(t *testing.T) {
+		_, fset, pkg := parseFixture(t, 0)
+		symbols, _ := collectSymbols(pkg, fset)
+		for _, sym := range symbols {
+			if sym.Name == "hidden" {
+				t.Error("unexported func indexed in exported-only mode")
+			}
+		}
+	})
+}
+
+func TestCollectExamples(t *testing.T) {
+	dir, fset, _ := parseFixture(t, 0)
+	// Examples of other targets and broken test files are left out.
+	writeFile(t, filepath.Join(dir, "list_windows_test.go"), "package list_test\n\nfunc ExampleWindows() {}\n")
+	writeFile(t, filepath.Join(dir, "gen_test.go"), "//go:build ignore\n\npackage list_test\n\nfunc ExampleIgnored() {}\n")
+	writeFile(t, filepath.Join(dir, "broken_test.go"), "package list_test\n\nfunc ExampleBroken( {\n")
+
+	examples := collectExamples(fset, buildContext("linux/amd64"), dir, "list")
+	if len(examples) != 2 {
+		t.Fatalf("collectExamples() returned %d entries, want 2", len(examples))
+	}
+
+	byName := make(map[string]symbolEntry)
+	for _, ex := range examples {
+		if ex.Type != "Example" {
+			t.Errorf("%s type = %q, want Example", ex.Name, ex.Type)
+		}
+		byName[ex.Name] = ex
+	}
+
+	mapEx, ok := byName["ExampleMap"]
+	if !ok {
+		t.Fatalf("missing ExampleMap in %v", examples)
+	}
+	for _, want := range []string{"Doubling a slice.", "list.Map([]int{1, 2}", "Output:\n[2 4]"} {
+		if !strings.Contains(mapEx.Body, want) {
+			t.Errorf("ExampleMap body missing %q:\n%s", want, mapEx.Body)
+		}
+	}
+	if _, ok := byName["ExampleList_Push_twice"]; !ok {
+		t.Errorf("missing ExampleList_Push_twice in %v", examples)
+	}
+}
+
+func TestSymbolFromHeading(t *testing.T) {
+	tests := map[string]string{
+		"type List":                 "List",
+		`func Map\[T, U any\]`:      "Map",
+		`func \(\*List\[T\]\) Push`: "List.Push",
+		"func (c *Client) Do":       "Client.Do",
+		"const MaxSize":             "MaxSize",
+	}
+	for heading, want := range tests {
+		if got := symbolFromHeading(heading); got != want {
+			t.Errorf("symbolFromHeading(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
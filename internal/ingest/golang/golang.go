@@ -1,28 +1,23 @@
 // package golang contains the ingestion pipeline for Go docs.
 //
-// TODO: Add a Go-specific filter option to exclude unexported symbols from the
-// search index. I think Go devs know the difference between exported (uppercase)
-// and unexported (lowercase) symbols, so including both is useful for context.
-// However, for a more focused public API view, we could add a flag to filter
-// out unexported symbols.
+// Unexported symbols are indexed by default since they are useful context;
+// set ExportedOnly for a focused public API view.
 package golang
 
 import (
 	"archive/zip"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"go/ast"
 	"go/doc"
-	"go/parser"
 	"go/printer"
 	"go/token"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
@@ -30,7 +25,6 @@ import (
 	"github.com/princjef/gomarkdoc"
 	"github.com/princjef/gomarkdoc/lang"
 	"github.com/princjef/gomarkdoc/logger"
-	"golang.org/x/mod/module"
 
 	"github.com/stormlightlabs/documango/internal/cache"
 	"github.com/stormlightlabs/documango/internal/codec"
@@ -42,6 +36,34 @@ type Options struct {
 	Version string
 	DB      *db.Store
 	Cache   *cache.FilesystemCache
+	// Dir ingests a module or go.work workspace from a local directory
+	// instead of the module proxy. When Module is also set, only that
+	// workspace member is ingested.
+	Dir string
+	// Env controls proxy selection and checksum verification. When nil, the
+	// settings reported by `go env` are used.
+	Env *GoEnv
+	// GoSum optionally names a go.sum file used to verify downloads before
+	// falling back to the checksum database.
+	GoSum string
+	// ExportedOnly leaves unexported symbols out of the docs and search index.
+	ExportedOnly bool
+	// GOOS, GOARCH and Platforms are passed through to PackageOptions.
+	GOOS      string
+	GOARCH    string
+	Platforms []string
+}
+
+// PackageOptions controls how a single package directory is documented.
+type PackageOptions struct {
+	ExportedOnly bool
+	// GOOS and GOARCH select the build context whose files are documented,
+	// defaulting to linux/amd64.
+	GOOS   string
+	GOARCH string
+	// Platforms optionally lists goos/goarch pairs to check symbols against;
+	// symbols missing on some of them are annotated with where they exist.
+	Platforms []string
 }
 
 type latestResponse struct {
@@ -49,187 +71,120 @@ type latestResponse struct {
 }
 
 func IngestModule(ctx context.Context, opts Options) error {
+	if opts.DB == nil {
+		return errors.New("db store is required")
+	}
+	if opts.Dir != "" {
+		return ingestLocal(ctx, opts)
+	}
 	if opts.Module == "" {
 		return errors.New("module is required")
 	}
-	if opts.DB == nil {
-		return errors.New("db store is required")
+
+	env := opts.Env
+	if env == nil {
+		loaded := LoadGoEnv(ctx)
+		env = &loaded
+	}
+	var cacheDir string
+	if opts.Cache != nil {
+		cacheDir = opts.Cache.Dir()
+	}
+	fetcher, err := newModuleFetcher(*env, opts.GoSum, cacheDir)
+	if err != nil {
+		return err
 	}
 
+	modCache := ModCacheDir()
 	version := opts.Version
 	if version == "" {
-		var err error
-		version, err = fetchLatestVersion(ctx, opts.Module)
+		version, err = fetcher.latest(ctx, opts.Module)
 		if err != nil {
-			return err
+			cached, ok := modCacheLatestVersion(modCache, opts.Module)
+			if !ok {
+				return err
+			}
+			log.Warn("module proxy lookup failed, using module cache", "module", opts.Module, "version", cached, "err", err)
+			version = cached
 		}
 	}
 
-	root, cleanup, err := downloadModuleZip(ctx, opts.Module, version, opts.Cache)
+	root, cleanup, err := moduleSource(ctx, fetcher, opts.Module, version, modCache, opts.Cache)
 	if err != nil {
 		return err
 	}
 	defer cleanup()
 
-	packages, err := discoverPackages(root)
-	if err != nil {
-		return err
-	}
-
-	if len(packages) == 0 {
-		return fmt.Errorf("no packages found in %s@%s", opts.Module, version)
-	}
-	log.Info("go module ingest starting", "module", opts.Module, "version", version, "packages", len(packages))
-
 	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
-		for _, pkgDir := range packages {
-			if err := ingestPackage(ctx, tx, opts.Module, root, pkgDir); err != nil {
-				return err
-			}
-		}
-		return nil
+		return ingestModuleRoot(ctx, tx, opts.Module, version, root, opts.packageOptions())
 	})
 }
 
-func fetchLatestVersion(ctx context.Context, modulePath string) (string, error) {
-	escaped, err := module.EscapePath(modulePath)
-	if err != nil {
-		return "", err
+func (opts Options) packageOptions() PackageOptions {
+	return PackageOptions{
+		ExportedOnly: opts.ExportedOnly,
+		GOOS:         opts.GOOS,
+		GOARCH:       opts.GOARCH,
+		Platforms:    opts.Platforms,
 	}
-	url := fmt.Sprintf("https://proxy.golang.org/%s/@latest", escaped)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return "", err
-	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("module proxy error: %s", resp.Status)
-	}
-	var payload latestResponse
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return "", err
-	}
-	if payload.Version == "" {
-		return "", errors.New("module proxy response missing version")
+}
+
+// moduleSource returns the module's source tree, preferring an extracted copy
+// in the local module cache over a download from the proxy.
+func moduleSource(ctx context.Context, f *moduleFetcher, modulePath, version, modCache string, c *cache.FilesystemCache) (string, func(), error) {
+	if dir, ok := modCacheModuleDir(modCache, modulePath, version); ok {
+		log.Info("using module cache", "module", modulePath, "version", version, "path", dir)
+		return dir, func() {}, nil
 	}
-	return payload.Version, nil
+	return downloadModuleZip(ctx, f, modulePath, version, c)
 }
 
-func downloadModuleZip(ctx context.Context, modulePath, version string, c *cache.FilesystemCache) (string, func(), error) {
+func downloadModuleZip(ctx context.Context, f *moduleFetcher, modulePath, version string, c *cache.FilesystemCache) (string, func(), error) {
 	cacheKey := cache.ModuleKey(modulePath, version)
 
 	if c != nil {
-		if cachedPath, entry, err := c.Get(cacheKey); err == nil {
+		if cachedPath, _, err := c.Get(cacheKey); err == nil {
 			log.Info("using cached module", "module", modulePath, "version", version, "path", cachedPath)
-			extractDir, err := os.MkdirTemp("", "documango-module-")
-			if err != nil {
-				return "", nil, err
-			}
-			if err := unzip(cachedPath, extractDir); err != nil {
-				_ = os.RemoveAll(extractDir)
-				return "", nil, err
-			}
-			root := extractDir
-			moduleDir := filepath.Join(extractDir, filepath.Base(entry.Path))
-			if info, err := os.Stat(moduleDir); err == nil && info.IsDir() {
-				root = moduleDir
-			} else {
-				entries, err := os.ReadDir(extractDir)
-				if err == nil && len(entries) == 1 && entries[0].IsDir() {
-					root = filepath.Join(extractDir, entries[0].Name())
-				}
-			}
-			cleanup := func() {
-				_ = os.RemoveAll(extractDir)
-			}
-			return root, cleanup, nil
+			return extractModuleZip(cachedPath, modulePath, version)
 		}
 	}
 
-	escaped, err := module.EscapePath(modulePath)
-	if err != nil {
-		return "", nil, err
-	}
-	url := fmt.Sprintf("https://proxy.golang.org/%s/@v/%s.zip", escaped, version)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	zipPath, source, err := f.fetchZip(ctx, modulePath, version)
 	if err != nil {
 		return "", nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", nil, fmt.Errorf("module proxy error: %s", resp.Status)
-	}
+	defer os.Remove(zipPath)
 
-	tmpFile, err := os.CreateTemp("", "documango-module-*.zip")
-	if err != nil {
-		return "", nil, err
-	}
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		_ = tmpFile.Close()
-		_ = os.Remove(tmpFile.Name())
-		return "", nil, err
-	}
-	if _, err := tmpFile.Seek(0, 0); err != nil {
-		_ = tmpFile.Close()
-		_ = os.Remove(tmpFile.Name())
+	if err := f.verifyZip(ctx, modulePath, version, zipPath); err != nil {
 		return "", nil, err
 	}
 
 	if c != nil {
-		_, err := c.Put(cacheKey, url, tmpFile, 0)
-		_ = tmpFile.Close()
-		if err != nil {
-			log.Warn("failed to cache module", "module", modulePath, "err", err)
-		}
-		if cachedPath, _, err := c.Get(cacheKey); err == nil {
-			_ = os.Remove(tmpFile.Name())
-			tmpFile, err = os.Open(cachedPath)
-			if err != nil {
-				return "", nil, err
+		if zipFile, err := os.Open(zipPath); err == nil {
+			if _, err := c.Put(cacheKey, redactURL(source), zipFile, 0); err != nil {
+				log.Warn("failed to cache module", "module", modulePath, "err", err)
 			}
-			defer tmpFile.Close()
-		}
-	} else {
-		if err := tmpFile.Close(); err != nil {
-			_ = os.Remove(tmpFile.Name())
-			return "", nil, err
+			_ = zipFile.Close()
 		}
 	}
 
-	zipPath := tmpFile.Name()
-	if c == nil {
-		defer os.Remove(zipPath)
-	}
+	return extractModuleZip(zipPath, modulePath, version)
+}
 
+// extractModuleZip unpacks a module zip and returns the module root, whose
+// entries are laid out as <module>@<version>/... in the archive.
+func extractModuleZip(zipPath, modulePath, version string) (string, func(), error) {
 	extractDir, err := os.MkdirTemp("", "documango-module-")
 	if err != nil {
-		if c == nil {
-			_ = os.Remove(zipPath)
-		}
 		return "", nil, err
 	}
-
 	if err := unzip(zipPath, extractDir); err != nil {
 		_ = os.RemoveAll(extractDir)
-		if c == nil {
-			_ = os.Remove(zipPath)
-		}
 		return "", nil, err
 	}
-	if c == nil {
-		_ = os.Remove(zipPath)
-	}
 
 	root := extractDir
-	moduleDir := filepath.Join(extractDir, escaped+"@"+version)
+	moduleDir := filepath.Join(extractDir, filepath.FromSlash(modulePath)+"@"+version)
 	if info, err := os.Stat(moduleDir); err == nil && info.IsDir() {
 		root = moduleDir
 	} else {
@@ -297,8 +252,14 @@ func discoverPackages(root string) ([]string, error) {
 			return err
 		}
 		if d.IsDir() {
+			if path == root {
+				return nil
+			}
 			name := d.Name()
-			if name == ".git" || name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") {
+			if name == ".git" || name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
 				return filepath.SkipDir
 			}
 			return nil
@@ -378,14 +339,15 @@ func collectSymbols(pkg *doc.Package, fset *token.FileSet) ([]symbolEntry, []age
 	}
 
 	for _, fn := range pkg.Funcs {
+		signature := signatureForDecl(fset, fn.Decl)
 		symbols = append(symbols, symbolEntry{
 			Name: fn.Name,
 			Type: "Func",
-			Body: summaryText(fn.Doc),
+			Body: signatureText(signature, fn.Doc),
 		})
 		agents = append(agents, agentEntry{
 			Symbol:    fn.Name,
-			Signature: signatureForDecl(fset, fn.Decl),
+			Signature: signature,
 			Summary:   doc.Synopsis(fn.Doc),
 		})
 	}
@@ -394,7 +356,7 @@ func collectSymbols(pkg *doc.Package, fset *token.FileSet) ([]symbolEntry, []age
 		symbols = append(symbols, symbolEntry{
 			Name: typ.Name,
 			Type: "Type",
-			Body: summaryText(typ.Doc),
+			Body: signatureText(typeHeader(fset, typ), typ.Doc),
 		})
 		agents = append(agents, agentEntry{
 			Symbol:    typ.Name,
@@ -402,16 +364,33 @@ func collectSymbols(pkg *doc.Package, fset *token.FileSet) ([]symbolEntry, []age
 			Summary:   doc.Synopsis(typ.Doc),
 		})
 
+		// Constructors are grouped under the type they return rather than
+		// listed in pkg.Funcs.
+		for _, fn := range typ.Funcs {
+			signature := signatureForDecl(fset, fn.Decl)
+			symbols = append(symbols, symbolEntry{
+				Name: fn.Name,
+				Type: "Func",
+				Body: signatureText(signature, fn.Doc),
+			})
+			agents = append(agents, agentEntry{
+				Symbol:    fn.Name,
+				Signature: signature,
+				Summary:   constructorSummary(typ.Name, fn.Doc),
+			})
+		}
+
 		for _, method := range typ.Methods {
 			name := typ.Name + "." + method.Name
+			signature := signatureForDecl(fset, method.Decl)
 			symbols = append(symbols, symbolEntry{
 				Name: name,
 				Type: "Method",
-				Body: summaryText(method.Doc),
+				Body: signatureText(signature, method.Doc),
 			})
 			agents = append(agents, agentEntry{
 				Symbol:    name,
-				Signature: signatureForDecl(fset, method.Decl),
+				Signature: signature,
 				Summary:   doc.Synopsis(method.Doc),
 			})
 		}
@@ -450,6 +429,39 @@ func collectSymbols(pkg *doc.Package, fset *token.FileSet) ([]symbolEntry, []age
 	return symbols, agents
 }
 
+// collectCommand indexes a package main directory as a single Command entry;
+// its declarations are implementation details rather than API.
+func collectCommand(pkg *doc.Package, importPath string) ([]symbolEntry, []agentEntry) {
+	name := commandName(importPath)
+	return []symbolEntry{{
+		Name: name,
+		Type: "Command",
+		Body: summaryText(pkg.Doc),
+	}}, []agentEntry{{
+		Symbol:    name,
+		Signature: "go install " + importPath + "@latest",
+		Summary:   doc.Synopsis(pkg.Doc),
+	}}
+}
+
+// annotatePlatforms records the platforms of symbols that are not declared on
+// every requested platform.
+func annotatePlatforms(symbols []symbolEntry, agents []agentEntry, partial map[string][]string) {
+	if len(partial) == 0 {
+		return
+	}
+	for i, sym := range symbols {
+		if platforms, ok := partial[sym.Name]; ok {
+			symbols[i].Body = strings.TrimSpace(sym.Body + "\n\nPlatforms: " + strings.Join(platforms, ", "))
+		}
+	}
+	for i, agent := range agents {
+		if platforms, ok := partial[agent.Symbol]; ok {
+			agents[i].Summary = strings.TrimSpace(agent.Summary + " (" + strings.Join(platforms, ", ") + " only)")
+		}
+	}
+}
+
 type symbolEntry struct {
 	Name string
 	Type string
@@ -462,6 +474,64 @@ type agentEntry struct {
 	Summary   string
 }
 
+// signatureText prefixes a symbol's doc text with its declaration so that
+// parameter and type parameter names are searchable.
+func signatureText(signature, text string) string {
+	body := summaryText(text)
+	if signature == "" {
+		return body
+	}
+	if body == "" {
+		return signature
+	}
+	return signature + "\n\n" + body
+}
+
+// typeHeader renders the first line of a type declaration, including any type
+// parameters, e.g. "type List[T any] struct".
+func typeHeader(fset *token.FileSet, typ *doc.Type) string {
+	for _, spec := range typ.Decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != typ.Name {
+			continue
+		}
+		var buf strings.Builder
+		buf.WriteString("type " + typ.Name)
+		if ts.TypeParams != nil && len(ts.TypeParams.List) > 0 {
+			var params []string
+			for _, field := range ts.TypeParams.List {
+				var names []string
+				for _, name := range field.Names {
+					names = append(names, name.Name)
+				}
+				params = append(params, strings.Join(names, ", ")+" "+signatureForDecl(fset, field.Type))
+			}
+			buf.WriteString("[" + strings.Join(params, ", ") + "]")
+		}
+		if ts.Assign.IsValid() {
+			buf.WriteString(" =")
+		}
+		switch ts.Type.(type) {
+		case *ast.StructType:
+			buf.WriteString(" struct")
+		case *ast.InterfaceType:
+			buf.WriteString(" interface")
+		default:
+			buf.WriteString(" " + signatureForDecl(fset, ts.Type))
+		}
+		return buf.String()
+	}
+	return "type " + typ.Name
+}
+
+func constructorSummary(typeName, text string) string {
+	summary := doc.Synopsis(text)
+	if summary == "" {
+		return fmt.Sprintf("Constructor for %s.", typeName)
+	}
+	return fmt.Sprintf("%s (constructor for %s)", summary, typeName)
+}
+
 func summaryText(text string) string {
 	if text == "" {
 		return ""
@@ -498,12 +568,12 @@ func injectAnchors(markdown string, symbols []symbolEntry) string {
 }
 
 func symbolFromHeading(heading string) string {
-	heading = strings.TrimSpace(heading)
+	heading = strings.TrimSpace(markdownUnescaper.Replace(heading))
 	switch {
 	case strings.HasPrefix(heading, "type "):
 		fields := strings.Fields(strings.TrimPrefix(heading, "type "))
 		if len(fields) > 0 {
-			return fields[0]
+			return stripTypeParams(fields[0])
 		}
 	case strings.HasPrefix(heading, "func "):
 		rest := strings.TrimPrefix(heading, "func ")
@@ -516,18 +586,18 @@ func symbolFromHeading(heading string) string {
 				if len(recvFields) > 0 {
 					recvType = recvFields[len(recvFields)-1]
 				}
-				recvType = strings.TrimLeft(recvType, "*")
+				recvType = stripTypeParams(strings.TrimLeft(recvType, "*"))
 				rest = strings.TrimSpace(rest[idx+1:])
 				nameFields := strings.Fields(rest)
 				if len(nameFields) > 0 {
-					return recvType + "." + nameFields[0]
+					return recvType + "." + stripTypeParams(nameFields[0])
 				}
 				return ""
 			}
 		}
 		nameFields := strings.Fields(rest)
 		if len(nameFields) > 0 {
-			return nameFields[0]
+			return stripTypeParams(nameFields[0])
 		}
 	case strings.HasPrefix(heading, "var "):
 		fields := strings.Fields(strings.TrimPrefix(heading, "var "))
@@ -543,39 +613,71 @@ func symbolFromHeading(heading string) string {
 	return ""
 }
 
-func ingestPackage(ctx context.Context, tx *sql.Tx, modulePath, moduleRoot, pkgDir string) error {
+// markdownUnescaper undoes the backslash escaping gomarkdoc applies to
+// headings such as "func \(\*List\[T\]\) Push".
+var markdownUnescaper = strings.NewReplacer(`\(`, "(", `\)`, ")", `\[`, "[", `\]`, "]", `\*`, "*", `\_`, "_")
+
+// stripTypeParams drops a trailing type parameter list, so "List[T]" and
+// "Map[T," both resolve to the bare identifier.
+func stripTypeParams(name string) string {
+	if i := strings.IndexAny(name, "[("); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func ingestPackage(ctx context.Context, tx *sql.Tx, modulePath, moduleRoot, pkgDir string, popts PackageOptions) error {
 	importPath := buildImportPath(modulePath, moduleRoot, pkgDir)
 	docPath := "go/" + importPath
-	return IngestPackageDir(ctx, tx, importPath, moduleRoot, pkgDir, docPath)
+	return IngestPackageDir(ctx, tx, importPath, moduleRoot, pkgDir, docPath, popts)
 }
 
-func IngestPackageDir(ctx context.Context, tx *sql.Tx, importPath, workDir, pkgDir, docPath string) error {
+func IngestPackageDir(ctx context.Context, tx *sql.Tx, importPath, workDir, pkgDir, docPath string, popts PackageOptions) error {
 	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, pkgDir, func(info os.FileInfo) bool {
-		name := info.Name()
-		return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
-	}, parser.ParseComments)
+	target := popts.target()
+	pkgName, files, err := parseBuildFiles(fset, buildContext(target), pkgDir)
 	if err != nil {
 		return err
 	}
-	if len(pkgs) == 0 {
+	if len(files) == 0 {
+		log.Debug("no buildable files for target", "package", importPath, "target", target)
 		return nil
 	}
 
-	pkgNames := make([]string, 0, len(pkgs))
-	for name := range pkgs {
-		pkgNames = append(pkgNames, name)
+	mode := doc.AllDecls
+	if popts.ExportedOnly {
+		mode = 0
 	}
-	sort.Strings(pkgNames)
-	astPkg := pkgs[pkgNames[0]]
-
-	pkgDoc := doc.New(astPkg, importPath, doc.AllDecls)
-
-	md, err := generateMarkdown(pkgDoc, workDir, pkgDir)
+	pkgDoc, err := doc.NewFromFiles(fset, files, importPath, mode)
 	if err != nil {
 		return err
 	}
-	symbols, agents := collectSymbols(pkgDoc, fset)
+
+	var md string
+	var symbols []symbolEntry
+	var agents []agentEntry
+	if pkgName == "main" {
+		md = commandMarkdown(pkgDoc, importPath)
+		symbols, agents = collectCommand(pkgDoc, importPath)
+	} else {
+		md, err = generateMarkdown(pkgDoc, workDir, pkgDir)
+		if err != nil {
+			return err
+		}
+		symbols, agents = collectSymbols(pkgDoc, fset)
+
+		platforms := popts.Platforms
+		if len(platforms) > 0 && !slices.Contains(platforms, target) {
+			platforms = append([]string{target}, platforms...)
+		}
+		partial, err := platformAvailability(pkgDir, importPath, mode, platforms)
+		if err != nil {
+			return err
+		}
+		annotatePlatforms(symbols, agents, partial)
+
+		symbols = append(symbols, collectExamples(fset, buildContext(target), pkgDir, pkgName)...)
+	}
 	md = injectAnchors(md, symbols)
 	compressed, err := codec.Compress([]byte(md))
 	if err != nil {
@@ -0,0 +1,131 @@
+package golang
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// LocalGOROOT returns the GOROOT reported by the go toolchain on PATH, or an
+// empty string when no toolchain is available.
+func LocalGOROOT(ctx context.Context) string {
+	if _, err := exec.LookPath("go"); err != nil {
+		return ""
+	}
+	out, err := exec.CommandContext(ctx, "go", "env", "GOROOT").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ingestGOROOT enumerates $GOROOT/src and ingests each package directly from
+// disk, without touching pkg.go.dev or gitiles.
+func ingestGOROOT(ctx context.Context, opts StdlibOptions) error {
+	goroot, err := filepath.Abs(opts.GOROOT)
+	if err != nil {
+		return err
+	}
+	srcDir := filepath.Join(goroot, "src")
+	if info, err := os.Stat(srcDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("not a GOROOT (missing src directory): %s", goroot)
+	}
+
+	version := opts.Version
+	if version == "" {
+		version, err = readGOROOTVersion(goroot)
+		if err != nil {
+			return err
+		}
+	}
+
+	packages, err := gorootPackages(srcDir)
+	if err != nil {
+		return err
+	}
+	if len(packages) == 0 {
+		return errors.New("no stdlib packages found")
+	}
+	packages = filterStdlibPackages(packages, opts.Start, opts.MaxPackages)
+	if len(packages) == 0 {
+		return errors.New("no stdlib packages selected")
+	}
+	log.Info("stdlib ingest starting", "goroot", goroot, "version", version, "packages", len(packages), "start", opts.Start, "max", opts.MaxPackages)
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, pkg := range packages {
+			log.Info("ingesting stdlib package", "path", pkg)
+			pkgDir := filepath.Join(srcDir, filepath.FromSlash(pkg))
+			docPath := "go/" + pkg
+			if err := IngestPackageDir(ctx, tx, pkg, goroot, pkgDir, docPath, opts.packageOptions()); err != nil {
+				return fmt.Errorf("%s: %w", pkg, err)
+			}
+		}
+		return nil
+	})
+}
+
+// readGOROOTVersion reads the toolchain version from the first line of
+// $GOROOT/VERSION (e.g. "go1.24.5").
+func readGOROOTVersion(goroot string) (string, error) {
+	f, err := os.Open(filepath.Join(goroot, "VERSION"))
+	if err != nil {
+		return "", fmt.Errorf("unable to detect stdlib version: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		if version := strings.TrimSpace(scanner.Text()); version != "" {
+			return version, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("unable to detect stdlib version: empty VERSION file")
+}
+
+// gorootPackages lists the import paths of the public standard library
+// packages under srcDir. Commands, vendored code and internal packages are
+// skipped to match the package list published on pkg.go.dev/std.
+func gorootPackages(srcDir string) ([]string, error) {
+	dirs, err := discoverPackages(srcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(srcDir, dir)
+		if err != nil || rel == "." {
+			continue
+		}
+		pkg := filepath.ToSlash(rel)
+		if !isPublicStdlibPackage(pkg) {
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+func isPublicStdlibPackage(pkg string) bool {
+	for i, elem := range strings.Split(pkg, "/") {
+		if i == 0 && elem == "cmd" {
+			return false
+		}
+		if elem == "internal" || elem == "vendor" || elem == "testdata" || strings.HasPrefix(elem, "_") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package golang
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGorootPackages(t *testing.T) {
+	goroot := t.TempDir()
+	src := filepath.Join(goroot, "src")
+	for _, file := range []string{
+		"fmt/print.go",
+		"net/http/client.go",
+		"net/http/client_test.go",
+		"net/http/internal/chunked.go",
+		"internal/abi/abi.go",
+		"cmd/go/main.go",
+		"vendor/golang.org/x/net/dns/dns.go",
+		"crypto/testdata/fixture.go",
+		"encoding/json/json_test.go",
+	} {
+		writeFile(t, filepath.Join(src, file), "package x\n")
+	}
+
+	got, err := gorootPackages(src)
+	if err != nil {
+		t.Fatalf("gorootPackages: %v", err)
+	}
+	want := []string{"fmt", "net/http"}
+	if !slices.Equal(got, want) {
+		t.Errorf("gorootPackages() = %v, want %v", got, want)
+	}
+}
+
+func TestReadGOROOTVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"release", "go1.24.5\ntime 2025-07-08T21:26:29Z\n", "go1.24.5", false},
+		{"single line", "go1.23.0", "go1.23.0", false},
+		{"empty", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goroot := t.TempDir()
+			writeFile(t, filepath.Join(goroot, "VERSION"), tt.content)
+			got, err := readGOROOTVersion(goroot)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readGOROOTVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readGOROOTVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := readGOROOTVersion(t.TempDir()); err == nil {
+		t.Error("expected error for missing VERSION file")
+	}
+}
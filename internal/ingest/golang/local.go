@@ -0,0 +1,211 @@
+package golang
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// localModule is a module whose sources are already on disk.
+type localModule struct {
+	Path string
+	Root string
+}
+
+// ingestLocal ingests the module rooted at opts.Dir, or every module listed in
+// its go.work file when the directory is a workspace.
+func ingestLocal(ctx context.Context, opts Options) error {
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	modules, err := resolveLocalModules(dir)
+	if err != nil {
+		return err
+	}
+	if opts.Module != "" {
+		modules = filterLocalModules(modules, opts.Module)
+		if len(modules) == 0 {
+			return fmt.Errorf("module %s not found in %s", opts.Module, dir)
+		}
+	}
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, mod := range modules {
+			if err := ingestModuleRoot(ctx, tx, mod.Path, "local", mod.Root, opts.packageOptions()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ingestModuleRoot ingests every package below root as part of modulePath.
+func ingestModuleRoot(ctx context.Context, tx *sql.Tx, modulePath, version, root string, popts PackageOptions) error {
+	packages, err := discoverPackages(root)
+	if err != nil {
+		return err
+	}
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages found in %s@%s", modulePath, version)
+	}
+	log.Info("go module ingest starting", "module", modulePath, "version", version, "root", root, "packages", len(packages))
+
+	for _, pkgDir := range packages {
+		if err := ingestPackage(ctx, tx, modulePath, root, pkgDir, popts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveLocalModules returns the modules rooted at dir. A go.work file takes
+// precedence over go.mod so that a workspace root ingests all of its members.
+func resolveLocalModules(dir string) ([]localModule, error) {
+	workPath := filepath.Join(dir, "go.work")
+	if data, err := os.ReadFile(workPath); err == nil {
+		work, err := modfile.ParseWork(workPath, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		var modules []localModule
+		for _, use := range work.Use {
+			root := use.Path
+			if !filepath.IsAbs(root) {
+				root = filepath.Join(dir, filepath.FromSlash(root))
+			}
+			mod, err := readLocalModule(root)
+			if err != nil {
+				return nil, fmt.Errorf("go.work use %s: %w", use.Path, err)
+			}
+			modules = append(modules, mod)
+		}
+		if len(modules) == 0 {
+			return nil, fmt.Errorf("go.work in %s has no use directives", dir)
+		}
+		return modules, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	mod, err := readLocalModule(dir)
+	if err != nil {
+		return nil, err
+	}
+	return []localModule{mod}, nil
+}
+
+func readLocalModule(root string) (localModule, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return localModule{}, fmt.Errorf("no go.mod or go.work found in %s", root)
+		}
+		return localModule{}, err
+	}
+	path := modfile.ModulePath(data)
+	if path == "" {
+		return localModule{}, fmt.Errorf("go.mod in %s has no module directive", root)
+	}
+	return localModule{Path: path, Root: root}, nil
+}
+
+func filterLocalModules(modules []localModule, modulePath string) []localModule {
+	var filtered []localModule
+	for _, mod := range modules {
+		if mod.Path == modulePath {
+			filtered = append(filtered, mod)
+		}
+	}
+	return filtered
+}
+
+// ModCacheDir returns the module cache directory, honoring $GOMODCACHE and
+// falling back to `go env GOMODCACHE` and $GOPATH/pkg/mod.
+func ModCacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if _, err := exec.LookPath("go"); err == nil {
+		if out, err := exec.Command("go", "env", "GOMODCACHE").Output(); err == nil {
+			if dir := strings.TrimSpace(string(out)); dir != "" {
+				return dir
+			}
+		}
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
+
+// modCacheModuleDir returns the extracted module directory for
+// modulePath@version in the module cache, if present.
+func modCacheModuleDir(modCache, modulePath, version string) (string, bool) {
+	if modCache == "" {
+		return "", false
+	}
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", false
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", false
+	}
+	dir := filepath.Join(modCache, filepath.FromSlash(escapedPath)+"@"+escapedVersion)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, true
+	}
+	return "", false
+}
+
+// modCacheLatestVersion returns the highest version of modulePath extracted
+// in the module cache.
+func modCacheLatestVersion(modCache, modulePath string) (string, bool) {
+	if modCache == "" {
+		return "", false
+	}
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", false
+	}
+	parent := filepath.Join(modCache, filepath.FromSlash(escapedPath))
+	prefix := filepath.Base(parent) + "@"
+	entries, err := os.ReadDir(filepath.Dir(parent))
+	if err != nil {
+		return "", false
+	}
+
+	var latest string
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !entry.IsDir() || !ok {
+			continue
+		}
+		version, err := module.UnescapeVersion(name)
+		if err != nil || !semver.IsValid(version) {
+			continue
+		}
+		if latest == "" || semver.Compare(version, latest) > 0 {
+			latest = version
+		}
+	}
+	return latest, latest != ""
+}
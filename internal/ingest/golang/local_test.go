@@ -0,0 +1,88 @@
+package golang
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestResolveLocalModules(t *testing.T) {
+	t.Run("module", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/app\n\ngo 1.24\n")
+
+		mods, err := resolveLocalModules(dir)
+		if err != nil {
+			t.Fatalf("resolveLocalModules: %v", err)
+		}
+		if len(mods) != 1 || mods[0].Path != "example.com/app" || mods[0].Root != dir {
+			t.Errorf("resolveLocalModules() = %+v", mods)
+		}
+	})
+
+	t.Run("workspace", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "go.work"), "go 1.24\n\nuse (\n\t./api\n\t./tools\n)\n")
+		writeFile(t, filepath.Join(dir, "api", "go.mod"), "module example.com/api\n")
+		writeFile(t, filepath.Join(dir, "tools", "go.mod"), "module example.com/tools\n")
+
+		mods, err := resolveLocalModules(dir)
+		if err != nil {
+			t.Fatalf("resolveLocalModules: %v", err)
+		}
+		var paths []string
+		for _, mod := range mods {
+			paths = append(paths, mod.Path)
+		}
+		if want := []string{"example.com/api", "example.com/tools"}; !slices.Equal(paths, want) {
+			t.Errorf("module paths = %v, want %v", paths, want)
+		}
+		if got := filterLocalModules(mods, "example.com/tools"); len(got) != 1 || got[0].Root != filepath.Join(dir, "tools") {
+			t.Errorf("filterLocalModules() = %+v", got)
+		}
+	})
+
+	t.Run("missing go.mod", func(t *testing.T) {
+		if _, err := resolveLocalModules(t.TempDir()); err == nil {
+			t.Error("expected error for directory without go.mod")
+		}
+	})
+}
+
+func TestDiscoverPackagesSkipsNestedModules(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/app\n")
+	writeFile(t, filepath.Join(dir, "app.go"), "package app\n")
+	writeFile(t, filepath.Join(dir, "sub", "sub.go"), "package sub\n")
+	writeFile(t, filepath.Join(dir, "plugin", "go.mod"), "module example.com/app/plugin\n")
+	writeFile(t, filepath.Join(dir, "plugin", "plugin.go"), "package plugin\n")
+
+	got, err := discoverPackages(dir)
+	if err != nil {
+		t.Fatalf("discoverPackages: %v", err)
+	}
+	want := []string{dir, filepath.Join(dir, "sub")}
+	if !slices.Equal(got, want) {
+		t.Errorf("discoverPackages() = %v, want %v", got, want)
+	}
+}
+
+func TestModCacheLookup(t *testing.T) {
+	modCache := t.TempDir()
+	for _, v := range []string{"v1.2.0", "v1.10.0", "v1.9.3"} {
+		writeFile(t, filepath.Join(modCache, "github.com", "!burnt!sushi", "toml@"+v, "toml.go"), "package toml\n")
+	}
+
+	dir, ok := modCacheModuleDir(modCache, "github.com/BurntSushi/toml", "v1.9.3")
+	if !ok || dir != filepath.Join(modCache, "github.com", "!burnt!sushi", "toml@v1.9.3") {
+		t.Errorf("modCacheModuleDir() = %q, %v", dir, ok)
+	}
+	if _, ok := modCacheModuleDir(modCache, "github.com/BurntSushi/toml", "v2.0.0"); ok {
+		t.Error("modCacheModuleDir() found a version that is not cached")
+	}
+
+	latest, ok := modCacheLatestVersion(modCache, "github.com/BurntSushi/toml")
+	if !ok || latest != "v1.10.0" {
+		t.Errorf("modCacheLatestVersion() = %q, %v, want v1.10.0", latest, ok)
+	}
+}
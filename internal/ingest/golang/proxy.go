@@ -0,0 +1,421 @@
+package golang
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+)
+
+const defaultGOPROXY = "https://proxy.golang.org,direct"
+
+// GoEnv holds the subset of the go command's environment that controls how
+// modules are fetched and verified.
+type GoEnv struct {
+	GOPROXY   string
+	GOPRIVATE string
+	GONOPROXY string
+	GONOSUMDB string
+	GOSUMDB   string
+}
+
+// LoadGoEnv reads the module fetching settings from `go env`, which already
+// merges the process environment with the user's go.env file. Without a go
+// toolchain on PATH, only the process environment is consulted.
+func LoadGoEnv(ctx context.Context) GoEnv {
+	env := GoEnv{
+		GOPROXY:   os.Getenv("GOPROXY"),
+		GOPRIVATE: os.Getenv("GOPRIVATE"),
+		GONOPROXY: os.Getenv("GONOPROXY"),
+		GONOSUMDB: os.Getenv("GONOSUMDB"),
+		GOSUMDB:   os.Getenv("GOSUMDB"),
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		return env
+	}
+	out, err := exec.CommandContext(ctx, "go", "env", "-json", "GOPROXY", "GOPRIVATE", "GONOPROXY", "GONOSUMDB", "GOSUMDB").Output()
+	if err != nil {
+		return env
+	}
+	_ = json.Unmarshal(out, &env)
+	return env
+}
+
+// proxyEntry is one element of a GOPROXY list. URL is either a proxy base
+// URL or one of the keywords "direct" and "off".
+type proxyEntry struct {
+	URL string
+	// FallbackOnError is set for entries followed by "|", which fall through
+	// to the next entry on any error rather than only on 404 and 410.
+	FallbackOnError bool
+}
+
+// parseGOPROXY splits a GOPROXY value into its entries.
+func parseGOPROXY(value string) ([]proxyEntry, error) {
+	if strings.TrimSpace(value) == "" {
+		value = defaultGOPROXY
+	}
+
+	var entries []proxyEntry
+	for value != "" {
+		var entry proxyEntry
+		if i := strings.IndexAny(value, ",|"); i >= 0 {
+			entry.URL = strings.TrimSpace(value[:i])
+			entry.FallbackOnError = value[i] == '|'
+			value = value[i+1:]
+		} else {
+			entry.URL = strings.TrimSpace(value)
+			value = ""
+		}
+		if entry.URL == "" {
+			continue
+		}
+		switch entry.URL {
+		case "direct", "off":
+		case "noproxy":
+			entry.URL = "direct"
+		default:
+			if !strings.Contains(entry.URL, "://") {
+				entry.URL = "https://" + entry.URL
+			}
+			if _, err := url.Parse(entry.URL); err != nil {
+				return nil, fmt.Errorf("invalid GOPROXY entry %q: %w", entry.URL, err)
+			}
+			entry.URL = strings.TrimSuffix(entry.URL, "/")
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("GOPROXY list is empty")
+	}
+	return entries, nil
+}
+
+// errNotFound marks proxy responses (404 and 410) that let a "," separated
+// GOPROXY list fall through to its next entry.
+type errNotFound struct {
+	URL    string
+	Status string
+}
+
+func (e *errNotFound) Error() string {
+	return fmt.Sprintf("module proxy error: %s: %s", e.URL, e.Status)
+}
+
+// moduleFetcher resolves and downloads modules following the go command's
+// GOPROXY, GOPRIVATE/GONOPROXY and GOSUMDB/GONOSUMDB rules.
+type moduleFetcher struct {
+	proxies  []proxyEntry
+	noProxy  string
+	noSumDB  string
+	sumDB    string
+	goSum    map[string]string
+	netrc    []netrcLine
+	client   *http.Client
+	cacheDir string
+}
+
+// newModuleFetcher builds a fetcher from env. goSumPath optionally names a
+// go.sum file whose hashes take precedence over the checksum database.
+func newModuleFetcher(env GoEnv, goSumPath, cacheDir string) (*moduleFetcher, error) {
+	proxies, err := parseGOPROXY(env.GOPROXY)
+	if err != nil {
+		return nil, err
+	}
+
+	noProxy := env.GONOPROXY
+	if noProxy == "" {
+		noProxy = env.GOPRIVATE
+	}
+	noSumDB := env.GONOSUMDB
+	if noSumDB == "" {
+		noSumDB = env.GOPRIVATE
+	}
+	sumDB := strings.TrimSpace(env.GOSUMDB)
+	if sumDB == "" {
+		sumDB = "sum.golang.org"
+	}
+
+	f := &moduleFetcher{
+		proxies:  proxies,
+		noProxy:  noProxy,
+		noSumDB:  noSumDB,
+		sumDB:    sumDB,
+		netrc:    loadNetrc(),
+		client:   newFetchClient(),
+		cacheDir: cacheDir,
+	}
+	if goSumPath != "" {
+		data, err := os.ReadFile(goSumPath)
+		if err != nil {
+			return nil, err
+		}
+		f.goSum = parseGoSum(data)
+	}
+	return f, nil
+}
+
+func newFetchClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return &http.Client{Transport: transport, Timeout: 5 * time.Minute}
+}
+
+// proxiesFor returns the GOPROXY entries to use for modulePath. Modules
+// matching GONOPROXY (or GOPRIVATE) are always fetched directly.
+func (f *moduleFetcher) proxiesFor(modulePath string) []proxyEntry {
+	if module.MatchPrefixPatterns(f.noProxy, modulePath) {
+		for _, p := range f.proxies {
+			if p.URL == "off" {
+				return []proxyEntry{p}
+			}
+		}
+		return []proxyEntry{{URL: "direct"}}
+	}
+	return f.proxies
+}
+
+// latest resolves the latest version of modulePath.
+func (f *moduleFetcher) latest(ctx context.Context, modulePath string) (string, error) {
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for _, proxy := range f.proxiesFor(modulePath) {
+		var version string
+		switch proxy.URL {
+		case "off":
+			return "", fmt.Errorf("module lookup disabled by GOPROXY=off: %s", modulePath)
+		case "direct":
+			version, err = f.directLatest(ctx, modulePath)
+		default:
+			version, err = f.proxyLatest(ctx, proxy.URL+"/"+escaped+"/@latest")
+		}
+		if err == nil {
+			return version, nil
+		}
+		lastErr = err
+		if !canFallBack(proxy, err) {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+func (f *moduleFetcher) proxyLatest(ctx context.Context, url string) (string, error) {
+	resp, err := f.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var payload latestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", err
+	}
+	if payload.Version == "" {
+		return "", errors.New("module proxy response missing version")
+	}
+	return payload.Version, nil
+}
+
+// fetchZip downloads the module zip for modulePath@version to a temporary
+// file and returns its path along with the source it was fetched from.
+func (f *moduleFetcher) fetchZip(ctx context.Context, modulePath, version string) (string, string, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", "", err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", "", err
+	}
+
+	var lastErr error
+	for _, proxy := range f.proxiesFor(modulePath) {
+		var zipPath, source string
+		switch proxy.URL {
+		case "off":
+			return "", "", fmt.Errorf("module lookup disabled by GOPROXY=off: %s@%s", modulePath, version)
+		case "direct":
+			zipPath, source, err = f.directZip(ctx, modulePath, version)
+		default:
+			source = fmt.Sprintf("%s/%s/@v/%s.zip", proxy.URL, escapedPath, escapedVersion)
+			zipPath, err = f.downloadToTemp(ctx, source)
+		}
+		if err == nil {
+			return zipPath, source, nil
+		}
+		lastErr = err
+		if !canFallBack(proxy, err) {
+			break
+		}
+	}
+	return "", "", lastErr
+}
+
+func canFallBack(proxy proxyEntry, err error) bool {
+	if proxy.FallbackOnError {
+		return true
+	}
+	var notFound *errNotFound
+	return errors.As(err, &notFound)
+}
+
+func (f *moduleFetcher) downloadToTemp(ctx context.Context, url string) (string, error) {
+	resp, err := f.get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	tmpFile, err := os.CreateTemp("", "documango-module-*.zip")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+	return tmpFile.Name(), nil
+}
+
+// get issues an authenticated GET request, returning errNotFound for 404 and
+// 410 responses.
+func (f *moduleFetcher) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	f.addCredentials(req)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, &errNotFound{URL: redactURL(rawURL), Status: resp.Status}
+	}
+	return nil, fmt.Errorf("module proxy error: %s: %s", redactURL(rawURL), resp.Status)
+}
+
+// addCredentials applies basic auth from URL userinfo or a matching .netrc
+// machine entry.
+func (f *moduleFetcher) addCredentials(req *http.Request) {
+	if req.URL.User != nil {
+		password, _ := req.URL.User.Password()
+		req.SetBasicAuth(req.URL.User.Username(), password)
+		return
+	}
+	host := req.URL.Hostname()
+	for _, line := range f.netrc {
+		if line.machine == host {
+			req.SetBasicAuth(line.login, line.password)
+			return
+		}
+	}
+}
+
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+type netrcLine struct {
+	machine  string
+	login    string
+	password string
+}
+
+// loadNetrc reads credentials from $NETRC or the user's .netrc (_netrc on
+// Windows). Missing files yield no credentials.
+func loadNetrc() []netrcLine {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		path = filepath.Join(home, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseNetrc(string(data))
+}
+
+// parseNetrc extracts machine/login/password triples. Entries after a
+// "default" token are ignored, as are macro definitions.
+func parseNetrc(data string) []netrcLine {
+	var lines []netrcLine
+	var current netrcLine
+	inMacro := false
+	for line := range strings.SplitSeq(data, "\n") {
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+	tokens:
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "default":
+				return lines
+			case "macdef":
+				inMacro = true
+				break tokens
+			case "machine", "login", "password":
+				if i+1 >= len(fields) {
+					break tokens
+				}
+				value := fields[i+1]
+				switch fields[i] {
+				case "machine":
+					current = netrcLine{machine: value}
+				case "login":
+					current.login = value
+				case "password":
+					current.password = value
+				}
+				i++
+			}
+			if current.machine != "" && current.login != "" && current.password != "" {
+				lines = append(lines, current)
+				current = netrcLine{}
+			}
+		}
+	}
+	return lines
+}
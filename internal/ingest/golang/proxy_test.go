@@ -0,0 +1,220 @@
+package golang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+	modzip "golang.org/x/mod/zip"
+)
+
+func TestParseGOPROXY(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []proxyEntry
+	}{
+		{"", []proxyEntry{{URL: "https://proxy.golang.org"}, {URL: "direct"}}},
+		{"off", []proxyEntry{{URL: "off"}}},
+		{
+			"https://athens.example.com/|https://proxy.golang.org,direct",
+			[]proxyEntry{
+				{URL: "https://athens.example.com", FallbackOnError: true},
+				{URL: "https://proxy.golang.org"},
+				{URL: "direct"},
+			},
+		},
+		{"goproxy.example.com", []proxyEntry{{URL: "https://goproxy.example.com"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseGOPROXY(tt.value)
+			if err != nil {
+				t.Fatalf("parseGOPROXY(%q): %v", tt.value, err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("parseGOPROXY(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	data := `machine athens.example.com login ci password s3cret
+machine git.example.com
+	login bot
+	password token
+
+macdef init
+machine ignored.example.com login x password y
+
+default login anonymous password guest
+machine after-default.example.com login a password b
+`
+	got := parseNetrc(data)
+	want := []netrcLine{
+		{machine: "athens.example.com", login: "ci", password: "s3cret"},
+		{machine: "git.example.com", login: "bot", password: "token"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("parseNetrc() = %v, want %v", got, want)
+	}
+}
+
+func TestModuleFetcherProxyFallback(t *testing.T) {
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	athens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "ci" || pass != "s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/example.com/!private/@latest" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"Version":"v1.4.0"}`)
+	}))
+	defer athens.Close()
+
+	newFetcher := func(t *testing.T, env GoEnv) *moduleFetcher {
+		t.Helper()
+		f, err := newModuleFetcher(env, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		host := strings.TrimPrefix(athens.URL, "http://")
+		host = host[:strings.LastIndex(host, ":")]
+		f.netrc = []netrcLine{{machine: host, login: "ci", password: "s3cret"}}
+		return f
+	}
+	ctx := context.Background()
+
+	t.Run("comma falls through on not found", func(t *testing.T) {
+		f := newFetcher(t, GoEnv{GOPROXY: missing.URL + "," + athens.URL})
+		got, err := f.latest(ctx, "example.com/Private")
+		if err != nil || got != "v1.4.0" {
+			t.Errorf("latest() = %q, %v", got, err)
+		}
+	})
+
+	t.Run("comma stops on server error", func(t *testing.T) {
+		f := newFetcher(t, GoEnv{GOPROXY: broken.URL + "," + athens.URL})
+		if _, err := f.latest(ctx, "example.com/Private"); err == nil {
+			t.Error("expected error from broken proxy")
+		}
+	})
+
+	t.Run("pipe falls through on any error", func(t *testing.T) {
+		f := newFetcher(t, GoEnv{GOPROXY: broken.URL + "|" + athens.URL})
+		got, err := f.latest(ctx, "example.com/Private")
+		if err != nil || got != "v1.4.0" {
+			t.Errorf("latest() = %q, %v", got, err)
+		}
+	})
+
+	t.Run("off", func(t *testing.T) {
+		f := newFetcher(t, GoEnv{GOPROXY: "off"})
+		if _, err := f.latest(ctx, "example.com/Private"); err == nil || !strings.Contains(err.Error(), "GOPROXY=off") {
+			t.Errorf("latest() error = %v, want GOPROXY=off", err)
+		}
+	})
+
+	t.Run("private modules bypass proxies", func(t *testing.T) {
+		f := newFetcher(t, GoEnv{GOPROXY: athens.URL, GOPRIVATE: "example.com/*,*.corp.internal"})
+		if got := f.proxiesFor("example.com/Private"); len(got) != 1 || got[0].URL != "direct" {
+			t.Errorf("proxiesFor(private) = %v, want direct", got)
+		}
+		if got := f.proxiesFor("golang.org/x/net"); len(got) != 1 || got[0].URL != athens.URL {
+			t.Errorf("proxiesFor(public) = %v, want %s", got, athens.URL)
+		}
+	})
+}
+
+func writeModuleZip(t *testing.T, modulePath, version string) string {
+	t.Helper()
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "go.mod"), "module "+modulePath+"\n")
+	writeFile(t, filepath.Join(src, "lib.go"), "// Package lib is a fixture.\npackage lib\n\n// Hello says hello.\nfunc Hello() string { return \"hello\" }\n")
+
+	zipPath := filepath.Join(t.TempDir(), "module.zip")
+	out, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := modzip.CreateFromDir(out, module.Version{Path: modulePath, Version: version}, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+func TestDownloadModuleZipVerifiesGoSum(t *testing.T) {
+	const modulePath, version = "example.com/lib", "v1.0.0"
+	zipPath := writeModuleZip(t, modulePath, version)
+	zipData, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := dirhash.HashZip(zipPath, dirhash.Hash1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/lib/@v/v1.0.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(zipData)
+	}))
+	defer proxy.Close()
+
+	ctx := context.Background()
+	env := GoEnv{GOPROXY: proxy.URL, GOSUMDB: "off"}
+
+	t.Run("matching hash", func(t *testing.T) {
+		goSum := filepath.Join(t.TempDir(), "go.sum")
+		writeFile(t, goSum, fmt.Sprintf("%s %s %s\n%s %s/go.mod h1:ignored=\n", modulePath, version, hash, modulePath, version))
+		f, err := newModuleFetcher(env, goSum, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, cleanup, err := downloadModuleZip(ctx, f, modulePath, version, nil)
+		if err != nil {
+			t.Fatalf("downloadModuleZip: %v", err)
+		}
+		defer cleanup()
+		if _, err := os.Stat(filepath.Join(root, "lib.go")); err != nil {
+			t.Errorf("module root %s missing lib.go: %v", root, err)
+		}
+	})
+
+	t.Run("mismatched hash", func(t *testing.T) {
+		goSum := filepath.Join(t.TempDir(), "go.sum")
+		writeFile(t, goSum, fmt.Sprintf("%s %s h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n", modulePath, version))
+		f, err := newModuleFetcher(env, goSum, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = downloadModuleZip(ctx, f, modulePath, version, nil)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("downloadModuleZip() error = %v, want ErrChecksumMismatch", err)
+		}
+	})
+}
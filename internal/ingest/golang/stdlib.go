@@ -36,12 +36,33 @@ type StdlibOptions struct {
 	Start       string
 	MaxPackages int
 	Cache       *cache.FilesystemCache
+	// GOROOT, when set, ingests the standard library from a local toolchain
+	// instead of scraping pkg.go.dev and downloading from gitiles.
+	GOROOT string
+	// ExportedOnly leaves unexported symbols out of the docs and search index.
+	ExportedOnly bool
+	// GOOS, GOARCH and Platforms are passed through to PackageOptions.
+	GOOS      string
+	GOARCH    string
+	Platforms []string
+}
+
+func (opts StdlibOptions) packageOptions() PackageOptions {
+	return PackageOptions{
+		ExportedOnly: opts.ExportedOnly,
+		GOOS:         opts.GOOS,
+		GOARCH:       opts.GOARCH,
+		Platforms:    opts.Platforms,
+	}
 }
 
 func IngestStdlib(ctx context.Context, opts StdlibOptions) error {
 	if opts.DB == nil {
 		return errors.New("db store is required")
 	}
+	if opts.GOROOT != "" {
+		return ingestGOROOT(ctx, opts)
+	}
 
 	client := &http.Client{Timeout: 30 * time.Second}
 	fetch := &fetcher{
@@ -92,7 +113,7 @@ func IngestStdlib(ctx context.Context, opts StdlibOptions) error {
 			}
 
 			docPath := "go/" + pkg
-			if err := IngestPackageDir(ctx, tx, pkg, root, pkgDir, docPath); err != nil {
+			if err := IngestPackageDir(ctx, tx, pkg, root, pkgDir, docPath, opts.packageOptions()); err != nil {
 				return fmt.Errorf("%s: %w", pkg, err)
 			}
 		}
@@ -0,0 +1,283 @@
+package hexpm
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// exdocPage is an ExDoc HTML page: a module (or behaviour, protocol,
+// exception, mix task) with its documented items, or an extra such as a guide,
+// cheatsheet or livebook.
+type exdocPage struct {
+	Title    string
+	Kind     string
+	Summary  string
+	Markdown string
+	Items    []exdocItem
+}
+
+// exdocItem is a function, macro, callback or type documented on a module
+// page. ID is the ExDoc anchor (decode/2, t:decode_opt/0, c:encode/2).
+type exdocItem struct {
+	ID        string
+	Name      string
+	Kind      string
+	Heading   string
+	Specs     []string
+	Summary   string
+	Markdown  string
+	Deprecate string
+}
+
+// Signature is what agent_context stores for the item: its @spec, @type or
+// @callback lines, or the heading when ExDoc has no spec.
+func (it exdocItem) Signature() string {
+	if len(it.Specs) > 0 {
+		return strings.Join(it.Specs, "\n")
+	}
+	return it.Heading
+}
+
+// exdocExtraKinds maps ExDoc's page-<type> body classes for extras to
+// document types.
+var exdocExtraKinds = map[string]string{
+	"page-extra":   "Guide",
+	"page-cheatmd": "Cheatsheet",
+	"page-livemd":  "Livebook",
+}
+
+// parseExDocPage reads a module or extra page. The item anchors of a module
+// page encode their kind: t: for types, c: for callbacks, and none for
+// functions and macros, which ExDoc tells apart with a "(macro)" note.
+func parseExDocPage(r io.Reader) (exdocPage, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return exdocPage{}, err
+	}
+
+	content := doc.Find("#content").First()
+	if content.Length() == 0 {
+		content = doc.Find("main, body").First()
+	}
+	content.Find(".icon-action, .detail-link, .hover-link, .bottom-actions, .view-source, footer, script").Remove()
+	markMakeupLanguages(content)
+
+	heading := content.Find("#top-content h1, h1").First().Clone()
+	heading.Find(".app-vsn").Remove()
+	page := exdocPage{Title: oneLine(heading.Text())}
+
+	if content.Find("#moduledoc").Length() > 0 || content.Find("section.detail, div.detail").Length() > 0 {
+		page.Kind = "Module"
+		moduledoc := content.Find("#moduledoc").First()
+		page.Summary = firstParagraph(moduledoc)
+		page.Markdown = htmlToMarkdown(moduledoc)
+		content.Find("section.details-list").Each(func(_ int, list *goquery.Selection) {
+			if id, _ := list.Attr("id"); id == "summary" {
+				return
+			}
+			list.Find("section.detail, div.detail").Each(func(_ int, detail *goquery.Selection) {
+				if item, ok := parseExDocItem(detail); ok {
+					page.Items = append(page.Items, item)
+				}
+			})
+		})
+		return page, nil
+	}
+
+	page.Kind = "Guide"
+	for class, kind := range exdocExtraKinds {
+		if doc.Find("body."+class).Length() > 0 {
+			page.Kind = kind
+		}
+	}
+	if content.Find(".livebook-badge").Length() > 0 {
+		page.Kind = "Livebook"
+	}
+	content.Find(".livebook-badge-container").Remove()
+
+	body := content.Find("#top-content").First()
+	if body.Length() == 0 {
+		body = content
+	}
+	body = body.Clone()
+	body.Find("h1").First().Remove()
+	page.Summary = firstParagraph(body)
+	page.Markdown = htmlToMarkdown(body)
+	return page, nil
+}
+
+func parseExDocItem(detail *goquery.Selection) (exdocItem, bool) {
+	id, ok := detail.Attr("id")
+	if !ok || id == "" {
+		return exdocItem{}, false
+	}
+
+	item := exdocItem{ID: id, Kind: "Function"}
+	name := id
+	switch {
+	case strings.HasPrefix(id, "t:"):
+		item.Kind, name = "Type", strings.TrimPrefix(id, "t:")
+	case strings.HasPrefix(id, "c:"):
+		item.Kind, name = "Callback", strings.TrimPrefix(id, "c:")
+	}
+	item.Name = name
+
+	signature := detail.Find(".signature").First().Clone()
+	note := strings.ToLower(signature.Find(".note").Text())
+	signature.Find(".note").Remove()
+	item.Heading = oneLine(signature.Text())
+	if strings.Contains(note, "macro") {
+		if item.Kind == "Callback" {
+			item.Kind = "MacroCallback"
+		} else {
+			item.Kind = "Macro"
+		}
+	}
+
+	docstring := detail.Find(".docstring").First()
+	docstring.Find(".specs pre").Each(func(_ int, pre *goquery.Selection) {
+		if spec := oneLine(pre.Text()); spec != "" {
+			item.Specs = append(item.Specs, spec)
+		}
+	})
+	docstring.Find(".specs").Remove()
+	if deprecated := detail.Find(".deprecated").First(); deprecated.Length() > 0 {
+		item.Deprecate = oneLine(deprecated.Text())
+		deprecated.Remove()
+	}
+	item.Summary = firstParagraph(docstring)
+	item.Markdown = htmlToMarkdown(docstring)
+	return item, true
+}
+
+// markMakeupLanguages rewrites ExDoc's <code class="makeup elixir"> so the
+// converter fences the block with its language.
+func markMakeupLanguages(sel *goquery.Selection) {
+	sel.Find("pre code").Each(func(_ int, code *goquery.Selection) {
+		class, _ := code.Attr("class")
+		fields := strings.Fields(class)
+		lang := ""
+		for _, f := range fields {
+			if f != "makeup" && !strings.HasPrefix(f, "language-") {
+				lang = f
+			}
+		}
+		if lang != "" {
+			code.SetAttr("class", "language-"+lang)
+		}
+	})
+}
+
+func firstParagraph(sel *goquery.Selection) string {
+	return oneLine(sel.Find("p").First().Text())
+}
+
+func htmlToMarkdown(sel *goquery.Selection) string {
+	if sel.Length() == 0 {
+		return ""
+	}
+	html, err := sel.Html()
+	if err != nil {
+		return ""
+	}
+	conv := converter.NewConverter(
+		converter.WithPlugins(
+			base.NewBasePlugin(),
+			commonmark.NewCommonmarkPlugin(),
+		),
+	)
+	md, err := conv.ConvertString(html)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(md)
+}
+
+var markdownHeading = regexp.MustCompile(`^(#{1,6}) `)
+
+// demoteHeadings shifts the headings of a docstring so the shallowest one is
+// at the given level, keeping them below the heading the docstring is
+// rendered under. Lines inside code fences are left alone.
+func demoteHeadings(md string, level int) string {
+	lines := strings.Split(md, "\n")
+	shallowest := 7
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil && !inFence && len(m[1]) < shallowest {
+			shallowest = len(m[1])
+		}
+	}
+	if shallowest >= level {
+		return md
+	}
+
+	shift := level - shallowest
+	inFence = false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil && !inFence {
+			lines[i] = strings.Repeat("#", min(len(m[1])+shift, 6)) + line[len(m[1]):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// exdocSections orders the item sections of a module page.
+var exdocSections = []struct{ kind, title string }{
+	{"Type", "Types"},
+	{"Callback", "Callbacks"},
+	{"MacroCallback", "Macro Callbacks"},
+	{"Function", "Functions"},
+	{"Macro", "Macros"},
+}
+
+// render builds the Markdown document for a page. Module items are grouped by
+// kind, each under its heading with its specs in an elixir block.
+func (p exdocPage) render() string {
+	var b strings.Builder
+	b.WriteString("# " + p.Title + "\n\n")
+	if p.Markdown != "" {
+		b.WriteString(demoteHeadings(p.Markdown, 2) + "\n\n")
+	}
+
+	for _, section := range exdocSections {
+		var items []exdocItem
+		for _, it := range p.Items {
+			if it.Kind == section.kind {
+				items = append(items, it)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		b.WriteString("## " + section.title + "\n\n")
+		for _, it := range items {
+			b.WriteString("### " + it.Heading + "\n\n")
+			if len(it.Specs) > 0 {
+				b.WriteString("```elixir\n" + strings.Join(it.Specs, "\n") + "\n```\n\n")
+			}
+			if it.Deprecate != "" {
+				b.WriteString("> **Deprecated**: " + it.Deprecate + "\n\n")
+			}
+			if it.Markdown != "" {
+				b.WriteString(demoteHeadings(it.Markdown, 4) + "\n\n")
+			}
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
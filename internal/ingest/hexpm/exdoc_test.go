@@ -0,0 +1,184 @@
+package hexpm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// exdocModuleFixture is a trimmed ExDoc module page with a type, a
+// callback, a function with two specs, a deprecated function and a macro.
+const exdocModuleFixture = `<!DOCTYPE html>
+<html><body class="page-module"><main class="content"><div id="content" class="content-inner">
+<div id="top-content">
+  <div class="heading-with-actions top-heading">
+    <h1><span translate="no">Jason</span> <small class="app-vsn" translate="no">(jason v1.4.4)</small></h1>
+    <a href="https://github.com/michalmuskala/jason/blob/v1.4.4/lib/jason.ex#L1" class="icon-action" title="View Source"><i class="ri-code-s-slash-line"></i></a>
+  </div>
+  <section id="moduledoc">
+    <p>A blazing fast JSON parser and generator in pure Elixir.</p>
+    <h2 id="module-examples" class="section-heading"><a href="#module-examples" class="hover-link"><i class="ri-link-m"></i></a><span class="text">Examples</span></h2>
+    <pre><code class="makeup elixir" translate="no"><span class="nc">Jason</span><span class="o">.</span><span class="n">encode!</span><span class="p">(</span><span class="p">%{</span><span class="p">}</span><span class="p">)</span></code></pre>
+  </section>
+</div>
+<section id="summary" class="details-list">
+  <h1 class="section-heading">Summary</h1>
+  <div class="summary-functions summary"><div class="summary-row"><div class="summary-signature"><a href="#decode/2">decode(input, opts \\ [])</a></div></div></div>
+</section>
+<section id="types" class="details-list">
+  <h1 class="section-heading">Types</h1>
+  <div class="types-list">
+    <section class="detail" id="t:decode_opt/0">
+      <div class="detail-header">
+        <a href="#t:decode_opt/0" class="detail-link" title="Link to this type"><i class="ri-link-m"></i></a>
+        <div class="heading-with-actions"><h1 class="signature" translate="no">decode_opt()</h1></div>
+      </div>
+      <section class="docstring">
+        <div class="specs"><pre translate="no"><span class="attribute">@type</span> decode_opt() ::
+  {:keys, :atoms | :strings}</pre></div>
+        <p>Decoding option.</p>
+      </section>
+    </section>
+  </div>
+</section>
+<section id="callbacks" class="details-list">
+  <h1 class="section-heading">Callbacks</h1>
+  <section class="detail" id="c:encode/2">
+    <div class="detail-header"><h1 class="signature" translate="no">encode(value, opts)</h1></div>
+    <section class="docstring">
+      <div class="specs"><pre translate="no"><span class="attribute">@callback</span> encode(term(), Jason.Encode.opts()) :: iodata()</pre></div>
+      <p>Encodes a value.</p>
+    </section>
+  </section>
+</section>
+<section id="functions" class="details-list">
+  <h1 class="section-heading">Functions</h1>
+  <section class="detail" id="decode/2">
+    <div class="detail-header">
+      <a href="#decode/2" class="detail-link"><i class="ri-link-m"></i></a>
+      <div class="heading-with-actions"><h1 class="signature" translate="no">decode(input, opts \\ [])</h1>
+      <a href="https://github.com/x" class="icon-action" title="View Source"><i class="ri-code-s-slash-line"></i></a></div>
+    </div>
+    <section class="docstring">
+      <div class="specs">
+        <pre translate="no"><span class="attribute">@spec</span> decode(iodata(), [decode_opt()]) ::
+  {:ok, term()} | {:error, Jason.DecodeError.t()}</pre>
+        <pre translate="no"><span class="attribute">@spec</span> decode(iodata(), map()) :: {:ok, term()}</pre>
+      </div>
+      <p>Parses a JSON value from <code class="inline">input</code> iodata.</p>
+      <h2 id="decode/2-options" class="section-heading"><a href="#decode/2-options" class="hover-link"><i class="ri-link-m"></i></a><span class="text">Options</span></h2>
+      <ul><li><code class="inline">:keys</code> - controls how keys are decoded</li></ul>
+    </section>
+  </section>
+  <section class="detail" id="old/1">
+    <div class="detail-header"><h1 class="signature" translate="no">old(x)</h1></div>
+    <div class="deprecated">This function is deprecated. Use decode/2 instead.</div>
+    <section class="docstring"><p>Old.</p></section>
+  </section>
+  <section class="detail" id="sigil_J/2">
+    <div class="detail-header"><h1 class="signature" translate="no">sigil_J(term, modifiers) <span class="note">(macro)</span></h1></div>
+    <section class="docstring"><p>Handles the sigil ~J.</p></section>
+  </section>
+</section>
+</div></main></body></html>`
+
+func TestParseExDocModule(t *testing.T) {
+	page, err := parseExDocPage(strings.NewReader(exdocModuleFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Title != "Jason" || page.Kind != "Module" {
+		t.Errorf("page = %q %q, want Jason Module", page.Title, page.Kind)
+	}
+	if page.Summary != "A blazing fast JSON parser and generator in pure Elixir." {
+		t.Errorf("summary = %q", page.Summary)
+	}
+
+	want := []struct {
+		name, kind, signature string
+	}{
+		{"decode_opt/0", "Type", "@type decode_opt() :: {:keys, :atoms | :strings}"},
+		{"encode/2", "Callback", "@callback encode(term(), Jason.Encode.opts()) :: iodata()"},
+		{"decode/2", "Function", "@spec decode(iodata(), [decode_opt()]) :: {:ok, term()} | {:error, Jason.DecodeError.t()}\n@spec decode(iodata(), map()) :: {:ok, term()}"},
+		{"old/1", "Function", "old(x)"},
+		{"sigil_J/2", "Macro", "sigil_J(term, modifiers)"},
+	}
+	if len(page.Items) != len(want) {
+		t.Fatalf("items = %+v", page.Items)
+	}
+	for i, w := range want {
+		it := page.Items[i]
+		if it.Name != w.name || it.Kind != w.kind || it.Signature() != w.signature {
+			t.Errorf("item %d = %q %q %q, want %q %q %q", i, it.Name, it.Kind, it.Signature(), w.name, w.kind, w.signature)
+		}
+	}
+	if page.Items[3].Deprecate != "This function is deprecated. Use decode/2 instead." {
+		t.Errorf("deprecation = %q", page.Items[3].Deprecate)
+	}
+	if page.Items[2].Summary != "Parses a JSON value from input iodata." {
+		t.Errorf("decode summary = %q", page.Items[2].Summary)
+	}
+
+	md := page.render()
+	for _, want := range []string{
+		"# Jason\n",
+		"## Examples",
+		"```elixir\nJason.encode!(%{})\n```",
+		"## Types\n\n### decode_opt()\n\n```elixir\n@type decode_opt() :: {:keys, :atoms | :strings}\n```",
+		"## Functions\n\n### decode(input, opts \\\\ [])",
+		"#### Options",
+		"> **Deprecated**: This function is deprecated. Use decode/2 instead.",
+		"## Macros\n\n### sigil_J(term, modifiers)",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	for _, unwanted := range []string{"Summary", "View Source", "jason v1.4.4"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("markdown contains %q:\n%s", unwanted, md)
+		}
+	}
+}
+
+func TestParseExDocExtras(t *testing.T) {
+	tests := []struct {
+		name, html, kind, title, body string
+	}{
+		{
+			"guide",
+			`<body class="page-extra"><div id="content"><div id="top-content"><h1>Getting Started</h1><p>Install it.</p><h2>Usage</h2><p>Call it.</p></div></div></body>`,
+			"Guide", "Getting Started", "Install it.\n\n## Usage\n\nCall it.",
+		},
+		{
+			"cheatsheet",
+			`<body class="page-cheatmd"><div id="content"><div id="top-content"><h1>Enum cheatsheet</h1><section class="h2"><h2>Predicates</h2><p>any?</p></section></div></div></body>`,
+			"Cheatsheet", "Enum cheatsheet", "## Predicates\n\nany?",
+		},
+		{
+			"livebook",
+			`<body><div id="content"><div id="top-content"><h1>Intro</h1><div class="livebook-badge-container"><a href="#" class="livebook-badge"><img alt="Run in Livebook"></a></div><p>Run me.</p></div></div></body>`,
+			"Livebook", "Intro", "Run me.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := parseExDocPage(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Kind != tt.kind || page.Title != tt.title || page.Markdown != tt.body {
+				t.Errorf("page = %q %q %q, want %q %q %q", page.Kind, page.Title, page.Markdown, tt.kind, tt.title, tt.body)
+			}
+		})
+	}
+}
+
+func TestDemoteHeadings(t *testing.T) {
+	md := "## Options\n\n```\n# not a heading\n```\n\n### Detail"
+	got := strings.Split(demoteHeadings(md, 4), "\n")
+	want := []string{"#### Options", "", "```", "# not a heading", "```", "", "##### Detail"}
+	if !slices.Equal(got, want) {
+		t.Errorf("demoteHeadings() = %q, want %q", got, want)
+	}
+}
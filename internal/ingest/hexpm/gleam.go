@@ -0,0 +1,438 @@
+package hexpm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// Gleam package-interface.json structures
+type GleamInterface struct {
+	Name    string                 `json:"name"`
+	Version string                 `json:"version"`
+	Modules map[string]GleamModule `json:"modules"`
+}
+
+type DocString []string
+
+func (d *DocString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*d = []string{s}
+		return nil
+	}
+	var sli []string
+	if err := json.Unmarshal(data, &sli); err != nil {
+		return err
+	}
+	*d = sli
+	return nil
+}
+
+func (d DocString) String() string {
+	return strings.Join(d, "")
+}
+
+// Markdown returns the doc comment with the space that follows each "///"
+// removed, so indented code blocks and headings are recognized.
+func (d DocString) Markdown() string {
+	lines := strings.Split(d.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// GleamDeprecation is the message of a @deprecated item. The compiler writes
+// it as {"message": "..."}; a bare string is accepted too.
+type GleamDeprecation struct {
+	Message string `json:"message"`
+}
+
+func (d *GleamDeprecation) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &d.Message)
+	}
+	type plain GleamDeprecation
+	return json.Unmarshal(data, (*plain)(d))
+}
+
+type GleamModule struct {
+	Documentation DocString                `json:"documentation"`
+	Types         map[string]GleamTypeDef  `json:"types"`
+	TypeAliases   map[string]GleamAlias    `json:"type-aliases"`
+	Functions     map[string]GleamFunction `json:"functions"`
+	Constants     map[string]GleamConstant `json:"constants"`
+}
+
+type GleamTypeDef struct {
+	Documentation DocString          `json:"documentation"`
+	Deprecation   *GleamDeprecation  `json:"deprecation"`
+	Parameters    int                `json:"parameters"`
+	Constructors  []GleamConstructor `json:"constructors"`
+	Opaque        bool               `json:"opaque"`
+}
+
+type GleamConstructor struct {
+	Documentation DocString    `json:"documentation"`
+	Name          string       `json:"name"`
+	Parameters    []GleamParam `json:"parameters"`
+}
+
+type GleamAlias struct {
+	Documentation DocString         `json:"documentation"`
+	Deprecation   *GleamDeprecation `json:"deprecation"`
+	Parameters    int               `json:"parameters"`
+	Alias         GleamTypeExpr     `json:"alias"`
+}
+
+type GleamConstant struct {
+	Documentation DocString         `json:"documentation"`
+	Deprecation   *GleamDeprecation `json:"deprecation"`
+	Type          GleamTypeExpr     `json:"type"`
+}
+
+type GleamFunction struct {
+	Documentation   DocString             `json:"documentation"`
+	Deprecation     *GleamDeprecation     `json:"deprecation"`
+	Implementations *GleamImplementations `json:"implementations"`
+	Parameters      []GleamParam          `json:"parameters"`
+	Return          GleamTypeExpr         `json:"return"`
+}
+
+// GleamImplementations describes which targets a function runs on and
+// whether it is implemented with external (FFI) code.
+type GleamImplementations struct {
+	Gleam                   bool `json:"gleam"`
+	UsesErlangExternals     bool `json:"uses_erlang_externals"`
+	UsesJavascriptExternals bool `json:"uses_javascript_externals"`
+	CanRunOnErlang          bool `json:"can_run_on_erlang"`
+	CanRunOnJavascript      bool `json:"can_run_on_javascript"`
+}
+
+// note describes externals and target restrictions, or "" for a pure Gleam
+// function that runs everywhere.
+func (i *GleamImplementations) note() string {
+	if i == nil {
+		return ""
+	}
+	var externals []string
+	if i.UsesErlangExternals {
+		externals = append(externals, "Erlang")
+	}
+	if i.UsesJavascriptExternals {
+		externals = append(externals, "JavaScript")
+	}
+	var parts []string
+	if len(externals) > 0 {
+		parts = append(parts, "External "+strings.Join(externals, " and ")+" implementation")
+	}
+	switch {
+	case i.CanRunOnErlang && !i.CanRunOnJavascript:
+		parts = append(parts, "Erlang target only")
+	case i.CanRunOnJavascript && !i.CanRunOnErlang:
+		parts = append(parts, "JavaScript target only")
+	}
+	return strings.Join(parts, "; ")
+}
+
+type GleamParam struct {
+	Label *string       `json:"label"`
+	Type  GleamTypeExpr `json:"type"`
+}
+
+type GleamTypeExpr struct {
+	Kind       string          `json:"kind"`
+	Name       string          `json:"name,omitempty"`
+	Module     string          `json:"module,omitempty"`
+	Package    string          `json:"package,omitempty"`
+	Parameters []GleamTypeExpr `json:"parameters,omitempty"`
+	Elements   []GleamTypeExpr `json:"elements,omitempty"`
+	ID         int             `json:"id,omitempty"`
+	Return     *GleamTypeExpr  `json:"return,omitempty"`
+}
+
+// gleamItem is a public item of a module, in the order it is rendered.
+type gleamItem struct {
+	Name        string
+	Kind        string
+	Signature   string
+	Docs        string
+	Deprecation string
+	Targets     string
+}
+
+// gleamSections orders the item sections of a module document.
+var gleamSections = []struct{ kind, title string }{
+	{"Type", "Types"},
+	{"TypeAlias", "Type Aliases"},
+	{"Constant", "Constants"},
+	{"Function", "Functions"},
+}
+
+// gleamItems collects a module's public items, sorted by name within each
+// kind so that the rendered document (and its hash) is stable across runs.
+func gleamItems(mod GleamModule) []gleamItem {
+	var items []gleamItem
+	for _, name := range slices.Sorted(maps.Keys(mod.Types)) {
+		td := mod.Types[name]
+		items = append(items, gleamItem{name, "Type", renderGleamTypeDef(name, td), td.Documentation.Markdown(), deprecationMessage(td.Deprecation), ""})
+	}
+	for _, name := range slices.Sorted(maps.Keys(mod.TypeAliases)) {
+		ta := mod.TypeAliases[name]
+		items = append(items, gleamItem{name, "TypeAlias", renderGleamAlias(name, ta), ta.Documentation.Markdown(), deprecationMessage(ta.Deprecation), ""})
+	}
+	for _, name := range slices.Sorted(maps.Keys(mod.Constants)) {
+		c := mod.Constants[name]
+		sig := "const " + name + ": " + renderGleamType(c.Type, map[int]string{})
+		items = append(items, gleamItem{name, "Constant", sig, c.Documentation.Markdown(), deprecationMessage(c.Deprecation), ""})
+	}
+	for _, name := range slices.Sorted(maps.Keys(mod.Functions)) {
+		fn := mod.Functions[name]
+		items = append(items, gleamItem{name, "Function", renderGleamSignature(name, fn), fn.Documentation.Markdown(), deprecationMessage(fn.Deprecation), fn.Implementations.note()})
+	}
+	return items
+}
+
+func deprecationMessage(d *GleamDeprecation) string {
+	if d == nil {
+		return ""
+	}
+	if d.Message == "" {
+		return "deprecated"
+	}
+	return d.Message
+}
+
+func renderGleamModule(modName string, mod GleamModule, items []gleamItem) string {
+	var b strings.Builder
+	b.WriteString("# " + modName + "\n\n")
+	if modDoc := mod.Documentation.Markdown(); modDoc != "" {
+		b.WriteString(demoteHeadings(modDoc, 2) + "\n\n")
+	}
+
+	for _, section := range gleamSections {
+		first := true
+		for _, it := range items {
+			if it.Kind != section.kind {
+				continue
+			}
+			if first {
+				b.WriteString("## " + section.title + "\n\n")
+				first = false
+			}
+			b.WriteString("### " + it.Name + "\n\n")
+			b.WriteString("```gleam\n" + it.Signature + "\n```\n\n")
+			if it.Deprecation != "" {
+				b.WriteString("> **Deprecated**: " + it.Deprecation + "\n\n")
+			}
+			if it.Targets != "" {
+				b.WriteString("> " + it.Targets + ".\n\n")
+			}
+			if it.Docs != "" {
+				b.WriteString(demoteHeadings(it.Docs, 4) + "\n\n")
+			}
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func ingestGleam(ctx context.Context, tx *sql.Tx, pkgName string, interfacePath string) error {
+	data, err := os.ReadFile(interfacePath)
+	if err != nil {
+		return err
+	}
+
+	var iface GleamInterface
+	if err := json.Unmarshal(data, &iface); err != nil {
+		return err
+	}
+
+	for _, modName := range slices.Sorted(maps.Keys(iface.Modules)) {
+		mod := iface.Modules[modName]
+		items := gleamItems(mod)
+		md := renderGleamModule(modName, mod, items)
+
+		docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
+			Path:   "hex/" + pkgName + "/" + modName,
+			Format: "markdown",
+			Body:   shared.Compress(md),
+			Hash:   db.HashBytes([]byte(md)),
+		})
+		if err != nil {
+			return err
+		}
+
+		modDoc := mod.Documentation.Markdown()
+		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+			Name:  modName,
+			Type:  "Module",
+			Body:  modName + " " + modDoc,
+			DocID: docID,
+		}); err != nil {
+			return err
+		}
+		if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    modName,
+			Signature: "import " + modName,
+			Summary:   shared.FirstLine(modDoc),
+		}); err != nil {
+			return err
+		}
+
+		for _, it := range items {
+			symbol := modName + "." + it.Name
+			summary := shared.FirstLine(it.Docs)
+			if it.Deprecation != "" {
+				summary = strings.TrimSpace(summary + " (deprecated: " + it.Deprecation + ")")
+			}
+			if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+				Name:  symbol,
+				Type:  it.Kind,
+				Body:  symbol + " " + it.Signature + " " + it.Docs,
+				DocID: docID,
+			}); err != nil {
+				return err
+			}
+			if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+				DocID:     docID,
+				Symbol:    symbol,
+				Signature: it.Signature,
+				Summary:   summary,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+var gleamVarNames = []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+// renderGleamType converts a GleamTypeExpr to Gleam type syntax.
+func renderGleamType(t GleamTypeExpr, vars map[int]string) string {
+	switch t.Kind {
+	case "named":
+		base := t.Name
+		if len(t.Parameters) > 0 {
+			params := make([]string, len(t.Parameters))
+			for i, p := range t.Parameters {
+				params[i] = renderGleamType(p, vars)
+			}
+			base += "(" + strings.Join(params, ", ") + ")"
+		}
+		return base
+	case "variable":
+		if name, ok := vars[t.ID]; ok {
+			return name
+		}
+		return gleamVarName(t.ID)
+	case "fn":
+		params := make([]string, len(t.Parameters))
+		for i, p := range t.Parameters {
+			params[i] = renderGleamType(p, vars)
+		}
+		ret := "Nil"
+		if t.Return != nil {
+			ret = renderGleamType(*t.Return, vars)
+		}
+		return "fn(" + strings.Join(params, ", ") + ") -> " + ret
+	case "tuple":
+		elems := make([]string, len(t.Elements))
+		for i, e := range t.Elements {
+			elems[i] = renderGleamType(e, vars)
+		}
+		return "#(" + strings.Join(elems, ", ") + ")"
+	default:
+		return "?"
+	}
+}
+
+func gleamVarName(id int) string {
+	if id >= 0 && id < len(gleamVarNames) {
+		return gleamVarNames[id]
+	}
+	return fmt.Sprintf("t%d", id)
+}
+
+// renderGleamParams renders parameters with their labels, the names callers
+// use for labelled arguments: fn(list, with: fn(a) -> b).
+func renderGleamParams(params []GleamParam, vars map[int]string) string {
+	out := make([]string, len(params))
+	for i, p := range params {
+		typeStr := renderGleamType(p.Type, vars)
+		if p.Label != nil && *p.Label != "" {
+			out[i] = *p.Label + ": " + typeStr
+		} else {
+			out[i] = typeStr
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// renderGleamSignature builds a Gleam function signature string.
+func renderGleamSignature(name string, fn GleamFunction) string {
+	vars := make(map[int]string)
+	params := renderGleamParams(fn.Parameters, vars)
+	ret := renderGleamType(fn.Return, vars)
+	return "pub fn " + name + "(" + params + ") -> " + ret
+}
+
+// gleamTypeParams returns the parameter list of a generic type or alias,
+// e.g. "(a, b)", registering the names in vars.
+func gleamTypeParams(n int, vars map[int]string) string {
+	if n == 0 {
+		return ""
+	}
+	names := make([]string, n)
+	for i := range names {
+		names[i] = gleamVarName(i)
+		vars[i] = names[i]
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func renderGleamAlias(name string, ta GleamAlias) string {
+	vars := make(map[int]string)
+	params := gleamTypeParams(ta.Parameters, vars)
+	return "pub type " + name + params + " = " + renderGleamType(ta.Alias, vars)
+}
+
+// renderGleamTypeDef builds a type definition string with constructors.
+// Opaque types hide their constructors.
+func renderGleamTypeDef(name string, td GleamTypeDef) string {
+	vars := make(map[int]string)
+	var sb strings.Builder
+	if td.Opaque {
+		sb.WriteString("pub opaque type ")
+	} else {
+		sb.WriteString("pub type ")
+	}
+	sb.WriteString(name)
+	sb.WriteString(gleamTypeParams(td.Parameters, vars))
+	if len(td.Constructors) > 0 && !td.Opaque {
+		sb.WriteString(" {\n")
+		for _, c := range td.Constructors {
+			sb.WriteString("  " + c.Name)
+			if len(c.Parameters) > 0 {
+				sb.WriteString("(" + renderGleamParams(c.Parameters, vars) + ")")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}")
+	}
+	return sb.String()
+}
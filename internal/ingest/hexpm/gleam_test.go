@@ -0,0 +1,139 @@
+package hexpm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// gleamInterfaceFixture is a trimmed package-interface.json with a type, an
+// opaque type, an alias, a constant, a deprecated function and an external
+// function with labelled parameters.
+const gleamInterfaceFixture = `{
+  "name": "demo",
+  "version": "1.0.0",
+  "modules": {
+    "demo/list": {
+      "documentation": [" Lists.\n"],
+      "types": {
+        "Pair": {
+          "documentation": null,
+          "deprecation": null,
+          "parameters": 2,
+          "constructors": [{"documentation": null, "name": "Pair", "parameters": [
+            {"label": "first", "type": {"kind": "variable", "id": 0}},
+            {"label": null, "type": {"kind": "variable", "id": 1}}
+          ]}]
+        },
+        "Box": {
+          "documentation": " An opaque box.",
+          "deprecation": null,
+          "parameters": 0,
+          "opaque": true,
+          "constructors": []
+        }
+      },
+      "type-aliases": {
+        "Ints": {
+          "documentation": null,
+          "deprecation": null,
+          "parameters": 0,
+          "alias": {"kind": "named", "name": "List", "module": "gleam", "package": "", "parameters": [{"kind": "named", "name": "Int", "module": "gleam", "package": "", "parameters": []}]}
+        }
+      },
+      "constants": {
+        "max": {
+          "documentation": " The largest size.",
+          "deprecation": null,
+          "type": {"kind": "named", "name": "Int", "module": "gleam", "package": "", "parameters": []}
+        }
+      },
+      "functions": {
+        "map": {
+          "documentation": " Maps a list.\n\n ## Examples\n",
+          "deprecation": null,
+          "implementations": {"gleam": false, "uses_erlang_externals": true, "uses_javascript_externals": false, "can_run_on_erlang": true, "can_run_on_javascript": false},
+          "parameters": [
+            {"label": null, "type": {"kind": "named", "name": "List", "module": "gleam", "package": "", "parameters": [{"kind": "variable", "id": 0}]}},
+            {"label": "with", "type": {"kind": "fn", "parameters": [{"kind": "variable", "id": 0}], "return": {"kind": "variable", "id": 1}}}
+          ],
+          "return": {"kind": "named", "name": "List", "module": "gleam", "package": "", "parameters": [{"kind": "variable", "id": 1}]}
+        },
+        "flatten": {
+          "documentation": " Flattens.",
+          "deprecation": {"message": "Use concat instead"},
+          "parameters": [],
+          "return": {"kind": "tuple", "elements": []}
+        }
+      }
+    }
+  }
+}`
+
+func TestGleamModuleRender(t *testing.T) {
+	var iface GleamInterface
+	if err := json.Unmarshal([]byte(gleamInterfaceFixture), &iface); err != nil {
+		t.Fatal(err)
+	}
+	mod := iface.Modules["demo/list"]
+	items := gleamItems(mod)
+
+	want := []struct{ name, kind, signature string }{
+		{"Box", "Type", "pub opaque type Box"},
+		{"Pair", "Type", "pub type Pair(a, b) {\n  Pair(first: a, b)\n}"},
+		{"Ints", "TypeAlias", "pub type Ints = List(Int)"},
+		{"max", "Constant", "const max: Int"},
+		{"flatten", "Function", "pub fn flatten() -> #()"},
+		{"map", "Function", "pub fn map(List(a), with: fn(a) -> b) -> List(b)"},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v", items)
+	}
+	for i, w := range want {
+		it := items[i]
+		if it.Name != w.name || it.Kind != w.kind || it.Signature != w.signature {
+			t.Errorf("item %d = %q %q %q, want %q %q %q", i, it.Name, it.Kind, it.Signature, w.name, w.kind, w.signature)
+		}
+	}
+	if items[4].Deprecation != "Use concat instead" {
+		t.Errorf("deprecation = %q", items[4].Deprecation)
+	}
+
+	md := renderGleamModule("demo/list", mod, items)
+	for i := 0; i < 10; i++ {
+		if again := renderGleamModule("demo/list", mod, gleamItems(mod)); again != md {
+			t.Fatalf("render is not deterministic:\n%s\n---\n%s", md, again)
+		}
+	}
+	for _, want := range []string{
+		"## Constants\n\n### max\n\n```gleam\nconst max: Int\n```\n\nThe largest size.",
+		"> **Deprecated**: Use concat instead",
+		"> External Erlang implementation; Erlang target only.",
+		"#### Examples",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	order := []string{"## Types", "### Box", "### Pair", "## Type Aliases", "## Constants", "## Functions", "### flatten", "### map"}
+	last := -1
+	for _, heading := range order {
+		i := strings.Index(md, heading)
+		if i <= last {
+			t.Fatalf("%q out of order:\n%s", heading, md)
+		}
+		last = i
+	}
+}
+
+func TestGleamDeprecation(t *testing.T) {
+	for _, data := range []string{`"gone"`, `{"message": "gone"}`} {
+		var d GleamDeprecation
+		if err := json.Unmarshal([]byte(data), &d); err != nil {
+			t.Fatal(err)
+		}
+		if d.Message != "gone" {
+			t.Errorf("%s: message = %q", data, d.Message)
+		}
+	}
+}
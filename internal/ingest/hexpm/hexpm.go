@@ -28,92 +28,6 @@ type Options struct {
 	Cache   *cache.FilesystemCache
 }
 
-// Gleam package-interface.json structures
-type GleamInterface struct {
-	Name    string                 `json:"name"`
-	Version string                 `json:"version"`
-	Modules map[string]GleamModule `json:"modules"`
-}
-
-type DocString []string
-
-func (d *DocString) UnmarshalJSON(data []byte) error {
-	if len(data) > 0 && data[0] == '"' {
-		var s string
-		if err := json.Unmarshal(data, &s); err != nil {
-			return err
-		}
-		*d = []string{s}
-		return nil
-	}
-	var sli []string
-	if err := json.Unmarshal(data, &sli); err != nil {
-		return err
-	}
-	*d = sli
-	return nil
-}
-
-func (d DocString) String() string {
-	return strings.Join(d, "")
-}
-
-type GleamModule struct {
-	Documentation DocString                `json:"documentation"`
-	Types         map[string]GleamTypeDef  `json:"types"`
-	TypeAliases   map[string]GleamAlias    `json:"type-aliases"`
-	Functions     map[string]GleamFunction `json:"functions"`
-	Constants     map[string]GleamConstant `json:"constants"`
-}
-
-type GleamTypeDef struct {
-	Documentation DocString          `json:"documentation"`
-	Deprecation   *string            `json:"deprecation"`
-	Parameters    int                `json:"parameters"`
-	Constructors  []GleamConstructor `json:"constructors"`
-}
-
-type GleamConstructor struct {
-	Name       string       `json:"name"`
-	Parameters []GleamParam `json:"parameters"`
-}
-
-type GleamAlias struct {
-	Documentation DocString     `json:"documentation"`
-	Deprecation   *string       `json:"deprecation"`
-	Parameters    int           `json:"parameters"`
-	Alias         GleamTypeExpr `json:"alias"`
-}
-
-type GleamConstant struct {
-	Documentation DocString     `json:"documentation"`
-	Deprecation   *string       `json:"deprecation"`
-	Type          GleamTypeExpr `json:"type"`
-}
-
-type GleamFunction struct {
-	Documentation DocString     `json:"documentation"`
-	Deprecation   *string       `json:"deprecation"`
-	Parameters    []GleamParam  `json:"parameters"`
-	Return        GleamTypeExpr `json:"return"`
-}
-
-type GleamParam struct {
-	Label *string       `json:"label"`
-	Type  GleamTypeExpr `json:"type"`
-}
-
-type GleamTypeExpr struct {
-	Kind       string          `json:"kind"`
-	Name       string          `json:"name,omitempty"`
-	Module     string          `json:"module,omitempty"`
-	Package    string          `json:"package,omitempty"`
-	Parameters []GleamTypeExpr `json:"parameters,omitempty"`
-	Elements   []GleamTypeExpr `json:"elements,omitempty"`
-	ID         int             `json:"id,omitempty"`
-	Return     *GleamTypeExpr  `json:"return,omitempty"`
-}
-
 // Elixir structures
 type SearchData struct {
 	Items []SearchItem `json:"items"`
@@ -307,66 +221,62 @@ func untar(tarPath, dest string) error {
 	return nil
 }
 
-func ingestGleam(ctx context.Context, tx *sql.Tx, pkgName string, interfacePath string) error {
-	data, err := os.ReadFile(interfacePath)
+// exdocSkipPages are generated pages with nothing to index.
+var exdocSkipPages = map[string]bool{
+	"index.html":         true,
+	"search.html":        true,
+	"404.html":           true,
+	"api-reference.html": true,
+}
+
+// ingestElixir converts the HTML pages of an ExDoc tarball to Markdown. Module
+// pages are indexed with their types, callbacks, functions and macros, whose
+// specs go into agent_context; extras become Guide, Cheatsheet or Livebook
+// documents. search_data names the page kinds (module, behaviour, protocol,
+// exception, task) and mix task titles when it is present.
+func ingestElixir(ctx context.Context, tx *sql.Tx, pkgName string, tmpDir string) error {
+	pages, err := filepath.Glob(filepath.Join(tmpDir, "*.html"))
 	if err != nil {
 		return err
 	}
-
-	var iface GleamInterface
-	if err := json.Unmarshal(data, &iface); err != nil {
-		return err
+	searchItems, err := loadSearchData(tmpDir)
+	if err != nil {
+		log.Warn("could not read search_data, using page markup only", "package", pkgName, "err", err)
 	}
 
-	for modName, mod := range iface.Modules {
-		docPath := "hex/" + pkgName + "/" + modName
-
-		var docBuilder strings.Builder
-		docBuilder.WriteString("# " + modName + "\n\n")
-		if modDoc := mod.Documentation.String(); modDoc != "" {
-			docBuilder.WriteString(modDoc + "\n\n")
+	ingested := 0
+	for _, pagePath := range pages {
+		ref := filepath.Base(pagePath)
+		if exdocSkipPages[ref] {
+			continue
 		}
 
-		if len(mod.Types) > 0 {
-			docBuilder.WriteString("## Types\n\n")
-			for typeName, td := range mod.Types {
-				sig := renderGleamTypeDef(typeName, td)
-				docBuilder.WriteString("### " + typeName + "\n\n")
-				docBuilder.WriteString("```gleam\n" + sig + "\n```\n\n")
-				if typeDoc := td.Documentation.String(); typeDoc != "" {
-					docBuilder.WriteString(typeDoc + "\n\n")
-				}
-			}
+		f, err := os.Open(pagePath)
+		if err != nil {
+			return err
 		}
-
-		if len(mod.TypeAliases) > 0 {
-			docBuilder.WriteString("## Type Aliases\n\n")
-			for aliasName, ta := range mod.TypeAliases {
-				vars := make(map[int]string)
-				aliasType := renderGleamType(ta.Alias, vars)
-				docBuilder.WriteString("### " + aliasName + "\n\n")
-				docBuilder.WriteString("```gleam\ntype " + aliasName + " = " + aliasType + "\n```\n\n")
-				if aliasDoc := ta.Documentation.String(); aliasDoc != "" {
-					docBuilder.WriteString(aliasDoc + "\n\n")
-				}
-			}
+		page, err := parseExDocPage(f)
+		f.Close()
+		if err != nil {
+			log.Warn("failed to parse exdoc page", "file", pagePath, "err", err)
+			continue
 		}
 
-		if len(mod.Functions) > 0 {
-			docBuilder.WriteString("## Functions\n\n")
-			for fnName, fn := range mod.Functions {
-				sig := renderGleamSignature(fnName, fn)
-				docBuilder.WriteString("### " + fnName + "\n\n")
-				docBuilder.WriteString("```gleam\n" + sig + "\n```\n\n")
-				if fnDoc := fn.Documentation.String(); fnDoc != "" {
-					docBuilder.WriteString(fnDoc + "\n\n")
-				}
+		moduleName := strings.TrimSuffix(ref, ".html")
+		name, kind := page.Title, page.Kind
+		if item, ok := searchItems[ref]; ok {
+			if item.Type != "extras" {
+				kind = shared.Capitalize(item.Type)
+			}
+			if item.Title != "" {
+				name = item.Title
 			}
 		}
+		page.Title = name
 
-		md := docBuilder.String()
+		md := page.render()
 		docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
-			Path:   docPath,
+			Path:   "hex/" + pkgName + "/" + moduleName,
 			Format: "markdown",
 			Body:   shared.Compress(md),
 			Hash:   db.HashBytes([]byte(md)),
@@ -374,264 +284,90 @@ func ingestGleam(ctx context.Context, tx *sql.Tx, pkgName string, interfacePath
 		if err != nil {
 			return err
 		}
+		ingested++
 
 		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
-			Name:  modName,
-			Type:  "Module",
-			Body:  modName + " " + mod.Documentation.String(),
+			Name:  name,
+			Type:  kind,
+			Body:  name + " " + page.Markdown,
 			DocID: docID,
 		}); err != nil {
 			return err
 		}
+		if page.Kind != "Module" {
+			continue
+		}
 
-		for fnName, fn := range mod.Functions {
-			symbol := modName + "." + fnName
-			sig := renderGleamSignature(fnName, fn)
-			fnDoc := fn.Documentation.String()
-			if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
-				Name:  symbol,
-				Type:  "Function",
-				Body:  symbol + " " + sig + " " + fnDoc,
-				DocID: docID,
-			}); err != nil {
-				return err
-			}
-
-			if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
-				DocID:     docID,
-				Symbol:    symbol,
-				Signature: sig,
-				Summary:   shared.FirstLine(fnDoc),
-			}); err != nil {
-				return err
-			}
+		if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    name,
+			Signature: "defmodule " + moduleName,
+			Summary:   page.Summary,
+		}); err != nil {
+			return err
 		}
 
-		for typeName, td := range mod.Types {
-			symbol := modName + "." + typeName
-			sig := renderGleamTypeDef(typeName, td)
-			typeDoc := td.Documentation.String()
+		for _, it := range page.Items {
+			symbol := moduleName + "." + it.Name
+			summary := it.Summary
+			if it.Deprecate != "" {
+				summary = strings.TrimSpace(summary + " (deprecated)")
+			}
 			if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
 				Name:  symbol,
-				Type:  "Type",
-				Body:  symbol + " " + sig + " " + typeDoc,
+				Type:  it.Kind,
+				Body:  symbol + " " + it.Signature() + " " + it.Markdown,
 				DocID: docID,
 			}); err != nil {
 				return err
 			}
-
 			if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
 				DocID:     docID,
 				Symbol:    symbol,
-				Signature: sig,
-				Summary:   shared.FirstLine(typeDoc),
-			}); err != nil {
-				return err
-			}
-		}
-
-		for aliasName, ta := range mod.TypeAliases {
-			symbol := modName + "." + aliasName
-			vars := make(map[int]string)
-			sig := "type " + aliasName + " = " + renderGleamType(ta.Alias, vars)
-			aliasDoc := ta.Documentation.String()
-			if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
-				Name:  symbol,
-				Type:  "TypeAlias",
-				Body:  symbol + " " + sig + " " + aliasDoc,
-				DocID: docID,
+				Signature: it.Signature(),
+				Summary:   summary,
 			}); err != nil {
 				return err
 			}
 		}
 	}
 
+	if ingested == 0 {
+		return errors.New("no exdoc pages found in doc tarball")
+	}
 	return nil
 }
 
-func ingestElixir(ctx context.Context, tx *sql.Tx, pkgName string, tmpDir string) error {
+// loadSearchData reads ExDoc's dist/search_data-*.js and returns the page
+// level entries (those without an anchor) by page file name.
+func loadSearchData(tmpDir string) (map[string]SearchItem, error) {
 	matches, err := filepath.Glob(filepath.Join(tmpDir, "dist", "search_data-*.js"))
 	if err != nil || len(matches) == 0 {
-		return errors.New("could not find search_data in doc tarball")
+		return nil, errors.New("could not find search_data in doc tarball")
 	}
-	searchDataPath := matches[0]
 
-	data, err := os.ReadFile(searchDataPath)
+	data, err := os.ReadFile(matches[0])
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	re := regexp.MustCompile(`searchData\s*=\s*({.*})`)
-	match := re.FindSubmatch(data)
+	match := searchDataPattern.FindSubmatch(data)
 	if len(match) < 2 {
-		return errors.New("could not parse searchData JS")
+		return nil, errors.New("could not parse searchData JS")
 	}
 
 	var searchData SearchData
 	if err := json.Unmarshal(match[1], &searchData); err != nil {
-		return err
+		return nil, err
 	}
 
-	pages := make(map[string][]SearchItem)
+	pages := make(map[string]SearchItem)
 	for _, item := range searchData.Items {
-		ref := strings.Split(item.Ref, "#")[0]
-		pages[ref] = append(pages[ref], item)
-	}
-
-	for ref, items := range pages {
-		docPath := "hex/" + pkgName + "/" + strings.TrimSuffix(ref, ".html")
-
-		var pageDoc string
-		for _, item := range items {
-			if !strings.Contains(item.Ref, "#") {
-				pageDoc = item.Doc
-				break
-			}
-		}
-
-		if pageDoc == "" && len(items) > 0 {
-			pageDoc = items[0].Doc
-		}
-
-		docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
-			Path:   docPath,
-			Format: "markdown",
-			Body:   shared.Compress(pageDoc),
-			Hash:   db.HashBytes([]byte(pageDoc)),
-		})
-		if err != nil {
-			return err
-		}
-
-		for _, item := range items {
-			name := item.Title
-			if item.Type == "task" {
-				name = "mix " + name
-			}
-
-			if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
-				Name:  name,
-				Type:  shared.Capitalize(item.Type),
-				Body:  name + " " + item.Doc,
-				DocID: docID,
-			}); err != nil {
-				return err
-			}
-
-			if item.Type != "module" && item.Type != "extras" {
-				if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
-					DocID:     docID,
-					Symbol:    name,
-					Signature: name,
-					Summary:   shared.FirstLine(item.Doc),
-				}); err != nil {
-					return err
-				}
-			}
-		}
-	}
-
-	return nil
-}
-
-// renderGleamType converts a GleamTypeExpr to Gleam type syntax.
-func renderGleamType(t GleamTypeExpr, vars map[int]string) string {
-	switch t.Kind {
-	case "named":
-		base := t.Name
-		if len(t.Parameters) > 0 {
-			params := make([]string, len(t.Parameters))
-			for i, p := range t.Parameters {
-				params[i] = renderGleamType(p, vars)
-			}
-			base += "(" + strings.Join(params, ", ") + ")"
-		}
-		return base
-	case "variable":
-		if name, ok := vars[t.ID]; ok {
-			return name
-		}
-		varNames := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
-		if t.ID < len(varNames) {
-			return varNames[t.ID]
-		}
-		return fmt.Sprintf("t%d", t.ID)
-	case "fn":
-		params := make([]string, len(t.Parameters))
-		for i, p := range t.Parameters {
-			params[i] = renderGleamType(p, vars)
-		}
-		ret := "Nil"
-		if t.Return != nil {
-			ret = renderGleamType(*t.Return, vars)
-		}
-		return "fn(" + strings.Join(params, ", ") + ") -> " + ret
-	case "tuple":
-		elems := make([]string, len(t.Elements))
-		for i, e := range t.Elements {
-			elems[i] = renderGleamType(e, vars)
-		}
-		return "#(" + strings.Join(elems, ", ") + ")"
-	default:
-		return "?"
-	}
-}
-
-// renderGleamSignature builds a Gleam function signature string.
-func renderGleamSignature(name string, fn GleamFunction) string {
-	vars := make(map[int]string)
-	params := make([]string, len(fn.Parameters))
-	for i, p := range fn.Parameters {
-		typeStr := renderGleamType(p.Type, vars)
-		if p.Label != nil && *p.Label != "" {
-			params[i] = *p.Label + " " + typeStr
-		} else {
-			params[i] = typeStr
+		if !strings.Contains(item.Ref, "#") {
+			pages[item.Ref] = item
 		}
 	}
-	ret := renderGleamType(fn.Return, vars)
-	return "fn " + name + "(" + strings.Join(params, ", ") + ") -> " + ret
+	return pages, nil
 }
 
-// renderGleamTypeDef builds a type definition string with constructors.
-func renderGleamTypeDef(name string, td GleamTypeDef) string {
-	vars := make(map[int]string)
-	var sb strings.Builder
-	sb.WriteString("type ")
-	sb.WriteString(name)
-	if td.Parameters > 0 {
-		typeParams := make([]string, td.Parameters)
-		varNames := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
-		for i := 0; i < td.Parameters; i++ {
-			if i < len(varNames) {
-				typeParams[i] = varNames[i]
-				vars[i] = varNames[i]
-			} else {
-				typeParams[i] = fmt.Sprintf("t%d", i)
-				vars[i] = typeParams[i]
-			}
-		}
-		sb.WriteString("(" + strings.Join(typeParams, ", ") + ")")
-	}
-	if len(td.Constructors) > 0 {
-		sb.WriteString(" {\n")
-		for _, c := range td.Constructors {
-			sb.WriteString("  " + c.Name)
-			if len(c.Parameters) > 0 {
-				cParams := make([]string, len(c.Parameters))
-				for i, p := range c.Parameters {
-					typeStr := renderGleamType(p.Type, vars)
-					if p.Label != nil && *p.Label != "" {
-						cParams[i] = *p.Label + ": " + typeStr
-					} else {
-						cParams[i] = typeStr
-					}
-				}
-				sb.WriteString("(" + strings.Join(cParams, ", ") + ")")
-			}
-			sb.WriteString("\n")
-		}
-		sb.WriteString("}")
-	}
-	return sb.String()
-}
+var searchDataPattern = regexp.MustCompile(`searchData\s*=\s*({.*})`)
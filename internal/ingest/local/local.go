@@ -0,0 +1,231 @@
+// Package local ingests directories of Markdown, MDX, reStructuredText,
+// AsciiDoc and Org-mode files on the local filesystem, such as design docs,
+// runbooks and notes kept next to the code, and keeps them up to date as the
+// files change.
+package local
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+)
+
+// SourceKind is the kind local directories are registered under in the
+// sources table.
+const SourceKind = "local"
+
+type Options struct {
+	// Name is the namespace documents are stored under, local/<name>; empty
+	// uses the directory name.
+	Name string
+	Dir  string
+	// Include and Exclude select documentation files by path glob (see
+	// docset.Filter); a nil Exclude uses docset.DefaultExclude.
+	Include []string
+	Exclude []string
+	DB      *db.Store
+}
+
+// Source is a registered local directory.
+type Source struct {
+	Name    string
+	Dir     string
+	Include []string
+	Exclude []string
+
+	// includes lists the files each document included when it was last
+	// converted, by slash-separated path. Watch sets it so a change to an
+	// included file re-indexes the documents including it.
+	includes map[string][]string
+}
+
+// Stats counts the outcome of a sync.
+type Stats struct {
+	Written   int
+	Unchanged int
+	Removed   int
+}
+
+type sourceOptions struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude"`
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// IngestDirectory registers a directory as a local source, so the watcher
+// picks it up, and syncs it into the database.
+func IngestDirectory(ctx context.Context, opts Options) (Stats, error) {
+	if opts.Dir == "" {
+		return Stats{}, errors.New("directory is required")
+	}
+	if opts.DB == nil {
+		return Stats{}, errors.New("db store is required")
+	}
+
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return Stats{}, err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return Stats{}, fmt.Errorf("not a directory: %s", opts.Dir)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = filepath.Base(dir)
+	}
+	if !validName.MatchString(name) {
+		return Stats{}, fmt.Errorf("invalid source name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+
+	exclude := opts.Exclude
+	if exclude == nil {
+		exclude = docset.DefaultExclude
+	}
+	src := Source{Name: name, Dir: dir, Include: opts.Include, Exclude: exclude}
+
+	options, err := json.Marshal(sourceOptions{Include: src.Include, Exclude: src.Exclude})
+	if err != nil {
+		return Stats{}, err
+	}
+	if err := opts.DB.PutSource(ctx, db.Source{Name: name, Kind: SourceKind, Location: dir, Options: string(options)}); err != nil {
+		return Stats{}, fmt.Errorf("register source: %w", err)
+	}
+
+	return Sync(ctx, opts.DB, src)
+}
+
+// Sources returns the local directories registered in the database.
+func Sources(ctx context.Context, store *db.Store) ([]Source, error) {
+	rows, err := store.Sources(ctx, SourceKind)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]Source, 0, len(rows))
+	for _, row := range rows {
+		var opts sourceOptions
+		if err := json.Unmarshal([]byte(row.Options), &opts); err != nil {
+			return nil, fmt.Errorf("source %s: invalid options: %w", row.Name, err)
+		}
+		sources = append(sources, Source{Name: row.Name, Dir: row.Location, Include: opts.Include, Exclude: opts.Exclude})
+	}
+	return sources, nil
+}
+
+// Prefix is the path prefix of the source's documents.
+func (s Source) Prefix() string {
+	return "local/" + s.Name
+}
+
+func (s Source) filter() docset.Filter {
+	return docset.NewFilter(s.Include, s.Exclude)
+}
+
+// Sync brings the source's documents in line with its directory: new and
+// modified files are converted and written, files whose converted content
+// is unchanged are skipped and documents whose file is gone are removed.
+// The table of contents of a static-site generator's site is rebuilt.
+func Sync(ctx context.Context, store *db.Store, src Source) (Stats, error) {
+	files, err := docset.Walk(src.Dir, src.filter())
+	if err != nil {
+		return Stats{}, err
+	}
+	site := src.site()
+
+	var stats Stats
+	err = store.WithTx(ctx, func(tx *sql.Tx) error {
+		stored, err := db.DocumentHashesTx(ctx, tx, src.Prefix()+"/")
+		if err != nil {
+			return err
+		}
+
+		for _, rel := range files {
+			docPath := src.Prefix() + "/" + rel
+			written, err := writeFile(ctx, tx, src, site, rel, stored[docPath])
+			if err != nil {
+				return err
+			}
+			delete(stored, docPath)
+			if written {
+				stats.Written++
+			} else {
+				stats.Unchanged++
+			}
+		}
+
+		for docPath := range stored {
+			if err := db.DeleteDocumentTx(ctx, tx, docPath); err != nil {
+				return err
+			}
+			stats.Removed++
+		}
+		return docset.IndexSite(ctx, tx, src.Prefix(), site, files)
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	log.Info("local source synced", "name", src.Name, "written", stats.Written, "unchanged", stats.Unchanged, "removed", stats.Removed)
+	return stats, nil
+}
+
+// site reads the navigation of the static-site generator the source's
+// directory is built with, if any. A broken configuration is logged and the
+// source is indexed without a table of contents.
+func (s Source) site() *docset.Site {
+	site, err := docset.DetectSite(s.Dir)
+	if err != nil {
+		log.Warn("failed to read site navigation", "name", s.Name, "err", err)
+	}
+	return site
+}
+
+// writeFile converts a file and writes it unless the result hashes to
+// oldHash. Unreadable files are logged and skipped.
+func writeFile(ctx context.Context, tx *sql.Tx, src Source, site *docset.Site, rel, oldHash string) (bool, error) {
+	content, err := os.ReadFile(filepath.Join(src.Dir, filepath.FromSlash(rel)))
+	if err != nil {
+		log.Warn("failed to read file", "path", rel, "err", err)
+		return false, nil
+	}
+	read := docset.DirReader(src.Dir)
+	if src.includes != nil {
+		var included []string
+		dirRead := read
+		read = func(p string) ([]byte, error) {
+			included = append(included, p)
+			return dirRead(p)
+		}
+		defer func() { src.includes[rel] = included }()
+	}
+	title, markdown := site.Convert(rel, docset.ExpandIncludes(rel, string(content), read))
+	if docset.Hash(title, markdown) == oldHash {
+		return false, nil
+	}
+	if _, err := docset.WriteDocument(ctx, tx, src.Prefix()+"/"+rel, title, markdown); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// relPath returns the slash-separated path of a file below the source's
+// directory, or false when it lies outside it.
+func (s Source) relPath(path string) (string, bool) {
+	rel, err := filepath.Rel(s.Dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,146 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func createFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func searchNames(t *testing.T, store *db.Store, query string) []string {
+	t.Helper()
+	results, err := store.Search(context.Background(), query, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func TestIngestDirectory(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+	dir := t.TempDir()
+	createFile(t, dir, "design/storage.md", "---\ntitle: Storage design\n---\n\nWe shard by tenant.\n")
+	createFile(t, dir, "runbooks/failover.mdx", "import Tabs from '@theme/Tabs';\n\n# Failover\n\n<Tabs>Promote the replica.</Tabs>\n")
+	createFile(t, dir, "node_modules/dep/README.md", "# Dependency\n")
+
+	stats, err := IngestDirectory(ctx, Options{Name: "team-handbook", Dir: dir, DB: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Written: 2}) {
+		t.Errorf("first sync = %+v, want 2 written", stats)
+	}
+	if _, err := store.ReadDocument(ctx, "local/team-handbook/design/storage.md"); err != nil {
+		t.Error(err)
+	}
+	if names := searchNames(t, store, "replica"); len(names) != 1 || names[0] != "Failover" {
+		t.Errorf("Search(replica) = %v", names)
+	}
+
+	sources, err := Sources(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].Name != "team-handbook" || sources[0].Dir != dir {
+		t.Fatalf("Sources = %+v", sources)
+	}
+
+	createFile(t, dir, "design/storage.md", "---\ntitle: Storage design\n---\n\nWe shard by region.\n")
+	if err := os.Remove(filepath.Join(dir, "runbooks/failover.mdx")); err != nil {
+		t.Fatal(err)
+	}
+	createFile(t, dir, "design/queues.md", "# Queues\n")
+
+	stats, err = Sync(ctx, store, sources[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Written: 2, Removed: 1}) {
+		t.Errorf("second sync = %+v, want 2 written, 1 removed", stats)
+	}
+	if names := searchNames(t, store, "tenant"); len(names) != 0 {
+		t.Errorf("stale search entries for the old content: %v", names)
+	}
+	if names := searchNames(t, store, "replica"); len(names) != 0 {
+		t.Errorf("search entries of a removed file remain: %v", names)
+	}
+
+	if stats, _ := Sync(ctx, store, sources[0]); stats != (Stats{Unchanged: 2}) {
+		t.Errorf("third sync = %+v, want 2 unchanged", stats)
+	}
+
+	if _, err := IngestDirectory(ctx, Options{Name: "../escape", Dir: dir, DB: store}); err == nil {
+		t.Error("accepted a source name with a slash")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := dbtest.Open(t)
+	dir := t.TempDir()
+	createFile(t, dir, "index.md", "# Index\n\nStart here.\n")
+	createFile(t, dir, "deploy.adoc", "= Deploy\n\ninclude::snippets/steps.txt[]\n")
+	createFile(t, dir, "snippets/steps.txt", "Run the canary first.\n")
+
+	src := Source{Name: "notes", Dir: dir}
+	done := make(chan error, 1)
+	go func() { done <- Watch(ctx, store, []Source{src}) }()
+
+	waitFor := func(query string, want bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if (len(searchNames(t, store, query)) > 0) == want {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("search for %q: found = %v after 5s", query, !want)
+	}
+
+	waitFor("Start here", true)
+	waitFor("canary", true)
+
+	// Changing an included file re-indexes the document including it.
+	createFile(t, dir, "snippets/steps.txt", "Drain the load balancer first.\n")
+	waitFor("balancer", true)
+	waitFor("canary", false)
+
+	createFile(t, dir, "index.md", "# Index\n\nBegin with the glossary.\n")
+	waitFor("glossary", true)
+	waitFor("Start here", false)
+
+	createFile(t, dir, "ops/oncall.md", "# On call\n\nPage the secondary.\n")
+	waitFor("secondary", true)
+
+	if err := os.RemoveAll(filepath.Join(dir, "ops")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("secondary", false)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,215 @@
+package local
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+)
+
+// debounce is how long the watcher waits after the last change before it
+// re-indexes, so an editor's save or a git checkout is handled in one batch.
+const debounce = 250 * time.Millisecond
+
+// Watch syncs the sources, then re-indexes their files as they change until
+// ctx is done. Written and removed documentation files are re-indexed
+// individually, along with the documents that include a changed file; new
+// directories, removed or renamed ones and changes to a site generator's
+// configuration trigger a sync of the whole source.
+func Watch(ctx context.Context, store *db.Store, sources []Source) error {
+	if len(sources) == 0 {
+		return errors.New("no local sources to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for i, src := range sources {
+		if err := watchTree(watcher, src, src.Dir); err != nil {
+			return err
+		}
+		src.includes = map[string][]string{}
+		sources[i] = src
+		if _, err := Sync(ctx, store, src); err != nil {
+			return err
+		}
+		log.Info("watching local source", "name", src.Name, "dir", src.Dir)
+	}
+
+	// pending holds the changed files of each source by index; a nil set
+	// means the whole source needs a sync.
+	pending := map[int]map[string]bool{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warn("watch error", "err", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			i, rel, ok := sourceOf(sources, event.Name)
+			if !ok {
+				continue
+			}
+
+			full := false
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchTree(watcher, sources[i], event.Name); err != nil {
+						log.Warn("failed to watch directory", "path", event.Name, "err", err)
+					}
+					full = true
+				}
+			}
+			if !docset.IsDocFile(rel) && event.Has(fsnotify.Remove|fsnotify.Rename) || docset.IsSiteFile(rel) {
+				full = true
+			}
+
+			changed := sources[i].includers(rel)
+			if docset.IsDocFile(rel) {
+				changed = append(changed, rel)
+			}
+			switch files, seen := pending[i]; {
+			case full:
+				pending[i] = nil
+			case seen && files == nil:
+			case len(changed) > 0:
+				if files == nil {
+					files = map[string]bool{}
+					pending[i] = files
+				}
+				for _, rel := range changed {
+					files[rel] = true
+				}
+			default:
+				continue
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			for i, files := range pending {
+				if err := reindex(ctx, store, sources[i], files); err != nil {
+					log.Error("re-index failed", "name", sources[i].Name, "err", err)
+				}
+			}
+			pending = map[int]map[string]bool{}
+		}
+	}
+}
+
+// reindex writes or removes the documents of changed files, or syncs the
+// whole source when files is nil. A site's table of contents is rebuilt, as
+// front matter can reorder it.
+func reindex(ctx context.Context, store *db.Store, src Source, files map[string]bool) error {
+	if files == nil {
+		_, err := Sync(ctx, store, src)
+		return err
+	}
+
+	filter := src.filter()
+	site := src.site()
+	return store.WithTx(ctx, func(tx *sql.Tx) error {
+		for rel := range files {
+			docPath := src.Prefix() + "/" + rel
+			info, err := os.Stat(filepath.Join(src.Dir, filepath.FromSlash(rel)))
+			if err != nil || info.IsDir() || !filter.Match(rel) {
+				if err := db.DeleteDocumentTx(ctx, tx, docPath); err != nil {
+					return err
+				}
+				delete(src.includes, rel)
+				log.Info("removed", "path", docPath)
+				continue
+			}
+
+			hashes, err := db.DocumentHashesTx(ctx, tx, docPath)
+			if err != nil {
+				return err
+			}
+			written, err := writeFile(ctx, tx, src, site, rel, hashes[docPath])
+			if err != nil {
+				return err
+			}
+			if written {
+				log.Info("re-indexed", "path", docPath)
+			}
+		}
+
+		if site == nil {
+			return docset.IndexSite(ctx, tx, src.Prefix(), nil, nil)
+		}
+		all, err := docset.Walk(src.Dir, filter)
+		if err != nil {
+			return err
+		}
+		return docset.IndexSite(ctx, tx, src.Prefix(), site, all)
+	})
+}
+
+// includers returns the documents that included the file at rel when they
+// were last converted.
+func (s Source) includers(rel string) []string {
+	var docs []string
+	for doc, included := range s.includes {
+		if slices.Contains(included, rel) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// watchTree adds a watch for dir and every directory below it that the
+// source's filter does not exclude.
+func watchTree(watcher *fsnotify.Watcher, src Source, dir string) error {
+	filter := src.filter()
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if rel, ok := src.relPath(path); ok && (d.Name() == ".git" || filter.SkipDir(rel)) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// sourceOf finds the source a changed path belongs to, the innermost when
+// sources are nested, and the path relative to it.
+func sourceOf(sources []Source, path string) (int, string, bool) {
+	best, bestRel := -1, ""
+	for i, src := range sources {
+		rel, ok := src.relPath(path)
+		if ok && (best < 0 || len(src.Dir) > len(sources[best].Dir)) {
+			best, bestRel = i, rel
+		}
+	}
+	return best, bestRel, best >= 0
+}
@@ -0,0 +1,197 @@
+package maven
+
+import (
+	"slices"
+	"strings"
+)
+
+// artifactDocs is the API of an artifact, read from its javadoc or sources
+// jar.
+type artifactDocs struct {
+	Format   string // "javadoc" or "sources"
+	Types    []*javaType
+	Packages map[string]*javaPackage
+}
+
+// javaPackage is a package with its description and, for Kotlin, its
+// top-level functions, properties and type aliases.
+type javaPackage struct {
+	Name    string
+	Doc     string // Markdown
+	Summary string
+	Lang    string // the language of the sources its members were read from
+	Members []javaMember
+}
+
+// javaType is a class, interface, enum, record or annotation type, or a
+// Kotlin object. Nested types are types of their own, named Outer.Inner.
+type javaType struct {
+	Package   string
+	Name      string
+	Kind      string
+	Signature string
+	Summary   string
+	Markdown  string
+	Members   []javaMember
+}
+
+// javaMember is a constructor, method, field, enum constant or annotation
+// element of a type, or a top-level Kotlin declaration. Overloads are
+// members of their own.
+type javaMember struct {
+	Name      string
+	Kind      string
+	Signature string
+	Summary   string
+	Doc       string // Markdown; javadoc pages render their members themselves
+}
+
+func (t *javaType) FullName() string {
+	if t.Package == "" {
+		return t.Name
+	}
+	return t.Package + "." + t.Name
+}
+
+// pkg returns the named package, adding it when it is new.
+func (d *artifactDocs) pkg(name string) *javaPackage {
+	if d.Packages == nil {
+		d.Packages = map[string]*javaPackage{}
+	}
+	p, ok := d.Packages[name]
+	if !ok {
+		p = &javaPackage{Name: name}
+		d.Packages[name] = p
+	}
+	return p
+}
+
+func (d *artifactDocs) empty() bool {
+	for _, p := range d.Packages {
+		if len(p.Members) > 0 {
+			return false
+		}
+	}
+	return len(d.Types) == 0
+}
+
+// packageNames returns the names of the packages that document something.
+func (d *artifactDocs) packageNames() []string {
+	var names []string
+	for name, p := range d.Packages {
+		if len(p.Members) > 0 || len(d.typesOf(name)) > 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (d *artifactDocs) typesOf(pkg string) []*javaType {
+	var types []*javaType
+	for _, t := range d.Types {
+		if t.Package == pkg {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// sortTypes orders types by package and name, so the documents of an
+// artifact do not depend on the order of its jar.
+func (d *artifactDocs) sortTypes() {
+	slices.SortStableFunc(d.Types, func(a, b *javaType) int {
+		return strings.Compare(a.FullName(), b.FullName())
+	})
+}
+
+// typeSections orders the types listed in a package document.
+var typeSections = []struct{ kind, title string }{
+	{"Interface", "Interfaces"},
+	{"Class", "Classes"},
+	{"Enum", "Enums"},
+	{"Record", "Records"},
+	{"Annotation", "Annotation Types"},
+	{"Object", "Objects"},
+}
+
+// memberSections orders the members rendered in a type or package document.
+var memberSections = []struct{ kind, title string }{
+	{"EnumConstant", "Enum Constants"},
+	{"Field", "Fields"},
+	{"Property", "Properties"},
+	{"Constructor", "Constructors"},
+	{"Method", "Methods"},
+	{"Element", "Elements"},
+	{"Function", "Functions"},
+	{"TypeAlias", "Type Aliases"},
+}
+
+// renderPackage renders a package document: its description, its types with
+// their summaries and its top-level members.
+func renderPackage(p *javaPackage, types []*javaType, format string) string {
+	var b strings.Builder
+	b.WriteString("# Package " + p.Name + "\n\n")
+	if p.Doc != "" {
+		b.WriteString(p.Doc + "\n\n")
+	}
+	for _, section := range typeSections {
+		first := true
+		for _, t := range types {
+			if t.Kind != section.kind {
+				continue
+			}
+			if first {
+				b.WriteString("## " + section.title + "\n\n")
+				first = false
+			}
+			b.WriteString("- `" + t.Name + "`")
+			if t.Summary != "" {
+				b.WriteString(": " + t.Summary)
+			}
+			b.WriteString("\n")
+		}
+		if !first {
+			b.WriteString("\n")
+		}
+	}
+	writeMembers(&b, "", p.Members, p.Lang)
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// renderType renders the document of a type read from sources, laid out as
+// a javadoc page: its declaration and description, then its members grouped
+// by kind.
+func renderType(t *javaType, doc, lang string) string {
+	var b strings.Builder
+	b.WriteString("# " + t.Kind + " " + t.Name + "\n\n")
+	if t.Package != "" {
+		b.WriteString("Package: `" + t.Package + "`\n\n")
+	}
+	b.WriteString("```" + lang + "\n" + t.Signature + "\n```\n\n")
+	if doc != "" {
+		b.WriteString(doc + "\n\n")
+	}
+	writeMembers(&b, t.Name+".", t.Members, lang)
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeMembers(b *strings.Builder, parent string, members []javaMember, lang string) {
+	for _, section := range memberSections {
+		first := true
+		for _, m := range members {
+			if m.Kind != section.kind {
+				continue
+			}
+			if first {
+				b.WriteString("## " + section.title + "\n\n")
+				first = false
+			}
+			b.WriteString("### " + parent + m.Name + "\n\n")
+			b.WriteString("```" + lang + "\n" + m.Signature + "\n```\n\n")
+			if m.Doc != "" {
+				b.WriteString(m.Doc + "\n\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package maven
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	javadocParam = regexp.MustCompile(`^(<\w+>|[\w$]+)\s*(?:-\s*)?`)
+	kdocLink     = regexp.MustCompile(`\[([\w.]+)\]`)
+)
+
+// docComment is a Javadoc or KDoc comment split into its description and
+// block tags. Javadoc descriptions are HTML and KDoc ones Markdown.
+type docComment struct {
+	desc   string
+	tags   []docTag
+	kotlin bool
+}
+
+// docTag is a block tag, such as @param, with its text.
+type docTag struct {
+	name, text string
+}
+
+// commentText returns the text of a /** */ comment without its delimiters
+// and leading asterisks.
+func commentText(comment string) string {
+	comment = strings.TrimPrefix(comment, "/**")
+	comment = strings.TrimSuffix(comment, "*/")
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(line, "*") {
+			line = strings.TrimPrefix(line[1:], " ")
+		}
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// parseComment splits a doc comment into its description and block tags.
+// Tags inside <pre> blocks and code fences belong to the text around them,
+// as annotations in code examples do.
+func parseComment(comment string, kotlin bool) docComment {
+	c := docComment{kotlin: kotlin}
+	var (
+		desc []string
+		code bool
+	)
+	for _, line := range strings.Split(commentText(comment), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			code = !code
+		case strings.Contains(trimmed, "<pre"):
+			code = !strings.Contains(trimmed, "</pre>")
+		case strings.Contains(trimmed, "</pre>"):
+			code = false
+		}
+		if !code && strings.HasPrefix(line, "@") {
+			name, text, _ := strings.Cut(line[1:], " ")
+			c.tags = append(c.tags, docTag{name: name, text: strings.TrimSpace(text)})
+			continue
+		}
+		if len(c.tags) > 0 {
+			c.tags[len(c.tags)-1].text += "\n" + line
+		} else {
+			desc = append(desc, line)
+		}
+	}
+	for i := range c.tags {
+		c.tags[i].text = strings.TrimSpace(c.tags[i].text)
+	}
+	c.desc = strings.TrimSpace(strings.Join(desc, "\n"))
+	return c
+}
+
+func (c docComment) has(tag string) bool {
+	for _, t := range c.tags {
+		if t.name == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// hidden reports whether the comment keeps its declaration out of the
+// documentation: @hidden in Javadoc, @suppress in KDoc.
+func (c docComment) hidden() bool {
+	return c.has("hidden") || c.has("suppress")
+}
+
+// text converts a piece of the comment to Markdown: Javadoc HTML with its
+// inline tags, or KDoc Markdown with its [Symbol] links as code spans.
+func (c docComment) text(s string) string {
+	if c.kotlin {
+		return kdocLinks(s)
+	}
+	// Code in <pre> blocks is Java, as in the examples javadoc renders.
+	s = strings.ReplaceAll(javadocInline(s), "<pre><code>", `<pre><code class="language-java">`)
+	return htmlToMarkdown(s)
+}
+
+// summary returns the first sentence of the description, marked when the
+// declaration is deprecated.
+func (c docComment) summary(deprecated bool) string {
+	para, _, _ := strings.Cut(c.text(c.desc), "\n\n")
+	summary := firstSentence(strings.Join(strings.Fields(para), " "))
+	if deprecated || c.has("deprecated") {
+		return strings.TrimSpace(summary + " (deprecated)")
+	}
+	return summary
+}
+
+// markdown converts the comment to Markdown, with its block tags as
+// labelled sections as javadoc renders them.
+func (c docComment) markdown() string {
+	var (
+		parts                       []string
+		params, typeParams, props   []string
+		throws, see, samples, notes []string
+		returns, receiver           string
+		deprecated                  string
+		remarks                     []string
+	)
+	for _, tag := range c.tags {
+		text := c.text(tag.text)
+		switch tag.name {
+		case "param", "property":
+			match := javadocParam.FindStringSubmatch(tag.text)
+			if match == nil {
+				continue
+			}
+			name := match[1]
+			entry := "- `" + strings.Trim(name, "<>") + "`"
+			if desc := oneLine(c.text(tag.text[len(match[0]):])); desc != "" {
+				entry += ": " + desc
+			}
+			switch {
+			case strings.HasPrefix(name, "<"):
+				typeParams = append(typeParams, entry)
+			case tag.name == "property":
+				props = append(props, entry)
+			default:
+				params = append(params, entry)
+			}
+		case "return", "returns":
+			returns = text
+		case "receiver":
+			receiver = text
+		case "throws", "exception":
+			typ, desc, _ := strings.Cut(tag.text, " ")
+			entry := "- `" + typ + "`"
+			if desc := oneLine(c.text(desc)); desc != "" {
+				entry += ": " + desc
+			}
+			throws = append(throws, entry)
+		case "see":
+			see = append(see, "- "+seeTarget(c, tag.text))
+		case "sample":
+			samples = append(samples, "- `"+tag.text+"`")
+		case "deprecated":
+			deprecated = "> **Deprecated**"
+			if text != "" {
+				deprecated += ": " + oneLine(text)
+			}
+		case "apiNote", "implSpec", "implNote":
+			remarks = append(remarks, "**"+noteTitles[tag.name]+"**", text)
+		case "since":
+			notes = append(notes, "Since: "+oneLine(text))
+		}
+	}
+
+	if deprecated != "" {
+		parts = append(parts, deprecated)
+	}
+	if c.desc != "" {
+		parts = append(parts, c.text(c.desc))
+	}
+	parts = append(parts, remarks...)
+	if len(typeParams) > 0 {
+		parts = append(parts, "**Type Parameters**", strings.Join(typeParams, "\n"))
+	}
+	if receiver != "" {
+		parts = append(parts, "**Receiver**", receiver)
+	}
+	if len(params) > 0 {
+		parts = append(parts, "**Parameters**", strings.Join(params, "\n"))
+	}
+	if len(props) > 0 {
+		parts = append(parts, "**Properties**", strings.Join(props, "\n"))
+	}
+	if returns != "" {
+		parts = append(parts, "**Returns**", returns)
+	}
+	if len(throws) > 0 {
+		parts = append(parts, "**Throws**", strings.Join(throws, "\n"))
+	}
+	if len(notes) > 0 {
+		parts = append(parts, strings.Join(notes, "\\\n"))
+	}
+	if len(samples) > 0 {
+		parts = append(parts, "**Samples**", strings.Join(samples, "\n"))
+	}
+	if len(see) > 0 {
+		parts = append(parts, "**See Also**", strings.Join(see, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+var noteTitles = map[string]string{
+	"apiNote":  "API Note",
+	"implSpec": "Implementation Requirements",
+	"implNote": "Implementation Note",
+}
+
+// seeTarget renders the target of a @see tag: a reference to a member as a
+// code span, or a quoted string or an HTML link as it reads.
+func seeTarget(c docComment, target string) string {
+	switch {
+	case strings.HasPrefix(target, "\""):
+		return strings.Trim(target, "\"")
+	case strings.HasPrefix(target, "<"):
+		return oneLine(c.text(target))
+	case c.kotlin:
+		return "`" + target + "`"
+	}
+	ref, label, _ := strings.Cut(target, " ")
+	if label = strings.TrimSpace(label); label != "" {
+		return label
+	}
+	return "`" + javadocRef(ref) + "`"
+}
+
+// javadocInline replaces the inline tags of a Javadoc description with the
+// HTML javadoc renders for them: {@code} and {@link} as code, {@literal} as
+// text. {@inheritDoc} is dropped; the inherited comment is not at hand.
+func javadocInline(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "{@")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+
+		// Tags may hold balanced braces, as {@code Map<K, {}>} can.
+		end, depth := len(s), 0
+		for j := i; j < len(s); j++ {
+			if s[j] == '{' {
+				depth++
+			} else if s[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		tag := s[i+2 : end]
+		name, content, _ := strings.Cut(tag, " ")
+		if n, rest, ok := strings.Cut(name, "\n"); ok {
+			name, content = n, rest+" "+content
+		}
+		switch name {
+		case "code":
+			content = strings.TrimPrefix(content, " ")
+			b.WriteString("<code>" + html.EscapeString(content) + "</code>")
+		case "literal":
+			b.WriteString(html.EscapeString(content))
+		case "link", "linkplain", "value":
+			ref, label, _ := strings.Cut(strings.TrimSpace(content), " ")
+			text := strings.TrimSpace(label)
+			if text == "" {
+				text = javadocRef(ref)
+			}
+			if name == "linkplain" {
+				b.WriteString(html.EscapeString(text))
+			} else {
+				b.WriteString("<code>" + html.EscapeString(text) + "</code>")
+			}
+		case "return":
+			b.WriteString("Returns " + strings.TrimSpace(content) + ".")
+		case "inheritDoc":
+		default:
+			b.WriteString(content)
+		}
+
+		if end == len(s) {
+			return b.String()
+		}
+		s = s[end+1:]
+	}
+}
+
+// javadocRef renders a reference such as List#add(Object) or #size() the
+// way code refers to it: List.add(Object), size().
+func javadocRef(ref string) string {
+	if rest, ok := strings.CutPrefix(ref, "#"); ok {
+		return rest
+	}
+	return strings.Replace(ref, "#", ".", 1)
+}
+
+// kdocLinks turns the [Symbol] links of a KDoc comment into code spans,
+// leaving Markdown links and indexing such as a[i] alone.
+func kdocLinks(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range kdocLink.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		if end < len(s) && (s[end] == '(' || s[end] == '[') {
+			continue
+		}
+		if start > 0 && (isIdent(s[start-1]) || s[start-1] == ']' || s[start-1] == ')') {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString("`" + s[m[2]:m[3]] + "`")
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
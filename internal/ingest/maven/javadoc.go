@@ -0,0 +1,336 @@
+package maven
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// javadocKinds maps the title of a class page, "Class Foo" or "Enum Class
+// Bar" since JDK 17, to the kind of the type.
+var javadocKinds = []struct{ prefix, kind string }{
+	{"Annotation Interface ", "Annotation"},
+	{"Annotation Type ", "Annotation"},
+	{"Record Class ", "Record"},
+	{"Record ", "Record"},
+	{"Enum Class ", "Enum"},
+	{"Enum ", "Enum"},
+	{"Interface ", "Interface"},
+	{"Class ", "Class"},
+}
+
+// javadocSkipDirs are the directories of a javadoc build that hold no class
+// pages.
+var javadocSkipDirs = map[string]bool{
+	"class-use":   true,
+	"doc-files":   true,
+	"index-files": true,
+	"jquery":      true,
+	"legal":       true,
+	"resources":   true,
+	"script-dir":  true,
+	"src-html":    true,
+}
+
+// readJavadoc reads the class and package pages of a javadoc jar. Pages are
+// recognized by their title, so the layouts of JDK 8 to current javadoc are
+// read alike; other HTML, such as Dokka's, yields no types.
+func readJavadoc(zr *zip.Reader) (*artifactDocs, error) {
+	docs := &artifactDocs{Format: "javadoc"}
+
+	// Javadoc of a modular build puts each module's packages in a directory
+	// named after the module.
+	modules := map[string]bool{}
+	for _, f := range zr.File {
+		if dir, name := path.Split(f.Name); name == "module-summary.html" && dir != "" {
+			modules[strings.TrimSuffix(dir, "/")] = true
+		}
+	}
+
+	for _, f := range zr.File {
+		if !isJavadocPage(f.Name) {
+			continue
+		}
+		doc, err := openHTML(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		dir, name := path.Split(f.Name)
+		dir = strings.TrimSuffix(dir, "/")
+		if module, rest, _ := strings.Cut(dir, "/"); modules[module] {
+			dir = rest
+		}
+		pkg := packagePath(dir)
+
+		if name == "package-summary.html" {
+			p := docs.pkg(pkg)
+			p.Doc, p.Summary = javadocPackage(doc)
+			continue
+		}
+		t, ok := javadocType(doc)
+		if !ok {
+			continue
+		}
+		t.Package, t.Name = pkg, strings.TrimSuffix(name, ".html")
+		docs.pkg(pkg)
+		docs.Types = append(docs.Types, t)
+	}
+	docs.sortTypes()
+	return docs, nil
+}
+
+// isJavadocPage reports whether a file of a javadoc jar may be a class page
+// or a package summary. Index, tree, use and help pages have a dash in their
+// names, which Java types cannot.
+func isJavadocPage(name string) bool {
+	if !strings.HasSuffix(name, ".html") {
+		return false
+	}
+	dir, base := path.Split(name)
+	for _, elem := range strings.Split(dir, "/") {
+		if javadocSkipDirs[elem] {
+			return false
+		}
+	}
+	return base == "package-summary.html" || !strings.Contains(base, "-")
+}
+
+func openHTML(f *zip.File) (*goquery.Document, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return goquery.NewDocumentFromReader(rc)
+}
+
+// javadocType reads a class page: the type's declaration, summary and
+// members are read from the markup, then the page is converted to Markdown.
+// ok is false for pages that do not document a type.
+func javadocType(doc *goquery.Document) (*javaType, bool) {
+	title := javadocText(doc.Find("h1.title, h2.title").First().Text())
+	t := &javaType{}
+	for _, k := range javadocKinds {
+		if strings.HasPrefix(title, k.prefix) {
+			t.Kind = k.kind
+			break
+		}
+	}
+	if t.Kind == "" {
+		return nil, false
+	}
+
+	sig := doc.Find(".type-signature").First()
+	if sig.Length() == 0 {
+		sig = doc.Find(".description pre").First()
+	}
+	t.Signature = javadocText(sig.Text())
+
+	desc := doc.Find("#class-description, .class-description, .description").First()
+	t.Summary = summaryOf(desc)
+	t.Members = javadocMembers(doc)
+	t.Markdown = javadocMarkdown(doc)
+	return t, true
+}
+
+// javadocDetailKinds maps the class of a member details section since JDK 16,
+// and javadocAnchorKinds the anchor of one in older javadoc, to member kinds.
+var (
+	javadocDetailKinds = map[string]string{
+		"constant-details":    "EnumConstant",
+		"field-details":       "Field",
+		"property-details":    "Property",
+		"constructor-details": "Constructor",
+		"method-details":      "Method",
+		"member-details":      "Element",
+	}
+	javadocAnchorKinds = map[string]string{
+		"enum.constant":           "EnumConstant",
+		"field":                   "Field",
+		"property":                "Property",
+		"constructor":             "Constructor",
+		"method":                  "Method",
+		"annotation.type.element": "Element",
+	}
+)
+
+// javadocMembers reads the member details of a class page. Since JDK 16 each
+// member is a <section class="detail"> holding its name in an h3 and its
+// declaration in a div.member-signature; before, it is a list item holding an
+// h4 and a pre, under an anchor such as method.detail.
+func javadocMembers(doc *goquery.Document) []javaMember {
+	var members []javaMember
+	doc.Find("section.detail").Each(func(_ int, sec *goquery.Selection) {
+		var kind string
+		class, _ := sec.Closest("section[class$='-details']").Attr("class")
+		for _, c := range strings.Fields(class) {
+			if k, ok := javadocDetailKinds[c]; ok {
+				kind = k
+			}
+		}
+		if kind == "" {
+			return
+		}
+		members = append(members, javaMember{
+			Name:      javadocText(sec.ChildrenFiltered("h3").First().Text()),
+			Kind:      kind,
+			Signature: javadocText(sec.Find(".member-signature").First().Text()),
+			Summary:   summaryOf(sec),
+		})
+	})
+	if len(members) > 0 {
+		return members
+	}
+
+	doc.Find("a[name$='.detail'], a[id$='.detail']").Each(func(_ int, anchor *goquery.Selection) {
+		id, ok := anchor.Attr("id")
+		if !ok {
+			id, _ = anchor.Attr("name")
+		}
+		kind, ok := javadocAnchorKinds[strings.TrimSuffix(id, ".detail")]
+		if !ok {
+			return
+		}
+		anchor.Parent().Find("h4").Each(func(_ int, h *goquery.Selection) {
+			item := h.Parent()
+			members = append(members, javaMember{
+				Name:      javadocText(h.Text()),
+				Kind:      kind,
+				Signature: javadocText(item.ChildrenFiltered("pre").First().Text()),
+				Summary:   summaryOf(item),
+			})
+		})
+	})
+	return members
+}
+
+// summaryOf returns the first sentence of the description block of a class
+// description or member detail, marked when the item is deprecated. JDK 8
+// puts the deprecation note in a block of its own, which is skipped.
+func summaryOf(sel *goquery.Selection) string {
+	block := sel.Find(".block").FilterFunction(func(_ int, b *goquery.Selection) bool {
+		return b.Closest(".deprecation-block, .deprecationBlock").Length() == 0 &&
+			b.Find(".deprecated-label, .deprecatedLabel").Length() == 0
+	}).First()
+	summary := firstSentence(javadocText(block.Text()))
+	if sel.Find(".deprecated-label, .deprecatedLabel").Length() > 0 {
+		summary = strings.TrimSpace(summary + " (deprecated)")
+	}
+	return summary
+}
+
+// javadocPackage reads the description of a package summary page: its
+// converted Markdown and first sentence.
+func javadocPackage(doc *goquery.Document) (md, summary string) {
+	block := doc.Find("#package-description .block, .package-description .block").First()
+	if block.Length() == 0 {
+		block = doc.Find("a[name='package.description'], a[id='package.description']").First().NextAllFiltered(".block").First()
+	}
+	if block.Length() == 0 {
+		block = doc.Find(".docSummary .block").First()
+	}
+	if block.Length() == 0 {
+		return "", ""
+	}
+	unwrapLinks(block)
+	raw, err := goquery.OuterHtml(block)
+	if err != nil {
+		return "", ""
+	}
+	return htmlToMarkdown(raw), firstSentence(javadocText(block.Text()))
+}
+
+// javadocMarkdown converts a class page to Markdown the way rustdoc pages
+// are: navigation and other chrome are removed and the main element is
+// converted. Member summary tables, which repeat the details below them, are
+// left out; links within the javadoc are kept as their text and
+// declarations become java code blocks.
+func javadocMarkdown(doc *goquery.Document) string {
+	doc.Find("script, noscript, nav, header, footer").Remove()
+	doc.Find(".top-nav, .sub-nav, .bottom-nav, .topNav, .subNav, .bottomNav, .skip-nav, .skipNav, .legal-copy").Remove()
+	doc.Find(".summary, .inheritance").Remove()
+
+	main := doc.Find("main")
+	if main.Length() == 0 {
+		// JDK 8 has no main element.
+		main = doc.Find(".header, .contentContainer")
+	}
+	if main.Length() == 0 {
+		return ""
+	}
+
+	unwrapLinks(main)
+	main.Find(".type-signature, .member-signature, pre").Each(func(_ int, sig *goquery.Selection) {
+		if goquery.NodeName(sig) == "pre" && (sig.Closest(".block").Length() > 0 || sig.Children().Is("code")) {
+			return
+		}
+		sig.ReplaceWithHtml(`<pre><code class="language-java">` + html.EscapeString(javadocText(sig.Text())) + "</code></pre>")
+	})
+	// Members are nested in lists of one item each, which would otherwise
+	// be indented as Markdown lists.
+	main.Find("ul.blockList, ul.blockListLast, ul.member-list, ul.details-list, ul.summary-list").Each(func(_ int, ul *goquery.Selection) {
+		ul.ChildrenFiltered("li").Each(func(_ int, li *goquery.Selection) {
+			li.ReplaceWithSelection(li.Contents())
+		})
+		ul.ReplaceWithSelection(ul.Contents())
+	})
+
+	var b strings.Builder
+	main.Each(func(_ int, sel *goquery.Selection) {
+		if raw, err := goquery.OuterHtml(sel); err == nil {
+			b.WriteString(raw)
+		}
+	})
+	return htmlToMarkdown(b.String())
+}
+
+// unwrapLinks replaces the links between javadoc pages with their text,
+// as the pages they point to are not stored under the same paths. Links to
+// other sites are kept.
+func unwrapLinks(sel *goquery.Selection) {
+	sel.Find("a").Each(func(_ int, a *goquery.Selection) {
+		if href, _ := a.Attr("href"); strings.Contains(href, "://") {
+			return
+		}
+		a.ReplaceWithSelection(a.Contents())
+	})
+}
+
+func htmlToMarkdown(raw string) string {
+	conv := converter.NewConverter(
+		converter.WithPlugins(
+			base.NewBasePlugin(),
+			commonmark.NewCommonmarkPlugin(),
+		),
+	)
+	md, err := conv.ConvertString(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(md)
+}
+
+// javadocText collapses the text of javadoc markup to one line, without the
+// non-breaking and zero-width spaces javadoc puts in declarations.
+func javadocText(s string) string {
+	s = strings.ReplaceAll(s, "\u200b", "")
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// firstSentence returns the first sentence of a description, as javadoc
+// summarizes it: the text up to the first period followed by a space.
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
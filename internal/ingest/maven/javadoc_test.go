@@ -0,0 +1,329 @@
+package maven
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// modernClassPage is a class page in the layout of javadoc since JDK 17.
+const modernClassPage = `<!DOCTYPE HTML>
+<html lang="en">
+<head><title>Cache (demo 1.1 API)</title><script type="text/javascript" src="../../../script.js"></script></head>
+<body class="class-declaration-page">
+<div class="flex-box">
+<header role="banner" class="flex-header">
+<nav role="navigation">
+<div class="top-nav" id="navbar-top"><ul class="nav-list"><li><a href="../../../index.html">Overview</a></li></ul></div>
+<div class="sub-nav"><ul class="sub-nav-list"><li>Summary:&nbsp;</li></ul></div>
+</nav>
+</header>
+<div class="flex-content">
+<main role="main">
+<div class="header">
+<div class="sub-title"><span class="package-label-in-type">Package</span>&nbsp;<a href="package-summary.html">com.example.cache</a></div>
+<h1 title="Class Cache" class="title">Class Cache&lt;K,<wbr>V&gt;</h1>
+</div>
+<div class="inheritance" title="Inheritance Tree"><a href="https://docs.oracle.com/en/java/javase/17/docs/api/java.base/java/lang/Object.html" class="external-link">java.lang.Object</a>
+<div class="inheritance">com.example.cache.Cache&lt;K,<wbr>V&gt;</div>
+</div>
+<section class="class-description" id="class-description">
+<dl class="notes">
+<dt>All Implemented Interfaces:</dt>
+<dd><code><a href="https://docs.oracle.com/en/java/javase/17/docs/api/java.base/java/lang/AutoCloseable.html" class="external-link">AutoCloseable</a></code></dd>
+</dl>
+<hr>
+<div class="type-signature"><span class="modifiers">public final class </span><span class="element-name type-name-label">Cache&lt;K,<wbr>V&gt;</span>
+<span class="extends-implements">extends <a href="https://docs.oracle.com/en/java/javase/17/docs/api/java.base/java/lang/Object.html" class="external-link">Object</a>
+implements <a href="https://docs.oracle.com/en/java/javase/17/docs/api/java.base/java/lang/AutoCloseable.html" class="external-link">AutoCloseable</a></span></div>
+<div class="block">A cache of computed values. Safe for concurrent use.
+
+ <p>Entries expire after <a href="#DEFAULT_TTL"><code>DEFAULT_TTL</code></a> seconds.</div>
+<dl class="notes">
+<dt>Since:</dt>
+<dd>1.2</dd>
+</dl>
+</section>
+<section class="summary">
+<ul class="summary-list">
+<li>
+<section class="method-summary" id="method-summary">
+<h2>Method Summary</h2>
+<div class="summary-table"><div class="col-second"><code><a href="#get(K)" class="member-name-link">get</a>(K&nbsp;key)</code></div></div>
+</section>
+</li>
+</ul>
+</section>
+<section class="details">
+<ul class="details-list">
+<li>
+<section class="field-details" id="field-detail">
+<h2>Field Details</h2>
+<ul class="member-list">
+<li>
+<section class="detail" id="DEFAULT_TTL">
+<h3>DEFAULT_TTL</h3>
+<div class="member-signature"><span class="modifiers">public static final</span>&nbsp;<span class="return-type">int</span>&nbsp;<span class="element-name">DEFAULT_TTL</span></div>
+<div class="block">Seconds an entry lives.</div>
+</section>
+</li>
+</ul>
+</section>
+</li>
+<li>
+<section class="constructor-details" id="constructor-detail">
+<h2>Constructor Details</h2>
+<ul class="member-list">
+<li>
+<section class="detail" id="&lt;init&gt;(int)">
+<h3>Cache</h3>
+<div class="member-signature"><span class="modifiers">public</span>&nbsp;<span class="element-name">Cache</span><wbr><span class="parameters">(int&nbsp;ttl)</span></div>
+<div class="block">Creates a cache.</div>
+</section>
+</li>
+</ul>
+</section>
+</li>
+<li>
+<section class="method-details" id="method-detail">
+<h2>Method Details</h2>
+<ul class="member-list">
+<li>
+<section class="detail" id="get(K)">
+<h3>get</h3>
+<div class="member-signature"><span class="modifiers">public</span>&nbsp;<span class="return-type"><a href="Cache.html" title="type parameter in Cache">V</a></span>&nbsp;<span class="element-name">get</span><wbr><span class="parameters">(<a href="Cache.html" title="type parameter in Cache">K</a>&nbsp;key)</span></div>
+<div class="block">Returns the value for a key. Never blocks.
+ <pre><code>
+ V v = cache.get(key);
+ </code></pre></div>
+<dl class="notes">
+<dt>Parameters:</dt>
+<dd><code>key</code> - the key</dd>
+<dt>Returns:</dt>
+<dd>the value, or <code>null</code></dd>
+</dl>
+</section>
+</li>
+<li>
+<section class="detail" id="shutdown()">
+<h3>shutdown</h3>
+<div class="member-signature"><span class="annotations"><a href="https://docs.oracle.com/en/java/javase/17/docs/api/java.base/java/lang/Deprecated.html" class="external-link">@Deprecated</a>
+</span><span class="modifiers">protected</span>&nbsp;<span class="return-type">void</span>&nbsp;<span class="element-name">shutdown</span>()</div>
+<div class="deprecation-block"><span class="deprecated-label">Deprecated.</span>
+<div class="deprecation-comment">use <a href="#close()"><code>close()</code></a></div>
+</div>
+</section>
+</li>
+</ul>
+</section>
+</li>
+</ul>
+</section>
+</main>
+<footer role="contentinfo">
+<p class="legal-copy"><small>Copyright &#169; 2024. All rights reserved.</small></p>
+</footer>
+</div>
+</div>
+</body>
+</html>
+`
+
+// legacyClassPage is a class page in the layout of JDK 8 javadoc, which
+// has no main element and nests members in single-item lists.
+const legacyClassPage = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">
+<html lang="en">
+<head><title>Cache.Policy (demo 1.0 API)</title></head>
+<body>
+<noscript><div>JavaScript is disabled on your browser.</div></noscript>
+<div class="topNav"><a name="navbar.top"><!--   --></a><ul class="navList" title="Navigation"><li><a href="../../../overview-summary.html">Overview</a></li></ul></div>
+<div class="subNav"><ul class="navList"><li>Prev&nbsp;Class</li></ul></div>
+<div class="header">
+<div class="subTitle">com.example.cache</div>
+<h2 title="Enum Cache.Policy" class="title">Enum Cache.Policy</h2>
+</div>
+<div class="contentContainer">
+<ul class="inheritance">
+<li>java.lang.Object</li>
+<li><ul class="inheritance"><li>com.example.cache.Cache.Policy</li></ul></li>
+</ul>
+<div class="description">
+<ul class="blockList">
+<li class="blockList">
+<dl>
+<dt>Enclosing class:</dt>
+<dd><a href="../../../com/example/cache/Cache.html" title="class in com.example.cache">Cache</a>&lt;K,V&gt;</dd>
+</dl>
+<hr>
+<br>
+<pre>public static enum <span class="typeNameLabel">Cache.Policy</span>
+extends java.lang.Enum&lt;<a href="../../../com/example/cache/Cache.Policy.html" title="enum in com.example.cache">Cache.Policy</a>&gt;</pre>
+<div class="block">Eviction policies. Pick one.</div>
+</li>
+</ul>
+</div>
+<div class="summary">
+<ul class="blockList">
+<li class="blockList"><a name="enum.constant.summary"><!--   --></a>
+<h3>Enum Constant Summary</h3>
+<table class="memberSummary"><tr><td class="colOne"><code><span class="memberNameLink"><a href="#LRU">LRU</a></span></code></td></tr></table>
+</li>
+</ul>
+</div>
+<div class="details">
+<ul class="blockList">
+<li class="blockList">
+<ul class="blockList">
+<li class="blockList"><a name="enum.constant.detail"><!--   --></a>
+<h3>Enum Constant Detail</h3>
+<a name="LRU"><!--   --></a>
+<ul class="blockList">
+<li class="blockList">
+<h4>LRU</h4>
+<pre>public static final&nbsp;<a href="../../../com/example/cache/Cache.Policy.html" title="enum in com.example.cache">Cache.Policy</a> LRU</pre>
+<div class="block">Least recently used.</div>
+</li>
+</ul>
+<a name="RANDOM"><!--   --></a>
+<ul class="blockListLast">
+<li class="blockList">
+<h4>RANDOM</h4>
+<pre>@Deprecated
+public static final&nbsp;<a href="../../../com/example/cache/Cache.Policy.html" title="enum in com.example.cache">Cache.Policy</a> RANDOM</pre>
+<div class="block"><span class="deprecatedLabel">Deprecated.</span>&nbsp;</div>
+</li>
+</ul>
+</li>
+</ul>
+<ul class="blockList">
+<li class="blockList"><a name="method.detail"><!--   --></a>
+<h3>Method Detail</h3>
+<a name="values--"><!--   --></a>
+<ul class="blockListLast">
+<li class="blockList">
+<h4>values</h4>
+<pre>public static&nbsp;<a href="../../../com/example/cache/Cache.Policy.html" title="enum in com.example.cache">Cache.Policy</a>[]&nbsp;values()</pre>
+<div class="block">Returns an array containing the constants of this enum type, in
+the order they are declared.  This method may be used to iterate
+over the constants as follows:
+<pre>
+for (Cache.Policy c : Cache.Policy.values())
+&nbsp;   System.out.println(c);
+</pre></div>
+<dl>
+<dt><span class="returnLabel">Returns:</span></dt>
+<dd>an array containing the constants of this enum type, in the order they are declared</dd>
+</dl>
+</li>
+</ul>
+</li>
+</ul>
+</li>
+</ul>
+</div>
+</div>
+<div class="bottomNav"><a name="navbar.bottom"><!--   --></a></div>
+</body>
+</html>
+`
+
+func TestJavadocType(t *testing.T) {
+	tests := []struct {
+		name, page         string
+		kind, sig, summary string
+		members            []string
+		markdown, absent   []string
+	}{
+		{
+			"modern", modernClassPage,
+			"Class", "public final class Cache<K,V> extends Object implements AutoCloseable", "A cache of computed values.",
+			[]string{
+				"Field DEFAULT_TTL: public static final int DEFAULT_TTL",
+				"Constructor Cache: public Cache(int ttl)",
+				"Method get: public V get(K key)",
+				"Method shutdown: @Deprecated protected void shutdown()",
+			},
+			[]string{
+				"# Class Cache&lt;K,V&gt;",
+				"```java\npublic final class Cache<K,V> extends Object implements AutoCloseable\n```",
+				"Entries expire after `DEFAULT_TTL` seconds.",
+				"## Method Details\n\n### get\n\n```java\npublic V get(K key)\n```",
+				"V v = cache.get(key);",
+				"Deprecated.",
+			},
+			[]string{"Method Summary", "Overview", "Copyright", "java.lang.Object", "Cache.html"},
+		},
+		{
+			"legacy", legacyClassPage,
+			"Enum", "public static enum Cache.Policy extends java.lang.Enum<Cache.Policy>", "Eviction policies.",
+			[]string{
+				"EnumConstant LRU: public static final Cache.Policy LRU",
+				"EnumConstant RANDOM: @Deprecated public static final Cache.Policy RANDOM",
+				"Method values: public static Cache.Policy[] values()",
+			},
+			[]string{
+				"## Enum Cache.Policy",
+				"```java\npublic static enum Cache.Policy extends java.lang.Enum<Cache.Policy>\n```",
+				"### Enum Constant Detail\n\n#### LRU\n\n```java\npublic static final Cache.Policy LRU\n```\n\nLeast recently used.",
+				"for (Cache.Policy c : Cache.Policy.values())",
+			},
+			[]string{"Enum Constant Summary", "Prev", "JavaScript", "- "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			typ, ok := javadocType(doc)
+			if !ok {
+				t.Fatal("javadocType() did not recognize the page")
+			}
+			if typ.Kind != tt.kind || typ.Signature != tt.sig || typ.Summary != tt.summary {
+				t.Errorf("javadocType() = %s %q %q", typ.Kind, typ.Signature, typ.Summary)
+			}
+			if got := members(typ); !slices.Equal(got, tt.members) {
+				t.Errorf("members = %q\nwant %q", got, tt.members)
+			}
+			for _, want := range tt.markdown {
+				if !strings.Contains(typ.Markdown, want) {
+					t.Errorf("markdown lacks %q:\n%s", want, typ.Markdown)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(typ.Markdown, unwanted) {
+					t.Errorf("markdown contains %q:\n%s", unwanted, typ.Markdown)
+				}
+			}
+		})
+	}
+
+	summaries := map[string]string{}
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(legacyClassPage))
+	for _, m := range javadocMembers(doc) {
+		summaries[m.Name] = m.Summary
+	}
+	if summaries["LRU"] != "Least recently used." || summaries["RANDOM"] != "(deprecated)" {
+		t.Errorf("member summaries = %q", summaries)
+	}
+}
+
+func TestIsJavadocPage(t *testing.T) {
+	for name, want := range map[string]bool{
+		"com/example/cache/Cache.html":           true,
+		"com/example/cache/Cache.Policy.html":    true,
+		"com/example/cache/package-summary.html": true,
+		"com/example/cache/package-tree.html":    false,
+		"com/example/cache/class-use/Cache.html": false,
+		"allclasses-index.html":                  false,
+		"src-html/com/example/cache/Cache.html":  false,
+		"element-list":                           false,
+	} {
+		if got := isJavadocPage(name); got != want {
+			t.Errorf("isJavadocPage(%q) = %v", name, got)
+		}
+	}
+}
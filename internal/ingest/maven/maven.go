@@ -0,0 +1,621 @@
+// Package maven ingests the API documentation of Java and Kotlin libraries
+// published to a Maven repository: the HTML of an artifact's javadoc jar, or
+// the doc comments in its sources jar when it has no javadoc.
+package maven
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// DefaultRepository is Maven Central.
+const DefaultRepository = "https://repo1.maven.org/maven2"
+
+type Options struct {
+	Group      string
+	Artifact   string
+	Version    string // the release in maven-metadata.xml when empty
+	Repository string // a repository URL or a local directory with its layout; Maven Central when empty
+	DB         *db.Store
+	Cache      *cache.FilesystemCache
+}
+
+// ParseCoordinate splits a coordinate such as com.google.guava:guava or
+// com.google.guava:guava:33.0.0-jre into its group, artifact and version.
+func ParseCoordinate(coord string) (group, artifact, version string, err error) {
+	parts := strings.Split(strings.TrimSpace(coord), ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid maven coordinate %q: want group:artifact[:version]", coord)
+	}
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+	return parts[0], parts[1], version, nil
+}
+
+// DocPrefix returns the path under which the documents of an artifact are
+// stored: maven/<group>/<artifact>/.
+func DocPrefix(group, artifact string) string {
+	return "maven/" + group + "/" + artifact + "/"
+}
+
+func IngestArtifact(ctx context.Context, opts Options) error {
+	if opts.Group == "" || opts.Artifact == "" {
+		return errors.New("group and artifact are required")
+	}
+	if opts.DB == nil {
+		return errors.New("db store is required")
+	}
+
+	repo := newRepository(opts.Repository)
+	a := artifact{Group: opts.Group, Artifact: opts.Artifact, Version: opts.Version}
+	if a.Version == "" {
+		version, err := repo.latestVersion(ctx, a)
+		if err != nil {
+			return err
+		}
+		a.Version = version
+	}
+	pom := repo.project(ctx, a)
+
+	log.Info("maven artifact ingest starting", "artifact", a, "repository", repo.base)
+
+	docs, err := readArtifact(ctx, repo, a, opts.Cache)
+	if err != nil {
+		return err
+	}
+	if docs.empty() {
+		return fmt.Errorf("no documented classes found in %s", a)
+	}
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := writeArtifact(ctx, tx, a, pom, docs); err != nil {
+			return err
+		}
+		log.Info("maven artifact ingested", "artifact", a, "from", docs.Format, "types", len(docs.Types), "packages", len(docs.Packages))
+		return nil
+	})
+}
+
+// artifact is a version of a Maven artifact.
+type artifact struct {
+	Group, Artifact, Version string
+}
+
+func (a artifact) String() string {
+	return a.Group + ":" + a.Artifact + ":" + a.Version
+}
+
+// dir returns the artifact's directory in a repository, or the directory of
+// the artifact itself when version is false.
+func (a artifact) dir(version bool) string {
+	p := strings.ReplaceAll(a.Group, ".", "/") + "/" + a.Artifact
+	if version {
+		p += "/" + a.Version
+	}
+	return p
+}
+
+// file returns the path of one of the artifact's files, such as its pom or
+// its javadoc jar.
+func (a artifact) file(classifier, ext string) string {
+	name := a.Artifact + "-" + a.Version
+	if classifier != "" {
+		name += "-" + classifier
+	}
+	return a.dir(true) + "/" + name + "." + ext
+}
+
+// repository reads the files of a Maven repository: a remote one over HTTP,
+// or a local directory with the same layout, such as ~/.m2/repository or a
+// mirror for air-gapped use.
+type repository struct {
+	base  string
+	local bool
+}
+
+func newRepository(spec string) repository {
+	switch {
+	case spec == "":
+		return repository{base: DefaultRepository}
+	case strings.HasPrefix(spec, "file://"):
+		return repository{base: filepath.FromSlash(strings.TrimPrefix(spec, "file://")), local: true}
+	case strings.Contains(spec, "://"):
+		return repository{base: strings.TrimSuffix(spec, "/")}
+	}
+	if abs, err := filepath.Abs(spec); err == nil {
+		spec = abs
+	}
+	return repository{base: spec, local: true}
+}
+
+// open opens a file of the repository. A missing file is reported as
+// fs.ErrNotExist for both kinds of repository.
+func (r repository) open(ctx context.Context, p string) (io.ReadCloser, error) {
+	if r.local {
+		return os.Open(filepath.Join(r.base, filepath.FromSlash(p)))
+	}
+
+	u := r.base + "/" + p
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", u, fs.ErrNotExist)
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, fmt.Errorf("maven repository error: %s: %s", u, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// mavenMetadata is the maven-metadata.xml listing the versions of an
+// artifact.
+type mavenMetadata struct {
+	Versioning struct {
+		Latest   string   `xml:"latest"`
+		Release  string   `xml:"release"`
+		Versions []string `xml:"versions>version"`
+	} `xml:"versioning"`
+}
+
+// latestVersion resolves the version to ingest from the artifact's
+// maven-metadata.xml: its release, else its latest version, else the highest
+// version listed. A local repository without metadata, as a copied tree may
+// be, has its version directories compared instead.
+func (r repository) latestVersion(ctx context.Context, a artifact) (string, error) {
+	names := []string{"maven-metadata.xml"}
+	if r.local {
+		// Maven writes the metadata of a local repository under this name.
+		names = append(names, "maven-metadata-local.xml")
+	}
+	for _, name := range names {
+		f, err := r.open(ctx, a.dir(false)+"/"+name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		var m mavenMetadata
+		err = xml.NewDecoder(f).Decode(&m)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("parse %s: %w", name, err)
+		}
+		switch {
+		case m.Versioning.Release != "":
+			return m.Versioning.Release, nil
+		case m.Versioning.Latest != "":
+			return m.Versioning.Latest, nil
+		case len(m.Versioning.Versions) > 0:
+			return highestVersion(m.Versioning.Versions), nil
+		}
+	}
+
+	if r.local {
+		entries, err := os.ReadDir(filepath.Join(r.base, filepath.FromSlash(a.dir(false))))
+		if err == nil {
+			var versions []string
+			for _, e := range entries {
+				if e.IsDir() {
+					versions = append(versions, e.Name())
+				}
+			}
+			if len(versions) > 0 {
+				return highestVersion(versions), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("artifact %s:%s not found in %s", a.Group, a.Artifact, r.base)
+}
+
+// highestVersion returns the highest of a list of versions.
+func highestVersion(versions []string) string {
+	return slices.MaxFunc(versions, compareVersions)
+}
+
+// compareVersions orders versions by their leading numeric parts, separated
+// by dots and dashes, so 33.0.0-jre is above 19.0. Versions with the same
+// numbers are ordered by the rest, their qualifier: a release is above any
+// qualified version, -SNAPSHOT below any other qualifier, and other
+// qualifiers compare as text.
+func compareVersions(a, b string) int {
+	na, qa := splitVersion(a)
+	nb, qb := splitVersion(b)
+	for i := 0; i < max(len(na), len(nb)); i++ {
+		var x, y int
+		if i < len(na) {
+			x = na[i]
+		}
+		if i < len(nb) {
+			y = nb[i]
+		}
+		if x != y {
+			return cmp.Compare(x, y)
+		}
+	}
+
+	rank := func(q string) int {
+		switch {
+		case q == "":
+			return 2
+		case strings.EqualFold(q, "SNAPSHOT"):
+			return 0
+		}
+		return 1
+	}
+	if c := cmp.Compare(rank(qa), rank(qb)); c != 0 {
+		return c
+	}
+	return strings.Compare(strings.ToLower(qa), strings.ToLower(qb))
+}
+
+// splitVersion splits a version into its leading numeric parts and the
+// qualifier after them: 2.0.0-rc1 is [2 0 0] and "rc1".
+func splitVersion(v string) ([]int, string) {
+	var nums []int
+	rest := v
+	for rest != "" {
+		end := strings.IndexAny(rest, ".-")
+		if end < 0 {
+			end = len(rest)
+		}
+		n, err := strconv.Atoi(rest[:end])
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+		rest = strings.TrimLeft(rest[end:], ".-")
+	}
+	return nums, rest
+}
+
+// pomProject holds the fields read from an artifact's pom.
+type pomProject struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	URL         string `xml:"url"`
+}
+
+// project reads the name and description of the artifact from its pom. A
+// missing or unreadable pom leaves them empty.
+func (r repository) project(ctx context.Context, a artifact) pomProject {
+	var pom pomProject
+	f, err := r.open(ctx, a.file("", "pom"))
+	if err != nil {
+		return pom
+	}
+	defer f.Close()
+	_ = xml.NewDecoder(f).Decode(&pom)
+	pom.Description = strings.Join(strings.Fields(pom.Description), " ")
+	return pom
+}
+
+// readArtifact reads the API of an artifact from its javadoc jar, or from
+// its sources jar when it has none or the javadoc is not in a format that is
+// understood, as the Dokka HTML of Kotlin libraries and the empty javadoc
+// jars some publish to satisfy Maven Central are not.
+func readArtifact(ctx context.Context, repo repository, a artifact, c *cache.FilesystemCache) (*artifactDocs, error) {
+	jarPath, cleanup, err := repo.jar(ctx, a, "javadoc", c)
+	switch {
+	case err == nil:
+		docs, err := readJar(jarPath, readJavadoc)
+		cleanup()
+		if err != nil {
+			return nil, err
+		}
+		if len(docs.Types) > 0 {
+			return docs, nil
+		}
+		log.Info("javadoc jar has no class pages, reading sources", "artifact", a)
+	case errors.Is(err, fs.ErrNotExist):
+		log.Info("artifact has no javadoc jar, reading sources", "artifact", a)
+	default:
+		return nil, err
+	}
+
+	jarPath, cleanup, err = repo.jar(ctx, a, "sources", c)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s has neither a javadoc nor a sources jar in %s", a, repo.base)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	return readJar(jarPath, readSources)
+}
+
+func readJar(jarPath string, read func(*zip.Reader) (*artifactDocs, error)) (*artifactDocs, error) {
+	zr, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", filepath.Base(jarPath), err)
+	}
+	defer zr.Close()
+	return read(&zr.Reader)
+}
+
+// jar returns the path of one of an artifact's jars. A local repository's
+// jar is read in place; a remote one is downloaded through the cache when
+// there is one. Either is checked against the .sha1 file next to it when
+// the repository has one.
+func (r repository) jar(ctx context.Context, a artifact, classifier string, c *cache.FilesystemCache) (string, func(), error) {
+	p := a.file(classifier, "jar")
+	want := r.checksum(ctx, p)
+
+	if r.local {
+		jarPath := filepath.Join(r.base, filepath.FromSlash(p))
+		if _, err := os.Stat(jarPath); err != nil {
+			return "", nil, err
+		}
+		if err := checkSHA1(jarPath, want); err != nil {
+			return "", nil, err
+		}
+		return jarPath, func() {}, nil
+	}
+
+	cacheKey := cache.MavenKey(a.Group, a.Artifact, a.Version, classifier)
+	if c != nil {
+		if cached, _, err := c.Get(cacheKey); err == nil {
+			if checkSHA1(cached, want) == nil {
+				return cached, func() {}, nil
+			}
+			_ = c.Delete(cacheKey)
+		}
+	}
+
+	body, err := r.open(ctx, p)
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	var jarPath string
+	cleanup := func() {}
+	if c != nil {
+		entry, err := c.Put(cacheKey, r.base+"/"+p, body, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		jarPath = filepath.Join(c.Dir(), entry.Path)
+	} else {
+		f, err := os.CreateTemp("", "documango-maven-*.jar")
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		jarPath = f.Name()
+		cleanup = func() { _ = os.Remove(jarPath) }
+		if _, err := io.Copy(f, body); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+
+	if err := checkSHA1(jarPath, want); err != nil {
+		if c != nil {
+			_ = c.Delete(cacheKey)
+		}
+		cleanup()
+		return "", nil, err
+	}
+	return jarPath, cleanup, nil
+}
+
+// checksum returns the SHA-1 digest published next to a repository file, or
+// "" when there is none.
+func (r repository) checksum(ctx context.Context, p string) string {
+	f, err := r.open(ctx, p+".sha1")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	raw, err := io.ReadAll(io.LimitReader(f, 1024))
+	if err != nil {
+		return ""
+	}
+	// The file holds the hex digest, followed by the file name in some
+	// older repositories.
+	if fields := strings.Fields(string(raw)); len(fields) > 0 {
+		return strings.ToLower(fields[0])
+	}
+	return ""
+}
+
+func checkSHA1(file, want string) error {
+	if want == "" {
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%s: sha1 %s does not match %s", filepath.Base(file), got, want)
+	}
+	return nil
+}
+
+// writeArtifact writes the documents of an artifact: one per type, one per
+// package and an index, replacing those of a previous ingest.
+func writeArtifact(ctx context.Context, tx *sql.Tx, a artifact, pom pomProject, docs *artifactDocs) error {
+	prefix := DocPrefix(a.Group, a.Artifact)
+	old, err := db.DocumentHashesTx(ctx, tx, prefix)
+	if err != nil {
+		return err
+	}
+	for p := range old {
+		if err := db.DeleteDocumentTx(ctx, tx, p); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range docs.Types {
+		docID, err := insertDocument(ctx, tx, prefix+t.FullName(), t.Markdown)
+		if err != nil {
+			return err
+		}
+		if err := insertSymbol(ctx, tx, docID, t.FullName(), t.Kind, t.Signature, t.Summary); err != nil {
+			return err
+		}
+		if err := insertMembers(ctx, tx, docID, t.FullName(), t.Members); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range docs.packageNames() {
+		pkg := docs.Packages[name]
+		docID, err := insertDocument(ctx, tx, prefix+name, renderPackage(pkg, docs.typesOf(name), docs.Format))
+		if err != nil {
+			return err
+		}
+		if err := insertSymbol(ctx, tx, docID, name, "Package", "package "+name, pkg.Summary); err != nil {
+			return err
+		}
+		if err := insertMembers(ctx, tx, docID, name, pkg.Members); err != nil {
+			return err
+		}
+	}
+
+	title, md := renderIndex(a, pom, docs)
+	docID, err := docset.WriteDocument(ctx, tx, prefix+"index", title, md)
+	if err != nil {
+		return err
+	}
+	return insertSymbol(ctx, tx, docID, a.Group+":"+a.Artifact, "Artifact", a.String(), pom.Description)
+}
+
+func insertDocument(ctx context.Context, tx *sql.Tx, docPath, md string) (int64, error) {
+	return db.InsertDocumentTx(ctx, tx, db.Document{
+		Path:   docPath,
+		Format: "markdown",
+		Body:   shared.Compress(md),
+		Hash:   db.HashBytes([]byte(md)),
+	})
+}
+
+func insertSymbol(ctx context.Context, tx *sql.Tx, docID int64, symbol, kind, signature, summary string) error {
+	if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+		Name:  symbol,
+		Type:  kind,
+		Body:  strings.TrimSpace(symbol + " " + signature + " " + summary),
+		DocID: docID,
+	}); err != nil {
+		return err
+	}
+	return db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+		DocID:     docID,
+		Symbol:    symbol,
+		Signature: signature,
+		Summary:   summary,
+	})
+}
+
+// insertMembers indexes the members of a type or package as parent.name,
+// pointing at the parent's document. Overloads share one entry whose
+// signature lists them all.
+func insertMembers(ctx context.Context, tx *sql.Tx, docID int64, parent string, members []javaMember) error {
+	var (
+		order  []string
+		merged = map[string]*javaMember{}
+	)
+	for _, m := range members {
+		key := m.Kind + " " + m.Name
+		if have, ok := merged[key]; ok {
+			have.Signature += "\n" + m.Signature
+			if have.Summary == "" {
+				have.Summary = m.Summary
+			}
+			continue
+		}
+		m := m
+		merged[key] = &m
+		order = append(order, key)
+	}
+	for _, key := range order {
+		m := merged[key]
+		if err := insertSymbol(ctx, tx, docID, parent+"."+m.Name, m.Kind, m.Signature, m.Summary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderIndex renders the artifact's index document: its pom description
+// and its packages.
+func renderIndex(a artifact, pom pomProject, docs *artifactDocs) (title, md string) {
+	title = a.Group + ":" + a.Artifact
+	var b strings.Builder
+	b.WriteString("# " + title + "\n\n")
+	if pom.Name != "" && pom.Name != a.Artifact {
+		b.WriteString("**" + pom.Name + "**\n\n")
+	}
+	if pom.Description != "" {
+		b.WriteString(pom.Description + "\n\n")
+	}
+	b.WriteString("Version: " + a.Version + "\\\n")
+	if pom.URL != "" {
+		b.WriteString("Project: <" + pom.URL + ">\\\n")
+	}
+	b.WriteString("Read from: " + docs.Format + " jar\n\n")
+
+	b.WriteString("```xml\n<dependency>\n  <groupId>" + a.Group + "</groupId>\n  <artifactId>" + a.Artifact + "</artifactId>\n  <version>" + a.Version + "</version>\n</dependency>\n```\n\n")
+
+	if names := docs.packageNames(); len(names) > 0 {
+		b.WriteString("## Packages\n\n")
+		for _, name := range names {
+			b.WriteString("- `" + name + "`")
+			if summary := docs.Packages[name].Summary; summary != "" {
+				b.WriteString(": " + summary)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return title, strings.TrimSpace(b.String()) + "\n"
+}
+
+// packagePath turns the directory of a file in a jar into a package name.
+func packagePath(dir string) string {
+	dir = path.Clean(dir)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return strings.ReplaceAll(strings.Trim(dir, "/"), "/", ".")
+}
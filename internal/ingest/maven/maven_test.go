@@ -0,0 +1,290 @@
+package maven
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/codec"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct{ coord, group, artifact, version string }{
+		{"com.google.code.gson:gson", "com.google.code.gson", "gson", ""},
+		{"com.google.guava:guava:33.0.0-jre", "com.google.guava", "guava", "33.0.0-jre"},
+	}
+	for _, tt := range tests {
+		group, artifact, version, err := ParseCoordinate(tt.coord)
+		if err != nil || group != tt.group || artifact != tt.artifact || version != tt.version {
+			t.Errorf("ParseCoordinate(%q) = %q, %q, %q, %v", tt.coord, group, artifact, version, err)
+		}
+	}
+	for _, coord := range []string{"gson", ":gson", "a:b:c:d"} {
+		if _, _, _, err := ParseCoordinate(coord); err == nil {
+			t.Errorf("ParseCoordinate(%q) succeeded", coord)
+		}
+	}
+}
+
+func TestHighestVersion(t *testing.T) {
+	tests := []struct {
+		versions []string
+		want     string
+	}{
+		{[]string{"1.9", "1.10", "1.2"}, "1.10"},
+		{[]string{"2.0.0-SNAPSHOT", "1.9.1", "2.0.0-rc1"}, "2.0.0-rc1"},
+		{[]string{"2.0.0-rc1", "2.0.0", "2.0.0-SNAPSHOT"}, "2.0.0"},
+		{[]string{"19.0", "33.0.0-jre", "18.0"}, "33.0.0-jre"},
+		{[]string{"2.0-rc1", "2.0-rc2", "1.0-beta"}, "2.0-rc2"},
+		{[]string{"33.0.0-jre", "32.1.3-jre", "33.0.0-android"}, "33.0.0-jre"},
+	}
+	for _, tt := range tests {
+		if got := highestVersion(tt.versions); got != tt.want {
+			t.Errorf("highestVersion(%q) = %q, want %q", tt.versions, got, tt.want)
+		}
+	}
+}
+
+// writeRepoFile writes a file of a repository laid out under root, with the
+// .sha1 file Maven publishes next to it.
+func writeRepoFile(t *testing.T, root, name string, data []byte) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum(data)
+	if err := os.WriteFile(p+".sha1", []byte(hex.EncodeToString(sum[:])), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func jarBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const packageSummaryPage = `<!DOCTYPE HTML>
+<html lang="en">
+<body class="package-declaration-page">
+<main role="main">
+<div class="header"><h1 title="Package com.example.cache" class="title">Package com.example.cache</h1></div>
+<section class="package-description" id="package-description">
+<div class="block">Caches for computed values. Start with <a href="Cache.html"><code>Cache</code></a>.</div>
+</section>
+</main>
+</body>
+</html>
+`
+
+// writeDemoRepository lays out a repository holding com.example:demo, with
+// a javadoc jar, and com.example:text, with only a sources jar.
+func writeDemoRepository(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeRepoFile(t, root, "com/example/demo/maven-metadata.xml", []byte(`<metadata>
+  <groupId>com.example</groupId>
+  <artifactId>demo</artifactId>
+  <versioning>
+    <latest>1.2.0-SNAPSHOT</latest>
+    <release>1.1.0</release>
+    <versions><version>1.0.0</version><version>1.1.0</version><version>1.2.0-SNAPSHOT</version></versions>
+  </versioning>
+</metadata>`))
+	writeRepoFile(t, root, "com/example/demo/1.1.0/demo-1.1.0.pom", []byte(`<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>demo</artifactId>
+  <version>1.1.0</version>
+  <name>Demo Cache</name>
+  <description>
+    Caches computed values.
+  </description>
+  <url>https://example.com/demo</url>
+</project>`))
+	writeRepoFile(t, root, "com/example/demo/1.1.0/demo-1.1.0-javadoc.jar", jarBytes(t, map[string]string{
+		"index.html":                                    "<html><body><h1>demo 1.1.0 API</h1></body></html>",
+		"allclasses-index.html":                         "<html><body></body></html>",
+		"com/example/cache/package-summary.html":        packageSummaryPage,
+		"com/example/cache/package-tree.html":           "<html><body></body></html>",
+		"com/example/cache/Cache.html":                  modernClassPage,
+		"com/example/cache/Cache.Policy.html":           legacyClassPage,
+		"com/example/cache/class-use/Cache.html":        modernClassPage,
+		"src-html/com/example/cache/Cache.html":         "<html><body><pre>class Cache</pre></body></html>",
+		"META-INF/MANIFEST.MF":                          "Manifest-Version: 1.0\n",
+		"com/example/cache/doc-files/architecture.html": "<html><body><h1 class=\"title\">Class Diagram</h1></body></html>",
+	}))
+
+	writeRepoFile(t, root, "com/example/text/2.0.0/text-2.0.0-sources.jar", jarBytes(t, map[string]string{
+		"com/example/text/Strings.kt": kotlinFixture,
+		"META-INF/MANIFEST.MF":        "Manifest-Version: 1.0\n",
+	}))
+	return root
+}
+
+func TestIngestArtifact(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	root := writeDemoRepository(t)
+	if err := IngestArtifact(ctx, Options{Group: "com.example", Artifact: "demo", Repository: root, DB: store}); err != nil {
+		t.Fatal(err)
+	}
+
+	readDoc := func(p string) string {
+		t.Helper()
+		doc, err := store.ReadDocument(ctx, p)
+		if err != nil {
+			t.Fatalf("ReadDocument(%s): %v", p, err)
+		}
+		raw, err := codec.Decompress(doc.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	index := readDoc("maven/com.example/demo/index")
+	for _, want := range []string{
+		"# com.example:demo\n\n**Demo Cache**\n\nCaches computed values.",
+		"Version: 1.1.0",
+		"Read from: javadoc jar",
+		"<artifactId>demo</artifactId>",
+		"## Packages\n\n- `com.example.cache`: Caches for computed values.",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index document lacks %q:\n%s", want, index)
+		}
+	}
+	pkg := readDoc("maven/com.example/demo/com.example.cache")
+	for _, want := range []string{"# Package com.example.cache", "Start with `Cache`.", "- `Cache`: A cache of computed values.", "- `Cache.Policy`: Eviction policies."} {
+		if !strings.Contains(pkg, want) {
+			t.Errorf("package document lacks %q:\n%s", want, pkg)
+		}
+	}
+	if class := readDoc("maven/com.example/demo/com.example.cache.Cache"); !strings.Contains(class, "Returns the value for a key.") {
+		t.Errorf("class document =\n%s", class)
+	}
+	if _, err := store.ReadDocument(ctx, "maven/com.example/demo/com.example.cache.Class Diagram"); err == nil {
+		t.Error("doc-files page ingested as a class")
+	}
+
+	for symbol, want := range map[string]string{
+		"com.example:demo":                   "com.example:demo:1.1.0",
+		"com.example.cache.Cache":            "public final class Cache<K,V> extends Object implements AutoCloseable",
+		"com.example.cache.Cache.get":        "public V get(K key)",
+		"com.example.cache.Cache.Policy.LRU": "public static final Cache.Policy LRU",
+	} {
+		sym, err := store.GetSymbolContext(ctx, symbol)
+		if err != nil || sym.Signature != want {
+			t.Errorf("GetSymbolContext(%s) = %+v, %v", symbol, sym, err)
+		}
+	}
+	results, err := store.Search(ctx, "maven/com.example/demo/shutdown", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(results, func(r db.SearchResult) bool {
+		return r.Name == "com.example.cache.Cache.shutdown" && r.Type == "Method"
+	}) {
+		t.Errorf("search for maven/com.example/demo/shutdown = %+v", results)
+	}
+
+	// Without a javadoc jar the sources jar is read.
+	if err := IngestArtifact(ctx, Options{Group: "com.example", Artifact: "text", Repository: "file://" + filepath.ToSlash(root), DB: store}); err != nil {
+		t.Fatal(err)
+	}
+	if index := readDoc("maven/com.example/text/index"); !strings.Contains(index, "Version: 2.0.0") || !strings.Contains(index, "Read from: sources jar") {
+		t.Errorf("text index document =\n%s", index)
+	}
+	if kt := readDoc("maven/com.example/text/com.example.text"); !strings.Contains(kt, "fun String.padTo(length: Int, padChar: Char = PAD): String") {
+		t.Errorf("Kotlin package document =\n%s", kt)
+	}
+	sym, err := store.GetSymbolContext(ctx, "com.example.text.Text.join")
+	if err != nil || sym.Summary != "Joins texts." {
+		t.Errorf("GetSymbolContext(com.example.text.Text.join) = %+v, %v", sym, err)
+	}
+
+	err = IngestArtifact(ctx, Options{Group: "com.example", Artifact: "demo", Version: "1.0.0", Repository: root, DB: store})
+	if err == nil || !strings.Contains(err.Error(), "neither a javadoc nor a sources jar") {
+		t.Errorf("IngestArtifact(1.0.0) = %v", err)
+	}
+}
+
+func TestRemoteRepository(t *testing.T) {
+	ctx := context.Background()
+	root := writeDemoRepository(t)
+	srv := httptest.NewServer(http.FileServer(http.Dir(root)))
+	t.Cleanup(srv.Close)
+
+	c, err := cache.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newRepository(srv.URL + "/")
+	a := artifact{Group: "com.example", Artifact: "demo"}
+	if a.Version, err = repo.latestVersion(ctx, a); err != nil || a.Version != "1.1.0" {
+		t.Fatalf("latestVersion() = %q, %v", a.Version, err)
+	}
+	if pom := repo.project(ctx, a); pom.Name != "Demo Cache" || pom.URL != "https://example.com/demo" {
+		t.Errorf("project() = %+v", pom)
+	}
+
+	docs, err := readArtifact(ctx, repo, a, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs.Format != "javadoc" || len(docs.Types) != 2 {
+		t.Errorf("readArtifact() = %s with %d types", docs.Format, len(docs.Types))
+	}
+	if !c.Has(cache.MavenKey("com.example", "demo", "1.1.0", "javadoc")) {
+		t.Error("javadoc jar not cached")
+	}
+
+	// A jar that does not match its checksum is rejected.
+	jar := filepath.Join(root, "com/example/text/2.0.0/text-2.0.0-sources.jar")
+	if err := os.WriteFile(jar+".sha1", []byte("0000000000000000000000000000000000000000  text-2.0.0-sources.jar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = readArtifact(ctx, repo, artifact{Group: "com.example", Artifact: "text", Version: "2.0.0"}, c)
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("readArtifact() with a bad checksum = %v", err)
+	}
+	if c.Has(cache.MavenKey("com.example", "text", "2.0.0", "sources")) {
+		t.Error("jar with a bad checksum left in the cache")
+	}
+}
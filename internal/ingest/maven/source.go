@@ -0,0 +1,732 @@
+package maven
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// readSources reads the Java and Kotlin files of a sources jar. A
+// package-info.java file documents its package.
+func readSources(zr *zip.Reader) (*artifactDocs, error) {
+	docs := &artifactDocs{Format: "sources"}
+	for _, f := range zr.File {
+		ext := path.Ext(f.Name)
+		if ext != ".java" && ext != ".kt" || strings.HasPrefix(f.Name, "META-INF/") || path.Base(f.Name) == "module-info.java" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		src, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		file := parseSource(string(src), ext == ".kt")
+		p := docs.pkg(file.Package)
+		if path.Base(f.Name) == "package-info.java" {
+			c := parseComment(file.Doc, false)
+			p.Doc, p.Summary = c.markdown(), c.summary(false)
+			continue
+		}
+		if len(file.Members) > 0 {
+			p.Lang = "kotlin"
+			p.Members = append(p.Members, file.Members...)
+		}
+		docs.Types = append(docs.Types, file.Types...)
+	}
+	docs.sortTypes()
+	return docs, nil
+}
+
+// sourceFile is what a Java or Kotlin file declares.
+type sourceFile struct {
+	Package string
+	Doc     string // the comment before the package statement
+	Types   []*javaType
+	Members []javaMember // top-level Kotlin functions, properties and type aliases
+}
+
+// parseSource reads the public API of a Java or Kotlin file: its types, with
+// their documented and undocumented public and protected members, and its
+// top-level Kotlin declarations. Bodies are skipped; no type checking is
+// done, so declarations are recognized by their keywords.
+func parseSource(src string, kotlin bool) sourceFile {
+	p := &sourceParser{kotlin: kotlin, lang: "java"}
+	if kotlin {
+		p.lang = "kotlin"
+	}
+	decls := splitDecls(src, kotlin)
+	for i, d := range decls {
+		header := oneLine(stripAnnotations(d.header))
+		if pkg, ok := strings.CutPrefix(header, "package "); ok {
+			p.file.Package, p.file.Doc = strings.TrimSpace(pkg), d.doc
+			decls[i].header = ""
+		} else if strings.HasPrefix(header, "import ") {
+			decls[i].header = ""
+		}
+	}
+	p.file.Members = p.walk(decls, nil)
+	return p.file
+}
+
+type sourceParser struct {
+	kotlin bool
+	lang   string
+	file   sourceFile
+}
+
+// walk reads the declarations of a file, or of the body of the type outer,
+// adding the types among them to the file and returning the members.
+func (p *sourceParser) walk(decls []srcDecl, outer *javaType) []javaMember {
+	var members []javaMember
+	for _, d := range decls {
+		if d.header == "" {
+			continue
+		}
+		var item srcItem
+		if p.kotlin {
+			item = kotlinItem(d.header, outer)
+		} else {
+			item = javaItem(d.header, outer)
+		}
+		comment := parseComment(d.doc, p.kotlin)
+		if item.kind == "" || !item.public || comment.hidden() {
+			continue
+		}
+
+		if !item.isType {
+			members = append(members, javaMember{
+				Name:      item.name,
+				Kind:      item.kind,
+				Signature: item.signature,
+				Summary:   comment.summary(item.deprecated),
+				Doc:       comment.markdown(),
+			})
+			continue
+		}
+
+		t := &javaType{Package: p.file.Package, Name: item.name, Kind: item.kind, Signature: item.signature}
+		if outer != nil {
+			t.Name = outer.Name + "." + item.name
+		}
+		t.Summary = comment.summary(item.deprecated)
+		p.file.Types = append(p.file.Types, t)
+
+		body := d.body
+		if t.Kind == "Enum" {
+			var constants []srcDecl
+			constants, body = enumConstants(body, p.kotlin)
+			for _, c := range constants {
+				name := enumConstantName(c.header)
+				if name == "" {
+					continue
+				}
+				sig := name
+				if !p.kotlin {
+					sig = "public static final " + item.name + " " + name
+				}
+				comment := parseComment(c.doc, p.kotlin)
+				t.Members = append(t.Members, javaMember{
+					Name:      name,
+					Kind:      "EnumConstant",
+					Signature: sig,
+					Summary:   comment.summary(strings.Contains(c.header, "@Deprecated")),
+					Doc:       comment.markdown(),
+				})
+			}
+		}
+		t.Members = append(t.Members, p.walk(splitDecls(body, p.kotlin), t)...)
+		t.Markdown = renderType(t, comment.markdown(), p.lang)
+	}
+	return members
+}
+
+// srcItem is a classified declaration.
+type srcItem struct {
+	kind, name, signature string
+	isType                bool
+	public                bool
+	deprecated            bool
+}
+
+var javaModifiers = []string{
+	"public", "protected", "private", "static", "final", "abstract", "sealed", "non-sealed",
+	"default", "synchronized", "native", "transient", "volatile", "strictfp",
+}
+
+var javaTypeKinds = map[string]string{
+	"class":      "Class",
+	"interface":  "Interface",
+	"enum":       "Enum",
+	"record":     "Record",
+	"@interface": "Annotation",
+}
+
+var (
+	javaMethodName = regexp.MustCompile(`([\w$]+)\s*\(`)
+	identifier     = regexp.MustCompile(`^[\w$]+`)
+)
+
+// javaItem classifies a Java declaration in the body of outer, or at the top
+// level of a file when outer is nil. Interface and annotation members are
+// public unless private; others must be public or protected.
+func javaItem(header string, outer *javaType) srcItem {
+	item := srcItem{deprecated: strings.Contains(header, "@Deprecated")}
+	text := signatureSpace.Replace(oneLine(stripAnnotations(header)))
+	words := strings.Fields(text)
+	k := 0
+	for k < len(words) && slices.Contains(javaModifiers, words[k]) {
+		k++
+	}
+	mods := words[:k]
+	implicit := outer != nil && (outer.Kind == "Interface" || outer.Kind == "Annotation")
+	item.public = slices.Contains(mods, "public") || slices.Contains(mods, "protected") ||
+		implicit && !slices.Contains(mods, "private")
+	item.signature = text
+
+	if k+1 < len(words) {
+		if kind, ok := javaTypeKinds[words[k]]; ok {
+			item.kind, item.isType = kind, true
+			item.name = identifier.FindString(words[k+1])
+			return item
+		}
+	}
+
+	decl, init := splitInitializer(text)
+	if m := javaMethodName.FindStringSubmatch(decl); m != nil && init == "" {
+		item.name, item.kind = m[1], "Method"
+		switch {
+		case outer != nil && item.name == simpleName(outer.Name):
+			item.kind = "Constructor"
+		case outer != nil && outer.Kind == "Annotation":
+			item.kind = "Element"
+		}
+		return item
+	}
+
+	// A field needs a type and a name; a lone word is an initializer block
+	// or a record's compact constructor. Constants keep a short initializer
+	// that is not a lambda or an instance.
+	if len(words)-k < 2 || outer == nil {
+		return srcItem{}
+	}
+	first, _ := splitTopLevel(decl, ',')
+	fields := strings.Fields(strings.ReplaceAll(first, "[]", " "))
+	item.name, item.kind = fields[len(fields)-1], "Field"
+	item.signature = first
+	if init != "" && slices.Contains(mods, "static") && slices.Contains(mods, "final") && len(init) <= 40 && !strings.HasPrefix(init, "new ") && !strings.ContainsAny(init, "{>") {
+		item.signature += " = " + init
+	}
+	return item
+}
+
+var kotlinModifiers = []string{
+	"public", "private", "protected", "internal", "open", "final", "abstract", "sealed", "data",
+	"inline", "value", "enum", "annotation", "inner", "companion", "override", "lateinit", "const",
+	"suspend", "operator", "infix", "tailrec", "external", "expect", "actual",
+}
+
+// kotlinAccessor matches the getter or setter lines following a property.
+var kotlinAccessor = regexp.MustCompile(`^(?:(?:public|protected|private|internal)\s+)?(?:get|set)\b`)
+
+// kotlinItem classifies a Kotlin declaration in the body of outer, or at the
+// top level of a file when outer is nil. Declarations are public unless
+// private or internal.
+func kotlinItem(header string, outer *javaType) srcItem {
+	item := srcItem{deprecated: strings.Contains(header, "@Deprecated")}
+
+	// Getters and setters on the lines after a property are not part of its
+	// declaration.
+	lines := strings.Split(stripAnnotations(header), "\n")
+	for i, line := range lines {
+		if i > 0 && kotlinAccessor.MatchString(strings.TrimSpace(line)) {
+			lines = lines[:i]
+			break
+		}
+	}
+	text := signatureSpace.Replace(oneLine(strings.Join(lines, "\n")))
+	words := strings.Fields(text)
+	k := 0
+	for k < len(words) && slices.Contains(kotlinModifiers, words[k]) {
+		k++
+	}
+	if k == len(words) {
+		return srcItem{}
+	}
+	mods := words[:k]
+	item.public = !slices.Contains(mods, "private") && !slices.Contains(mods, "internal")
+	item.signature = text
+	next := ""
+	if k+1 < len(words) {
+		next = strings.Trim(identifier.FindString(strings.Trim(words[k+1], "`")), "`")
+	}
+
+	switch identifier.FindString(words[k]) {
+	case "class":
+		item.kind, item.isType, item.name = "Class", true, next
+		switch {
+		case slices.Contains(mods, "enum"):
+			item.kind = "Enum"
+		case slices.Contains(mods, "annotation"):
+			item.kind = "Annotation"
+		}
+	case "interface":
+		item.kind, item.isType, item.name = "Interface", true, next
+	case "object":
+		item.kind, item.isType, item.name = "Object", true, next
+		if item.name == "" {
+			item.name = "Companion"
+		}
+	case "typealias":
+		item.kind, item.name = "TypeAlias", next
+	case "constructor":
+		if outer == nil {
+			return srcItem{}
+		}
+		item.kind, item.name = "Constructor", simpleName(outer.Name)
+		item.signature, _ = splitInitializer(text)
+	case "fun":
+		if next == "interface" {
+			item.kind, item.isType = "Interface", true
+			if k+2 < len(words) {
+				item.name = identifier.FindString(words[k+2])
+			}
+			break
+		}
+		item.signature, _ = splitInitializer(text)
+		_, rest, _ := strings.Cut(" "+item.signature, " fun ")
+		receiver, _, _ := strings.Cut(skipTypeParams(strings.TrimSpace(rest)), "(")
+		item.name = strings.Trim(lastSegment(strings.TrimSpace(receiver)), "`")
+		item.kind = "Function"
+		if outer != nil {
+			item.kind = "Method"
+		}
+	case "val", "var":
+		decl, init := splitInitializer(text)
+		decl, _, _ = strings.Cut(decl, " by ")
+		item.signature = strings.TrimSpace(decl)
+		if init != "" && slices.Contains(mods, "const") {
+			item.signature += " = " + init
+		}
+		_, rest, _ := strings.Cut(" "+text, " "+words[k]+" ")
+		name, _ := splitTopLevel(skipTypeParams(strings.TrimSpace(rest)), ':')
+		name, _, _ = strings.Cut(name, "=")
+		item.name = strings.Trim(lastSegment(strings.TrimSpace(name)), "`")
+		item.kind = "Property"
+	default:
+		return srcItem{}
+	}
+	if item.name == "" {
+		return srcItem{}
+	}
+	return item
+}
+
+// signatureSpace removes the spaces left inside brackets when a declaration
+// wrapped over lines, with one parameter per line, is put on one line.
+var signatureSpace = strings.NewReplacer(", )", ")", "( ", "(", " )", ")", ",)", ")")
+
+// skipTypeParams drops the type parameters at the start of a function or
+// property declaration: fun <T> T.also(...).
+func skipTypeParams(s string) string {
+	if !strings.HasPrefix(s, "<") {
+		return s
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(s[i+1:])
+			}
+		}
+	}
+	return s
+}
+
+// lastSegment returns the name of an extension after its receiver type:
+// padStart for String.padStart, first for List<T>.first.
+func lastSegment(s string) string {
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case '.':
+			if depth == 0 {
+				start = i + 1
+			}
+		}
+	}
+	return s[start:]
+}
+
+func simpleName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// enumConstantName returns the name of an enum constant, without its
+// annotations, arguments and body.
+func enumConstantName(header string) string {
+	return identifier.FindString(strings.TrimSpace(stripAnnotations(header)))
+}
+
+// splitInitializer splits a declaration at its initializer or expression
+// body: the first = outside brackets that is not part of an operator.
+func splitInitializer(s string) (decl, init string) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\'':
+			i = skipLiteral(s, i, false) - 1
+		case '(', '[', '<', '{':
+			depth++
+		case ')', ']', '>', '}':
+			if i > 0 && s[i-1] == '-' && c == '>' {
+				continue
+			}
+			depth--
+		case '=':
+			if depth == 0 && isAssign(s, i) {
+				return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+			}
+		}
+	}
+	return s, ""
+}
+
+// splitTopLevel splits s at the first sep outside brackets.
+func splitTopLevel(s string, sep byte) (before, after string) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '(' || c == '[' || c == '<':
+			depth++
+		case c == ')' || c == ']' || c == '>':
+			depth--
+		case c == sep && depth == 0:
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+		}
+	}
+	return strings.TrimSpace(s), ""
+}
+
+func isAssign(s string, i int) bool {
+	if i+1 < len(s) && (s[i+1] == '=' || s[i+1] == '>') {
+		return false
+	}
+	return i == 0 || !strings.ContainsRune("=!<>+-*/%&|^:", rune(s[i-1]))
+}
+
+// srcDecl is a declaration of a Java or Kotlin file or type body: its doc
+// comment, its header up to its body or end, and the text of its body when
+// it has a block one.
+type srcDecl struct {
+	doc    string
+	header string
+	body   string
+}
+
+// splitDecls splits source text into declarations. A Java declaration ends
+// at a semicolon or after its block; a Kotlin one also at a line break that
+// neither its line nor the next continues. Blocks following an initializer,
+// such as lambdas and anonymous classes, belong to the declaration.
+func splitDecls(src string, kotlin bool) []srcDecl {
+	var (
+		decls []srcDecl
+		doc   string
+	)
+	for i := 0; i < len(src); {
+		switch c := src[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "/**") && !strings.HasPrefix(src[i:], "/**/"):
+			end := skipComment(src, i)
+			doc, i = src[i:end], end
+			continue
+		case strings.HasPrefix(src[i:], "//") || strings.HasPrefix(src[i:], "/*"):
+			i = skipComment(src, i)
+			continue
+		}
+
+		hdrEnd, bodyStart, bodyEnd, next := scanDecl(src, i, kotlin)
+		d := srcDecl{doc: doc, header: strings.TrimSpace(removeComments(src[i:hdrEnd], kotlin))}
+		if bodyStart >= 0 {
+			d.body = src[bodyStart:bodyEnd]
+		}
+		if d.header != "" {
+			decls = append(decls, d)
+		}
+		doc, i = "", next
+	}
+	return decls
+}
+
+// scanDecl scans the declaration starting at i. It returns the end of its
+// header, the bounds of its body or -1 when it has none, and where the text
+// after it starts.
+func scanDecl(src string, i int, kotlin bool) (hdrEnd, bodyStart, bodyEnd, next int) {
+	depth, assign := 0, false
+	for j := i; j < len(src); {
+		switch c := src[j]; {
+		case c == '"' || c == '\'':
+			j = skipLiteral(src, j, kotlin)
+			continue
+		case c == '/' && j+1 < len(src) && (src[j+1] == '/' || src[j+1] == '*'):
+			j = skipComment(src, j)
+			continue
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth = max(depth-1, 0)
+		case depth > 0:
+		case c == '=' && isAssign(src, j):
+			assign = true
+		case c == '{':
+			end := matchBrace(src, j, kotlin)
+			if assign {
+				j = min(end+1, len(src))
+				continue
+			}
+			return j, j + 1, end, min(end+1, len(src))
+		case c == ';' || c == '}':
+			return j, -1, -1, j + 1
+		case c == '\n' && kotlin && kotlinEnds(src, i, j):
+			return j, -1, -1, j + 1
+		}
+		j++
+	}
+	return len(src), -1, -1, len(src)
+}
+
+// kotlinNext are the starts of a line that continue the declaration on the
+// line before it.
+var kotlinNext = []string{".", "?.", "?:", ":", "=", "{", "->", "&&", "||", ")", "]", "where ", "by "}
+
+// kotlinEnds reports whether the line break at j ends the Kotlin declaration
+// starting at start: the declaration holds more than annotations, its line
+// does not end in an operator or comma, and the next line does not start
+// with one or with a getter or setter.
+func kotlinEnds(src string, start, j int) bool {
+	text := strings.TrimSpace(stripAnnotations(removeComments(src[start:j], true)))
+	if text == "" {
+		return false
+	}
+	if strings.HasPrefix(text, "import ") || strings.HasPrefix(text, "package ") {
+		return true
+	}
+	if strings.HasSuffix(text, "->") || strings.ContainsRune(",.(=:+-*/|&<", rune(text[len(text)-1])) {
+		return false
+	}
+	next := strings.TrimSpace(src[j:])
+	for _, prefix := range kotlinNext {
+		if strings.HasPrefix(next, prefix) {
+			return false
+		}
+	}
+	return !kotlinAccessor.MatchString(next)
+}
+
+// enumConstants splits the constants off the body of an enum: the list up
+// to the first semicolon, or the whole body when there is none. It returns
+// the constants and the rest of the body.
+func enumConstants(body string, kotlin bool) ([]srcDecl, string) {
+	var (
+		constants []srcDecl
+		doc       string
+	)
+	for i := 0; i < len(body); {
+		switch c := body[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+			continue
+		case c == ';':
+			return constants, body[i+1:]
+		case strings.HasPrefix(body[i:], "/**") && !strings.HasPrefix(body[i:], "/**/"):
+			end := skipComment(body, i)
+			doc, i = body[i:end], end
+			continue
+		case strings.HasPrefix(body[i:], "//") || strings.HasPrefix(body[i:], "/*"):
+			i = skipComment(body, i)
+			continue
+		}
+
+		start, depth := i, 0
+	scan:
+		for i < len(body) {
+			switch c := body[i]; {
+			case c == '"' || c == '\'':
+				i = skipLiteral(body, i, kotlin)
+				continue
+			case c == '/' && i+1 < len(body) && (body[i+1] == '/' || body[i+1] == '*'):
+				i = skipComment(body, i)
+				continue
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			case c == '{' && depth == 0:
+				i = matchBrace(body, i, kotlin) + 1
+				continue
+			case (c == ',' || c == ';') && depth == 0:
+				break scan
+			}
+			i++
+		}
+		constants = append(constants, srcDecl{doc: doc, header: strings.TrimSpace(removeComments(body[start:min(i, len(body))], kotlin))})
+		doc = ""
+	}
+	return constants, ""
+}
+
+// matchBrace returns the index of the brace closing the one at i, or
+// len(src) when it is not closed.
+func matchBrace(src string, i int, kotlin bool) int {
+	depth := 0
+	for j := i; j < len(src); {
+		switch c := src[j]; {
+		case c == '"' || c == '\'':
+			j = skipLiteral(src, j, kotlin)
+			continue
+		case c == '/' && j+1 < len(src) && (src[j+1] == '/' || src[j+1] == '*'):
+			j = skipComment(src, j)
+			continue
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+		j++
+	}
+	return len(src)
+}
+
+// skipLiteral returns the index after the string or character literal at
+// i: a text block or raw string in triple quotes, or a quoted literal with
+// escapes. Kotlin templates such as ${a["b"]} are skipped as a whole.
+func skipLiteral(src string, i int, kotlin bool) int {
+	if strings.HasPrefix(src[i:], `"""`) {
+		if end := strings.Index(src[i+3:], `"""`); end >= 0 {
+			// A raw string may end in more quotes than three.
+			j := i + 3 + end + 3
+			for j < len(src) && src[j] == '"' {
+				j++
+			}
+			return j
+		}
+		return len(src)
+	}
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch c := src[j]; {
+		case c == '\\':
+			j++
+		case c == quote:
+			return j + 1
+		case c == '\n':
+			// An unterminated literal, or an apostrophe in text that is not
+			// code; it ends with its line.
+			return j
+		case kotlin && quote == '"' && c == '$' && j+1 < len(src) && src[j+1] == '{':
+			j = matchBrace(src, j+1, kotlin)
+		}
+	}
+	return len(src)
+}
+
+// skipComment returns the index after the comment at i. A line comment ends
+// before its line break, which may end a Kotlin declaration.
+func skipComment(src string, i int) int {
+	if strings.HasPrefix(src[i:], "//") {
+		if end := strings.IndexByte(src[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(src)
+	}
+	if end := strings.Index(src[i+2:], "*/"); end >= 0 {
+		return i + 2 + end + 2
+	}
+	return len(src)
+}
+
+// removeComments returns source text without its comments.
+func removeComments(s string, kotlin bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '"' || c == '\'':
+			end := skipLiteral(s, i, kotlin)
+			b.WriteString(s[i:end])
+			i = end
+		case c == '/' && i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '*'):
+			i = skipComment(s, i)
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// stripAnnotations removes annotations such as @Override,
+// @SuppressWarnings("unchecked") and Kotlin's @file:JvmName("X") from a
+// declaration. Java's @interface keyword is kept.
+func stripAnnotations(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\'':
+			end := skipLiteral(s, i, false)
+			b.WriteString(s[i:end])
+			i = end
+			continue
+		case c != '@' || strings.HasPrefix(s[i:], "@interface"):
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(s) && (isIdent(s[j]) || s[j] == '.' || s[j] == ':') {
+			j++
+		}
+		if j < len(s) && s[j] == '(' {
+			depth := 0
+			for ; j < len(s); j++ {
+				if s[j] == '"' || s[j] == '\'' {
+					j = skipLiteral(s, j, false) - 1
+					continue
+				}
+				if s[j] == '(' {
+					depth++
+				} else if s[j] == ')' {
+					depth--
+					if depth == 0 {
+						j++
+						break
+					}
+				}
+			}
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,381 @@
+package maven
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// javaFixture exercises generics, overloads, constructors, fields with
+// initializers, nested types, enum constants with bodies, annotations and
+// members that are not public.
+const javaFixture = `/*
+ * Copyright notice.
+ */
+package com.example.cache;
+
+import java.util.Map;
+import java.util.function.Function;
+
+/**
+ * A cache of computed values.
+ *
+ * <p>Entries expire after {@link #DEFAULT_TTL} seconds; see
+ * {@link Loader#load(Object) the loader}.
+ *
+ * @param <K> the key type
+ * @param <V> the value type
+ * @since 1.2
+ */
+@SuppressWarnings({"unchecked", "rawtypes"})
+public final class Cache<K, V extends Comparable<V>> implements AutoCloseable {
+    /** Seconds an entry lives. */
+    public static final int DEFAULT_TTL = 60;
+    private final Map<K, V> entries = new java.util.HashMap<>();
+    public static final Function<String, String> IDENTITY = s -> { return s; };
+    String packagePrivate;
+
+    static {
+        System.loadLibrary("cache");
+    }
+
+    /** Creates an empty cache. */
+    public Cache() {
+        this(DEFAULT_TTL);
+    }
+
+    /**
+     * Creates a cache.
+     * @param ttl seconds an entry lives, {@code > 0}
+     * @throws IllegalArgumentException if ttl is negative
+     */
+    public Cache(int ttl) {
+        if (ttl < 0) throw new IllegalArgumentException("ttl < 0: '" + ttl + "'");
+    }
+
+    /**
+     * Returns the value for a key.
+     *
+     * <pre>{@code
+     * V v = cache.get(key);
+     * }</pre>
+     *
+     * @param key the key
+     * @return the value, or {@code null}
+     */
+    public V get(K key) { return entries.get(key); }
+
+    /** Returns the value for a key, computing it when missing. */
+    public <R extends V> V get(K key, Function<? super K, R> loader) throws java.io.IOException {
+        return null;
+    }
+
+    /** @deprecated use {@link #close()} */
+    @Deprecated
+    protected void shutdown() {}
+
+    @Override
+    public void close() {}
+
+    private void evict() {}
+
+    /** Loads values. */
+    public interface Loader<K, V> {
+        /** Loads one value. */
+        V load(K key) throws Exception;
+
+        default String name() { return "loader"; }
+    }
+
+    /** Eviction policies. */
+    public enum Policy {
+        /** Least recently used. */
+        LRU,
+        FIFO("fifo") {
+            @Override String label() { return "first"; }
+        },
+        @Deprecated RANDOM;
+
+        Policy() {}
+        Policy(String s) {}
+
+        /** The policy's label. */
+        public String label() { return name(); }
+    }
+
+    private static class Node {}
+}
+
+/** Marks cached methods. */
+@interface Cached {
+    String value() default "";
+}
+`
+
+func TestParseJava(t *testing.T) {
+	file := parseSource(javaFixture, false)
+	if file.Package != "com.example.cache" {
+		t.Errorf("Package = %q", file.Package)
+	}
+
+	var names []string
+	for _, typ := range file.Types {
+		names = append(names, typ.Kind+" "+typ.Name)
+	}
+	if want := []string{"Class Cache", "Interface Cache.Loader", "Enum Cache.Policy"}; !slices.Equal(names, want) {
+		t.Fatalf("types = %q, want %q", names, want)
+	}
+
+	cache := file.Types[0]
+	if want := "public final class Cache<K, V extends Comparable<V>> implements AutoCloseable"; cache.Signature != want {
+		t.Errorf("Signature = %q", cache.Signature)
+	}
+	if cache.Summary != "A cache of computed values." {
+		t.Errorf("Summary = %q", cache.Summary)
+	}
+	if got, want := members(cache), []string{
+		"Field DEFAULT_TTL: public static final int DEFAULT_TTL = 60",
+		"Field IDENTITY: public static final Function<String, String> IDENTITY",
+		"Constructor Cache: public Cache()",
+		"Constructor Cache: public Cache(int ttl)",
+		"Method get: public V get(K key)",
+		"Method get: public <R extends V> V get(K key, Function<? super K, R> loader) throws java.io.IOException",
+		"Method shutdown: protected void shutdown()",
+		"Method close: public void close()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Cache members = %q\nwant %q", got, want)
+	}
+	if s := cache.Members[6].Summary; s != "(deprecated)" {
+		t.Errorf("shutdown summary = %q", s)
+	}
+
+	for _, want := range []string{
+		"# Class Cache\n\nPackage: `com.example.cache`\n\n```java\npublic final class Cache",
+		"Entries expire after `DEFAULT_TTL` seconds; see `the loader`.",
+		"**Type Parameters**\n\n- `K`: the key type\n- `V`: the value type",
+		"Since: 1.2",
+		"## Constructors\n\n### Cache.Cache",
+		"**Throws**\n\n- `IllegalArgumentException`: if ttl is negative",
+		"```java\nV v = cache.get(key);\n```",
+		"**Returns**\n\nthe value, or `null`",
+		"> **Deprecated**: use `close()`",
+	} {
+		if !strings.Contains(cache.Markdown, want) {
+			t.Errorf("Cache document lacks %q:\n%s", want, cache.Markdown)
+		}
+	}
+
+	if got, want := members(file.Types[1]), []string{
+		"Method load: V load(K key) throws Exception",
+		"Method name: default String name()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Loader members = %q\nwant %q", got, want)
+	}
+	if got, want := members(file.Types[2]), []string{
+		"EnumConstant LRU: public static final Policy LRU",
+		"EnumConstant FIFO: public static final Policy FIFO",
+		"EnumConstant RANDOM: public static final Policy RANDOM",
+		"Method label: public String label()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Policy members = %q\nwant %q", got, want)
+	}
+	if s := file.Types[2].Members[2].Summary; s != "(deprecated)" {
+		t.Errorf("RANDOM summary = %q", s)
+	}
+}
+
+const kotlinFixture = `@file:JvmName("Strings")
+@file:Suppress("unused")
+
+package com.example.text
+
+import kotlin.math.max
+
+/** The default padding character. */
+const val PAD = ' '
+
+/**
+ * Pads a string to [length] with [padChar].
+ *
+ * @receiver the string to pad
+ * @param length the length to reach
+ * @return the padded string, or this string when it is long enough
+ * @sample com.example.text.samples.padSample
+ */
+fun String.padTo(length: Int, padChar: Char = PAD): String =
+    if (this.length >= length) this
+    else padChar.toString().repeat(length - this.length) + this
+
+/** Counts the words. */
+val CharSequence.wordCount: Int
+    get() = split(" ").size
+
+internal fun helper() = Unit
+
+typealias Predicate<T> = (T) -> Boolean
+
+/**
+ * A piece of text.
+ *
+ * @property text the content
+ */
+data class Text(val text: String, val lang: String = "en") : Comparable<Text> {
+    /** Whether the text is blank. */
+    val isBlank: Boolean
+        get() = text.isBlank()
+
+    /** Creates an empty text. */
+    constructor() : this("")
+
+    override fun compareTo(other: Text): Int = text.compareTo(other.text)
+
+    /** Joins texts. */
+    fun <T : Text> join(
+        others: List<T>,
+        separator: String = ", ",
+    ): Text {
+        val all = listOf(this) + others
+        return Text(all.joinToString(separator) { it.text })
+    }
+
+    private fun secret() {}
+
+    companion object {
+        /** An empty text. */
+        @JvmStatic
+        val EMPTY = Text("")
+
+        fun of(s: String) = Text(s)
+    }
+}
+
+/** A language. */
+enum class Lang(val code: String) {
+    /** English. */
+    EN("en"),
+    DE("de") {
+        override fun toString() = "German"
+    };
+
+    fun label() = "${code.uppercase()}: ${name}"
+}
+
+sealed interface Node
+object Root : Node
+`
+
+func TestParseKotlin(t *testing.T) {
+	file := parseSource(kotlinFixture, true)
+	if file.Package != "com.example.text" {
+		t.Errorf("Package = %q", file.Package)
+	}
+
+	var got []string
+	for _, m := range file.Members {
+		got = append(got, m.Kind+" "+m.Name+": "+m.Signature)
+	}
+	if want := []string{
+		"Property PAD: const val PAD = ' '",
+		"Function padTo: fun String.padTo(length: Int, padChar: Char = PAD): String",
+		"Property wordCount: val CharSequence.wordCount: Int",
+		"TypeAlias Predicate: typealias Predicate<T> = (T) -> Boolean",
+	}; !slices.Equal(got, want) {
+		t.Errorf("top-level members = %q\nwant %q", got, want)
+	}
+	pad := file.Members[1]
+	for _, want := range []string{
+		"Pads a string to `length` with `padChar`.",
+		"**Receiver**\n\nthe string to pad",
+		"**Parameters**\n\n- `length`: the length to reach",
+		"**Samples**\n\n- `com.example.text.samples.padSample`",
+	} {
+		if !strings.Contains(pad.Doc, want) {
+			t.Errorf("padTo doc lacks %q:\n%s", want, pad.Doc)
+		}
+	}
+
+	var names []string
+	for _, typ := range file.Types {
+		names = append(names, typ.Kind+" "+typ.Name)
+	}
+	if want := []string{"Class Text", "Object Text.Companion", "Enum Lang", "Interface Node", "Object Root"}; !slices.Equal(names, want) {
+		t.Fatalf("types = %q, want %q", names, want)
+	}
+
+	text := file.Types[0]
+	if want := `data class Text(val text: String, val lang: String = "en") : Comparable<Text>`; text.Signature != want {
+		t.Errorf("Text signature = %q", text.Signature)
+	}
+	if got, want := members(text), []string{
+		"Property isBlank: val isBlank: Boolean",
+		"Constructor Text: constructor() : this(\"\")",
+		"Method compareTo: override fun compareTo(other: Text): Int",
+		"Method join: fun <T : Text> join(others: List<T>, separator: String = \", \"): Text",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Text members = %q\nwant %q", got, want)
+	}
+	if !strings.Contains(text.Markdown, "**Properties**\n\n- `text`: the content") {
+		t.Errorf("Text document:\n%s", text.Markdown)
+	}
+	if got, want := members(file.Types[1]), []string{
+		"Property EMPTY: val EMPTY",
+		"Method of: fun of(s: String)",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Companion members = %q\nwant %q", got, want)
+	}
+	if got, want := members(file.Types[2]), []string{
+		"EnumConstant EN: EN",
+		"EnumConstant DE: DE",
+		"Method label: fun label()",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Lang members = %q\nwant %q", got, want)
+	}
+}
+
+func members(t *javaType) []string {
+	var sigs []string
+	for _, m := range t.Members {
+		sigs = append(sigs, m.Kind+" "+m.Name+": "+m.Signature)
+	}
+	return sigs
+}
+
+func TestSplitDecls(t *testing.T) {
+	src := "int a = 1; // a; b\n" +
+		"String s = \"}\";\n" +
+		"Runnable r = () -> { run(); };\n" +
+		"void f() { if (x) { y(); } }\n" +
+		"/** Doc. */ @Ann(\"x;\") int g();\n"
+	var got []string
+	for _, d := range splitDecls(src, false) {
+		got = append(got, d.header+"|"+d.doc)
+	}
+	want := []string{
+		"int a = 1|",
+		`String s = "}"|`,
+		"Runnable r = () -> { run(); }|",
+		"void f()|",
+		`@Ann("x;") int g()|/** Doc. */`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitDecls(java) = %q\nwant %q", got, want)
+	}
+
+	src = "val a = listOf(\n  1,\n  2,\n)\n" +
+		"val b = a\n  .map { it * 2 }\n  .sum()\n" +
+		"@Suppress(\"x\")\nfun c(): String =\n  \"${a.first { it > 1 }}\"\n" +
+		"fun d() {}\n"
+	got = nil
+	for _, d := range splitDecls(src, true) {
+		got = append(got, d.header)
+	}
+	want = []string{
+		"val a = listOf(\n  1,\n  2,\n)",
+		"val b = a\n  .map { it * 2 }\n  .sum()",
+		"@Suppress(\"x\")\nfun c(): String =\n  \"${a.first { it > 1 }}\"",
+		"fun d()",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitDecls(kotlin) = %q\nwant %q", got, want)
+	}
+}
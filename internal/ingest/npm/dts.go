@@ -0,0 +1,638 @@
+package npm
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tsModule is what a declaration file (or a namespace or ambient module in
+// one) declares and exports.
+type tsModule struct {
+	Doc     string // a JSDoc comment tagged @packageDocumentation, @module or @file
+	Items   []*tsItem
+	Exports []tsExport
+	Imports map[string]tsImport // by local name
+	Ambient map[string]*tsModule
+	// ExportAssign is the name in `export = Name`, whose declarations are the
+	// module's exports.
+	ExportAssign string
+	// Script is set when every declaration is exported: in a file without
+	// imports or exports, whose declarations are global, and in an ambient
+	// module that marks none.
+	Script bool
+}
+
+// tsItem is a declaration. Declarations merge in TypeScript, so a name can
+// have several items of different kinds; overloads are one item.
+type tsItem struct {
+	Name      string
+	Kind      string
+	Signature string
+	Doc       string
+	Exported  bool
+	Default   bool // the module's default export
+	Members   []*tsItem
+}
+
+// tsExport is an export list or re-export. Names maps each exported name to
+// the local (or, with From, the imported) name.
+type tsExport struct {
+	From  string
+	Names [][2]string // exported, local
+	Star  bool
+	As    string // export * as As from ...
+}
+
+type tsImport struct {
+	From string
+	Name string // "*" for a namespace import
+}
+
+// tsStmt is a statement or member: its source, comments included, and the
+// JSDoc comment before it.
+type tsStmt struct {
+	text string
+	doc  string
+}
+
+var (
+	tsDeclName   = regexp.MustCompile(`^(function|class|interface|type|enum|const\s+enum|const|let|var|namespace|module)\s+([\w$]+(?:\.[\w$]+)*|'[^']*'|"[^"]*")`)
+	tsExportList = regexp.MustCompile(`^export\s+(?:type\s+)?(?:(\*)(?:\s+as\s+([\w$]+))?|\{([^}]*)\})\s*(?:from\s+['"]([^'"]+)['"])?$`)
+	tsExportName = regexp.MustCompile(`^export\s*(=|default)\s*([\w$.]+)$`)
+	tsImportFrom = regexp.MustCompile(`^import\s+(?:type\s+)?(.+?)\s+from\s+['"]([^'"]+)['"]$`)
+	tsImportReq  = regexp.MustCompile(`^import\s+([\w$]+)\s*=\s*require\(\s*['"]([^'"]+)['"]\s*\)$`)
+	tsMemberName = regexp.MustCompile(`^(#?[\w$]+|'[^']*'|"[^"]*"|\[[^\]]+\])\??\s*([(<:;]|$)`)
+	tsDocTag     = regexp.MustCompile(`(?m)^@(packageDocumentation|module|file|fileoverview)\b`)
+	tsWord       = regexp.MustCompile(`^[\w$]+`)
+)
+
+// memberModifiers may precede a class or interface member; they are kept in
+// its signature except for declare and public.
+var memberModifiers = map[string]bool{
+	"public": true, "protected": true, "private": true, "static": true, "readonly": true,
+	"abstract": true, "declare": true, "override": true, "accessor": true, "async": true,
+}
+
+// parseDeclarations parses the source of a declaration file.
+func parseDeclarations(src string) *tsModule {
+	m := &tsModule{Imports: map[string]tsImport{}, Ambient: map[string]*tsModule{}, Script: true}
+	stmts := splitStatements(src, false)
+	if len(stmts) > 0 && tsDocTag.MatchString(jsdocText(stmts[0].doc)) {
+		m.Doc = stmts[0].doc
+		stmts[0].doc = ""
+	}
+	for _, st := range stmts {
+		m.statement(st)
+	}
+	return m
+}
+
+func (m *tsModule) statement(st tsStmt) {
+	text := stripComments(st.text)
+	if strings.HasPrefix(text, "import ") || strings.HasPrefix(text, "import{") {
+		m.Script = false
+		m.parseImport(text)
+		return
+	}
+	if match := tsExportList.FindStringSubmatch(text); match != nil {
+		m.Script = false
+		e := tsExport{From: match[4], Star: match[1] != "", As: match[2]}
+		if e.Star && e.From == "" {
+			return
+		}
+		if !e.Star {
+			for _, spec := range strings.Split(match[3], ",") {
+				spec = strings.TrimPrefix(strings.TrimSpace(spec), "type ")
+				if spec == "" {
+					continue
+				}
+				local, exported, ok := strings.Cut(spec, " as ")
+				if !ok {
+					exported = local
+				}
+				e.Names = append(e.Names, [2]string{strings.TrimSpace(exported), strings.TrimSpace(local)})
+			}
+		}
+		m.Exports = append(m.Exports, e)
+		return
+	}
+	if match := tsExportName.FindStringSubmatch(text); match != nil {
+		m.Script = false
+		if match[1] == "=" {
+			m.ExportAssign = match[2]
+		} else {
+			m.Exports = append(m.Exports, tsExport{Names: [][2]string{{"default", match[2]}}})
+		}
+		return
+	}
+	if strings.HasPrefix(text, "export as namespace") {
+		return
+	}
+
+	exported, isDefault, abstract := false, false, false
+	rest := text
+	for {
+		word := tsWord.FindString(rest)
+		switch word {
+		case "export":
+			exported = true
+			m.Script = false
+		case "default":
+			isDefault = true
+		case "abstract":
+			abstract = true
+		case "declare", "async":
+		default:
+			if hidden(st.doc) {
+				return
+			}
+			header := rest
+			if abstract {
+				header = "abstract " + header
+			}
+			if isDefault {
+				header = "export default " + header
+			}
+			m.declaration(st, rest, header, exported || isDefault)
+			return
+		}
+		rest = strings.TrimSpace(rest[len(word):])
+	}
+}
+
+// declaration adds the declaration in text, which starts at its keyword;
+// header is text with the modifiers shown in its signature.
+func (m *tsModule) declaration(st tsStmt, text, header string, exported bool) {
+	if strings.HasPrefix(text, "global") {
+		return
+	}
+	match := tsDeclName.FindStringSubmatch(text)
+	if match == nil {
+		if strings.HasPrefix(header, "export default ") {
+			// An anonymous default export: export default function (...).
+			kind := "Variable"
+			switch tsWord.FindString(text) {
+			case "function":
+				kind = "Function"
+			case "class":
+				kind = "Class"
+			}
+			m.add(&tsItem{Name: "default", Kind: kind, Signature: signature(header), Doc: st.doc, Exported: true, Default: true})
+		}
+		return
+	}
+	keyword, name := strings.Join(strings.Fields(match[1]), " "), match[2]
+
+	if keyword == "module" && (name[0] == '\'' || name[0] == '"') {
+		amb := parseDeclarations(blockBody(st.text))
+		amb.Script = !slices.ContainsFunc(amb.Items, func(it *tsItem) bool { return it.Exported }) &&
+			!slices.ContainsFunc(amb.Exports, func(e tsExport) bool { return e.From == "" })
+		m.Ambient[strings.Trim(name, `'"`)] = amb
+		return
+	}
+
+	it := &tsItem{Name: name, Doc: st.doc, Exported: exported, Default: strings.HasPrefix(header, "export default ")}
+	switch keyword {
+	case "function":
+		it.Kind, it.Signature = "Function", signature(header)
+	case "class", "interface":
+		it.Kind = "Class"
+		if keyword == "interface" {
+			it.Kind = "Interface"
+		}
+		it.Signature = signature(blockHeader(header))
+		it.Members = parseMembers(blockBody(st.text))
+	case "enum", "const enum":
+		it.Kind, it.Signature = "Enum", signature(header)
+	case "type":
+		it.Kind, it.Signature = "TypeAlias", signature(header)
+	case "const", "let", "var":
+		it.Kind, it.Signature = "Variable", signature(header)
+	case "namespace", "module":
+		it.Kind, it.Signature = "Namespace", signature(blockHeader(header))
+		ns := parseDeclarations(blockBody(st.text))
+		explicit := false
+		for _, member := range ns.Items {
+			explicit = explicit || member.Exported
+		}
+		for _, member := range ns.Items {
+			if member.Exported || !explicit {
+				it.Members = append(it.Members, member)
+			}
+		}
+	}
+	m.add(it)
+}
+
+// add appends an item, joining it to an earlier overload of the same
+// function.
+func (m *tsModule) add(it *tsItem) {
+	m.Items = addItem(m.Items, it)
+}
+
+func addItem(items []*tsItem, it *tsItem) []*tsItem {
+	if it.Kind == "Function" || it.Kind == "Method" || it.Kind == "Constructor" {
+		for _, have := range items {
+			if have.Name == it.Name && have.Kind == it.Kind {
+				have.Signature += "\n" + it.Signature
+				if have.Doc == "" {
+					have.Doc = it.Doc
+				}
+				have.Exported = have.Exported || it.Exported
+				return items
+			}
+		}
+	}
+	if it.Kind == "Property" {
+		// A get and set accessor pair is one property.
+		for _, have := range items {
+			if have.Name == it.Name && have.Kind == it.Kind {
+				return items
+			}
+		}
+	}
+	return append(items, it)
+}
+
+func (m *tsModule) parseImport(text string) {
+	if match := tsImportReq.FindStringSubmatch(text); match != nil {
+		m.Imports[match[1]] = tsImport{From: match[2], Name: "*"}
+		return
+	}
+	match := tsImportFrom.FindStringSubmatch(text)
+	if match == nil {
+		return
+	}
+	clause, from := match[1], match[2]
+	if i := strings.Index(clause, "{"); i >= 0 {
+		names := strings.Trim(clause[i:], "{} ")
+		for _, spec := range strings.Split(names, ",") {
+			spec = strings.TrimPrefix(strings.TrimSpace(spec), "type ")
+			if spec == "" {
+				continue
+			}
+			imported, local, ok := strings.Cut(spec, " as ")
+			if !ok {
+				local = imported
+			}
+			m.Imports[strings.TrimSpace(local)] = tsImport{From: from, Name: strings.TrimSpace(imported)}
+		}
+		clause = strings.TrimSpace(clause[:i])
+	}
+	for _, part := range strings.Split(clause, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+		case strings.HasPrefix(part, "*"):
+			_, local, _ := strings.Cut(part, " as ")
+			m.Imports[strings.TrimSpace(local)] = tsImport{From: from, Name: "*"}
+		default:
+			m.Imports[part] = tsImport{From: from, Name: "default"}
+		}
+	}
+}
+
+// parseMembers parses the body of a class or interface.
+func parseMembers(body string) []*tsItem {
+	var members []*tsItem
+	for _, st := range splitStatements(body, true) {
+		if hidden(st.doc) {
+			continue
+		}
+		text := stripComments(st.text)
+		var kept []string
+		private := false
+		for {
+			word := tsWord.FindString(text)
+			rest := strings.TrimSpace(text[len(word):])
+			// A modifier is followed by a name, not by ( : ? or <.
+			if !memberModifiers[word] || rest == "" || strings.ContainsRune("(:?<;", rune(rest[0])) {
+				break
+			}
+			if word == "private" {
+				private = true
+			}
+			if word != "declare" && word != "public" {
+				kept = append(kept, word)
+			}
+			text = rest
+		}
+		if private {
+			continue
+		}
+
+		it := &tsItem{Doc: st.doc, Exported: true}
+		name := text
+		if word := tsWord.FindString(text); word == "get" || word == "set" {
+			if rest := strings.TrimSpace(text[len(word):]); rest != "" && !strings.ContainsRune("(:?<;", rune(rest[0])) {
+				name = rest
+			}
+		}
+		switch {
+		case strings.HasPrefix(text, "constructor"):
+			it.Name, it.Kind = "constructor", "Constructor"
+		case strings.HasPrefix(text, "new ") || strings.HasPrefix(text, "new("):
+			it.Name, it.Kind = "new", "Constructor"
+		case strings.HasPrefix(text, "(") || strings.HasPrefix(text, "<"):
+			it.Name, it.Kind = "call", "Method"
+		case strings.HasPrefix(text, "[") && strings.Contains(strings.SplitN(text, "]", 2)[0], ":"):
+			it.Name, it.Kind = strings.SplitN(text, "]", 2)[0]+"]", "Property"
+		default:
+			match := tsMemberName.FindStringSubmatch(name)
+			if match == nil || strings.HasPrefix(match[1], "#") {
+				continue
+			}
+			it.Name, it.Kind = match[1], "Property"
+			if name == text && (match[2] == "(" || match[2] == "<") {
+				it.Kind = "Method"
+			}
+		}
+		if len(kept) > 0 {
+			text = strings.Join(kept, " ") + " " + text
+		}
+		it.Signature = signature(text)
+		members = addItem(members, it)
+	}
+	return members
+}
+
+// splitStatements splits source into statements, or with commas, into the
+// members of a class, interface or object type. Statements end at a
+// semicolon, at the closing brace of a block declaration, or at a line break
+// where automatic semicolon insertion would end them.
+func splitStatements(src string, commas bool) []tsStmt {
+	var (
+		stmts        []tsStmt
+		start        = -1
+		doc, pending string
+		depth        int
+	)
+	emit := func(end int) {
+		if text := strings.TrimSpace(src[start:end]); text != "" {
+			stmts = append(stmts, tsStmt{text: text, doc: doc})
+		}
+		start, doc, depth = -1, "", 0
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case strings.HasPrefix(src[i:], "//"):
+			i = skipLine(src, i)
+			continue
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 4
+			}
+			if start < 0 && strings.HasPrefix(src[i:], "/**") {
+				pending = src[i:end]
+			}
+			i = end
+			continue
+		case c == '\n' && start >= 0 && depth == 0 && statementEnds(src[start:i], src[i:]):
+			emit(i)
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		default:
+			if start < 0 {
+				start, doc, pending = i, pending, ""
+			}
+			switch c {
+			case '\'', '"', '`':
+				i = skipString(src, i)
+				continue
+			case '(', '[', '{', '<':
+				depth++
+			case ')', ']', '}':
+				depth--
+			case '>':
+				if i == 0 || src[i-1] != '=' {
+					depth--
+				}
+			}
+			depth = max(depth, 0)
+			switch {
+			case depth > 0:
+			case c == ';' || c == ',' && commas:
+				emit(i)
+			case c == '}' && isBlock(src[start:i+1]):
+				emit(i + 1)
+			}
+		}
+		i++
+	}
+	if start >= 0 {
+		emit(len(src))
+	}
+	return stmts
+}
+
+// continuations are the words after which a statement cannot end, and
+// nextContinuations the words that continue it on the next line.
+var (
+	continuations = map[string]bool{
+		"extends": true, "implements": true, "keyof": true, "typeof": true, "readonly": true, "unique": true,
+		"infer": true, "is": true, "as": true, "new": true, "in": true, "of": true, "asserts": true, "satisfies": true,
+	}
+	nextContinuations = map[string]bool{"extends": true, "implements": true, "is": true, "as": true, "satisfies": true}
+)
+
+// statementEnds reports whether a line break after stmt ends it, given the
+// source that follows.
+func statementEnds(stmt, rest string) bool {
+	last := strings.TrimSpace(stripComments(stmt))
+	if last == "" || strings.HasSuffix(last, "=>") || strings.ContainsRune("|&=,:(<[{?.+-*/", rune(last[len(last)-1])) {
+		return false
+	}
+	if i := strings.LastIndexFunc(last, func(r rune) bool { return !isIdent(r) }); continuations[last[i+1:]] {
+		return false
+	}
+	next := strings.TrimSpace(rest)
+	if next == "" || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/*") {
+		return true
+	}
+	return !strings.ContainsRune("|&.?:={", rune(next[0])) && !nextContinuations[tsWord.FindString(next)]
+}
+
+// isBlock reports whether a statement is a declaration ending with its body:
+// a class, interface, enum, namespace or module.
+func isBlock(stmt string) bool {
+	text := stripComments(stmt)
+	for {
+		word := tsWord.FindString(text)
+		switch word {
+		case "export", "declare", "default", "abstract", "const":
+			text = strings.TrimSpace(text[len(word):])
+		case "class", "interface", "enum", "namespace", "module", "global":
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// blockHeader returns a block declaration up to its body.
+func blockHeader(text string) string {
+	if i := bodyStart(text); i >= 0 {
+		return strings.TrimSpace(text[:i])
+	}
+	return text
+}
+
+// blockBody returns the source between the braces of a block declaration.
+func blockBody(text string) string {
+	i := bodyStart(text)
+	j := strings.LastIndex(text, "}")
+	if i < 0 || j <= i {
+		return ""
+	}
+	return text[i+1 : j]
+}
+
+// bodyStart finds the brace opening the body of a block declaration, past
+// type parameters and heritage clauses.
+func bodyStart(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\'', '"', '`':
+			i = skipString(text, i) - 1
+		case '/':
+			if strings.HasPrefix(text[i:], "//") {
+				i = skipLine(text, i) - 1
+			} else if strings.HasPrefix(text[i:], "/*") {
+				if end := strings.Index(text[i+2:], "*/"); end >= 0 {
+					i += end + 3
+				}
+			}
+		case '{':
+			if depth == 0 {
+				return i
+			}
+			depth++
+		case '(', '[', '<':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '>':
+			if i > 0 && text[i-1] != '=' {
+				depth--
+			}
+		}
+	}
+	return -1
+}
+
+func skipLine(src string, i int) int {
+	if j := strings.IndexByte(src[i:], '\n'); j >= 0 {
+		return i + j
+	}
+	return len(src)
+}
+
+// skipString returns the offset after the string literal at i. Template
+// literal types may nest substitutions.
+func skipString(src string, i int) int {
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		case '\n':
+			if quote != '`' {
+				return j
+			}
+		case '$':
+			if quote == '`' && j+1 < len(src) && src[j+1] == '{' {
+				depth := 0
+				for j++; j < len(src); j++ {
+					if src[j] == '{' {
+						depth++
+					} else if src[j] == '}' {
+						if depth--; depth == 0 {
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+	return len(src)
+}
+
+// stripComments removes comments from source, keeping string literals.
+func stripComments(src string) string {
+	var b strings.Builder
+	for i := 0; i < len(src); {
+		switch {
+		case src[i] == '\'' || src[i] == '"' || src[i] == '`':
+			j := skipString(src, i)
+			b.WriteString(src[i:j])
+			i = j
+		case strings.HasPrefix(src[i:], "//"):
+			i = skipLine(src, i)
+		case strings.HasPrefix(src[i:], "/*"):
+			if end := strings.Index(src[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(src)
+			}
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(src[i])
+			i++
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// signatureSpace tidies a declaration joined onto one line.
+var signatureSpace = strings.NewReplacer("( ", "(", " )", ")", "; }", " }", "= | ", "= ", "= & ", "= ")
+
+// maxSignatureLen is the length up to which a declaration is shown on one
+// line; longer ones keep their line breaks.
+const maxSignatureLen = 100
+
+// signature returns a declaration without comments and the export and
+// declare keywords, on one line unless it is long.
+func signature(text string) string {
+	text = stripComments(text)
+	for _, word := range []string{"export ", "declare "} {
+		if strings.HasPrefix(text, word) && !strings.HasPrefix(text, "export default ") {
+			text = strings.TrimSpace(text[len(word):])
+		}
+	}
+	text = strings.TrimSuffix(text, ";")
+	if line := strings.Join(strings.Fields(text), " "); len(line) <= maxSignatureLen {
+		return signatureSpace.Replace(line)
+	}
+	return dedent(text)
+}
+
+// dedent removes trailing space and, when a declaration ends with a
+// closing bracket, the indentation of that line from the lines after the
+// first, which starts at the declaration.
+func dedent(text string) string {
+	lines := strings.Split(text, "\n")
+	last := lines[len(lines)-1]
+	indent := 0
+	if trimmed := strings.TrimLeft(last, " \t"); trimmed != "" && strings.ContainsRune("}])", rune(trimmed[0])) {
+		indent = len(last) - len(trimmed)
+	}
+	for i, line := range lines {
+		if i > 0 && len(line)-len(strings.TrimLeft(line, " \t")) >= indent {
+			line = line[indent:]
+		}
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isIdent(r rune) bool {
+	return r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
@@ -0,0 +1,195 @@
+package npm
+
+import (
+	"slices"
+	"testing"
+)
+
+// declarationFixture exercises overloads, class and interface members,
+// hidden members, multi-line unions, namespaces and export lists.
+const declarationFixture = `/**
+ * Client for the XRPC API.
+ * @packageDocumentation
+ */
+import { Agent as BaseAgent } from './agent';
+import type * as z from 'zod';
+export * from './types';
+export { helper as assist, type Options as Opts } from "./util.js";
+
+/** Default timeout in milliseconds. */
+export declare const TIMEOUT = 5000;
+
+/** Sends a request. */
+export declare function send(url: string, opts?: Options): Promise<string>;
+export declare function send(url: URL): Promise<string>;
+
+/** A client. */
+export declare abstract class Client<T extends { id: string } = Record<string, unknown>> extends BaseAgent implements Disposable {
+    /** Creates a client. */
+    constructor(service: string);
+    private secret;
+    #internal: number;
+    readonly service: string;
+    static create(): Client;
+    get closed(): boolean;
+    set closed(v: boolean);
+    protected abstract call<R>(method: string, ...args: unknown[]): Promise<R>;
+    [Symbol.dispose](): void;
+    /** @internal */
+    _debug(): void;
+}
+
+export interface Options {
+    /** Request timeout. */
+    timeout?: number
+    headers: Record<string, string>
+    onError?(err: Error): void
+    readonly id: string
+    [key: string]: unknown
+}
+
+export type Result<T> =
+    | { ok: true; value: T }
+    | { ok: false; error: Error }
+
+export declare enum Level {
+    Debug = 0,
+    Info = 1
+}
+
+export declare namespace Util {
+    function join(a: string, b: string): string;
+}
+
+type Private = string;
+export { Private as Public };
+export default Client;
+`
+
+func TestParseDeclarations(t *testing.T) {
+	m := parseDeclarations(declarationFixture)
+
+	if m.Doc == "" || m.Script {
+		t.Errorf("Doc = %q, Script = %v", m.Doc, m.Script)
+	}
+	if imp := m.Imports["BaseAgent"]; imp.From != "./agent" || imp.Name != "Agent" || m.Imports["z"].Name != "*" {
+		t.Errorf("Imports = %+v", m.Imports)
+	}
+	if len(m.Exports) != 4 || !m.Exports[0].Star || m.Exports[1].Names[1] != [2]string{"Opts", "Options"} || m.Exports[3].Names[0] != [2]string{"default", "Client"} {
+		t.Errorf("Exports = %+v", m.Exports)
+	}
+
+	want := []tsItem{
+		{Name: "TIMEOUT", Kind: "Variable", Signature: "const TIMEOUT = 5000"},
+		{Name: "send", Kind: "Function", Signature: "function send(url: string, opts?: Options): Promise<string>\nfunction send(url: URL): Promise<string>"},
+		{Name: "Client", Kind: "Class", Signature: "abstract class Client<T extends { id: string } = Record<string, unknown>> extends BaseAgent implements Disposable"},
+		{Name: "Options", Kind: "Interface", Signature: "interface Options"},
+		{Name: "Result", Kind: "TypeAlias", Signature: "type Result<T> = { ok: true; value: T } | { ok: false; error: Error }"},
+		{Name: "Level", Kind: "Enum", Signature: "enum Level { Debug = 0, Info = 1 }"},
+		{Name: "Util", Kind: "Namespace", Signature: "namespace Util"},
+		{Name: "Private", Kind: "TypeAlias", Signature: "type Private = string"},
+	}
+	if len(m.Items) != len(want) {
+		t.Fatalf("Items = %+v", m.Items)
+	}
+	for i, it := range m.Items {
+		if it.Name != want[i].Name || it.Kind != want[i].Kind || it.Signature != want[i].Signature || it.Exported != (it.Name != "Private") {
+			t.Errorf("item %d = %+v\nwant %+v", i, it, want[i])
+		}
+	}
+
+	members := func(it *tsItem) []string {
+		var sigs []string
+		for _, member := range it.Members {
+			sigs = append(sigs, member.Kind+" "+member.Name+": "+member.Signature)
+		}
+		return sigs
+	}
+	if got, want := members(m.Items[2]), []string{
+		"Constructor constructor: constructor(service: string)",
+		"Property service: readonly service: string",
+		"Method create: static create(): Client",
+		"Property closed: get closed(): boolean",
+		"Method call: protected abstract call<R>(method: string, ...args: unknown[]): Promise<R>",
+		"Method [Symbol.dispose]: [Symbol.dispose](): void",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Client members = %q\nwant %q", got, want)
+	}
+	if got, want := members(m.Items[3]), []string{
+		"Property timeout: timeout?: number",
+		"Property headers: headers: Record<string, string>",
+		"Method onError: onError?(err: Error): void",
+		"Property id: readonly id: string",
+		"Property [key: string]: [key: string]: unknown",
+	}; !slices.Equal(got, want) {
+		t.Errorf("Options members = %q\nwant %q", got, want)
+	}
+	if got := members(m.Items[6]); !slices.Equal(got, []string{"Function join: function join(a: string, b: string): string"}) {
+		t.Errorf("Util members = %q", got)
+	}
+}
+
+func TestExports(t *testing.T) {
+	r := newResolver(map[string]*tsModule{
+		"dist/index.d.ts": parseDeclarations(declarationFixture),
+		"dist/types.d.ts": parseDeclarations("export interface Session { did: string }\nexport type Handle = string\nexport default function main(): void;\n"),
+		"dist/util.d.ts":  parseDeclarations("export declare function helper(): void;\nexport interface Options { dry: boolean }\n"),
+		"legacy.d.ts": parseDeclarations(`declare function debounce<T>(fn: T, wait?: number): T;
+declare namespace debounce {
+    interface Options { leading?: boolean }
+}
+export = debounce;
+declare module "ambient" {
+    const version: string;
+}
+`),
+	})
+
+	var names []string
+	for _, it := range r.exportsOf("dist/index.d.ts") {
+		names = append(names, it.Kind+" "+it.Name)
+	}
+	want := []string{
+		"Variable TIMEOUT", "Function send", "Class Client", "Interface Options", "TypeAlias Result", "Enum Level", "Namespace Util",
+		"Interface Session", "TypeAlias Handle", "Function assist", "Interface Opts", "TypeAlias Public",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("exports = %q\nwant %q", names, want)
+	}
+
+	names = nil
+	for _, it := range r.exportsOf("legacy.d.ts") {
+		names = append(names, it.Kind+" "+it.Name)
+	}
+	if !slices.Equal(names, []string{"Function debounce", "Interface Options"}) {
+		t.Errorf("export = exports %q", names)
+	}
+	if got := r.exportsOf("module:ambient"); len(got) != 1 || got[0].Name != "version" {
+		t.Errorf("ambient module exports %+v", got)
+	}
+	if got := r.resolve("dist/index.d.ts", "./util.js"); got != "dist/util.d.ts" {
+		t.Errorf("resolve(./util.js) = %q", got)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	src := "type A = string\n" +
+		"type B =\n  | 'a'\n  | 'b'\n" +
+		"declare const c: `x-${string}`; // comment; with semicolon\n" +
+		"interface D\n  extends E {\n  f(): void\n}\n" +
+		"declare function g(cb: (x: number) => void): Map<string,\n  number>\n"
+	var got []string
+	for _, st := range splitStatements(src, false) {
+		got = append(got, stripComments(st.text))
+	}
+	want := []string{
+		"type A = string",
+		"type B =\n  | 'a'\n  | 'b'",
+		"declare const c: `x-${string}`",
+		"interface D\n  extends E {\n  f(): void\n}",
+		"declare function g(cb: (x: number) => void): Map<string,\n  number>",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitStatements() = %q\nwant %q", got, want)
+	}
+}
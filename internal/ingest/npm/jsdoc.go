@@ -0,0 +1,271 @@
+package npm
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	jsdocLink  = regexp.MustCompile(`\{@(?:link|linkcode|linkplain)\s+([^}|\s]+)(?:\s*\|\s*|\s+)?([^}]*)\}`)
+	jsdocType  = regexp.MustCompile(`^\{([^}]*)\}\s*`)
+	jsdocParam = regexp.MustCompile(`^(\[[^\]]*\]|[\w$.]+)\s*(?:-\s*)?`)
+)
+
+// jsdocText returns the text of a /** */ comment without its delimiters and
+// leading asterisks.
+func jsdocText(comment string) string {
+	comment = strings.TrimPrefix(comment, "/**")
+	comment = strings.TrimSuffix(comment, "*/")
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(line, "*") {
+			line = strings.TrimPrefix(line[1:], " ")
+		}
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// jsdocTag is a block tag, such as @param, with its text.
+type jsdocTag struct {
+	name, text string
+}
+
+// splitTags splits a JSDoc comment into its description and block tags. Tags
+// inside code fences belong to the text around them.
+func splitTags(comment string) (string, []jsdocTag) {
+	var (
+		desc   []string
+		tags   []jsdocTag
+		fenced bool
+	)
+	for _, line := range strings.Split(jsdocText(comment), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		if !fenced && strings.HasPrefix(line, "@") {
+			name, text, _ := strings.Cut(line[1:], " ")
+			tags = append(tags, jsdocTag{name: name, text: strings.TrimSpace(text)})
+			continue
+		}
+		if len(tags) > 0 {
+			tags[len(tags)-1].text += "\n" + line
+		} else {
+			desc = append(desc, line)
+		}
+	}
+	for i := range tags {
+		tags[i].text = strings.TrimSpace(tags[i].text)
+	}
+	return strings.TrimSpace(strings.Join(desc, "\n")), tags
+}
+
+// hidden reports whether a comment keeps its declaration out of the
+// documentation: @internal, @hidden, @ignore or @private.
+func hidden(comment string) bool {
+	if comment == "" {
+		return false
+	}
+	_, tags := splitTags(comment)
+	for _, tag := range tags {
+		switch tag.name {
+		case "internal", "hidden", "ignore", "private":
+			return true
+		}
+	}
+	return false
+}
+
+// jsdocSummary returns the first line of a comment's description, marked
+// when the declaration is deprecated.
+func jsdocSummary(comment string) string {
+	desc, tags := splitTags(comment)
+	summary := inlineTags(firstLine(desc))
+	for _, tag := range tags {
+		if tag.name == "deprecated" {
+			return strings.TrimSpace(summary + " (deprecated)")
+		}
+	}
+	return summary
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+// jsdocMarkdown converts a JSDoc or TSDoc comment to Markdown. The
+// description is Markdown already; block tags become labelled sections.
+func jsdocMarkdown(comment string) string {
+	desc, tags := splitTags(comment)
+
+	var (
+		parts               []string
+		params, typeParams  []string
+		throws, see         []string
+		returns             string
+		remarks, examples   []string
+		notes               []string
+		deprecated, release string
+	)
+	for _, tag := range tags {
+		text := inlineTags(tag.text)
+		switch tag.name {
+		case "param", "arg", "argument", "typeParam", "template":
+			if entry := paramEntry(text); entry != "" {
+				if tag.name == "typeParam" || tag.name == "template" {
+					typeParams = append(typeParams, entry)
+				} else {
+					params = append(params, entry)
+				}
+			}
+		case "returns", "return":
+			returns = typed(text)
+		case "throws", "throw", "exception":
+			throws = append(throws, "- "+typed(text))
+		case "example":
+			examples = append(examples, exampleBlock(tag.text))
+		case "remarks":
+			remarks = append(remarks, text)
+		case "see":
+			see = append(see, "- "+text)
+		case "deprecated":
+			deprecated = "> **Deprecated**"
+			if text != "" {
+				deprecated += ": " + strings.ReplaceAll(text, "\n", " ")
+			}
+		case "beta", "alpha", "experimental":
+			release = "> **" + strings.ToUpper(tag.name[:1]) + tag.name[1:] + "**: this API may change."
+		case "defaultValue", "default":
+			notes = append(notes, "Default: "+codeSpan(text))
+		case "since":
+			notes = append(notes, "Since: "+text)
+		}
+	}
+
+	if deprecated != "" {
+		parts = append(parts, deprecated)
+	}
+	if release != "" {
+		parts = append(parts, release)
+	}
+	if desc != "" {
+		parts = append(parts, inlineTags(desc))
+	}
+	parts = append(parts, remarks...)
+	if len(typeParams) > 0 {
+		parts = append(parts, "**Type Parameters**", strings.Join(typeParams, "\n"))
+	}
+	if len(params) > 0 {
+		parts = append(parts, "**Parameters**", strings.Join(params, "\n"))
+	}
+	if returns != "" {
+		parts = append(parts, "**Returns**", returns)
+	}
+	if len(throws) > 0 {
+		parts = append(parts, "**Throws**", strings.Join(throws, "\n"))
+	}
+	if len(notes) > 0 {
+		parts = append(parts, strings.Join(notes, "\\\n"))
+	}
+	if len(examples) > 0 {
+		parts = append(parts, "**Example**")
+		parts = append(parts, examples...)
+	}
+	if len(see) > 0 {
+		parts = append(parts, "**See Also**", strings.Join(see, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// typed renders the text of a @returns or @throws tag whose type is given
+// in braces as "`Type`: description".
+func typed(text string) string {
+	match := jsdocType.FindStringSubmatch(text)
+	if match == nil {
+		return text
+	}
+	if rest := text[len(match[0]):]; rest != "" {
+		return "`" + match[1] + "`: " + rest
+	}
+	return "`" + match[1] + "`"
+}
+
+// paramEntry renders a @param or @typeParam tag as a list item:
+// `name` (type): description. Optional names in brackets keep their default.
+func paramEntry(text string) string {
+	var typ string
+	if match := jsdocType.FindStringSubmatch(text); match != nil {
+		typ, text = match[1], text[len(match[0]):]
+	}
+	match := jsdocParam.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	name, desc := match[1], strings.Join(strings.Fields(text[len(match[0]):]), " ")
+	var notes []string
+	if typ != "" {
+		notes = append(notes, typ)
+	}
+	if optional, ok := strings.CutPrefix(name, "["); ok {
+		var def string
+		name, def, ok = strings.Cut(strings.TrimSuffix(optional, "]"), "=")
+		notes = append(notes, "optional")
+		if ok {
+			notes = append(notes, "default "+strings.TrimSpace(def))
+		}
+	}
+
+	s := "- `" + strings.TrimSpace(name) + "`"
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, ", ") + ")"
+	}
+	if desc != "" {
+		s += ": " + desc
+	}
+	return s
+}
+
+// exampleBlock fences an @example unless it contains a fence already; a
+// leading <caption> becomes its title.
+func exampleBlock(text string) string {
+	var caption string
+	if rest, ok := strings.CutPrefix(text, "<caption>"); ok {
+		caption, text, _ = strings.Cut(rest, "</caption>")
+		caption, text = strings.TrimSpace(caption), strings.TrimSpace(text)
+	}
+	if !strings.Contains(text, "```") {
+		text = "```ts\n" + text + "\n```"
+	}
+	if caption != "" {
+		return caption + "\n\n" + text
+	}
+	return text
+}
+
+// inlineTags replaces {@link Target} and {@link Target | text} with code
+// spans or their text, and links to URLs with Markdown links.
+func inlineTags(s string) string {
+	return jsdocLink.ReplaceAllStringFunc(s, func(tag string) string {
+		match := jsdocLink.FindStringSubmatch(tag)
+		target, text := match[1], strings.TrimSpace(match[2])
+		switch {
+		case strings.Contains(target, "://") && text != "":
+			return "[" + text + "](" + target + ")"
+		case strings.Contains(target, "://"):
+			return "<" + target + ">"
+		case text != "":
+			return text
+		}
+		return "`" + target + "`"
+	})
+}
+
+func codeSpan(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "`") {
+		return s
+	}
+	return "`" + s + "`"
+}
@@ -0,0 +1,34 @@
+package npm
+
+import "testing"
+
+func TestJSDocMarkdown(t *testing.T) {
+	tests := []struct {
+		name, comment, want string
+	}{
+		{
+			"tags",
+			"/**\n * Sends a request to {@link Agent.call}.\n *\n * @param url - The URL to call.\n * @param {number} [retries=3] How often\n *   to retry.\n * @typeParam T - The body type.\n * @returns {Promise<T>} The response body.\n * @throws {RangeError} When the URL is empty.\n * @since 1.2\n */",
+			"Sends a request to `Agent.call`.\n\n**Type Parameters**\n\n- `T`: The body type.\n\n**Parameters**\n\n- `url`: The URL to call.\n- `retries` (number, optional, default 3): How often to retry.\n\n**Returns**\n\n`Promise<T>`: The response body.\n\n**Throws**\n\n- `RangeError`: When the URL is empty.\n\nSince: 1.2",
+		},
+		{
+			"example",
+			"/**\n * Pads a string.\n * @deprecated Use {@link String.padStart | padStart}.\n * @example <caption>Basic</caption>\n * leftPad(\"a\", 3)\n * @example\n * ```js\n * // @ts-ignore\n * leftPad(1)\n * ```\n * @see {@link https://example.com | the docs}\n */",
+			"> **Deprecated**: Use padStart.\n\nPads a string.\n\n**Example**\n\nBasic\n\n```ts\nleftPad(\"a\", 3)\n```\n\n```js\n// @ts-ignore\nleftPad(1)\n```\n\n**See Also**\n\n- [the docs](https://example.com)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsdocMarkdown(tt.comment); got != tt.want {
+				t.Errorf("jsdocMarkdown() =\n%s\n\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if got := jsdocSummary("/** Pads a string.\n * More.\n * @deprecated */"); got != "Pads a string. (deprecated)" {
+		t.Errorf("jsdocSummary() = %q", got)
+	}
+	if !hidden("/** @internal */") || hidden("/** Public. */") {
+		t.Error("hidden() did not recognize @internal")
+	}
+}
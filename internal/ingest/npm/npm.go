@@ -0,0 +1,933 @@
+// Package npm ingests the API documentation of npm packages from their type
+// declarations: the .d.ts files a package ships, or its @types package, and
+// the JSDoc and TSDoc comments in them.
+package npm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+type Options struct {
+	Package string
+	Version string // a version or dist-tag; latest when empty
+	DB      *db.Store
+	Cache   *cache.FilesystemCache
+}
+
+// npmManifest is the registry's document for one version of a package, and
+// packageJSON the fields read from the package.json in its tarball.
+type npmManifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Dist    struct {
+		Tarball   string `json:"tarball"`
+		Integrity string `json:"integrity"`
+		Shasum    string `json:"shasum"`
+	} `json:"dist"`
+}
+
+type packageJSON struct {
+	Name        string          `json:"name"`
+	Version     string          `json:"version"`
+	Description string          `json:"description"`
+	Types       string          `json:"types"`
+	Typings     string          `json:"typings"`
+	Main        string          `json:"main"`
+	Exports     json.RawMessage `json:"exports"`
+}
+
+// SplitSpec splits a package spec such as react@18.3.1 or
+// @atproto/api@0.13.0 into the package name and version.
+func SplitSpec(spec string) (name, version string) {
+	spec = strings.TrimSpace(spec)
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i], spec[i+1:]
+	}
+	return spec, ""
+}
+
+// TypesPackage returns the DefinitelyTyped package for a package without
+// declarations: @types/node for node, @types/babel__core for @babel/core.
+func TypesPackage(name string) string {
+	name = strings.TrimPrefix(name, "@")
+	return "@types/" + strings.Replace(name, "/", "__", 1)
+}
+
+func IngestPackage(ctx context.Context, opts Options) error {
+	if opts.Package == "" {
+		return errors.New("package name is required")
+	}
+	if opts.DB == nil {
+		return errors.New("db store is required")
+	}
+
+	manifest, err := fetchManifest(ctx, opts.Package, opts.Version)
+	if err != nil {
+		return err
+	}
+	dir, cleanup, err := downloadTarball(ctx, manifest, opts.Cache)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if !hasDeclarations(dir) && !strings.HasPrefix(opts.Package, "@types/") {
+		types := TypesPackage(opts.Package)
+		log.Info("npm package has no type declarations, trying DefinitelyTyped", "package", opts.Package, "types", types)
+		typesManifest, err := fetchManifest(ctx, types, "latest")
+		if err != nil {
+			return fmt.Errorf("%s %s ships no type declarations: %w", manifest.Name, manifest.Version, err)
+		}
+		typesDir, typesCleanup, err := downloadTarball(ctx, typesManifest, opts.Cache)
+		if err != nil {
+			return err
+		}
+		defer typesCleanup()
+		// The declarations are documented under the package's own name, with
+		// its README.
+		if err := copyDeclarations(typesDir, dir); err != nil {
+			return err
+		}
+	}
+
+	log.Info("npm package ingest starting", "package", manifest.Name, "version", manifest.Version)
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		n, err := ingestPackage(ctx, tx, dir)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no documented modules found in %s %s", manifest.Name, manifest.Version)
+		}
+		log.Info("npm package ingested", "package", manifest.Name, "modules", n)
+		return nil
+	})
+}
+
+func fetchManifest(ctx context.Context, pkg, version string) (*npmManifest, error) {
+	if version == "" {
+		version = "latest"
+	}
+	u := fmt.Sprintf("https://registry.npmjs.org/%s/%s", url.PathEscape(pkg), url.PathEscape(version))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && version != "latest":
+		return nil, fmt.Errorf("version %s of %s not found on npm", version, pkg)
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("package %s not found on npm", pkg)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("npm registry error: %s", resp.Status)
+	}
+
+	var m npmManifest
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Dist.Tarball == "" {
+		return nil, fmt.Errorf("%s %s has no tarball", pkg, version)
+	}
+	return &m, nil
+}
+
+// downloadTarball fetches a package tarball, through the cache when there is
+// one, checks it against the registry's integrity digest and extracts its
+// package.json, declaration files and README to a temporary directory.
+func downloadTarball(ctx context.Context, m *npmManifest, c *cache.FilesystemCache) (string, func(), error) {
+	cacheKey := cache.NpmKey(m.Name, m.Version)
+	var archivePath string
+
+	if c != nil {
+		if cached, _, err := c.Get(cacheKey); err == nil {
+			if checkIntegrity(cached, m) == nil {
+				archivePath = cached
+			} else {
+				_ = c.Delete(cacheKey)
+			}
+		}
+	}
+
+	if archivePath == "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.Dist.Tarball, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", nil, fmt.Errorf("npm download error: %s", resp.Status)
+		}
+
+		if c != nil {
+			entry, err := c.Put(cacheKey, m.Dist.Tarball, resp.Body, 0)
+			if err != nil {
+				return "", nil, err
+			}
+			archivePath = filepath.Join(c.Dir(), entry.Path)
+		} else {
+			f, err := os.CreateTemp("", "documango-npm-*.tgz")
+			if err != nil {
+				return "", nil, err
+			}
+			defer f.Close()
+			if _, err := io.Copy(f, resp.Body); err != nil {
+				return "", nil, err
+			}
+			archivePath = f.Name()
+		}
+
+		if err := checkIntegrity(archivePath, m); err != nil {
+			if c != nil {
+				_ = c.Delete(cacheKey)
+			} else {
+				_ = os.Remove(archivePath)
+			}
+			return "", nil, err
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "documango-npm-extract-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := extractTarball(archivePath, tmpDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", nil, err
+	}
+
+	cleanup := func() {
+		_ = os.RemoveAll(tmpDir)
+		if c == nil {
+			_ = os.Remove(archivePath)
+		}
+	}
+
+	return tmpDir, cleanup, nil
+}
+
+// checkIntegrity checks a tarball against the Subresource Integrity digest
+// the registry lists, or the SHA-1 shasum of older packages.
+func checkIntegrity(archivePath string, m *npmManifest) error {
+	var (
+		h    hash.Hash
+		want string
+	)
+	algo, digest, _ := strings.Cut(m.Dist.Integrity, "-")
+	switch {
+	case algo == "sha512":
+		h, want = sha512.New(), digest
+	case algo == "sha256":
+		h, want = sha256.New(), digest
+	case m.Dist.Shasum != "":
+		h, want = sha1.New(), m.Dist.Shasum
+	default:
+		return nil
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	got := hex.EncodeToString(h.Sum(nil))
+	if algo == "sha512" || algo == "sha256" {
+		got = base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+	if got != want {
+		return fmt.Errorf("%s@%s: tarball digest %s does not match %s", m.Name, m.Version, got, want)
+	}
+	return nil
+}
+
+// isDeclaration reports whether a file name is a TypeScript declaration file.
+func isDeclaration(name string) bool {
+	return strings.HasSuffix(name, ".d.ts") || strings.HasSuffix(name, ".d.mts") || strings.HasSuffix(name, ".d.cts")
+}
+
+// extractTarball extracts the files of a package tarball that are read:
+// package.json, the README and declaration files. The tarball's top
+// directory, package/ for most packages, is dropped.
+func extractTarball(tarPath, dest string) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, name, ok := strings.Cut(strings.TrimPrefix(header.Name, "./"), "/")
+		if header.Typeflag != tar.TypeReg || !ok || strings.Contains(name, "node_modules/") {
+			continue
+		}
+		if !isDeclaration(name) && name != "package.json" && !isReadme(name) {
+			continue
+		}
+		if err := shared.ExtractFile(dest, name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func isReadme(name string) bool {
+	return !strings.Contains(name, "/") && strings.EqualFold(strings.TrimSuffix(name, path.Ext(name)), "readme")
+}
+
+// hasDeclarations reports whether an extracted package has declaration
+// files.
+func hasDeclarations(dir string) bool {
+	files, err := declarationFiles(dir)
+	return err == nil && len(files) > 0
+}
+
+// copyDeclarations copies the declarations of an @types package into the
+// package in dest, whose package.json takes the entry points of the @types
+// package.
+func copyDeclarations(src, dest string) error {
+	files, err := declarationFiles(src)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, err := os.Open(filepath.Join(src, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		err = shared.ExtractFile(dest, name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	var pkg, types map[string]json.RawMessage
+	for p, v := range map[string]*map[string]json.RawMessage{src: &types, dest: &pkg} {
+		raw, err := os.ReadFile(filepath.Join(p, "package.json"))
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, v); err != nil {
+			return fmt.Errorf("parse package.json: %w", err)
+		}
+	}
+	for _, key := range []string{"types", "typings", "exports"} {
+		if v, ok := types[key]; ok {
+			pkg[key] = v
+		} else {
+			delete(pkg, key)
+		}
+	}
+	raw, err := json.Marshal(pkg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dest, "package.json"), raw, 0o644)
+}
+
+// declarationFiles lists the declaration files below dir by slash-separated
+// relative path.
+func declarationFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isDeclaration(p) {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	slices.Sort(files)
+	return files, err
+}
+
+// entryPoints maps the subpaths a package can be imported from ("." for the
+// package itself) to their declaration files: the types conditions of
+// package.json exports, else its types or typings field, else the
+// declarations next to main or index.d.ts. A package without any of these
+// has every declaration file as a subpath.
+func entryPoints(pkg packageJSON, files []string) map[string]string {
+	have := func(p string) bool { return slices.Contains(files, p) }
+	entries := map[string]string{}
+	add := func(subpath, target string) {
+		target = path.Clean(strings.TrimPrefix(target, "./"))
+		if !isDeclaration(target) {
+			target = declarationFor(target, have)
+		}
+		if have(target) {
+			entries[subpath] = target
+		}
+	}
+
+	var exports any
+	if len(pkg.Exports) > 0 && json.Unmarshal(pkg.Exports, &exports) == nil {
+		conditions, ok := exports.(map[string]any)
+		isSubpaths := false
+		for key := range conditions {
+			isSubpaths = isSubpaths || strings.HasPrefix(key, ".")
+		}
+		switch {
+		case !ok:
+			if target := exportTarget(exports); target != "" {
+				add(".", target)
+			}
+		case isSubpaths:
+			for subpath, value := range conditions {
+				if strings.Contains(subpath, "*") || subpath == "./package.json" {
+					continue
+				}
+				if target := exportTarget(value); target != "" {
+					add(subpath, target)
+				}
+			}
+		default:
+			if target := exportTarget(exports); target != "" {
+				add(".", target)
+			}
+		}
+	}
+
+	if _, ok := entries["."]; !ok {
+		for _, target := range []string{pkg.Types, pkg.Typings, pkg.Main, "index.d.ts"} {
+			if target != "" {
+				if add(".", target); entries["."] != "" {
+					break
+				}
+			}
+		}
+	}
+
+	if len(entries) == 0 {
+		for _, f := range files {
+			entries["./"+trimDeclarationExt(f)] = f
+		}
+	}
+	return entries
+}
+
+// exportTarget returns the target of an exports entry, preferring a types
+// condition and otherwise trying import, require, node and default in turn.
+func exportTarget(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []any:
+		for _, alt := range v {
+			if target := exportTarget(alt); target != "" {
+				return target
+			}
+		}
+	case map[string]any:
+		for _, cond := range []string{"types", "typings", "import", "require", "node", "default"} {
+			if alt, ok := v[cond]; ok {
+				if target := exportTarget(alt); target != "" {
+					return target
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// declarationFor returns the declaration file for a JavaScript file or
+// directory: lib/index.js is declared in lib/index.d.ts, lib/index.mjs in
+// lib/index.d.mts.
+func declarationFor(p string, have func(string) bool) string {
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	var candidates []string
+	switch ext {
+	case ".js", ".ts":
+		candidates = []string{base + ".d.ts"}
+	case ".mjs", ".mts":
+		candidates = []string{base + ".d.mts", base + ".d.ts"}
+	case ".cjs", ".cts":
+		candidates = []string{base + ".d.cts", base + ".d.ts"}
+	default:
+		candidates = []string{p + ".d.ts", p + "/index.d.ts", p + ".d.mts", p + ".d.cts"}
+	}
+	for _, c := range candidates {
+		if have(c) {
+			return c
+		}
+	}
+	return ""
+}
+
+func trimDeclarationExt(name string) string {
+	for _, ext := range []string{".d.ts", ".d.mts", ".d.cts"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// resolver computes the exports of declaration files, following re-exports
+// and imports between files of the package.
+type resolver struct {
+	files   map[string]*tsModule // by path, and ambient modules by "module:" and name
+	exports map[string][]*tsItem
+	busy    map[string]bool
+}
+
+func newResolver(files map[string]*tsModule) *resolver {
+	r := &resolver{files: map[string]*tsModule{}, exports: map[string][]*tsItem{}, busy: map[string]bool{}}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		r.files[name] = files[name]
+		for ambient, m := range files[name].Ambient {
+			r.files["module:"+ambient] = m
+		}
+	}
+	return r
+}
+
+// resolve returns the file a module specifier in file refers to, or "" for
+// another package.
+func (r *resolver) resolve(file, spec string) string {
+	if !strings.HasPrefix(spec, ".") {
+		if _, ok := r.files["module:"+spec]; ok {
+			return "module:" + spec
+		}
+		return ""
+	}
+	target := path.Join(path.Dir(file), spec)
+	if _, ok := r.files[target]; ok && isDeclaration(target) {
+		return target
+	}
+	return declarationFor(target, func(p string) bool { _, ok := r.files[p]; return ok })
+}
+
+// exportsOf returns the items a file exports, renamed to their exported
+// names.
+func (r *resolver) exportsOf(file string) []*tsItem {
+	if items, ok := r.exports[file]; ok || r.busy[file] {
+		return items
+	}
+	m := r.files[file]
+	if m == nil {
+		return nil
+	}
+	r.busy[file] = true
+	defer delete(r.busy, file)
+
+	var out []*tsItem
+	add := func(name string, it *tsItem) {
+		// A default export is documented under its declared name.
+		isDefault := name == "default"
+		if isDefault {
+			name = it.Name
+		}
+		if slices.ContainsFunc(out, func(have *tsItem) bool { return have.Name == name && have.Kind == it.Kind }) {
+			return
+		}
+		if it.Name != name || isDefault && !it.Default {
+			renamed := *it
+			renamed.Name, renamed.Default = name, isDefault
+			it = &renamed
+		}
+		out = append(out, it)
+	}
+	local := func(name string) []*tsItem {
+		var items []*tsItem
+		for _, it := range m.Items {
+			if it.Name == name {
+				items = append(items, it)
+			}
+		}
+		if len(items) > 0 {
+			return items
+		}
+		imp, ok := m.Imports[name]
+		if !ok {
+			return nil
+		}
+		target := r.resolve(file, imp.From)
+		if imp.Name == "*" {
+			if target == "" {
+				return nil
+			}
+			return []*tsItem{{Name: name, Kind: "Namespace", Signature: "import * as " + name + " from \"" + imp.From + "\"", Members: r.exportsOf(target)}}
+		}
+		return pick(r.exportsOf(target), imp.Name)
+	}
+
+	if m.ExportAssign != "" {
+		// The namespace merged with an exported function or class holds
+		// the module's other exports.
+		for _, it := range local(m.ExportAssign) {
+			if it.Kind != "Namespace" {
+				add(it.Name, it)
+				continue
+			}
+			for _, member := range it.Members {
+				add(member.Name, member)
+			}
+		}
+		r.exports[file] = out
+		return out
+	}
+
+	for _, it := range m.Items {
+		if it.Exported || m.Script {
+			add(it.Name, it)
+		}
+	}
+	for _, e := range m.Exports {
+		if e.From == "" {
+			for _, name := range e.Names {
+				for _, it := range local(name[1]) {
+					add(name[0], it)
+				}
+			}
+			continue
+		}
+		target := r.resolve(file, e.From)
+		if target == "" {
+			continue
+		}
+		items := r.exportsOf(target)
+		switch {
+		case e.Star && e.As != "":
+			add(e.As, &tsItem{Name: e.As, Kind: "Namespace", Signature: "export * as " + e.As + " from \"" + e.From + "\"", Members: items})
+		case e.Star:
+			for _, it := range items {
+				if !it.Default {
+					add(it.Name, it)
+				}
+			}
+		default:
+			for _, name := range e.Names {
+				for _, it := range pick(items, name[1]) {
+					add(name[0], it)
+				}
+			}
+		}
+	}
+	r.exports[file] = out
+	return out
+}
+
+// pick returns the exports named name; "default" picks the default export.
+func pick(items []*tsItem, name string) []*tsItem {
+	var picked []*tsItem
+	for _, it := range items {
+		if it.Name == name || name == "default" && it.Default {
+			picked = append(picked, it)
+		}
+	}
+	return picked
+}
+
+// DocPath returns the document path of a module of a package:
+// npm/<package>/index for the package itself and npm/<package>/<subpath>
+// for the others.
+func DocPath(pkg, subpath string) string {
+	if subpath == "." {
+		return "npm/" + pkg + "/index"
+	}
+	return "npm/" + pkg + "/" + strings.TrimPrefix(subpath, "./")
+}
+
+// ingestPackage parses the declaration files of an extracted package and
+// writes a document for every entry point that exports something, and one
+// for the README. Documents of a previous ingest of the package are
+// replaced.
+func ingestPackage(ctx context.Context, tx *sql.Tx, dir string) (int, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return 0, fmt.Errorf("read package.json: %w", err)
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(raw, &pkg); err != nil {
+		return 0, fmt.Errorf("parse package.json: %w", err)
+	}
+
+	names, err := declarationFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	files := map[string]*tsModule{}
+	for _, name := range names {
+		src, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return 0, err
+		}
+		files[name] = parseDeclarations(string(src))
+	}
+	r := newResolver(files)
+
+	prefix := "npm/" + pkg.Name + "/"
+	old, err := db.DocumentHashesTx(ctx, tx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for p := range old {
+		if err := db.DeleteDocumentTx(ctx, tx, p); err != nil {
+			return 0, err
+		}
+	}
+
+	entries := entryPoints(pkg, names)
+	// A package declaring itself in an ambient module, as some @types
+	// packages do, is documented from that module.
+	for _, name := range slices.Sorted(maps.Keys(r.files)) {
+		sub, ok := strings.CutPrefix(name, "module:"+pkg.Name)
+		if !ok || sub != "" && !strings.HasPrefix(sub, "/") {
+			continue
+		}
+		if have, ok := entries["."+sub]; !ok || len(r.exportsOf(have)) == 0 {
+			entries["."+sub] = name
+		}
+	}
+
+	var written []string
+	for _, subpath := range slices.Sorted(maps.Keys(entries)) {
+		file := entries[subpath]
+		items := r.exportsOf(file)
+		module := pkg.Name + strings.TrimPrefix(subpath, ".")
+		if len(items) == 0 {
+			continue
+		}
+		if err := writeModule(ctx, tx, DocPath(pkg.Name, subpath), module, r.files[file].Doc, items); err != nil {
+			return 0, err
+		}
+		written = append(written, module)
+	}
+
+	// Packages such as @types/node declare the modules they describe in
+	// ambient modules; those are documented under their own names when the
+	// package exports nothing itself. A node: alias of a declared module is
+	// skipped.
+	if len(written) == 0 {
+		for _, file := range slices.Sorted(maps.Keys(r.files)) {
+			module, ok := strings.CutPrefix(file, "module:")
+			if alias, isAlias := strings.CutPrefix(module, "node:"); !ok || isAlias && r.files["module:"+alias] != nil {
+				continue
+			}
+			items := r.exportsOf(file)
+			if len(items) == 0 {
+				continue
+			}
+			if err := writeModule(ctx, tx, DocPath(pkg.Name, "./"+module), module, r.files[file].Doc, items); err != nil {
+				return 0, err
+			}
+			written = append(written, module)
+		}
+	}
+
+	title, md := renderIndex(dir, pkg, written)
+	if _, err := docset.WriteDocument(ctx, tx, prefix+"README.md", title, md); err != nil {
+		return 0, err
+	}
+	return len(written), nil
+}
+
+// moduleSections orders the item sections of a module document.
+var moduleSections = []struct{ kind, title string }{
+	{"Class", "Classes"},
+	{"Interface", "Interfaces"},
+	{"Function", "Functions"},
+	{"TypeAlias", "Type Aliases"},
+	{"Enum", "Enums"},
+	{"Variable", "Variables"},
+	{"Namespace", "Namespaces"},
+}
+
+func renderModule(module, doc string, items []*tsItem) string {
+	var b strings.Builder
+	b.WriteString("# " + module + "\n\n")
+	if doc := jsdocMarkdown(doc); doc != "" {
+		b.WriteString(doc + "\n\n")
+	}
+
+	for _, section := range moduleSections {
+		first := true
+		for _, it := range items {
+			if it.Kind != section.kind {
+				continue
+			}
+			if first {
+				b.WriteString("## " + section.title + "\n\n")
+				first = false
+			}
+			writeItem(&b, "###", it.Name, it)
+			for _, member := range it.Members {
+				writeItem(&b, "####", it.Name+"."+member.Name, member)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeItem(b *strings.Builder, heading, name string, it *tsItem) {
+	b.WriteString(heading + " " + name + "\n\n")
+	b.WriteString("```ts\n" + it.Signature + "\n```\n\n")
+	if doc := jsdocMarkdown(it.Doc); doc != "" {
+		b.WriteString(doc + "\n\n")
+	}
+}
+
+// renderIndex renders the package README with the list of documented
+// modules.
+func renderIndex(dir string, pkg packageJSON, modules []string) (title, md string) {
+	var readme string
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && isReadme(e.Name()) {
+				if raw, err := os.ReadFile(filepath.Join(dir, e.Name())); err == nil {
+					title, readme = docset.Convert(e.Name(), string(raw))
+					break
+				}
+			}
+		}
+	}
+	if t, _ := docset.ExtractTitleAndContent(readme); t == "" {
+		header := "# " + pkg.Name + "\n\n"
+		if pkg.Description != "" {
+			header += pkg.Description + "\n\n"
+		}
+		readme = header + readme
+	}
+	if title == "" || strings.EqualFold(title, "readme") {
+		title = pkg.Name
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(readme) + "\n\n")
+	if pkg.Version != "" {
+		b.WriteString("Version: " + pkg.Version + "\n\n")
+	}
+	if len(modules) > 0 {
+		b.WriteString("## Modules\n\n")
+		for _, name := range modules {
+			b.WriteString("- `" + name + "`\n")
+		}
+	}
+	return title, strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeModule(ctx context.Context, tx *sql.Tx, docPath, module, doc string, items []*tsItem) error {
+	md := renderModule(module, doc, items)
+	docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
+		Path:   docPath,
+		Format: "markdown",
+		Body:   shared.Compress(md),
+		Hash:   db.HashBytes([]byte(md)),
+	})
+	if err != nil {
+		return err
+	}
+
+	summary := jsdocSummary(doc)
+	if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+		Name:  module,
+		Type:  "Module",
+		Body:  module + " " + summary,
+		DocID: docID,
+	}); err != nil {
+		return err
+	}
+	if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+		DocID:     docID,
+		Symbol:    module,
+		Signature: `import "` + module + `"`,
+		Summary:   summary,
+	}); err != nil {
+		return err
+	}
+
+	insert := func(symbol string, it *tsItem) error {
+		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+			Name:  symbol,
+			Type:  it.Kind,
+			Body:  symbol + " " + it.Signature + " " + jsdocText(it.Doc),
+			DocID: docID,
+		}); err != nil {
+			return err
+		}
+		return db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    symbol,
+			Signature: it.Signature,
+			Summary:   jsdocSummary(it.Doc),
+		})
+	}
+	for _, it := range items {
+		symbol := module + "." + it.Name
+		if err := insert(symbol, it); err != nil {
+			return err
+		}
+		for _, member := range it.Members {
+			if err := insert(symbol+"."+member.Name, member); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
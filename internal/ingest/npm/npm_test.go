@@ -0,0 +1,165 @@
+package npm
+
+import (
+	"context"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/base64"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/codec"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+	"github.com/stormlightlabs/documango/internal/shared/sharedtest"
+)
+
+func TestSplitSpec(t *testing.T) {
+	tests := []struct{ spec, name, version string }{
+		{"react", "react", ""},
+		{"react@18.3.1", "react", "18.3.1"},
+		{"@atproto/api", "@atproto/api", ""},
+		{"@atproto/api@next", "@atproto/api", "next"},
+	}
+	for _, tt := range tests {
+		if name, version := SplitSpec(tt.spec); name != tt.name || version != tt.version {
+			t.Errorf("SplitSpec(%q) = %q, %q", tt.spec, name, version)
+		}
+	}
+	if got := TypesPackage("@babel/core"); got != "@types/babel__core" {
+		t.Errorf("TypesPackage() = %q", got)
+	}
+}
+
+func TestEntryPoints(t *testing.T) {
+	files := []string{"dist/index.d.ts", "dist/index.d.mts", "dist/xrpc.d.ts", "index.d.ts", "lib/main.d.ts"}
+	tests := []struct {
+		name string
+		pkg  packageJSON
+		want map[string]string
+	}{
+		{"exports", packageJSON{Exports: []byte(`{
+			".": {"import": {"types": "./dist/index.d.mts", "default": "./dist/index.mjs"}, "require": "./dist/index.js"},
+			"./xrpc": "./dist/xrpc.js",
+			"./package.json": "./package.json",
+			"./*": "./dist/*.js"
+		}`)}, map[string]string{".": "dist/index.d.mts", "./xrpc": "dist/xrpc.d.ts"}},
+		{"conditions", packageJSON{Exports: []byte(`{"types": "./dist/index.d.ts", "default": "./dist/index.js"}`)}, map[string]string{".": "dist/index.d.ts"}},
+		{"types", packageJSON{Types: "dist/index.d.ts", Main: "lib/main.js"}, map[string]string{".": "dist/index.d.ts"}},
+		{"main", packageJSON{Main: "./lib/main"}, map[string]string{".": "lib/main.d.ts"}},
+		{"index", packageJSON{Main: "missing.js"}, map[string]string{".": "index.d.ts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entryPoints(tt.pkg, files); !maps.Equal(got, tt.want) {
+				t.Errorf("entryPoints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := entryPoints(packageJSON{}, []string{"a.d.ts", "b/c.d.ts"}); !maps.Equal(got, map[string]string{"./a": "a.d.ts", "./b/c": "b/c.d.ts"}) {
+		t.Errorf("entryPoints() without entries = %v", got)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "pkg.tgz")
+	sharedtest.WriteTarGz(t, archive, map[string]string{"package/package.json": "{}"})
+	raw, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512(raw)
+
+	m := &npmManifest{Name: "pkg", Version: "1.0.0"}
+	m.Dist.Integrity = "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+	if err := checkIntegrity(archive, m); err != nil {
+		t.Errorf("checkIntegrity() = %v", err)
+	}
+	m.Dist.Integrity = "sha512-AAAA"
+	if err := checkIntegrity(archive, m); err == nil {
+		t.Error("checkIntegrity() accepted a wrong digest")
+	}
+}
+
+func TestIngestPackage(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	archive := filepath.Join(t.TempDir(), "api-1.0.0.tgz")
+	sharedtest.WriteTarGz(t, archive, map[string]string{
+		"package/package.json":          `{"name": "@demo/api", "version": "1.0.0", "description": "A demo.", "types": "dist/index.d.ts", "exports": {".": {"types": "./dist/index.d.ts"}, "./xrpc": {"types": "./dist/xrpc/index.d.ts"}}}`,
+		"package/README.md":             "# @demo/api\n\nTalks to servers.\n",
+		"package/dist/index.js":         "module.exports = {}\n",
+		"package/dist/index.d.ts":       "export * from './agent';\nexport { Session } from './session';\n",
+		"package/dist/agent.d.ts":       "/** Talks to a server. */\nexport declare class Agent {\n    /** Logs in. */\n    login(id: string): Promise<void>;\n}\n/** @internal */\nexport declare function debug(): void;\n",
+		"package/dist/session.d.ts":     "export interface Session {\n    did: string;\n}\nexport interface Hidden {}\n",
+		"package/dist/xrpc/index.d.ts":  "/** Calls a method. */\nexport declare function call(nsid: string): unknown;\n",
+		"package/dist/empty.d.ts":       "export {};\n",
+		"package/node_modules/x/a.d.ts": "export declare const a: 1;\n",
+	})
+	dir := t.TempDir()
+	if err := extractTarball(archive, dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dist/index.js", "node_modules/x/a.d.ts"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s extracted: %v", name, err)
+		}
+	}
+
+	var n int
+	if err := store.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		n, err = ingestPackage(ctx, tx, dir)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("ingested %d modules, want 2", n)
+	}
+
+	doc, err := store.ReadDocument(ctx, "npm/@demo/api/index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := codec.Decompress(doc.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(raw)
+	for _, want := range []string{"# @demo/api\n", "## Classes\n\n### Agent\n\n```ts\nclass Agent\n```\n\nTalks to a server.", "#### Agent.login", "## Interfaces\n\n### Session"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index document lacks %q:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{"debug", "Hidden"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("index document documents %s:\n%s", unwanted, body)
+		}
+	}
+
+	readme, err := store.ReadDocument(ctx, "npm/@demo/api/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := codec.Decompress(readme.Body); !strings.Contains(string(raw), "Talks to servers.") || !strings.Contains(string(raw), "- `@demo/api/xrpc`") {
+		t.Errorf("README document =\n%s", raw)
+	}
+
+	sym, err := store.GetSymbolContext(ctx, "@demo/api.Agent.login")
+	if err != nil || sym.Signature != "login(id: string): Promise<void>" || sym.Summary != "Logs in." {
+		t.Errorf("GetSymbolContext(@demo/api.Agent.login) = %+v, %v", sym, err)
+	}
+	results, err := store.Search(ctx, "npm/@demo/api/call", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(results, func(r db.SearchResult) bool { return r.Name == "@demo/api/xrpc.call" && r.Type == "Function" }) {
+		t.Errorf("search for npm/@demo/api/call = %+v", results)
+	}
+}
@@ -0,0 +1,368 @@
+package python
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	googleSection = regexp.MustCompile(`^(Args|Arguments|Parameters|Params|Keyword Args|Keyword Arguments|Other Parameters|Attributes|Returns?|Yields?|Raises|Warns|Examples?|Notes?|Warnings?|See Also|References|Todo):\s*$`)
+	numpySection  = regexp.MustCompile(`^(Parameters|Other Parameters|Attributes|Methods|Returns|Yields|Receives|Raises|Warns|Warnings|See Also|Notes|References|Examples?)$`)
+	googleEntry   = regexp.MustCompile(`^(\*{0,2}[A-Za-z_][\w.]*)\s*(?:\(([^)]*)\))?\s*:\s*(.*)$`)
+	numpyEntry    = regexp.MustCompile(`^(\*{0,2}[A-Za-z_][\w.]*(?:\s*,\s*\*{0,2}[A-Za-z_][\w.]*)*)\s*(?::\s*(.*))?$`)
+	rstField      = regexp.MustCompile(`^:(param|parameter|arg|argument|key|keyword|type|returns?|rtype|raises?|except|exception|var|ivar|cvar|vartype|yields?|ytype)(?:\s+([^:]+?))?:\s*(.*)$`)
+	rstDirective  = regexp.MustCompile(`^\.\.\s+([\w:-]+)::\s*(.*)$`)
+	rstRole       = regexp.MustCompile(":(?:[\\w-]+:)?[\\w-]+:`!?~?([^`<]*?)(?:\\s*<([^>]+)>)?`")
+	rstLiteral    = regexp.MustCompile("``([^`]+)``")
+)
+
+// listSections are the docstring sections whose entries describe a name:
+// they become lists of `name` (type): description.
+var listSections = map[string]bool{
+	"Args": true, "Arguments": true, "Parameters": true, "Params": true,
+	"Keyword Args": true, "Keyword Arguments": true, "Other Parameters": true,
+	"Attributes": true, "Methods": true, "Raises": true, "Warns": true,
+}
+
+// fieldSections names the section each reStructuredText info field is
+// listed in.
+var fieldSections = map[string]string{
+	"param": "Parameters", "parameter": "Parameters", "arg": "Parameters", "argument": "Parameters",
+	"key": "Parameters", "keyword": "Parameters",
+	"returns": "Returns", "return": "Returns", "rtype": "Returns",
+	"yields": "Yields", "yield": "Yields", "ytype": "Yields",
+	"raises": "Raises", "raise": "Raises", "except": "Raises", "exception": "Raises",
+	"var": "Attributes", "ivar": "Attributes", "cvar": "Attributes",
+}
+
+// docstringMarkdown converts a cleaned docstring to Markdown. Google and
+// NumPy style sections and reStructuredText info fields (:param x:) become
+// bold section labels over lists of names; doctests, literal blocks (::)
+// and code-block directives become fenced Python; admonitions become
+// block quotes and roles inline code. Other text is left alone, except
+// that indentation, which would read as code in Markdown, is removed.
+func docstringMarkdown(doc string) string {
+	if doc == "" {
+		return ""
+	}
+	lines := strings.Split(doc, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, ">>>"):
+			var block []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				block = append(block, strings.TrimSpace(lines[i]))
+			}
+			out = append(out, fence("pycon", block)...)
+			continue
+
+		case googleSection.MatchString(line):
+			name := strings.TrimSuffix(trimmed, ":")
+			body, end := indented(lines, i+1, 1)
+			out = append(out, section(name, body)...)
+			i = end - 1
+			continue
+
+		case numpySection.MatchString(line) && i+1 < len(lines) && isUnderline(strings.TrimSpace(lines[i+1])) && strings.TrimSpace(lines[i+1])[0] == '-':
+			end := i + 2
+			for end < len(lines) && !(numpySection.MatchString(lines[end]) && end+1 < len(lines) && isUnderline(strings.TrimSpace(lines[end+1]))) {
+				end++
+			}
+			out = append(out, numpy(trimmed, lines[i+2:end])...)
+			i = end - 1
+			continue
+
+		case rstField.MatchString(line):
+			end := i
+			var fields []string
+			for end < len(lines) && (rstField.MatchString(lines[end]) || (len(fields) > 0 && indent(lines[end]) > 0)) {
+				if rstField.MatchString(lines[end]) {
+					fields = append(fields, lines[end])
+				} else {
+					fields[len(fields)-1] += " " + strings.TrimSpace(lines[end])
+				}
+				end++
+			}
+			out = append(out, infoFields(fields)...)
+			i = end - 1
+			continue
+
+		case rstDirective.MatchString(trimmed):
+			m := rstDirective.FindStringSubmatch(trimmed)
+			body, end := indented(lines, i+1, indent(line)+1)
+			i = end - 1
+			switch m[1] {
+			case "code-block", "code", "sourcecode", "doctest", "testcode":
+				lang := m[2]
+				if lang == "" {
+					lang = "python"
+				}
+				out = append(out, fence(lang, skipOptions(body))...)
+			case "note", "tip", "hint", "important", "warning", "caution", "attention", "danger", "error", "seealso",
+				"deprecated", "versionadded", "versionchanged":
+				label := strings.ToUpper(m[1][:1]) + m[1][1:]
+				if label == "Seealso" {
+					label = "See also"
+				}
+				text := strings.TrimSpace(m[2] + " " + strings.Join(trimAll(body), " "))
+				out = append(out, "> **"+label+"**: "+inline(text))
+			}
+			continue
+
+		case trimmed == ".." || strings.HasPrefix(trimmed, ".. "):
+			// Comments, targets and substitution definitions.
+			_, end := indented(lines, i+1, indent(line)+1)
+			i = end - 1
+			continue
+
+		case isUnderline(trimmed) && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "":
+			out[len(out)-1] = "**" + strings.TrimSpace(out[len(out)-1]) + "**"
+			continue
+
+		case strings.HasSuffix(trimmed, "::"):
+			body, end := indented(lines, i+1, indent(line)+1)
+			if len(body) == 0 {
+				break
+			}
+			if text := strings.TrimSuffix(trimmed, "::"); text != "" {
+				if !strings.HasSuffix(text, " ") {
+					text += ":"
+				}
+				out = append(out, inline(strings.TrimSpace(text)), "")
+			}
+			out = append(out, fence("python", body)...)
+			i = end - 1
+			continue
+		}
+		out = append(out, inline(trimmed))
+	}
+	return strings.TrimSpace(collapseBlank(strings.Join(out, "\n")))
+}
+
+// section renders a Google style section: entries of the list sections
+// become list items, other sections keep their text below the label.
+func section(name string, body []string) []string {
+	out := []string{"", "**" + name + "**", ""}
+	if !listSections[name] {
+		return append(append(out, docstringMarkdown(strings.Join(dedent(body), "\n"))), "")
+	}
+
+	var items []string
+	for _, line := range dedent(body) {
+		m := googleEntry.FindStringSubmatch(line)
+		switch {
+		case strings.TrimSpace(line) == "":
+		case indent(line) == 0 && m != nil:
+			items = append(items, listItem(m[1], m[2], m[3]))
+		case len(items) > 0:
+			items[len(items)-1] += " " + inline(strings.TrimSpace(line))
+		default:
+			items = append(items, "- "+inline(strings.TrimSpace(line)))
+		}
+	}
+	return append(append(out, items...), "")
+}
+
+// numpy renders a NumPy style section, whose entries are a "name : type"
+// line followed by an indented description.
+func numpy(name string, body []string) []string {
+	if !listSections[name] && name != "Returns" && name != "Yields" && name != "Receives" {
+		return append([]string{"", "**" + name + "**", ""}, docstringMarkdown(strings.Join(body, "\n")), "")
+	}
+	out := []string{"", "**" + name + "**", ""}
+	described := false
+	for _, line := range body {
+		switch m := numpyEntry.FindStringSubmatch(line); {
+		case strings.TrimSpace(line) == "":
+		case indent(line) == 0 && m != nil:
+			out = append(out, listItem(m[1], m[2], ""))
+			described = false
+		case indent(line) == 0:
+			out = append(out, "- "+inline(strings.TrimSpace(line)))
+			described = false
+		case len(out) > 3:
+			sep := " "
+			if !described {
+				sep = ": "
+			}
+			out[len(out)-1] += sep + inline(strings.TrimSpace(line))
+			described = true
+		}
+	}
+	return append(out, "")
+}
+
+// infoFields renders reStructuredText info fields, merging :type x: into
+// the :param x: it describes.
+func infoFields(fields []string) []string {
+	type entry struct{ section, name, typ, desc string }
+	var entries []*entry
+	find := func(section, name string) *entry {
+		for _, e := range entries {
+			if e.section == section && e.name == name {
+				return e
+			}
+		}
+		e := &entry{section: section, name: name}
+		entries = append(entries, e)
+		return e
+	}
+	for _, field := range fields {
+		m := rstField.FindStringSubmatch(field)
+		kind, arg, desc := m[1], strings.TrimSpace(m[2]), strings.TrimSpace(m[3])
+		switch kind {
+		case "type":
+			find("Parameters", arg).typ = desc
+		case "vartype":
+			find("Attributes", arg).typ = desc
+		case "rtype", "ytype":
+			find(fieldSections[kind], "").typ = desc
+		default:
+			name := arg
+			if fs := strings.Fields(arg); len(fs) > 1 && fieldSections[kind] == "Parameters" {
+				// :param int x: carries the type before the name.
+				name = fs[len(fs)-1]
+				find("Parameters", name).typ = strings.Join(fs[:len(fs)-1], " ")
+			}
+			find(fieldSections[kind], name).desc = desc
+		}
+	}
+
+	var out []string
+	last := ""
+	for _, e := range entries {
+		if e.section != last {
+			out = append(out, "", "**"+e.section+"**", "")
+			last = e.section
+		}
+		if e.name == "" {
+			text := e.desc
+			if e.typ != "" {
+				text = strings.TrimSuffix("`"+e.typ+"`: "+text, ": ")
+			}
+			out = append(out, inline(text))
+			continue
+		}
+		out = append(out, listItem(e.name, e.typ, e.desc))
+	}
+	return append(out, "")
+}
+
+// isUnderline reports whether a line is a reStructuredText section
+// adornment: three or more of the same punctuation character.
+func isUnderline(line string) bool {
+	return len(line) >= 3 && strings.ContainsRune(`=-~^"'*+#`, rune(line[0])) && strings.Count(line, line[:1]) == len(line)
+}
+
+func listItem(name, typ, desc string) string {
+	item := "- `" + strings.TrimSpace(name) + "`"
+	if typ = strings.TrimSpace(typ); typ != "" {
+		item += " (" + inline(typ) + ")"
+	}
+	if desc = strings.TrimSpace(desc); desc != "" {
+		item += ": " + inline(desc)
+	}
+	return item
+}
+
+// inline turns reStructuredText literals and roles into code spans.
+func inline(s string) string {
+	s = rstLiteral.ReplaceAllString(s, "`$1`")
+	return rstRole.ReplaceAllStringFunc(s, func(match string) string {
+		m := rstRole.FindStringSubmatch(match)
+		text := strings.TrimSpace(m[1])
+		if m[2] != "" && text == "" {
+			text = m[2]
+		}
+		if strings.Contains(match, "`~") {
+			text = text[strings.LastIndex(text, ".")+1:]
+		}
+		return "`" + text + "`"
+	})
+}
+
+// indented returns the lines from start indented at least min columns,
+// with the blank lines between them, and the index after the block.
+func indented(lines []string, start, min int) ([]string, int) {
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	end := start
+	for end < len(lines) && (strings.TrimSpace(lines[end]) == "" || indent(lines[end]) >= min) {
+		end++
+	}
+	for end > start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return dedent(lines[start:end]), end
+}
+
+func indent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func dedent(lines []string) []string {
+	margin := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && (margin < 0 || indent(line) < margin) {
+			margin = indent(line)
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= margin && margin > 0 {
+			line = line[margin:]
+		}
+		out[i] = line
+	}
+	return out
+}
+
+func trimAll(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// skipOptions drops the :option: lines opening a directive's content.
+func skipOptions(lines []string) []string {
+	for len(lines) > 0 && (strings.HasPrefix(lines[0], ":") || strings.TrimSpace(lines[0]) == "") {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+func fence(lang string, lines []string) []string {
+	out := append([]string{"", "```" + lang}, lines...)
+	return append(out, "```", "")
+}
+
+// collapseBlank removes runs of blank lines outside fences.
+func collapseBlank(s string) string {
+	var out []string
+	inFence, blank := false, false
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.TrimSpace(line) == "" {
+			if blank {
+				continue
+			}
+			blank = true
+			out = append(out, "")
+			continue
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,37 @@
+package python
+
+import "testing"
+
+func TestDocstringMarkdown(t *testing.T) {
+	tests := []struct {
+		name, doc, want string
+	}{
+		{
+			"google",
+			"Fetch a URL.\n\nArgs:\n    url (str): The URL.\n    retries: How often to\n        retry.\n\nReturns:\n    The body.\n\nExample:\n    >>> fetch(\"x\")\n    b''",
+			"Fetch a URL.\n\n**Args**\n\n- `url` (str): The URL.\n- `retries`: How often to retry.\n\n**Returns**\n\nThe body.\n\n**Example**\n\n```pycon\n>>> fetch(\"x\")\nb''\n```",
+		},
+		{
+			"numpy",
+			"Sum values.\n\nParameters\n----------\nvalues : list of int\n    The values.\naxis : int, optional\n\nReturns\n-------\nint\n    The total.",
+			"Sum values.\n\n**Parameters**\n\n- `values` (list of int): The values.\n- `axis` (int, optional)\n\n**Returns**\n\n- `int`: The total.",
+		},
+		{
+			"fields",
+			"Send it.\n\n:param str body: the body\n:param timeout: seconds to\n    wait\n:type timeout: float\n:returns: the reply\n:rtype: Reply\n:raises OSError: on failure",
+			"Send it.\n\n**Parameters**\n\n- `body` (str): the body\n- `timeout` (float): seconds to wait\n\n**Returns**\n\n`Reply`: the reply\n\n**Raises**\n\n- `OSError`: on failure",
+		},
+		{
+			"rst",
+			"Usage\n=====\n\nCall :func:`~pkg.run` with ``quiet``::\n\n    run(quiet=True)\n\n.. note:: Runs once.\n\n.. code-block:: console\n\n   $ pkg run\n\n.. _target:",
+			"**Usage**\n\nCall `run` with `quiet`:\n\n```python\nrun(quiet=True)\n```\n\n> **Note**: Runs once.\n\n```console\n$ pkg run\n```",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := docstringMarkdown(tt.doc); got != tt.want {
+				t.Errorf("docstringMarkdown() =\n%s\n\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
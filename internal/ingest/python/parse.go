@@ -0,0 +1,541 @@
+package python
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// pyModule is what parseModule reads from a Python source file.
+type pyModule struct {
+	Name    string
+	Doc     string
+	All     []string // names listed in __all__, nil when there is none
+	Items   []pyItem
+	Imports []pyImport
+}
+
+// pyItem is a definition: a class, function, method, property or
+// assignment. Members holds the public members of a class.
+type pyItem struct {
+	Name      string
+	Kind      string
+	Signature string
+	Doc       string
+	Members   []pyItem
+}
+
+// pyImport is a module level from-import, used to document the names a
+// public module re-exports from a private one.
+type pyImport struct {
+	From  string            // module as written, with its leading dots
+	Names map[string]string // local name to imported name
+	Star  bool
+}
+
+// pyLine is a logical line of Python source: physical lines joined by
+// brackets, backslashes or triple-quoted strings, with comments removed.
+type pyLine struct {
+	indent int
+	text   string
+}
+
+var (
+	pyDef      = regexp.MustCompile(`^(?:async\s+)?def\s+([A-Za-z_]\w*)`)
+	pyClass    = regexp.MustCompile(`^class\s+([A-Za-z_]\w*)`)
+	pyTypeStmt = regexp.MustCompile(`^type\s+([A-Za-z_]\w*)\b`)
+	pyCompound = regexp.MustCompile(`^(?:if|elif|else|try|except|finally|with|async\s+with)\b`)
+	pyAllStmt  = regexp.MustCompile(`^__all__\s*(?:[+]?=|:[^=]*=|\.extend\s*\(|\.append\s*\()`)
+	pyFromStmt = regexp.MustCompile(`^from\s+(\.*[\w.]*)\s+import\s+(.+)$`)
+	pyStrItem  = regexp.MustCompile(`["']([A-Za-z_]\w*)["']`)
+	pyIdent    = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	pyConstant = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// maxValueLen bounds the values shown in the signatures of assignments;
+// longer ones are elided.
+const maxValueLen = 80
+
+// parseModule reads the docstring, __all__, from-imports and definitions
+// of a module without running it. Definitions in if, try and with blocks
+// count as the module's own, the first of a name winning; overloads of a
+// function are listed together.
+func parseModule(name, src string) *pyModule {
+	lines := logicalLines(shared.NormalizeLineEndings(src))
+	m := &pyModule{Name: name}
+	m.Doc, m.Items = parseBlock(lines, m)
+	return m
+}
+
+// parseBlock parses a run of statements at the indentation of the first,
+// returning the docstring opening it and its definitions. Module level
+// statements (__all__ and imports) are recorded in mod, which is nil for
+// class bodies.
+func parseBlock(lines []pyLine, mod *pyModule) (doc string, items []pyItem) {
+	if len(lines) == 0 {
+		return "", nil
+	}
+	if s, ok := stringLiteral(lines[0].text); ok {
+		doc = cleandoc(s)
+	}
+
+	var decorators []string
+	indent := lines[0].indent
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		end := i + 1
+		for end < len(lines) && lines[end].indent > indent {
+			end++
+		}
+		body := lines[i+1 : end]
+		i = end - 1
+
+		text := line.text
+		if strings.HasPrefix(text, "@") {
+			decorators = append(decorators, normalizeSpace(text))
+			continue
+		}
+		decorated := decorators
+		decorators = nil
+
+		var item pyItem
+		switch {
+		case pyDef.MatchString(text):
+			header, inline := splitHeader(text)
+			item = pyItem{
+				Name:      pyDef.FindStringSubmatch(text)[1],
+				Kind:      "Function",
+				Signature: signature(decorated, header),
+				Doc:       bodyDoc(inline, body),
+			}
+			if mod == nil {
+				item.Kind = "Method"
+				if decoratedWith(decorated, "property", "cached_property") {
+					item.Kind = "Property"
+				}
+			}
+			// Overloads are listed together; the implementation that
+			// follows them only adds its docstring.
+			if k := slices.IndexFunc(items, func(it pyItem) bool { return it.Name == item.Name }); k >= 0 {
+				if items[k].Kind == item.Kind && strings.Contains(items[k].Signature, "overload\n") {
+					if decoratedWith(decorated, "overload") {
+						items[k].Signature += "\n" + item.Signature
+					}
+					if items[k].Doc == "" {
+						items[k].Doc = item.Doc
+					}
+				}
+				continue
+			}
+		case pyClass.MatchString(text):
+			header, inline := splitHeader(text)
+			item = pyItem{
+				Name:      pyClass.FindStringSubmatch(text)[1],
+				Kind:      "Class",
+				Signature: signature(decorated, header),
+			}
+			if inline != "" {
+				body = []pyLine{{indent: indent + 1, text: inline}}
+			}
+			item.Doc, item.Members = parseBlock(body, nil)
+		case pyCompound.MatchString(text):
+			_, inline := splitHeader(text)
+			if inline != "" {
+				body = []pyLine{{indent: indent + 1, text: inline}}
+			}
+			_, nested := parseBlock(body, mod)
+			for _, it := range nested {
+				if !slices.ContainsFunc(items, func(have pyItem) bool { return have.Name == it.Name }) {
+					items = append(items, it)
+				}
+			}
+			continue
+		case mod != nil && pyAllStmt.MatchString(text):
+			// An assignment replaces the list, += and extend add to it.
+			if _, _, _, ok := splitAssign(text); ok || mod.All == nil {
+				mod.All = []string{}
+			}
+			for _, m := range pyStrItem.FindAllStringSubmatch(text, -1) {
+				mod.All = append(mod.All, m[1])
+			}
+			continue
+		case mod != nil && pyFromStmt.MatchString(text):
+			mod.Imports = append(mod.Imports, parseFromImport(text))
+			continue
+		case pyTypeStmt.MatchString(text):
+			item = pyItem{
+				Name:      pyTypeStmt.FindStringSubmatch(text)[1],
+				Kind:      "TypeAlias",
+				Signature: normalizeSpace(text),
+			}
+		default:
+			target, annotation, value, ok := splitAssign(text)
+			if !ok {
+				continue
+			}
+			item = pyItem{Name: target, Signature: assignSignature(target, annotation, value)}
+			switch {
+			case annotation == "TypeAlias" || strings.HasSuffix(annotation, ".TypeAlias"):
+				item.Kind = "TypeAlias"
+				item.Signature = target + " = " + normalizeSpace(value)
+			case mod == nil:
+				item.Kind = "Attribute"
+			case pyConstant.MatchString(target):
+				item.Kind = "Constant"
+			default:
+				item.Kind = "Variable"
+			}
+			// A string statement after an assignment documents it, as
+			// Sphinx autodoc reads attribute docstrings.
+			if i+1 < len(lines) && lines[i+1].indent == indent {
+				if s, ok := stringLiteral(lines[i+1].text); ok {
+					item.Doc = cleandoc(s)
+					i++
+				}
+			}
+			if k := slices.IndexFunc(items, func(it pyItem) bool { return it.Name == item.Name }); k >= 0 {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+	return doc, items
+}
+
+// bodyDoc returns the docstring of a function: a string literal opening its
+// body, or its one-line body.
+func bodyDoc(inline string, body []pyLine) string {
+	first := inline
+	if first == "" && len(body) > 0 {
+		first = body[0].text
+	}
+	s, _ := stringLiteral(first)
+	return cleandoc(s)
+}
+
+// decoratedWith reports whether one of the decorators is one of names,
+// possibly qualified by a module (@typing.overload).
+func decoratedWith(decorators []string, names ...string) bool {
+	for _, d := range decorators {
+		d, _, _ = strings.Cut(strings.TrimPrefix(d, "@"), "(")
+		if slices.Contains(names, d[strings.LastIndex(d, ".")+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// signature joins decorators and a def or class header.
+func signature(decorators []string, header string) string {
+	return strings.Join(append(slices.Clone(decorators), normalizeSpace(header)), "\n")
+}
+
+func assignSignature(target, annotation, value string) string {
+	sig := target
+	if annotation != "" {
+		sig += ": " + normalizeSpace(annotation)
+	}
+	if value != "" {
+		value = normalizeSpace(value)
+		if len(value) > maxValueLen {
+			value = "..."
+		}
+		sig += " = " + value
+	}
+	return sig
+}
+
+func parseFromImport(text string) pyImport {
+	m := pyFromStmt.FindStringSubmatch(text)
+	imp := pyImport{From: m[1], Names: map[string]string{}}
+	names := strings.Trim(strings.TrimSpace(m[2]), "()")
+	for _, name := range strings.Split(names, ",") {
+		fields := strings.Fields(name)
+		switch {
+		case len(fields) == 1 && fields[0] == "*":
+			imp.Star = true
+		case len(fields) == 1:
+			imp.Names[fields[0]] = fields[0]
+		case len(fields) == 3 && fields[1] == "as":
+			imp.Names[fields[2]] = fields[0]
+		}
+	}
+	return imp
+}
+
+// logicalLines splits source into logical lines, dropping blank lines and
+// comments. String literals are kept verbatim, newlines within brackets
+// become spaces.
+func logicalLines(src string) []pyLine {
+	var (
+		lines  []pyLine
+		b      strings.Builder
+		depth  int
+		indent = -1
+	)
+	emit := func() {
+		if text := strings.TrimSpace(b.String()); text != "" {
+			lines = append(lines, pyLine{indent: indent, text: text})
+		}
+		b.Reset()
+		depth, indent = 0, -1
+	}
+
+	for i := 0; i < len(src); {
+		if indent < 0 {
+			n, j := 0, i
+			for ; j < len(src) && (src[j] == ' ' || src[j] == '\t' || src[j] == '\f'); j++ {
+				if src[j] == '\t' {
+					n += 8 - n%8
+				} else {
+					n++
+				}
+			}
+			if j < len(src) && (src[j] == '\n' || src[j] == '#') {
+				for j < len(src) && src[j] != '\n' {
+					j++
+				}
+				i = j + 1
+				continue
+			}
+			indent, i = n, j
+			continue
+		}
+
+		switch c := src[i]; {
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			b.WriteByte(' ')
+			i += 2
+		case c == '\n':
+			i++
+			if depth > 0 {
+				b.WriteByte(' ')
+				continue
+			}
+			emit()
+		case c == '\'' || c == '"':
+			end := stringEnd(src, i)
+			b.WriteString(src[i:end])
+			i = end
+		default:
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth = max(depth-1, 0)
+			}
+			b.WriteByte(c)
+			i++
+		}
+	}
+	emit()
+	return lines
+}
+
+// stringEnd returns the index just past the string literal whose opening
+// quote is at s[i]. An unterminated single-quoted string ends at the end of
+// its line.
+func stringEnd(s string, i int) int {
+	q := s[i : i+1]
+	if strings.HasPrefix(s[i:], q+q+q) {
+		q = q + q + q
+	}
+	for j := i + len(q); j < len(s); j++ {
+		switch {
+		case s[j] == '\\':
+			j++
+		case s[j] == '\n' && len(q) == 1:
+			return j
+		case strings.HasPrefix(s[j:], q):
+			return j + len(q)
+		}
+	}
+	return len(s)
+}
+
+// scanTop calls fn with the index of every character of s outside string
+// literals and brackets, stopping when fn returns true.
+func scanTop(s string, fn func(i int) bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"':
+			i = stringEnd(s, i) - 1
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && fn(i):
+			return
+		}
+	}
+}
+
+// splitHeader splits a compound statement at the colon ending its header,
+// returning the header and the statement following the colon on the same
+// line, if any.
+func splitHeader(text string) (header, inline string) {
+	header = text
+	scanTop(text, func(i int) bool {
+		if text[i] != ':' {
+			return false
+		}
+		header, inline = text[:i], strings.TrimSpace(text[i+1:])
+		return true
+	})
+	return strings.TrimSpace(header), inline
+}
+
+// splitAssign splits an assignment to a single name, with an optional
+// annotation. Other statements are not assignments.
+func splitAssign(text string) (target, annotation, value string, ok bool) {
+	colon, eq := -1, -1
+	scanTop(text, func(i int) bool {
+		switch {
+		case text[i] == ':' && colon < 0 && eq < 0:
+			colon = i
+		case text[i] == '=' && !strings.ContainsRune("=<>!:+-*/%&|^@", rune(prev(text, i))) && (i+1 >= len(text) || text[i+1] != '='):
+			eq = i
+			return true
+		case text[i] == '=' && i+1 < len(text) && text[i+1] == '=':
+			return true
+		}
+		return false
+	})
+	switch {
+	case colon >= 0:
+		target = strings.TrimSpace(text[:colon])
+		if eq >= 0 {
+			annotation, value = text[colon+1:eq], text[eq+1:]
+		} else {
+			annotation = text[colon+1:]
+		}
+	case eq >= 0:
+		target, value = strings.TrimSpace(text[:eq]), text[eq+1:]
+	default:
+		return "", "", "", false
+	}
+	if !pyIdent.MatchString(target) {
+		return "", "", "", false
+	}
+	return target, strings.TrimSpace(annotation), strings.TrimSpace(value), true
+}
+
+func prev(s string, i int) byte {
+	if i == 0 {
+		return 0
+	}
+	return s[i-1]
+}
+
+// stringLiteral returns the value of a statement made of string literals
+// only, as docstrings are. Bytes and f-strings are not docstrings.
+func stringLiteral(text string) (string, bool) {
+	var b strings.Builder
+	rest := strings.TrimSpace(text)
+	if rest == "" {
+		return "", false
+	}
+	for rest != "" {
+		prefix := strings.IndexAny(rest, `'"`)
+		if prefix < 0 || prefix > 2 || strings.Trim(strings.ToLower(rest[:prefix]), "ru") != "" {
+			return "", false
+		}
+		raw := strings.ContainsAny(rest[:prefix], "rR")
+		rest = rest[prefix:]
+		end := stringEnd(rest, 0)
+		q := 1
+		if end >= 6 && strings.HasPrefix(rest, rest[:1]+rest[:1]+rest[:1]) {
+			q = 3
+		}
+		if end < 2*q {
+			return "", false
+		}
+		body := rest[q : end-q]
+		if !raw {
+			body = unescape(body)
+		}
+		b.WriteString(body)
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return b.String(), true
+}
+
+var pyEscapes = strings.NewReplacer(`\\`, `\`, `\'`, `'`, `\"`, `"`, `\n`, "\n", `\t`, "\t", "\\\n", "")
+
+func unescape(s string) string {
+	return pyEscapes.Replace(s)
+}
+
+// cleandoc removes the indentation of a docstring's continuation lines and
+// surrounding blank lines, as inspect.cleandoc does.
+func cleandoc(doc string) string {
+	lines := strings.Split(strings.ReplaceAll(doc, "\t", "        "), "\n")
+	margin := -1
+	for _, line := range lines[1:] {
+		if trimmed := strings.TrimLeft(line, " "); trimmed != "" {
+			if n := len(line) - len(trimmed); margin < 0 || n < margin {
+				margin = n
+			}
+		}
+	}
+	lines[0] = strings.TrimSpace(lines[0])
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= margin && margin > 0 {
+			lines[i] = lines[i][margin:]
+		}
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n ")
+}
+
+// normalizeSpace collapses whitespace outside string literals, and the
+// space and trailing commas just inside brackets, so a declaration split
+// over several lines reads as one.
+func normalizeSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := stringEnd(s, i)
+			if space {
+				b.WriteByte(' ')
+				space = false
+			}
+			b.WriteString(s[i:end])
+			i = end - 1
+		case c == ' ' || c == '\t' || c == '\n':
+			space = b.Len() > 0
+		case c == ')' || c == ']' || c == '}':
+			// A trailing comma before a line break is formatting; (1,)
+			// is a tuple.
+			out := b.String()
+			if space {
+				out = strings.TrimSuffix(out, ",")
+			}
+			b.Reset()
+			b.WriteString(out)
+			b.WriteByte(c)
+			space = false
+		default:
+			last := byte(0)
+			if b.Len() > 0 {
+				last = b.String()[b.Len()-1]
+			}
+			if space && last != '(' && last != '[' && last != '{' {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,156 @@
+package python
+
+import (
+	"slices"
+	"testing"
+)
+
+// moduleFixture exercises decorators, overloads, multi-line signatures,
+// attribute docstrings, conditional definitions and __all__.
+const moduleFixture = `"""HTTP helpers."""
+from __future__ import annotations
+
+import typing as t
+from ._impl import Session, _hidden as hidden
+from ._types import *
+
+__all__ = ["get", "Client", "TIMEOUT", "Session", "Headers"]
+__all__ += ["Response"]
+
+TIMEOUT: float = 5.0  # seconds
+"""Seconds to wait."""
+
+@t.overload
+def get(url: str, *, stream: t.Literal[True]) -> t.Iterator[bytes]: ...
+@t.overload
+def get(url: str, *, stream: t.Literal[False] = ...) -> Response: ...
+def get(url, *, stream=False):
+    """Send a GET request."""
+
+class Client(
+    Base,  # comment
+    metaclass=Meta,
+):
+    """A client.
+
+    Longer text.
+    """
+
+    timeout: float = 1.0
+    _private = 2
+
+    def __init__(self, base: str = "", retries: int = 3,
+                 sizes=(1,)) -> None:
+        self.base = base
+
+    @property
+    def closed(self) -> bool:
+        r"""Whether it is closed \o/."""
+
+    async def send(self, request: "Request", /) -> Response: ...
+
+class Response: """The result."""
+
+if t.TYPE_CHECKING:
+    type Headers = dict[str, str]
+else:
+    Headers = dict
+
+x == 1
+`
+
+func TestParseModule(t *testing.T) {
+	m := parseModule("httpx", moduleFixture)
+
+	if m.Doc != "HTTP helpers." {
+		t.Errorf("Doc = %q", m.Doc)
+	}
+	if want := []string{"get", "Client", "TIMEOUT", "Session", "Headers", "Response"}; !slices.Equal(m.All, want) {
+		t.Errorf("All = %q, want %q", m.All, want)
+	}
+	if len(m.Imports) != 3 || m.Imports[1].From != "._impl" || m.Imports[1].Names["hidden"] != "_hidden" || !m.Imports[2].Star {
+		t.Errorf("Imports = %+v", m.Imports)
+	}
+
+	want := []pyItem{
+		{Name: "TIMEOUT", Kind: "Constant", Signature: "TIMEOUT: float = 5.0", Doc: "Seconds to wait."},
+		{Name: "get", Kind: "Function", Doc: "Send a GET request.", Signature: "@t.overload\ndef get(url: str, *, stream: t.Literal[True]) -> t.Iterator[bytes]\n" +
+			"@t.overload\ndef get(url: str, *, stream: t.Literal[False] = ...) -> Response"},
+		{Name: "Client", Kind: "Class", Signature: "class Client(Base, metaclass=Meta)", Doc: "A client.\n\nLonger text.", Members: []pyItem{
+			{Name: "timeout", Kind: "Attribute", Signature: "timeout: float = 1.0"},
+			{Name: "_private", Kind: "Attribute", Signature: "_private = 2"},
+			{Name: "__init__", Kind: "Method", Signature: `def __init__(self, base: str = "", retries: int = 3, sizes=(1,)) -> None`},
+			{Name: "closed", Kind: "Property", Signature: "@property\ndef closed(self) -> bool", Doc: `Whether it is closed \o/.`},
+			{Name: "send", Kind: "Method", Signature: `async def send(self, request: "Request", /) -> Response`},
+		}},
+		{Name: "Response", Kind: "Class", Signature: "class Response", Doc: "The result."},
+		{Name: "Headers", Kind: "TypeAlias", Signature: "type Headers = dict[str, str]"},
+	}
+	if len(m.Items) != len(want) {
+		t.Fatalf("Items = %+v", m.Items)
+	}
+	for i, it := range m.Items {
+		if it.Name != want[i].Name || it.Kind != want[i].Kind || it.Signature != want[i].Signature || it.Doc != want[i].Doc {
+			t.Errorf("item %d = %+v\nwant %+v", i, it, want[i])
+		}
+		if !slices.EqualFunc(it.Members, want[i].Members, func(a, b pyItem) bool {
+			return a.Name == b.Name && a.Kind == b.Kind && a.Signature == b.Signature && a.Doc == b.Doc
+		}) {
+			t.Errorf("%s members = %+v\nwant %+v", it.Name, it.Members, want[i].Members)
+		}
+	}
+
+	items := publicItems(m)
+	var names []string
+	for _, it := range items {
+		names = append(names, it.Name)
+	}
+	if want := []string{"TIMEOUT", "get", "Client", "Response", "Headers"}; !slices.Equal(names, want) {
+		t.Errorf("public items = %q, want %q", names, want)
+	}
+	if n := len(items[2].Members); n != 4 {
+		t.Errorf("public Client members = %+v", items[2].Members)
+	}
+}
+
+func TestSplitAssign(t *testing.T) {
+	tests := []struct {
+		text, target, annotation, value string
+		ok                              bool
+	}{
+		{"x = 1", "x", "", "1", true},
+		{"x: int", "x", "int", "", true},
+		{"x: dict[str, int] = {'a': 1}", "x", "dict[str, int]", "{'a': 1}", true},
+		{"f = lambda a: a", "f", "", "lambda a: a", true},
+		{"x += 1", "", "", "", false},
+		{"x == 1", "", "", "", false},
+		{"a.b = 1", "", "", "", false},
+		{"call(a=1)", "", "", "", false},
+		{"for x in y: pass", "", "", "", false},
+	}
+	for _, tt := range tests {
+		target, annotation, value, ok := splitAssign(tt.text)
+		if target != tt.target || annotation != tt.annotation || value != tt.value || ok != tt.ok {
+			t.Errorf("splitAssign(%q) = %q, %q, %q, %v", tt.text, target, annotation, value, ok)
+		}
+	}
+}
+
+func TestResolveImport(t *testing.T) {
+	tests := []struct {
+		from, module string
+		pkg          bool
+		want         string
+	}{
+		{"os.path", "pkg.mod", false, "os.path"},
+		{"._impl", "pkg", true, "pkg._impl"},
+		{"._impl", "pkg.mod", false, "pkg._impl"},
+		{"..", "pkg.sub", true, "pkg"},
+		{"..util", "pkg.sub.mod", false, "pkg.util"},
+	}
+	for _, tt := range tests {
+		if got := resolveImport(tt.from, tt.module, tt.pkg); got != tt.want {
+			t.Errorf("resolveImport(%q, %q, %v) = %q, want %q", tt.from, tt.module, tt.pkg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,728 @@
+// Package python ingests the API documentation of Python packages from
+// PyPI. A wheel or sdist is read statically: docstrings and signatures come
+// from the source, so no Python interpreter is needed.
+package python
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+type Options struct {
+	Package string
+	Version string
+	DB      *db.Store
+	Cache   *cache.FilesystemCache
+}
+
+type pypiRelease struct {
+	Info pypiInfo   `json:"info"`
+	URLs []pypiFile `json:"urls"`
+}
+
+type pypiInfo struct {
+	Name                   string `json:"name"`
+	Version                string `json:"version"`
+	Summary                string `json:"summary"`
+	Description            string `json:"description"`
+	DescriptionContentType string `json:"description_content_type"`
+}
+
+type pypiFile struct {
+	Filename    string `json:"filename"`
+	URL         string `json:"url"`
+	PackageType string `json:"packagetype"`
+	Digests     struct {
+		SHA256 string `json:"sha256"`
+	} `json:"digests"`
+	Yanked bool `json:"yanked"`
+}
+
+var (
+	nameSeparators = regexp.MustCompile(`[-_.]+`)
+	// requirement is a project name with optional extras and an optional
+	// exact version; ranges, wildcards and markers do not name a release.
+	requirement = regexp.MustCompile(`^([^\s\[\]=<>!~;,@]+)\s*(?:\[[^\]]*\])?\s*(?:==\s*([^\s=<>!~;,*]+))?$`)
+)
+
+// skipDirs are never packages; skipRootDirs and skipRootFiles are the
+// directories and modules of an sdist's root that are not part of the
+// package.
+var (
+	skipDirs      = map[string]bool{"tests": true, "__pycache__": true}
+	skipRootDirs  = map[string]bool{"test": true, "docs": true, "doc": true, "examples": true, "benchmarks": true, "scripts": true, "build": true}
+	skipRootFiles = map[string]bool{"setup": true, "noxfile": true, "fabfile": true, "versioneer": true, "manage": true}
+)
+
+// SplitSpec splits a requirement such as requests==2.32.3 into the project
+// name and version; extras are dropped and the version is empty when none
+// is pinned. Other version operators (>=, ~=, ===) are rejected.
+func SplitSpec(spec string) (name, version string, err error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return "", "", nil
+	}
+	m := requirement.FindStringSubmatch(spec)
+	if m == nil {
+		return "", "", fmt.Errorf("unsupported requirement %q: pin an exact version with ==, as requests==2.32.3", spec)
+	}
+	return m[1], m[2], nil
+}
+
+// NormalizeName returns the normalized form of a project name (PEP 503),
+// used in document paths: python/<name>/<module>.
+func NormalizeName(name string) string {
+	return strings.ToLower(nameSeparators.ReplaceAllString(name, "-"))
+}
+
+func IngestPackage(ctx context.Context, opts Options) error {
+	if opts.Package == "" {
+		return errors.New("package name is required")
+	}
+	if opts.DB == nil {
+		return errors.New("db store is required")
+	}
+
+	rel, err := fetchRelease(ctx, opts.Package, opts.Version)
+	if err != nil {
+		return err
+	}
+	file, err := selectDist(rel.URLs)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", rel.Info.Name, rel.Info.Version, err)
+	}
+
+	tmpDir, cleanup, err := downloadDist(ctx, file, opts.Cache)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	log.Info("python package ingest starting", "package", rel.Info.Name, "version", rel.Info.Version, "file", file.Filename)
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		n, err := ingestSources(ctx, tx, rel.Info, tmpDir)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no documented modules found in %s", file.Filename)
+		}
+		log.Info("python package ingested", "package", rel.Info.Name, "modules", n)
+		return nil
+	})
+}
+
+func fetchRelease(ctx context.Context, pkg, version string) (*pypiRelease, error) {
+	url := fmt.Sprintf("https://pypi.org/pypi/%s/json", pkg)
+	if version != "" {
+		url = fmt.Sprintf("https://pypi.org/pypi/%s/%s/json", pkg, version)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && version != "":
+		return nil, fmt.Errorf("version %s of %s not found on PyPI", version, pkg)
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("package %s not found on PyPI", pkg)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("pypi api error: %s", resp.Status)
+	}
+
+	var rel pypiRelease
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+// selectDist picks the file to read a release from: a pure Python wheel,
+// which holds the package as installed, else the sdist, else any wheel,
+// whose Python modules and .pyi stubs are read without its extensions.
+func selectDist(files []pypiFile) (pypiFile, error) {
+	files = slices.DeleteFunc(slices.Clone(files), func(f pypiFile) bool { return f.Yanked })
+	for _, match := range []func(pypiFile) bool{
+		func(f pypiFile) bool { return strings.HasSuffix(f.Filename, "-none-any.whl") },
+		func(f pypiFile) bool {
+			return f.PackageType == "sdist" && (strings.HasSuffix(f.Filename, ".tar.gz") || strings.HasSuffix(f.Filename, ".zip"))
+		},
+		func(f pypiFile) bool { return strings.HasSuffix(f.Filename, ".whl") },
+	} {
+		if i := slices.IndexFunc(files, match); i >= 0 {
+			return files[i], nil
+		}
+	}
+	return pypiFile{}, errors.New("no wheel or sdist to read")
+}
+
+// downloadDist fetches a release file, through the cache when there is one,
+// checks it against the SHA-256 digest PyPI lists and extracts its Python
+// sources to a temporary directory.
+func downloadDist(ctx context.Context, file pypiFile, c *cache.FilesystemCache) (string, func(), error) {
+	cacheKey := cache.PythonDistKey(file.Filename)
+	var archivePath string
+
+	if c != nil {
+		if cached, entry, err := c.Get(cacheKey); err == nil {
+			if file.Digests.SHA256 == "" || entry.Checksum == file.Digests.SHA256 {
+				archivePath = cached
+			} else {
+				_ = c.Delete(cacheKey)
+			}
+		}
+	}
+
+	if archivePath == "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URL, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", nil, fmt.Errorf("pypi download error: %s", resp.Status)
+		}
+
+		var checksum string
+		if c != nil {
+			entry, err := c.Put(cacheKey, file.URL, resp.Body, 0)
+			if err != nil {
+				return "", nil, err
+			}
+			archivePath, checksum = filepath.Join(c.Dir(), entry.Path), entry.Checksum
+		} else {
+			f, err := os.CreateTemp("", "documango-python-*-"+file.Filename)
+			if err != nil {
+				return "", nil, err
+			}
+			defer f.Close()
+			hash := sha256.New()
+			if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+				return "", nil, err
+			}
+			archivePath, checksum = f.Name(), hex.EncodeToString(hash.Sum(nil))
+		}
+
+		if file.Digests.SHA256 != "" && checksum != file.Digests.SHA256 {
+			if c != nil {
+				_ = c.Delete(cacheKey)
+			} else {
+				_ = os.Remove(archivePath)
+			}
+			return "", nil, fmt.Errorf("%s: sha256 %s does not match %s", file.Filename, checksum, file.Digests.SHA256)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "documango-python-extract-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := extractSources(archivePath, tmpDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", nil, err
+	}
+
+	cleanup := func() {
+		_ = os.RemoveAll(tmpDir)
+		if c == nil {
+			_ = os.Remove(archivePath)
+		}
+	}
+
+	return tmpDir, cleanup, nil
+}
+
+// extractSources extracts the .py and .pyi files of a wheel, or of a
+// .tar.gz or .zip sdist.
+func extractSources(archivePath, dest string) error {
+	if strings.HasSuffix(archivePath, ".tar.gz") {
+		return untarSources(archivePath, dest)
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || !isSource(file.Name) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = shared.ExtractFile(dest, file.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func untarSources(tarPath, dest string) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || !isSource(header.Name) {
+			continue
+		}
+		if err := shared.ExtractFile(dest, header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func isSource(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".py" || ext == ".pyi"
+}
+
+// sourceFile is a module found in a package tree.
+type sourceFile struct {
+	path string
+	pkg  bool // an __init__ file, whose relative imports start at itself
+}
+
+// sourceRoot finds the directory a distribution's packages are imported
+// from: the root of a wheel, or the project directory of an sdist, or its
+// src directory.
+func sourceRoot(dir string) string {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) == 1 && entries[0].IsDir() && !pyIdent.MatchString(entries[0].Name()) {
+		dir = filepath.Join(dir, entries[0].Name())
+	}
+	if info, err := os.Stat(filepath.Join(dir, "src")); err == nil && info.IsDir() {
+		return filepath.Join(dir, "src")
+	}
+	return dir
+}
+
+// findModules lists the modules below root by dotted name. Tests, build
+// scripts and directories that cannot be imported are skipped; a .py file
+// is preferred to a .pyi stub of the same module.
+func findModules(root string) (map[string]sourceFile, error) {
+	modules := map[string]sourceFile{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		atRoot := !strings.Contains(rel, "/")
+
+		if d.IsDir() {
+			if name := d.Name(); !pyIdent.MatchString(name) || skipDirs[name] || atRoot && skipRootDirs[name] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := path.Ext(rel)
+		stem := strings.TrimSuffix(d.Name(), ext)
+		if !isSource(rel) || !pyIdent.MatchString(stem) || stem == "conftest" ||
+			strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test") || atRoot && skipRootFiles[stem] {
+			return nil
+		}
+
+		var parts []string
+		if dir := path.Dir(rel); dir != "." {
+			parts = strings.Split(dir, "/")
+		}
+		if stem != "__init__" {
+			parts = append(parts, stem)
+		}
+		if len(parts) == 0 {
+			return nil
+		}
+		name := strings.Join(parts, ".")
+		if have, ok := modules[name]; ok && path.Ext(have.path) == ".py" {
+			return nil
+		}
+		modules[name] = sourceFile{path: p, pkg: stem == "__init__"}
+		return nil
+	})
+	return modules, err
+}
+
+// isPrivate reports whether a module is private by convention: one of its
+// components starts with an underscore.
+func isPrivate(module string) bool {
+	for _, part := range strings.Split(module, ".") {
+		if strings.HasPrefix(part, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// exports reports whether a module exports name: it is listed in __all__,
+// or there is no __all__ and the name does not start with an underscore.
+func (m *pyModule) exports(name string) bool {
+	if m.All != nil {
+		return slices.Contains(m.All, name)
+	}
+	return !strings.HasPrefix(name, "_")
+}
+
+func (m *pyModule) item(name string) (pyItem, bool) {
+	i := slices.IndexFunc(m.Items, func(it pyItem) bool { return it.Name == name })
+	if i < 0 {
+		return pyItem{}, false
+	}
+	return m.Items[i], true
+}
+
+// resolveImport returns the absolute name of the module a from-import in
+// module names.
+func resolveImport(from, module string, pkg bool) string {
+	rest := strings.TrimLeft(from, ".")
+	dots := len(from) - len(rest)
+	if dots == 0 {
+		return from
+	}
+	base := module
+	if !pkg {
+		dots++
+	}
+	for ; dots > 1; dots-- {
+		i := strings.LastIndex(base, ".")
+		if i < 0 {
+			base = ""
+			break
+		}
+		base = base[:i]
+	}
+	switch {
+	case base == "":
+		return rest
+	case rest == "":
+		return base
+	}
+	return base + "." + rest
+}
+
+// reexporter adds to a module the definitions it imports from private
+// modules and exports, so an API implemented in _impl.py is documented where
+// users import it from.
+type reexporter struct {
+	modules map[string]*pyModule
+	files   map[string]sourceFile
+	state   map[string]int // 1 while resolving, 2 when done
+}
+
+func (r *reexporter) resolve(name string) {
+	m := r.modules[name]
+	if r.state[name] != 0 || m == nil {
+		return
+	}
+	r.state[name] = 1
+	defer func() { r.state[name] = 2 }()
+
+	for _, imp := range m.Imports {
+		srcName := resolveImport(imp.From, name, r.files[name].pkg)
+		src := r.modules[srcName]
+		if src == nil || !isPrivate(srcName) || r.state[srcName] == 1 {
+			continue
+		}
+		r.resolve(srcName)
+
+		if imp.Star {
+			for _, it := range src.Items {
+				if src.exports(it.Name) && m.exports(it.Name) {
+					if _, ok := m.item(it.Name); !ok {
+						m.Items = append(m.Items, it)
+					}
+				}
+			}
+		}
+		for _, local := range slices.Sorted(maps.Keys(imp.Names)) {
+			it, ok := src.item(imp.Names[local])
+			if _, have := m.item(local); !ok || have || !m.exports(local) {
+				continue
+			}
+			it.Name = local
+			m.Items = append(m.Items, it)
+		}
+	}
+}
+
+// ingestSources parses the modules of an extracted distribution and writes
+// a document for every public module with a docstring or public items, and
+// an index document for the project. Documents of a previous ingest of the
+// project are replaced.
+func ingestSources(ctx context.Context, tx *sql.Tx, info pypiInfo, dir string) (int, error) {
+	files, err := findModules(sourceRoot(dir))
+	if err != nil {
+		return 0, err
+	}
+
+	modules := map[string]*pyModule{}
+	for name, file := range files {
+		src, err := os.ReadFile(file.path)
+		if err != nil {
+			return 0, err
+		}
+		modules[name] = parseModule(name, string(src))
+	}
+	r := &reexporter{modules: modules, files: files, state: map[string]int{}}
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		r.resolve(name)
+	}
+
+	prefix := "python/" + NormalizeName(info.Name) + "/"
+	old, err := db.DocumentHashesTx(ctx, tx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for p := range old {
+		if err := db.DeleteDocumentTx(ctx, tx, p); err != nil {
+			return 0, err
+		}
+	}
+
+	var written []string
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		m := modules[name]
+		if isPrivate(name) {
+			continue
+		}
+		items := publicItems(m)
+		if m.Doc == "" && len(items) == 0 {
+			continue
+		}
+		if err := writeModule(ctx, tx, prefix+name, m, items); err != nil {
+			return 0, err
+		}
+		written = append(written, name)
+	}
+
+	title, md := renderIndex(info, written)
+	if _, err := docset.WriteDocument(ctx, tx, prefix+"index", title, md); err != nil {
+		return 0, err
+	}
+	return len(written), nil
+}
+
+// publicItems returns the items a module exports, with the public members
+// of classes: names without a leading underscore, and dunder methods.
+func publicItems(m *pyModule) []pyItem {
+	var items []pyItem
+	for _, it := range m.Items {
+		if !m.exports(it.Name) {
+			continue
+		}
+		var members []pyItem
+		for _, member := range it.Members {
+			dunder := strings.HasPrefix(member.Name, "__") && strings.HasSuffix(member.Name, "__")
+			if !strings.HasPrefix(member.Name, "_") || dunder && member.Kind == "Method" {
+				members = append(members, member)
+			}
+		}
+		it.Members = members
+		items = append(items, it)
+	}
+	return items
+}
+
+// moduleSections orders the item sections of a module document.
+var moduleSections = []struct{ kind, title string }{
+	{"Class", "Classes"},
+	{"Function", "Functions"},
+	{"TypeAlias", "Type Aliases"},
+	{"Constant", "Constants"},
+	{"Variable", "Variables"},
+}
+
+func renderModule(m *pyModule, items []pyItem) string {
+	var b strings.Builder
+	b.WriteString("# " + m.Name + "\n\n")
+	if doc := docstringMarkdown(m.Doc); doc != "" {
+		b.WriteString(doc + "\n\n")
+	}
+
+	for _, section := range moduleSections {
+		first := true
+		for _, it := range items {
+			if it.Kind != section.kind {
+				continue
+			}
+			if first {
+				b.WriteString("## " + section.title + "\n\n")
+				first = false
+			}
+			writeItem(&b, "###", it)
+			for _, member := range it.Members {
+				writeItem(&b, "####", member)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeItem(b *strings.Builder, heading string, it pyItem) {
+	b.WriteString(heading + " " + it.Name + "\n\n")
+	b.WriteString("```python\n" + it.Signature + "\n```\n\n")
+	if doc := docstringMarkdown(it.Doc); doc != "" {
+		b.WriteString(doc + "\n\n")
+	}
+}
+
+// renderIndex renders the project's description, as uploaded to PyPI, with
+// the list of documented modules.
+func renderIndex(info pypiInfo, modules []string) (title, md string) {
+	if info.Description == "UNKNOWN" {
+		info.Description = ""
+	}
+	file := "README.md"
+	if strings.HasPrefix(info.DescriptionContentType, "text/x-rst") {
+		file = "README.rst"
+	}
+	title, md = docset.Convert(file, info.Description)
+	if t, _ := docset.ExtractTitleAndContent(md); t == "" {
+		header := "# " + info.Name + "\n\n"
+		if info.Summary != "" {
+			header += info.Summary + "\n\n"
+		}
+		md = header + md
+	}
+	if title == "" || title == docset.TitleFromPath(file) {
+		title = info.Name
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(md) + "\n\n")
+	if info.Version != "" {
+		b.WriteString("Version: " + info.Version + "\n\n")
+	}
+	if len(modules) > 0 {
+		b.WriteString("## Modules\n\n")
+		for _, name := range modules {
+			b.WriteString("- `" + name + "`\n")
+		}
+	}
+	return title, strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeModule(ctx context.Context, tx *sql.Tx, docPath string, m *pyModule, items []pyItem) error {
+	md := renderModule(m, items)
+	docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
+		Path:   docPath,
+		Format: "markdown",
+		Body:   shared.Compress(md),
+		Hash:   db.HashBytes([]byte(md)),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+		Name:  m.Name,
+		Type:  "Module",
+		Body:  m.Name + " " + m.Doc,
+		DocID: docID,
+	}); err != nil {
+		return err
+	}
+	if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+		DocID:     docID,
+		Symbol:    m.Name,
+		Signature: "import " + m.Name,
+		Summary:   shared.FirstLine(m.Doc),
+	}); err != nil {
+		return err
+	}
+
+	insert := func(symbol string, it pyItem) error {
+		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+			Name:  symbol,
+			Type:  it.Kind,
+			Body:  symbol + " " + it.Signature + " " + it.Doc,
+			DocID: docID,
+		}); err != nil {
+			return err
+		}
+		return db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    symbol,
+			Signature: it.Signature,
+			Summary:   shared.FirstLine(it.Doc),
+		})
+	}
+	for _, it := range items {
+		symbol := m.Name + "." + it.Name
+		if err := insert(symbol, it); err != nil {
+			return err
+		}
+		for _, member := range it.Members {
+			if err := insert(symbol+"."+member.Name, member); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
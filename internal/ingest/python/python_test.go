@@ -0,0 +1,136 @@
+package python
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/codec"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+	"github.com/stormlightlabs/documango/internal/shared/sharedtest"
+)
+
+func TestSplitSpec(t *testing.T) {
+	tests := []struct{ spec, name, version string }{
+		{"requests", "requests", ""},
+		{"requests==2.32.3", "requests", "2.32.3"},
+		{"uvicorn[standard]==0.30.1", "uvicorn", "0.30.1"},
+		{" zope.interface == 6.4 ", "zope.interface", "6.4"},
+	}
+	for _, tt := range tests {
+		if name, version, err := SplitSpec(tt.spec); err != nil || name != tt.name || version != tt.version {
+			t.Errorf("SplitSpec(%q) = %q, %q, %v", tt.spec, name, version, err)
+		}
+	}
+	for _, spec := range []string{"requests>=2", "requests~=2.3", "requests===2.0", "requests!=2.0", "requests==2.*", "requests<3,>=2"} {
+		if _, _, err := SplitSpec(spec); err == nil || !strings.Contains(err.Error(), "unsupported requirement") {
+			t.Errorf("SplitSpec(%q) error = %v", spec, err)
+		}
+	}
+	if got := NormalizeName("Zope.Interface__x"); got != "zope-interface-x" {
+		t.Errorf("NormalizeName() = %q", got)
+	}
+}
+
+func TestSelectDist(t *testing.T) {
+	files := []pypiFile{
+		{Filename: "demo-1.0-cp312-cp312-manylinux_x86_64.whl", PackageType: "bdist_wheel"},
+		{Filename: "demo-1.0.tar.gz", PackageType: "sdist"},
+		{Filename: "demo-1.0-py3-none-any.whl", PackageType: "bdist_wheel", Yanked: true},
+	}
+	if got, err := selectDist(files); err != nil || got.Filename != "demo-1.0.tar.gz" {
+		t.Errorf("selectDist() = %q, %v", got.Filename, err)
+	}
+	files[2].Yanked = false
+	if got, _ := selectDist(files); got.Filename != "demo-1.0-py3-none-any.whl" {
+		t.Errorf("selectDist() = %q, want the pure wheel", got.Filename)
+	}
+	if _, err := selectDist(nil); err == nil {
+		t.Error("selectDist(nil) succeeded")
+	}
+}
+
+func TestIngestSources(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	archive := filepath.Join(t.TempDir(), "demo-1.0.tar.gz")
+	sharedtest.WriteTarGz(t, archive, map[string]string{
+		"demo-1.0/setup.py":                   "from setuptools import setup\nsetup()\n",
+		"demo-1.0/README.md":                  "# Demo\n",
+		"demo-1.0/tests/test_core.py":         "def test_run(): pass\n",
+		"demo-1.0/src/demo/__init__.py":       "\"\"\"Demo toolkit.\"\"\"\nfrom ._core import run, Runner\nfrom .util import helper\n\n__all__ = [\"run\", \"Runner\"]\n",
+		"demo-1.0/src/demo/_core.py":          "def run(task: str, *, dry: bool = False) -> int:\n    \"\"\"Run a task.\n\n    Args:\n        task: Name of the task.\n    \"\"\"\n\nclass Runner:\n    \"\"\"Runs tasks.\"\"\"\n\n    def start(self) -> None:\n        \"\"\"Start the runner.\"\"\"\n",
+		"demo-1.0/src/demo/util.py":           "def helper(x):\n    \"\"\"Help.\"\"\"\n\ndef _internal(): pass\n",
+		"demo-1.0/src/demo/empty/__init__.py": "",
+		"demo-1.0/src/demo/py.typed":          "",
+	})
+	dir := t.TempDir()
+	if err := extractSources(archive, dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "demo-1.0", "README.md")); !os.IsNotExist(err) {
+		t.Errorf("non-Python file extracted: %v", err)
+	}
+
+	info := pypiInfo{Name: "Demo", Version: "1.0", Summary: "A demo.", Description: "Demo\n====\n\nDoes things.\n", DescriptionContentType: "text/x-rst"}
+	var n int
+	if err := store.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		n, err = ingestSources(ctx, tx, info, dir)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("ingested %d modules, want 2 (demo and demo.util)", n)
+	}
+
+	doc, err := store.ReadDocument(ctx, "python/demo/demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := codec.Decompress(doc.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(raw)
+	for _, want := range []string{"# demo\n\nDemo toolkit.", "### run\n\n```python\ndef run(task: str, *, dry: bool = False) -> int\n```", "- `task`: Name of the task.", "#### start"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("demo document lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "helper") {
+		t.Errorf("demo documents helper, which is not in __all__:\n%s", body)
+	}
+
+	index, err := store.ReadDocument(ctx, "python/demo/index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := codec.Decompress(index.Body); !strings.Contains(string(raw), "# Demo\n\nDoes things.") || !strings.Contains(string(raw), "- `demo.util`") {
+		t.Errorf("index document =\n%s", raw)
+	}
+	for _, p := range []string{"python/demo/demo._core", "python/demo/demo.empty", "python/demo/setup", "python/demo/tests.test_core"} {
+		if _, err := store.ReadDocument(ctx, p); err == nil {
+			t.Errorf("%s was written", p)
+		}
+	}
+
+	sym, err := store.GetSymbolContext(ctx, "demo.Runner.start")
+	if err != nil || sym.Signature != "def start(self) -> None" || sym.Summary != "Start the runner." {
+		t.Errorf("GetSymbolContext(demo.Runner.start) = %+v, %v", sym, err)
+	}
+	results, err := store.Search(ctx, "helper", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(results, func(r db.SearchResult) bool { return r.Name == "demo.util.helper" && r.Type == "Function" }) {
+		t.Errorf("search for helper = %+v", results)
+	}
+}
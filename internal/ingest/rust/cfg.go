@@ -0,0 +1,466 @@
+package rust
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/stormlightlabs/documango/internal/db"
+)
+
+// cfgExpr is a parsed cfg predicate such as
+// all(feature = "fs", any(unix, target_os = "wasi")).
+type cfgExpr struct {
+	Name  string    // option name, or all/any/not for combinators
+	Value string    // value of a key = "value" option
+	Args  []cfgExpr // operands of all/any/not
+}
+
+func (e cfgExpr) combinator() bool {
+	return e.Name == "all" || e.Name == "any" || e.Name == "not"
+}
+
+// String renders the predicate back in source form.
+func (e cfgExpr) String() string {
+	if e.combinator() {
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = arg.String()
+		}
+		return e.Name + "(" + strings.Join(args, ", ") + ")"
+	}
+	if e.Value != "" {
+		return e.Name + " = " + fmt.Sprintf("%q", e.Value)
+	}
+	return e.Name
+}
+
+// features returns the crate features the predicate mentions outside of
+// not(...), in order of appearance.
+func (e cfgExpr) features() []string {
+	var out []string
+	var walk func(cfgExpr)
+	walk = func(e cfgExpr) {
+		switch {
+		case e.Name == "not":
+		case e.combinator():
+			for _, arg := range e.Args {
+				walk(arg)
+			}
+		case e.Name == "feature" && !slices.Contains(out, e.Value):
+			out = append(out, e.Value)
+		}
+	}
+	walk(e)
+	return out
+}
+
+// options returns the other cfg options the predicate mentions outside of
+// not(...), as name or name=value (unix, target_os=linux).
+func (e cfgExpr) options() []string {
+	var out []string
+	var walk func(cfgExpr)
+	walk = func(e cfgExpr) {
+		switch {
+		case e.Name == "not":
+		case e.combinator():
+			for _, arg := range e.Args {
+				walk(arg)
+			}
+		case e.Name != "feature":
+			opt := e.Name
+			if e.Value != "" {
+				opt += "=" + e.Value
+			}
+			if !slices.Contains(out, opt) {
+				out = append(out, opt)
+			}
+		}
+	}
+	walk(e)
+	return out
+}
+
+// enabledWith reports whether the predicate can hold with the given features
+// enabled. Options other than features are assumed to hold, since the
+// documentation was already built for one target.
+func (e cfgExpr) enabledWith(features []string) bool {
+	switch e.Name {
+	case "all":
+		for _, arg := range e.Args {
+			if !arg.enabledWith(features) {
+				return false
+			}
+		}
+		return true
+	case "any":
+		for _, arg := range e.Args {
+			if arg.enabledWith(features) {
+				return true
+			}
+		}
+		return len(e.Args) == 0
+	case "not":
+		if len(e.Args) != 1 || len(e.Args[0].features()) == 0 {
+			return true
+		}
+		return !e.Args[0].enabledWith(features)
+	case "feature":
+		return slices.Contains(features, e.Value)
+	default:
+		return true
+	}
+}
+
+// cfgNames are the display names rustdoc uses for common cfg options.
+var cfgNames = map[string]string{
+	"unix":                    "Unix",
+	"windows":                 "Windows",
+	"test":                    "test",
+	"doc":                     "documentation",
+	"debug_assertions":        "debug-assertions enabled",
+	"target_os=linux":         "Linux",
+	"target_os=macos":         "macOS",
+	"target_os=ios":           "iOS",
+	"target_os=android":       "Android",
+	"target_os=windows":       "Windows",
+	"target_os=freebsd":       "FreeBSD",
+	"target_os=netbsd":        "NetBSD",
+	"target_os=openbsd":       "OpenBSD",
+	"target_os=wasi":          "WASI",
+	"target_os=none":          "bare-metal",
+	"target_family=unix":      "Unix",
+	"target_family=windows":   "Windows",
+	"target_family=wasm":      "WebAssembly",
+	"target_arch=x86":         "x86",
+	"target_arch=x86_64":      "x86-64",
+	"target_arch=aarch64":     "AArch64",
+	"target_arch=arm":         "ARM",
+	"target_arch=wasm32":      "WebAssembly",
+	"target_arch=riscv64":     "RISC-V RV64",
+	"target_env=gnu":          "GNU",
+	"target_env=musl":         "MUSL",
+	"target_env=msvc":         "MSVC",
+	"target_pointer_width=64": "64-bit",
+	"target_pointer_width=32": "32-bit",
+	"target_has_atomic=64":    "64-bit atomics",
+	"target_has_atomic=ptr":   "pointer-sized atomics",
+	"panic=unwind":            "panic=unwind",
+}
+
+// display renders the predicate the way docs.rs does, e.g.
+// "crate feature `fs` and (Unix or WASI)".
+func (e cfgExpr) display() string {
+	return e.displayNested(false)
+}
+
+func (e cfgExpr) displayNested(nested bool) string {
+	switch e.Name {
+	case "all", "any":
+		sep := " and "
+		if e.Name == "any" {
+			sep = " or "
+		}
+		parts := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			parts[i] = arg.displayNested(true)
+		}
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		s := strings.Join(parts, sep)
+		if nested {
+			return "(" + s + ")"
+		}
+		return s
+	case "not":
+		if len(e.Args) == 1 {
+			return "non-" + e.Args[0].displayNested(true)
+		}
+	case "feature":
+		return "crate feature `" + e.Value + "`"
+	}
+	key := e.Name
+	if e.Value != "" {
+		key += "=" + e.Value
+	}
+	if name, ok := cfgNames[key]; ok {
+		return name
+	}
+	return "`" + e.String() + "`"
+}
+
+// cfgAttrs are the forms of cfg attribute rustdoc keeps on items, with the
+// suffix closing each. Recent format versions print #[<cfg>(...)], older ones
+// #[cfg(...)]; an explicit #[doc(cfg(...))] is honored too.
+var cfgAttrs = []struct{ prefix, suffix string }{
+	{"#[<cfg>(", ")]"},
+	{"#[cfg(", ")]"},
+	{"#[doc(cfg(", "))]"},
+}
+
+// itemCfg combines the cfg attributes of an item into one predicate, or
+// returns false if it has none. Attributes that do not parse are ignored.
+func itemCfg(attrs []string) (cfgExpr, bool) {
+	var preds []cfgExpr
+	for _, attr := range attrs {
+		attr = strings.TrimSpace(attr)
+		for _, form := range cfgAttrs {
+			if !strings.HasPrefix(attr, form.prefix) || !strings.HasSuffix(attr, form.suffix) {
+				continue
+			}
+			if pred, err := parseCfg(attr[len(form.prefix) : len(attr)-len(form.suffix)]); err == nil {
+				preds = append(preds, pred)
+			}
+			break
+		}
+	}
+	return combineCfg(preds...)
+}
+
+// combineCfg joins predicates with all(...), flattening nested alls and
+// dropping duplicates. It returns false if there is nothing to combine.
+func combineCfg(preds ...cfgExpr) (cfgExpr, bool) {
+	var args []cfgExpr
+	seen := make(map[string]bool)
+	var add func(cfgExpr)
+	add = func(p cfgExpr) {
+		if p.Name == "all" {
+			for _, arg := range p.Args {
+				add(arg)
+			}
+			return
+		}
+		if key := p.String(); !seen[key] {
+			seen[key] = true
+			args = append(args, p)
+		}
+	}
+	for _, p := range preds {
+		add(p)
+	}
+	switch len(args) {
+	case 0:
+		return cfgExpr{}, false
+	case 1:
+		return args[0], true
+	default:
+		return cfgExpr{Name: "all", Args: args}, true
+	}
+}
+
+// parseCfg parses the inside of cfg(...).
+func parseCfg(s string) (cfgExpr, error) {
+	p := &cfgParser{s: s}
+	e, err := p.expr()
+	if err != nil {
+		return cfgExpr{}, err
+	}
+	p.space()
+	if p.pos != len(p.s) {
+		return cfgExpr{}, fmt.Errorf("cfg %q: unexpected %q", s, p.s[p.pos:])
+	}
+	return e, nil
+}
+
+type cfgParser struct {
+	s   string
+	pos int
+}
+
+func (p *cfgParser) space() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *cfgParser) peek(c byte) bool {
+	p.space()
+	return p.pos < len(p.s) && p.s[p.pos] == c
+}
+
+func (p *cfgParser) expr() (cfgExpr, error) {
+	p.space()
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] == '_' || p.s[p.pos] == ':' ||
+		'a' <= p.s[p.pos] && p.s[p.pos] <= 'z' || 'A' <= p.s[p.pos] && p.s[p.pos] <= 'Z' || '0' <= p.s[p.pos] && p.s[p.pos] <= '9') {
+		p.pos++
+	}
+	if start == p.pos {
+		return cfgExpr{}, fmt.Errorf("cfg %q: expected name at offset %d", p.s, start)
+	}
+	e := cfgExpr{Name: p.s[start:p.pos]}
+
+	switch {
+	case p.peek('='):
+		p.pos++
+		p.space()
+		if p.pos >= len(p.s) || p.s[p.pos] != '"' {
+			return cfgExpr{}, fmt.Errorf("cfg %q: expected string after %s =", p.s, e.Name)
+		}
+		end := strings.IndexByte(p.s[p.pos+1:], '"')
+		if end < 0 {
+			return cfgExpr{}, fmt.Errorf("cfg %q: unterminated string", p.s)
+		}
+		e.Value = p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	case p.peek('('):
+		p.pos++
+		for !p.peek(')') {
+			arg, err := p.expr()
+			if err != nil {
+				return cfgExpr{}, err
+			}
+			e.Args = append(e.Args, arg)
+			if !p.peek(',') {
+				break
+			}
+			p.pos++
+		}
+		if !p.peek(')') {
+			return cfgExpr{}, fmt.Errorf("cfg %q: expected )", p.s)
+		}
+		p.pos++
+	}
+	return e, nil
+}
+
+// availability is what an item is gated on: the cargo features and other cfg
+// options it needs, and the sentence docs.rs shows for them ("crate feature
+// `fs` and Unix"). The zero value means the item is always available.
+type availability struct {
+	Features []string
+	Options  []string
+	Text     string
+	expr     *cfgExpr
+}
+
+func cfgAvailability(e cfgExpr) availability {
+	return availability{Features: e.features(), Options: e.options(), Text: e.display(), expr: &e}
+}
+
+func (a availability) gated() bool {
+	return a.Text != ""
+}
+
+// note is the line rendered under an item's declaration.
+func (a availability) note() string {
+	if !a.gated() {
+		return ""
+	}
+	return "> Available on " + a.Text + " only."
+}
+
+// plain is Text without markdown, for search bodies and agent summaries.
+func (a availability) plain() string {
+	return strings.ReplaceAll(a.Text, "`", "")
+}
+
+// enabledWith reports whether the item is compiled in with the given feature
+// set. Items whose predicate is unknown (HTML pages) need every feature they
+// list unless docs.rs joined them with "or".
+func (a availability) enabledWith(features []string) bool {
+	if a.expr != nil {
+		return a.expr.enabledWith(features)
+	}
+	if len(a.Features) == 0 {
+		return true
+	}
+	enabled := 0
+	for _, f := range a.Features {
+		if slices.Contains(features, f) {
+			enabled++
+		}
+	}
+	if strings.Contains(a.Text, " or ") {
+		return enabled > 0
+	}
+	return enabled == len(a.Features)
+}
+
+// annotate appends the availability to a search body and agent summary, the
+// way Go symbols record their platforms.
+func (a availability) annotate(body, summary string) (string, string) {
+	if !a.gated() {
+		return body, summary
+	}
+	return strings.TrimSpace(body + "\n\nAvailable on: " + a.plain()),
+		strings.TrimSpace(summary + " (" + a.plain() + " only)")
+}
+
+// insertAvailability records an item's features and cfg options as
+// item_metadata, which backs the feature: and cfg: search filters.
+func insertAvailability(ctx context.Context, tx *sql.Tx, docID int64, symbol string, a availability) error {
+	var entries []db.Metadata
+	for _, f := range a.Features {
+		entries = append(entries, db.Metadata{Key: "feature", Value: f})
+	}
+	for _, o := range a.Options {
+		entries = append(entries, db.Metadata{Key: "cfg", Value: o})
+	}
+	if a.gated() {
+		entries = append(entries, db.Metadata{Key: "available", Value: a.plain()})
+	}
+	for _, m := range entries {
+		m.DocID, m.Symbol = docID, symbol
+		if err := db.InsertMetadataTx(ctx, tx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// and combines an item's availability with that of its parent.
+func (a availability) and(b availability) availability {
+	switch {
+	case !b.gated():
+		return a
+	case !a.gated():
+		return b
+	case a.expr != nil && b.expr != nil:
+		if e, ok := combineCfg(*a.expr, *b.expr); ok {
+			return cfgAvailability(e)
+		}
+	}
+	out := availability{Text: a.Text + " and " + b.Text}
+	for _, f := range append(append([]string{}, a.Features...), b.Features...) {
+		if !slices.Contains(out.Features, f) {
+			out.Features = append(out.Features, f)
+		}
+	}
+	for _, o := range append(append([]string{}, a.Options...), b.Options...) {
+		if !slices.Contains(out.Options, o) {
+			out.Options = append(out.Options, o)
+		}
+	}
+	return out
+}
+
+// buildConfig describes the build a crate's documentation is ingested for.
+type buildConfig struct {
+	// Target is the triple the docs were built for, recorded on the crate
+	// when known.
+	Target string
+	// Features, when non-nil, is the enabled feature set: items gated on
+	// features outside it are skipped.
+	Features []string
+}
+
+func (c buildConfig) enabled(a availability) bool {
+	return c.Features == nil || a.enabledWith(c.Features)
+}
+
+// ParseFeatures splits a cargo feature list, which may be separated by commas
+// or spaces. The result is never nil, so an empty list still filters out
+// feature-gated items.
+func ParseFeatures(value string) []string {
+	features := []string{}
+	for _, f := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !slices.Contains(features, f) {
+			features = append(features, f)
+		}
+	}
+	return features
+}
@@ -0,0 +1,115 @@
+package rust
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestItemCfg(t *testing.T) {
+	tests := []struct {
+		attrs    []string
+		display  string
+		features []string
+		options  []string
+	}{
+		{[]string{`#[<cfg>(feature = "fs")]`}, "crate feature `fs`", []string{"fs"}, nil},
+		{[]string{`#[cfg(unix)]`, `#[inline]`}, "Unix", nil, []string{"unix"}},
+		{
+			[]string{`#[<cfg>(all(feature = "a", any(target_os = "linux", windows)))]`},
+			"crate feature `a` and (Linux or Windows)",
+			[]string{"a"},
+			[]string{"target_os=linux", "windows"},
+		},
+		{
+			[]string{`#[<cfg>(feature = "derive")]`, `#[doc(cfg(feature = "derive"))]`},
+			"crate feature `derive`",
+			[]string{"derive"},
+			nil,
+		},
+		{[]string{`#[cfg(not(feature = "std"))]`}, "non-crate feature `std`", nil, nil},
+	}
+	for _, tt := range tests {
+		e, ok := itemCfg(tt.attrs)
+		if !ok {
+			t.Errorf("itemCfg(%q) found no cfg", tt.attrs)
+			continue
+		}
+		a := cfgAvailability(e)
+		if a.Text != tt.display || !slices.Equal(a.Features, tt.features) || !slices.Equal(a.Options, tt.options) {
+			t.Errorf("itemCfg(%q) = %q %v %v, want %q %v %v", tt.attrs, a.Text, a.Features, a.Options, tt.display, tt.features, tt.options)
+		}
+	}
+
+	if _, ok := itemCfg([]string{"#[must_use]", `#[doc(hidden)]`}); ok {
+		t.Error("itemCfg() found a cfg in unrelated attributes")
+	}
+}
+
+func TestAvailabilityEnabledWith(t *testing.T) {
+	parse := func(s string) availability {
+		e, err := parseCfg(s)
+		if err != nil {
+			t.Fatalf("parseCfg(%q): %v", s, err)
+		}
+		return cfgAvailability(e)
+	}
+
+	tests := []struct {
+		cfg      string
+		features []string
+		want     bool
+	}{
+		{`feature = "fs"`, []string{"fs"}, true},
+		{`feature = "fs"`, nil, false},
+		{`all(feature = "x", feature = "y")`, []string{"x"}, false},
+		{`any(feature = "serde", feature = "serde1")`, []string{"serde1"}, true},
+		{`all(feature = "a", windows)`, []string{"a"}, true},
+		{`not(feature = "std")`, []string{"std"}, false},
+		{`unix`, nil, true},
+	}
+	for _, tt := range tests {
+		if got := parse(tt.cfg).enabledWith(tt.features); got != tt.want {
+			t.Errorf("%s enabledWith(%v) = %v, want %v", tt.cfg, tt.features, got, tt.want)
+		}
+	}
+
+	both := parse(`feature = "fs"`).and(parse(`unix`))
+	if both.Text != "crate feature `fs` and Unix" {
+		t.Errorf("and() = %q", both.Text)
+	}
+}
+
+func TestHTMLAvailability(t *testing.T) {
+	html := `<main><section id="main-content">
+<pre class="rust item-decl"><code>pub struct Both;</code></pre>
+<span class="item-info"><div class="stab portability">Available on <strong>crate feature <code>a</code> and (Linux or Windows)</strong> only.</div></span>
+<div id="implementations-list"><details><summary>
+<section id="method.ser" class="method"><h4 class="code-header">pub fn ser(&amp;self)</h4></section>
+<span class="item-info"><div class="stab portability">Available on <strong>crate features <code>serde</code> or <code>serde1</code></strong> only.</div></span>
+</summary><div class="docblock"><p>Serde only.</p></div></details></div>
+</section></main>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := htmlAvailability(doc.Find("#main-content > .item-info .portability").First())
+	if a.Text != "crate feature `a` and (Linux or Windows)" || !slices.Equal(a.Features, []string{"a"}) {
+		t.Errorf("item availability = %q %v", a.Text, a.Features)
+	}
+	if !a.enabledWith([]string{"a"}) || a.enabledWith(nil) {
+		t.Errorf("item enabledWith() is wrong for %q", a.Text)
+	}
+
+	members := htmlMembers(doc)
+	if len(members) != 1 {
+		t.Fatalf("htmlMembers() = %+v", members)
+	}
+	m := members[0].Available
+	if !slices.Equal(m.Features, []string{"serde", "serde1"}) || !m.enabledWith([]string{"serde1"}) {
+		t.Errorf("member availability = %q %v", m.Text, m.Features)
+	}
+}
@@ -0,0 +1,382 @@
+package rust
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/codec"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+// errNoRustdocJSON is returned when docs.rs has no JSON build for a release.
+// Releases built before docs.rs started producing JSON only have HTML.
+var errNoRustdocJSON = errors.New("rustdoc json not available")
+
+// itemKinds maps rustdoc JSON item kinds to the document types used in paths
+// and search entries. They match the names used by the HTML pipeline.
+var itemKinds = map[string]string{
+	"struct":     "Struct",
+	"enum":       "Enum",
+	"trait":      "Trait",
+	"function":   "Function",
+	"type_alias": "Type",
+	"constant":   "Constant",
+	"static":     "Static",
+	"union":      "Union",
+	"macro":      "Macro",
+	"proc_macro": "Macro",
+}
+
+// moduleSections orders the item listing on crate and module pages.
+var moduleSections = []struct{ kind, title string }{
+	{"Module", "Modules"},
+	{"Struct", "Structs"},
+	{"Enum", "Enums"},
+	{"Trait", "Traits"},
+	{"Function", "Functions"},
+	{"Type", "Type Aliases"},
+	{"Constant", "Constants"},
+	{"Static", "Statics"},
+	{"Union", "Unions"},
+	{"Macro", "Macros"},
+}
+
+// fetchRustdocJSON downloads a release's rustdoc JSON, for the given target
+// triple or docs.rs' default target when it is empty.
+func fetchRustdocJSON(ctx context.Context, crate, version, target string, c *cache.FilesystemCache) ([]byte, error) {
+	cacheKey := cache.RustdocJSONKey(crate, version, target)
+	if c != nil {
+		if cached, _, err := c.Get(cacheKey); err == nil {
+			if data, err := os.ReadFile(cached); err == nil {
+				return decompressRustdocJSON(data)
+			}
+		}
+	}
+
+	url := fmt.Sprintf("https://docs.rs/crate/%s/%s/json", crate, version)
+	if target != "" {
+		url = fmt.Sprintf("https://docs.rs/crate/%s/%s/%s/json", crate, version, target)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "documango (https://github.com/stormlightlabs/documango)")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNoRustdocJSON
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("docs.rs json download error: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c != nil {
+		if _, err := c.Put(cacheKey, url, bytes.NewReader(data), 0); err != nil {
+			log.Warn("failed to cache rustdoc json", "crate", crate, "err", err)
+		}
+	}
+
+	return decompressRustdocJSON(data)
+}
+
+// decompressRustdocJSON handles the zstd (default) and gzip encodings docs.rs
+// serves, as well as plain JSON from a local build.
+func decompressRustdocJSON(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return codec.Decompress(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	default:
+		return data, nil
+	}
+}
+
+type rustdocEntry struct {
+	id      rustdocID
+	name    string
+	modPath []string
+	avail   availability
+}
+
+// jsonIngester writes one crate's rustdoc JSON into the database. Modules are
+// walked breadth first from the crate root so that an item re-exported in
+// several places is indexed once, under its shortest public path.
+type jsonIngester struct {
+	r         *renderer
+	tx        *sql.Tx
+	crate     string
+	version   string
+	rootName  string
+	build     buildConfig
+	seen      map[rustdocID]bool
+	processed int
+}
+
+func ingestRustdocJSON(ctx context.Context, tx *sql.Tx, krate *rustdocCrate, crate, version string, build buildConfig) error {
+	root := krate.Index[krate.Root]
+	if krate.Target != nil && krate.Target.Triple != "" {
+		build.Target = krate.Target.Triple
+	}
+	in := &jsonIngester{
+		r:        &renderer{krate: krate},
+		tx:       tx,
+		crate:    crate,
+		version:  version,
+		rootName: root.Name,
+		build:    build,
+		seen:     map[rustdocID]bool{krate.Root: true},
+	}
+
+	queue := []rustdocEntry{{id: krate.Root, name: root.Name}}
+	for len(queue) > 0 {
+		entry := queue[0]
+		queue = queue[1:]
+		subs, err := in.module(ctx, entry)
+		if err != nil {
+			return err
+		}
+		queue = append(queue, subs...)
+	}
+
+	log.Info("ingestion complete", "crate", crate, "processed", in.processed)
+	return nil
+}
+
+// module indexes a module page and the items it contains, returning the
+// submodules still to visit.
+func (in *jsonIngester) module(ctx context.Context, entry rustdocEntry) ([]rustdocEntry, error) {
+	it := in.r.krate.Index[entry.id]
+	var mod rustModule
+	if err := it.decode(&mod); err != nil {
+		return nil, fmt.Errorf("module %s: %w", strings.Join(entry.modPath, "::"), err)
+	}
+
+	members := in.members(mod.Items, entry.modPath, entry.avail, map[rustdocID]bool{entry.id: true})
+
+	var subs []rustdocEntry
+	listing := make(map[string][]string)
+	for _, m := range members {
+		member := in.r.krate.Index[m.id]
+		if e, ok := itemCfg(member.attrStrings()); ok {
+			m.avail = m.avail.and(cfgAvailability(e))
+		}
+		if !in.build.enabled(m.avail) {
+			continue
+		}
+		summary := docSummary(member.Docs)
+		line := "- `" + m.name + "`"
+		if summary != "" {
+			line += " - " + summary
+		}
+
+		if member.kind() == "module" {
+			if in.seen[m.id] {
+				continue
+			}
+			in.seen[m.id] = true
+			var sub rustModule
+			if member.decode(&sub) == nil && sub.IsStripped {
+				continue
+			}
+			subs = append(subs, rustdocEntry{id: m.id, name: m.name, modPath: append(append([]string{}, entry.modPath...), m.name), avail: m.avail})
+			listing["Module"] = append(listing["Module"], line)
+			continue
+		}
+
+		kind, ok := itemKinds[member.kind()]
+		if !ok {
+			continue
+		}
+		listing[kind] = append(listing[kind], line)
+		if in.seen[m.id] {
+			continue
+		}
+		in.seen[m.id] = true
+		if err := in.item(ctx, member, kind, m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := in.modulePage(ctx, it, entry, listing); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// members resolves a module's item list: `pub use` re-exports of local items
+// are replaced by their targets under the exported name and glob imports of
+// local modules are expanded. Re-exports of other crates' items are skipped
+// since their documentation is not part of this crate.
+func (in *jsonIngester) members(ids []rustdocID, modPath []string, avail availability, expanded map[rustdocID]bool) []rustdocEntry {
+	var out []rustdocEntry
+	for _, id := range ids {
+		it, ok := in.r.krate.Index[id]
+		if !ok || !it.isPublic() {
+			continue
+		}
+		if it.kind() != "use" {
+			out = append(out, rustdocEntry{id: id, name: it.Name, modPath: modPath, avail: avail})
+			continue
+		}
+
+		useAvail := avail
+		if e, ok := itemCfg(it.attrStrings()); ok {
+			useAvail = avail.and(cfgAvailability(e))
+		}
+
+		var use rustUse
+		if err := it.decode(&use); err != nil || use.ID == nil {
+			continue
+		}
+		target, ok := in.r.krate.Index[*use.ID]
+		if !ok {
+			continue
+		}
+		if use.glob() {
+			var mod rustModule
+			if target.kind() != "module" || expanded[*use.ID] || target.decode(&mod) != nil {
+				continue
+			}
+			expanded[*use.ID] = true
+			out = append(out, in.members(mod.Items, modPath, useAvail, expanded)...)
+			continue
+		}
+		out = append(out, rustdocEntry{id: *use.ID, name: use.Name, modPath: modPath, avail: useAvail})
+	}
+	return out
+}
+
+func (in *jsonIngester) fullName(modPath []string, name string) string {
+	parts := append([]string{in.rootName}, modPath...)
+	if name != "" {
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, "::")
+}
+
+func (in *jsonIngester) modulePage(ctx context.Context, it rustdocItem, entry rustdocEntry, listing map[string][]string) error {
+	kind, docPath := "Crate", "rust/"+in.crate+"/index"
+	if len(entry.modPath) > 0 {
+		kind, docPath = "Module", "rust/"+in.crate+"/Module/"+strings.Join(entry.modPath, "/")
+	}
+	fullName := in.fullName(entry.modPath, "")
+
+	var b strings.Builder
+	b.WriteString(in.r.markdown(it, kind, fullName, entry.avail))
+	for _, section := range moduleSections {
+		if lines := listing[section.kind]; len(lines) > 0 {
+			b.WriteString("\n\n## " + section.title + "\n\n" + strings.Join(lines, "\n"))
+		}
+	}
+
+	signature := "mod " + fullName
+	if kind == "Crate" {
+		signature = "crate " + fullName
+	}
+	docID, err := in.insert(ctx, docPath, kind, fullName, signature, it.Docs, b.String(), entry.avail)
+	if err != nil || kind != "Crate" || in.build.Target == "" {
+		return err
+	}
+	return db.InsertMetadataTx(ctx, in.tx, db.Metadata{DocID: docID, Symbol: fullName, Key: "target", Value: in.build.Target})
+}
+
+func (in *jsonIngester) item(ctx context.Context, it rustdocItem, kind string, entry rustdocEntry) error {
+	// Re-exports are documented under the exported name.
+	it.Name = entry.name
+	fullName := in.fullName(entry.modPath, entry.name)
+
+	prefix := "rust/" + in.crate + "/" + kind + "/"
+	if len(entry.modPath) > 0 {
+		prefix += strings.Join(entry.modPath, "/") + "/"
+	}
+
+	markdown := in.r.markdown(it, kind, fullName, entry.avail)
+	docID, err := in.insert(ctx, prefix+entry.name, kind, fullName, in.r.signature(it), it.Docs, markdown, entry.avail)
+	if err != nil {
+		return err
+	}
+
+	var members []memberEntry
+	for _, m := range in.r.members(it) {
+		if in.build.enabled(entry.avail.and(m.Available)) {
+			members = append(members, m)
+		}
+	}
+	return insertMembers(ctx, in.tx, docID, fullName, entry.avail, members)
+}
+
+func (in *jsonIngester) insert(ctx context.Context, docPath, kind, fullName, signature, docs, markdown string, avail availability) (int64, error) {
+	docID, err := insertDoc(ctx, in.tx, in.crate, in.version, docPath, markdown)
+	if err != nil {
+		log.Error("failed to insert doc", "path", docPath, "err", err)
+		return 0, err
+	}
+	in.processed++
+
+	body, summary := avail.annotate(strings.TrimSpace(fullName+" "+signature+" "+docSummary(docs)), docSummary(docs))
+	if err := db.InsertSearchEntryTx(ctx, in.tx, db.SearchEntry{
+		Name:  fullName,
+		Type:  kind,
+		Body:  body,
+		DocID: docID,
+	}); err != nil {
+		return 0, err
+	}
+
+	if err := db.InsertAgentContextTx(ctx, in.tx, db.AgentContext{
+		DocID:     docID,
+		Symbol:    fullName,
+		Signature: signature,
+		Summary:   summary,
+	}); err != nil {
+		return 0, err
+	}
+	return docID, insertAvailability(ctx, in.tx, docID, fullName, avail)
+}
+
+// signature is the declaration used for search and agent context. Type
+// bodies (fields, variants, trait items) are left out; they live on the page.
+func (r *renderer) signature(it rustdocItem) string {
+	decl := r.declaration(it)
+	switch it.kind() {
+	case "struct", "union", "enum", "trait":
+		if i := strings.Index(decl, "{"); i >= 0 {
+			decl = decl[:i]
+		}
+	}
+	return oneLine(strings.TrimSuffix(decl, ";"))
+}
+
+// docSummary returns the first line of an item's docs.
+func docSummary(docs string) string {
+	return strings.TrimSpace(shared.FirstLine(strings.TrimSpace(docs)))
+}
@@ -0,0 +1,287 @@
+package rust
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/log"
+)
+
+// localCrate is a workspace member whose documentation was built with
+// `cargo doc`. Lib is the library target name rustdoc files its output
+// under, which defaults to the package name with dashes replaced.
+type localCrate struct {
+	Name string
+	Lib  string
+}
+
+// errNotDocumented is returned for a crate with no rustdoc output, such as a
+// binary-only workspace member built with `cargo doc --lib`.
+var errNotDocumented = errors.New("no rustdoc output")
+
+type cargoManifest struct {
+	Package *struct {
+		Name string `toml:"name"`
+	} `toml:"package"`
+	Lib *struct {
+		Name string `toml:"name"`
+	} `toml:"lib"`
+	Workspace *struct {
+		Members []string `toml:"members"`
+		Exclude []string `toml:"exclude"`
+	} `toml:"workspace"`
+}
+
+// ingestLocal ingests the `cargo doc` output of the crate or workspace rooted
+// at opts.Dir. Only workspace members are ingested, not the dependencies
+// cargo documents alongside them. opts.Dir may also point at a doc directory
+// directly, in which case every crate in it is ingested.
+func ingestLocal(ctx context.Context, opts Options) error {
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	var crates []localCrate
+	if _, err := os.Stat(filepath.Join(dir, "Cargo.toml")); err == nil {
+		if crates, err = resolveLocalCrates(dir); err != nil {
+			return err
+		}
+	}
+
+	docDir, err := findDocDir(dir, opts.Target)
+	if err != nil {
+		return err
+	}
+	if crates == nil {
+		if crates, err = documentedCrates(docDir); err != nil {
+			return err
+		}
+	}
+	if opts.Crate != "" {
+		crates = filterLocalCrates(crates, opts.Crate)
+		if len(crates) == 0 {
+			return fmt.Errorf("crate %s not found in %s", opts.Crate, dir)
+		}
+	}
+	if len(crates) == 0 {
+		return fmt.Errorf("no documented crates found in %s", docDir)
+	}
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		ingested := 0
+		for _, crate := range crates {
+			err := ingestLocalCrate(ctx, tx, crate, docDir, opts.Format, opts.build())
+			if errors.Is(err, errNotDocumented) && len(crates) > 1 {
+				log.Warn("skipping undocumented crate", "crate", crate.Name, "err", err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			ingested++
+		}
+		if ingested == 0 {
+			return fmt.Errorf("no documented crates found in %s", docDir)
+		}
+		return nil
+	})
+}
+
+// ingestLocalCrate ingests one crate from docDir, preferring its JSON output
+// (`<crate>.json`) over the HTML tree unless format says otherwise.
+func ingestLocalCrate(ctx context.Context, tx *sql.Tx, local localCrate, docDir, format string, build buildConfig) error {
+	crate := local.Name
+	lib := local.Lib
+	if lib == "" {
+		lib = strings.ReplaceAll(crate, "-", "_")
+	}
+	jsonPath := filepath.Join(docDir, lib+".json")
+	htmlDir := filepath.Join(docDir, lib)
+
+	if format != FormatHTML {
+		if data, err := os.ReadFile(jsonPath); err == nil {
+			krate, err := parseRustdocJSON(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", jsonPath, err)
+			}
+			log.Info("rust crate ingest starting", "crate", crate, "version", "local", "format", FormatJSON, "format_version", krate.FormatVersion)
+			return ingestRustdocJSON(ctx, tx, krate, crate, "local", build)
+		} else if format == FormatJSON {
+			return fmt.Errorf("%w: no %s.json in %s (build it with cargo +nightly rustdoc -- -Z unstable-options --output-format json)", errNotDocumented, lib, docDir)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(htmlDir, "index.html")); err != nil {
+		return fmt.Errorf("%w: no %s docs in %s (run cargo doc first)", errNotDocumented, lib, docDir)
+	}
+	log.Info("rust crate ingest starting", "crate", crate, "version", "local", "format", FormatHTML)
+	return ingestCrateDir(ctx, tx, crate, "local", htmlDir, "", build)
+}
+
+// resolveLocalCrates returns the crates of the package or workspace rooted at
+// dir. A workspace root that is also a package is included with its members.
+func resolveLocalCrates(dir string) ([]localCrate, error) {
+	manifest, err := readCargoManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var crates []localCrate
+	if crate, ok := manifest.crate(); ok {
+		crates = append(crates, crate)
+	}
+	if manifest.Workspace != nil {
+		var excluded []string
+		for _, pattern := range manifest.Workspace.Exclude {
+			excluded = append(excluded, filepath.Join(dir, filepath.FromSlash(pattern)))
+		}
+		for _, pattern := range manifest.Workspace.Members {
+			roots, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+			if err != nil {
+				return nil, fmt.Errorf("workspace member %s: %w", pattern, err)
+			}
+			for _, root := range roots {
+				if slices.Contains(excluded, root) || root == dir || !isDir(root) {
+					continue
+				}
+				member, err := readCargoManifest(root)
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					return nil, fmt.Errorf("workspace member %s: %w", root, err)
+				}
+				if crate, ok := member.crate(); ok {
+					crates = append(crates, crate)
+				}
+			}
+		}
+	}
+	if len(crates) == 0 {
+		return nil, fmt.Errorf("Cargo.toml in %s has no package or workspace members", dir)
+	}
+	return crates, nil
+}
+
+func (m cargoManifest) crate() (localCrate, bool) {
+	if m.Package == nil || m.Package.Name == "" {
+		return localCrate{}, false
+	}
+	crate := localCrate{Name: m.Package.Name}
+	if m.Lib != nil {
+		crate.Lib = m.Lib.Name
+	}
+	return crate, true
+}
+
+func readCargoManifest(dir string) (cargoManifest, error) {
+	var manifest cargoManifest
+	data, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return manifest, err
+	}
+	if err := toml.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("%s: %w", filepath.Join(dir, "Cargo.toml"), err)
+	}
+	return manifest, nil
+}
+
+// findDocDir locates the rustdoc output for dir: $CARGO_TARGET_DIR/doc,
+// target/doc, target/<triple>/doc for cross builds (the requested target, or
+// one chosen the same way as docs.rs archive targets), or dir itself when it
+// already is a doc directory.
+func findDocDir(dir, target string) (string, error) {
+	targetDir := filepath.Join(dir, "target")
+	if env := os.Getenv("CARGO_TARGET_DIR"); env != "" {
+		if !filepath.IsAbs(env) {
+			env = filepath.Join(dir, env)
+		}
+		targetDir = env
+	}
+
+	if target != "" {
+		if docDir := filepath.Join(targetDir, target, "doc"); isDir(docDir) {
+			return docDir, nil
+		}
+		return "", fmt.Errorf("no rustdoc output for %s in %s (run cargo doc --target %s first)", target, targetDir, target)
+	}
+	if isDir(filepath.Join(targetDir, "doc")) {
+		return filepath.Join(targetDir, "doc"), nil
+	}
+	if target, err := preferredTarget(crossDocTargets(targetDir)); err == nil {
+		return filepath.Join(targetDir, target, "doc"), nil
+	}
+	if crates, _ := documentedCrates(dir); len(crates) > 0 {
+		return dir, nil
+	}
+	return "", fmt.Errorf("no rustdoc output found in %s (run cargo doc first)", dir)
+}
+
+// crossDocTargets returns the target triples under targetDir that have docs,
+// leaving out debug/ and release/.
+func crossDocTargets(targetDir string) []string {
+	entries, err := os.ReadDir(targetDir)
+	if err != nil {
+		return nil
+	}
+	var triples []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != "doc" && isDir(filepath.Join(targetDir, entry.Name(), "doc")) {
+			triples = append(triples, entry.Name())
+		}
+	}
+	return triples
+}
+
+// documentedCrates lists the crates in a rustdoc output directory, from
+// either <crate>.json files or <crate>/index.html trees.
+func documentedCrates(docDir string) ([]localCrate, error) {
+	entries, err := os.ReadDir(docDir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var crates []localCrate
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+			if _, err := os.Stat(filepath.Join(docDir, name, "index.html")); err != nil {
+				continue
+			}
+		case !entry.IsDir() && strings.HasSuffix(name, ".json"):
+			name = strings.TrimSuffix(name, ".json")
+		default:
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			crates = append(crates, localCrate{Name: name})
+		}
+	}
+	return crates, nil
+}
+
+func filterLocalCrates(crates []localCrate, name string) []localCrate {
+	want := strings.ReplaceAll(name, "-", "_")
+	var filtered []localCrate
+	for _, crate := range crates {
+		if strings.ReplaceAll(crate.Name, "-", "_") == want || crate.Lib == want {
+			filtered = append(filtered, crate)
+		}
+	}
+	return filtered
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
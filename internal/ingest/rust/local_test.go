@@ -0,0 +1,108 @@
+package rust
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveLocalCrates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Cargo.toml"), `[package]
+name = "root-app"
+
+[workspace]
+members = ["crates/*"]
+exclude = ["crates/scratch"]
+`)
+	writeFile(t, filepath.Join(dir, "crates", "core", "Cargo.toml"), "[package]\nname = \"ws-core\"\n\n[lib]\nname = \"wscore\"\n")
+	writeFile(t, filepath.Join(dir, "crates", "cli", "Cargo.toml"), "[package]\nname = \"ws-cli\"\n")
+	writeFile(t, filepath.Join(dir, "crates", "scratch", "Cargo.toml"), "[package]\nname = \"scratch\"\n")
+	writeFile(t, filepath.Join(dir, "crates", "README.md"), "not a crate\n")
+
+	crates, err := resolveLocalCrates(dir)
+	if err != nil {
+		t.Fatalf("resolveLocalCrates: %v", err)
+	}
+	want := []localCrate{{Name: "root-app"}, {Name: "ws-cli"}, {Name: "ws-core", Lib: "wscore"}}
+	if !slices.Equal(crates, want) {
+		t.Errorf("crates = %+v, want %+v", crates, want)
+	}
+
+	if got := filterLocalCrates(crates, "wscore"); !slices.Equal(got, want[2:]) {
+		t.Errorf("filter by lib name = %+v", got)
+	}
+	if got := filterLocalCrates(crates, "ws_cli"); !slices.Equal(got, want[1:2]) {
+		t.Errorf("filter by crate name = %+v", got)
+	}
+}
+
+func TestFindDocDir(t *testing.T) {
+	t.Setenv("CARGO_TARGET_DIR", "")
+
+	t.Run("target/doc", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "target", "doc", "demo", "index.html"), "")
+		writeFile(t, filepath.Join(dir, "target", "x86_64-unknown-linux-gnu", "doc", "demo", "index.html"), "")
+		got, err := findDocDir(dir, "")
+		if err != nil || got != filepath.Join(dir, "target", "doc") {
+			t.Errorf("findDocDir() = %q, %v", got, err)
+		}
+	})
+
+	t.Run("cross target", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "target", "debug", "demo"), "")
+		writeFile(t, filepath.Join(dir, "target", "wasm32-unknown-unknown", "doc", "demo", "index.html"), "")
+		writeFile(t, filepath.Join(dir, "target", "aarch64-unknown-linux-gnu", "doc", "demo", "index.html"), "")
+		got, err := findDocDir(dir, "")
+		if err != nil || got != filepath.Join(dir, "target", "aarch64-unknown-linux-gnu", "doc") {
+			t.Errorf("findDocDir() = %q, %v", got, err)
+		}
+	})
+
+	t.Run("requested target", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "target", "doc", "demo", "index.html"), "")
+		writeFile(t, filepath.Join(dir, "target", "x86_64-pc-windows-msvc", "doc", "demo", "index.html"), "")
+		got, err := findDocDir(dir, "x86_64-pc-windows-msvc")
+		if err != nil || got != filepath.Join(dir, "target", "x86_64-pc-windows-msvc", "doc") {
+			t.Errorf("findDocDir() = %q, %v", got, err)
+		}
+		if _, err := findDocDir(dir, "wasm32-unknown-unknown"); err == nil {
+			t.Error("expected error for a target without docs")
+		}
+	})
+
+	t.Run("doc dir itself", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "demo.json"), "{}")
+		writeFile(t, filepath.Join(dir, "other", "index.html"), "")
+		writeFile(t, filepath.Join(dir, "static.files", "main.js"), "")
+		got, err := findDocDir(dir, "")
+		if err != nil || got != dir {
+			t.Fatalf("findDocDir() = %q, %v", got, err)
+		}
+		crates, _ := documentedCrates(dir)
+		if want := []localCrate{{Name: "demo"}, {Name: "other"}}; !slices.Equal(crates, want) {
+			t.Errorf("documentedCrates() = %+v, want %+v", crates, want)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := findDocDir(t.TempDir(), ""); err == nil {
+			t.Error("expected error without rustdoc output")
+		}
+	})
+}
@@ -0,0 +1,978 @@
+package rust
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// renderer turns rustdoc JSON items into Rust declarations and Markdown.
+type renderer struct {
+	krate *rustdocCrate
+}
+
+func (r *renderer) item(id rustdocID) (rustdocItem, bool) {
+	it, ok := r.krate.Index[id]
+	return it, ok
+}
+
+// typ renders a type as it would appear in source.
+func (r *renderer) typ(t rustType) string {
+	switch {
+	case t.Infer:
+		return "_"
+	case t.ResolvedPath != nil:
+		return r.path(*t.ResolvedPath)
+	case t.Generic != nil:
+		return *t.Generic
+	case t.Primitive != nil:
+		return *t.Primitive
+	case t.DynTrait != nil:
+		return "dyn " + r.dynTrait(*t.DynTrait)
+	case t.Tuple != nil:
+		parts := make([]string, len(*t.Tuple))
+		for i, elem := range *t.Tuple {
+			parts[i] = r.typ(elem)
+		}
+		if len(parts) == 1 {
+			return "(" + parts[0] + ",)"
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	case t.Slice != nil:
+		return "[" + r.typ(*t.Slice) + "]"
+	case t.Array != nil:
+		return "[" + r.typ(t.Array.Type) + "; " + t.Array.Len + "]"
+	case t.Pat != nil:
+		return r.typ(t.Pat.Type)
+	case t.ImplTrait != nil:
+		return "impl " + r.bounds(*t.ImplTrait)
+	case t.RawPointer != nil:
+		if t.RawPointer.IsMutable || t.RawPointer.Mutable {
+			return "*mut " + r.typ(t.RawPointer.Type)
+		}
+		return "*const " + r.typ(t.RawPointer.Type)
+	case t.BorrowedRef != nil:
+		var b strings.Builder
+		b.WriteString("&")
+		if t.BorrowedRef.Lifetime != nil {
+			b.WriteString(*t.BorrowedRef.Lifetime + " ")
+		}
+		if t.BorrowedRef.IsMutable || t.BorrowedRef.Mutable {
+			b.WriteString("mut ")
+		}
+		b.WriteString(r.typ(t.BorrowedRef.Type))
+		return b.String()
+	case t.QualifiedPath != nil:
+		q := t.QualifiedPath
+		self := r.typ(q.SelfType)
+		name := q.Name + r.genericArgs(q.Args)
+		if q.Trait == nil {
+			return "<" + self + ">::" + name
+		}
+		trait := r.path(*q.Trait)
+		if trait == "" || (q.SelfType.Generic != nil && *q.SelfType.Generic == "Self") && trait == "" {
+			return self + "::" + name
+		}
+		return "<" + self + " as " + trait + ">::" + name
+	case t.FunctionPointer != nil:
+		fp := t.FunctionPointer
+		sig := fp.Sig
+		if sig == nil {
+			sig = fp.Decl
+		}
+		var b strings.Builder
+		if len(fp.GenericParams) > 0 {
+			b.WriteString("for" + r.genericParams(fp.GenericParams) + " ")
+		}
+		b.WriteString(r.header(fp.Header))
+		b.WriteString("fn(")
+		if sig != nil {
+			var params []string
+			for _, in := range sig.Inputs {
+				if in.Name == "" || in.Name == "_" {
+					params = append(params, r.typ(in.Type))
+				} else {
+					params = append(params, in.Name+": "+r.typ(in.Type))
+				}
+			}
+			if sig.IsCVariadic || sig.CVariadic {
+				params = append(params, "...")
+			}
+			b.WriteString(strings.Join(params, ", "))
+			b.WriteString(")")
+			b.WriteString(r.output(sig.Output))
+		} else {
+			b.WriteString(")")
+		}
+		return b.String()
+	}
+	return "_"
+}
+
+func (r *renderer) path(p rustPath) string {
+	name := p.Path
+	if name == "" {
+		name = p.Name
+	}
+	name = strings.TrimPrefix(name, "$crate::")
+	return name + r.genericArgs(p.Args)
+}
+
+func (r *renderer) dynTrait(d rustDynTrait) string {
+	var parts []string
+	for _, tr := range d.Traits {
+		prefix := ""
+		if len(tr.GenericParams) > 0 {
+			prefix = "for" + r.genericParams(tr.GenericParams) + " "
+		}
+		parts = append(parts, prefix+r.path(tr.Trait))
+	}
+	if d.Lifetime != nil {
+		parts = append(parts, *d.Lifetime)
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (r *renderer) genericArgs(a *rustGenericArgs) string {
+	if a == nil {
+		return ""
+	}
+	if p := a.Parenthesized; p != nil {
+		inputs := make([]string, len(p.Inputs))
+		for i, in := range p.Inputs {
+			inputs[i] = r.typ(in)
+		}
+		return "(" + strings.Join(inputs, ", ") + ")" + r.output(p.Output)
+	}
+	ab := a.AngleBracketed
+	if ab == nil {
+		return ""
+	}
+	var parts []string
+	for _, arg := range ab.Args {
+		switch {
+		case arg.Infer:
+			parts = append(parts, "_")
+		case arg.Lifetime != nil:
+			parts = append(parts, *arg.Lifetime)
+		case arg.Type != nil:
+			parts = append(parts, r.typ(*arg.Type))
+		case arg.Const != nil:
+			parts = append(parts, arg.Const.Expr)
+		}
+	}
+	constraints := ab.Constraints
+	if len(constraints) == 0 {
+		constraints = ab.Bindings
+	}
+	for _, c := range constraints {
+		name := c.Name + r.genericArgs(c.Args)
+		switch {
+		case c.Binding.Equality != nil:
+			parts = append(parts, name+" = "+r.term(*c.Binding.Equality))
+		case len(c.Binding.Constraint) > 0:
+			parts = append(parts, name+": "+r.bounds(c.Binding.Constraint))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "<" + strings.Join(parts, ", ") + ">"
+}
+
+func (r *renderer) term(t rustTerm) string {
+	if t.Type != nil {
+		return r.typ(*t.Type)
+	}
+	if t.Constant != nil {
+		return t.Constant.Expr
+	}
+	return "_"
+}
+
+func (r *renderer) output(t *rustType) string {
+	if t == nil {
+		return ""
+	}
+	if t.Tuple != nil && len(*t.Tuple) == 0 {
+		return ""
+	}
+	return " -> " + r.typ(*t)
+}
+
+func (r *renderer) bounds(bounds []rustBound) string {
+	var parts []string
+	for _, b := range bounds {
+		switch {
+		case b.TraitBound != nil:
+			s := r.path(b.TraitBound.Trait)
+			switch b.TraitBound.Modifier {
+			case "maybe":
+				s = "?" + s
+			case "maybe_const":
+				s = "~const " + s
+			}
+			if len(b.TraitBound.GenericParams) > 0 {
+				s = "for" + r.genericParams(b.TraitBound.GenericParams) + " " + s
+			}
+			parts = append(parts, s)
+		case b.Outlives != nil:
+			parts = append(parts, *b.Outlives)
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+// genericParams renders "<'a, T: Clone, const N: usize>", leaving out the
+// synthetic parameters rustdoc creates for `impl Trait` arguments.
+func (r *renderer) genericParams(params []rustGenericParam) string {
+	var parts []string
+	for _, p := range params {
+		switch {
+		case p.Kind.Lifetime != nil:
+			s := p.Name
+			if len(p.Kind.Lifetime.Outlives) > 0 {
+				s += ": " + strings.Join(p.Kind.Lifetime.Outlives, " + ")
+			}
+			parts = append(parts, s)
+		case p.Kind.Type != nil:
+			if p.Kind.Type.IsSynthetic || p.Kind.Type.Synthetic {
+				continue
+			}
+			s := p.Name
+			if len(p.Kind.Type.Bounds) > 0 {
+				s += ": " + r.bounds(p.Kind.Type.Bounds)
+			}
+			if p.Kind.Type.Default != nil {
+				s += " = " + r.typ(*p.Kind.Type.Default)
+			}
+			parts = append(parts, s)
+		case p.Kind.Const != nil:
+			s := "const " + p.Name + ": " + r.typ(p.Kind.Const.Type)
+			if p.Kind.Const.Default != nil {
+				s += " = " + *p.Kind.Const.Default
+			}
+			parts = append(parts, s)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "<" + strings.Join(parts, ", ") + ">"
+}
+
+// whereClause renders a where clause on its own lines, or "" if empty.
+func (r *renderer) whereClause(g rustGenerics, indent string) string {
+	var preds []string
+	for _, p := range g.WherePredicates {
+		switch {
+		case p.BoundPredicate != nil:
+			s := r.typ(p.BoundPredicate.Type) + ": " + r.bounds(p.BoundPredicate.Bounds)
+			if len(p.BoundPredicate.GenericParams) > 0 {
+				s = "for" + r.genericParams(p.BoundPredicate.GenericParams) + " " + s
+			}
+			preds = append(preds, s)
+		case p.LifetimePredicate != nil:
+			preds = append(preds, p.LifetimePredicate.Lifetime+": "+strings.Join(p.LifetimePredicate.Outlives, " + "))
+		case p.EqPredicate != nil:
+			preds = append(preds, r.typ(p.EqPredicate.LHS)+" = "+r.term(p.EqPredicate.RHS))
+		}
+	}
+	if len(preds) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n" + indent + "where")
+	for _, p := range preds {
+		b.WriteString("\n" + indent + "    " + p + ",")
+	}
+	return b.String()
+}
+
+func (r *renderer) header(h rustHeader) string {
+	var b strings.Builder
+	if h.IsConst || h.Const {
+		b.WriteString("const ")
+	}
+	if h.IsAsync || h.Async {
+		b.WriteString("async ")
+	}
+	if h.IsUnsafe || h.Unsafe {
+		b.WriteString("unsafe ")
+	}
+	if abi := h.abi(); abi != "" {
+		b.WriteString(`extern "` + abi + `" `)
+	}
+	return b.String()
+}
+
+func visibility(it rustdocItem) string {
+	if it.isPublic() {
+		return "pub "
+	}
+	return ""
+}
+
+// fnDecl renders a function or method signature without a body.
+func (r *renderer) fnDecl(it rustdocItem, fn rustFunction, indent string) string {
+	sig := fn.signature()
+	var params []string
+	for _, in := range sig.Inputs {
+		params = append(params, r.fnParam(in))
+	}
+	if sig.IsCVariadic || sig.CVariadic {
+		params = append(params, "...")
+	}
+	decl := visibility(it) + r.header(fn.Header) + "fn " + it.Name + r.genericParams(fn.Generics.Params) +
+		"(" + strings.Join(params, ", ") + ")" + r.output(sig.Output)
+	return decl + r.whereClause(fn.Generics, indent)
+}
+
+// fnParam renders self receivers in their short form.
+func (r *renderer) fnParam(in rustFnInput) string {
+	if in.Name == "self" {
+		t := in.Type
+		switch {
+		case t.Generic != nil && *t.Generic == "Self":
+			return "self"
+		case t.BorrowedRef != nil && t.BorrowedRef.Type.Generic != nil && *t.BorrowedRef.Type.Generic == "Self":
+			lifetime := ""
+			if t.BorrowedRef.Lifetime != nil {
+				lifetime = *t.BorrowedRef.Lifetime + " "
+			}
+			if t.BorrowedRef.IsMutable || t.BorrowedRef.Mutable {
+				return "&" + lifetime + "mut self"
+			}
+			return "&" + lifetime + "self"
+		}
+	}
+	return in.Name + ": " + r.typ(in.Type)
+}
+
+// fieldDecls renders the public fields of a struct, union or struct variant.
+func (r *renderer) fieldDecls(ids []rustdocID, stripped bool, indent string) string {
+	var b strings.Builder
+	for _, id := range ids {
+		field, ok := r.item(id)
+		if !ok {
+			continue
+		}
+		var t rustType
+		if err := field.decode(&t); err != nil {
+			continue
+		}
+		b.WriteString(indent + visibility(field) + field.Name + ": " + r.typ(t) + ",\n")
+	}
+	if stripped {
+		b.WriteString(indent + "/* private fields */\n")
+	}
+	return b.String()
+}
+
+func (r *renderer) tupleFields(ids []*rustdocID) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		if id == nil {
+			parts[i] = "_"
+			continue
+		}
+		field, ok := r.item(*id)
+		if !ok {
+			parts[i] = "_"
+			continue
+		}
+		var t rustType
+		if err := field.decode(&t); err != nil {
+			parts[i] = "_"
+			continue
+		}
+		parts[i] = visibility(field) + r.typ(t)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (r *renderer) variantDecl(it rustdocItem, indent string) string {
+	var v rustVariant
+	if err := it.decode(&v); err != nil {
+		return it.Name
+	}
+	decl := it.Name
+	switch {
+	case v.Kind.Tuple != nil:
+		decl += r.tupleFields(v.Kind.Tuple)
+	case v.Kind.Struct != nil:
+		decl += " {\n" + r.fieldDecls(v.Kind.Struct.Fields, v.Kind.Struct.stripped(), indent+"    ") + indent + "}"
+	}
+	if v.Discriminant != nil {
+		decl += " = " + v.Discriminant.Expr
+	}
+	return decl
+}
+
+// assocItemDecl renders a trait or impl member: method, associated type or
+// associated constant.
+func (r *renderer) assocItemDecl(it rustdocItem, indent string) string {
+	switch it.kind() {
+	case "function":
+		var fn rustFunction
+		if err := it.decode(&fn); err != nil {
+			return ""
+		}
+		return r.fnDecl(it, fn, indent)
+	case "assoc_type":
+		var at rustAssocType
+		if err := it.decode(&at); err != nil {
+			return ""
+		}
+		decl := visibility(it) + "type " + it.Name + r.genericParams(at.Generics.Params)
+		if len(at.Bounds) > 0 {
+			decl += ": " + r.bounds(at.Bounds)
+		}
+		if t := at.Type; t != nil {
+			decl += " = " + r.typ(*t)
+		} else if t := at.Default; t != nil {
+			decl += " = " + r.typ(*t)
+		}
+		return decl + r.whereClause(at.Generics, indent) + ";"
+	case "assoc_const":
+		var ac rustAssocConst
+		if err := it.decode(&ac); err != nil {
+			return ""
+		}
+		decl := visibility(it) + "const " + it.Name + ": " + r.typ(ac.Type)
+		if ac.Value != nil {
+			decl += " = " + *ac.Value
+		} else if ac.Default != nil {
+			decl += " = " + *ac.Default
+		}
+		return decl + ";"
+	}
+	return ""
+}
+
+// implHeader renders "impl<T> Trait for Type where ...".
+func (r *renderer) implHeader(impl rustImpl) string {
+	var b strings.Builder
+	if impl.IsUnsafe {
+		b.WriteString("unsafe ")
+	}
+	b.WriteString("impl" + r.genericParams(impl.Generics.Params) + " ")
+	if impl.Trait != nil {
+		if impl.negative() {
+			b.WriteString("!")
+		}
+		b.WriteString(r.path(*impl.Trait) + " for ")
+	}
+	b.WriteString(r.typ(impl.For))
+	b.WriteString(r.whereClause(impl.Generics, ""))
+	return b.String()
+}
+
+// declaration renders the full declaration shown at the top of an item page.
+func (r *renderer) declaration(it rustdocItem) string {
+	vis := visibility(it)
+	switch it.kind() {
+	case "module":
+		var m rustModule
+		if err := it.decode(&m); err != nil || m.IsCrate {
+			return ""
+		}
+		return vis + "mod " + it.Name
+	case "function":
+		var fn rustFunction
+		if err := it.decode(&fn); err != nil {
+			return ""
+		}
+		return r.fnDecl(it, fn, "")
+	case "struct":
+		var s rustStruct
+		if err := it.decode(&s); err != nil {
+			return ""
+		}
+		head := vis + "struct " + it.Name + r.genericParams(s.Generics.Params)
+		where := r.whereClause(s.Generics, "")
+		switch {
+		case s.Kind.Plain != nil:
+			body := r.fieldDecls(s.Kind.Plain.Fields, s.Kind.Plain.stripped(), "    ")
+			if where != "" {
+				return head + where + "\n{\n" + body + "}"
+			}
+			return head + " {\n" + body + "}"
+		case s.Kind.Tuple != nil:
+			return head + r.tupleFields(s.Kind.Tuple) + where + ";"
+		default:
+			return head + where + ";"
+		}
+	case "union":
+		var u rustUnion
+		if err := it.decode(&u); err != nil {
+			return ""
+		}
+		head := vis + "union " + it.Name + r.genericParams(u.Generics.Params)
+		where := r.whereClause(u.Generics, "")
+		body := r.fieldDecls(u.Fields, u.HasStrippedFields, "    ")
+		if where != "" {
+			return head + where + "\n{\n" + body + "}"
+		}
+		return head + " {\n" + body + "}"
+	case "enum":
+		var e rustEnum
+		if err := it.decode(&e); err != nil {
+			return ""
+		}
+		var b strings.Builder
+		b.WriteString(vis + "enum " + it.Name + r.genericParams(e.Generics.Params))
+		if where := r.whereClause(e.Generics, ""); where != "" {
+			b.WriteString(where + "\n{\n")
+		} else {
+			b.WriteString(" {\n")
+		}
+		for _, id := range e.Variants {
+			if v, ok := r.item(id); ok {
+				b.WriteString("    " + r.variantDecl(v, "    ") + ",\n")
+			}
+		}
+		if e.HasStrippedVariants {
+			b.WriteString("    // some variants omitted\n")
+		}
+		b.WriteString("}")
+		return b.String()
+	case "trait":
+		var t rustTrait
+		if err := it.decode(&t); err != nil {
+			return ""
+		}
+		var b strings.Builder
+		b.WriteString(vis)
+		if t.IsUnsafe {
+			b.WriteString("unsafe ")
+		}
+		if t.IsAuto {
+			b.WriteString("auto ")
+		}
+		b.WriteString("trait " + it.Name + r.genericParams(t.Generics.Params))
+		if len(t.Bounds) > 0 {
+			b.WriteString(": " + r.bounds(t.Bounds))
+		}
+		if where := r.whereClause(t.Generics, ""); where != "" {
+			b.WriteString(where + "\n{\n")
+		} else {
+			b.WriteString(" {\n")
+		}
+		for _, id := range t.Items {
+			member, ok := r.item(id)
+			if !ok {
+				continue
+			}
+			decl := r.assocItemDecl(member, "    ")
+			if decl == "" {
+				continue
+			}
+			if member.kind() == "function" {
+				var fn rustFunction
+				if member.decode(&fn) == nil && fn.HasBody {
+					decl += " { ... }"
+				} else {
+					decl += ";"
+				}
+			}
+			b.WriteString("    " + decl + "\n")
+		}
+		b.WriteString("}")
+		return b.String()
+	case "type_alias":
+		var ta rustTypeAlias
+		if err := it.decode(&ta); err != nil {
+			return ""
+		}
+		return vis + "type " + it.Name + r.genericParams(ta.Generics.Params) + r.whereClause(ta.Generics, "") + " = " + r.typ(ta.Type) + ";"
+	case "constant":
+		var c rustConstant
+		if err := it.decode(&c); err != nil {
+			return ""
+		}
+		decl := vis + "const " + it.Name + ": " + r.typ(c.Type)
+		if expr := c.expr(); expr != "" && expr != "_" {
+			decl += " = " + expr
+		}
+		return decl + ";"
+	case "static":
+		var s rustStatic
+		if err := it.decode(&s); err != nil {
+			return ""
+		}
+		mut := ""
+		if s.IsMutable || s.Mutable {
+			mut = "mut "
+		}
+		return vis + "static " + mut + it.Name + ": " + r.typ(s.Type) + ";"
+	case "macro":
+		var src string
+		if err := it.decode(&src); err != nil {
+			return ""
+		}
+		return src
+	case "proc_macro":
+		var pm rustProcMacro
+		if err := it.decode(&pm); err != nil {
+			return ""
+		}
+		switch pm.Kind {
+		case "attr":
+			return "#[" + it.Name + "]"
+		case "derive":
+			decl := "#[derive(" + it.Name + ")]"
+			if len(pm.Helpers) > 0 {
+				decl += "\n// helper attributes: " + strings.Join(pm.Helpers, ", ")
+			}
+			return decl
+		default:
+			return it.Name + "!() { /* proc-macro */ }"
+		}
+	}
+	return ""
+}
+
+// itemImpls splits a type's impls into inherent impls, trait impls and
+// auto trait impls. Blanket impls (From<T> for T and friends) are skipped.
+func (r *renderer) itemImpls(ids []rustdocID) (inherent, traits, auto []rustImpl) {
+	for _, id := range ids {
+		it, ok := r.item(id)
+		if !ok {
+			continue
+		}
+		var impl rustImpl
+		if err := it.decode(&impl); err != nil || impl.BlanketImpl != nil {
+			continue
+		}
+		switch {
+		case impl.Trait == nil:
+			inherent = append(inherent, impl)
+		case impl.synthetic():
+			auto = append(auto, impl)
+		default:
+			traits = append(traits, impl)
+		}
+	}
+	sort.SliceStable(traits, func(i, j int) bool {
+		return r.path(*traits[i].Trait) < r.path(*traits[j].Trait)
+	})
+	return inherent, traits, auto
+}
+
+// markdown renders a full page for an item: heading, declaration, docs and
+// the sections that apply to its kind.
+func (r *renderer) markdown(it rustdocItem, kind, fullName string, avail availability) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s\n\n", kind, fullName)
+	if decl := r.declaration(it); decl != "" {
+		b.WriteString("```rust\n" + decl + "\n```\n\n")
+	}
+	if avail.gated() {
+		b.WriteString(avail.note() + "\n\n")
+	}
+	if it.Deprecation != nil {
+		b.WriteString("> **Deprecated**")
+		if it.Deprecation.Since != "" {
+			b.WriteString(" since " + it.Deprecation.Since)
+		}
+		if it.Deprecation.Note != "" {
+			b.WriteString(": " + it.Deprecation.Note)
+		}
+		b.WriteString("\n\n")
+	}
+	if docs := cleanDocs(it.Docs); docs != "" {
+		b.WriteString(docs + "\n\n")
+	}
+
+	switch it.kind() {
+	case "struct":
+		var s rustStruct
+		if it.decode(&s) == nil {
+			if s.Kind.Plain != nil {
+				r.writeFields(&b, "Fields", s.Kind.Plain.Fields)
+			}
+			r.writeImpls(&b, s.Impls)
+		}
+	case "union":
+		var u rustUnion
+		if it.decode(&u) == nil {
+			r.writeFields(&b, "Fields", u.Fields)
+			r.writeImpls(&b, u.Impls)
+		}
+	case "enum":
+		var e rustEnum
+		if it.decode(&e) == nil {
+			r.writeVariants(&b, e.Variants)
+			r.writeImpls(&b, e.Impls)
+		}
+	case "trait":
+		var t rustTrait
+		if it.decode(&t) == nil {
+			r.writeTraitItems(&b, t)
+			r.writeImplementors(&b, t.Implementations)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (r *renderer) writeFields(b *strings.Builder, title string, ids []rustdocID) {
+	var entries []string
+	for _, id := range ids {
+		field, ok := r.item(id)
+		if !ok || !field.isPublic() {
+			continue
+		}
+		var t rustType
+		if err := field.decode(&t); err != nil {
+			continue
+		}
+		entry := "### `" + field.Name + ": " + r.typ(t) + "`\n\n"
+		if docs := cleanDocs(field.Docs); docs != "" {
+			entry += docs + "\n\n"
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return
+	}
+	b.WriteString("## " + title + "\n\n" + strings.Join(entries, ""))
+}
+
+func (r *renderer) writeVariants(b *strings.Builder, ids []rustdocID) {
+	if len(ids) == 0 {
+		return
+	}
+	b.WriteString("## Variants\n\n")
+	for _, id := range ids {
+		v, ok := r.item(id)
+		if !ok {
+			continue
+		}
+		b.WriteString("### `" + oneLine(r.variantDecl(v, "")) + "`\n\n")
+		if docs := cleanDocs(v.Docs); docs != "" {
+			b.WriteString(docs + "\n\n")
+		}
+	}
+}
+
+func (r *renderer) writeImpls(b *strings.Builder, ids []rustdocID) {
+	inherent, traits, auto := r.itemImpls(ids)
+	if len(inherent) > 0 {
+		b.WriteString("## Implementations\n\n")
+		for _, impl := range inherent {
+			b.WriteString("### `" + oneLine(r.implHeader(impl)) + "`\n\n")
+			for _, id := range impl.Items {
+				member, ok := r.item(id)
+				if !ok || !member.isPublic() {
+					continue
+				}
+				r.writeMember(b, member, "####")
+			}
+		}
+	}
+	if len(traits) > 0 {
+		b.WriteString("## Implements\n\n")
+		for _, impl := range traits {
+			b.WriteString("- `" + oneLine(r.implHeader(impl)) + "`\n")
+		}
+		b.WriteString("\n")
+	}
+	if len(auto) > 0 {
+		b.WriteString("## Auto Trait Implementations\n\n")
+		for _, impl := range auto {
+			b.WriteString("- `" + oneLine(r.implHeader(impl)) + "`\n")
+		}
+		b.WriteString("\n")
+	}
+}
+
+func (r *renderer) writeMember(b *strings.Builder, member rustdocItem, level string) {
+	decl := r.assocItemDecl(member, "")
+	if decl == "" {
+		return
+	}
+	b.WriteString(level + " `" + oneLine(decl) + "`\n\n")
+	if e, ok := itemCfg(member.attrStrings()); ok {
+		b.WriteString(cfgAvailability(e).note() + "\n\n")
+	}
+	if docs := cleanDocs(member.Docs); docs != "" {
+		b.WriteString(docs + "\n\n")
+	}
+}
+
+func (r *renderer) writeTraitItems(b *strings.Builder, t rustTrait) {
+	sections := []struct {
+		title string
+		match func(rustdocItem) bool
+	}{
+		{"Associated Types", func(it rustdocItem) bool { return it.kind() == "assoc_type" }},
+		{"Associated Constants", func(it rustdocItem) bool { return it.kind() == "assoc_const" }},
+		{"Required Methods", func(it rustdocItem) bool {
+			var fn rustFunction
+			return it.kind() == "function" && it.decode(&fn) == nil && !fn.HasBody
+		}},
+		{"Provided Methods", func(it rustdocItem) bool {
+			var fn rustFunction
+			return it.kind() == "function" && it.decode(&fn) == nil && fn.HasBody
+		}},
+	}
+	for _, section := range sections {
+		var members []rustdocItem
+		for _, id := range t.Items {
+			if member, ok := r.item(id); ok && section.match(member) {
+				members = append(members, member)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		b.WriteString("## " + section.title + "\n\n")
+		for _, member := range members {
+			r.writeMember(b, member, "###")
+		}
+	}
+}
+
+func (r *renderer) writeImplementors(b *strings.Builder, ids []rustdocID) {
+	var lines []string
+	for _, id := range ids {
+		it, ok := r.item(id)
+		if !ok {
+			continue
+		}
+		var impl rustImpl
+		if err := it.decode(&impl); err != nil || impl.synthetic() {
+			continue
+		}
+		lines = append(lines, "- `"+oneLine(r.implHeader(impl))+"`")
+	}
+	if len(lines) == 0 {
+		return
+	}
+	sort.Strings(lines)
+	b.WriteString("## Implemented By\n\n" + strings.Join(lines, "\n") + "\n\n")
+}
+
+// memberKinds maps associated item kinds to the types they are indexed as.
+var memberKinds = map[string]string{
+	"function":    "Method",
+	"assoc_type":  "AssocType",
+	"assoc_const": "AssocConst",
+}
+
+// members returns the associated items indexed under a type or trait: the
+// public items of a type's inherent impls, or every item of a trait.
+func (r *renderer) members(it rustdocItem) []memberEntry {
+	var ids []rustdocID
+	switch it.kind() {
+	case "struct", "enum", "union":
+		var impls struct {
+			Impls []rustdocID `json:"impls"`
+		}
+		if err := it.decode(&impls); err != nil {
+			return nil
+		}
+		inherent, _, _ := r.itemImpls(impls.Impls)
+		for _, impl := range inherent {
+			for _, id := range impl.Items {
+				if member, ok := r.item(id); ok && member.isPublic() {
+					ids = append(ids, id)
+				}
+			}
+		}
+	case "trait":
+		var t rustTrait
+		if err := it.decode(&t); err != nil {
+			return nil
+		}
+		ids = t.Items
+	}
+
+	var members []memberEntry
+	for _, id := range ids {
+		member, ok := r.item(id)
+		if !ok {
+			continue
+		}
+		kind, ok := memberKinds[member.kind()]
+		if !ok {
+			continue
+		}
+		entry := memberEntry{
+			Name:      member.Name,
+			Type:      kind,
+			Signature: oneLine(strings.TrimSuffix(r.assocItemDecl(member, ""), ";")),
+			Summary:   docSummary(member.Docs),
+		}
+		if e, ok := itemCfg(member.attrStrings()); ok {
+			entry.Available = cfgAvailability(e)
+		}
+		members = append(members, entry)
+	}
+	return members
+}
+
+// oneLine collapses a multi-line declaration, dropping the trailing commas
+// that where clauses and wrapped parameter lists leave behind.
+func oneLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.NewReplacer("( ", "(", ", )", ")", ", }", " }").Replace(s)
+	return strings.TrimSuffix(s, ",")
+}
+
+// cleanDocs prepares a doc comment for embedding in a page: headings are
+// demoted below the item heading, untagged code fences are marked as Rust and
+// lines hidden from rustdoc output ("# use foo;") are dropped.
+func cleanDocs(docs string) string {
+	docs = strings.TrimSpace(docs)
+	if docs == "" {
+		return ""
+	}
+	var out []string
+	inFence, rustFence := false, false
+	for line := range strings.SplitSeq(docs, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			if !inFence {
+				inFence = true
+				info := strings.TrimSpace(trimmed[3:])
+				rustFence = info == "" || strings.HasPrefix(info, "rust") || isRustdocFenceAttr(info)
+				if rustFence {
+					line = trimmed[:3] + "rust"
+				}
+			} else {
+				inFence = false
+			}
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			if rustFence && (trimmed == "#" || strings.HasPrefix(trimmed, "# ")) {
+				continue
+			}
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			line = "##" + line
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// isRustdocFenceAttr reports whether a fence info string only holds rustdoc
+// test attributes such as "no_run" or "should_panic,edition2021".
+func isRustdocFenceAttr(info string) bool {
+	for attr := range strings.SplitSeq(info, ",") {
+		attr = strings.TrimSpace(attr)
+		switch {
+		case attr == "ignore", attr == "no_run", attr == "should_panic", attr == "compile_fail",
+			strings.HasPrefix(attr, "edition"), strings.HasPrefix(attr, "ignore-"):
+		default:
+			return false
+		}
+	}
+	return true
+}
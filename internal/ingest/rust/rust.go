@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
@@ -26,11 +27,30 @@ import (
 	"github.com/stormlightlabs/documango/internal/shared"
 )
 
+// Documentation sources accepted by Options.Format.
+const (
+	FormatJSON = "json"
+	FormatHTML = "html"
+)
+
 type Options struct {
 	Crate   string
 	Version string
-	DB      *db.Store
-	Cache   *cache.FilesystemCache
+	// Format selects the docs.rs build to ingest: FormatJSON for rustdoc JSON,
+	// FormatHTML for the HTML archive, or "" to prefer JSON and fall back to
+	// HTML for releases that have no JSON build.
+	Format string
+	// Dir ingests the `cargo doc` output of a local crate or workspace instead
+	// of fetching from docs.rs. Crate, if set, selects one workspace member.
+	Dir string
+	// Target selects the target triple to ingest documentation for (e.g.
+	// x86_64-pc-windows-msvc). By default docs.rs' default target is used.
+	Target string
+	// Features, when set, limits ingestion to items available with these
+	// cargo features enabled. Items without a feature gate are always kept.
+	Features []string
+	DB       *db.Store
+	Cache    *cache.FilesystemCache
 }
 
 type cratesioResponse struct {
@@ -49,6 +69,10 @@ type sidebarItems struct {
 	TypeDefs  []string `json:"type"`
 	Constants []string `json:"constant"`
 	Statics   []string `json:"static"`
+	Unions    []string `json:"union"`
+	Macros    []string `json:"macro"`
+	Attrs     []string `json:"attr"`
+	Derives   []string `json:"derive"`
 }
 
 var targetPreference = []string{
@@ -58,12 +82,18 @@ var targetPreference = []string{
 }
 
 func IngestCrate(ctx context.Context, opts Options) error {
-	if opts.Crate == "" {
-		return errors.New("crate name is required")
-	}
 	if opts.DB == nil {
 		return errors.New("db store is required")
 	}
+	if opts.Format != "" && opts.Format != FormatJSON && opts.Format != FormatHTML {
+		return fmt.Errorf("unknown rustdoc format %q, want %s or %s", opts.Format, FormatJSON, FormatHTML)
+	}
+	if opts.Dir != "" {
+		return ingestLocal(ctx, opts)
+	}
+	if opts.Crate == "" {
+		return errors.New("crate name is required")
+	}
 
 	version := opts.Version
 	if version == "" {
@@ -74,23 +104,68 @@ func IngestCrate(ctx context.Context, opts Options) error {
 		}
 	}
 
+	if opts.Format != FormatHTML {
+		err := ingestJSON(ctx, opts, version)
+		if err == nil || opts.Format == FormatJSON {
+			return err
+		}
+		log.Warn("rustdoc json unavailable, falling back to html", "crate", opts.Crate, "version", version, "err", err)
+	}
+
+	return ingestHTML(ctx, opts, version)
+}
+
+func (o Options) build() buildConfig {
+	return buildConfig{Target: o.Target, Features: o.Features}
+}
+
+func ingestJSON(ctx context.Context, opts Options, version string) error {
+	data, err := fetchRustdocJSON(ctx, opts.Crate, version, opts.Target, opts.Cache)
+	if err != nil {
+		return err
+	}
+	krate, err := parseRustdocJSON(data)
+	if err != nil {
+		return err
+	}
+
+	log.Info("rust crate ingest starting", "crate", opts.Crate, "version", version, "format", FormatJSON, "format_version", krate.FormatVersion)
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		return ingestRustdocJSON(ctx, tx, krate, opts.Crate, version, opts.build())
+	})
+}
+
+func ingestHTML(ctx context.Context, opts Options, version string) error {
 	tmpDir, cleanup, err := downloadDocs(ctx, opts.Crate, version, opts.Cache)
 	if err != nil {
 		return err
 	}
 	defer cleanup()
 
-	log.Info("rust crate ingest starting", "crate", opts.Crate, "version", version)
+	log.Info("rust crate ingest starting", "crate", opts.Crate, "version", version, "format", FormatHTML)
 
 	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		build := opts.build()
 		crateName := strings.ReplaceAll(opts.Crate, "-", "_")
 		crateDir := filepath.Join(tmpDir, crateName)
 
+		// The default target is at the archive root, others under their triple.
+		if build.Target != "" {
+			if dir := filepath.Join(tmpDir, build.Target, crateName); isDir(dir) {
+				crateDir = dir
+			} else {
+				log.Warn("target not in rustdoc archive, using the default target", "crate", opts.Crate, "target", build.Target)
+				build.Target = ""
+			}
+		}
+
 		if _, err := os.Stat(crateDir); os.IsNotExist(err) {
 			if target, err := selectTarget(tmpDir); err != nil {
 				return err
 			} else {
 				crateDir = filepath.Join(tmpDir, target, crateName)
+				build.Target = target
 			}
 		}
 
@@ -98,7 +173,7 @@ func IngestCrate(ctx context.Context, opts Options) error {
 			return fmt.Errorf("crate directory not found: %s", crateDir)
 		}
 
-		return ingestCrateDir(ctx, tx, opts.Crate, version, crateDir, "")
+		return ingestCrateDir(ctx, tx, opts.Crate, version, crateDir, "", build)
 	})
 }
 
@@ -289,6 +364,12 @@ func selectTarget(extractDir string) (string, error) {
 		}
 	}
 
+	return preferredTarget(targets)
+}
+
+// preferredTarget picks a target triple from targets by targetPreference,
+// falling back to the first one.
+func preferredTarget(targets []string) (string, error) {
 	for _, pref := range targetPreference {
 		for _, t := range targets {
 			if strings.HasPrefix(t, pref) {
@@ -304,7 +385,7 @@ func selectTarget(extractDir string) (string, error) {
 	return targets[0], nil
 }
 
-func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, modulePath string) error {
+func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, modulePath string, build buildConfig) error {
 	sidebarPath := findSidebarItems(crateDir)
 	if sidebarPath == "" {
 		log.Debug("sidebar-items.js not found, skipping recursive ingestion", "dir", crateDir)
@@ -348,9 +429,29 @@ func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, m
 	for _, s := range items.Statics {
 		allItems = append(allItems, docItem{Name: s, Type: "Static"})
 	}
+	for _, u := range items.Unions {
+		allItems = append(allItems, docItem{Name: u, Type: "Union"})
+	}
+	for _, m := range items.Macros {
+		allItems = append(allItems, docItem{Name: m, Type: "Macro", Page: "macro." + m + ".html"})
+	}
+	for _, a := range items.Attrs {
+		allItems = append(allItems, docItem{Name: a, Type: "Macro", Page: "attr." + a + ".html"})
+	}
+	for _, d := range items.Derives {
+		allItems = append(allItems, docItem{Name: d, Type: "Macro", Page: "derive." + d + ".html"})
+	}
 
 	crateIndexPath := filepath.Join(crateDir, "index.html")
-	crateDoc, err := parseRustdocHTML(crateIndexPath)
+	crateDoc := ""
+	page, err := parseRustdocHTML(crateIndexPath)
+	if err == nil {
+		crateDoc = page.markdown
+	}
+	if err == nil && !build.enabled(page.available) {
+		log.Debug("skipping module outside feature set", "module", modulePath)
+		return nil
+	}
 	if err == nil && crateDoc != "" {
 		log.Info("inserting index", "path", crateIndexPath, "module", modulePath)
 
@@ -376,23 +477,33 @@ func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, m
 			itemType = "Module"
 		}
 
+		body, summary := page.available.annotate(fullName+" "+shared.FirstLine(crateDoc), shared.FirstLine(crateDoc))
 		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
 			Name:  fullName,
 			Type:  itemType,
-			Body:  fullName + " " + shared.FirstLine(crateDoc),
+			Body:  body,
 			DocID: docID,
 		}); err != nil {
 			return err
 		}
 
-		signature := extractSignature(crateDoc)
-		if signature != "" {
-			if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
-				DocID:     docID,
-				Symbol:    fullName,
-				Signature: signature,
-				Summary:   shared.FirstLine(crateDoc),
-			}); err != nil {
+		signature := "crate " + fullName
+		if modulePath != "" {
+			signature = "mod " + fullName
+		}
+		if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    fullName,
+			Signature: signature,
+			Summary:   summary,
+		}); err != nil {
+			return err
+		}
+		if err := insertAvailability(ctx, tx, docID, fullName, page.available); err != nil {
+			return err
+		}
+		if modulePath == "" && build.Target != "" {
+			if err := db.InsertMetadataTx(ctx, tx, db.Metadata{DocID: docID, Symbol: fullName, Key: "target", Value: build.Target}); err != nil {
 				return err
 			}
 		}
@@ -410,7 +521,7 @@ func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, m
 			if modulePath != "" {
 				subModulePath = modulePath + "::" + item.Name
 			}
-			if err := ingestCrateDir(ctx, tx, crate, version, subDir, subModulePath); err != nil {
+			if err := ingestCrateDir(ctx, tx, crate, version, subDir, subModulePath, build); err != nil {
 				log.Warn("failed to ingest submodule", "module", subModulePath, "err", err)
 			}
 			continue
@@ -428,6 +539,10 @@ func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, m
 			htmlPath = filepath.Join(crateDir, "constant."+item.Name+".html")
 		case "Static":
 			htmlPath = filepath.Join(crateDir, "static."+item.Name+".html")
+		case "Union":
+			htmlPath = filepath.Join(crateDir, "union."+item.Name+".html")
+		case "Macro":
+			htmlPath = filepath.Join(crateDir, item.Page)
 		default:
 			continue
 		}
@@ -436,13 +551,14 @@ func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, m
 			continue
 		}
 
-		markdown, err := parseRustdocHTML(htmlPath)
+		page, err := parseRustdocHTML(htmlPath)
 		if err != nil {
 			log.Warn("failed to parse rustdoc", "file", htmlPath, "err", err)
 			continue
 		}
 
-		if markdown == "" {
+		markdown, signature := page.markdown, page.signature
+		if markdown == "" || !build.enabled(page.available) {
 			continue
 		}
 
@@ -466,26 +582,39 @@ func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, m
 		}
 		fullName += "::" + item.Name
 
+		body, summary := page.available.annotate(fullName+" "+shared.FirstLine(markdown), shared.FirstLine(markdown))
 		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
 			Name:  fullName,
 			Type:  item.Type,
-			Body:  fullName + " " + shared.FirstLine(markdown),
+			Body:  body,
 			DocID: docID,
 		}); err != nil {
 			return err
 		}
 
-		signature := extractSignature(markdown)
 		if signature != "" {
 			if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
 				DocID:     docID,
 				Symbol:    fullName,
 				Signature: signature,
-				Summary:   shared.FirstLine(markdown),
+				Summary:   summary,
 			}); err != nil {
 				return err
 			}
 		}
+		if err := insertAvailability(ctx, tx, docID, fullName, page.available); err != nil {
+			return err
+		}
+
+		var members []memberEntry
+		for _, m := range page.members {
+			if build.enabled(page.available.and(m.Available)) {
+				members = append(members, m)
+			}
+		}
+		if err := insertMembers(ctx, tx, docID, fullName, page.available, members); err != nil {
+			return err
+		}
 	}
 
 	log.Info("ingestion complete", "dir", crateDir, "processed", processedCount)
@@ -495,6 +624,47 @@ func ingestCrateDir(ctx context.Context, tx *sql.Tx, crate, version, crateDir, m
 type docItem struct {
 	Name string
 	Type string
+	Page string
+}
+
+// memberEntry is an associated item (method, associated type or constant)
+// indexed under its parent type or trait, e.g. Vec::push or Iterator::Item.
+type memberEntry struct {
+	Name      string
+	Type      string
+	Signature string
+	Summary   string
+	Available availability
+}
+
+// insertMembers indexes a page's associated items. They point at the parent's
+// document, which is where they are rendered, and inherit its availability.
+func insertMembers(ctx context.Context, tx *sql.Tx, docID int64, parent string, parentAvail availability, members []memberEntry) error {
+	for _, m := range members {
+		name := parent + "::" + m.Name
+		avail := parentAvail.and(m.Available)
+		body, summary := avail.annotate(strings.TrimSpace(name+" "+m.Signature+" "+m.Summary), m.Summary)
+		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+			Name:  name,
+			Type:  m.Type,
+			Body:  body,
+			DocID: docID,
+		}); err != nil {
+			return err
+		}
+		if err := db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    name,
+			Signature: m.Signature,
+			Summary:   summary,
+		}); err != nil {
+			return err
+		}
+		if err := insertAvailability(ctx, tx, docID, name, avail); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func findSidebarItems(crateDir string) string {
@@ -521,18 +691,126 @@ func findSidebarItems(crateDir string) string {
 	return ""
 }
 
-func parseRustdocHTML(htmlPath string) (string, error) {
+// htmlPage is a rustdoc HTML page converted to markdown, with the item's
+// declaration and associated items read from the markup beforehand.
+type htmlPage struct {
+	markdown  string
+	signature string
+	available availability
+	members   []memberEntry
+}
+
+func parseRustdocHTML(htmlPath string) (htmlPage, error) {
 	data, err := os.ReadFile(htmlPath)
 	if err != nil {
-		return "", err
+		return htmlPage{}, err
 	}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(data)))
 	if err != nil {
-		return "", err
+		return htmlPage{}, err
 	}
 
-	return parseRustdocHTMLFromDoc(doc), nil
+	page := htmlPage{
+		signature: itemDeclaration(doc),
+		available: htmlAvailability(doc.Find("#main-content > .item-info .portability, main > .item-info .portability").First()),
+		members:   htmlMembers(doc),
+	}
+	page.markdown = parseRustdocHTMLFromDoc(doc)
+	return page, nil
+}
+
+// htmlMemberKinds maps rustdoc anchor prefixes to member types.
+var htmlMemberKinds = map[string]string{
+	"method":             "Method",
+	"tymethod":           "Method",
+	"associatedtype":     "AssocType",
+	"associatedconstant": "AssocConst",
+}
+
+// htmlMembers reads associated items from a type's inherent impls or a
+// trait's item list. Each is a <section id="method.push"> holding an
+// h4.code-header, followed by its docblock. Trait impl members are left out.
+func htmlMembers(doc *goquery.Document) []memberEntry {
+	var members []memberEntry
+	seen := make(map[string]bool)
+	doc.Find("main #implementations-list section[id], main div.methods section[id]").Each(func(_ int, sec *goquery.Selection) {
+		id, _ := sec.Attr("id")
+		prefix, name, ok := strings.Cut(id, ".")
+		kind, known := htmlMemberKinds[prefix]
+		if !ok || !known || seen[id] {
+			return
+		}
+		seen[id] = true
+
+		header := sec.Find(".code-header").First().Clone()
+		header.Find(".out-of-band, .rightside").Remove()
+		docblock := sec.Parent().NextFiltered("div.docblock")
+		if docblock.Length() == 0 {
+			docblock = sec.NextFiltered("div.docblock")
+		}
+
+		members = append(members, memberEntry{
+			Name:      name,
+			Type:      kind,
+			Signature: oneLine(strings.TrimSuffix(header.Text(), ";")),
+			Summary:   strings.TrimSpace(docblock.Find("p").First().Text()),
+			Available: htmlAvailability(sec.NextFiltered(".item-info").Find(".portability").First()),
+		})
+	})
+	return members
+}
+
+// htmlAvailability reads a portability note such as "Available on <strong>crate
+// features <code>a</code> and (Linux or Windows)</strong> only." Features are
+// the <code> spans following "feature"; the remaining conditions are only kept
+// as text since the original cfg is not in the markup.
+func htmlAvailability(stab *goquery.Selection) availability {
+	strong := stab.Find("strong").First()
+	if strong.Length() == 0 {
+		return availability{}
+	}
+
+	var a availability
+	var text strings.Builder
+	inFeatures := false
+	strong.Contents().Each(func(_ int, node *goquery.Selection) {
+		if goquery.NodeName(node) == "code" {
+			if inFeatures && !slices.Contains(a.Features, node.Text()) {
+				a.Features = append(a.Features, node.Text())
+			}
+			text.WriteString("`" + node.Text() + "`")
+			return
+		}
+		t := node.Text()
+		switch strings.TrimSpace(t) {
+		case "and", "or", ",":
+		default:
+			inFeatures = strings.HasSuffix(t, "feature ") || strings.HasSuffix(t, "features ")
+		}
+		text.WriteString(t)
+	})
+	a.Text = oneLine(text.String())
+	return a
+}
+
+// itemDeclaration returns the text of the page's declaration block, collapsed
+// to one line. Modules and crates have none.
+func itemDeclaration(doc *goquery.Document) string {
+	decl := doc.Find("main pre.item-decl").First()
+	if decl.Length() == 0 {
+		return ""
+	}
+	decl.Find(".out-of-band, button").Remove()
+	decl.Find(".where, br").BeforeHtml(" ")
+	text := oneLine(decl.Text())
+	for _, kw := range []string{"struct ", "enum ", "union ", "trait ", "unsafe trait ", "auto trait "} {
+		if strings.HasPrefix(strings.TrimPrefix(text, "pub "), kw) {
+			text, _, _ = strings.Cut(text, "{")
+			break
+		}
+	}
+	return oneLine(strings.TrimSuffix(strings.TrimSpace(text), ";"))
 }
 
 func parseRustdocHTMLFromDoc(doc *goquery.Document) string {
@@ -587,27 +865,6 @@ func cleanText(s string) string {
 	return strings.TrimSpace(s)
 }
 
-func extractSignature(markdown string) string {
-	lines := strings.SplitSeq(markdown, "\n")
-	for line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "```rust") {
-			continue
-		}
-
-		if after, ok := strings.CutPrefix(trimmed, "# Module "); ok {
-			return "mod " + after
-		}
-		if strings.HasPrefix(trimmed, "pub ") || strings.HasPrefix(trimmed, "fn ") ||
-			strings.HasPrefix(trimmed, "struct ") || strings.HasPrefix(trimmed, "enum ") ||
-			strings.HasPrefix(trimmed, "trait ") || strings.HasPrefix(trimmed, "type ") {
-			sig := strings.TrimSuffix(trimmed, ";")
-			return strings.TrimPrefix(sig, "pub ")
-		}
-	}
-	return ""
-}
-
 func insertDoc(ctx context.Context, tx *sql.Tx, crate, version, docPath, markdown string) (int64, error) {
 	if docPath == "" {
 		docPath = "rust/" + crate + "/index"
@@ -1,6 +1,7 @@
 package rust
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -83,3 +84,46 @@ func TestParseRustdocHTMLStream(t *testing.T) {
 		})
 	}
 }
+
+func TestHTMLMembers(t *testing.T) {
+	html := `<main>
+		<h2 id="implementations">Implementations</h2>
+		<div id="implementations-list"><details class="toggle implementors-toggle" open>
+			<summary><section id="impl-Vec%3CT%3E" class="impl"><h3 class="code-header">impl&lt;T&gt; Vec&lt;T&gt;</h3></section></summary>
+			<div class="impl-items">
+				<details class="toggle method-toggle" open>
+					<summary><section id="method.push" class="method"><a class="src rightside" href="#">Source</a><h4 class="code-header">pub fn <a href="#method.push">push</a>(&amp;mut self, value: T)</h4></section></summary>
+					<div class="docblock"><p>Appends an element to the back of a collection.</p><p>Panics on overflow.</p></div>
+				</details>
+			</div>
+		</details></div>
+		<h2 id="trait-implementations">Trait Implementations</h2>
+		<div id="trait-implementations-list"><details class="toggle method-toggle" open>
+			<summary><section id="method.clone" class="method"><h4 class="code-header">fn clone(&amp;self) -&gt; Self</h4></section></summary>
+		</details></div>
+		<h2 id="required-associated-types">Required Associated Types</h2>
+		<div class="methods"><details class="toggle" open>
+			<summary><section id="associatedtype.Item" class="method"><h4 class="code-header">type Item</h4></section></summary>
+			<div class="docblock"><p>The type of the elements.</p></div>
+		</details></div>
+	</main>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+
+	got := htmlMembers(doc)
+	want := []memberEntry{
+		{Name: "push", Type: "Method", Signature: "pub fn push(&mut self, value: T)", Summary: "Appends an element to the back of a collection."},
+		{Name: "Item", Type: "AssocType", Signature: "type Item", Summary: "The type of the elements."},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("htmlMembers() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("htmlMembers()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
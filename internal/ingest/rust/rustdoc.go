@@ -0,0 +1,545 @@
+package rust
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// The types below model the subset of rustdoc's JSON output
+// (`rustdoc --output-format json`, also served by docs.rs) that documango
+// renders. Field names follow recent format versions; older spellings that are
+// cheap to support (mutable vs is_mutable, decl vs sig) are accepted too.
+
+// rustdocID identifies an item. Format versions before 35 used strings such
+// as "0:12:345", later ones use integers; both decode to a string.
+type rustdocID string
+
+func (id *rustdocID) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = rustdocID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*id = rustdocID(n.String())
+	return nil
+}
+
+type rustdocCrate struct {
+	Root           rustdocID                     `json:"root"`
+	CrateVersion   string                        `json:"crate_version"`
+	Index          map[rustdocID]rustdocItem     `json:"index"`
+	Paths          map[rustdocID]rustdocSummary  `json:"paths"`
+	ExternalCrates map[string]rustdocExternCrate `json:"external_crates"`
+	FormatVersion  int                           `json:"format_version"`
+	Target         *rustdocTarget                `json:"target"`
+}
+
+// rustdocTarget is the platform the JSON was built for (format version 35+).
+type rustdocTarget struct {
+	Triple string `json:"triple"`
+}
+
+type rustdocSummary struct {
+	CrateID int      `json:"crate_id"`
+	Path    []string `json:"path"`
+	Kind    string   `json:"kind"`
+}
+
+type rustdocExternCrate struct {
+	Name        string `json:"name"`
+	HTMLRootURL string `json:"html_root_url"`
+}
+
+type rustdocItem struct {
+	ID          rustdocID                  `json:"id"`
+	CrateID     int                        `json:"crate_id"`
+	Name        string                     `json:"name"`
+	Visibility  json.RawMessage            `json:"visibility"`
+	Docs        string                     `json:"docs"`
+	Attrs       []json.RawMessage          `json:"attrs"`
+	Deprecation *rustDeprecation           `json:"deprecation"`
+	Inner       map[string]json.RawMessage `json:"inner"`
+}
+
+type rustDeprecation struct {
+	Since string `json:"since"`
+	Note  string `json:"note"`
+}
+
+// kind returns the item's variant name, e.g. "struct" or "function".
+func (it rustdocItem) kind() string {
+	for k := range it.Inner {
+		return k
+	}
+	return ""
+}
+
+// decode unmarshals the item's inner payload into v.
+func (it rustdocItem) decode(v any) error {
+	return json.Unmarshal(it.Inner[it.kind()], v)
+}
+
+func (it rustdocItem) isPublic() bool {
+	var vis string
+	return json.Unmarshal(it.Visibility, &vis) == nil && vis == "public"
+}
+
+// attrStrings returns attributes as source text. Newer format versions emit
+// structured attributes; those are rendered from their "other" form if present.
+func (it rustdocItem) attrStrings() []string {
+	var attrs []string
+	for _, raw := range it.Attrs {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			attrs = append(attrs, s)
+			continue
+		}
+		var structured map[string]json.RawMessage
+		if json.Unmarshal(raw, &structured) == nil {
+			if other, ok := structured["other"]; ok && json.Unmarshal(other, &s) == nil {
+				attrs = append(attrs, s)
+			}
+		}
+	}
+	return attrs
+}
+
+type rustModule struct {
+	IsCrate    bool        `json:"is_crate"`
+	Items      []rustdocID `json:"items"`
+	IsStripped bool        `json:"is_stripped"`
+}
+
+type rustStruct struct {
+	Kind     rustStructKind `json:"kind"`
+	Generics rustGenerics   `json:"generics"`
+	Impls    []rustdocID    `json:"impls"`
+}
+
+// rustStructKind is "unit", {"tuple": [id|null]} or {"plain": {...}}.
+type rustStructKind struct {
+	Unit  bool
+	Tuple []*rustdocID
+	Plain *rustFieldList
+}
+
+func (k *rustStructKind) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		k.Unit = s == "unit"
+		return nil
+	}
+	var v struct {
+		Tuple []*rustdocID     `json:"tuple"`
+		Plain *rustFieldList   `json:"plain"`
+		Unit  *json.RawMessage `json:"unit"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	k.Tuple, k.Plain, k.Unit = v.Tuple, v.Plain, v.Unit != nil
+	return nil
+}
+
+type rustFieldList struct {
+	Fields            []rustdocID `json:"fields"`
+	HasStrippedFields bool        `json:"has_stripped_fields"`
+	FieldsStripped    bool        `json:"fields_stripped"`
+}
+
+func (f rustFieldList) stripped() bool {
+	return f.HasStrippedFields || f.FieldsStripped
+}
+
+type rustUnion struct {
+	Generics          rustGenerics `json:"generics"`
+	Fields            []rustdocID  `json:"fields"`
+	HasStrippedFields bool         `json:"has_stripped_fields"`
+	Impls             []rustdocID  `json:"impls"`
+}
+
+type rustEnum struct {
+	Generics            rustGenerics `json:"generics"`
+	Variants            []rustdocID  `json:"variants"`
+	HasStrippedVariants bool         `json:"has_stripped_variants"`
+	Impls               []rustdocID  `json:"impls"`
+}
+
+type rustVariant struct {
+	Kind         rustVariantKind `json:"kind"`
+	Discriminant *struct {
+		Expr string `json:"expr"`
+	} `json:"discriminant"`
+}
+
+// rustVariantKind is "plain", {"tuple": [id|null]} or {"struct": {...}}.
+type rustVariantKind struct {
+	Tuple  []*rustdocID
+	Struct *rustFieldList
+}
+
+func (k *rustVariantKind) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		return nil
+	}
+	var v struct {
+		Tuple  []*rustdocID   `json:"tuple"`
+		Struct *rustFieldList `json:"struct"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	k.Tuple, k.Struct = v.Tuple, v.Struct
+	return nil
+}
+
+type rustFunction struct {
+	Sig      *rustFnSig   `json:"sig"`
+	Decl     *rustFnSig   `json:"decl"`
+	Generics rustGenerics `json:"generics"`
+	Header   rustHeader   `json:"header"`
+	HasBody  bool         `json:"has_body"`
+}
+
+func (f rustFunction) signature() rustFnSig {
+	if f.Sig != nil {
+		return *f.Sig
+	}
+	if f.Decl != nil {
+		return *f.Decl
+	}
+	return rustFnSig{}
+}
+
+type rustFnSig struct {
+	Inputs      []rustFnInput `json:"inputs"`
+	Output      *rustType     `json:"output"`
+	IsCVariadic bool          `json:"is_c_variadic"`
+	CVariadic   bool          `json:"c_variadic"`
+}
+
+// rustFnInput is a ["name", type] pair.
+type rustFnInput struct {
+	Name string
+	Type rustType
+}
+
+func (in *rustFnInput) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return nil
+	}
+	if err := json.Unmarshal(pair[0], &in.Name); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &in.Type)
+}
+
+type rustHeader struct {
+	IsConst  bool            `json:"is_const"`
+	IsUnsafe bool            `json:"is_unsafe"`
+	IsAsync  bool            `json:"is_async"`
+	Const    bool            `json:"const"`
+	Unsafe   bool            `json:"unsafe"`
+	Async    bool            `json:"async"`
+	ABI      json.RawMessage `json:"abi"`
+}
+
+// abi returns the extern ABI name, or "" for the Rust ABI.
+func (h rustHeader) abi() string {
+	var s string
+	if json.Unmarshal(h.ABI, &s) == nil {
+		if s == "Rust" {
+			return ""
+		}
+		return s
+	}
+	var other map[string]json.RawMessage
+	if json.Unmarshal(h.ABI, &other) == nil {
+		for name, value := range other {
+			if name == "Other" {
+				_ = json.Unmarshal(value, &s)
+				return s
+			}
+			return name
+		}
+	}
+	return ""
+}
+
+type rustTrait struct {
+	IsAuto          bool         `json:"is_auto"`
+	IsUnsafe        bool         `json:"is_unsafe"`
+	Items           []rustdocID  `json:"items"`
+	Generics        rustGenerics `json:"generics"`
+	Bounds          []rustBound  `json:"bounds"`
+	Implementations []rustdocID  `json:"implementations"`
+}
+
+type rustImpl struct {
+	IsUnsafe             bool         `json:"is_unsafe"`
+	Generics             rustGenerics `json:"generics"`
+	ProvidedTraitMethods []string     `json:"provided_trait_methods"`
+	Trait                *rustPath    `json:"trait"`
+	For                  rustType     `json:"for"`
+	Items                []rustdocID  `json:"items"`
+	IsNegative           bool         `json:"is_negative"`
+	Negative             bool         `json:"negative"`
+	IsSynthetic          bool         `json:"is_synthetic"`
+	Synthetic            bool         `json:"synthetic"`
+	BlanketImpl          *rustType    `json:"blanket_impl"`
+}
+
+func (i rustImpl) negative() bool  { return i.IsNegative || i.Negative }
+func (i rustImpl) synthetic() bool { return i.IsSynthetic || i.Synthetic }
+
+type rustTypeAlias struct {
+	Type     rustType     `json:"type"`
+	Generics rustGenerics `json:"generics"`
+}
+
+type rustConstant struct {
+	Type  rustType `json:"type"`
+	Const *struct {
+		Expr  string `json:"expr"`
+		Value string `json:"value"`
+	} `json:"const"`
+	Expr string `json:"expr"`
+}
+
+func (c rustConstant) expr() string {
+	if c.Const != nil {
+		return c.Const.Expr
+	}
+	return c.Expr
+}
+
+type rustStatic struct {
+	Type      rustType `json:"type"`
+	IsMutable bool     `json:"is_mutable"`
+	Mutable   bool     `json:"mutable"`
+	Expr      string   `json:"expr"`
+}
+
+type rustAssocConst struct {
+	Type    rustType `json:"type"`
+	Value   *string  `json:"value"`
+	Default *string  `json:"default"`
+}
+
+type rustAssocType struct {
+	Generics rustGenerics `json:"generics"`
+	Bounds   []rustBound  `json:"bounds"`
+	Type     *rustType    `json:"type"`
+	Default  *rustType    `json:"default"`
+}
+
+type rustProcMacro struct {
+	Kind    string   `json:"kind"`
+	Helpers []string `json:"helpers"`
+}
+
+type rustUse struct {
+	Source string     `json:"source"`
+	Name   string     `json:"name"`
+	ID     *rustdocID `json:"id"`
+	IsGlob bool       `json:"is_glob"`
+	Glob   bool       `json:"glob"`
+}
+
+func (u rustUse) glob() bool { return u.IsGlob || u.Glob }
+
+type rustGenerics struct {
+	Params          []rustGenericParam   `json:"params"`
+	WherePredicates []rustWherePredicate `json:"where_predicates"`
+}
+
+type rustGenericParam struct {
+	Name string `json:"name"`
+	Kind struct {
+		Lifetime *struct {
+			Outlives []string `json:"outlives"`
+		} `json:"lifetime"`
+		Type *struct {
+			Bounds      []rustBound `json:"bounds"`
+			Default     *rustType   `json:"default"`
+			IsSynthetic bool        `json:"is_synthetic"`
+			Synthetic   bool        `json:"synthetic"`
+		} `json:"type"`
+		Const *struct {
+			Type    rustType `json:"type"`
+			Default *string  `json:"default"`
+		} `json:"const"`
+	} `json:"kind"`
+}
+
+type rustWherePredicate struct {
+	BoundPredicate *struct {
+		Type          rustType           `json:"type"`
+		Bounds        []rustBound        `json:"bounds"`
+		GenericParams []rustGenericParam `json:"generic_params"`
+	} `json:"bound_predicate"`
+	LifetimePredicate *struct {
+		Lifetime string   `json:"lifetime"`
+		Outlives []string `json:"outlives"`
+	} `json:"lifetime_predicate"`
+	EqPredicate *struct {
+		LHS rustType `json:"lhs"`
+		RHS rustTerm `json:"rhs"`
+	} `json:"eq_predicate"`
+}
+
+type rustBound struct {
+	TraitBound *struct {
+		Trait         rustPath           `json:"trait"`
+		GenericParams []rustGenericParam `json:"generic_params"`
+		Modifier      string             `json:"modifier"`
+	} `json:"trait_bound"`
+	Outlives *string `json:"outlives"`
+}
+
+type rustPath struct {
+	Path string           `json:"path"`
+	Name string           `json:"name"`
+	ID   rustdocID        `json:"id"`
+	Args *rustGenericArgs `json:"args"`
+}
+
+type rustGenericArgs struct {
+	AngleBracketed *struct {
+		Args        []rustGenericArg      `json:"args"`
+		Constraints []rustAssocConstraint `json:"constraints"`
+		Bindings    []rustAssocConstraint `json:"bindings"`
+	} `json:"angle_bracketed"`
+	Parenthesized *struct {
+		Inputs []rustType `json:"inputs"`
+		Output *rustType  `json:"output"`
+	} `json:"parenthesized"`
+}
+
+func (a *rustGenericArgs) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		// "return_type_notation" and similar unit variants carry no arguments.
+		return nil
+	}
+	type plain rustGenericArgs
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+// rustGenericArg is {"lifetime": ..}, {"type": ..}, {"const": ..} or "infer".
+type rustGenericArg struct {
+	Lifetime *string   `json:"lifetime"`
+	Type     *rustType `json:"type"`
+	Const    *struct {
+		Expr string `json:"expr"`
+	} `json:"const"`
+	Infer bool `json:"-"`
+}
+
+func (a *rustGenericArg) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		a.Infer = true
+		return nil
+	}
+	type plain rustGenericArg
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+type rustAssocConstraint struct {
+	Name    string           `json:"name"`
+	Args    *rustGenericArgs `json:"args"`
+	Binding struct {
+		Equality   *rustTerm   `json:"equality"`
+		Constraint []rustBound `json:"constraint"`
+	} `json:"binding"`
+}
+
+type rustTerm struct {
+	Type     *rustType `json:"type"`
+	Constant *struct {
+		Expr string `json:"expr"`
+	} `json:"constant"`
+}
+
+// rustType is one variant of rustdoc's Type enum.
+type rustType struct {
+	ResolvedPath    *rustPath     `json:"resolved_path"`
+	DynTrait        *rustDynTrait `json:"dyn_trait"`
+	Generic         *string       `json:"generic"`
+	Primitive       *string       `json:"primitive"`
+	FunctionPointer *struct {
+		Sig           *rustFnSig         `json:"sig"`
+		Decl          *rustFnSig         `json:"decl"`
+		GenericParams []rustGenericParam `json:"generic_params"`
+		Header        rustHeader         `json:"header"`
+	} `json:"function_pointer"`
+	Tuple *[]rustType `json:"tuple"`
+	Slice *rustType   `json:"slice"`
+	Array *struct {
+		Type rustType `json:"type"`
+		Len  string   `json:"len"`
+	} `json:"array"`
+	Pat *struct {
+		Type rustType `json:"type"`
+	} `json:"pat"`
+	ImplTrait  *[]rustBound `json:"impl_trait"`
+	RawPointer *struct {
+		IsMutable bool     `json:"is_mutable"`
+		Mutable   bool     `json:"mutable"`
+		Type      rustType `json:"type"`
+	} `json:"raw_pointer"`
+	BorrowedRef *struct {
+		Lifetime  *string  `json:"lifetime"`
+		IsMutable bool     `json:"is_mutable"`
+		Mutable   bool     `json:"mutable"`
+		Type      rustType `json:"type"`
+	} `json:"borrowed_ref"`
+	QualifiedPath *struct {
+		Name     string           `json:"name"`
+		Args     *rustGenericArgs `json:"args"`
+		SelfType rustType         `json:"self_type"`
+		Trait    *rustPath        `json:"trait"`
+	} `json:"qualified_path"`
+	Infer bool `json:"-"`
+}
+
+func (t *rustType) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		t.Infer = true
+		return nil
+	}
+	type plain rustType
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+type rustDynTrait struct {
+	Traits []struct {
+		Trait         rustPath           `json:"trait"`
+		GenericParams []rustGenericParam `json:"generic_params"`
+	} `json:"traits"`
+	Lifetime *string `json:"lifetime"`
+}
+
+// parseRustdocJSON decodes a rustdoc JSON document.
+func parseRustdocJSON(data []byte) (*rustdocCrate, error) {
+	var krate rustdocCrate
+	if err := json.Unmarshal(data, &krate); err != nil {
+		return nil, err
+	}
+	if _, ok := krate.Index[krate.Root]; !ok {
+		return nil, fmt.Errorf("rustdoc json: root item %q not found in index", krate.Root)
+	}
+	return &krate, nil
+}
@@ -0,0 +1,254 @@
+package rust
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// rustdocFixture is a trimmed rustdoc JSON (format version 55) for:
+//
+//	//! A demo crate.
+//	pub use inner::deep;
+//	/// A point.
+//	#[derive(Clone)]
+//	pub struct Point<T: Copy> where T: Default { /// X coord.
+//	    pub x: T, y: T }
+//	impl<T: Copy + Default> Point<T> { /// Borrow x.
+//	    pub fn x(&self) -> &T { &self.x } }
+//	/// A trait.
+//	pub trait Area { /// Area.
+//	    fn area(&self) -> Self::Out; /// Name.
+//	    fn name(&self) -> String { String::new() } type Out; }
+//	impl Area for Point<u8> { .. }
+//	/// Sum things.
+//	pub fn sum<'a, I>(items: I) -> i64 where I: IntoIterator<Item = &'a i64> { 0 }
+//	pub mod inner { pub fn deep(f: impl Fn(u8) -> u8, p: *const u8) {} }
+const rustdocFixture = `{
+  "root": 0,
+  "crate_version": "0.1.0",
+  "format_version": 55,
+  "includes_private": false,
+  "paths": {},
+  "external_crates": {},
+  "index": {
+    "0": {"id": 0, "crate_id": 0, "name": "demo", "visibility": "public", "docs": "A demo crate.", "attrs": [],
+      "inner": {"module": {"is_crate": true, "items": [1, 7, 12, 16, 13], "is_stripped": false}}},
+    "1": {"id": 1, "crate_id": 0, "name": "Point", "visibility": "public", "docs": "A point.", "attrs": [],
+      "inner": {"struct": {
+        "kind": {"plain": {"fields": [2], "has_stripped_fields": true}},
+        "generics": {
+          "params": [{"name": "T", "kind": {"type": {"bounds": [], "default": null, "is_synthetic": false}}}],
+          "where_predicates": [{"bound_predicate": {"type": {"generic": "T"}, "generic_params": [], "bounds": [
+            {"trait_bound": {"trait": {"path": "Default", "id": 50, "args": null}, "generic_params": [], "modifier": "none"}},
+            {"trait_bound": {"trait": {"path": "Copy", "id": 51, "args": null}, "generic_params": [], "modifier": "none"}}]}}]
+        },
+        "impls": [3, 5, 6, 10]}}},
+    "2": {"id": 2, "crate_id": 0, "name": "x", "visibility": "public", "docs": "X coord.", "attrs": [],
+      "inner": {"struct_field": {"generic": "T"}}},
+    "3": {"id": 3, "crate_id": 0, "name": null, "visibility": "default", "docs": null, "attrs": [],
+      "inner": {"impl": {"is_unsafe": false, "provided_trait_methods": [], "trait": null,
+        "generics": {"params": [{"name": "T", "kind": {"type": {"bounds": [
+          {"trait_bound": {"trait": {"path": "Copy", "id": 51, "args": null}, "generic_params": [], "modifier": "none"}},
+          {"trait_bound": {"trait": {"path": "Default", "id": 50, "args": null}, "generic_params": [], "modifier": "none"}}],
+          "default": null, "is_synthetic": false}}}], "where_predicates": []},
+        "for": {"resolved_path": {"path": "Point", "id": 1, "args": {"angle_bracketed": {"args": [{"type": {"generic": "T"}}], "constraints": []}}}},
+        "items": [4], "is_negative": false, "is_synthetic": false, "blanket_impl": null}}},
+    "4": {"id": 4, "crate_id": 0, "name": "x", "visibility": "public", "docs": "Borrow x.", "attrs": [],
+      "inner": {"function": {
+        "sig": {"inputs": [["self", {"borrowed_ref": {"lifetime": null, "is_mutable": false, "type": {"generic": "Self"}}}]],
+          "output": {"borrowed_ref": {"lifetime": null, "is_mutable": false, "type": {"generic": "T"}}}, "is_c_variadic": false},
+        "generics": {"params": [], "where_predicates": []},
+        "header": {"is_const": false, "is_unsafe": false, "is_async": false, "abi": "Rust"}, "has_body": true}}},
+    "5": {"id": 5, "crate_id": 0, "name": null, "visibility": "default", "docs": null, "attrs": ["automatically_derived"],
+      "inner": {"impl": {"is_unsafe": false, "provided_trait_methods": ["clone_from"],
+        "generics": {"params": [{"name": "T", "kind": {"type": {"bounds": [], "default": null, "is_synthetic": false}}}],
+          "where_predicates": [{"bound_predicate": {"type": {"generic": "T"}, "generic_params": [], "bounds": [
+            {"trait_bound": {"trait": {"path": "$crate::clone::Clone", "id": 52, "args": null}, "generic_params": [], "modifier": "none"}}]}}]},
+        "trait": {"path": "Clone", "id": 52, "args": null},
+        "for": {"resolved_path": {"path": "Point", "id": 1, "args": {"angle_bracketed": {"args": [{"type": {"generic": "T"}}], "constraints": []}}}},
+        "items": [], "is_negative": false, "is_synthetic": false, "blanket_impl": null}}},
+    "6": {"id": 6, "crate_id": 0, "name": null, "visibility": "default", "docs": null, "attrs": [],
+      "inner": {"impl": {"is_unsafe": false, "provided_trait_methods": [],
+        "generics": {"params": [{"name": "T", "kind": {"type": {"bounds": [], "default": null, "is_synthetic": false}}}],
+          "where_predicates": [{"bound_predicate": {"type": {"generic": "T"}, "generic_params": [], "bounds": [
+            {"trait_bound": {"trait": {"path": "Send", "id": 53, "args": null}, "generic_params": [], "modifier": "none"}}]}}]},
+        "trait": {"path": "Send", "id": 53, "args": null},
+        "for": {"resolved_path": {"path": "Point", "id": 1, "args": {"angle_bracketed": {"args": [{"type": {"generic": "T"}}], "constraints": []}}}},
+        "items": [], "is_negative": false, "is_synthetic": true, "blanket_impl": null}}},
+    "7": {"id": 7, "crate_id": 0, "name": "Area", "visibility": "public", "docs": "A trait.", "attrs": [],
+      "inner": {"trait": {"is_auto": false, "is_unsafe": false, "is_dyn_compatible": false, "items": [8, 9, 11],
+        "generics": {"params": [], "where_predicates": []}, "bounds": [], "implementations": [10]}}},
+    "8": {"id": 8, "crate_id": 0, "name": "area", "visibility": "default", "docs": "Area.", "attrs": [],
+      "inner": {"function": {
+        "sig": {"inputs": [["self", {"borrowed_ref": {"lifetime": null, "is_mutable": false, "type": {"generic": "Self"}}}]],
+          "output": {"qualified_path": {"name": "Out", "args": null, "self_type": {"generic": "Self"}, "trait": {"path": "", "id": 7, "args": null}}},
+          "is_c_variadic": false},
+        "generics": {"params": [], "where_predicates": []},
+        "header": {"is_const": false, "is_unsafe": false, "is_async": false, "abi": "Rust"}, "has_body": false}}},
+    "9": {"id": 9, "crate_id": 0, "name": "name", "visibility": "default", "docs": "Name.\n\n# Example\n\n` + "```" + `\n# use demo::Area;\nlet n = p.name();\n` + "```" + `", "attrs": [],
+      "inner": {"function": {
+        "sig": {"inputs": [["self", {"borrowed_ref": {"lifetime": null, "is_mutable": false, "type": {"generic": "Self"}}}]],
+          "output": {"resolved_path": {"path": "String", "id": 54, "args": null}}, "is_c_variadic": false},
+        "generics": {"params": [], "where_predicates": []},
+        "header": {"is_const": false, "is_unsafe": false, "is_async": false, "abi": "Rust"}, "has_body": true}}},
+    "10": {"id": 10, "crate_id": 0, "name": null, "visibility": "default", "docs": null, "attrs": [],
+      "inner": {"impl": {"is_unsafe": false, "provided_trait_methods": ["name"],
+        "generics": {"params": [], "where_predicates": []},
+        "trait": {"path": "Area", "id": 7, "args": null},
+        "for": {"resolved_path": {"path": "Point", "id": 1, "args": {"angle_bracketed": {"args": [{"type": {"primitive": "u8"}}], "constraints": []}}}},
+        "items": [], "is_negative": false, "is_synthetic": false, "blanket_impl": null}}},
+    "11": {"id": 11, "crate_id": 0, "name": "Out", "visibility": "default", "docs": null, "attrs": [],
+      "inner": {"assoc_type": {"generics": {"params": [], "where_predicates": []}, "bounds": [], "type": null}}},
+    "12": {"id": 12, "crate_id": 0, "name": "sum", "visibility": "public", "docs": "Sum things.", "attrs": [],
+      "inner": {"function": {
+        "sig": {"inputs": [["items", {"generic": "I"}]], "output": {"primitive": "i64"}, "is_c_variadic": false},
+        "generics": {
+          "params": [{"name": "'a", "kind": {"lifetime": {"outlives": []}}},
+            {"name": "I", "kind": {"type": {"bounds": [], "default": null, "is_synthetic": false}}}],
+          "where_predicates": [{"bound_predicate": {"type": {"generic": "I"}, "generic_params": [], "bounds": [
+            {"trait_bound": {"trait": {"path": "IntoIterator", "id": 55, "args": {"angle_bracketed": {"args": [], "constraints": [
+              {"name": "Item", "args": null, "binding": {"equality": {"type": {"borrowed_ref": {"lifetime": "'a", "is_mutable": false, "type": {"primitive": "i64"}}}}}}]}}},
+             "generic_params": [], "modifier": "none"}}]}}]
+        },
+        "header": {"is_const": false, "is_unsafe": false, "is_async": false, "abi": "Rust"}, "has_body": true}}},
+    "13": {"id": 13, "crate_id": 0, "name": "inner", "visibility": "public", "docs": "Inner mod.", "attrs": [],
+      "inner": {"module": {"is_crate": false, "items": [14], "is_stripped": false}}},
+    "14": {"id": 14, "crate_id": 0, "name": "deep", "visibility": "public", "docs": "Deep fn.", "attrs": [],
+      "inner": {"function": {
+        "sig": {"inputs": [
+          ["f", {"impl_trait": [{"trait_bound": {"trait": {"path": "Fn", "id": 56, "args": {"parenthesized": {"inputs": [{"primitive": "u8"}], "output": {"primitive": "u8"}}}}, "generic_params": [], "modifier": "none"}}]}],
+          ["p", {"raw_pointer": {"is_mutable": false, "type": {"primitive": "u8"}}}]],
+          "output": null, "is_c_variadic": false},
+        "generics": {"params": [{"name": "impl Fn(u8) -> u8", "kind": {"type": {"bounds": [], "default": null, "is_synthetic": true}}}], "where_predicates": []},
+        "header": {"is_const": false, "is_unsafe": false, "is_async": false, "abi": "Rust"}, "has_body": true}}},
+    "16": {"id": 16, "crate_id": 0, "name": null, "visibility": "public", "docs": null, "attrs": [],
+      "inner": {"use": {"source": "inner::deep", "name": "deep", "id": 14, "is_glob": false}}}
+  }
+}`
+
+func parseFixtureCrate(t *testing.T) *rustdocCrate {
+	t.Helper()
+	krate, err := parseRustdocJSON([]byte(rustdocFixture))
+	if err != nil {
+		t.Fatalf("parseRustdocJSON: %v", err)
+	}
+	return krate
+}
+
+func TestRustdocSignatures(t *testing.T) {
+	krate := parseFixtureCrate(t)
+	r := &renderer{krate: krate}
+
+	tests := map[rustdocID]string{
+		"1":  "pub struct Point<T> where T: Default + Copy",
+		"4":  "pub fn x(&self) -> &T",
+		"7":  "pub trait Area",
+		"8":  "fn area(&self) -> Self::Out",
+		"12": "pub fn sum<'a, I>(items: I) -> i64 where I: IntoIterator<Item = &'a i64>",
+		"14": "pub fn deep(f: impl Fn(u8) -> u8, p: *const u8)",
+	}
+	for id, want := range tests {
+		if got := r.signature(krate.Index[id]); got != want {
+			t.Errorf("signature(%s) = %q, want %q", krate.Index[id].Name, got, want)
+		}
+	}
+
+	wantDecl := "pub struct Point<T>\nwhere\n    T: Default + Copy,\n{\n    pub x: T,\n    /* private fields */\n}"
+	if got := r.declaration(krate.Index["1"]); got != wantDecl {
+		t.Errorf("Point declaration =\n%s\nwant\n%s", got, wantDecl)
+	}
+}
+
+func TestRustdocMarkdown(t *testing.T) {
+	krate := parseFixtureCrate(t)
+	r := &renderer{krate: krate}
+
+	point := r.markdown(krate.Index["1"], "Struct", "demo::Point", availability{})
+	for _, want := range []string{
+		"# Struct demo::Point",
+		"## Fields\n\n### `x: T`\n\nX coord.",
+		"## Implementations\n\n### `impl<T: Copy + Default> Point<T>`\n\n#### `pub fn x(&self) -> &T`\n\nBorrow x.",
+		"## Implements\n\n- `impl Area for Point<u8>`\n- `impl<T> Clone for Point<T> where T: clone::Clone`",
+		"## Auto Trait Implementations\n\n- `impl<T> Send for Point<T> where T: Send`",
+	} {
+		if !strings.Contains(point, want) {
+			t.Errorf("Point markdown missing %q:\n%s", want, point)
+		}
+	}
+
+	area := r.markdown(krate.Index["7"], "Trait", "demo::Area", availability{})
+	for _, want := range []string{
+		"    fn area(&self) -> Self::Out;\n    fn name(&self) -> String { ... }\n    type Out;\n}",
+		"## Required Methods\n\n### `fn area(&self) -> Self::Out`",
+		"## Provided Methods\n\n### `fn name(&self) -> String`\n\nName.\n\n### Example\n\n```rust\nlet n = p.name();\n```",
+		"## Implemented By\n\n- `impl Area for Point<u8>`",
+	} {
+		if !strings.Contains(area, want) {
+			t.Errorf("Area markdown missing %q:\n%s", want, area)
+		}
+	}
+}
+
+func TestRustdocAssociatedItems(t *testing.T) {
+	krate := parseFixtureCrate(t)
+	r := &renderer{krate: krate}
+
+	tests := []struct {
+		id   rustdocID
+		want []memberEntry
+	}{
+		{"1", []memberEntry{{Name: "x", Type: "Method", Signature: "pub fn x(&self) -> &T", Summary: "Borrow x."}}},
+		{"7", []memberEntry{
+			{Name: "area", Type: "Method", Signature: "fn area(&self) -> Self::Out", Summary: "Area."},
+			{Name: "name", Type: "Method", Signature: "fn name(&self) -> String", Summary: "Name."},
+			{Name: "Out", Type: "AssocType", Signature: "type Out"},
+		}},
+		{"12", nil},
+	}
+	for _, tt := range tests {
+		if got := r.members(krate.Index[tt.id]); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("members(%s) = %+v, want %+v", krate.Index[tt.id].Name, got, tt.want)
+		}
+	}
+}
+
+func TestRustdocModuleMembers(t *testing.T) {
+	krate := parseFixtureCrate(t)
+	in := &jsonIngester{r: &renderer{krate: krate}, rootName: "demo"}
+
+	var root rustModule
+	if err := krate.Index["0"].decode(&root); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range in.members(root.Items, nil, availability{}, map[rustdocID]bool{}) {
+		got = append(got, in.fullName(m.modPath, m.name))
+	}
+	want := []string{"demo::Point", "demo::Area", "demo::sum", "demo::deep", "demo::inner"}
+	if !slices.Equal(got, want) {
+		t.Errorf("members = %v, want %v", got, want)
+	}
+}
+
+func TestItemDeclaration(t *testing.T) {
+	tests := map[string]string{
+		`<main><pre class="rust item-decl"><code>pub fn parse(s: &amp;str) -&gt; Result&lt;Version, Error&gt;</code></pre></main>`: "pub fn parse(s: &str) -> Result<Version, Error>",
+		`<main><pre class="rust item-decl"><code>pub struct Version {
+    pub major: u64,
+}</code></pre></main>`: "pub struct Version",
+		`<main><h1>Module semver</h1></main>`: "",
+	}
+	for html, want := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := itemDeclaration(doc); got != want {
+			t.Errorf("itemDeclaration() = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,473 @@
+package zig
+
+import (
+	"regexp"
+	"strings"
+)
+
+// file is the API read from one .zig file, which is a struct.
+type file struct {
+	Doc   string // its //! comments, which are Markdown
+	Decls []*decl
+}
+
+// decl is a public declaration of a container, or a field or value of one.
+type decl struct {
+	// Name is qualified by the containers around it within the file:
+	// Server.Options.port.
+	Name      string
+	Kind      string
+	Signature string
+	Doc       string // Markdown
+	Members   []*decl
+}
+
+// item is a declaration, field or value of a container body, with the ///
+// comments before it.
+type item struct {
+	doc  []string
+	text string // without comments
+	body string // the block of a fn, test or comptime
+}
+
+// typeKinds are the kinds of decl listed as types of a file rather than as
+// members of one.
+var typeKinds = map[string]bool{"Struct": true, "Enum": true, "Union": true, "Opaque": true, "ErrorSet": true}
+
+// parseFile reads the public declarations of a .zig file and their doc
+// comments.
+func parseFile(src string) *file {
+	items, doc := scanItems(src)
+	f := &file{Doc: strings.Join(doc, "\n")}
+	p := &parser{f: f}
+	p.container(items, "", "struct", nil)
+	return f
+}
+
+type parser struct {
+	f *file
+}
+
+var (
+	declHead      = regexp.MustCompile(`^(?:pub\s+)?(?:(?:extern(?:\s+"[^"]*")?|export|inline|noinline|threadlocal)\s+)*(fn|const|var|usingnamespace|test|comptime)\b`)
+	nameRe        = regexp.MustCompile(`^(@"[^"]*"|[A-Za-z_][\w]*)`)
+	containerInit = regexp.MustCompile(`^((?:extern|packed)\s+)?(struct|enum|union|opaque|error)\s*(\([^)]*\))?\s*\{`)
+	fieldRe       = regexp.MustCompile(`^(?:comptime\s+)?(@"[^"]*"|[A-Za-z_]\w*)\s*:`)
+	valueRe       = regexp.MustCompile(`^(@"[^"]*"|[A-Za-z_]\w*)\s*(=|$)`)
+	typeReturn    = regexp.MustCompile(`\breturn\s+((?:extern|packed)\s+)?(struct|enum|union|opaque)\s*(\([^)]*\))?\s*\{`)
+)
+
+var containerKinds = map[string]string{
+	"struct": "Struct", "enum": "Enum", "union": "Union", "opaque": "Opaque", "error": "ErrorSet",
+}
+
+// container reads the items of a container body. Its public declarations
+// are added to the file, types among them to its list of types and others
+// to owner, and its fields or values to owner.
+func (p *parser) container(items []*item, prefix, keyword string, owner *decl) {
+	for _, it := range items {
+		m := declHead.FindStringSubmatchIndex(it.text)
+		if m == nil {
+			if owner != nil {
+				p.field(it, prefix, keyword, owner)
+			}
+			continue
+		}
+		if !strings.HasPrefix(it.text, "pub") {
+			continue
+		}
+		rest := strings.TrimSpace(it.text[m[1]:])
+		switch it.text[m[2]:m[3]] {
+		case "fn":
+			p.function(it, rest, prefix, owner)
+		case "const", "var":
+			p.variable(it, it.text[m[2]:m[3]], rest, prefix, owner)
+		}
+	}
+}
+
+// field reads a field of a struct or union, or a value of an enum.
+func (p *parser) field(it *item, prefix, keyword string, owner *decl) {
+	kind := "Field"
+	m := fieldRe.FindStringSubmatch(it.text)
+	if keyword == "enum" || keyword == "error" {
+		m, kind = valueRe.FindStringSubmatch(it.text), "Value"
+	} else if m == nil {
+		// A union field of type void.
+		m = valueRe.FindStringSubmatch(it.text)
+	}
+	if m == nil || m[1] == "_" {
+		// _ marks a non-exhaustive enum rather than naming a value.
+		return
+	}
+	owner.Members = append(owner.Members, &decl{
+		Name:      prefix + m[1],
+		Kind:      kind,
+		Signature: oneLine(it.text),
+		Doc:       docText(it.doc),
+	})
+}
+
+// function reads a fn declaration. A function returning a type, as generic
+// types are declared, has the public members of the container it returns.
+func (p *parser) function(it *item, rest, prefix string, owner *decl) {
+	name := nameRe.FindString(rest)
+	if name == "" {
+		return
+	}
+	d := &decl{Name: prefix + name, Kind: "Function", Signature: formatSignature(it.text), Doc: docText(it.doc)}
+	p.add(d, owner)
+
+	if !strings.HasSuffix(strings.TrimSpace(it.text), " type") {
+		return
+	}
+	body := removeComments(it.body)
+	if m := typeReturn.FindStringSubmatchIndex(body); m != nil {
+		end := matchBrace(body, m[1]-1)
+		items, _ := scanItems(body[m[1] : end-1])
+		p.container(items, d.Name+".", body[m[4]:m[5]], d)
+	}
+}
+
+// variable reads a const or var declaration: a container type, an error
+// set, or a value.
+func (p *parser) variable(it *item, keyword, rest, prefix string, owner *decl) {
+	name := nameRe.FindString(rest)
+	if name == "" {
+		return
+	}
+	d := &decl{Name: prefix + name, Kind: "Constant", Doc: docText(it.doc)}
+	if keyword == "var" {
+		d.Kind = "Variable"
+	}
+
+	init := ""
+	if i := assignIndex(it.text); i >= 0 {
+		init = strings.TrimSpace(it.text[i+1:])
+	}
+	if len(it.doc) == 0 && (init == "@This()" || builtinImport.MatchString(init)) {
+		return
+	}
+
+	if m := containerInit.FindStringSubmatchIndex(init); m != nil && keyword == "const" {
+		kw := init[m[4]:m[5]]
+		end := matchBrace(init, m[1]-1)
+		items, _ := scanItems(init[m[1] : end-1])
+		d.Kind = containerKinds[kw]
+		if kw == "error" {
+			// An error set holds nothing but its names, so it is shown as
+			// written.
+			d.Signature = formatSignature(it.text)
+		} else {
+			header := strings.TrimSpace(it.text[:len(it.text)-len(init)]) + " " + oneLine(init[:m[1]-1])
+			d.Signature = definition(header, items)
+		}
+		p.add(d, owner)
+		p.container(items, d.Name+".", kw, d)
+		return
+	}
+
+	sig := it.text
+	if strings.Contains(init, "\n") || len(oneLine(sig)) > 100 {
+		sig = strings.TrimSpace(it.text[:assignIndex(it.text)])
+	}
+	d.Signature = formatSignature(sig)
+	p.add(d, owner)
+}
+
+var builtinImport = regexp.MustCompile(`^@import\("(std|builtin|root)"\)$`)
+
+// add records a declaration: types in the file's list of types, others in
+// their owner.
+func (p *parser) add(d *decl, owner *decl) {
+	if owner != nil && !typeKinds[d.Kind] {
+		owner.Members = append(owner.Members, d)
+		return
+	}
+	p.f.Decls = append(p.f.Decls, d)
+}
+
+// definition renders a container with its fields or values, without their
+// doc comments or default values that span lines, and without its
+// declarations.
+func definition(header string, items []*item) string {
+	var fields []string
+	for _, it := range items {
+		if declHead.MatchString(it.text) {
+			continue
+		}
+		text := it.text
+		if strings.Contains(text, "\n") {
+			if i := assignIndex(text); i >= 0 {
+				text = strings.TrimSpace(text[:i])
+			}
+		}
+		fields = append(fields, oneLine(text))
+	}
+	if len(fields) == 0 {
+		return header + " {}"
+	}
+	return header + " {\n    " + strings.Join(fields, ",\n    ") + ",\n}"
+}
+
+// scanItems splits a container body into items, ending each at a semicolon
+// or comma, or for a fn, test or comptime block at its closing brace.
+// Strings and comments are skipped over; the //! comments are returned as
+// the container's doc.
+func scanItems(src string) (items []*item, containerDoc []string) {
+	var doc []string
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			end := lineEnd(src, i)
+			line := src[i:end]
+			switch {
+			case strings.HasPrefix(line, "//!"):
+				containerDoc = append(containerDoc, docLine(line))
+			case strings.HasPrefix(line, "///") && !strings.HasPrefix(line, "////"):
+				doc = append(doc, docLine(line))
+			}
+			i = end
+		case ch == ',' || ch == ';':
+			i++
+		default:
+			it, end := scanItem(src, i)
+			it.doc, doc = doc, nil
+			if it.text != "" {
+				items = append(items, it)
+			}
+			i = end
+		}
+	}
+	return items, containerDoc
+}
+
+// scanItem reads the item starting at i and returns it with the index after
+// it.
+func scanItem(src string, i int) (*item, int) {
+	it := &item{}
+	var (
+		b      strings.Builder
+		depth  int
+		assign bool
+	)
+	for j := i; j < len(src); {
+		ch := src[j]
+		switch {
+		case ch == '"' || ch == '\'':
+			end := skipLiteral(src, j)
+			b.WriteString(src[j:end])
+			j = end
+			continue
+		case strings.HasPrefix(src[j:], `\\`):
+			end := lineEnd(src, j)
+			b.WriteString(src[j:end])
+			j = end
+			continue
+		case strings.HasPrefix(src[j:], "//"):
+			j = lineEnd(src, j)
+			continue
+		case ch == '(' || ch == '[':
+			depth++
+		case ch == ')' || ch == ']':
+			depth--
+		case depth > 0:
+		case ch == ';' || ch == ',':
+			it.text = strings.TrimSpace(b.String())
+			return it, j + 1
+		case ch == '=' && isAssign(src, j):
+			assign = true
+		case ch == '{':
+			end := matchBrace(src, j)
+			if assign || isTypeBrace(b.String()) {
+				b.WriteString(src[j:end])
+				j = end
+				continue
+			}
+			it.text = strings.TrimSpace(b.String())
+			it.body = src[j+1 : max(j+1, end-1)]
+			return it, end
+		}
+		b.WriteByte(ch)
+		j++
+	}
+	it.text = strings.TrimSpace(b.String())
+	return it, len(src)
+}
+
+var typeBrace = regexp.MustCompile(`\b(struct|enum|union|opaque|error)\s*(\([^()]*(\([^()]*\))?[^()]*\))?\s*$`)
+
+// isTypeBrace reports whether a brace after text opens a container type,
+// as in a return type of error{Overflow}!u32, rather than a block.
+func isTypeBrace(text string) bool {
+	return typeBrace.MatchString(text)
+}
+
+// isAssign reports whether the = at i assigns rather than compares or
+// starts =>.
+func isAssign(src string, i int) bool {
+	if i+1 < len(src) && (src[i+1] == '=' || src[i+1] == '>') {
+		return false
+	}
+	return i == 0 || !strings.ContainsRune("=!<>+-*/%&|^", rune(src[i-1]))
+}
+
+// assignIndex returns the index of the = starting the initializer of a
+// declaration, or -1.
+func assignIndex(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '"', '\'':
+			i = skipLiteral(text, i) - 1
+		case '=':
+			if depth == 0 && isAssign(text, i) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// matchBrace returns the index after the brace closing the one at i.
+func matchBrace(src string, i int) int {
+	depth := 0
+	for j := i; j < len(src); j++ {
+		switch {
+		case src[j] == '"' || src[j] == '\'':
+			j = skipLiteral(src, j) - 1
+		case strings.HasPrefix(src[j:], "//"), strings.HasPrefix(src[j:], `\\`):
+			j = lineEnd(src, j) - 1
+		case src[j] == '{':
+			depth++
+		case src[j] == '}':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(src)
+}
+
+// skipLiteral returns the index after the string or character literal at i.
+func skipLiteral(src string, i int) int {
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		case '\n':
+			return j
+		}
+	}
+	return len(src)
+}
+
+func lineEnd(src string, i int) int {
+	if end := strings.IndexByte(src[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(src)
+}
+
+// removeComments blanks the comments of a source, keeping its strings.
+func removeComments(src string) string {
+	var b strings.Builder
+	for i := 0; i < len(src); {
+		switch {
+		case src[i] == '"' || src[i] == '\'':
+			end := skipLiteral(src, i)
+			b.WriteString(src[i:end])
+			i = end
+		case strings.HasPrefix(src[i:], `\\`):
+			end := lineEnd(src, i)
+			b.WriteString(src[i:end])
+			i = end
+		case strings.HasPrefix(src[i:], "//"):
+			i = lineEnd(src, i)
+		default:
+			b.WriteByte(src[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// docLine returns the text of a /// or //! line.
+func docLine(line string) string {
+	return strings.TrimRight(strings.TrimPrefix(line[3:], " "), " \t\r")
+}
+
+func docText(lines []string) string {
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// formatSignature renders a declaration on one line, or with its line breaks
+// when it is longer than 100 characters.
+func formatSignature(s string) string {
+	s = strings.TrimSpace(s)
+	if line := signatureSpace.Replace(oneLine(s)); len(line) <= 100 {
+		return line
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	if len(lines) > 1 {
+		lines = append(lines[:1], dedent(lines[1:])...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dedent removes the indentation lines have in common.
+func dedent(lines []string) []string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimPrefix(line, prefix)
+	}
+	return out
+}
+
+var signatureSpace = strings.NewReplacer("( ", "(", " )", ")", ", )", ")")
+
+// summary returns the first sentence of a doc comment's first paragraph.
+func summary(doc string) string {
+	para, _, _ := strings.Cut(doc, "\n\n")
+	if strings.HasPrefix(para, "```") {
+		return ""
+	}
+	para = oneLine(para)
+	if i := strings.Index(para, ". "); i >= 0 {
+		return para[:i+1]
+	}
+	return para
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
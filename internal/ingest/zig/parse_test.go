@@ -0,0 +1,203 @@
+package zig
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const zigFixture = `//! An HTTP server.
+//!
+//! Serves requests on a single thread.
+
+const std = @import("std");
+const Allocator = std.mem.Allocator;
+
+pub const http = @import("http.zig");
+
+/// The default port.
+pub const default_port: u16 = 8080;
+
+/// Requests served so far.
+pub var requests: usize = 0;
+
+const internal_limit = 4;
+
+/// Errors a server returns.
+pub const Error = error{AddressInUse,OutOfMemory};
+
+/// A listening server.
+pub const Server = struct {
+    /// The address it listens on.
+    address: []const u8,
+    port: u16 = default_port, // not a doc comment
+    handlers: std.ArrayList(Handler) = .{},
+
+    const Self = @This();
+
+    /// Options for init.
+    pub const Options = struct {
+        /// Threads to serve with.
+        threads: u8 = 1,
+    };
+
+    /// Creates a server.
+    ///
+    /// Call deinit to free it.
+    pub fn init(allocator: Allocator, options: Options) Error!Self {
+        _ = allocator;
+        if (options.threads == 0) return error.OutOfMemory;
+        return .{ .address = "0.0.0.0" };
+    }
+
+    /// Accepts a connection.
+    pub fn accept(self: *Self) error{ConnectionReset}!void {
+        _ = self;
+    }
+
+    fn helper() void {}
+
+    pub fn deinit(self: *Self) void {
+        const s = "}; not the end";
+        _ = s;
+        _ = self;
+    }
+};
+
+/// HTTP methods.
+pub const Method = enum(u8) {
+    get,
+    /// Posts a body.
+    post = 2,
+    _,
+
+    /// Returns whether the method has a body.
+    pub fn hasBody(m: Method) bool {
+        return m == .post;
+    }
+};
+
+/// A parsed value.
+pub const Value = union(enum) {
+    none,
+    int: i64,
+    text: []const u8,
+};
+
+/// A handle to the C library.
+pub const Handle = opaque {};
+
+/// A fixed-size queue.
+pub fn Queue(comptime T: type, comptime size: usize) type {
+    return struct {
+        items: [size]T = undefined,
+        len: usize = 0,
+
+        /// Adds an item.
+        pub fn push(self: *@This(), item: T) void {
+            self.items[self.len] = item;
+            self.len += 1;
+        }
+    };
+}
+
+/// Writes a message to standard error, formatted with the arguments.
+pub fn print(
+    comptime format: []const u8,
+    args: anytype,
+    writer: anytype,
+    options: struct { newline: bool = true },
+) !void {
+    const banner =
+        \\ a "multiline" string }
+    ;
+    _ = banner;
+}
+
+pub extern "c" fn write(fd: c_int, buf: [*]const u8, len: usize) isize;
+
+test "server starts" {
+    try std.testing.expect(true);
+}
+`
+
+// decls lists declarations and their members as "Kind Name: Signature",
+// with signatures on one line.
+func decls(ds []*decl) []string {
+	var out []string
+	for _, d := range ds {
+		out = append(out, d.Kind+" "+d.Name+": "+oneLine(d.Signature))
+		out = append(out, decls(d.Members)...)
+	}
+	return out
+}
+
+func TestParseFile(t *testing.T) {
+	f := parseFile(zigFixture)
+	if f.Doc != "An HTTP server.\n\nServes requests on a single thread." {
+		t.Errorf("Doc = %q", f.Doc)
+	}
+
+	want := []string{
+		`Constant http: pub const http = @import("http.zig")`,
+		"Constant default_port: pub const default_port: u16 = 8080",
+		"Variable requests: pub var requests: usize = 0",
+		"ErrorSet Error: pub const Error = error{AddressInUse,OutOfMemory}",
+		"Value Error.AddressInUse: AddressInUse",
+		"Value Error.OutOfMemory: OutOfMemory",
+		"Struct Server: pub const Server = struct { address: []const u8, port: u16 = default_port, handlers: std.ArrayList(Handler) = .{}, }",
+		"Field Server.address: address: []const u8",
+		"Field Server.port: port: u16 = default_port",
+		"Field Server.handlers: handlers: std.ArrayList(Handler) = .{}",
+		"Function Server.init: pub fn init(allocator: Allocator, options: Options) Error!Self",
+		"Function Server.accept: pub fn accept(self: *Self) error{ConnectionReset}!void",
+		"Function Server.deinit: pub fn deinit(self: *Self) void",
+		"Struct Server.Options: pub const Options = struct { threads: u8 = 1, }",
+		"Field Server.Options.threads: threads: u8 = 1",
+		"Enum Method: pub const Method = enum(u8) { get, post = 2, _, }",
+		"Value Method.get: get",
+		"Value Method.post: post = 2",
+		"Function Method.hasBody: pub fn hasBody(m: Method) bool",
+		"Union Value: pub const Value = union(enum) { none, int: i64, text: []const u8, }",
+		"Field Value.none: none",
+		"Field Value.int: int: i64",
+		"Field Value.text: text: []const u8",
+		"Opaque Handle: pub const Handle = opaque {}",
+		"Function Queue: pub fn Queue(comptime T: type, comptime size: usize) type",
+		"Field Queue.items: items: [size]T = undefined",
+		"Field Queue.len: len: usize = 0",
+		"Function Queue.push: pub fn push(self: *@This(), item: T) void",
+		"Function print: pub fn print( comptime format: []const u8, args: anytype, writer: anytype, options: struct { newline: bool = true }, ) !void",
+		`Function write: pub extern "c" fn write(fd: c_int, buf: [*]const u8, len: usize) isize`,
+	}
+	if got := decls(f.Decls); !slices.Equal(got, want) {
+		t.Errorf("decls =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	server := f.Decls[4]
+	if server.Members[0].Doc != "The address it listens on." || server.Members[1].Doc != "" {
+		t.Errorf("field docs = %q, %q", server.Members[0].Doc, server.Members[1].Doc)
+	}
+	if init := server.Members[3]; init.Doc != "Creates a server.\n\nCall deinit to free it." || summary(init.Doc) != "Creates a server." {
+		t.Errorf("init doc = %q", init.Doc)
+	}
+	if sig := f.Decls[len(f.Decls)-2].Signature; !strings.HasPrefix(sig, "pub fn print(\n    comptime format: []const u8,\n") || !strings.HasSuffix(sig, "\n) !void") {
+		t.Errorf("print signature = %q", sig)
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	tests := []struct{ pkg, path, want string }{
+		{"zap", "src/zap.zig", "zap"},
+		{"zap", "src/root.zig", "zap"},
+		{"zap", "main.zig", "zap"},
+		{"zap", "src/http/server.zig", "zap.http.server"},
+		{"std", "array_list.zig", "std.array_list"},
+		{"std", "std.zig", "std"},
+	}
+	for _, tt := range tests {
+		if got := ModuleName(tt.pkg, tt.path); got != tt.want {
+			t.Errorf("ModuleName(%q, %q) = %q, want %q", tt.pkg, tt.path, got, tt.want)
+		}
+	}
+}
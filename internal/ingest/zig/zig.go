@@ -0,0 +1,329 @@
+// Package zig ingests the API documentation of Zig packages from their
+// sources: the public declarations of .zig files and their doc comments.
+package zig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/stormlightlabs/documango/internal/cache"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/docset"
+	"github.com/stormlightlabs/documango/internal/ingest/git"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+type Options struct {
+	// Source is a local directory or anything git clone accepts.
+	Source string
+	// Ref is a branch, tag or commit of a repository; a local directory is
+	// read in place without one.
+	Ref string
+	// Subdir limits ingestion to a directory of the source.
+	Subdir string
+	// Name names the package in document paths and symbols; the base name
+	// of the directory or repository when empty.
+	Name string
+	// Include and Exclude select files by path glob relative to Subdir (see
+	// docset.Filter); a nil Exclude uses DefaultExclude.
+	Include []string
+	Exclude []string
+	DB      *db.Store
+	Cache   *cache.FilesystemCache
+}
+
+// DefaultExclude leaves out build scripts, build output and caches, tests
+// and examples.
+var DefaultExclude = append(append([]string{}, docset.DefaultExclude...),
+	"build.zig", "zig-cache", ".zig-cache", "zig-out", "test", "tests", "example", "examples")
+
+// module is a documented .zig file.
+type module struct {
+	Path string // slash-separated, relative to the source root
+	Name string // the module path, as in std.array_list
+	*file
+}
+
+// IngestSource reads the .zig files of a local directory or repository and
+// stores a document per file under zig/<name>/<path>, with its public
+// declarations as symbols qualified by the file's module path.
+func IngestSource(ctx context.Context, opts Options) error {
+	if opts.Source == "" {
+		return errors.New("source directory or repository is required")
+	}
+	if opts.DB == nil {
+		return errors.New("db store is required")
+	}
+
+	dir, name, cleanup, err := git.OpenTree(ctx, opts.Source, opts.Ref, opts.Cache)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if opts.Name != "" {
+		name = opts.Name
+	}
+
+	subdir := path.Clean("/" + filepath.ToSlash(opts.Subdir))[1:]
+	root := filepath.Join(dir, filepath.FromSlash(subdir))
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("subdirectory %q not found in %s", opts.Subdir, opts.Source)
+	}
+
+	exclude := opts.Exclude
+	if exclude == nil {
+		exclude = DefaultExclude
+	}
+	files, err := walkSources(root, docset.NewFilter(opts.Include, exclude))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no .zig files found in %s", opts.Source)
+	}
+
+	log.Info("zig source ingest starting", "package", name, "files", len(files))
+
+	var modules []*module
+	for _, p := range files {
+		src, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			log.Warn("failed to read file", "path", p, "err", err)
+			continue
+		}
+		f := parseFile(shared.NormalizeLineEndings(string(src)))
+		if len(f.Decls) > 0 || f.Doc != "" {
+			modules = append(modules, &module{Path: p, Name: ModuleName(name, p), file: f})
+		}
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no public declarations found in %s", opts.Source)
+	}
+
+	return opts.DB.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := ingestModules(ctx, tx, name, modules); err != nil {
+			return err
+		}
+		log.Info("zig source ingested", "package", name, "modules", len(modules))
+		return nil
+	})
+}
+
+// walkSources lists the .zig files below root that pass the filter, as
+// slash-separated paths relative to root.
+func walkSources(root string, filter docset.Filter) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || filter.SkipDir(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(rel) == ".zig" && filter.Match(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// ModuleName returns the module path of a file of a package: the package
+// name for its root file (<name>.zig, root.zig, lib.zig or main.zig, at the
+// top or under src/), else the package name and the file's path without
+// src/ and .zig, as in std.array_list or zap.http.server.
+func ModuleName(pkg, p string) string {
+	p = strings.TrimSuffix(strings.TrimPrefix(p, "src/"), ".zig")
+	switch p {
+	case pkg, "root", "lib", "main":
+		return pkg
+	}
+	return pkg + "." + strings.ReplaceAll(p, "/", ".")
+}
+
+// DocPath returns the document path of a file of a package.
+func DocPath(pkg, p string) string {
+	return "zig/" + pkg + "/" + p
+}
+
+func ingestModules(ctx context.Context, tx *sql.Tx, name string, modules []*module) error {
+	prefix := "zig/" + name + "/"
+	old, err := db.DocumentHashesTx(ctx, tx, prefix)
+	if err != nil {
+		return err
+	}
+	for p := range old {
+		if err := db.DeleteDocumentTx(ctx, tx, p); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range modules {
+		if err := writeModule(ctx, tx, DocPath(name, m.Path), m); err != nil {
+			return err
+		}
+	}
+
+	title, md := renderIndex(name, modules)
+	_, err = docset.WriteDocument(ctx, tx, prefix+"index", title, md)
+	return err
+}
+
+// moduleSections orders the declaration sections of a module document.
+var moduleSections = []struct {
+	title string
+	kinds map[string]bool
+}{
+	{"Types", typeKinds},
+	{"Functions", map[string]bool{"Function": true}},
+	{"Constants", map[string]bool{"Constant": true}},
+	{"Variables", map[string]bool{"Variable": true}},
+}
+
+func renderModule(m *module) string {
+	var b strings.Builder
+	b.WriteString("# " + m.Name + "\n\n")
+	b.WriteString("Source: `" + m.Path + "`\n\n")
+	if m.Doc != "" {
+		b.WriteString(m.Doc + "\n\n")
+	}
+
+	for _, section := range moduleSections {
+		first := true
+		for _, d := range m.Decls {
+			if !section.kinds[d.Kind] {
+				continue
+			}
+			if first {
+				b.WriteString("## " + section.title + "\n\n")
+				first = false
+			}
+			writeDecl(&b, "###", d)
+			writeMembers(&b, d)
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func writeDecl(b *strings.Builder, heading string, d *decl) {
+	b.WriteString(heading + " " + d.Name + "\n\n")
+	b.WriteString("```zig\n" + d.Signature + "\n```\n\n")
+	if d.Doc != "" {
+		b.WriteString(d.Doc + "\n\n")
+	}
+}
+
+// writeMembers renders the fields or values of a type as a list, which its
+// definition shows, and its functions and constants under their own
+// headings.
+func writeMembers(b *strings.Builder, d *decl) {
+	var fields, decls []*decl
+	for _, m := range d.Members {
+		if m.Kind == "Field" || m.Kind == "Value" {
+			fields = append(fields, m)
+		} else {
+			decls = append(decls, m)
+		}
+	}
+	if len(fields) > 0 {
+		if fields[0].Kind == "Value" {
+			b.WriteString("**Values**\n\n")
+		} else {
+			b.WriteString("**Fields**\n\n")
+		}
+		for _, f := range fields {
+			line := "- `" + f.Signature + "`"
+			if doc := oneLine(f.Doc); doc != "" {
+				line += ": " + doc
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+	for _, m := range decls {
+		writeDecl(b, "####", m)
+	}
+}
+
+// renderIndex lists the modules of a package with their summaries.
+func renderIndex(name string, modules []*module) (title, md string) {
+	var b strings.Builder
+	b.WriteString("# " + name + "\n\n## Modules\n\n")
+	for _, m := range modules {
+		line := "- `" + m.Name + "` (`" + m.Path + "`)"
+		if s := summary(m.Doc); s != "" {
+			line += ": " + s
+		}
+		b.WriteString(line + "\n")
+	}
+	return name, b.String()
+}
+
+func writeModule(ctx context.Context, tx *sql.Tx, docPath string, m *module) error {
+	md := renderModule(m)
+	docID, err := db.InsertDocumentTx(ctx, tx, db.Document{
+		Path:   docPath,
+		Format: "markdown",
+		Body:   shared.Compress(md),
+		Hash:   db.HashBytes([]byte(md)),
+	})
+	if err != nil {
+		return err
+	}
+
+	insert := func(symbol, kind, signature, doc string) error {
+		if err := db.InsertSearchEntryTx(ctx, tx, db.SearchEntry{
+			Name:  symbol,
+			Type:  kind,
+			Body:  symbol + " " + signature + " " + doc,
+			DocID: docID,
+		}); err != nil {
+			return err
+		}
+		return db.InsertAgentContextTx(ctx, tx, db.AgentContext{
+			DocID:     docID,
+			Symbol:    symbol,
+			Signature: signature,
+			Summary:   summary(doc),
+		})
+	}
+	// Only a package's root module is imported by its name.
+	imp := m.Path
+	if !strings.Contains(m.Name, ".") {
+		imp = m.Name
+	}
+	if err := insert(m.Name, "Module", `@import("`+imp+`")`, m.Doc); err != nil {
+		return err
+	}
+
+	var walk func(ds []*decl) error
+	walk = func(ds []*decl) error {
+		for _, d := range ds {
+			if err := insert(m.Name+"."+d.Name, d.Kind, d.Signature, d.Doc); err != nil {
+				return err
+			}
+			if err := walk(d.Members); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(m.Decls)
+}
@@ -0,0 +1,122 @@
+package zig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/codec"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func TestIngestSource(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"src/root.zig":        "//! A small web framework.\n\npub const Server = @import(\"http/server.zig\").Server;\n",
+		"src/http/server.zig": zigFixture,
+		"src/internal.zig":    "const std = @import(\"std\");\nfn helper() void {}\n",
+		"build.zig":           "/// Builds the package.\npub fn build(b: *std.Build) void {}\n",
+		"tests/server.zig":    "/// A test fixture.\npub const fixture = 1;\n",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := IngestSource(ctx, Options{Source: root, Name: "zap", DB: store}); err != nil {
+		t.Fatal(err)
+	}
+
+	readDoc := func(p string) string {
+		t.Helper()
+		doc, err := store.ReadDocument(ctx, p)
+		if err != nil {
+			t.Fatalf("ReadDocument(%s): %v", p, err)
+		}
+		raw, err := codec.Decompress(doc.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	index := readDoc("zig/zap/index")
+	for _, want := range []string{
+		"# zap",
+		"- `zap` (`src/root.zig`): A small web framework.",
+		"- `zap.http.server` (`src/http/server.zig`): An HTTP server.",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index document lacks %q:\n%s", want, index)
+		}
+	}
+	for _, skipped := range []string{"internal.zig", "build.zig", "tests/"} {
+		if strings.Contains(index, skipped) {
+			t.Errorf("index lists %s:\n%s", skipped, index)
+		}
+	}
+
+	server := readDoc("zig/zap/src/http/server.zig")
+	for _, want := range []string{
+		"# zap.http.server\n\nSource: `src/http/server.zig`\n\nAn HTTP server.\n\nServes requests on a single thread.",
+		"## Types\n\n### Error\n\n```zig\npub const Error = error{AddressInUse,OutOfMemory}\n```",
+		"**Fields**\n\n- `address: []const u8`: The address it listens on.\n- `port: u16 = default_port`\n",
+		"#### Server.init\n\n```zig\npub fn init(allocator: Allocator, options: Options) Error!Self\n```\n\nCreates a server.\n\nCall deinit to free it.",
+		"**Values**\n\n- `get`\n- `post = 2`: Posts a body.",
+		"## Functions\n\n### Queue",
+		"## Constants\n\n### http",
+		"## Variables\n\n### requests",
+	} {
+		if !strings.Contains(server, want) {
+			t.Errorf("server.zig document lacks %q:\n%s", want, server)
+		}
+	}
+
+	for symbol, want := range map[string]string{
+		"zap":                         `@import("zap")`,
+		"zap.http.server":             `@import("src/http/server.zig")`,
+		"zap.http.server.Server.init": "pub fn init(allocator: Allocator, options: Options) Error!Self",
+		"zap.http.server.Queue.push":  "pub fn push(self: *@This(), item: T) void",
+		"zap.http.server.Method.post": "post = 2",
+	} {
+		sym, err := store.GetSymbolContext(ctx, symbol)
+		if err != nil || sym.Signature != want {
+			t.Errorf("GetSymbolContext(%s) = %+v, %v", symbol, sym, err)
+		}
+	}
+	results, err := store.Search(ctx, "zig/zap/accept", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(results, func(r db.SearchResult) bool {
+		return r.Name == "zap.http.server.Server.accept" && r.Type == "Function"
+	}) {
+		t.Errorf("search for zig/zap/accept = %+v", results)
+	}
+
+	// A new ingest replaces the documents of files that are gone.
+	if err := os.Remove(filepath.Join(root, "src", "http", "server.zig")); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestSource(ctx, Options{Source: root, Name: "zap", DB: store}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.ReadDocument(ctx, "zig/zap/src/http/server.zig"); err == nil {
+		t.Error("document of a removed file kept")
+	}
+
+	if err := IngestSource(ctx, Options{Source: root, Subdir: "src/http", DB: store}); err == nil || !strings.Contains(err.Error(), "no .zig files found") {
+		t.Errorf("IngestSource(src/http) = %v", err)
+	}
+}
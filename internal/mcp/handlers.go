@@ -2,10 +2,12 @@ package mcp
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stormlightlabs/documango/internal/codec"
 	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/atproto"
 )
 
 type Handlers struct {
@@ -49,3 +51,63 @@ func (h *Handlers) GetSymbolHandler(ctx context.Context, req *mcp.CallToolReques
 	}
 	return nil, NewSymbolOutput(entry), nil
 }
+
+func (h *Handlers) lexiconTarget(ctx context.Context, ref, part string) (atproto.Catalog, atproto.Target, error) {
+	nsid, _ := atproto.SplitRef("", ref)
+	catalog, err := atproto.LoadCatalog(ctx, h.store, nsid)
+	if err != nil {
+		return nil, atproto.Target{}, err
+	}
+	target, err := catalog.Target(ref, part)
+	return catalog, target, err
+}
+
+func (h *Handlers) ValidateLexiconHandler(ctx context.Context, req *mcp.CallToolRequest, input ValidateLexiconInput) (*mcp.CallToolResult, any, error) {
+	catalog, target, err := h.lexiconTarget(ctx, input.NSID, input.Part)
+	if err != nil {
+		return nil, nil, err
+	}
+	errs, err := catalog.Validate(target, []byte(input.Document))
+	if err != nil {
+		return nil, nil, err
+	}
+	if errs == nil {
+		errs = []atproto.ValidationError{}
+	}
+	return nil, ValidateLexiconOutput{Valid: len(errs) == 0, Errors: errs}, nil
+}
+
+func (h *Handlers) LexiconExampleHandler(ctx context.Context, req *mcp.CallToolRequest, input LexiconExampleInput) (*mcp.CallToolResult, any, error) {
+	catalog, target, err := h.lexiconTarget(ctx, input.NSID, input.Part)
+	if err != nil {
+		return nil, nil, err
+	}
+	example, err := catalog.Example(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, LexiconExampleOutput{Example: example}, nil
+}
+
+func (h *Handlers) LexiconCodegenHandler(ctx context.Context, req *mcp.CallToolRequest, input LexiconCodegenInput) (*mcp.CallToolResult, any, error) {
+	nsids, err := atproto.StoredLexicons(ctx, h.store, input.NSID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nsids) == 0 {
+		return nil, nil, fmt.Errorf("no lexicons match %s", input.NSID)
+	}
+	catalog, err := atproto.LoadCatalog(ctx, h.store, nsids...)
+	if err != nil {
+		return nil, nil, err
+	}
+	lang := input.Lang
+	if lang == "" {
+		lang = atproto.LangGo
+	}
+	code, err := catalog.Codegen(nsids, atproto.CodegenOptions{Lang: lang, Package: input.Package})
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, LexiconCodegenOutput{Lexicons: nsids, Code: code}, nil
+}
@@ -1,6 +1,9 @@
 package mcp
 
-import "github.com/stormlightlabs/documango/internal/db"
+import (
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/ingest/atproto"
+)
 
 // SearchDocsInput defines the input schema for the search_docs tool.
 type SearchDocsInput struct {
@@ -40,3 +43,40 @@ type GetSymbolOutput struct {
 func NewSymbolOutput(entry db.AgentContext) GetSymbolOutput {
 	return GetSymbolOutput{Symbol: entry.Symbol, Signature: entry.Signature, Summary: entry.Summary}
 }
+
+// ValidateLexiconInput defines the input schema for the validate_lexicon tool.
+type ValidateLexiconInput struct {
+	NSID     string `json:"nsid" jsonschema:"Lexicon NSID or nsid#definition (e.g., 'app.bsky.feed.post')"`
+	Document string `json:"document" jsonschema:"JSON record or XRPC payload to validate"`
+	Part     string `json:"part,omitempty" jsonschema:"Schema to validate against: record, params, input, output or message (default depends on the lexicon type)"`
+}
+
+// ValidateLexiconOutput defines the output schema for the validate_lexicon tool.
+type ValidateLexiconOutput struct {
+	Valid  bool                      `json:"valid"`
+	Errors []atproto.ValidationError `json:"errors"`
+}
+
+// LexiconExampleInput defines the input schema for the lexicon_example tool.
+type LexiconExampleInput struct {
+	NSID string `json:"nsid" jsonschema:"Lexicon NSID or nsid#definition (e.g., 'app.bsky.feed.post')"`
+	Part string `json:"part,omitempty" jsonschema:"Schema to generate: record, params, input, output or message (default depends on the lexicon type)"`
+}
+
+// LexiconExampleOutput defines the output schema for the lexicon_example tool.
+type LexiconExampleOutput struct {
+	Example any `json:"example"`
+}
+
+// LexiconCodegenInput defines the input schema for the lexicon_codegen tool.
+type LexiconCodegenInput struct {
+	NSID    string `json:"nsid" jsonschema:"Lexicon NSID or namespace prefix (e.g., 'app.bsky.feed.post' or 'app.bsky.feed')"`
+	Lang    string `json:"lang,omitempty" jsonschema:"Output language: go or ts (default go)"`
+	Package string `json:"package,omitempty" jsonschema:"Package name of generated Go code (default lexicon)"`
+}
+
+// LexiconCodegenOutput defines the output schema for the lexicon_codegen tool.
+type LexiconCodegenOutput struct {
+	Lexicons []string `json:"lexicons"`
+	Code     string   `json:"code"`
+}
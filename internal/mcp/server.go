@@ -42,6 +42,24 @@ func NewServer(store *db.Store, version string) *mcp.Server {
 			return handlers.GetSymbolHandler(ctx, req, input)
 		})
 
+	mcp.AddTool(server, newTool("validate_lexicon", "Validate a JSON record or XRPC payload against an AT Protocol lexicon"),
+		func(ctx context.Context, req *mcp.CallToolRequest, input ValidateLexiconInput) (*mcp.CallToolResult, any, error) {
+			logger.Info("Tool call: validate_lexicon", "nsid", input.NSID, "part", input.Part)
+			return handlers.ValidateLexiconHandler(ctx, req, input)
+		})
+
+	mcp.AddTool(server, newTool("lexicon_example", "Generate a minimal valid JSON document for an AT Protocol lexicon"),
+		func(ctx context.Context, req *mcp.CallToolRequest, input LexiconExampleInput) (*mcp.CallToolResult, any, error) {
+			logger.Info("Tool call: lexicon_example", "nsid", input.NSID, "part", input.Part)
+			return handlers.LexiconExampleHandler(ctx, req, input)
+		})
+
+	mcp.AddTool(server, newTool("lexicon_codegen", "Generate Go structs or TypeScript interfaces for AT Protocol lexicons"),
+		func(ctx context.Context, req *mcp.CallToolRequest, input LexiconCodegenInput) (*mcp.CallToolResult, any, error) {
+			logger.Info("Tool call: lexicon_codegen", "nsid", input.NSID, "lang", input.Lang)
+			return handlers.LexiconCodegenHandler(ctx, req, input)
+		})
+
 	return server
 }
 
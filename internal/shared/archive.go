@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractFile writes an archive member to its slash-separated name below
+// dest, creating its directories. Names that would land outside dest, as
+// with "../" segments, are rejected.
+func ExtractFile(dest, name string, r io.Reader) error {
+	p := filepath.Join(dest, filepath.FromSlash(name))
+	if !strings.HasPrefix(p, dest+string(os.PathSeparator)) {
+		return fmt.Errorf("invalid archive path: %s", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,23 @@
+package shared
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractFile(t *testing.T) {
+	dest := t.TempDir()
+	if err := ExtractFile(dest, "pkg/mod.py", strings.NewReader("x = 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dest, "pkg", "mod.py")); err != nil || string(got) != "x = 1\n" {
+		t.Errorf("extracted file = %q, %v", got, err)
+	}
+	for _, name := range []string{"../escape.py", "pkg/../../escape.py", ""} {
+		if err := ExtractFile(dest, name, strings.NewReader("")); err == nil {
+			t.Errorf("ExtractFile(%q) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package shared
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Section is a heading of a Markdown document and the text up to the next
+// heading of any level.
+type Section struct {
+	Level   int
+	Heading string
+	Body    string
+}
+
+var (
+	atxHeading   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdLink       = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	mdEmphasis   = regexp.MustCompile(`(\*\*|\*|~~)`)
+	fenceOpening = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+)
+
+// MarkdownSections splits a Markdown document at its ATX headings, ignoring
+// lines inside fenced code blocks. Text before the first heading is returned
+// as a section with level 0 and no heading. Headings are reduced to plain
+// text: links keep their label and code spans and emphasis lose their
+// markers.
+func MarkdownSections(md string) []Section {
+	var sections []Section
+	current := Section{}
+	var body []string
+	flush := func() {
+		current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+		if current.Level > 0 || current.Body != "" {
+			sections = append(sections, current)
+		}
+		body = nil
+	}
+
+	fence := ""
+	for _, line := range strings.Split(NormalizeLineEndings(md), "\n") {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			body = append(body, line)
+			continue
+		}
+		if m := fenceOpening.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			body = append(body, line)
+			continue
+		}
+		if m := atxHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			current = Section{Level: len(m[1]), Heading: PlainHeading(m[2])}
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
+
+// PlainHeading strips inline Markdown from heading text.
+func PlainHeading(s string) string {
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = strings.ReplaceAll(s, "`", "")
+	s = mdEmphasis.ReplaceAllString(s, "")
+	return strings.Join(strings.Fields(s), " ")
+}
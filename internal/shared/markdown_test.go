@@ -0,0 +1,19 @@
+package shared
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkdownSections(t *testing.T) {
+	md := "Intro text.\n\n# Title #\n\nAbout.\n\n~~~md\n## fenced\n~~~\n\n## Install [`pkg`](https://example.com) **now**\n\nRun it.\n#hashtag\n### \n"
+	want := []Section{
+		{Level: 0, Body: "Intro text."},
+		{Level: 1, Heading: "Title", Body: "About.\n\n~~~md\n## fenced\n~~~"},
+		{Level: 2, Heading: "Install pkg now", Body: "Run it.\n#hashtag"},
+		{Level: 3},
+	}
+	if got := MarkdownSections(md); !reflect.DeepEqual(got, want) {
+		t.Errorf("MarkdownSections() =\n%#v\nwant\n%#v", got, want)
+	}
+}
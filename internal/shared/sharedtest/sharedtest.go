@@ -0,0 +1,38 @@
+// Package sharedtest builds fixtures for ingestor tests.
+package sharedtest
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"maps"
+	"os"
+	"slices"
+	"testing"
+)
+
+// WriteTarGz writes a gzipped tarball of regular files, keyed by their name
+// in the archive, to path.
+func WriteTarGz(t testing.TB, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/glamour/ansi"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/stormlightlabs/documango/internal/codec"
 	"github.com/stormlightlabs/documango/internal/db"
 	"github.com/stormlightlabs/documango/internal/shared"
 )
@@ -90,13 +91,18 @@ func (m DocModel) loadDocument(docID int64) tea.Cmd {
 			return docLoadedMsg{err: err}
 		}
 
+		body := doc.Body
+		if decompressed, err := codec.Decompress(body); err == nil {
+			body = decompressed
+		}
+
 		path := doc.Path
-		content, err := m.renderMarkdown(string(doc.Body))
+		content, err := m.renderMarkdown(string(body))
 		if err != nil {
 			return docLoadedMsg{err: err}
 		}
 
-		links := m.extractLinks(string(doc.Body))
+		links := m.extractLinks(string(body))
 		return docLoadedMsg{content: content, path: path, links: links}
 	}
 }
@@ -202,6 +208,13 @@ func (m DocModel) extractLinks(content string) []Link {
 	return links
 }
 
+// linkDocPath turns a link target into a document path. Links between
+// documents use the web server's /doc/ prefix and may carry an anchor.
+func linkDocPath(target string) string {
+	target, _, _ = strings.Cut(target, "#")
+	return strings.TrimPrefix(target, "/doc/")
+}
+
 // Update handles messages.
 func (m DocModel) Update(msg tea.Msg) (DocModel, tea.Cmd) {
 	var cmd tea.Cmd
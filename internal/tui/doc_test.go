@@ -552,3 +552,16 @@ func TestDocModel_LinkKey_Zero(t *testing.T) {
 
 	_ = newModel
 }
+
+// TestLinkDocPath tests resolving link targets to document paths
+func TestLinkDocPath(t *testing.T) {
+	tests := map[string]string{
+		"go/net/http": "go/net/http",
+		"/doc/atproto/lexicon/app.bsky.feed.defs#definition-appbskyfeeddefspostview": "atproto/lexicon/app.bsky.feed.defs",
+	}
+	for target, want := range tests {
+		if got := linkDocPath(target); got != want {
+			t.Errorf("linkDocPath(%q) = %q, want %q", target, got, want)
+		}
+	}
+}
@@ -12,6 +12,7 @@ type keyBindings struct {
 	Scroll   key.Binding
 	Help     key.Binding
 	Link     key.Binding
+	TOC      key.Binding
 }
 
 // newKeyBindings creates a new key binding set.
@@ -49,6 +50,10 @@ func newKeyBindings() keyBindings {
 			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
 			key.WithHelp("1-9", "link"),
 		),
+		TOC: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "contents"),
+		),
 	}
 }
 
@@ -68,6 +73,6 @@ func (k keyBindings) ShortHelp() []key.Binding {
 func (k keyBindings) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Search, k.Navigate, k.Open, k.Back},
-		{k.Scroll, k.Link, k.Help, k.Quit},
+		{k.Scroll, k.Link, k.TOC, k.Help, k.Quit},
 	}
 }
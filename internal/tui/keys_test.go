@@ -184,6 +184,14 @@ func TestKeyBindings_Link(t *testing.T) {
 	}
 }
 
+// TestKeyBindings_TOC verifies the table of contents binding
+func TestKeyBindings_TOC(t *testing.T) {
+	kb := newKeyBindings()
+	if keys := kb.TOC.Keys(); !slices.Equal(keys, []string{"t"}) {
+		t.Errorf("expected TOC keys [t], got %v", keys)
+	}
+}
+
 // TestKeyBindings_ShortHelp verifies short help returns correct bindings
 func TestKeyBindings_ShortHelp(t *testing.T) {
 	kb := newKeyBindings()
@@ -220,8 +228,8 @@ func TestKeyBindings_FullHelp(t *testing.T) {
 		t.Errorf("expected 4 bindings in first row, got %d", len(bindings[0]))
 	}
 
-	if len(bindings[1]) != 4 {
-		t.Errorf("expected 4 bindings in second row, got %d", len(bindings[1]))
+	if len(bindings[1]) != 5 {
+		t.Errorf("expected 5 bindings in second row, got %d", len(bindings[1]))
 	}
 }
 
@@ -17,6 +17,7 @@ const (
 	modeSearch appMode = iota
 	modeList
 	modeDoc
+	modeTOC
 )
 
 // RootModel is the top-level application model that orchestrates all components.
@@ -28,6 +29,7 @@ type RootModel struct {
 	search   SearchModel
 	list     ListModel
 	doc      DocModel
+	toc      TOCModel
 	tabs     TabBar
 	help     help.Model
 	keys     keyBindings
@@ -43,6 +45,7 @@ func NewRootModel(store *db.Store) RootModel {
 		search: NewSearchModel(store),
 		list:   NewListModel(),
 		doc:    NewDocModel(store),
+		toc:    NewTOCModel(),
 		tabs:   NewTabBar(),
 		help:   h,
 		keys:   newKeyBindings(),
@@ -70,6 +73,10 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "?":
 			m.showHelp = !m.showHelp
 			return m, nil
+		case "t":
+			if m.mode == modeDoc && m.doc.Path() != "" {
+				return m, loadTOC(m.store, m.doc.Path())
+			}
 		case "ctrl+tab":
 			if m.tabs.HasTabs() {
 				m.tabs.NextTab()
@@ -95,6 +102,7 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.WindowSizeMsg:
 		m.list.SetSize(msg.Width, msg.Height-4)
+		m.toc.SetSize(msg.Width, msg.Height-6)
 		_, _ = m.doc.Update(msg)
 		m.tabs.SetWidth(msg.Width)
 
@@ -135,6 +143,23 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.doc, cmd = m.doc.Update(msg)
 		return m, cmd
 
+	case tocLoadedMsg:
+		if msg.err == nil && len(msg.entries) > 0 {
+			m.toc.SetEntries(msg.site, msg.entries, msg.current)
+			m.mode = modeTOC
+		}
+		return m, nil
+
+	case tocSelectMsg:
+		m.mode = modeDoc
+		m.tabs.AddTab(msg.entry.Title, msg.entry.DocID, "")
+		m.doc = NewDocModel(m.store)
+		return m, m.doc.LoadDocument(msg.entry.DocID)
+
+	case backToDocMsg:
+		m.mode = modeDoc
+		return m, nil
+
 	case backToListMsg:
 		m.mode = modeList
 		m.list = m.list.Focus()
@@ -143,7 +168,7 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case docLinkMsg:
 		if !m.tabs.TabLimitReached() {
 			ctx := context.Background()
-			doc, err := m.store.ReadDocument(ctx, msg.target)
+			doc, err := m.store.ReadDocument(ctx, linkDocPath(msg.target))
 			if err == nil {
 				results, err := m.store.Search(ctx, doc.Path, 1)
 				if err == nil && len(results) > 0 {
@@ -170,6 +195,8 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list, cmd = m.list.Update(msg)
 	case modeDoc:
 		m.doc, cmd = m.doc.Update(msg)
+	case modeTOC:
+		m.toc, cmd = m.toc.Update(msg)
 	}
 
 	return m, cmd
@@ -205,6 +232,13 @@ func (m RootModel) View() string {
 		helpText := m.help.View(m.keys)
 		parts = append(parts, "", helpText)
 		return lipgloss.JoinVertical(lipgloss.Left, parts...)
+	case modeTOC:
+		var parts []string
+		if m.tabs.HasTabs() {
+			parts = append(parts, m.tabs.Render())
+		}
+		parts = append(parts, m.toc.View(), "", m.help.View(m.keys))
+		return lipgloss.JoinVertical(lipgloss.Left, parts...)
 	default:
 		return ""
 	}
@@ -0,0 +1,197 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stormlightlabs/documango/internal/db"
+)
+
+// tocLoadedMsg is sent when the table of contents of the current
+// document's site is fetched. site is empty when it belongs to no site.
+type tocLoadedMsg struct {
+	site    db.Site
+	entries []TOCEntry
+	current string
+	err     error
+}
+
+// tocSelectMsg is sent when the user opens a page from the table of
+// contents.
+type tocSelectMsg struct {
+	entry TOCEntry
+}
+
+// backToDocMsg is sent when the user leaves the table of contents.
+type backToDocMsg struct{}
+
+// TOCEntry is a page or section of a documentation site's table of
+// contents. DocID is 0 for sections without a page.
+type TOCEntry struct {
+	db.NavEntry
+	DocID int64
+}
+
+// FilterValue implements list.Item.
+func (e TOCEntry) FilterValue() string {
+	return e.Title
+}
+
+// TOCDelegate renders table of contents entries indented by depth,
+// highlighting the page at current.
+type TOCDelegate struct {
+	current string
+}
+
+// Height implements list.ItemDelegate.
+func (d TOCDelegate) Height() int {
+	return 1
+}
+
+// Spacing implements list.ItemDelegate.
+func (d TOCDelegate) Spacing() int {
+	return 0
+}
+
+// Update implements list.ItemDelegate.
+func (d TOCDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+// Render implements list.ItemDelegate.
+func (d TOCDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	entry, ok := item.(TOCEntry)
+	if !ok {
+		return
+	}
+
+	indent := strings.Repeat("  ", entry.Depth)
+	var style lipgloss.Style
+	switch {
+	case index == m.Index():
+		style = selectedNameStyle
+	case entry.DocID == 0:
+		style = typeStyle
+	case entry.Path == d.current:
+		style = accentStyle
+	default:
+		style = nameStyle
+	}
+	fmt.Fprint(w, indent+style.Render(entry.Title))
+}
+
+// TOCModel shows the table of contents of the site the open document
+// belongs to.
+type TOCModel struct {
+	list list.Model
+	site db.Site
+}
+
+// NewTOCModel creates an empty table of contents.
+func NewTOCModel() TOCModel {
+	l := list.New(nil, TOCDelegate{}, 0, 0)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.SetShowTitle(false)
+	l.SetShowPagination(true)
+	l.SetShowFilter(false)
+	l.DisableQuitKeybindings()
+	return TOCModel{list: l}
+}
+
+// loadTOC fetches the table of contents of the site the document at path
+// belongs to.
+func loadTOC(store *db.Store, path string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		site, ok, err := store.SiteOf(ctx, path)
+		if err != nil || !ok {
+			return tocLoadedMsg{err: err}
+		}
+		nav, err := store.SiteNav(ctx, site.Root)
+		if err != nil {
+			return tocLoadedMsg{err: err}
+		}
+
+		entries := make([]TOCEntry, len(nav))
+		for i, entry := range nav {
+			entries[i] = TOCEntry{NavEntry: entry}
+			if entry.Path != "" {
+				entries[i].DocID, _ = store.DocumentID(ctx, entry.Path)
+			}
+		}
+		return tocLoadedMsg{site: site, entries: entries, current: path}
+	}
+}
+
+// SetEntries shows a site's table of contents and selects the current page.
+func (m *TOCModel) SetEntries(site db.Site, entries []TOCEntry, current string) {
+	m.site = site
+	m.list.SetDelegate(TOCDelegate{current: current})
+	items := make([]list.Item, len(entries))
+	selected := 0
+	for i, entry := range entries {
+		items[i] = entry
+		if entry.Path == current {
+			selected = i
+		}
+	}
+	m.list.SetItems(items)
+	m.list.Select(selected)
+}
+
+// Update handles messages.
+func (m TOCModel) Update(msg tea.Msg) (TOCModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			if entry, ok := m.list.SelectedItem().(TOCEntry); ok && entry.DocID != 0 {
+				return m, func() tea.Msg {
+					return tocSelectMsg{entry: entry}
+				}
+			}
+			return m, nil
+		case "esc", "t":
+			return m, func() tea.Msg {
+				return backToDocMsg{}
+			}
+		case "j", "down":
+			m.list.CursorDown()
+			return m, nil
+		case "k", "up":
+			m.list.CursorUp()
+			return m, nil
+		case "g":
+			m.list.Select(0)
+			return m, nil
+		case "G":
+			m.list.Select(len(m.list.Items()) - 1)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the table of contents.
+func (m TOCModel) View() string {
+	if len(m.list.Items()) == 0 {
+		return emptyStateStyle.Render("This document is not part of a documentation site.")
+	}
+	header := docTitleStyle.Render(m.site.Title) + docBackStyle.Render(" Esc: back")
+	return lipgloss.JoinVertical(lipgloss.Left, header, "", m.list.View())
+}
+
+// SetSize sets the width and height of the list.
+func (m *TOCModel) SetSize(w, h int) {
+	m.list.SetWidth(w)
+	m.list.SetHeight(h)
+}
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+)
+
+func testTOCEntries() []TOCEntry {
+	return []TOCEntry{
+		{NavEntry: db.NavEntry{Title: "Introduction", Path: "git/local/book/src/intro.md"}, DocID: 1},
+		{NavEntry: db.NavEntry{Title: "User guide"}},
+		{NavEntry: db.NavEntry{Title: "Installation", Path: "git/local/book/src/install.md", Depth: 1}, DocID: 2},
+	}
+}
+
+// TestTOCModel_View_Empty tests the view without a site
+func TestTOCModel_View_Empty(t *testing.T) {
+	model := NewTOCModel()
+	if view := model.View(); !strings.Contains(view, "not part of a documentation site") {
+		t.Errorf("expected empty state, got %q", view)
+	}
+}
+
+// TestTOCModel_SetEntries tests that the current page is selected
+func TestTOCModel_SetEntries(t *testing.T) {
+	model := NewTOCModel()
+	model.SetSize(80, 20)
+	model.SetEntries(db.Site{Title: "The Book"}, testTOCEntries(), "git/local/book/src/install.md")
+
+	if model.list.Index() != 2 {
+		t.Errorf("expected the current page to be selected, got index %d", model.list.Index())
+	}
+	view := model.View()
+	for _, want := range []string{"The Book", "Introduction", "User guide", "  Installation"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+}
+
+// TestTOCModel_Update_Select tests opening pages and skipping sections
+func TestTOCModel_Update_Select(t *testing.T) {
+	model := NewTOCModel()
+	model.SetEntries(db.Site{Title: "The Book"}, testTOCEntries(), "git/local/book/src/intro.md")
+
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected command from enter on a page")
+	}
+	if msg, ok := cmd().(tocSelectMsg); !ok || msg.entry.DocID != 1 {
+		t.Errorf("expected tocSelectMsg for doc 1, got %#v", cmd())
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if _, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil {
+		t.Error("expected no command from enter on a section")
+	}
+
+	_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("expected command from esc")
+	}
+	if _, ok := cmd().(backToDocMsg); !ok {
+		t.Error("expected backToDocMsg from esc")
+	}
+}
+
+// TestLoadTOC tests loading the table of contents of a document's site
+func TestLoadTOC(t *testing.T) {
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	var docID int64
+	if err := store.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		docID, err = db.InsertDocumentTx(ctx, tx, db.Document{Path: "local/book/intro.md", Format: "markdown", Body: []byte("# Intro"), Hash: "h"})
+		if err != nil {
+			return err
+		}
+		return db.ReplaceSiteTx(ctx, tx, db.Site{Root: "local/book", Generator: "mdbook", Title: "The Book"}, []db.NavEntry{
+			{Title: "Intro", Path: "local/book/intro.md"},
+			{Title: "Appendix"},
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, ok := loadTOC(store, "local/book/intro.md")().(tocLoadedMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("expected tocLoadedMsg, got %#v", msg)
+	}
+	if msg.site.Title != "The Book" || len(msg.entries) != 2 || msg.entries[0].DocID != docID || msg.entries[1].DocID != 0 {
+		t.Errorf("unexpected table of contents: %+v", msg)
+	}
+
+	if msg := loadTOC(store, "local/other.md")().(tocLoadedMsg); len(msg.entries) != 0 || msg.err != nil {
+		t.Errorf("expected no entries for a document outside a site, got %+v", msg)
+	}
+}
+
+// TestRootModel_TOC tests switching between the document and its table of contents
+func TestRootModel_TOC(t *testing.T) {
+	model := NewRootModel(&db.Store{})
+	model.mode = modeDoc
+
+	updated, _ := model.Update(tocLoadedMsg{site: db.Site{Title: "The Book"}, entries: testTOCEntries(), current: "git/local/book/src/intro.md"})
+	model = updated.(RootModel)
+	if model.mode != modeTOC {
+		t.Fatalf("expected TOC mode, got %v", model.mode)
+	}
+	if view := model.View(); !strings.Contains(view, "The Book") {
+		t.Error("expected view to show the table of contents")
+	}
+
+	updated, _ = model.Update(backToDocMsg{})
+	model = updated.(RootModel)
+	if model.mode != modeDoc {
+		t.Errorf("expected doc mode after leaving the table of contents, got %v", model.mode)
+	}
+
+	updated, cmd := model.Update(tocSelectMsg{entry: testTOCEntries()[2]})
+	model = updated.(RootModel)
+	if model.mode != modeDoc || cmd == nil {
+		t.Error("expected selecting a page to load it in doc mode")
+	}
+	if tab, ok := model.tabs.ActiveTab(); !ok || tab.DocID != 2 || tab.Title != "Installation" {
+		t.Errorf("expected a tab for the selected page, got %+v", tab)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/stormlightlabs/documango/internal/codec"
 	"github.com/stormlightlabs/documango/internal/db"
 )
 
@@ -15,9 +16,19 @@ type PackageGroup struct {
 	Packages []db.PackageInfo
 }
 
+// SiteLink is a documentation site on the index page, linking to its first
+// page.
+type SiteLink struct {
+	db.Site
+	URL   string
+	Pages int
+}
+
 // IndexPageData holds data for the index template.
 type IndexPageData struct {
-	Groups []PackageGroup
+	ActiveNav string
+	Sites     []SiteLink
+	Groups    []PackageGroup
 }
 
 // handleIndex displays the landing page with package overview.
@@ -43,19 +54,70 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	data := IndexPageData{Groups: groups}
+	sites, err := s.siteLinks(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load sites", http.StatusInternalServerError)
+		return
+	}
+
+	data := IndexPageData{ActiveNav: "index", Sites: sites, Groups: groups}
 	if err := s.renderTemplate(w, "index.html", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// siteLinks lists the documentation sites with a link to their first page.
+func (s *Server) siteLinks(ctx context.Context) ([]SiteLink, error) {
+	sites, err := s.store.Sites(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]SiteLink, 0, len(sites))
+	for _, site := range sites {
+		nav, err := s.store.SiteNav(ctx, site.Root)
+		if err != nil {
+			return nil, err
+		}
+		link := SiteLink{Site: site}
+		for _, entry := range nav {
+			if entry.Path == "" {
+				continue
+			}
+			if link.URL == "" {
+				link.URL = "/doc/" + entry.Path
+			}
+			link.Pages++
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
 // DocPageData holds the data for the document template.
 type DocPageData struct {
+	ActiveNav   string
 	Title       string
 	Path        string
 	Breadcrumbs []BreadcrumbItem
 	Content     string
 	TOC         []TOCItem
+	Site        *SiteNavData
+}
+
+// SiteNavData is the table of contents of the site a document belongs to.
+type SiteNavData struct {
+	Title   string
+	Entries []SiteNavItem
+}
+
+// SiteNavItem is an entry of a site's table of contents. URL is empty for
+// sections without a page.
+type SiteNavItem struct {
+	Title   string
+	URL     string
+	Depth   int
+	Current bool
 }
 
 // BreadcrumbItem represents a single breadcrumb entry.
@@ -87,12 +149,19 @@ func (s *Server) handleDoc(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	site, err := s.siteNav(ctx, doc.Path)
+	if err != nil {
+		http.Error(w, "Failed to load site navigation", http.StatusInternalServerError)
+		return
+	}
+
 	data := DocPageData{
 		Title:       extractTitle(doc, docPath),
 		Path:        docPath,
 		Breadcrumbs: buildBreadcrumbs(docPath),
 		Content:     htmlContent,
 		TOC:         toc,
+		Site:        site,
 	}
 
 	if err := s.renderTemplate(w, "doc.html", data); err != nil {
@@ -100,20 +169,48 @@ func (s *Server) handleDoc(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// fetchDocument retrieves a document from the store by path.
+// siteNav returns the table of contents of the site the document at docPath
+// belongs to, or nil when it belongs to none.
+func (s *Server) siteNav(ctx context.Context, docPath string) (*SiteNavData, error) {
+	site, ok, err := s.store.SiteOf(ctx, docPath)
+	if err != nil || !ok {
+		return nil, err
+	}
+	nav, err := s.store.SiteNav(ctx, site.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SiteNavData{Title: site.Title}
+	for _, entry := range nav {
+		item := SiteNavItem{Title: entry.Title, Depth: min(entry.Depth, 3), Current: entry.Path == docPath}
+		if entry.Path != "" {
+			item.URL = "/doc/" + entry.Path
+		}
+		data.Entries = append(data.Entries, item)
+	}
+	return data, nil
+}
+
+// fetchDocument retrieves a document from the store by path, with its body
+// decompressed.
 func (s *Server) fetchDocument(ctx context.Context, docPath string) (db.Document, error) {
 	doc, err := s.store.ReadDocument(ctx, docPath)
 	if err != nil {
 		return db.Document{}, err
 	}
+	if body, err := codec.Decompress(doc.Body); err == nil {
+		doc.Body = body
+	}
 	return doc, nil
 }
 
 // ErrorPageData holds data for error templates.
 type ErrorPageData struct {
-	Code    int
-	Title   string
-	Message string
+	ActiveNav string
+	Code      int
+	Title     string
+	Message   string
 }
 
 func newErrorPageData(c int, t, m string) ErrorPageData {
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stormlightlabs/documango/internal/db"
+	"github.com/stormlightlabs/documango/internal/dbtest"
+	"github.com/stormlightlabs/documango/internal/shared"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	ctx := context.Background()
+	store := dbtest.Open(t)
+
+	err := store.WithTx(ctx, func(tx *sql.Tx) error {
+		for path, body := range map[string]string{
+			"local/book/src/intro.md":         "# Introduction\n\nWelcome to the book.",
+			"local/book/src/guide/install.md": "# Installing\n\nRun `make install`.",
+		} {
+			if _, err := db.InsertDocumentTx(ctx, tx, db.Document{Path: path, Format: "markdown", Body: shared.Compress(body), Hash: path}); err != nil {
+				return err
+			}
+		}
+		return db.ReplaceSiteTx(ctx, tx, db.Site{Root: "local/book", Generator: "mdbook", Title: "The Book"}, []db.NavEntry{
+			{Title: "Intro", Path: "local/book/src/intro.md"},
+			{Title: "Guide"},
+			{Title: "Install", Path: "local/book/src/guide/install.md", Depth: 1},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(store, "")
+}
+
+func get(t *testing.T, s *Server, url string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestHandleIndex(t *testing.T) {
+	s := newTestServer(t)
+	code, body := get(t, s, "/")
+	if code != http.StatusOK {
+		t.Fatalf("GET / = %d\n%s", code, body)
+	}
+	for _, want := range []string{"Guides", `href="/doc/local/book/src/intro.md"`, "The Book", "mdbook · 2 pages", `class="nav-link active">Index`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index page does not contain %q", want)
+		}
+	}
+}
+
+func TestHandleDoc(t *testing.T) {
+	s := newTestServer(t)
+	code, body := get(t, s, "/doc/local/book/src/guide/install.md")
+	if code != http.StatusOK {
+		t.Fatalf("GET /doc = %d\n%s", code, body)
+	}
+	for _, want := range []string{
+		`<h1 id="installing">`,
+		`<h2 class="toc-title">The Book</h2>`,
+		`<span class="site-nav-section">Guide</span>`,
+		`<a href="/doc/local/book/src/guide/install.md" aria-current="page">Install</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("document page does not contain %q", want)
+		}
+	}
+
+	if code, _ := get(t, s, "/doc/local/missing.md"); code != http.StatusNotFound {
+		t.Errorf("GET missing document = %d, want 404", code)
+	}
+}
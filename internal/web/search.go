@@ -38,10 +38,11 @@ func NewSearchErrorResponse(e string, c string) SearchErrorResponse {
 
 // SearchPageData holds data for the search template.
 type SearchPageData struct {
-	Query   string
-	Results []SearchResultItem
-	Total   int
-	Package string
+	ActiveNav string
+	Query     string
+	Results   []SearchResultItem
+	Total     int
+	Package   string
 }
 
 // handleAPISearch provides a JSON search API endpoint.
@@ -86,8 +87,9 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	pkg := r.URL.Query().Get("pkg")
 
 	data := SearchPageData{
-		Query:   query,
-		Package: pkg,
+		ActiveNav: "search",
+		Query:     query,
+		Package:   pkg,
 	}
 
 	if query != "" {
@@ -4,26 +4,45 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
+	"path"
 
 	"github.com/stormlightlabs/documango/internal/assets"
 )
 
-var templates *template.Template
+// templates holds a template set per page, each parsed together with
+// base.html, since every page defines its own "title" and "content" blocks.
+var templates = map[string]*template.Template{}
 
 func init() {
-	var err error
 	funcMap := template.FuncMap{
 		"safeHTML": func(s string) template.HTML {
 			return template.HTML(s)
 		},
 	}
-	templates, err = template.New("").Funcs(funcMap).ParseFS(assets.TemplateFS, "templates/*.html")
+
+	pages, err := fs.Glob(assets.TemplateFS, "templates/*.html")
 	if err != nil {
-		panic(fmt.Sprintf("failed to parse templates: %v", err))
+		panic(fmt.Sprintf("failed to list templates: %v", err))
+	}
+	for _, page := range pages {
+		name := path.Base(page)
+		if name == "base.html" {
+			continue
+		}
+		t, err := template.New(name).Funcs(funcMap).ParseFS(assets.TemplateFS, "templates/base.html", page)
+		if err != nil {
+			panic(fmt.Sprintf("failed to parse templates: %v", err))
+		}
+		templates[name] = t
 	}
 }
 
 // renderTemplate executes the specified template.
 func (s *Server) renderTemplate(w io.Writer, name string, data any) error {
-	return templates.ExecuteTemplate(w, name, data)
+	t, ok := templates[name]
+	if !ok {
+		return fmt.Errorf("template %s not found", name)
+	}
+	return t.ExecuteTemplate(w, name, data)
 }